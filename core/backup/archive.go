@@ -0,0 +1,199 @@
+package backup
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"filippo.io/age"
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxManifestSize bounds the manifest entry read from an archive
+const maxManifestSize = 64 << 20
+
+// writeArchive writes the manifest and the files m stores to the
+// encrypted archive of m
+func (r *Repository) writeArchive(m *Manifest, root string, recipients []age.Recipient) (err error) {
+	tmp, err := os.CreateTemp(r.dir, "."+m.ID+".*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+	if err := tmp.Chmod(0600); err != nil {
+		return err
+	}
+
+	encrypted, err := age.Encrypt(tmp, recipients...)
+	if err != nil {
+		return fmt.Errorf("could not encrypt snapshot: %w", err)
+	}
+	compressed, err := zstd.NewWriter(encrypted)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(compressed)
+
+	manifest, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     manifestEntry,
+		Mode:     0600,
+		Size:     int64(len(manifest)),
+		ModTime:  m.Created,
+	}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifest); err != nil {
+		return err
+	}
+
+	for _, f := range m.Files {
+		if f.Snapshot != "" {
+			continue
+		}
+		if err := addFile(tw, root, f); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := compressed.Close(); err != nil {
+		return err
+	}
+	if err := encrypted.Close(); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), r.ArchivePath(m.ID))
+}
+
+// addFile copies a scanned file into the archive, failing if it changed
+// since it was hashed
+func addFile(tw *tar.Writer, root string, f File) error {
+	src, err := os.Open(filepath.Join(root, filepath.FromSlash(f.Path)))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     filesPrefix + f.Path,
+		Mode:     int64(f.Mode.Perm()),
+		Size:     f.Size,
+		ModTime:  f.ModTime,
+	}); err != nil {
+		return err
+	}
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tw, h), io.LimitReader(src, f.Size))
+	if err != nil {
+		return fmt.Errorf("%s: %w", f.Path, err)
+	}
+	if n != f.Size || hex.EncodeToString(h.Sum(nil)) != f.SHA256 {
+		return fmt.Errorf("%s changed while it was being backed up", f.Path)
+	}
+	return nil
+}
+
+// archiveReader reads the entries of a decrypted archive
+type archiveReader struct {
+	file     *os.File
+	zr       *zstd.Decoder
+	tr       *tar.Reader
+	manifest *Manifest
+}
+
+// openArchive decrypts the archive of snapshot id and reads its manifest
+func (r *Repository) openArchive(id string, identities []age.Identity) (*archiveReader, error) {
+	file, err := os.Open(r.ArchivePath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	ar := &archiveReader{file: file}
+	if err := ar.open(id, identities); err != nil {
+		ar.Close()
+		return nil, fmt.Errorf("snapshot %s: %w", id, err)
+	}
+	return ar, nil
+}
+
+func (ar *archiveReader) open(id string, identities []age.Identity) error {
+	decrypted, err := age.Decrypt(ar.file, identities...)
+	if err != nil {
+		return fmt.Errorf("could not decrypt: %w", err)
+	}
+	ar.zr, err = zstd.NewReader(decrypted)
+	if err != nil {
+		return err
+	}
+	ar.tr = tar.NewReader(ar.zr)
+
+	header, err := ar.tr.Next()
+	if err != nil {
+		return fmt.Errorf("corrupt archive: %w", err)
+	}
+	if header.Name != manifestEntry {
+		return fmt.Errorf("corrupt archive: first entry is %q", header.Name)
+	}
+	if header.Size > maxManifestSize {
+		return fmt.Errorf("corrupt archive: manifest of %d bytes", header.Size)
+	}
+	var m Manifest
+	if err := json.NewDecoder(io.LimitReader(ar.tr, header.Size)).Decode(&m); err != nil {
+		return fmt.Errorf("corrupt manifest: %w", err)
+	}
+	if err := m.validate(id); err != nil {
+		return err
+	}
+	ar.manifest = &m
+	return nil
+}
+
+// next returns the manifest path of the next stored file, or io.EOF
+func (ar *archiveReader) next() (string, *tar.Header, error) {
+	header, err := ar.tr.Next()
+	if err != nil {
+		if !errors.Is(err, io.EOF) {
+			err = fmt.Errorf("corrupt archive: %w", err)
+		}
+		return "", nil, err
+	}
+	if header.Typeflag != tar.TypeReg || !strings.HasPrefix(header.Name, filesPrefix) {
+		return "", nil, fmt.Errorf("corrupt archive: unexpected entry %q", header.Name)
+	}
+	return strings.TrimPrefix(header.Name, filesPrefix), header, nil
+}
+
+func (ar *archiveReader) Close() error {
+	if ar.zr != nil {
+		ar.zr.Close()
+	}
+	return ar.file.Close()
+}
@@ -0,0 +1,443 @@
+// Package backup keeps encrypted, incremental snapshots of a directory
+// tree. A snapshot is a zstd-compressed tar stream encrypted with age,
+// either to X25519 recipients or with a passphrase. Its first entry is a
+// manifest with the size, mode and SHA-256 of every file. Incremental
+// snapshots store only the files that changed since their parent and point
+// to the older snapshots holding the rest.
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+)
+
+// FormatVersion is the manifest version written by this package
+const FormatVersion = 1
+
+// DefaultFullEvery is how many incremental snapshots may follow a full one
+// before the next snapshot is full again
+const DefaultFullEvery = 7
+
+// Files inside the repository directory and entries inside an archive
+const (
+	archiveExt    = ".tar.zst.age"
+	sidecarExt    = ".manifest.json"
+	manifestEntry = "manifest.json"
+	filesPrefix   = "files/"
+)
+
+// ErrNotFound is returned for a snapshot that is not in the repository
+var ErrNotFound = errors.New("snapshot not found")
+
+// File is a regular file recorded in a snapshot
+type File struct {
+	// Path is slash-separated and relative to the backed up root; its
+	// first element is the component
+	Path    string      `json:"path"`
+	Size    int64       `json:"size"`
+	Mode    fs.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+	SHA256  string      `json:"sha256"`
+	// Snapshot is the ID of the older snapshot holding the content, empty
+	// when this snapshot stores it
+	Snapshot string `json:"snapshot,omitempty"`
+}
+
+// Component is the top-level directory the file belongs to
+func (f File) Component() string {
+	component, _, _ := strings.Cut(f.Path, "/")
+	return component
+}
+
+// Manifest describes a snapshot. A copy without any file content is kept
+// next to the archive so incremental snapshots and retention can be
+// planned without decrypting anything.
+type Manifest struct {
+	Version int    `json:"version"`
+	ID      string `json:"id"`
+	// Parent is the snapshot this one is incremental to, empty for a full one
+	Parent     string    `json:"parent,omitempty"`
+	Created    time.Time `json:"created"`
+	Host       string    `json:"host,omitempty"`
+	Components []string  `json:"components"`
+	Files      []File    `json:"files"`
+}
+
+// Full reports whether the snapshot stores all of its files
+func (m *Manifest) Full() bool {
+	return m.Parent == ""
+}
+
+// Stored returns how many files the snapshot stores and their total size
+func (m *Manifest) Stored() (files int, size int64) {
+	for _, f := range m.Files {
+		if f.Snapshot == "" {
+			files++
+			size += f.Size
+		}
+	}
+	return files, size
+}
+
+// Depends lists the older snapshots holding content of this one
+func (m *Manifest) Depends() []string {
+	seen := map[string]bool{}
+	var ids []string
+	for _, f := range m.Files {
+		if f.Snapshot != "" && !seen[f.Snapshot] {
+			seen[f.Snapshot] = true
+			ids = append(ids, f.Snapshot)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// validate checks what a manifest read from an archive claims about itself
+func (m *Manifest) validate(id string) error {
+	if m.Version != FormatVersion {
+		return fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	if m.ID != id {
+		return fmt.Errorf("archive holds snapshot %q, not %q", m.ID, id)
+	}
+	seen := map[string]bool{}
+	for _, f := range m.Files {
+		if !validPath(f.Path) {
+			return fmt.Errorf("invalid path %q in manifest", f.Path)
+		}
+		if seen[f.Path] {
+			return fmt.Errorf("duplicate path %q in manifest", f.Path)
+		}
+		seen[f.Path] = true
+		if f.Snapshot == m.ID {
+			return fmt.Errorf("%s refers to its own snapshot", f.Path)
+		}
+	}
+	return nil
+}
+
+// validPath accepts relative, clean, slash-separated paths that stay
+// inside the restore directory
+func validPath(p string) bool {
+	return p != "" && p == path.Clean(p) && !path.IsAbs(p) &&
+		p != ".." && !strings.HasPrefix(p, "../") && !strings.Contains(p, `\`) &&
+		filepath.IsLocal(filepath.FromSlash(p))
+}
+
+// validComponent accepts a single directory or file name under the root
+func validComponent(name string) bool {
+	return validPath(name) && !strings.Contains(name, "/") && name != "."
+}
+
+// Repository is a directory of snapshots
+type Repository struct {
+	dir string
+	now func() time.Time
+}
+
+// Open opens the repository in dir, creating the directory if needed
+func Open(dir string) (*Repository, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &Repository{dir: dir, now: time.Now}, nil
+}
+
+// OpenArchive opens the repository holding the archive at path and returns
+// the ID of its snapshot
+func OpenArchive(archive string) (*Repository, string, error) {
+	name := filepath.Base(archive)
+	if !strings.HasSuffix(name, archiveExt) {
+		return nil, "", fmt.Errorf("%s is not a snapshot archive (*%s)", archive, archiveExt)
+	}
+	if _, err := os.Stat(archive); err != nil {
+		return nil, "", err
+	}
+	return &Repository{dir: filepath.Dir(archive), now: time.Now}, strings.TrimSuffix(name, archiveExt), nil
+}
+
+// SetClock replaces the clock used to name and date snapshots
+func (r *Repository) SetClock(now func() time.Time) {
+	r.now = now
+}
+
+// Dir is the repository directory
+func (r *Repository) Dir() string {
+	return r.dir
+}
+
+// ArchivePath is the encrypted archive of snapshot id
+func (r *Repository) ArchivePath(id string) string {
+	return filepath.Join(r.dir, id+archiveExt)
+}
+
+func (r *Repository) sidecarPath(id string) string {
+	return filepath.Join(r.dir, id+sidecarExt)
+}
+
+// List returns the snapshots of the repository, oldest first
+func (r *Repository) List() ([]*Manifest, error) {
+	paths, err := filepath.Glob(filepath.Join(r.dir, "*"+sidecarExt))
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]*Manifest, 0, len(paths))
+	for _, p := range paths {
+		id := strings.TrimSuffix(filepath.Base(p), sidecarExt)
+		m, err := r.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, m)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].Created.Equal(snapshots[j].Created) {
+			return snapshots[i].Created.Before(snapshots[j].Created)
+		}
+		return snapshots[i].ID < snapshots[j].ID
+	})
+	return snapshots, nil
+}
+
+// Get reads the unencrypted manifest kept next to the archive of snapshot
+// id. Only Verify and Restore check it against the archive.
+func (r *Repository) Get(id string) (*Manifest, error) {
+	data, err := os.ReadFile(r.sidecarPath(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest of snapshot %s: %w", id, err)
+	}
+	return &m, nil
+}
+
+// CreateOptions configures a new snapshot
+type CreateOptions struct {
+	// Root is the directory the components are read from
+	Root string
+	// Components are the files or directories directly under Root to back
+	// up; missing ones are skipped
+	Components []string
+	// Recipients encrypt the archive, see age.NewScryptRecipient for a
+	// passphrase
+	Recipients []age.Recipient
+	// Incremental stores only what changed since the latest snapshot
+	Incremental bool
+	// FullEvery limits the incremental snapshots in a row, DefaultFullEvery
+	// when zero
+	FullEvery int
+	Host      string
+}
+
+// Create takes a snapshot of the components under opts.Root
+func (r *Repository) Create(opts CreateOptions) (*Manifest, error) {
+	if len(opts.Recipients) == 0 {
+		return nil, errors.New("no recipients to encrypt the snapshot to")
+	}
+	if len(opts.Components) == 0 {
+		return nil, errors.New("no components to back up")
+	}
+	for _, c := range opts.Components {
+		if !validComponent(c) {
+			return nil, fmt.Errorf("invalid component %q", c)
+		}
+	}
+
+	files, err := scan(opts.Root, opts.Components)
+	if err != nil {
+		return nil, err
+	}
+
+	now := r.now().UTC()
+	m := &Manifest{
+		Version:    FormatVersion,
+		ID:         r.newID(now),
+		Created:    now,
+		Host:       opts.Host,
+		Components: opts.Components,
+		Files:      files,
+	}
+	if opts.Incremental {
+		if err := r.linkParent(m, opts.FullEvery); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.writeArchive(m, opts.Root, opts.Recipients); err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFileAtomic(r.sidecarPath(m.ID), data, 0600); err != nil {
+		os.Remove(r.ArchivePath(m.ID))
+		return nil, err
+	}
+	return m, nil
+}
+
+// newID names a snapshot after its creation time
+func (r *Repository) newID(now time.Time) string {
+	base := now.Format("20060102T150405Z")
+	id := base
+	for n := 2; ; n++ {
+		if _, err := os.Stat(r.sidecarPath(id)); errors.Is(err, os.ErrNotExist) {
+			if _, err := os.Stat(r.ArchivePath(id)); errors.Is(err, os.ErrNotExist) {
+				return id
+			}
+		}
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+}
+
+// linkParent makes m incremental to the latest snapshot: files unchanged
+// since then point to the snapshot that stores them
+func (r *Repository) linkParent(m *Manifest, fullEvery int) error {
+	if fullEvery <= 0 {
+		fullEvery = DefaultFullEvery
+	}
+	snapshots, err := r.List()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		return nil
+	}
+	byID := make(map[string]*Manifest, len(snapshots))
+	for _, s := range snapshots {
+		byID[s.ID] = s
+	}
+	parent := snapshots[len(snapshots)-1]
+
+	// Count the incremental snapshots since the last full one
+	chain := 0
+	for s := parent; s != nil && !s.Full(); s = byID[s.Parent] {
+		chain++
+	}
+	if chain+1 > fullEvery {
+		return nil
+	}
+
+	previous := make(map[string]File, len(parent.Files))
+	for _, f := range parent.Files {
+		previous[f.Path] = f
+	}
+	for i, f := range m.Files {
+		old, ok := previous[f.Path]
+		if !ok || old.SHA256 != f.SHA256 || old.Size != f.Size {
+			continue
+		}
+		holder := old.Snapshot
+		if holder == "" {
+			holder = parent.ID
+		}
+		if _, ok := byID[holder]; !ok {
+			continue
+		}
+		m.Files[i].Snapshot = holder
+	}
+	m.Parent = parent.ID
+	return nil
+}
+
+// scan hashes the regular files of the components, sorted by path
+func scan(root string, components []string) ([]File, error) {
+	var files []File
+	for _, c := range components {
+		err := filepath.WalkDir(filepath.Join(root, c), func(p string, d fs.DirEntry, err error) error {
+			if errors.Is(err, os.ErrNotExist) && p == filepath.Join(root, c) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			sum, size, err := hashFile(p)
+			if err != nil {
+				return err
+			}
+			files = append(files, File{
+				Path:    filepath.ToSlash(rel),
+				Size:    size,
+				Mode:    info.Mode().Perm(),
+				ModTime: info.ModTime().UTC().Truncate(time.Second),
+				SHA256:  sum,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", c, err)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+func hashFile(p string) (string, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// writeFileAtomic replaces path with data through a temporary file
+func writeFileAtomic(p string, data []byte, perm fs.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
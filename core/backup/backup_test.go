@@ -0,0 +1,323 @@
+package backup_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+
+	"syntropy-cc/cooperative-grid/core/backup"
+)
+
+// tree writes files, relative to root, with the given contents
+func tree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the contents of every file under root
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(root, p)
+		files[filepath.ToSlash(rel)] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func equalTrees(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, ok := b[k]; !ok || w != v {
+			return false
+		}
+	}
+	return true
+}
+
+// newRepository opens a repository whose clock advances an hour per call
+func newRepository(t *testing.T) *backup.Repository {
+	t.Helper()
+	repo, err := backup.Open(filepath.Join(t.TempDir(), "backups"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo.SetClock(func() time.Time {
+		now = now.Add(time.Hour)
+		return now
+	})
+	return repo
+}
+
+func newIdentity(t *testing.T) *age.X25519Identity {
+	t.Helper()
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return identity
+}
+
+func TestCreateVerifyRestore(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"nodes/node-01.json":        `{"name":"node-01"}`,
+		"keys/node-01_owner.key":    "PRIVATE KEY",
+		"config/manager.json":       "{}",
+		"cache/discovery.json":      "[]",
+		"work/not-backed-up.txt":    "x",
+		"keys/nested/wireguard.key": "WG",
+	}
+	tree(t, root, files)
+	os.Chmod(filepath.Join(root, "config", "manager.json"), 0640)
+
+	repo := newRepository(t)
+	identity := newIdentity(t)
+	m, err := repo.Create(backup.CreateOptions{
+		Root:       root,
+		Components: []string{"nodes", "keys", "config", "cache", "ca"},
+		Recipients: []age.Recipient{identity.Recipient()},
+		Host:       "manager",
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !m.Full() || len(m.Files) != 5 {
+		t.Fatalf("manifest = %+v", m)
+	}
+
+	archive, err := os.ReadFile(repo.ArchivePath(m.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(archive), "PRIVATE KEY") {
+		t.Fatal("archive holds a key in plaintext")
+	}
+
+	verified, err := repo.Verify(m.ID, identity)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if verified.ID != m.ID || len(verified.Files) != len(m.Files) {
+		t.Errorf("verified manifest = %+v", verified)
+	}
+
+	dest := filepath.Join(t.TempDir(), "restore")
+	restored, err := repo.Restore(m.ID, dest, nil, identity)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(restored) != 5 {
+		t.Errorf("restored %d files", len(restored))
+	}
+	delete(files, "work/not-backed-up.txt")
+	if got := readTree(t, dest); !equalTrees(got, files) {
+		t.Errorf("restored tree = %v", got)
+	}
+	info, err := os.Stat(filepath.Join(dest, "config", "manager.json"))
+	if err != nil || info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, %v", info.Mode(), err)
+	}
+
+	// Only the selected component
+	dest = filepath.Join(t.TempDir(), "restore")
+	restored, err = repo.Restore(m.ID, dest, func(f backup.File) bool { return f.Component() == "nodes" }, identity)
+	if err != nil || len(restored) != 1 {
+		t.Fatalf("selective restore: %v, %+v", err, restored)
+	}
+	if got := readTree(t, dest); len(got) != 1 || got["nodes/node-01.json"] == "" {
+		t.Errorf("selective tree = %v", got)
+	}
+
+	// Another key cannot decrypt it
+	if _, err := repo.Verify(m.ID, newIdentity(t)); err == nil {
+		t.Error("verified with the wrong identity")
+	}
+}
+
+func TestIncremental(t *testing.T) {
+	root := t.TempDir()
+	tree(t, root, map[string]string{
+		"nodes/node-01.json": "one",
+		"nodes/node-02.json": "two",
+		"keys/node-01.key":   "key",
+	})
+	repo := newRepository(t)
+	identity := newIdentity(t)
+	opts := backup.CreateOptions{
+		Root:        root,
+		Components:  []string{"nodes", "keys"},
+		Recipients:  []age.Recipient{identity.Recipient()},
+		Incremental: true,
+		FullEvery:   2,
+	}
+
+	first, err := repo.Create(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !first.Full() {
+		t.Errorf("first snapshot is incremental to %s", first.Parent)
+	}
+
+	tree(t, root, map[string]string{"nodes/node-02.json": "two, changed", "nodes/node-03.json": "three"})
+	os.Remove(filepath.Join(root, "keys", "node-01.key"))
+	second, err := repo.Create(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Parent != first.ID {
+		t.Fatalf("parent = %q, want %q", second.Parent, first.ID)
+	}
+	if n, _ := second.Stored(); n != 2 {
+		t.Errorf("second stores %d files, want 2", n)
+	}
+	if deps := second.Depends(); len(deps) != 1 || deps[0] != first.ID {
+		t.Errorf("depends = %v", deps)
+	}
+
+	// Unchanged files point to the snapshot that stores them, not to the parent
+	third, err := repo.Create(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range third.Files {
+		want := second.ID
+		if f.Path == "nodes/node-01.json" {
+			want = first.ID
+		}
+		if f.Snapshot != want {
+			t.Errorf("%s held by %q, want %q", f.Path, f.Snapshot, want)
+		}
+	}
+
+	dest := filepath.Join(t.TempDir(), "restore")
+	if _, err := repo.Restore(third.ID, dest, nil, identity); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if got, want := readTree(t, dest), readTree(t, root); !equalTrees(got, want) {
+		t.Errorf("restored %v, want %v", got, want)
+	}
+
+	// FullEvery caps the chain of incrementals
+	fourth, err := repo.Create(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !fourth.Full() {
+		t.Errorf("fourth snapshot is incremental to %s", fourth.Parent)
+	}
+
+	// Losing a snapshot breaks the ones that depend on it
+	os.Remove(repo.ArchivePath(first.ID))
+	_, err = repo.Verify(third.ID, identity)
+	var integrity *backup.IntegrityError
+	if !errors.As(err, &integrity) || !strings.Contains(err.Error(), "nodes/node-01.json: snapshot "+first.ID+" holding it is missing") {
+		t.Errorf("verify without %s: %v", first.ID, err)
+	}
+}
+
+func TestTamperedArchive(t *testing.T) {
+	root := t.TempDir()
+	tree(t, root, map[string]string{"config/manager.json": strings.Repeat("config ", 10000)})
+	repo := newRepository(t)
+
+	passphrase, err := age.NewScryptRecipient("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	passphrase.SetWorkFactor(10)
+	m, err := repo.Create(backup.CreateOptions{
+		Root:       root,
+		Components: []string{"config"},
+		Recipients: []age.Recipient{passphrase},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	identity, _ := age.NewScryptIdentity("correct horse")
+	if _, err := repo.Verify(m.ID, identity); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	wrong, _ := age.NewScryptIdentity("wrong")
+	if _, err := repo.Verify(m.ID, wrong); err == nil {
+		t.Error("verified with the wrong passphrase")
+	}
+
+	path := repo.ArchivePath(m.ID)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data[len(data)-20] ^= 0xff
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(t.TempDir(), "restore")
+	if _, err := repo.Restore(m.ID, dest, nil, identity); err == nil {
+		t.Error("restored a tampered archive")
+	}
+
+	// An archive renamed to another snapshot is refused
+	renamed, err := repo.Create(backup.CreateOptions{Root: root, Components: []string{"config"}, Recipients: []age.Recipient{passphrase}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(repo.ArchivePath(m.ID))
+	if err := os.Rename(repo.ArchivePath(renamed.ID), repo.ArchivePath(m.ID)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Verify(m.ID, identity); err == nil || !strings.Contains(err.Error(), "archive holds snapshot") {
+		t.Errorf("swapped archive: %v", err)
+	}
+}
+
+func TestCreateErrors(t *testing.T) {
+	repo := newRepository(t)
+	recipient := newIdentity(t).Recipient()
+	for _, opts := range []backup.CreateOptions{
+		{Root: t.TempDir(), Components: []string{"nodes"}},
+		{Root: t.TempDir(), Recipients: []age.Recipient{recipient}},
+		{Root: t.TempDir(), Components: []string{"../etc"}, Recipients: []age.Recipient{recipient}},
+		{Root: t.TempDir(), Components: []string{"nodes/x"}, Recipients: []age.Recipient{recipient}},
+	} {
+		if _, err := repo.Create(opts); err == nil {
+			t.Errorf("expected error for %+v", opts)
+		}
+	}
+
+	if _, _, err := backup.OpenArchive(filepath.Join(t.TempDir(), "backup.tar.gz")); err == nil {
+		t.Error("opened an archive in the old format")
+	}
+	if _, err := repo.Get("20260101T000000Z"); !errors.Is(err, backup.ErrNotFound) {
+		t.Errorf("Get of a missing snapshot: %v", err)
+	}
+}
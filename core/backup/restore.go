@@ -0,0 +1,257 @@
+package backup
+
+import (
+	"archive/tar"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"filippo.io/age"
+)
+
+// IntegrityError lists the files of a snapshot that are missing, damaged
+// or inconsistent with its manifest
+type IntegrityError struct {
+	Snapshot string
+	Problems []string
+}
+
+func (e *IntegrityError) Error() string {
+	return fmt.Sprintf("snapshot %s failed verification: %s", e.Snapshot, strings.Join(e.Problems, "; "))
+}
+
+// Verify decrypts snapshot id and every snapshot it depends on and checks
+// the size and SHA-256 of each of its files against the manifest. It
+// returns the manifest read from the archive.
+func (r *Repository) Verify(id string, identities ...age.Identity) (*Manifest, error) {
+	m, _, err := r.extract(id, "", nil, identities)
+	return m, err
+}
+
+// Restore verifies the files of snapshot id selected by match, or all of
+// them when match is nil, and writes them under dest, which must not hold
+// any of them yet. Nothing is written unless the snapshot decrypts; a file
+// that fails verification is removed and reported in an IntegrityError.
+func (r *Repository) Restore(id, dest string, match func(File) bool, identities ...age.Identity) ([]File, error) {
+	if dest == "" {
+		return nil, errors.New("no restore directory")
+	}
+	_, files, err := r.extract(id, dest, match, identities)
+	return files, err
+}
+
+// extract reads the selected files of snapshot id from the archives that
+// store them, writing them under dest unless it is empty
+func (r *Repository) extract(id, dest string, match func(File) bool, identities []age.Identity) (*Manifest, []File, error) {
+	ar, err := r.openArchive(id, identities)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := ar.manifest
+
+	var selected []File
+	byHolder := map[string]map[string]File{}
+	for _, f := range m.Files {
+		if match != nil && !match(f) {
+			continue
+		}
+		selected = append(selected, f)
+		holder := f.Snapshot
+		if holder == "" {
+			holder = id
+		}
+		if byHolder[holder] == nil {
+			byHolder[holder] = map[string]File{}
+		}
+		byHolder[holder][f.Path] = f
+	}
+
+	report := &IntegrityError{Snapshot: id}
+	// A full verification also checks the entries no one selected
+	err = readFiles(ar, byHolder[id], dest, match == nil, report)
+	ar.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	holders := make([]string, 0, len(byHolder))
+	for holder := range byHolder {
+		if holder != id {
+			holders = append(holders, holder)
+		}
+	}
+	sort.Strings(holders)
+	for _, holder := range holders {
+		if err := r.extractFrom(holder, byHolder[holder], dest, identities, report); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if len(report.Problems) > 0 {
+		return nil, nil, report
+	}
+	return m, selected, nil
+}
+
+// extractFrom reads the wanted files from the older snapshot holder,
+// whose manifest must store them with the same content
+func (r *Repository) extractFrom(holder string, wanted map[string]File, dest string, identities []age.Identity, report *IntegrityError) error {
+	ar, err := r.openArchive(holder, identities)
+	if errors.Is(err, ErrNotFound) {
+		for _, p := range sortedPaths(wanted) {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s: snapshot %s holding it is missing", p, holder))
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer ar.Close()
+
+	stored := make(map[string]File, len(ar.manifest.Files))
+	for _, f := range ar.manifest.Files {
+		stored[f.Path] = f
+	}
+	for _, p := range sortedPaths(wanted) {
+		f := wanted[p]
+		s, ok := stored[p]
+		if !ok || s.Snapshot != "" || s.SHA256 != f.SHA256 || s.Size != f.Size {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s: not stored by snapshot %s", p, holder))
+			delete(wanted, p)
+		}
+	}
+	return readFiles(ar, wanted, dest, false, report)
+}
+
+// readFiles streams the stored files of an archive, checking the wanted
+// ones and writing them under dest. With all set, every file the archive
+// stores is checked against its manifest.
+func readFiles(ar *archiveReader, wanted map[string]File, dest string, all bool, report *IntegrityError) error {
+	stored := map[string]File{}
+	for _, f := range ar.manifest.Files {
+		if f.Snapshot == "" {
+			stored[f.Path] = f
+		}
+	}
+	found := map[string]bool{}
+
+	for {
+		p, header, err := ar.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		f, ok := stored[p]
+		if !ok || found[p] {
+			return fmt.Errorf("snapshot %s: corrupt archive: unexpected entry %q", ar.manifest.ID, header.Name)
+		}
+		found[p] = true
+
+		want, ok := wanted[p]
+		if !ok && !all {
+			continue
+		}
+		if !ok {
+			want = f
+		}
+		target := ""
+		if ok && dest != "" {
+			target = filepath.Join(dest, filepath.FromSlash(p))
+		}
+		if problem, err := checkFile(ar, header, want, target); err != nil {
+			return err
+		} else if problem != "" {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s: %s", p, problem))
+		}
+	}
+
+	// Reading the rest of the stream authenticates the end of the ciphertext
+	if _, err := io.Copy(io.Discard, ar.zr); err != nil {
+		return fmt.Errorf("snapshot %s: corrupt archive: %w", ar.manifest.ID, err)
+	}
+
+	var missing []string
+	for p := range stored {
+		if !found[p] {
+			if _, ok := wanted[p]; ok || all {
+				missing = append(missing, p)
+			}
+		}
+	}
+	for p := range wanted {
+		if _, ok := stored[p]; !ok {
+			missing = append(missing, p)
+		}
+	}
+	sort.Strings(missing)
+	for _, p := range missing {
+		report.Problems = append(report.Problems, fmt.Sprintf("%s: missing from snapshot %s", p, ar.manifest.ID))
+	}
+	return nil
+}
+
+// checkFile hashes the current entry, copying it to target when set, and
+// describes any mismatch with f. I/O errors on target are returned.
+func checkFile(ar *archiveReader, header *tar.Header, f File, target string) (string, error) {
+	if header.Size != f.Size {
+		return fmt.Sprintf("size %d, manifest says %d", header.Size, f.Size), nil
+	}
+
+	h := sha256.New()
+	w := io.Writer(h)
+	var out *os.File
+	if target != "" {
+		if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+			return "", err
+		}
+		var err error
+		out, err = os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, f.Mode.Perm())
+		if err != nil {
+			return "", err
+		}
+		w = io.MultiWriter(out, h)
+	}
+
+	_, err := io.Copy(w, ar.tr)
+	if out != nil {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		if out != nil {
+			os.Remove(target)
+		}
+		return "", fmt.Errorf("snapshot %s: %s: %w", ar.manifest.ID, f.Path, err)
+	}
+
+	if hex.EncodeToString(h.Sum(nil)) != f.SHA256 {
+		if out != nil {
+			os.Remove(target)
+		}
+		return "SHA-256 mismatch", nil
+	}
+	if out != nil {
+		if err := os.Chtimes(target, f.ModTime, f.ModTime); err != nil {
+			return "", err
+		}
+	}
+	return "", nil
+}
+
+func sortedPaths(files map[string]File) []string {
+	paths := make([]string, 0, len(files))
+	for p := range files {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
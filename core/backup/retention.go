@@ -0,0 +1,121 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Retention decides which snapshots Prune keeps. Each rule keeps the
+// newest snapshot of the last N periods that have one; a snapshot kept by
+// any rule stays, along with the older snapshots holding its files. The
+// zero value keeps everything.
+type Retention struct {
+	KeepLast    int `json:"keep_last"`
+	KeepDaily   int `json:"keep_daily"`
+	KeepWeekly  int `json:"keep_weekly"`
+	KeepMonthly int `json:"keep_monthly"`
+}
+
+// IsZero reports whether the policy keeps every snapshot
+func (p Retention) IsZero() bool {
+	return p.KeepLast <= 0 && p.KeepDaily <= 0 && p.KeepWeekly <= 0 && p.KeepMonthly <= 0
+}
+
+// Validate rejects negative counts
+func (p Retention) Validate() error {
+	if p.KeepLast < 0 || p.KeepDaily < 0 || p.KeepWeekly < 0 || p.KeepMonthly < 0 {
+		return errors.New("retention counts must not be negative")
+	}
+	return nil
+}
+
+// Keep returns the IDs of the snapshots the policy keeps
+func (p Retention) Keep(snapshots []*Manifest) map[string]bool {
+	keep := make(map[string]bool, len(snapshots))
+	if p.IsZero() {
+		for _, s := range snapshots {
+			keep[s.ID] = true
+		}
+		return keep
+	}
+
+	rules := []struct {
+		count  int
+		period func(time.Time) string
+	}{
+		{p.KeepLast, func(t time.Time) string { return t.Format(time.RFC3339Nano) }},
+		{p.KeepDaily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{p.KeepWeekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+		{p.KeepMonthly, func(t time.Time) string { return t.Format("2006-01") }},
+	}
+	for _, rule := range rules {
+		seen := map[string]bool{}
+		for i := len(snapshots) - 1; i >= 0 && len(seen) < rule.count; i-- {
+			s := snapshots[i]
+			key := rule.period(s.Created.UTC())
+			if !seen[key] {
+				seen[key] = true
+				keep[s.ID] = true
+			}
+		}
+	}
+
+	// Snapshots holding files of kept ones stay as well
+	byID := make(map[string]*Manifest, len(snapshots))
+	for _, s := range snapshots {
+		byID[s.ID] = s
+	}
+	pending := make([]string, 0, len(keep))
+	for id := range keep {
+		pending = append(pending, id)
+	}
+	for len(pending) > 0 {
+		s := byID[pending[len(pending)-1]]
+		pending = pending[:len(pending)-1]
+		if s == nil {
+			continue
+		}
+		for _, holder := range s.Depends() {
+			if !keep[holder] {
+				keep[holder] = true
+				pending = append(pending, holder)
+			}
+		}
+	}
+	return keep
+}
+
+// Prune deletes the snapshots the policy does not keep and returns their
+// IDs, oldest first
+func (r *Repository) Prune(p Retention) ([]string, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	snapshots, err := r.List()
+	if err != nil {
+		return nil, err
+	}
+	keep := p.Keep(snapshots)
+
+	var removed []string
+	for _, s := range snapshots {
+		if keep[s.ID] {
+			continue
+		}
+		// The manifest goes first so a half-removed snapshot is no longer
+		// listed
+		if err := os.Remove(r.sidecarPath(s.ID)); err != nil {
+			return removed, err
+		}
+		if err := os.Remove(r.ArchivePath(s.ID)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed = append(removed, s.ID)
+	}
+	return removed, nil
+}
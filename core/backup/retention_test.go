@@ -0,0 +1,121 @@
+package backup_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"filippo.io/age"
+
+	"syntropy-cc/cooperative-grid/core/backup"
+)
+
+// snapshots returns one snapshot per day, the last one at end; depends maps
+// an index to the index of the snapshot it depends on
+func snapshots(end time.Time, days int, depends map[int]int) []*backup.Manifest {
+	list := make([]*backup.Manifest, days)
+	for i := range list {
+		created := end.AddDate(0, 0, i-days+1)
+		list[i] = &backup.Manifest{ID: created.Format("20060102"), Created: created}
+	}
+	for i, holder := range depends {
+		list[i].Parent = list[i-1].ID
+		list[i].Files = []backup.File{{Path: "nodes/a.json", Snapshot: list[holder].ID}}
+	}
+	return list
+}
+
+func kept(keep map[string]bool) []string {
+	var ids []string
+	for id := range keep {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestRetentionKeep(t *testing.T) {
+	end := time.Date(2026, 3, 31, 10, 0, 0, 0, time.UTC)
+	list := snapshots(end, 60, nil)
+
+	tests := []struct {
+		policy backup.Retention
+		want   []string
+	}{
+		{backup.Retention{KeepLast: 2}, []string{"20260330", "20260331"}},
+		{backup.Retention{KeepDaily: 1, KeepWeekly: 3}, []string{"20260322", "20260329", "20260331"}},
+		{backup.Retention{KeepMonthly: 3}, []string{"20260131", "20260228", "20260331"}},
+	}
+	for _, tt := range tests {
+		if got := kept(tt.policy.Keep(list)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%+v kept %v, want %v", tt.policy, got, tt.want)
+		}
+	}
+
+	if got := len(backup.Retention{}.Keep(list)); got != 60 {
+		t.Errorf("zero policy kept %d of 60", got)
+	}
+	if err := (backup.Retention{KeepDaily: -1}).Validate(); err == nil {
+		t.Error("expected error for a negative count")
+	}
+}
+
+func TestRetentionKeepsDependencies(t *testing.T) {
+	end := time.Date(2026, 3, 31, 10, 0, 0, 0, time.UTC)
+	// The last snapshot needs the one before it, which needs the first
+	list := snapshots(end, 5, map[int]int{4: 3, 3: 0})
+
+	got := kept(backup.Retention{KeepLast: 1}.Keep(list))
+	if want := []string{"20260327", "20260330", "20260331"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("kept %v, want %v", got, want)
+	}
+}
+
+func TestPrune(t *testing.T) {
+	root := t.TempDir()
+	tree(t, root, map[string]string{"nodes/node-01.json": "one"})
+	repo := newRepository(t)
+	recipient := newIdentity(t).Recipient()
+
+	var ids []string
+	for i := 0; i < 4; i++ {
+		tree(t, root, map[string]string{fmt.Sprintf("nodes/node-%02d.json", i+2): "new"})
+		m, err := repo.Create(backup.CreateOptions{
+			Root:        root,
+			Components:  []string{"nodes"},
+			Recipients:  []age.Recipient{recipient},
+			Incremental: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, m.ID)
+	}
+
+	// Every snapshot holds one new file, so the last one needs all of them
+	removed, err := repo.Prune(backup.Retention{KeepLast: 1})
+	if err != nil || len(removed) != 0 {
+		t.Fatalf("pruned %v, %v", removed, err)
+	}
+
+	// A full snapshot frees the older ones
+	m, err := repo.Create(backup.CreateOptions{Root: root, Components: []string{"nodes"}, Recipients: []age.Recipient{recipient}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	removed, err = repo.Prune(backup.Retention{KeepLast: 1})
+	if err != nil || !reflect.DeepEqual(removed, ids) {
+		t.Fatalf("pruned %v, %v; want %v", removed, err, ids)
+	}
+	list, err := repo.List()
+	if err != nil || len(list) != 1 || list[0].ID != m.ID {
+		t.Errorf("left %+v, %v", list, err)
+	}
+	left, _ := filepath.Glob(filepath.Join(repo.Dir(), "*"))
+	if len(left) != 2 {
+		t.Errorf("files left: %v", left)
+	}
+}
@@ -0,0 +1,259 @@
+// Package bundle describes the offline provisioning bundles staged next to
+// the installer on USB media for sites without an uplink. A bundle is a
+// directory with a local apt repository, container image tarballs and the
+// agent binary, sealed by a manifest with the hash of every file.
+package bundle
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+)
+
+// Files and directories inside a bundle
+const (
+	ManifestFile = "manifest.json"
+	// ChecksumFile lists the files in sha256sum format, so a node can
+	// verify the bundle before anything else is installed
+	ChecksumFile = "SHA256SUMS"
+	AptDir       = "apt"
+	ImagesDir    = "images"
+	AgentDir     = "agent"
+)
+
+// ManifestVersion is the manifest format written by Seal
+const ManifestVersion = 1
+
+// File kinds recorded in the manifest
+const (
+	KindPackage = "package"
+	KindIndex   = "index"
+	KindImage   = "image"
+	KindAgent   = "agent"
+	KindOther   = "other"
+)
+
+// File is a file of the bundle
+type File struct {
+	Path   string `json:"path"`
+	Kind   string `json:"kind"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes what a bundle was built from and what it contains
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	// Release and Arch are the Ubuntu release and architecture of the packages
+	Release  string   `json:"release"`
+	Arch     string   `json:"arch"`
+	Packages []string `json:"packages"`
+	Images   []string `json:"images"`
+	AgentURL string   `json:"agent_url"`
+	Files    []File   `json:"files"`
+}
+
+// Size returns the total size of the files in the manifest
+func (m *Manifest) Size() int64 {
+	var total int64
+	for _, f := range m.Files {
+		total += f.Size
+	}
+	return total
+}
+
+// Key identifies the contents a bundle was built from, so an existing
+// bundle can be reused for nodes that need the same packages and images
+func Key(release, arch string, packages, images []string, agentURL string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", release, arch, agentURL)
+	for _, list := range [][]string{packages, images} {
+		sorted := append([]string(nil), list...)
+		sort.Strings(sorted)
+		fmt.Fprintf(h, "%s\n", strings.Join(sorted, " "))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// Seal hashes every file in dir and writes the manifest and checksum file.
+// The fields of m other than Version and Files are kept as given.
+func Seal(dir string, m *Manifest) error {
+	m.Version = ManifestVersion
+	m.Files = nil
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == ManifestFile || rel == ChecksumFile {
+			return nil
+		}
+		sum, size, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		m.Files = append(m.Files, File{Path: rel, Kind: kindOf(rel), Size: size, SHA256: sum})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to hash bundle: %w", err)
+	}
+	sort.Slice(m.Files, func(i, j int) bool { return m.Files[i].Path < m.Files[j].Path })
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	manifestPath := filepath.Join(dir, ManifestFile)
+	if err := os.WriteFile(manifestPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write bundle manifest: %w", err)
+	}
+
+	// The checksum file also covers the manifest
+	var sums strings.Builder
+	for _, f := range m.Files {
+		fmt.Fprintf(&sums, "%s  %s\n", f.SHA256, f.Path)
+	}
+	sum, _, err := hashFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&sums, "%s  %s\n", sum, ManifestFile)
+	if err := os.WriteFile(filepath.Join(dir, ChecksumFile), []byte(sums.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write bundle checksums: %w", err)
+	}
+	return nil
+}
+
+// Load reads the manifest of a bundle
+func Load(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeBundleInvalid, "Invalid bundle manifest", err.Error())
+	}
+	if m.Version != ManifestVersion {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeBundleInvalid, "Unsupported bundle manifest",
+			fmt.Sprintf("version %d, expected %d", m.Version, ManifestVersion))
+	}
+	return &m, nil
+}
+
+// Verify checks every file of the bundle against the manifest and the
+// manifest against the checksum file
+func Verify(dir string) (*Manifest, error) {
+	m, err := Load(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, f := range m.Files {
+		sum, size, err := hashFile(filepath.Join(dir, filepath.FromSlash(f.Path)))
+		switch {
+		case os.IsNotExist(err):
+			problems = append(problems, fmt.Sprintf("%s: missing", f.Path))
+		case err != nil:
+			problems = append(problems, fmt.Sprintf("%s: %v", f.Path, err))
+		case size != f.Size || sum != f.SHA256:
+			problems = append(problems, fmt.Sprintf("%s: checksum mismatch", f.Path))
+		}
+	}
+
+	sums, err := readChecksums(filepath.Join(dir, ChecksumFile))
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("%s: %v", ChecksumFile, err))
+	} else {
+		manifestSum, _, err := hashFile(filepath.Join(dir, ManifestFile))
+		if err != nil {
+			return nil, err
+		}
+		if sums[ManifestFile] != manifestSum {
+			problems = append(problems, fmt.Sprintf("%s: does not match %s", ManifestFile, ChecksumFile))
+		}
+		for _, f := range m.Files {
+			if sums[f.Path] != f.SHA256 {
+				problems = append(problems, fmt.Sprintf("%s: does not match %s", f.Path, ChecksumFile))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return m, coreerrors.NewAPIError(coreerrors.ErrCodeBundleInvalid, "Offline bundle failed verification",
+			strings.Join(problems, "; "))
+	}
+	return m, nil
+}
+
+// kindOf classifies a file by where it is in the bundle
+func kindOf(path string) string {
+	switch {
+	case strings.HasPrefix(path, AptDir+"/") && strings.HasSuffix(path, ".deb"):
+		return KindPackage
+	case strings.HasPrefix(path, AptDir+"/"):
+		return KindIndex
+	case strings.HasPrefix(path, ImagesDir+"/"):
+		return KindImage
+	case strings.HasPrefix(path, AgentDir+"/"):
+		return KindAgent
+	default:
+		return KindOther
+	}
+}
+
+func hashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// readChecksums parses a file in sha256sum format
+func readChecksums(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("malformed line %q", line)
+		}
+		sums[name] = sum
+	}
+	return sums, scanner.Err()
+}
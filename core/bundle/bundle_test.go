@@ -0,0 +1,150 @@
+package bundle_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/bundle"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+)
+
+func writeBundle(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	files := map[string]string{
+		"apt/docker.io_24.0.7_amd64.deb": "deb one",
+		"apt/jq_1.7.1_amd64.deb":         "deb two",
+		"apt/Packages.gz":                "index",
+		"images/nginx_alpine.tar":        "image",
+		"agent/syntropy-agent":           "agent",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestSealAndVerify(t *testing.T) {
+	dir := writeBundle(t)
+	m := &bundle.Manifest{
+		CreatedAt: time.Now().UTC(),
+		Release:   "noble",
+		Arch:      "amd64",
+		Packages:  []string{"docker.io", "jq"},
+		Images:    []string{"nginx:alpine"},
+	}
+	if err := bundle.Seal(dir, m); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if len(m.Files) != 5 {
+		t.Fatalf("expected 5 files, got %+v", m.Files)
+	}
+	kinds := map[string]string{}
+	for _, f := range m.Files {
+		kinds[f.Path] = f.Kind
+	}
+	if kinds["apt/jq_1.7.1_amd64.deb"] != bundle.KindPackage || kinds["apt/Packages.gz"] != bundle.KindIndex ||
+		kinds["images/nginx_alpine.tar"] != bundle.KindImage || kinds["agent/syntropy-agent"] != bundle.KindAgent {
+		t.Fatalf("unexpected kinds: %v", kinds)
+	}
+
+	sums, err := os.ReadFile(filepath.Join(dir, bundle.ChecksumFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(sums), "  "+bundle.ManifestFile+"\n") {
+		t.Fatalf("checksum file does not cover the manifest:\n%s", sums)
+	}
+
+	got, err := bundle.Verify(dir)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got.Size() != m.Size() || got.Release != "noble" {
+		t.Fatalf("unexpected manifest: %+v", got)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	dir := writeBundle(t)
+	if err := bundle.Seal(dir, &bundle.Manifest{Release: "noble", Arch: "amd64"}); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "agent", "syntropy-agent"), []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "images", "nginx_alpine.tar")); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := bundle.Verify(dir)
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeBundleInvalid {
+		t.Fatalf("expected BUNDLE_INVALID, got %v", err)
+	}
+	for _, want := range []string{"agent/syntropy-agent: checksum mismatch", "images/nginx_alpine.tar: missing"} {
+		if !strings.Contains(err.(*coreerrors.APIError).Details, want) {
+			t.Errorf("details do not mention %q: %v", want, err)
+		}
+	}
+}
+
+func TestVerifyDetectsEditedManifest(t *testing.T) {
+	dir := writeBundle(t)
+	if err := bundle.Seal(dir, &bundle.Manifest{Release: "noble", Arch: "amd64"}); err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+
+	// Replacing a file and fixing its manifest entry still breaks SHA256SUMS
+	agent := []byte("other agent")
+	if err := os.WriteFile(filepath.Join(dir, "agent", "syntropy-agent"), agent, 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := bundle.Load(dir)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	for i := range m.Files {
+		if m.Files[i].Path == "agent/syntropy-agent" {
+			sum := sha256.Sum256(agent)
+			m.Files[i].SHA256 = hex.EncodeToString(sum[:])
+			m.Files[i].Size = int64(len(agent))
+		}
+	}
+	data, _ := json.Marshal(m)
+	if err := os.WriteFile(filepath.Join(dir, bundle.ManifestFile), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = bundle.Verify(dir)
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeBundleInvalid {
+		t.Fatalf("expected BUNDLE_INVALID, got %v", err)
+	}
+	if !strings.Contains(err.(*coreerrors.APIError).Details, "manifest.json: does not match SHA256SUMS") {
+		t.Fatalf("unexpected details: %v", err)
+	}
+}
+
+func TestKeyIgnoresOrder(t *testing.T) {
+	a := bundle.Key("noble", "amd64", []string{"jq", "curl"}, []string{"nginx:alpine"}, "https://example/agent")
+	b := bundle.Key("noble", "amd64", []string{"curl", "jq"}, []string{"nginx:alpine"}, "https://example/agent")
+	c := bundle.Key("noble", "amd64", []string{"curl"}, []string{"nginx:alpine"}, "https://example/agent")
+	if a != b {
+		t.Fatalf("key depends on package order: %s != %s", a, b)
+	}
+	if a == c {
+		t.Fatalf("different package lists share a key")
+	}
+}
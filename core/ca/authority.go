@@ -0,0 +1,736 @@
+// Package ca keeps the grid certificate authority: a persistent root,
+// optional intermediate CAs, the certificates they issue to nodes and the
+// revocation lists they publish.
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+)
+
+// RootName is the issuer name of the root CA
+const RootName = "root"
+
+// DefaultOrganization is the subject organization of the authority
+const DefaultOrganization = "Syntropy Cooperative Grid"
+
+// Default certificate lifetimes
+const (
+	DefaultRootLifetime         = 10 * 365 * 24 * time.Hour
+	DefaultIntermediateLifetime = 5 * 365 * 24 * time.Hour
+	DefaultNodeLifetime         = 365 * 24 * time.Hour
+)
+
+// crlLifetime is how long a published CRL stays current; it is republished
+// on every revocation
+const crlLifetime = 7 * 24 * time.Hour
+
+var issuerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// Options configures a new authority
+type Options struct {
+	Organization string
+	RootLifetime time.Duration
+	// NodeLifetime is used when an IssueRequest has no lifetime
+	NodeLifetime time.Duration
+}
+
+// IssueRequest describes a node certificate
+type IssueRequest struct {
+	CommonName  string
+	DNSNames    []string
+	IPAddresses []net.IP
+	// Lifetime defaults to the node lifetime of the authority and is capped
+	// at the expiry of the issuer
+	Lifetime time.Duration
+	// Issuer is the name of an intermediate CA, empty for the root
+	Issuer string
+	// Allocate, when set, picks IPAddresses from the current certificate of
+	// every node while the authority is locked, so concurrent issuers never
+	// hand out the same address
+	Allocate func(current []*Record) ([]net.IP, error)
+}
+
+// Issued is a freshly issued certificate with its key
+type Issued struct {
+	Record *Record
+	// CertPEM holds the certificate followed by any intermediate CA
+	CertPEM []byte
+	KeyPEM  []byte
+	RootPEM []byte
+}
+
+// Authority issues and revokes certificates from a directory on disk
+type Authority struct {
+	dir  string
+	cfg  config
+	root *issuer
+	now  func() time.Time
+	mu   sync.Mutex
+}
+
+// issuer is a CA able to sign certificates
+type issuer struct {
+	name    string
+	cert    *x509.Certificate
+	certPEM []byte
+	key     crypto.Signer
+}
+
+// Exists reports whether dir holds an initialized authority
+func Exists(dir string) bool {
+	return fileExists(filepath.Join(dir, rootCertFile))
+}
+
+// Init creates the root CA in dir and publishes its empty CRL
+func Init(dir string, opts Options) (*Authority, error) {
+	unlock, err := lockNewDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if Exists(dir) {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeCAAlreadyInitialized,
+			"Certificate authority already initialized", dir)
+	}
+	return initAuthority(dir, opts)
+}
+
+// OpenOrInit opens the authority in dir, creating it first if needed.
+// Concurrent callers, in this process or others, all end up with the same
+// root. created reports whether this call created it.
+func OpenOrInit(dir string, opts Options) (a *Authority, created bool, err error) {
+	unlock, err := lockNewDir(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	defer unlock()
+	if Exists(dir) {
+		a, err = Open(dir)
+		return a, false, err
+	}
+	a, err = initAuthority(dir, opts)
+	return a, err == nil, err
+}
+
+// lockNewDir creates dir if needed and locks it for initialization
+func lockNewDir(dir string) (unlock func(), err error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create CA directory: %w", err)
+	}
+	return lockDir(dir)
+}
+
+// initAuthority creates the root CA; the caller holds the directory lock
+func initAuthority(dir string, opts Options) (*Authority, error) {
+	if opts.Organization == "" {
+		opts.Organization = DefaultOrganization
+	}
+	if opts.RootLifetime <= 0 {
+		opts.RootLifetime = DefaultRootLifetime
+	}
+	if opts.NodeLifetime <= 0 {
+		opts.NodeLifetime = DefaultNodeLifetime
+	}
+	a := &Authority{
+		dir: dir,
+		cfg: config{
+			Organization: opts.Organization,
+			NodeLifetime: opts.NodeLifetime,
+			CreatedAt:    time.Now().UTC(),
+		},
+		now: time.Now,
+	}
+	if err := writeJSON(filepath.Join(dir, configFile), a.cfg); err != nil {
+		return nil, fmt.Errorf("failed to write CA configuration: %w", err)
+	}
+
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{Organization: []string{opts.Organization}, CommonName: opts.Organization + " Root CA"},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	root, err := a.sign(idx, template, nil, opts.RootLifetime, RootName)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.saveIssuer(RootName, root.CertPEM, root.KeyPEM); err != nil {
+		return nil, err
+	}
+	if a.root, err = a.loadIssuer(RootName); err != nil {
+		return nil, err
+	}
+	root.Record.Name = RootName
+	idx.Records[root.Record.Serial] = root.Record
+	if _, err := a.publishCRL(idx, a.root); err != nil {
+		return nil, err
+	}
+	return a, a.saveIndex(idx)
+}
+
+// Open loads the authority kept in dir
+func Open(dir string) (*Authority, error) {
+	if !Exists(dir) {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeCANotInitialized,
+			"Certificate authority not initialized", dir)
+	}
+	a := &Authority{dir: dir, now: time.Now}
+	if err := readJSON(filepath.Join(dir, configFile), &a.cfg); err != nil {
+		return nil, fmt.Errorf("failed to read CA configuration: %w", err)
+	}
+	if a.cfg.NodeLifetime <= 0 {
+		a.cfg.NodeLifetime = DefaultNodeLifetime
+	}
+	root, err := a.loadIssuer(RootName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load root CA: %w", err)
+	}
+	a.root = root
+	return a, nil
+}
+
+// SetClock replaces the time source, mainly for tests
+func (a *Authority) SetClock(now func() time.Time) {
+	a.now = now
+}
+
+// Dir returns the authority directory
+func (a *Authority) Dir() string {
+	return a.dir
+}
+
+// RootPEM returns the root certificate, the trust anchor of the grid
+func (a *Authority) RootPEM() []byte {
+	return a.root.certPEM
+}
+
+// CreateIntermediate creates an intermediate CA signed by the root.
+// Certificates are issued from it by naming it in IssueRequest.Issuer.
+func (a *Authority) CreateIntermediate(name string, lifetime time.Duration) (*Record, error) {
+	if name == RootName || !issuerNamePattern.MatchString(name) {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid intermediate CA name", name)
+	}
+	if lifetime <= 0 {
+		lifetime = DefaultIntermediateLifetime
+	}
+
+	unlock, err := a.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if certPath, _ := a.issuerPaths(name); fileExists(certPath) {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeConflict, "Intermediate CA already exists", name)
+	}
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		Subject:               pkix.Name{Organization: []string{a.cfg.Organization}, CommonName: a.cfg.Organization + " " + name},
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	issued, err := a.sign(idx, template, a.root, lifetime, RootName)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.saveIssuer(name, issued.CertPEM, issued.KeyPEM); err != nil {
+		return nil, err
+	}
+	issued.Record.Name = name
+	idx.Records[issued.Record.Serial] = issued.Record
+
+	inter, err := a.loadIssuer(name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := a.publishCRL(idx, inter); err != nil {
+		return nil, err
+	}
+	return issued.Record, a.saveIndex(idx)
+}
+
+// Issue signs a new node certificate with a unique serial
+func (a *Authority) Issue(req IssueRequest) (*Issued, error) {
+	unlock, err := a.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	if req.Allocate != nil {
+		if req.IPAddresses, err = req.Allocate(nodeRecords(idx)); err != nil {
+			return nil, err
+		}
+	}
+	issued, err := a.issue(idx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.saveIndex(idx); err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+// Renew issues a replacement for a node certificate with the same names,
+// addresses and issuer. The old certificate stays valid until the caller
+// revokes it, once the node serves the new one.
+func (a *Authority) Renew(serial string) (*Issued, error) {
+	unlock, err := a.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	old, ok := idx.Records[serial]
+	if !ok {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeCertificateNotFound, "Certificate not found", serial)
+	}
+	if old.IsCA {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "CA certificates are not renewed", serial)
+	}
+	if old.RevokedAt != nil {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeCertificateRevoked, "Certificate already revoked", serial)
+	}
+
+	req := IssueRequest{CommonName: old.CommonName, DNSNames: old.DNSNames, Issuer: old.Issuer}
+	for _, s := range old.IPAddresses {
+		if ip := net.ParseIP(s); ip != nil {
+			req.IPAddresses = append(req.IPAddresses, ip)
+		}
+	}
+	issued, err := a.issue(idx, req)
+	if err != nil {
+		return nil, err
+	}
+	issued.Record.RenewedFrom = old.Serial
+	old.ReplacedBy = issued.Record.Serial
+	if err := a.saveIndex(idx); err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+// issue signs a node certificate and adds it to idx, which the caller saves
+func (a *Authority) issue(idx *index, req IssueRequest) (*Issued, error) {
+	if req.CommonName == "" {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Certificate common name is required")
+	}
+	if req.Lifetime <= 0 {
+		req.Lifetime = a.cfg.NodeLifetime
+	}
+	if req.Issuer == "" {
+		req.Issuer = RootName
+	}
+
+	signer, err := a.activeIssuer(idx, req.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	dnsNames := req.DNSNames
+	if len(dnsNames) == 0 {
+		dnsNames = []string{req.CommonName}
+	}
+	template := &x509.Certificate{
+		Subject:     pkix.Name{Organization: []string{a.cfg.Organization}, CommonName: req.CommonName},
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:    dnsNames,
+		IPAddresses: req.IPAddresses,
+	}
+	issued, err := a.sign(idx, template, signer, req.Lifetime, req.Issuer)
+	if err != nil {
+		return nil, err
+	}
+	if signer.name != RootName {
+		issued.CertPEM = append(issued.CertPEM, signer.certPEM...)
+	}
+
+	if err := os.MkdirAll(filepath.Join(a.dir, issuedDir), 0700); err != nil {
+		return nil, err
+	}
+	certPath := filepath.Join(a.dir, issuedDir, issued.Record.Serial+".crt")
+	if err := os.WriteFile(certPath, issued.CertPEM, 0644); err != nil {
+		return nil, fmt.Errorf("failed to store issued certificate: %w", err)
+	}
+	idx.Records[issued.Record.Serial] = issued.Record
+	return issued, nil
+}
+
+// Current returns the newest unrevoked certificate of a node, or nil when
+// the node has none
+func (a *Authority) Current(commonName string) (*Record, error) {
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	return currentRecords(idx)[commonName], nil
+}
+
+// NodeCertificates returns the current certificate of every node, soonest
+// expiry first
+func (a *Authority) NodeCertificates() ([]*Record, error) {
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	return nodeRecords(idx), nil
+}
+
+// Expiring returns the current certificate of every node that expires
+// within the given window, soonest first. Expired certificates are included.
+func (a *Authority) Expiring(within time.Duration) ([]*Record, error) {
+	records, err := a.NodeCertificates()
+	if err != nil {
+		return nil, err
+	}
+	deadline := a.now().Add(within)
+	var due []*Record
+	for _, rec := range records {
+		if !rec.NotAfter.After(deadline) {
+			due = append(due, rec)
+		}
+	}
+	return due, nil
+}
+
+// Revoke marks a certificate as revoked and republishes the CRL of its issuer
+func (a *Authority) Revoke(serial, reason string) (*Record, error) {
+	unlock, err := a.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	rec, ok := idx.Records[serial]
+	if !ok {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeCertificateNotFound, "Certificate not found", serial)
+	}
+	if rec.Name == RootName {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "The root CA cannot be revoked")
+	}
+	if rec.RevokedAt != nil {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeCertificateRevoked, "Certificate already revoked", serial)
+	}
+
+	now := a.now().UTC()
+	rec.RevokedAt = &now
+	rec.RevocationReason = reason
+
+	signer := a.root
+	if rec.Issuer != RootName {
+		if signer, err = a.loadIssuer(rec.Issuer); err != nil {
+			return nil, fmt.Errorf("failed to load CA %s: %w", rec.Issuer, err)
+		}
+	}
+	if _, err := a.publishCRL(idx, signer); err != nil {
+		return nil, err
+	}
+	return rec, a.saveIndex(idx)
+}
+
+// Verify checks that a PEM certificate, optionally followed by its
+// intermediates, chains to the root of the authority and was issued by it
+// without being revoked. It returns the record of the leaf certificate.
+func (a *Authority) Verify(certPEM []byte) (*Record, error) {
+	var chain []*x509.Certificate
+	for rest := certPEM; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid certificate", err.Error())
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "No PEM certificate found")
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(a.root.cert)
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	if _, err := chain[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   a.now(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}); err != nil {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Certificate does not chain to the grid CA", err.Error())
+	}
+
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	leaf, ok := idx.Records[serialString(chain[0].SerialNumber)]
+	if !ok {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeCertificateNotFound, "Certificate not issued by this authority", serialString(chain[0].SerialNumber))
+	}
+	for _, cert := range chain {
+		if rec, ok := idx.Records[serialString(cert.SerialNumber)]; ok && rec.RevokedAt != nil {
+			return leaf, coreerrors.NewAPIError(coreerrors.ErrCodeCertificateRevoked, "Certificate is revoked", rec.Serial)
+		}
+	}
+	return leaf, nil
+}
+
+// Get returns the record of a certificate
+func (a *Authority) Get(serial string) (*Record, error) {
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	rec, ok := idx.Records[serial]
+	if !ok {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeCertificateNotFound, "Certificate not found", serial)
+	}
+	return rec, nil
+}
+
+// List returns every certificate of the authority, oldest first
+func (a *Authority) List() ([]*Record, error) {
+	idx, err := a.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	return sortedRecords(idx), nil
+}
+
+// CRLPath returns where the CRL of an issuer is published
+func (a *Authority) CRLPath(issuerName string) string {
+	if issuerName == "" {
+		issuerName = RootName
+	}
+	return filepath.Join(a.dir, crlDir, issuerName+".crl")
+}
+
+// PublishCRL signs a fresh CRL for an issuer, for example before the
+// current one expires, and returns its path
+func (a *Authority) PublishCRL(issuerName string) (string, error) {
+	if issuerName == "" {
+		issuerName = RootName
+	}
+	unlock, err := a.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	idx, err := a.loadIndex()
+	if err != nil {
+		return "", err
+	}
+	signer := a.root
+	if issuerName != RootName {
+		if signer, err = a.loadIssuer(issuerName); err != nil {
+			return "", coreerrors.NewAPIError(coreerrors.ErrCodeCertificateNotFound, "Unknown CA", issuerName)
+		}
+	}
+	path, err := a.publishCRL(idx, signer)
+	if err != nil {
+		return "", err
+	}
+	return path, a.saveIndex(idx)
+}
+
+// activeIssuer loads an issuer, refusing revoked or expired intermediates
+func (a *Authority) activeIssuer(idx *index, name string) (*issuer, error) {
+	if name == RootName {
+		return a.root, nil
+	}
+	signer, err := a.loadIssuer(name)
+	if err != nil {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeCertificateNotFound, "Unknown CA", name)
+	}
+	rec, ok := idx.Records[serialString(signer.cert.SerialNumber)]
+	if ok && rec.RevokedAt != nil {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeCertificateRevoked, "Intermediate CA is revoked", name)
+	}
+	if a.now().After(signer.cert.NotAfter) {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Intermediate CA is expired", name)
+	}
+	return signer, nil
+}
+
+// sign generates a key and signs template with parent, or self-signs it
+// when parent is nil. The record is not added to the index.
+func (a *Authority) sign(idx *index, template *x509.Certificate, parent *issuer, lifetime time.Duration, issuerName string) (*Issued, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate key: %w", err)
+	}
+	serial, err := newSerial(idx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now().UTC()
+	template.SerialNumber = serial
+	template.NotBefore = now.Add(-5 * time.Minute)
+	template.NotAfter = now.Add(lifetime)
+
+	parentCert, parentKey := template, crypto.Signer(key)
+	if parent != nil {
+		parentCert, parentKey = parent.cert, parent.key
+		if template.NotAfter.After(parent.cert.NotAfter) {
+			template.NotAfter = parent.cert.NotAfter
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parentCert, &key.PublicKey, parentKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign certificate: %w", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := make([]string, 0, len(cert.IPAddresses))
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+	sum := sha256.Sum256(der)
+	return &Issued{
+		Record: &Record{
+			Serial:      serialString(serial),
+			CommonName:  cert.Subject.CommonName,
+			Issuer:      issuerName,
+			IsCA:        cert.IsCA,
+			DNSNames:    cert.DNSNames,
+			IPAddresses: ips,
+			NotBefore:   cert.NotBefore,
+			NotAfter:    cert.NotAfter,
+			Fingerprint: hex.EncodeToString(sum[:]),
+		},
+		CertPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		KeyPEM:  pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}),
+		RootPEM: a.rootPEMOr(der),
+	}, nil
+}
+
+// rootPEMOr returns the root certificate, or der itself while the root is
+// being created
+func (a *Authority) rootPEMOr(der []byte) []byte {
+	if a.root != nil {
+		return a.root.certPEM
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+// publishCRL writes the CRL of an issuer listing its revoked certificates
+func (a *Authority) publishCRL(idx *index, signer *issuer) (string, error) {
+	var revoked []x509.RevocationListEntry
+	for _, rec := range sortedRecords(idx) {
+		if rec.Issuer != signer.name || rec.RevokedAt == nil {
+			continue
+		}
+		serial, ok := new(big.Int).SetString(rec.Serial, 16)
+		if !ok {
+			continue
+		}
+		revoked = append(revoked, x509.RevocationListEntry{SerialNumber: serial, RevocationTime: *rec.RevokedAt})
+	}
+
+	idx.CRLNumbers[signer.name]++
+	now := a.now().UTC()
+	der, err := x509.CreateRevocationList(rand.Reader, &x509.RevocationList{
+		Number:                    big.NewInt(idx.CRLNumbers[signer.name]),
+		ThisUpdate:                now,
+		NextUpdate:                now.Add(crlLifetime),
+		RevokedCertificateEntries: revoked,
+	}, signer.cert, signer.key)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign CRL: %w", err)
+	}
+
+	path := a.CRLPath(signer.name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to publish CRL: %w", err)
+	}
+	return path, nil
+}
+
+// newSerial returns a random 128-bit serial not yet used by the authority
+func newSerial(idx *index) (*big.Int, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), 128)
+	for {
+		serial, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate serial number: %w", err)
+		}
+		if serial.Sign() == 0 {
+			continue
+		}
+		if _, used := idx.Records[serialString(serial)]; !used {
+			return serial, nil
+		}
+	}
+}
+
+func serialString(serial *big.Int) string {
+	return fmt.Sprintf("%x", serial)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,401 @@
+package ca_test
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+)
+
+func newAuthority(t *testing.T) *ca.Authority {
+	t.Helper()
+	a, err := ca.Init(t.TempDir(), ca.Options{NodeLifetime: 30 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	return a
+}
+
+func parsePEM(t *testing.T, data []byte) []*x509.Certificate {
+	t.Helper()
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			t.Fatalf("parse certificate: %v", err)
+		}
+		certs = append(certs, cert)
+	}
+}
+
+// verify checks an issued chain against the root of the authority
+func verify(t *testing.T, a *ca.Authority, issued *ca.Issued, host string) error {
+	t.Helper()
+	chain := parsePEM(t, issued.CertPEM)
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(a.RootPEM())
+	inter := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		inter.AddCert(c)
+	}
+	_, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:       host,
+		Roots:         roots,
+		Intermediates: inter,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
+}
+
+func TestInitAndOpen(t *testing.T) {
+	dir := t.TempDir()
+	if ca.Exists(dir) {
+		t.Fatal("empty directory reported as an authority")
+	}
+	if _, err := ca.Open(dir); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeCANotInitialized {
+		t.Fatalf("Open before Init: got %v", err)
+	}
+
+	a, err := ca.Init(dir, ca.Options{})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if _, err := ca.Init(dir, ca.Options{}); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeCAAlreadyInitialized {
+		t.Fatalf("second Init: got %v", err)
+	}
+
+	reopened, err := ca.Open(dir)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if string(reopened.RootPEM()) != string(a.RootPEM()) {
+		t.Fatal("reopened authority has a different root")
+	}
+	if _, err := os.Stat(a.CRLPath("")); err != nil {
+		t.Fatalf("root CRL not published: %v", err)
+	}
+}
+
+func TestIssueSharesRootWithUniqueSerials(t *testing.T) {
+	a := newAuthority(t)
+
+	first, err := a.Issue(ca.IssueRequest{
+		CommonName:  "node-01",
+		DNSNames:    []string{"node-01", "node-01.local"},
+		IPAddresses: []net.IP{net.ParseIP("172.20.0.11")},
+	})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	second, err := a.Issue(ca.IssueRequest{CommonName: "node-02"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	if first.Record.Serial == second.Record.Serial {
+		t.Fatal("two certificates share a serial")
+	}
+	if err := verify(t, a, first, "node-01.local"); err != nil {
+		t.Fatalf("first certificate does not verify: %v", err)
+	}
+	if err := verify(t, a, second, "node-02"); err != nil {
+		t.Fatalf("second certificate does not verify: %v", err)
+	}
+
+	leaf := parsePEM(t, first.CertPEM)[0]
+	if len(leaf.IPAddresses) != 1 || !leaf.IPAddresses[0].Equal(net.ParseIP("172.20.0.11")) {
+		t.Fatalf("unexpected IP SANs: %v", leaf.IPAddresses)
+	}
+	if got := leaf.NotAfter.Sub(leaf.NotBefore); got < 30*24*time.Hour || got > 31*24*time.Hour {
+		t.Fatalf("node lifetime not applied: %v", got)
+	}
+
+	records, err := a.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	// root + two nodes
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+}
+
+func TestConcurrentAuthorities(t *testing.T) {
+	dir := t.TempDir()
+
+	// Workers racing on a fresh directory must all share one root
+	const workers = 8
+	roots := make([]string, workers)
+	authorities := make([]*ca.Authority, workers)
+	created := make([]bool, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a, c, err := ca.OpenOrInit(dir, ca.Options{})
+			if err != nil {
+				t.Errorf("OpenOrInit: %v", err)
+				return
+			}
+			authorities[i], created[i], roots[i] = a, c, string(a.RootPEM())
+		}(i)
+	}
+	wg.Wait()
+	if t.Failed() {
+		return
+	}
+	inits := 0
+	for i := range roots {
+		if roots[i] != roots[0] {
+			t.Fatal("concurrent initialization produced different roots")
+		}
+		if created[i] {
+			inits++
+		}
+	}
+	if inits != 1 {
+		t.Fatalf("authority initialized %d times", inits)
+	}
+
+	// Separately opened authorities issue at the same time without losing
+	// each other's records
+	const perWorker = 5
+	serials := make(chan string, workers*perWorker)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a, err := ca.Open(dir)
+			if err != nil {
+				t.Errorf("Open: %v", err)
+				return
+			}
+			for j := 0; j < perWorker; j++ {
+				issued, err := a.Issue(ca.IssueRequest{CommonName: fmt.Sprintf("node-%d-%d", i, j)})
+				if err != nil {
+					t.Errorf("Issue: %v", err)
+					return
+				}
+				serials <- issued.Record.Serial
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(serials)
+
+	records, err := authorities[0].List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	indexed := make(map[string]bool, len(records))
+	for _, r := range records {
+		indexed[r.Serial] = true
+	}
+	count := 0
+	for serial := range serials {
+		count++
+		if !indexed[serial] {
+			t.Errorf("serial %s missing from the index", serial)
+		}
+	}
+	if count != workers*perWorker {
+		t.Errorf("issued %d certificates, want %d", count, workers*perWorker)
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(leftovers) > 0 {
+		t.Errorf("temporary files left behind: %v", leftovers)
+	}
+}
+
+func TestIssueAllocatesUnderLock(t *testing.T) {
+	dir := newAuthority(t).Dir()
+
+	// Every worker takes the lowest address no current certificate holds;
+	// without the lock two of them would see the same free address
+	firstFree := func(current []*ca.Record) ([]net.IP, error) {
+		used := make(map[string]bool)
+		for _, rec := range current {
+			for _, ip := range rec.IPAddresses {
+				used[ip] = true
+			}
+		}
+		for i := 1; i < 255; i++ {
+			if ip := fmt.Sprintf("10.0.0.%d", i); !used[ip] {
+				return []net.IP{net.ParseIP(ip)}, nil
+			}
+		}
+		return nil, fmt.Errorf("no free address")
+	}
+
+	const workers = 8
+	addresses := make(chan string, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a, err := ca.Open(dir)
+			if err != nil {
+				t.Errorf("Open: %v", err)
+				return
+			}
+			issued, err := a.Issue(ca.IssueRequest{CommonName: fmt.Sprintf("node-%d", i), Allocate: firstFree})
+			if err != nil {
+				t.Errorf("Issue: %v", err)
+				return
+			}
+			addresses <- issued.Record.IPAddresses[0]
+		}(i)
+	}
+	wg.Wait()
+	close(addresses)
+
+	seen := make(map[string]bool)
+	for ip := range addresses {
+		if seen[ip] {
+			t.Errorf("address %s issued twice", ip)
+		}
+		seen[ip] = true
+	}
+	if len(seen) != workers {
+		t.Errorf("issued %d addresses, want %d", len(seen), workers)
+	}
+
+	a, _ := ca.Open(dir)
+	if _, err := a.Issue(ca.IssueRequest{CommonName: "full", Allocate: func([]*ca.Record) ([]net.IP, error) {
+		return nil, fmt.Errorf("no free address")
+	}}); err == nil {
+		t.Error("allocation error not returned")
+	}
+}
+
+func TestIntermediateChain(t *testing.T) {
+	a := newAuthority(t)
+
+	if _, err := a.CreateIntermediate("site-a", 0); err != nil {
+		t.Fatalf("CreateIntermediate: %v", err)
+	}
+	if _, err := a.CreateIntermediate("site-a", 0); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeConflict {
+		t.Fatalf("duplicate intermediate: got %v", err)
+	}
+
+	issued, err := a.Issue(ca.IssueRequest{CommonName: "node-03", Issuer: "site-a", Lifetime: 100 * 365 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	chain := parsePEM(t, issued.CertPEM)
+	if len(chain) != 2 {
+		t.Fatalf("expected leaf and intermediate, got %d certificates", len(chain))
+	}
+	if chain[0].NotAfter.After(chain[1].NotAfter) {
+		t.Fatal("leaf outlives its issuer")
+	}
+	if err := verify(t, a, issued, "node-03"); err != nil {
+		t.Fatalf("chain does not verify: %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	a := newAuthority(t)
+	if _, err := a.CreateIntermediate("site-c", 0); err != nil {
+		t.Fatalf("CreateIntermediate: %v", err)
+	}
+	issued, err := a.Issue(ca.IssueRequest{CommonName: "node-05", Issuer: "site-c"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	rec, err := a.Verify(issued.CertPEM)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if rec.CommonName != "node-05" {
+		t.Fatalf("verified %s, want node-05", rec.CommonName)
+	}
+
+	// Without the intermediate the leaf does not chain to the root
+	leaf, _ := pem.Decode(issued.CertPEM)
+	if _, err := a.Verify(pem.EncodeToMemory(leaf)); err == nil {
+		t.Fatal("leaf without intermediate verified")
+	}
+
+	other := newAuthority(t)
+	foreign, err := other.Issue(ca.IssueRequest{CommonName: "node-05"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := a.Verify(foreign.CertPEM); err == nil {
+		t.Fatal("certificate from another authority verified")
+	}
+
+	if _, err := a.Revoke(issued.Record.Serial, "retired"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := a.Verify(issued.CertPEM); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeCertificateRevoked {
+		t.Fatalf("revoked certificate: got %v", err)
+	}
+}
+
+func TestRevokePublishesCRL(t *testing.T) {
+	a := newAuthority(t)
+	inter, err := a.CreateIntermediate("site-b", 0)
+	if err != nil {
+		t.Fatalf("CreateIntermediate: %v", err)
+	}
+	issued, err := a.Issue(ca.IssueRequest{CommonName: "node-04", Issuer: "site-b"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	rec, err := a.Revoke(issued.Record.Serial, "key compromise")
+	if err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if rec.Status(time.Now()) != ca.StatusRevoked {
+		t.Fatalf("status after revoke: %s", rec.Status(time.Now()))
+	}
+	if _, err := a.Revoke(issued.Record.Serial, ""); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeCertificateRevoked {
+		t.Fatalf("second revoke: got %v", err)
+	}
+
+	data, err := os.ReadFile(a.CRLPath("site-b"))
+	if err != nil {
+		t.Fatalf("read CRL: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	crl, err := x509.ParseRevocationList(block.Bytes)
+	if err != nil {
+		t.Fatalf("parse CRL: %v", err)
+	}
+	interCert := parsePEM(t, issued.CertPEM)[1]
+	if err := crl.CheckSignatureFrom(interCert); err != nil {
+		t.Fatalf("CRL not signed by the intermediate: %v", err)
+	}
+	if len(crl.RevokedCertificateEntries) != 1 || crl.RevokedCertificateEntries[0].SerialNumber.Cmp(parsePEM(t, issued.CertPEM)[0].SerialNumber) != 0 {
+		t.Fatalf("CRL does not list the revoked certificate: %+v", crl.RevokedCertificateEntries)
+	}
+
+	// A revoked intermediate can no longer issue
+	if _, err := a.Revoke(inter.Serial, "retired"); err != nil {
+		t.Fatalf("Revoke intermediate: %v", err)
+	}
+	if _, err := a.Issue(ca.IssueRequest{CommonName: "node-05", Issuer: "site-b"}); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeCertificateRevoked {
+		t.Fatalf("issue from revoked intermediate: got %v", err)
+	}
+	if _, err := a.Revoke("deadbeef", ""); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeCertificateNotFound {
+		t.Fatalf("revoke unknown serial: got %v", err)
+	}
+}
@@ -0,0 +1,132 @@
+package ca
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// DefaultRenewalWindow is how long before expiry a node certificate is renewed
+const DefaultRenewalWindow = 30 * 24 * time.Hour
+
+// Revocation reasons recorded by the renewal flow
+const (
+	ReasonSuperseded     = "superseded"
+	ReasonRotationFailed = "rotation failed"
+)
+
+// Deployer installs certificates on nodes. Implementations restart the
+// node service as part of Install.
+type Deployer interface {
+	// Install replaces the certificate, key and CA of a node
+	Install(ctx context.Context, node string, issued *Issued) error
+	// ServingSerial returns the serial of the certificate the node service
+	// presents, in the format of Record.Serial
+	ServingSerial(ctx context.Context, node string) (string, error)
+	// Rollback restores the certificate the node had before Install
+	Rollback(ctx context.Context, node string) error
+}
+
+// RenewalResult is the outcome of rotating the certificate of one node
+type RenewalResult struct {
+	Node      string    `json:"node"`
+	OldSerial string    `json:"old_serial"`
+	NewSerial string    `json:"new_serial,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+	Renewed   bool      `json:"renewed"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Renewer rotates node certificates that are close to expiry
+type Renewer struct {
+	authority *Authority
+	deployer  Deployer
+	window    time.Duration
+}
+
+// NewRenewer creates a renewer; a window of 0 uses DefaultRenewalWindow
+func NewRenewer(authority *Authority, deployer Deployer, window time.Duration) *Renewer {
+	if window <= 0 {
+		window = DefaultRenewalWindow
+	}
+	return &Renewer{authority: authority, deployer: deployer, window: window}
+}
+
+// Due returns the node certificates inside the renewal window
+func (r *Renewer) Due() ([]*Record, error) {
+	return r.authority.Expiring(r.window)
+}
+
+// RenewDue rotates every certificate inside the renewal window. A failure
+// on one node does not stop the others.
+func (r *Renewer) RenewDue(ctx context.Context) ([]RenewalResult, error) {
+	due, err := r.Due()
+	if err != nil {
+		return nil, err
+	}
+	results := make([]RenewalResult, 0, len(due))
+	for _, rec := range due {
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+		results = append(results, r.rotate(ctx, rec))
+	}
+	return results, nil
+}
+
+// RenewNode rotates the certificate of a node regardless of the window
+func (r *Renewer) RenewNode(ctx context.Context, node string) (RenewalResult, error) {
+	rec, err := r.authority.Current(node)
+	if err != nil {
+		return RenewalResult{}, err
+	}
+	if rec == nil {
+		return RenewalResult{}, fmt.Errorf("no certificate issued for node %s", node)
+	}
+	return r.rotate(ctx, rec), nil
+}
+
+// rotate issues a replacement, installs it and only revokes the old
+// certificate once the node serves the new one. On failure the node is
+// rolled back and the replacement revoked, leaving the old one current.
+func (r *Renewer) rotate(ctx context.Context, old *Record) RenewalResult {
+	result := RenewalResult{Node: old.CommonName, OldSerial: old.Serial, ExpiresAt: old.NotAfter}
+
+	issued, err := r.authority.Renew(old.Serial)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.NewSerial = issued.Record.Serial
+
+	fail := func(err error, rollback bool) RenewalResult {
+		result.Error = err.Error()
+		if rollback {
+			if rbErr := r.deployer.Rollback(ctx, old.CommonName); rbErr != nil {
+				result.Error += fmt.Sprintf(" (rollback failed: %v)", rbErr)
+			}
+		}
+		if _, rvErr := r.authority.Revoke(issued.Record.Serial, ReasonRotationFailed); rvErr != nil {
+			result.Error += fmt.Sprintf(" (revoking the new certificate failed: %v)", rvErr)
+		}
+		return result
+	}
+
+	if err := r.deployer.Install(ctx, old.CommonName, issued); err != nil {
+		return fail(fmt.Errorf("install failed: %w", err), true)
+	}
+	serving, err := r.deployer.ServingSerial(ctx, old.CommonName)
+	if err != nil {
+		return fail(fmt.Errorf("verification failed: %w", err), true)
+	}
+	if serving != issued.Record.Serial {
+		return fail(fmt.Errorf("node serves certificate %s instead of %s", serving, issued.Record.Serial), true)
+	}
+
+	if _, err := r.authority.Revoke(old.Serial, ReasonSuperseded); err != nil {
+		result.Error = fmt.Sprintf("renewed, but revoking the old certificate failed: %v", err)
+	}
+	result.Renewed = true
+	result.ExpiresAt = issued.Record.NotAfter
+	return result
+}
@@ -0,0 +1,179 @@
+package ca_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+)
+
+// fakeDeployer records installs and serves whatever was installed last,
+// unless broken is set for the node
+type fakeDeployer struct {
+	serving  map[string]string
+	broken   map[string]bool
+	rollback []string
+}
+
+func newFakeDeployer() *fakeDeployer {
+	return &fakeDeployer{serving: make(map[string]string), broken: make(map[string]bool)}
+}
+
+func (d *fakeDeployer) Install(ctx context.Context, node string, issued *ca.Issued) error {
+	if d.broken[node] {
+		// The files are written but the service keeps the old certificate
+		return nil
+	}
+	d.serving[node] = issued.Record.Serial
+	return nil
+}
+
+func (d *fakeDeployer) ServingSerial(ctx context.Context, node string) (string, error) {
+	serial, ok := d.serving[node]
+	if !ok {
+		return "", errors.New("node unreachable")
+	}
+	return serial, nil
+}
+
+func (d *fakeDeployer) Rollback(ctx context.Context, node string) error {
+	d.rollback = append(d.rollback, node)
+	return nil
+}
+
+type clock struct{ t time.Time }
+
+func (c *clock) Now() time.Time { return c.t }
+
+func TestRenewDueRotatesInsideWindow(t *testing.T) {
+	a, err := ca.Init(t.TempDir(), ca.Options{NodeLifetime: 90 * 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	c := &clock{t: time.Now()}
+	a.SetClock(c.Now)
+
+	old, err := a.Issue(ca.IssueRequest{
+		CommonName:  "node-01",
+		DNSNames:    []string{"node-01", "node-01.local"},
+		IPAddresses: []net.IP{net.ParseIP("172.20.0.11")},
+	})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if _, err := a.Issue(ca.IssueRequest{CommonName: "node-02", Lifetime: 365 * 24 * time.Hour}); err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	d := newFakeDeployer()
+	d.serving["node-01"] = old.Record.Serial
+	r := ca.NewRenewer(a, d, 30*24*time.Hour)
+
+	due, err := r.Due()
+	if err != nil || len(due) != 0 {
+		t.Fatalf("nothing should be due yet: %v %v", due, err)
+	}
+
+	// 70 days later node-01 is inside the 30-day window, node-02 is not
+	c.t = c.t.Add(70 * 24 * time.Hour)
+	results, err := r.RenewDue(context.Background())
+	if err != nil {
+		t.Fatalf("RenewDue: %v", err)
+	}
+	if len(results) != 1 || !results[0].Renewed || results[0].Node != "node-01" {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	cur, err := a.Current("node-01")
+	if err != nil {
+		t.Fatalf("Current: %v", err)
+	}
+	if cur.Serial != results[0].NewSerial || cur.RenewedFrom != old.Record.Serial {
+		t.Fatalf("current certificate is not the renewal: %+v", cur)
+	}
+	if len(cur.IPAddresses) != 1 || cur.IPAddresses[0] != "172.20.0.11" || len(cur.DNSNames) != 2 {
+		t.Fatalf("renewal lost SANs: %+v", cur)
+	}
+	prev, _ := a.Get(old.Record.Serial)
+	if prev.RevocationReason != ca.ReasonSuperseded || prev.ReplacedBy != cur.Serial {
+		t.Fatalf("old certificate not superseded: %+v", prev)
+	}
+
+	due, _ = r.Due()
+	if len(due) != 0 {
+		t.Fatalf("renewed node still due: %+v", due)
+	}
+}
+
+func TestRotationRollsBackWhenNodeKeepsOldCertificate(t *testing.T) {
+	a, err := ca.Init(t.TempDir(), ca.Options{})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	old, err := a.Issue(ca.IssueRequest{CommonName: "node-03"})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	d := newFakeDeployer()
+	d.serving["node-03"] = old.Record.Serial
+	d.broken["node-03"] = true
+
+	result, err := ca.NewRenewer(a, d, 0).RenewNode(context.Background(), "node-03")
+	if err != nil {
+		t.Fatalf("RenewNode: %v", err)
+	}
+	if result.Renewed || result.Error == "" {
+		t.Fatalf("rotation should fail: %+v", result)
+	}
+	if len(d.rollback) != 1 {
+		t.Fatalf("node not rolled back: %v", d.rollback)
+	}
+
+	cur, _ := a.Current("node-03")
+	if cur.Serial != old.Record.Serial {
+		t.Fatalf("old certificate should stay current, got %s", cur.Serial)
+	}
+	failed, _ := a.Get(result.NewSerial)
+	if failed.RevocationReason != ca.ReasonRotationFailed {
+		t.Fatalf("failed replacement not revoked: %+v", failed)
+	}
+}
+
+// revokingDeployer fails the install after someone else already revoked
+// the new certificate, so the rotation cannot revoke it again
+type revokingDeployer struct {
+	*fakeDeployer
+	authority *ca.Authority
+}
+
+func (d *revokingDeployer) Install(ctx context.Context, node string, issued *ca.Issued) error {
+	if _, err := d.authority.Revoke(issued.Record.Serial, ca.ReasonSuperseded); err != nil {
+		return err
+	}
+	return errors.New("disk full")
+}
+
+func TestRotationReportsFailedRevocation(t *testing.T) {
+	a, err := ca.Init(t.TempDir(), ca.Options{})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if _, err := a.Issue(ca.IssueRequest{CommonName: "node-04"}); err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	d := &revokingDeployer{fakeDeployer: newFakeDeployer(), authority: a}
+	result, err := ca.NewRenewer(a, d, 0).RenewNode(context.Background(), "node-04")
+	if err != nil {
+		t.Fatalf("RenewNode: %v", err)
+	}
+	if !strings.HasPrefix(result.Error, "install failed: disk full") ||
+		!strings.Contains(result.Error, "revoking the new certificate failed") {
+		t.Fatalf("error = %q", result.Error)
+	}
+}
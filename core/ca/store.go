@@ -0,0 +1,280 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Files inside the authority directory
+const (
+	configFile       = "ca.json"
+	indexFile        = "index.json"
+	rootCertFile     = "root.crt"
+	rootKeyFile      = "root.key"
+	intermediatesDir = "intermediates"
+	issuedDir        = "issued"
+	crlDir           = "crl"
+	lockFileName     = ".lock"
+)
+
+// Record is a certificate issued by the authority
+type Record struct {
+	Serial     string `json:"serial"`
+	CommonName string `json:"common_name"`
+	// Issuer is the name of the signing CA, RootName or an intermediate
+	Issuer string `json:"issuer"`
+	// Name is set on intermediate CAs, which are also issuers
+	Name        string    `json:"name,omitempty"`
+	IsCA        bool      `json:"is_ca"`
+	DNSNames    []string  `json:"dns_names,omitempty"`
+	IPAddresses []string  `json:"ip_addresses,omitempty"`
+	NotBefore   time.Time `json:"not_before"`
+	NotAfter    time.Time `json:"not_after"`
+	Fingerprint string    `json:"fingerprint"`
+
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+	RevocationReason string     `json:"revocation_reason,omitempty"`
+
+	// RenewedFrom and ReplacedBy link the certificates of a renewal
+	RenewedFrom string `json:"renewed_from,omitempty"`
+	ReplacedBy  string `json:"replaced_by,omitempty"`
+}
+
+// Status returns "revoked", "expired" or "valid" at the given time
+func (r *Record) Status(now time.Time) string {
+	switch {
+	case r.RevokedAt != nil:
+		return StatusRevoked
+	case now.After(r.NotAfter):
+		return StatusExpired
+	default:
+		return StatusValid
+	}
+}
+
+// Certificate statuses reported by Record.Status
+const (
+	StatusValid   = "valid"
+	StatusRevoked = "revoked"
+	StatusExpired = "expired"
+)
+
+// config is the on-disk authority configuration
+type config struct {
+	Organization string        `json:"organization"`
+	NodeLifetime time.Duration `json:"node_lifetime"`
+	CreatedAt    time.Time     `json:"created_at"`
+}
+
+// index is the on-disk list of issued certificates, keyed by serial
+type index struct {
+	Records map[string]*Record `json:"records"`
+	// CRLNumbers is the last CRL number published by each issuer
+	CRLNumbers map[string]int64 `json:"crl_numbers"`
+}
+
+func (a *Authority) loadIndex() (*index, error) {
+	idx := &index{Records: make(map[string]*Record), CRLNumbers: make(map[string]int64)}
+	if err := readJSON(filepath.Join(a.dir, indexFile), idx); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read CA index: %w", err)
+	}
+	if idx.Records == nil {
+		idx.Records = make(map[string]*Record)
+	}
+	if idx.CRLNumbers == nil {
+		idx.CRLNumbers = make(map[string]int64)
+	}
+	return idx, nil
+}
+
+func (a *Authority) saveIndex(idx *index) error {
+	if err := writeJSON(filepath.Join(a.dir, indexFile), idx); err != nil {
+		return fmt.Errorf("failed to write CA index: %w", err)
+	}
+	return nil
+}
+
+// sortedRecords returns the records, oldest first
+func sortedRecords(idx *index) []*Record {
+	records := make([]*Record, 0, len(idx.Records))
+	for _, r := range idx.Records {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].NotBefore.Equal(records[j].NotBefore) {
+			return records[i].NotBefore.Before(records[j].NotBefore)
+		}
+		return records[i].Serial < records[j].Serial
+	})
+	return records
+}
+
+// currentRecords returns the newest unrevoked node certificate of each
+// common name
+func currentRecords(idx *index) map[string]*Record {
+	current := make(map[string]*Record)
+	for _, rec := range idx.Records {
+		if rec.IsCA || rec.RevokedAt != nil {
+			continue
+		}
+		if cur, ok := current[rec.CommonName]; !ok || rec.NotBefore.After(cur.NotBefore) ||
+			(rec.NotBefore.Equal(cur.NotBefore) && rec.RenewedFrom == cur.Serial) {
+			current[rec.CommonName] = rec
+		}
+	}
+	return current
+}
+
+// nodeRecords returns the current certificate of every node in idx,
+// soonest expiry first
+func nodeRecords(idx *index) []*Record {
+	var records []*Record
+	for _, rec := range currentRecords(idx) {
+		records = append(records, rec)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].NotAfter.Before(records[j].NotAfter) })
+	return records
+}
+
+// issuerPaths returns where the certificate and key of an issuer are kept
+func (a *Authority) issuerPaths(name string) (string, string) {
+	if name == RootName {
+		return filepath.Join(a.dir, rootCertFile), filepath.Join(a.dir, rootKeyFile)
+	}
+	base := filepath.Join(a.dir, intermediatesDir, name)
+	return base + ".crt", base + ".key"
+}
+
+// loadIssuer reads the certificate and key of an issuing CA
+func (a *Authority) loadIssuer(name string) (*issuer, error) {
+	certPath, keyPath := a.issuerPaths(name)
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := parseCertificate(certPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate for CA %s: %w", name, err)
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	key, err := parsePrivateKey(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid key for CA %s: %w", name, err)
+	}
+	return &issuer{name: name, cert: cert, certPEM: certPEM, key: key}, nil
+}
+
+// saveIssuer writes the certificate and key of an issuing CA
+func (a *Authority) saveIssuer(name string, certPEM, keyPEM []byte) error {
+	certPath, keyPath := a.issuerPaths(name)
+	if err := os.MkdirAll(filepath.Dir(certPath), 0700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write key for CA %s: %w", name, err)
+	}
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return fmt.Errorf("failed to write certificate for CA %s: %w", name, err)
+	}
+	return nil
+}
+
+func parseCertificate(data []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func parsePrivateKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM key found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	return signer, nil
+}
+
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// writeJSON writes v atomically through a temporary file of its own, so
+// concurrent writers never rename each other's half-written data
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// lockDir takes an exclusive lock on the authority directory, shared with
+// every other process and Authority using it. The index is read, changed
+// and written back while it is held.
+func lockDir(dir string) (unlock func(), err error) {
+	f, err := os.OpenFile(filepath.Join(dir, lockFileName), os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CA lock: %w", err)
+	}
+	if err := lockFile(f); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock CA directory: %w", err)
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
+
+// lock serializes index updates within the process and across processes
+func (a *Authority) lock() (unlock func(), err error) {
+	a.mu.Lock()
+	release, err := lockDir(a.dir)
+	if err != nil {
+		a.mu.Unlock()
+		return nil, err
+	}
+	return func() {
+		release()
+		a.mu.Unlock()
+	}, nil
+}
@@ -0,0 +1,91 @@
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// CacheEntry is the last known state of an address
+type CacheEntry struct {
+	Host
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+// Cache remembers every host seen by past sweeps, keyed by address
+type Cache struct {
+	Entries map[string]*CacheEntry `json:"entries"`
+	path    string
+}
+
+// LoadCache reads the cache at path; a missing file is an empty cache
+func LoadCache(path string) (*Cache, error) {
+	cache := &Cache{Entries: map[string]*CacheEntry{}, path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, cache); err != nil {
+		return nil, fmt.Errorf("invalid discovery cache %s: %w", path, err)
+	}
+	if cache.Entries == nil {
+		cache.Entries = map[string]*CacheEntry{}
+	}
+	return cache, nil
+}
+
+// Merge records the hosts of a sweep made at now. A host that could not be
+// identified this time keeps the node it was last identified as.
+func (c *Cache) Merge(hosts []Host, now time.Time) {
+	for _, host := range hosts {
+		entry, ok := c.Entries[host.Addr]
+		if !ok {
+			entry = &CacheEntry{FirstSeen: now}
+			c.Entries[host.Addr] = entry
+		}
+		previous := entry.Node
+		entry.Host = host
+		if entry.Node == nil {
+			entry.Node = previous
+		}
+		entry.LastSeen = now
+	}
+}
+
+// List returns the entries sorted by address
+func (c *Cache) List() []*CacheEntry {
+	entries := make([]*CacheEntry, 0, len(c.Entries))
+	for _, entry := range c.Entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		a, _ := netip.ParseAddr(entries[i].Addr)
+		b, _ := netip.ParseAddr(entries[j].Addr)
+		return a.Less(b)
+	})
+	return entries
+}
+
+// Save writes the cache atomically
+func (c *Cache) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
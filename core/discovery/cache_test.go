@@ -0,0 +1,55 @@
+package discovery_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/discovery"
+)
+
+func TestCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache", "discovery.json")
+	cache, err := discovery.LoadCache(path)
+	if err != nil {
+		t.Fatalf("LoadCache: %v", err)
+	}
+	if len(cache.Entries) != 0 {
+		t.Fatalf("new cache has %d entries", len(cache.Entries))
+	}
+
+	first := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	node := &discovery.Node{Name: "node-01", Method: "handshake", Verified: true}
+	cache.Merge([]discovery.Host{
+		{Addr: "192.168.1.10", Port: 22, SSH: true, Node: node},
+		{Addr: "192.168.1.9", Port: 22, SSH: true},
+	}, first)
+	if err := cache.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	cache, err = discovery.LoadCache(path)
+	if err != nil {
+		t.Fatalf("LoadCache: %v", err)
+	}
+	second := first.Add(time.Hour)
+	cache.Merge([]discovery.Host{
+		{Addr: "192.168.1.10", Port: 22, SSH: true, Latency: time.Millisecond, Error: "handshake: timeout"},
+	}, second)
+
+	entries := cache.List()
+	if len(entries) != 2 || entries[0].Addr != "192.168.1.9" || entries[1].Addr != "192.168.1.10" {
+		t.Fatalf("entries = %+v", entries)
+	}
+	e := entries[1]
+	if !e.FirstSeen.Equal(first) || !e.LastSeen.Equal(second) {
+		t.Errorf("seen %v - %v", e.FirstSeen, e.LastSeen)
+	}
+	// The node identified before survives a failed identification
+	if e.Node == nil || e.Node.Name != "node-01" || e.Error != "handshake: timeout" || e.Latency != time.Millisecond {
+		t.Errorf("entry = %+v", e)
+	}
+	if !entries[0].LastSeen.Equal(first) {
+		t.Errorf("unseen host last seen %v", entries[0].LastSeen)
+	}
+}
@@ -0,0 +1,160 @@
+// Package discovery finds grid nodes on the network: it sweeps address
+// ranges for open SSH ports, identifies the hosts that are Syntropy nodes
+// and keeps a persistent cache of what was seen.
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MetadataPath is where provisioning writes the node metadata
+const MetadataPath = "/opt/syntropy/metadata/node.json"
+
+// MaxTargets bounds how many addresses a single sweep may probe
+const MaxTargets = 1 << 16
+
+// nodeNamePattern keeps names announced by hosts usable as file names
+var nodeNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,62}$`)
+
+// Host is an address that answered on the scanned port
+type Host struct {
+	Addr    string        `json:"addr"`
+	Port    int           `json:"port"`
+	Latency time.Duration `json:"latency"`
+	// SSH is set when the port answered with an SSH banner
+	SSH    bool   `json:"ssh"`
+	Banner string `json:"banner,omitempty"`
+	// Node is set when the host was identified as a grid node
+	Node *Node `json:"node,omitempty"`
+	// Error is the last identification failure of an unidentified host
+	Error string `json:"error,omitempty"`
+}
+
+// Node is a host identified as a grid node
+type Node struct {
+	Name     string   `json:"name"`
+	Metadata Metadata `json:"metadata"`
+	// Method is how the node was identified: "handshake" or "ssh"
+	Method string `json:"method"`
+	// Verified is set when the identity was proven by a certificate of the
+	// grid CA; metadata read over SSH is only as trustworthy as the host
+	Verified bool `json:"verified"`
+	// Serial is the certificate serial presented in the handshake
+	Serial string `json:"serial,omitempty"`
+	// HostKey is the SHA256 fingerprint of the SSH host key
+	HostKey string `json:"host_key,omitempty"`
+}
+
+// Identifier decides whether a host is a grid node
+type Identifier interface {
+	// Name identifies the method in results and errors
+	Name() string
+	Identify(ctx context.Context, host Host) (*Node, error)
+}
+
+// Metadata is the part of node.json used by discovery
+type Metadata struct {
+	NodeName         string `json:"node_name"`
+	Description      string `json:"description,omitempty"`
+	Role             string `json:"role,omitempty"`
+	Coordinates      string `json:"coordinates,omitempty"`
+	PlatformVersion  string `json:"platform_version,omitempty"`
+	OwnerFingerprint string `json:"owner_fingerprint,omitempty"`
+}
+
+// ParseMetadata reads a node.json document (metadata_version 2.0)
+func ParseMetadata(data []byte) (Metadata, error) {
+	var doc struct {
+		NodeInfo struct {
+			NodeName        string `json:"node_name"`
+			Description     string `json:"description"`
+			Role            string `json:"role"`
+			PlatformVersion string `json:"platform_version"`
+		} `json:"node_info"`
+		GeographicInfo struct {
+			Coordinates struct {
+				Formatted string `json:"formatted"`
+			} `json:"coordinates"`
+		} `json:"geographic_info"`
+		Security struct {
+			OwnerKeyFingerprint string `json:"owner_key_fingerprint"`
+		} `json:"security"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Metadata{}, fmt.Errorf("invalid node metadata: %w", err)
+	}
+	name := strings.TrimSpace(doc.NodeInfo.NodeName)
+	if name == "" {
+		return Metadata{}, fmt.Errorf("invalid node metadata: node_info.node_name is empty")
+	}
+	if !nodeNamePattern.MatchString(name) {
+		return Metadata{}, fmt.Errorf("invalid node metadata: node name %q", name)
+	}
+	return Metadata{
+		NodeName:         name,
+		Description:      doc.NodeInfo.Description,
+		Role:             doc.NodeInfo.Role,
+		Coordinates:      doc.GeographicInfo.Coordinates.Formatted,
+		PlatformVersion:  doc.NodeInfo.PlatformVersion,
+		OwnerFingerprint: doc.Security.OwnerKeyFingerprint,
+	}, nil
+}
+
+// Targets expands networks into the addresses to probe. Each entry is a
+// CIDR or a single address; the network and broadcast addresses of IPv4
+// ranges larger than /31 are skipped.
+func Targets(networks []string) ([]netip.Addr, error) {
+	var targets []netip.Addr
+	seen := map[netip.Addr]bool{}
+	for _, network := range networks {
+		prefix, err := parseNetwork(network)
+		if err != nil {
+			return nil, err
+		}
+		hostBits := prefix.Addr().BitLen() - prefix.Bits()
+		if hostBits >= 32 || len(targets)+(1<<hostBits) > MaxTargets+2 {
+			return nil, fmt.Errorf("network %s is too large (at most %d addresses per sweep)", network, MaxTargets)
+		}
+
+		first := prefix.Addr()
+		last := first
+		for i := 0; i < 1<<hostBits-1; i++ {
+			last = last.Next()
+		}
+		if first.Is4() && hostBits > 1 {
+			first, last = first.Next(), last.Prev()
+		}
+		for addr := first; addr.IsValid() && addr.Compare(last) <= 0; addr = addr.Next() {
+			if !seen[addr] {
+				seen[addr] = true
+				targets = append(targets, addr)
+			}
+		}
+	}
+	if len(targets) > MaxTargets {
+		return nil, fmt.Errorf("%d addresses to probe (at most %d per sweep)", len(targets), MaxTargets)
+	}
+	return targets, nil
+}
+
+func parseNetwork(network string) (netip.Prefix, error) {
+	network = strings.TrimSpace(network)
+	if !strings.Contains(network, "/") {
+		addr, err := netip.ParseAddr(network)
+		if err != nil {
+			return netip.Prefix{}, fmt.Errorf("invalid network %q: %w", network, err)
+		}
+		return netip.PrefixFrom(addr, addr.BitLen()), nil
+	}
+	prefix, err := netip.ParsePrefix(network)
+	if err != nil {
+		return netip.Prefix{}, fmt.Errorf("invalid network %q: %w", network, err)
+	}
+	return prefix.Masked(), nil
+}
@@ -0,0 +1,320 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Record types and classes used by mDNS service discovery (RFC 6763)
+const (
+	typeA    uint16 = 1
+	typePTR  uint16 = 12
+	typeTXT  uint16 = 16
+	typeAAAA uint16 = 28
+	typeSRV  uint16 = 33
+	typeANY  uint16 = 255
+
+	classIN uint16 = 1
+	// classFlag is the unicast-response bit of a question and the
+	// cache-flush bit of a record (RFC 6762 section 5.4 and 10.2)
+	classFlag uint16 = 1 << 15
+
+	flagResponse      uint16 = 1 << 15
+	flagAuthoritative uint16 = 1 << 10
+)
+
+var errTruncated = errors.New("truncated DNS message")
+
+// dnsQuestion is an entry of the question section
+type dnsQuestion struct {
+	Name  string
+	Type  uint16
+	Class uint16
+}
+
+// dnsRecord is a resource record. Only the fields of its type are set:
+// Target for PTR and SRV, Port for SRV, Text for TXT and IP for A/AAAA.
+type dnsRecord struct {
+	Name   string
+	Type   uint16
+	Class  uint16
+	TTL    uint32
+	Target string
+	Port   uint16
+	Text   []string
+	IP     net.IP
+}
+
+// dnsMessage is the subset of a DNS message used by mDNS
+type dnsMessage struct {
+	ID          uint16
+	Flags       uint16
+	Questions   []dnsQuestion
+	Answers     []dnsRecord
+	Additionals []dnsRecord
+}
+
+func (m *dnsMessage) response() bool {
+	return m.Flags&flagResponse != 0
+}
+
+// pack encodes the message without name compression
+func (m *dnsMessage) pack() ([]byte, error) {
+	b := make([]byte, 12, 512)
+	binary.BigEndian.PutUint16(b[0:], m.ID)
+	binary.BigEndian.PutUint16(b[2:], m.Flags)
+	binary.BigEndian.PutUint16(b[4:], uint16(len(m.Questions)))
+	binary.BigEndian.PutUint16(b[6:], uint16(len(m.Answers)))
+	binary.BigEndian.PutUint16(b[10:], uint16(len(m.Additionals)))
+
+	var err error
+	for _, q := range m.Questions {
+		if b, err = appendName(b, q.Name); err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint16(b, q.Type)
+		b = binary.BigEndian.AppendUint16(b, q.Class)
+	}
+	for _, section := range [][]dnsRecord{m.Answers, m.Additionals} {
+		for _, r := range section {
+			if b, err = appendRecord(b, r); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return b, nil
+}
+
+func appendRecord(b []byte, r dnsRecord) ([]byte, error) {
+	b, err := appendName(b, r.Name)
+	if err != nil {
+		return nil, err
+	}
+	b = binary.BigEndian.AppendUint16(b, r.Type)
+	b = binary.BigEndian.AppendUint16(b, r.Class)
+	b = binary.BigEndian.AppendUint32(b, r.TTL)
+
+	start := len(b)
+	b = append(b, 0, 0) // rdata length, filled below
+	switch r.Type {
+	case typePTR:
+		b, err = appendName(b, r.Target)
+	case typeSRV:
+		b = binary.BigEndian.AppendUint16(b, 0) // priority
+		b = binary.BigEndian.AppendUint16(b, 0) // weight
+		b = binary.BigEndian.AppendUint16(b, r.Port)
+		b, err = appendName(b, r.Target)
+	case typeTXT:
+		for _, s := range r.Text {
+			if len(s) > 255 {
+				return nil, fmt.Errorf("TXT string too long: %d bytes", len(s))
+			}
+			b = append(append(b, byte(len(s))), s...)
+		}
+		if len(r.Text) == 0 {
+			b = append(b, 0)
+		}
+	case typeA:
+		ip := r.IP.To4()
+		if ip == nil {
+			return nil, fmt.Errorf("not an IPv4 address: %s", r.IP)
+		}
+		b = append(b, ip...)
+	case typeAAAA:
+		b = append(b, r.IP.To16()...)
+	default:
+		return nil, fmt.Errorf("unsupported record type %d", r.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	binary.BigEndian.PutUint16(b[start:], uint16(len(b)-start-2))
+	return b, nil
+}
+
+// appendName encodes a dotted name. A label may contain dots escaped as
+// "\." (RFC 6763 section 4.3), as in instance names.
+func appendName(b []byte, name string) ([]byte, error) {
+	for _, label := range splitName(name) {
+		if len(label) == 0 || len(label) > 63 {
+			return nil, fmt.Errorf("invalid DNS name %q", name)
+		}
+		b = append(append(b, byte(len(label))), label...)
+	}
+	return append(b, 0), nil
+}
+
+func splitName(name string) []string {
+	var labels []string
+	var label strings.Builder
+	for i := 0; i < len(name); i++ {
+		switch c := name[i]; {
+		case c == '\\' && i+1 < len(name):
+			i++
+			label.WriteByte(name[i])
+		case c == '.':
+			labels = append(labels, label.String())
+			label.Reset()
+		default:
+			label.WriteByte(c)
+		}
+	}
+	if label.Len() > 0 {
+		labels = append(labels, label.String())
+	}
+	return labels
+}
+
+// escapeLabel escapes a label for use in a dotted name
+func escapeLabel(label string) string {
+	return strings.NewReplacer(`\`, `\\`, `.`, `\.`).Replace(label)
+}
+
+// unpackMessage decodes a message, following compression pointers
+func unpackMessage(b []byte) (*dnsMessage, error) {
+	if len(b) < 12 {
+		return nil, errTruncated
+	}
+	m := &dnsMessage{
+		ID:    binary.BigEndian.Uint16(b[0:]),
+		Flags: binary.BigEndian.Uint16(b[2:]),
+	}
+	counts := [4]int{}
+	for i := range counts {
+		counts[i] = int(binary.BigEndian.Uint16(b[4+2*i:]))
+	}
+
+	off := 12
+	for i := 0; i < counts[0]; i++ {
+		name, next, err := readName(b, off)
+		if err != nil {
+			return nil, err
+		}
+		if next+4 > len(b) {
+			return nil, errTruncated
+		}
+		m.Questions = append(m.Questions, dnsQuestion{
+			Name:  name,
+			Type:  binary.BigEndian.Uint16(b[next:]),
+			Class: binary.BigEndian.Uint16(b[next+2:]),
+		})
+		off = next + 4
+	}
+	// Authority records are read and dropped
+	for section := 1; section < 4; section++ {
+		for i := 0; i < counts[section]; i++ {
+			r, next, err := readRecord(b, off)
+			if err != nil {
+				return nil, err
+			}
+			off = next
+			switch {
+			case r == nil:
+			case section == 1:
+				m.Answers = append(m.Answers, *r)
+			case section == 3:
+				m.Additionals = append(m.Additionals, *r)
+			}
+		}
+	}
+	return m, nil
+}
+
+// readRecord decodes the record at off; records of other types are
+// skipped and returned as nil
+func readRecord(b []byte, off int) (*dnsRecord, int, error) {
+	name, off, err := readName(b, off)
+	if err != nil {
+		return nil, 0, err
+	}
+	if off+10 > len(b) {
+		return nil, 0, errTruncated
+	}
+	r := &dnsRecord{
+		Name:  name,
+		Type:  binary.BigEndian.Uint16(b[off:]),
+		Class: binary.BigEndian.Uint16(b[off+2:]),
+		TTL:   binary.BigEndian.Uint32(b[off+4:]),
+	}
+	length := int(binary.BigEndian.Uint16(b[off+8:]))
+	start, end := off+10, off+10+length
+	if end > len(b) {
+		return nil, 0, errTruncated
+	}
+	rdata := b[start:end]
+
+	switch r.Type {
+	case typePTR:
+		if r.Target, _, err = readName(b, start); err != nil {
+			return nil, 0, err
+		}
+	case typeSRV:
+		if length < 7 {
+			return nil, 0, errTruncated
+		}
+		r.Port = binary.BigEndian.Uint16(rdata[4:])
+		if r.Target, _, err = readName(b, start+6); err != nil {
+			return nil, 0, err
+		}
+	case typeTXT:
+		for i := 0; i < len(rdata); {
+			n := int(rdata[i])
+			if i+1+n > len(rdata) {
+				return nil, 0, errTruncated
+			}
+			if n > 0 {
+				r.Text = append(r.Text, string(rdata[i+1:i+1+n]))
+			}
+			i += 1 + n
+		}
+	case typeA, typeAAAA:
+		if (r.Type == typeA && length != net.IPv4len) || (r.Type == typeAAAA && length != net.IPv6len) {
+			return nil, 0, fmt.Errorf("invalid address record of %d bytes", length)
+		}
+		r.IP = append(net.IP(nil), rdata...)
+	default:
+		return nil, end, nil
+	}
+	return r, end, nil
+}
+
+// readName decodes the name at off and returns the offset after it
+func readName(b []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(b) {
+			return "", 0, errTruncated
+		}
+		n := int(b[off])
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case n&0xC0 == 0xC0:
+			if off+1 >= len(b) {
+				return "", 0, errTruncated
+			}
+			if jumps++; jumps > 16 {
+				return "", 0, fmt.Errorf("DNS name compression loop")
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(b[off:]) & 0x3FFF)
+		case n > 63:
+			return "", 0, fmt.Errorf("invalid DNS label length %d", n)
+		default:
+			if off+1+n > len(b) {
+				return "", 0, errTruncated
+			}
+			labels = append(labels, escapeLabel(string(b[off+1:off+1+n])))
+			off += 1 + n
+		}
+	}
+}
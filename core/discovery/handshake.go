@@ -0,0 +1,220 @@
+package discovery
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// HandshakePath is served by the agent API of every node
+	HandshakePath = "/v1/discovery"
+	// DefaultHandshakePort is the TLS port of the agent API
+	DefaultHandshakePort = 8080
+)
+
+const (
+	nonceSize       = 32
+	handshakeDomain = "syntropy-discovery-v1\n"
+	// maxHandshakeBody bounds the response read from an untrusted host
+	maxHandshakeBody = 1 << 20
+)
+
+// handshakeResponse is the body served at HandshakePath. Metadata holds
+// node.json byte for byte, so the signature can be checked on what was
+// received.
+type handshakeResponse struct {
+	Metadata  []byte `json:"metadata"`
+	Signature []byte `json:"signature"`
+}
+
+// Handshake identifies nodes by asking the agent to sign a fresh nonce
+// together with its metadata using the key of its node certificate. The
+// certificate must chain to Roots and be issued to the name in the
+// metadata, so a node cannot claim to be another one.
+type Handshake struct {
+	Port  int
+	Roots *x509.CertPool
+	// Check, if set, is given the certificate chain presented by the node
+	// in PEM after it was verified, e.g. to consult revocation
+	Check   func(chainPEM []byte) error
+	Timeout time.Duration
+}
+
+// Name implements Identifier
+func (h *Handshake) Name() string {
+	return "handshake"
+}
+
+// Identify implements Identifier
+func (h *Handshake) Identify(ctx context.Context, host Host) (*Node, error) {
+	if h.Roots == nil {
+		return nil, fmt.Errorf("no grid CA to verify nodes against")
+	}
+	port, timeout := h.Port, h.Timeout
+	if port == 0 {
+		port = DefaultHandshakePort
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	// The node is reached by address and its certificate names the node,
+	// so the chain is verified here instead of against a host name
+	var chain []*x509.Certificate
+	config := &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			if len(state.PeerCertificates) == 0 {
+				return fmt.Errorf("no certificate presented")
+			}
+			intermediates := x509.NewCertPool()
+			for _, cert := range state.PeerCertificates[1:] {
+				intermediates.AddCert(cert)
+			}
+			if _, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+				Roots:         h.Roots,
+				Intermediates: intermediates,
+				KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			}); err != nil {
+				return fmt.Errorf("certificate does not chain to the grid CA: %w", err)
+			}
+			chain = state.PeerCertificates
+			return nil
+		},
+	}
+	client := &http.Client{
+		Timeout:   timeout,
+		Transport: &http.Transport{TLSClientConfig: config, DisableKeepAlives: true},
+	}
+
+	url := fmt.Sprintf("https://%s%s?nonce=%s", net.JoinHostPort(host.Addr, strconv.Itoa(port)), HandshakePath, hex.EncodeToString(nonce))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("agent answered %s", resp.Status)
+	}
+	var body handshakeResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxHandshakeBody)).Decode(&body); err != nil {
+		return nil, fmt.Errorf("invalid handshake response: %w", err)
+	}
+
+	leaf := chain[0]
+	if err := verifySignature(leaf, handshakeMessage(nonce, body.Metadata), body.Signature); err != nil {
+		return nil, err
+	}
+	metadata, err := ParseMetadata(body.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	if leaf.Subject.CommonName != metadata.NodeName {
+		return nil, fmt.Errorf("certificate of %s presented by node %s", leaf.Subject.CommonName, metadata.NodeName)
+	}
+	if h.Check != nil {
+		var chainPEM bytes.Buffer
+		for _, cert := range chain {
+			pem.Encode(&chainPEM, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+		}
+		if err := h.Check(chainPEM.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Node{
+		Name:     metadata.NodeName,
+		Metadata: metadata,
+		Method:   h.Name(),
+		Verified: true,
+		Serial:   fmt.Sprintf("%x", leaf.SerialNumber),
+	}, nil
+}
+
+// NewHandler serves the discovery handshake for the agent. metadata is
+// the content of node.json and signer the key of the node certificate
+// presented by the TLS listener the handler is mounted on.
+func NewHandler(metadata []byte, signer crypto.Signer) (http.Handler, error) {
+	if _, err := ParseMetadata(metadata); err != nil {
+		return nil, err
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		nonce, err := hex.DecodeString(r.URL.Query().Get("nonce"))
+		if err != nil || len(nonce) != nonceSize {
+			http.Error(w, "invalid nonce", http.StatusBadRequest)
+			return
+		}
+		signature, err := sign(signer, handshakeMessage(nonce, metadata))
+		if err != nil {
+			http.Error(w, "signing failed", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(handshakeResponse{Metadata: metadata, Signature: signature})
+	}), nil
+}
+
+// handshakeMessage is what the node signs; the domain prefix keeps the
+// signature from being valid for any other protocol using the same key
+func handshakeMessage(nonce, metadata []byte) []byte {
+	message := make([]byte, 0, len(handshakeDomain)+len(nonce)+len(metadata))
+	message = append(message, handshakeDomain...)
+	message = append(message, nonce...)
+	return append(message, metadata...)
+}
+
+func sign(signer crypto.Signer, message []byte) ([]byte, error) {
+	if _, ok := signer.Public().(ed25519.PublicKey); ok {
+		return signer.Sign(rand.Reader, message, crypto.Hash(0))
+	}
+	digest := sha256.Sum256(message)
+	return signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+}
+
+func verifySignature(cert *x509.Certificate, message, signature []byte) error {
+	var algorithm x509.SignatureAlgorithm
+	switch cert.PublicKey.(type) {
+	case *ecdsa.PublicKey:
+		algorithm = x509.ECDSAWithSHA256
+	case *rsa.PublicKey:
+		algorithm = x509.SHA256WithRSA
+	case ed25519.PublicKey:
+		algorithm = x509.PureEd25519
+	default:
+		return fmt.Errorf("unsupported node key %T", cert.PublicKey)
+	}
+	if err := cert.CheckSignature(algorithm, message, signature); err != nil {
+		return fmt.Errorf("invalid handshake signature: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package discovery_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+	"syntropy-cc/cooperative-grid/core/discovery"
+)
+
+const nodeJSON = `{
+  "metadata_version": "2.0",
+  "node_info": {
+    "node_name": "node-01",
+    "description": "Rack A",
+    "role": "worker",
+    "platform_version": "2.0.0"
+  },
+  "geographic_info": {"coordinates": {"formatted": "-23.5505,-46.6333"}},
+  "security": {"owner_key_fingerprint": "SHA256:abc"}
+}`
+
+func TestParseMetadata(t *testing.T) {
+	metadata, err := discovery.ParseMetadata([]byte(nodeJSON))
+	if err != nil {
+		t.Fatalf("ParseMetadata: %v", err)
+	}
+	want := discovery.Metadata{
+		NodeName:         "node-01",
+		Description:      "Rack A",
+		Role:             "worker",
+		Coordinates:      "-23.5505,-46.6333",
+		PlatformVersion:  "2.0.0",
+		OwnerFingerprint: "SHA256:abc",
+	}
+	if metadata != want {
+		t.Errorf("metadata = %+v", metadata)
+	}
+	for _, doc := range []string{`{"node_info": {}}`, `{"node_info": {"node_name": "../../etc/passwd"}}`, `[]`} {
+		if _, err := discovery.ParseMetadata([]byte(doc)); err == nil {
+			t.Errorf("%s: expected error", doc)
+		}
+	}
+}
+
+// serveHandshake starts an agent presenting the certificate issued to
+// certName and serving metadata
+func serveHandshake(t *testing.T, authority *ca.Authority, certName, metadata string) int {
+	t.Helper()
+	issued, err := authority.Issue(ca.IssueRequest{CommonName: certName, IPAddresses: []net.IP{net.ParseIP("127.0.0.1")}})
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	cert, err := tls.X509KeyPair(issued.CertPEM, issued.KeyPEM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler, err := discovery.NewHandler([]byte(metadata), cert.PrivateKey.(crypto.Signer))
+	if err != nil {
+		t.Fatalf("NewHandler: %v", err)
+	}
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server.Listener.Addr().(*net.TCPAddr).Port
+}
+
+func roots(authority *ca.Authority) *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(authority.RootPEM())
+	return pool
+}
+
+func TestHandshake(t *testing.T) {
+	authority, err := ca.Init(t.TempDir(), ca.Options{})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	port := serveHandshake(t, authority, "node-01", nodeJSON)
+	host := discovery.Host{Addr: "127.0.0.1", Port: 22}
+
+	handshake := &discovery.Handshake{
+		Port:    port,
+		Roots:   roots(authority),
+		Timeout: 2 * time.Second,
+		Check: func(chainPEM []byte) error {
+			_, err := authority.Verify(chainPEM)
+			return err
+		},
+	}
+	node, err := handshake.Identify(context.Background(), host)
+	if err != nil {
+		t.Fatalf("Identify: %v", err)
+	}
+	if node.Name != "node-01" || !node.Verified || node.Method != "handshake" || node.Metadata.Role != "worker" {
+		t.Errorf("node = %+v", node)
+	}
+	current, err := authority.Current("node-01")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Serial != current.Serial {
+		t.Errorf("serial = %s, want %s", node.Serial, current.Serial)
+	}
+
+	// A revoked certificate is refused through the Check hook
+	if _, err := authority.Revoke(current.Serial, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := handshake.Identify(context.Background(), host); err == nil {
+		t.Error("expected error for a revoked certificate")
+	}
+}
+
+func TestHandshakeRejects(t *testing.T) {
+	authority, err := ca.Init(t.TempDir(), ca.Options{})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	other, err := ca.Init(t.TempDir(), ca.Options{})
+	if err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	host := discovery.Host{Addr: "127.0.0.1"}
+
+	// node-02 claiming to be node-01
+	impostor := serveHandshake(t, authority, "node-02", nodeJSON)
+	handshake := &discovery.Handshake{Port: impostor, Roots: roots(authority)}
+	if _, err := handshake.Identify(context.Background(), host); err == nil || !strings.Contains(err.Error(), "node-02") {
+		t.Errorf("impostor: %v", err)
+	}
+
+	// A certificate from another authority
+	foreign := serveHandshake(t, other, "node-01", nodeJSON)
+	handshake = &discovery.Handshake{Port: foreign, Roots: roots(authority)}
+	if _, err := handshake.Identify(context.Background(), host); err == nil {
+		t.Error("expected error for a foreign CA")
+	}
+
+	handshake = &discovery.Handshake{Port: foreign}
+	if _, err := handshake.Identify(context.Background(), host); err == nil {
+		t.Error("expected error without roots")
+	}
+}
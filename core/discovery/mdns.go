@@ -0,0 +1,485 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// ServiceType is the DNS-SD service announced by grid nodes
+	ServiceType = "_syntropy._tcp"
+	// DefaultTTL is how long browsers may cache an announcement
+	DefaultTTL = 120 * time.Second
+
+	serviceName  = ServiceType + ".local."
+	servicesName = "_services._dns-sd._udp.local."
+	// legacyTTL caps the TTL of answers to queriers that are not full mDNS
+	// implementations (RFC 6762 section 6.7)
+	legacyTTL = 10
+	maxPacket = 9000
+)
+
+// MDNSGroup is the IPv4 mDNS multicast group
+var MDNSGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service is a node announced over mDNS. Anyone on the link can announce
+// anything, so a service only says where to look: the node still has to
+// be identified.
+type Service struct {
+	// Instance is the node name
+	Instance string
+	// Host defaults to the instance name in the .local domain
+	Host  string
+	Port  int
+	Addrs []net.IP
+	// Metadata carries the TXT record: node name, owner fingerprint, role
+	// and version
+	Metadata Metadata
+}
+
+// Node returns the unverified node a service claims to be
+func (s Service) Node() *Node {
+	return &Node{Name: s.Metadata.NodeName, Metadata: s.Metadata, Method: "mdns"}
+}
+
+func (s Service) instanceName() string {
+	return escapeLabel(s.Instance) + "." + serviceName
+}
+
+func (s Service) hostName() string {
+	if s.Host == "" {
+		return strings.ReplaceAll(s.Instance, ".", "-") + ".local."
+	}
+	return strings.TrimSuffix(s.Host, ".") + "."
+}
+
+func (s Service) txt() []string {
+	name := s.Metadata.NodeName
+	if name == "" {
+		name = s.Instance
+	}
+	txt := []string{"txtvers=1", "name=" + name}
+	for key, value := range map[string]string{
+		"owner":   s.Metadata.OwnerFingerprint,
+		"role":    s.Metadata.Role,
+		"version": s.Metadata.PlatformVersion,
+	} {
+		if value != "" {
+			txt = append(txt, key+"="+value)
+		}
+	}
+	sort.Strings(txt[2:])
+	return txt
+}
+
+// parseTXT reads the TXT record of a service. Keys are case-insensitive
+// and only the first occurrence counts (RFC 6763 section 6.4).
+func parseTXT(text []string) Metadata {
+	values := map[string]string{}
+	for _, s := range text {
+		key, value, _ := strings.Cut(s, "=")
+		key = strings.ToLower(key)
+		if _, ok := values[key]; !ok {
+			values[key] = value
+		}
+	}
+	return Metadata{
+		NodeName:         values["name"],
+		OwnerFingerprint: values["owner"],
+		Role:             values["role"],
+		PlatformVersion:  values["version"],
+	}
+}
+
+// Responder announces a node over mDNS and answers queries for it. It is
+// meant to run inside the agent for as long as the node is up.
+type Responder struct {
+	Service Service
+	// Group defaults to MDNSGroup
+	Group *net.UDPAddr
+	// Interface defaults to the one chosen by the system
+	Interface *net.Interface
+	// TTL defaults to DefaultTTL
+	TTL time.Duration
+}
+
+// responderRecords are the records describing the service
+type responderRecords struct {
+	services, ptr, srv, txt dnsRecord
+	addrs                   []dnsRecord
+}
+
+func (r *Responder) records(ttl uint32) (*responderRecords, error) {
+	s := r.Service
+	if !nodeNamePattern.MatchString(s.Instance) {
+		return nil, fmt.Errorf("invalid instance name %q", s.Instance)
+	}
+	if s.Port <= 0 || s.Port > 65535 {
+		return nil, fmt.Errorf("invalid service port %d", s.Port)
+	}
+	// Unique records carry the cache-flush bit (RFC 6762 section 10.2)
+	recs := &responderRecords{
+		services: dnsRecord{Name: servicesName, Type: typePTR, Class: classIN, TTL: ttl, Target: serviceName},
+		ptr:      dnsRecord{Name: serviceName, Type: typePTR, Class: classIN, TTL: ttl, Target: s.instanceName()},
+		srv:      dnsRecord{Name: s.instanceName(), Type: typeSRV, Class: classIN | classFlag, TTL: ttl, Target: s.hostName(), Port: uint16(s.Port)},
+		txt:      dnsRecord{Name: s.instanceName(), Type: typeTXT, Class: classIN | classFlag, TTL: ttl, Text: s.txt()},
+	}
+	for _, ip := range s.Addrs {
+		rtype := typeAAAA
+		if ip.To4() != nil {
+			rtype = typeA
+		}
+		recs.addrs = append(recs.addrs, dnsRecord{Name: s.hostName(), Type: rtype, Class: classIN | classFlag, TTL: ttl, IP: ip})
+	}
+	return recs, nil
+}
+
+// answer builds the answers to questions, with the records a browser will
+// need next as additionals
+func (recs *responderRecords) answer(questions []dnsQuestion) (answers, additionals []dnsRecord) {
+	wants := func(q dnsQuestion, name string, rtype uint16) bool {
+		return strings.EqualFold(q.Name, name) && (q.Type == rtype || q.Type == typeANY)
+	}
+	for _, q := range questions {
+		if wants(q, servicesName, typePTR) {
+			answers = append(answers, recs.services)
+		}
+		if wants(q, serviceName, typePTR) {
+			answers = append(answers, recs.ptr)
+			additionals = append(additionals, recs.srv, recs.txt)
+			additionals = append(additionals, recs.addrs...)
+		}
+		if wants(q, recs.srv.Name, typeSRV) {
+			answers = append(answers, recs.srv)
+			additionals = append(additionals, recs.addrs...)
+		}
+		if wants(q, recs.txt.Name, typeTXT) {
+			answers = append(answers, recs.txt)
+		}
+		for _, addr := range recs.addrs {
+			if wants(q, addr.Name, addr.Type) {
+				answers = append(answers, addr)
+			}
+		}
+	}
+	return dedupe(answers, nil), dedupe(additionals, answers)
+}
+
+// dedupe drops repeated records and those already in seen
+func dedupe(records, seen []dnsRecord) []dnsRecord {
+	var out []dnsRecord
+	has := func(list []dnsRecord, r dnsRecord) bool {
+		for _, other := range list {
+			if other.Type == r.Type && other.Name == r.Name && other.IP.Equal(r.IP) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, r := range records {
+		if !has(seen, r) && !has(out, r) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Serve announces the service and answers queries until ctx is done, then
+// says goodbye so browsers drop it at once (RFC 6762 section 10.1)
+func (r *Responder) Serve(ctx context.Context) error {
+	group := r.Group
+	if group == nil {
+		group = MDNSGroup
+	}
+	ttl := r.TTL
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	recs, err := r.records(uint32(ttl / time.Second))
+	if err != nil {
+		return err
+	}
+	goodbye, err := r.records(0)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.ListenMulticastUDP("udp4", r.Interface, group)
+	if err != nil {
+		return fmt.Errorf("failed to join mDNS group %s: %w", group, err)
+	}
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-ctx.Done():
+			announce(conn, group, goodbye)
+		case <-stopped:
+		}
+		conn.Close()
+	}()
+
+	// Unsolicited announcements, one second apart (RFC 6762 section 8.3)
+	announce(conn, group, recs)
+	again := time.AfterFunc(time.Second, func() { announce(conn, group, recs) })
+	defer again.Stop()
+
+	buf := make([]byte, maxPacket)
+	for {
+		n, src, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		query, err := unpackMessage(buf[:n])
+		if err != nil || query.response() {
+			continue
+		}
+		answers, additionals := recs.answer(query.Questions)
+		if len(answers) == 0 {
+			continue
+		}
+
+		reply := &dnsMessage{Flags: flagResponse | flagAuthoritative, Answers: answers, Additionals: additionals}
+		dest := group
+		switch {
+		case src.Port != group.Port:
+			// A one-shot querier such as dig expects a plain unicast DNS
+			// answer to its own port
+			reply.ID, reply.Questions = query.ID, query.Questions
+			reply.Answers, reply.Additionals = legacy(answers), legacy(additionals)
+			dest = src
+		case unicastRequested(query.Questions):
+			dest = src
+		}
+		if packet, err := reply.pack(); err == nil {
+			conn.WriteToUDP(packet, dest)
+		}
+	}
+}
+
+func announce(conn *net.UDPConn, group *net.UDPAddr, recs *responderRecords) {
+	msg := &dnsMessage{
+		Flags:       flagResponse | flagAuthoritative,
+		Answers:     []dnsRecord{recs.ptr, recs.srv, recs.txt},
+		Additionals: recs.addrs,
+	}
+	if packet, err := msg.pack(); err == nil {
+		conn.WriteToUDP(packet, group)
+	}
+}
+
+func unicastRequested(questions []dnsQuestion) bool {
+	for _, q := range questions {
+		if q.Class&classFlag != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// legacy strips mDNS-only bits from records sent to a unicast querier
+func legacy(records []dnsRecord) []dnsRecord {
+	out := make([]dnsRecord, len(records))
+	for i, r := range records {
+		r.Class &^= classFlag
+		if r.TTL > legacyTTL {
+			r.TTL = legacyTTL
+		}
+		out[i] = r
+	}
+	return out
+}
+
+// Browser looks for grid nodes announced over mDNS
+type Browser struct {
+	// Group defaults to MDNSGroup
+	Group *net.UDPAddr
+	// Interface defaults to the one chosen by the system
+	Interface *net.Interface
+}
+
+// Browse queries for the service and collects answers and announcements
+// for wait, or until ctx is done. Services that said goodbye meanwhile are
+// left out.
+func (b *Browser) Browse(ctx context.Context, wait time.Duration) ([]Service, error) {
+	group := b.Group
+	if group == nil {
+		group = MDNSGroup
+	}
+	// Announcements and goodbyes arrive on the group. Queries go out from
+	// a socket of their own, since the group socket is bound to the group
+	// address, and responders answer them by unicast (RFC 6762 section 6.7).
+	listener, err := net.ListenMulticastUDP("udp4", b.Interface, group)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join mDNS group %s: %w", group, err)
+	}
+	sender, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	browseCtx, cancel := context.WithTimeout(ctx, wait)
+	var wg sync.WaitGroup
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		<-browseCtx.Done()
+		listener.Close()
+		sender.Close()
+	}()
+
+	query, err := (&dnsMessage{Questions: []dnsQuestion{{Name: serviceName, Type: typePTR, Class: classIN}}}).pack()
+	if err != nil {
+		return nil, err
+	}
+	// The query is repeated with growing intervals in case a packet is
+	// lost (RFC 6762 section 5.2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for interval := time.Second; ; interval *= 2 {
+			sender.WriteToUDP(query, group)
+			select {
+			case <-browseCtx.Done():
+				return
+			case <-time.After(interval):
+			}
+		}
+	}()
+
+	var mu sync.Mutex
+	found := newBrowseResults()
+	errs := make(chan error, 2)
+	read := func(conn *net.UDPConn) {
+		buf := make([]byte, maxPacket)
+		for {
+			n, src, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				if browseCtx.Err() != nil {
+					err = nil
+				}
+				errs <- err
+				return
+			}
+			if msg, err := unpackMessage(buf[:n]); err == nil && msg.response() {
+				mu.Lock()
+				found.add(msg, src.IP)
+				mu.Unlock()
+			}
+		}
+	}
+	go read(listener)
+	go read(sender)
+
+	var readErr error
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil && readErr == nil {
+			readErr = err
+			cancel()
+		}
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return found.services(), ctx.Err()
+}
+
+// browseResults accumulates the records seen while browsing, keyed by
+// lower-case name
+type browseResults struct {
+	instances map[string]bool
+	srv       map[string]dnsRecord
+	txt       map[string][]string
+	addrs     map[string][]net.IP
+	sources   map[string]net.IP
+}
+
+func newBrowseResults() *browseResults {
+	return &browseResults{
+		instances: map[string]bool{},
+		srv:       map[string]dnsRecord{},
+		txt:       map[string][]string{},
+		addrs:     map[string][]net.IP{},
+		sources:   map[string]net.IP{},
+	}
+}
+
+func (b *browseResults) add(msg *dnsMessage, source net.IP) {
+	for _, r := range append(msg.Answers, msg.Additionals...) {
+		name := strings.ToLower(r.Name)
+		switch r.Type {
+		case typePTR:
+			if name != serviceName {
+				continue
+			}
+			instance := strings.ToLower(r.Target)
+			if r.TTL == 0 {
+				delete(b.instances, instance)
+			} else {
+				b.instances[instance] = true
+			}
+		case typeSRV:
+			b.srv[name] = r
+			b.sources[name] = source
+		case typeTXT:
+			b.txt[name] = r.Text
+		case typeA, typeAAAA:
+			if r.TTL == 0 {
+				continue
+			}
+			known := false
+			for _, ip := range b.addrs[name] {
+				known = known || ip.Equal(r.IP)
+			}
+			if !known {
+				b.addrs[name] = append(b.addrs[name], r.IP)
+			}
+		}
+	}
+}
+
+func (b *browseResults) services() []Service {
+	var services []Service
+	for instance := range b.instances {
+		srv, ok := b.srv[instance]
+		if !ok {
+			continue
+		}
+		labels := splitName(srv.Name)
+		metadata := parseTXT(b.txt[instance])
+		if metadata.NodeName == "" {
+			metadata.NodeName = labels[0]
+		}
+		if !nodeNamePattern.MatchString(metadata.NodeName) {
+			continue
+		}
+		addrs := b.addrs[strings.ToLower(srv.Target)]
+		if len(addrs) == 0 {
+			addrs = []net.IP{b.sources[instance]}
+		}
+		services = append(services, Service{
+			Instance: labels[0],
+			Host:     srv.Target,
+			Port:     int(srv.Port),
+			Addrs:    addrs,
+			Metadata: metadata,
+		})
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Instance < services[j].Instance
+	})
+	return services
+}
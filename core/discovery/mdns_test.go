@@ -0,0 +1,235 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDNSMessage(t *testing.T) {
+	msg := &dnsMessage{
+		ID:        7,
+		Flags:     flagResponse | flagAuthoritative,
+		Questions: []dnsQuestion{{Name: serviceName, Type: typePTR, Class: classIN | classFlag}},
+		Answers: []dnsRecord{
+			{Name: serviceName, Type: typePTR, Class: classIN, TTL: 120, Target: `rack\.a._syntropy._tcp.local.`},
+			{Name: `rack\.a._syntropy._tcp.local.`, Type: typeSRV, Class: classIN | classFlag, TTL: 120, Target: "rack-a.local.", Port: 8080},
+			{Name: `rack\.a._syntropy._tcp.local.`, Type: typeTXT, Class: classIN | classFlag, TTL: 120, Text: []string{"txtvers=1", "name=rack.a"}},
+		},
+		Additionals: []dnsRecord{
+			{Name: "rack-a.local.", Type: typeA, Class: classIN | classFlag, TTL: 120, IP: net.IPv4(192, 0, 2, 10).To4()},
+			{Name: "rack-a.local.", Type: typeAAAA, Class: classIN | classFlag, TTL: 120, IP: net.ParseIP("fd00::10")},
+		},
+	}
+	packet, err := msg.pack()
+	if err != nil {
+		t.Fatalf("pack: %v", err)
+	}
+	got, err := unpackMessage(packet)
+	if err != nil {
+		t.Fatalf("unpack: %v", err)
+	}
+	if !reflect.DeepEqual(got, msg) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", got, msg)
+	}
+	if labels := splitName(msg.Answers[0].Target); labels[0] != "rack.a" || len(labels) != 4 {
+		t.Errorf("splitName = %q", labels)
+	}
+
+	for i := 12; i < len(packet); i++ {
+		if _, err := unpackMessage(packet[:i]); err == nil {
+			t.Fatalf("truncated at %d: expected error", i)
+		}
+	}
+}
+
+func TestDNSCompression(t *testing.T) {
+	// A PTR answer whose target points back at the owner name, as sent by
+	// Avahi and Bonjour
+	packet := []byte{0, 0, 0x84, 0, 0, 0, 0, 1, 0, 0, 0, 0}
+	packet = append(packet, 9, '_', 's', 'y', 'n', 't', 'r', 'o', 'p', 'y', 4, '_', 't', 'c', 'p', 5, 'l', 'o', 'c', 'a', 'l', 0)
+	packet = append(packet, 0, 12, 0, 1, 0, 0, 0, 120, 0, 10)
+	packet = append(packet, 7, 'n', 'o', 'd', 'e', '-', '0', '1', 0xC0, 12)
+	msg, err := unpackMessage(packet)
+	if err != nil {
+		t.Fatalf("unpack: %v", err)
+	}
+	if len(msg.Answers) != 1 || msg.Answers[0].Target != "node-01."+serviceName {
+		t.Errorf("answers = %+v", msg.Answers)
+	}
+
+	loop := []byte{0, 0, 0x84, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0xC0, 12, 0, 12, 0, 1}
+	if _, err := unpackMessage(loop); err == nil {
+		t.Error("expected error for a compression loop")
+	}
+}
+
+func testService() Service {
+	return Service{
+		Instance: "node-01",
+		Port:     8080,
+		Addrs:    []net.IP{net.IPv4(192, 0, 2, 10)},
+		Metadata: Metadata{Role: "leader", OwnerFingerprint: "SHA256:abc", PlatformVersion: "2.0.0"},
+	}
+}
+
+func TestResponderAnswer(t *testing.T) {
+	recs, err := (&Responder{Service: testService()}).records(120)
+	if err != nil {
+		t.Fatalf("records: %v", err)
+	}
+
+	answers, additionals := recs.answer([]dnsQuestion{{Name: "_SYNTROPY._tcp.local.", Type: typePTR, Class: classIN}})
+	if len(answers) != 1 || answers[0].Target != "node-01."+serviceName {
+		t.Errorf("PTR answers = %+v", answers)
+	}
+	if len(additionals) != 3 || additionals[0].Port != 8080 || additionals[2].Name != "node-01.local." {
+		t.Errorf("PTR additionals = %+v", additionals)
+	}
+	if want := []string{"txtvers=1", "name=node-01", "owner=SHA256:abc", "role=leader", "version=2.0.0"}; !reflect.DeepEqual(additionals[1].Text, want) {
+		t.Errorf("TXT = %q", additionals[1].Text)
+	}
+
+	answers, _ = recs.answer([]dnsQuestion{{Name: "node-01.local.", Type: typeANY, Class: classIN}})
+	if len(answers) != 1 || !answers[0].IP.Equal(net.IPv4(192, 0, 2, 10)) {
+		t.Errorf("host answers = %+v", answers)
+	}
+	if answers, _ = recs.answer([]dnsQuestion{{Name: "_http._tcp.local.", Type: typePTR, Class: classIN}}); len(answers) != 0 {
+		t.Errorf("foreign service answered: %+v", answers)
+	}
+
+	if _, err := (&Responder{Service: Service{Instance: "../x", Port: 8080}}).records(120); err == nil {
+		t.Error("expected error for an invalid instance")
+	}
+}
+
+// testGroup returns the mDNS group on a free port, skipping the test when
+// multicast does not loop back on this machine
+func testGroup(t *testing.T) *net.UDPAddr {
+	t.Helper()
+	free, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		t.Skipf("no UDP: %v", err)
+	}
+	group := &net.UDPAddr{IP: MDNSGroup.IP, Port: free.LocalAddr().(*net.UDPAddr).Port}
+	free.Close()
+
+	listener, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		t.Skipf("no multicast: %v", err)
+	}
+	defer listener.Close()
+	probe, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		t.Skipf("no UDP: %v", err)
+	}
+	defer probe.Close()
+	if _, err := probe.WriteToUDP([]byte("probe"), group); err != nil {
+		t.Skipf("no multicast route: %v", err)
+	}
+	listener.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+	if _, _, err := listener.ReadFromUDP(make([]byte, 16)); err != nil {
+		t.Skipf("multicast does not loop back: %v", err)
+	}
+	return group
+}
+
+func serveResponder(t *testing.T, group *net.UDPAddr) context.CancelFunc {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- (&Responder{Service: testService(), Group: group}).Serve(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	})
+	return cancel
+}
+
+func TestBrowse(t *testing.T) {
+	group := testGroup(t)
+	serveResponder(t, group)
+
+	services, err := (&Browser{Group: group}).Browse(context.Background(), 1500*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Browse: %v", err)
+	}
+	if len(services) != 1 {
+		t.Fatalf("got %d services, want 1: %+v", len(services), services)
+	}
+	s := services[0]
+	if s.Instance != "node-01" || s.Host != "node-01.local." || s.Port != 8080 {
+		t.Errorf("service = %+v", s)
+	}
+	if len(s.Addrs) != 1 || !s.Addrs[0].Equal(net.IPv4(192, 0, 2, 10)) {
+		t.Errorf("addrs = %v", s.Addrs)
+	}
+	want := Metadata{NodeName: "node-01", Role: "leader", OwnerFingerprint: "SHA256:abc", PlatformVersion: "2.0.0"}
+	if s.Metadata != want {
+		t.Errorf("metadata = %+v", s.Metadata)
+	}
+	if node := s.Node(); node.Verified || node.Method != "mdns" || node.Name != "node-01" {
+		t.Errorf("node = %+v", node)
+	}
+}
+
+func TestBrowseGoodbye(t *testing.T) {
+	group := testGroup(t)
+
+	results := make(chan []Service, 1)
+	go func() {
+		services, _ := (&Browser{Group: group}).Browse(context.Background(), 1500*time.Millisecond)
+		results <- services
+	}()
+	time.Sleep(100 * time.Millisecond)
+	stop := serveResponder(t, group)
+	time.Sleep(500 * time.Millisecond)
+	stop()
+
+	if services := <-results; len(services) != 0 {
+		t.Errorf("service still listed after goodbye: %+v", services)
+	}
+}
+
+func TestLegacyQuery(t *testing.T) {
+	group := testGroup(t)
+	serveResponder(t, group)
+
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	query, _ := (&dnsMessage{ID: 42, Questions: []dnsQuestion{{Name: serviceName, Type: typePTR, Class: classIN}}}).pack()
+
+	// The responder may still be joining the group
+	buf := make([]byte, maxPacket)
+	for attempt := 0; attempt < 5; attempt++ {
+		conn.WriteToUDP(query, group)
+		conn.SetReadDeadline(time.Now().Add(300 * time.Millisecond))
+		n, _, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			continue
+		}
+		reply, err := unpackMessage(buf[:n])
+		if err != nil {
+			t.Fatalf("unpack: %v", err)
+		}
+		if reply.ID != 42 || len(reply.Questions) != 1 || len(reply.Answers) != 1 {
+			t.Fatalf("reply = %+v", reply)
+		}
+		for _, r := range append(reply.Answers, reply.Additionals...) {
+			if r.TTL > legacyTTL || r.Class&classFlag != 0 {
+				t.Errorf("legacy record %+v", r)
+			}
+		}
+		return
+	}
+	t.Fatal("no unicast reply")
+}
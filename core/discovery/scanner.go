@@ -0,0 +1,164 @@
+package discovery
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultPort is the port probed on every address
+	DefaultPort = 22
+	// DefaultTimeout bounds the connection to each address
+	DefaultTimeout = 2 * time.Second
+	// DefaultWorkers is how many addresses are probed at once
+	DefaultWorkers = 64
+)
+
+// Scanner sweeps address ranges with a bounded pool of workers
+type Scanner struct {
+	Port    int
+	Timeout time.Duration
+	Workers int
+	// Identifiers are tried in order on every host that answered with an
+	// SSH banner; the first one to recognise the host wins
+	Identifiers []Identifier
+	// Progress, if set, is called after each address is probed
+	Progress func(done, total int)
+}
+
+// Discover probes every address of networks and returns the hosts that
+// answered, sorted by address. Cancelling ctx stops the sweep and returns
+// what was found so far together with the context error.
+func (s *Scanner) Discover(ctx context.Context, networks []string) ([]Host, error) {
+	targets, err := Targets(networks)
+	if err != nil {
+		return nil, err
+	}
+	return s.Probe(ctx, targets)
+}
+
+// Probe is Discover for a list of addresses, such as those announced over
+// mDNS
+func (s *Scanner) Probe(ctx context.Context, targets []netip.Addr) ([]Host, error) {
+	port, timeout, workers := s.Port, s.Timeout, s.Workers
+	if port == 0 {
+		port = DefaultPort
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	if workers > len(targets) {
+		workers = len(targets)
+	}
+
+	jobs := make(chan netip.Addr)
+	var (
+		mu    sync.Mutex
+		hosts []Host
+		done  int
+		wg    sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for addr := range jobs {
+				host, ok := s.probe(ctx, addr, port, timeout)
+				if ok && host.SSH {
+					s.identify(ctx, &host)
+				}
+				mu.Lock()
+				if ok {
+					hosts = append(hosts, host)
+				}
+				done++
+				if s.Progress != nil {
+					s.Progress(done, len(targets))
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for _, addr := range targets {
+		select {
+		case jobs <- addr:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Slice(hosts, func(i, j int) bool {
+		a, _ := netip.ParseAddr(hosts[i].Addr)
+		b, _ := netip.ParseAddr(hosts[j].Addr)
+		return a.Less(b)
+	})
+	return hosts, ctx.Err()
+}
+
+// probe connects to addr and reads the SSH banner, if any
+func (s *Scanner) probe(ctx context.Context, addr netip.Addr, port int, timeout time.Duration) (Host, bool) {
+	address := net.JoinHostPort(addr.String(), strconv.Itoa(port))
+	dialer := net.Dialer{Timeout: timeout}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return Host{}, false
+	}
+	defer conn.Close()
+
+	host := Host{Addr: addr.String(), Port: port, Latency: time.Since(start)}
+	conn.SetReadDeadline(time.Now().Add(timeout))
+	if banner, err := readBanner(conn); err == nil {
+		host.SSH = true
+		host.Banner = banner
+	}
+	return host, true
+}
+
+// readBanner reads the SSH identification line. RFC 4253 lets the server
+// send other lines before it.
+func readBanner(conn net.Conn) (string, error) {
+	reader := bufio.NewReaderSize(conn, 256)
+	for i := 0; i < 10; i++ {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if strings.HasPrefix(line, "SSH-") {
+			return line, nil
+		}
+	}
+	return "", fmt.Errorf("no SSH banner")
+}
+
+// identify tries each identifier in turn and records the result in host
+func (s *Scanner) identify(ctx context.Context, host *Host) {
+	var failures []string
+	for _, identifier := range s.Identifiers {
+		node, err := identifier.Identify(ctx, *host)
+		if err == nil {
+			host.Node = node
+			host.Error = ""
+			return
+		}
+		failures = append(failures, fmt.Sprintf("%s: %v", identifier.Name(), err))
+	}
+	host.Error = strings.Join(failures, "; ")
+}
@@ -0,0 +1,128 @@
+package discovery_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/discovery"
+)
+
+func TestTargets(t *testing.T) {
+	for _, tc := range []struct {
+		networks []string
+		want     []string
+	}{
+		{[]string{"192.168.1.0/30"}, []string{"192.168.1.1", "192.168.1.2"}},
+		{[]string{"10.0.0.7/29"}, []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5", "10.0.0.6"}},
+		{[]string{"10.0.0.4/31"}, []string{"10.0.0.4", "10.0.0.5"}},
+		{[]string{"10.0.0.9", "10.0.0.8/30"}, []string{"10.0.0.9", "10.0.0.10"}},
+		{[]string{"fd00::/127"}, []string{"fd00::", "fd00::1"}},
+	} {
+		got, err := discovery.Targets(tc.networks)
+		if err != nil {
+			t.Errorf("%v: %v", tc.networks, err)
+			continue
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("%v: got %v, want %v", tc.networks, got, tc.want)
+		}
+	}
+
+	for _, networks := range [][]string{{"10.0.0.0/8"}, {"fd00::/64"}, {"10.0.0.0/16", "10.1.0.0/24"}, {"not-a-network"}} {
+		if _, err := discovery.Targets(networks); err == nil {
+			t.Errorf("%v: expected error", networks)
+		}
+	}
+}
+
+// serve accepts connections on addr and answers each with greeting
+func serve(t *testing.T, addr, greeting string) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", addr, err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte(greeting))
+			conn.Close()
+		}
+	}()
+	return l
+}
+
+// fakeIdentifier recognises a single address
+type fakeIdentifier struct {
+	addr  string
+	calls atomic.Int32
+}
+
+func (f *fakeIdentifier) Name() string { return "fake" }
+
+func (f *fakeIdentifier) Identify(ctx context.Context, host discovery.Host) (*discovery.Node, error) {
+	f.calls.Add(1)
+	if host.Addr != f.addr {
+		return nil, fmt.Errorf("not a node")
+	}
+	return &discovery.Node{Name: "node-01", Method: "fake"}, nil
+}
+
+func TestDiscover(t *testing.T) {
+	ssh := serve(t, "127.0.0.2:0", "SSH-2.0-OpenSSH_9.6\r\n")
+	port := ssh.Addr().(*net.TCPAddr).Port
+	serve(t, net.JoinHostPort("127.0.0.3", strconv.Itoa(port)), "HTTP/1.1 400 Bad Request\r\n\r\n")
+	serve(t, net.JoinHostPort("127.0.0.5", strconv.Itoa(port)), "SSH-2.0-dropbear\r\n")
+
+	identifier := &fakeIdentifier{addr: "127.0.0.2"}
+	var progress atomic.Int32
+	scanner := &discovery.Scanner{
+		Port:        port,
+		Timeout:     time.Second,
+		Workers:     3,
+		Identifiers: []discovery.Identifier{identifier},
+		Progress:    func(done, total int) { progress.Add(1) },
+	}
+	hosts, err := scanner.Discover(context.Background(), []string{"127.0.0.0/29"})
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+
+	if len(hosts) != 3 {
+		t.Fatalf("got %d hosts, want 3: %+v", len(hosts), hosts)
+	}
+	if h := hosts[0]; h.Addr != "127.0.0.2" || !h.SSH || h.Banner != "SSH-2.0-OpenSSH_9.6" || h.Node == nil || h.Node.Name != "node-01" {
+		t.Errorf("ssh node = %+v", h)
+	}
+	if h := hosts[1]; h.Addr != "127.0.0.3" || h.SSH || h.Node != nil {
+		t.Errorf("non-ssh host = %+v", h)
+	}
+	if h := hosts[2]; h.Addr != "127.0.0.5" || !h.SSH || h.Node != nil || h.Error != "fake: not a node" {
+		t.Errorf("unidentified host = %+v", h)
+	}
+	// Only hosts with an SSH banner are identified
+	if got := identifier.calls.Load(); got != 2 {
+		t.Errorf("identifier called %d times, want 2", got)
+	}
+	if got := progress.Load(); got != 6 {
+		t.Errorf("progress called %d times, want 6", got)
+	}
+}
+
+func TestDiscoverCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scanner := &discovery.Scanner{Port: 1, Timeout: time.Second}
+	if _, err := scanner.Discover(ctx, []string{"127.0.0.0/24"}); err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
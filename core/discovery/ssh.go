@@ -0,0 +1,111 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxKeysPerAttempt stays under the default MaxAuthTries of sshd, which
+// drops the connection after six failed keys
+const maxKeysPerAttempt = 5
+
+// SSHIdentifier identifies nodes by reading node.json over SSH. Whoever
+// controls the host controls that file, so the nodes it finds are not
+// verified; the host key fingerprint is recorded for later pinning.
+type SSHIdentifier struct {
+	Users   []string
+	Signers []ssh.Signer
+	// HostKeyCallback defaults to accepting any key
+	HostKeyCallback ssh.HostKeyCallback
+	Timeout         time.Duration
+}
+
+// Name implements Identifier
+func (s *SSHIdentifier) Name() string {
+	return "ssh"
+}
+
+// Identify implements Identifier
+func (s *SSHIdentifier) Identify(ctx context.Context, host Host) (*Node, error) {
+	if len(s.Users) == 0 || len(s.Signers) == 0 {
+		return nil, fmt.Errorf("no SSH user or key configured")
+	}
+	var lastErr error
+	for _, user := range s.Users {
+		for start := 0; start < len(s.Signers); start += maxKeysPerAttempt {
+			signers := s.Signers[start:min(start+maxKeysPerAttempt, len(s.Signers))]
+			node, err := s.identify(ctx, host, user, signers)
+			if err == nil {
+				return node, nil
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			lastErr = err
+		}
+	}
+	return nil, lastErr
+}
+
+func (s *SSHIdentifier) identify(ctx context.Context, host Host, user string, signers []ssh.Signer) (*Node, error) {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	var hostKey ssh.PublicKey
+	config := &ssh.ClientConfig{
+		User: user,
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			if s.HostKeyCallback != nil {
+				return s.HostKeyCallback(hostname, remote, key)
+			}
+			return nil
+		},
+		Timeout: timeout,
+	}
+
+	address := net.JoinHostPort(host.Addr, strconv.Itoa(host.Port))
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	// The deadline covers the handshake and the command
+	conn.SetDeadline(time.Now().Add(2 * timeout))
+
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		return nil, err
+	}
+	client := ssh.NewClient(clientConn, chans, reqs)
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+	output, err := session.Output("cat " + MetadataPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s as %s: %w", MetadataPath, user, err)
+	}
+	metadata, err := ParseMetadata(output)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Node{
+		Name:     metadata.NodeName,
+		Metadata: metadata,
+		Method:   s.Name(),
+		HostKey:  ssh.FingerprintSHA256(hostKey),
+	}, nil
+}
@@ -0,0 +1,133 @@
+package discovery_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"syntropy-cc/cooperative-grid/core/discovery"
+)
+
+func newSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// serveSSH starts an SSH server that lets user in with key and answers
+// "cat <MetadataPath>" with metadata. It drops the connection after six
+// failed keys, like sshd.
+func serveSSH(t *testing.T, user string, key ssh.PublicKey, hostKey ssh.Signer, metadata string) int {
+	t.Helper()
+	config := &ssh.ServerConfig{
+		MaxAuthTries: 6,
+		PublicKeyCallback: func(conn ssh.ConnMetadata, offered ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == user && string(offered.Marshal()) == string(key.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key")
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go handleSSH(conn, config, metadata)
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func handleSSH(conn net.Conn, config *ssh.ServerConfig, metadata string) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		for req := range requests {
+			var exec struct{ Command string }
+			if req.Type != "exec" || ssh.Unmarshal(req.Payload, &exec) != nil {
+				req.Reply(false, nil)
+				continue
+			}
+			req.Reply(true, nil)
+			status := uint32(0)
+			if exec.Command == "cat "+discovery.MetadataPath {
+				channel.Write([]byte(metadata))
+			} else {
+				status = 1
+			}
+			channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+			channel.Close()
+		}
+	}
+}
+
+func TestSSHIdentifier(t *testing.T) {
+	key, hostKey := newSigner(t), newSigner(t)
+	port := serveSSH(t, "syntropy", key.PublicKey(), hostKey, nodeJSON)
+
+	// The right key comes after more wrong keys than the server accepts
+	// on one connection
+	var signers []ssh.Signer
+	for i := 0; i < 7; i++ {
+		signers = append(signers, newSigner(t))
+	}
+	identifier := &discovery.SSHIdentifier{
+		Users:   []string{"admin", "syntropy"},
+		Signers: append(signers, key),
+		Timeout: 2 * time.Second,
+	}
+	node, err := identifier.Identify(context.Background(), discovery.Host{Addr: "127.0.0.1", Port: port})
+	if err != nil {
+		t.Fatalf("Identify: %v", err)
+	}
+	if node.Name != "node-01" || node.Verified || node.Method != "ssh" {
+		t.Errorf("node = %+v", node)
+	}
+	if node.HostKey != ssh.FingerprintSHA256(hostKey.PublicKey()) {
+		t.Errorf("host key = %s", node.HostKey)
+	}
+
+	identifier.Signers = signers
+	if _, err := identifier.Identify(context.Background(), discovery.Host{Addr: "127.0.0.1", Port: port}); err == nil {
+		t.Error("expected error without the right key")
+	}
+}
+
+func TestSSHIdentifierInvalidMetadata(t *testing.T) {
+	key := newSigner(t)
+	port := serveSSH(t, "syntropy", key.PublicKey(), newSigner(t), "not json")
+	identifier := &discovery.SSHIdentifier{Users: []string{"syntropy"}, Signers: []ssh.Signer{key}}
+	if _, err := identifier.Identify(context.Background(), discovery.Host{Addr: "127.0.0.1", Port: port}); err == nil {
+		t.Error("expected error for invalid metadata")
+	}
+}
@@ -1,12 +1,12 @@
 module syntropy-cc/cooperative-grid/core
 
-go 1.21
+go 1.22
 
 require (
+	filippo.io/age v1.2.1
+	github.com/klauspost/compress v1.18.0
 	github.com/sirupsen/logrus v1.9.3
-	golang.org/x/crypto v0.17.0
+	go.etcd.io/bbolt v1.3.10
+	golang.org/x/crypto v0.24.0
+	golang.org/x/sys v0.21.0
 )
-
-require (
-	golang.org/x/sys v0.16.0 // indirect
-)
\ No newline at end of file
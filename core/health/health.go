@@ -0,0 +1,276 @@
+// Package health checks grid nodes with a set of probes and rolls their
+// outcomes up into a score and a status under a threshold policy.
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a whole node check, all probes included
+const DefaultTimeout = 20 * time.Second
+
+// Severity is how much a failure of a probe matters to the node
+type Severity int
+
+const (
+	Info Severity = iota
+	Warning
+	Critical
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Info:
+		return "info"
+	case Warning:
+		return "warning"
+	case Critical:
+		return "critical"
+	default:
+		return fmt.Sprintf("severity(%d)", int(s))
+	}
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (s *Severity) UnmarshalText(text []byte) error {
+	for _, v := range []Severity{Info, Warning, Critical} {
+		if v.String() == string(text) {
+			*s = v
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown severity %q", text)
+}
+
+// State is the outcome of a single probe
+type State string
+
+const (
+	Pass State = "pass"
+	Warn State = "warn"
+	Fail State = "fail"
+	// Skip means the probe could not tell, e.g. the node has no
+	// WireGuard peers; it does not count towards the score
+	Skip State = "skip"
+)
+
+// Check is the outcome of one probe on one node
+type Check struct {
+	Probe    string   `json:"probe"`
+	Severity Severity `json:"severity"`
+	State    State    `json:"state"`
+	Message  string   `json:"message,omitempty"`
+}
+
+// Status of a node after a check
+const (
+	StatusOnline    = "online"
+	StatusDegraded  = "degraded"
+	StatusUnhealthy = "unhealthy"
+	StatusOffline   = "offline"
+)
+
+// Target is a node to be checked
+type Target struct {
+	Name string
+	Addr string
+	// HostKey is the pinned SSH host key fingerprint ("SHA256:..."). When
+	// empty, the key the node presents is trusted and reported in
+	// Result.HostKey for the caller to pin.
+	HostKey string
+	// CertNotAfter is when the node certificate expires; zero if unknown
+	CertNotAfter time.Time
+}
+
+// Probe checks one aspect of a node. Probes of the same node run
+// concurrently and share its Session.
+type Probe interface {
+	Name() string
+	Severity() Severity
+	Run(ctx context.Context, s *Session) Check
+}
+
+// Result is the rolled-up health of a node
+type Result struct {
+	Node   string  `json:"node"`
+	Addr   string  `json:"addr"`
+	Status string  `json:"status"`
+	Score  int     `json:"score"`
+	Checks []Check `json:"checks"`
+	// RTT is the round trip measured by the reachability probe
+	RTT time.Duration `json:"rtt"`
+	// HostKey is the SSH host key fingerprint the node presented, if any
+	HostKey string `json:"host_key,omitempty"`
+}
+
+// Problems describes the checks that did not pass, most severe first
+func (r *Result) Problems() string {
+	var problems []string
+	for _, severity := range []Severity{Critical, Warning, Info} {
+		for _, c := range r.Checks {
+			if c.Severity == severity && (c.State == Fail || c.State == Warn) {
+				problems = append(problems, fmt.Sprintf("%s: %s", c.Probe, c.Message))
+			}
+		}
+	}
+	return strings.Join(problems, "; ")
+}
+
+// Policy turns checks into a score from 0 to 100 and the score into a
+// status. A failed check costs the weight of its severity; a warning costs
+// half of it.
+type Policy struct {
+	CriticalWeight int `json:"critical_weight"`
+	WarningWeight  int `json:"warning_weight"`
+	InfoWeight     int `json:"info_weight"`
+	// Nodes scoring at least Healthy are online and at least Degraded are
+	// degraded; below that they are unhealthy
+	Healthy  int `json:"healthy"`
+	Degraded int `json:"degraded"`
+}
+
+// DefaultPolicy makes a node with a failed critical probe unhealthy and one
+// with a failed warning probe, or a critical probe warning, degraded
+func DefaultPolicy() Policy {
+	return Policy{
+		CriticalWeight: 60,
+		WarningWeight:  25,
+		InfoWeight:     5,
+		Healthy:        80,
+		Degraded:       50,
+	}
+}
+
+// Validate checks that the thresholds are ordered and within 0-100
+func (p Policy) Validate() error {
+	if p.CriticalWeight < 0 || p.WarningWeight < 0 || p.InfoWeight < 0 {
+		return fmt.Errorf("health policy weights must not be negative")
+	}
+	if p.Degraded < 0 || p.Healthy > 100 || p.Degraded > p.Healthy {
+		return fmt.Errorf("health policy needs 0 <= degraded (%d) <= healthy (%d) <= 100", p.Degraded, p.Healthy)
+	}
+	return nil
+}
+
+func (p Policy) weight(s Severity) int {
+	switch s {
+	case Critical:
+		return p.CriticalWeight
+	case Warning:
+		return p.WarningWeight
+	default:
+		return p.InfoWeight
+	}
+}
+
+// Score rolls checks up into a score from 0 to 100
+func (p Policy) Score(checks []Check) int {
+	score := 100
+	for _, c := range checks {
+		switch c.State {
+		case Fail:
+			score -= p.weight(c.Severity)
+		case Warn:
+			score -= p.weight(c.Severity) / 2
+		}
+	}
+	return max(score, 0)
+}
+
+// Status classifies a score
+func (p Policy) Status(score int) string {
+	switch {
+	case score >= p.Healthy:
+		return StatusOnline
+	case score >= p.Degraded:
+		return StatusDegraded
+	default:
+		return StatusUnhealthy
+	}
+}
+
+// Checker runs the probes on a node
+type Checker struct {
+	// Reachability runs first. When it fails the node is offline and the
+	// other probes are skipped.
+	Reachability Probe
+	Probes       []Probe
+	SSH          SSHConfig
+	// Policy defaults to DefaultPolicy
+	Policy  *Policy
+	Timeout time.Duration
+}
+
+// Check runs every probe on target and rolls up the result
+func (c *Checker) Check(ctx context.Context, target Target) Result {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	policy := DefaultPolicy()
+	if c.Policy != nil {
+		policy = *c.Policy
+	}
+
+	s := &Session{Target: target, ssh: c.SSH}
+	defer s.close()
+	result := Result{Node: target.Name, Addr: target.Addr}
+
+	if c.Reachability != nil {
+		check := run(ctx, c.Reachability, s)
+		result.Checks = append(result.Checks, check)
+		if check.State == Fail {
+			for _, p := range c.Probes {
+				result.Checks = append(result.Checks, Check{
+					Probe:    p.Name(),
+					Severity: p.Severity(),
+					State:    Skip,
+					Message:  "node unreachable",
+				})
+			}
+			result.RTT = s.RTT()
+			result.Status = StatusOffline
+			return result
+		}
+	}
+
+	checks := make([]Check, len(c.Probes))
+	var wg sync.WaitGroup
+	for i, p := range c.Probes {
+		wg.Add(1)
+		go func(i int, p Probe) {
+			defer wg.Done()
+			checks[i] = run(ctx, p, s)
+		}(i, p)
+	}
+	wg.Wait()
+
+	result.Checks = append(result.Checks, checks...)
+	result.RTT = s.RTT()
+	result.HostKey = s.HostKey()
+	result.Score = policy.Score(result.Checks)
+	result.Status = policy.Status(result.Score)
+	return result
+}
+
+func run(ctx context.Context, p Probe, s *Session) Check {
+	check := p.Run(ctx, s)
+	check.Probe = p.Name()
+	check.Severity = p.Severity()
+	if check.State == "" {
+		check.State = Skip
+	}
+	return check
+}
@@ -0,0 +1,161 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/health"
+)
+
+// fakeProbe returns a fixed state
+type fakeProbe struct {
+	name     string
+	severity health.Severity
+	state    health.State
+	ran      bool
+}
+
+func (p *fakeProbe) Name() string              { return p.name }
+func (p *fakeProbe) Severity() health.Severity { return p.severity }
+func (p *fakeProbe) Run(ctx context.Context, s *health.Session) health.Check {
+	p.ran = true
+	return health.Check{State: p.state, Message: string(p.state)}
+}
+
+func TestPolicy(t *testing.T) {
+	policy := health.DefaultPolicy()
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("default policy: %v", err)
+	}
+
+	tests := []struct {
+		checks []health.Check
+		score  int
+		status string
+	}{
+		{nil, 100, health.StatusOnline},
+		{[]health.Check{{Severity: health.Warning, State: health.Warn}}, 88, health.StatusOnline},
+		{[]health.Check{{Severity: health.Warning, State: health.Fail}}, 75, health.StatusDegraded},
+		{[]health.Check{{Severity: health.Critical, State: health.Warn}}, 70, health.StatusDegraded},
+		{[]health.Check{{Severity: health.Critical, State: health.Fail}}, 40, health.StatusUnhealthy},
+		{[]health.Check{{Severity: health.Critical, State: health.Skip}, {Severity: health.Info, State: health.Pass}}, 100, health.StatusOnline},
+		{[]health.Check{{Severity: health.Critical, State: health.Fail}, {Severity: health.Critical, State: health.Fail}}, 0, health.StatusUnhealthy},
+	}
+	for i, tt := range tests {
+		score := policy.Score(tt.checks)
+		if score != tt.score || policy.Status(score) != tt.status {
+			t.Errorf("%d: score %d (%s), want %d (%s)", i, score, policy.Status(score), tt.score, tt.status)
+		}
+	}
+
+	if err := (health.Policy{Healthy: 40, Degraded: 60}).Validate(); err == nil {
+		t.Error("expected error for degraded above healthy")
+	}
+	if err := (health.Policy{CriticalWeight: -1, Healthy: 80}).Validate(); err == nil {
+		t.Error("expected error for a negative weight")
+	}
+}
+
+func TestCheckerOffline(t *testing.T) {
+	docker := &fakeProbe{name: "docker", severity: health.Critical, state: health.Pass}
+	checker := &health.Checker{
+		Reachability: &fakeProbe{name: "rtt", severity: health.Critical, state: health.Fail},
+		Probes:       []health.Probe{docker},
+	}
+	result := checker.Check(context.Background(), health.Target{Name: "node-01", Addr: "192.0.2.1"})
+	if result.Status != health.StatusOffline || result.Score != 0 {
+		t.Errorf("result = %+v", result)
+	}
+	if docker.ran || len(result.Checks) != 2 || result.Checks[1].State != health.Skip {
+		t.Errorf("probes ran on an unreachable node: %+v", result.Checks)
+	}
+}
+
+func TestCheckerRollUp(t *testing.T) {
+	policy := health.Policy{CriticalWeight: 50, WarningWeight: 30, Healthy: 90, Degraded: 60}
+	checker := &health.Checker{
+		Reachability: &fakeProbe{name: "rtt", severity: health.Critical, state: health.Pass},
+		Probes: []health.Probe{
+			&fakeProbe{name: "docker", severity: health.Critical, state: health.Pass},
+			&fakeProbe{name: "wireguard", severity: health.Warning, state: health.Fail},
+			&fakeProbe{name: "disk", severity: health.Info, state: health.Warn},
+		},
+		Policy: &policy,
+	}
+	result := checker.Check(context.Background(), health.Target{Name: "node-01"})
+	if result.Score != 70 || result.Status != health.StatusDegraded {
+		t.Errorf("score %d, status %s", result.Score, result.Status)
+	}
+	if want := "wireguard: fail; disk: warn"; result.Problems() != want {
+		t.Errorf("problems = %q, want %q", result.Problems(), want)
+	}
+
+	data, err := json.Marshal(result.Checks[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"probe":"wireguard","severity":"warning","state":"fail","message":"fail"}`; string(data) != want {
+		t.Errorf("check JSON = %s", data)
+	}
+	var check health.Check
+	if err := json.Unmarshal(data, &check); err != nil || check.Severity != health.Warning {
+		t.Errorf("unmarshal = %+v, %v", check, err)
+	}
+}
+
+func TestRTTProbe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	checker := &health.Checker{Reachability: &health.RTTProbe{Port: port, Timeout: time.Second}}
+	result := checker.Check(context.Background(), health.Target{Addr: "127.0.0.1"})
+	if result.Checks[0].State != health.Pass || result.RTT <= 0 {
+		t.Errorf("reachable: %+v", result)
+	}
+	if !strings.Contains(result.Checks[0].Message, "median of 3") {
+		t.Errorf("message = %q", result.Checks[0].Message)
+	}
+
+	l.Close()
+	result = checker.Check(context.Background(), health.Target{Addr: "127.0.0.1"})
+	if result.Status != health.StatusOffline {
+		t.Errorf("closed port: %+v", result)
+	}
+}
+
+func TestCertProbe(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	probe := &health.CertProbe{Window: 30 * 24 * time.Hour, Now: func() time.Time { return now }}
+	tests := []struct {
+		notAfter time.Time
+		state    health.State
+	}{
+		{time.Time{}, health.Skip},
+		{now.Add(-time.Hour), health.Fail},
+		{now.Add(10 * 24 * time.Hour), health.Warn},
+		{now.Add(90 * 24 * time.Hour), health.Pass},
+	}
+	for _, tt := range tests {
+		checker := &health.Checker{Probes: []health.Probe{probe}}
+		result := checker.Check(context.Background(), health.Target{CertNotAfter: tt.notAfter})
+		if got := result.Checks[0]; got.State != tt.state {
+			t.Errorf("not after %s: %+v, want %s", tt.notAfter, got, tt.state)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package health
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// scrapedMetrics are the node exporter samples the probes use
+var scrapedMetrics = map[string]bool{
+	"node_filesystem_avail_bytes":    true,
+	"node_filesystem_size_bytes":     true,
+	"node_memory_MemAvailable_bytes": true,
+	"node_memory_MemTotal_bytes":     true,
+	"node_load1":                     true,
+	"node_cpu_seconds_total":         true,
+}
+
+// sample is one line of the Prometheus text format
+type sample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+type metricSet []sample
+
+func scrapeMetrics(ctx context.Context, url string, timeout time.Duration) (metricSet, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("node exporter unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("node exporter answered %s", resp.Status)
+	}
+	return parseMetrics(io.LimitReader(resp.Body, 16<<20))
+}
+
+// parseMetrics reads the samples listed in scrapedMetrics from the
+// Prometheus text exposition format
+func parseMetrics(r io.Reader) (metricSet, error) {
+	var set metricSet
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		name := line
+		if i := strings.IndexAny(line, "{ "); i >= 0 {
+			name = line[:i]
+		}
+		if !scrapedMetrics[name] {
+			continue
+		}
+		s, err := parseSample(line, name)
+		if err != nil {
+			return nil, err
+		}
+		set = append(set, s)
+	}
+	return set, scanner.Err()
+}
+
+func parseSample(line, name string) (sample, error) {
+	s := sample{name: name, labels: map[string]string{}}
+	rest := line[len(name):]
+	if strings.HasPrefix(rest, "{") {
+		end, err := parseLabels(rest, s.labels)
+		if err != nil {
+			return sample{}, fmt.Errorf("invalid metric line %q: %w", line, err)
+		}
+		rest = rest[end:]
+	}
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return sample{}, fmt.Errorf("invalid metric line %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample{}, fmt.Errorf("invalid metric line %q", line)
+	}
+	s.value = value
+	return s, nil
+}
+
+// parseLabels reads {a="x",b="y"} at the start of s into labels and
+// returns the offset after the closing brace
+func parseLabels(s string, labels map[string]string) (int, error) {
+	i := 1
+	for {
+		for i < len(s) && (s[i] == ' ' || s[i] == ',') {
+			i++
+		}
+		if i < len(s) && s[i] == '}' {
+			return i + 1, nil
+		}
+		eq := strings.IndexByte(s[i:], '=')
+		if eq < 0 || i+eq+1 >= len(s) || s[i+eq+1] != '"' {
+			return 0, fmt.Errorf("malformed labels")
+		}
+		key := strings.TrimSpace(s[i : i+eq])
+		i += eq + 2
+
+		var value strings.Builder
+		for ; i < len(s) && s[i] != '"'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				if s[i] == 'n' {
+					value.WriteByte('\n')
+					continue
+				}
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return 0, fmt.Errorf("unterminated label value")
+		}
+		labels[key] = value.String()
+		i++
+	}
+}
+
+func (m metricSet) find(name string, labels map[string]string) (float64, bool) {
+	for _, s := range m {
+		if s.name != name {
+			continue
+		}
+		match := true
+		for k, v := range labels {
+			if s.labels[k] != v {
+				match = false
+				break
+			}
+		}
+		if match {
+			return s.value, true
+		}
+	}
+	return 0, false
+}
+
+// diskUsage is the used fraction of the filesystem mounted at mountpoint
+func (m metricSet) diskUsage(mountpoint string) (float64, bool) {
+	labels := map[string]string{"mountpoint": mountpoint}
+	avail, ok1 := m.find("node_filesystem_avail_bytes", labels)
+	size, ok2 := m.find("node_filesystem_size_bytes", labels)
+	if !ok1 || !ok2 || size <= 0 {
+		return 0, false
+	}
+	return 1 - avail/size, true
+}
+
+// memoryUsage is the fraction of memory not available to new processes
+func (m metricSet) memoryUsage() (float64, bool) {
+	avail, ok1 := m.find("node_memory_MemAvailable_bytes", nil)
+	total, ok2 := m.find("node_memory_MemTotal_bytes", nil)
+	if !ok1 || !ok2 || total <= 0 {
+		return 0, false
+	}
+	return 1 - avail/total, true
+}
+
+// loadPerCPU is the one-minute load average divided by the CPU count
+func (m metricSet) loadPerCPU() (float64, bool) {
+	load, ok := m.find("node_load1", nil)
+	if !ok {
+		return 0, false
+	}
+	cpus := map[string]bool{}
+	for _, s := range m {
+		if s.name == "node_cpu_seconds_total" && s.labels["mode"] == "idle" {
+			cpus[s.labels["cpu"]] = true
+		}
+	}
+	if len(cpus) == 0 {
+		return 0, false
+	}
+	return load / float64(len(cpus)), true
+}
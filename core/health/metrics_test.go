@@ -0,0 +1,42 @@
+package health
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseMetrics(t *testing.T) {
+	input := `# TYPE node_filesystem_size_bytes gauge
+node_filesystem_size_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/"} 100
+node_filesystem_avail_bytes{mountpoint="/",note="a \"quoted\", value"} 25 1700000000000
+node_network_receive_bytes_total{device="eth0"} 5
+node_memory_MemTotal_bytes 4e+09
+`
+	set, err := parseMetrics(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("parseMetrics: %v", err)
+	}
+	if len(set) != 3 {
+		t.Fatalf("got %d samples, want 3: %+v", len(set), set)
+	}
+	if got := set[1].labels["note"]; got != `a "quoted", value` {
+		t.Errorf("escaped label = %q", got)
+	}
+	if usage, ok := set.diskUsage("/"); !ok || usage != 0.75 {
+		t.Errorf("disk usage = %v, %v", usage, ok)
+	}
+	if _, ok := set.memoryUsage(); ok {
+		t.Error("memory usage without MemAvailable")
+	}
+
+	for _, bad := range []string{
+		`node_load1{cpu="0" 1`,
+		`node_load1{cpu="0} 1`,
+		`node_load1 abc`,
+		`node_load1`,
+	} {
+		if _, err := parseMetrics(strings.NewReader(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
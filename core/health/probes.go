@@ -0,0 +1,335 @@
+package health
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// RTTProbe measures the round trip to a node by timing TCP connections to
+// its SSH port. It is meant as the Reachability probe of a Checker.
+type RTTProbe struct {
+	// Port defaults to 22
+	Port int
+	// Count is how many connections are timed; defaults to 3
+	Count int
+	// Timeout bounds each connection; defaults to 5 seconds
+	Timeout time.Duration
+	// Slow is the round trip above which the probe warns; defaults to 250ms
+	Slow time.Duration
+}
+
+func (p *RTTProbe) Name() string       { return "rtt" }
+func (p *RTTProbe) Severity() Severity { return Critical }
+
+func (p *RTTProbe) Run(ctx context.Context, s *Session) Check {
+	port, count, timeout, slow := p.Port, p.Count, p.Timeout, p.Slow
+	if port == 0 {
+		port = 22
+	}
+	if count <= 0 {
+		count = 3
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	if slow <= 0 {
+		slow = 250 * time.Millisecond
+	}
+
+	address := net.JoinHostPort(s.Target.Addr, strconv.Itoa(port))
+	dialer := net.Dialer{Timeout: timeout}
+	var samples []time.Duration
+	var lastErr error
+	for i := 0; i < count && ctx.Err() == nil; i++ {
+		start := time.Now()
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		samples = append(samples, time.Since(start))
+		conn.Close()
+	}
+	if len(samples) == 0 {
+		if lastErr == nil {
+			lastErr = ctx.Err()
+		}
+		return Check{State: Fail, Message: lastErr.Error()}
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	rtt := samples[len(samples)/2]
+	s.setRTT(rtt)
+	message := fmt.Sprintf("%s median of %d", rtt.Round(100*time.Microsecond), len(samples))
+	switch {
+	case len(samples) < count:
+		return Check{State: Warn, Message: fmt.Sprintf("%s; %d of %d connections failed", message, count-len(samples), count)}
+	case rtt > slow:
+		return Check{State: Warn, Message: message + ", slow"}
+	}
+	return Check{State: Pass, Message: message}
+}
+
+// SSHProbe completes an SSH handshake and checks the host key against the
+// pinned one. A node that shows the right key but refuses every configured
+// login only warns; without credentials the probe is skipped.
+type SSHProbe struct{}
+
+func (p *SSHProbe) Name() string       { return "ssh" }
+func (p *SSHProbe) Severity() Severity { return Critical }
+
+func (p *SSHProbe) Run(ctx context.Context, s *Session) Check {
+	_, err := s.Client(ctx)
+	var mismatch *HostKeyError
+	switch {
+	case errors.Is(err, ErrNoSSHCredentials):
+		return Check{State: Skip, Message: err.Error()}
+	case errors.As(err, &mismatch):
+		return Check{State: Fail, Message: mismatch.Error()}
+	case s.HostKey() == "":
+		return Check{State: Fail, Message: err.Error()}
+	}
+
+	key := "host key " + s.HostKey()
+	if s.Target.HostKey == "" {
+		key += " trusted on first use"
+	} else {
+		key += " verified"
+	}
+	if err != nil {
+		return Check{State: Warn, Message: fmt.Sprintf("%s; login failed: %v", key, err)}
+	}
+	return Check{State: Pass, Message: key}
+}
+
+// NodeExporterProbe reads disk, memory and load from the Prometheus node
+// exporter. Usage is a fraction from 0 to 1; load is per CPU.
+type NodeExporterProbe struct {
+	// Port defaults to 9100
+	Port    int
+	Timeout time.Duration
+	// Thresholds default to 85%/95% of the root filesystem, 90%/97% of
+	// memory and a load of 2/4 per CPU
+	DiskWarn, DiskFail     float64
+	MemoryWarn, MemoryFail float64
+	LoadWarn, LoadFail     float64
+}
+
+func (p *NodeExporterProbe) Name() string       { return "node_exporter" }
+func (p *NodeExporterProbe) Severity() Severity { return Warning }
+
+func (p *NodeExporterProbe) Run(ctx context.Context, s *Session) Check {
+	port, timeout := p.Port, p.Timeout
+	if port == 0 {
+		port = 9100
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	url := fmt.Sprintf("http://%s/metrics", net.JoinHostPort(s.Target.Addr, strconv.Itoa(port)))
+	metrics, err := scrapeMetrics(ctx, url, timeout)
+	if err != nil {
+		return Check{State: Fail, Message: err.Error()}
+	}
+
+	state := Pass
+	var parts []string
+	measure := func(part string, value, warn, fail float64) {
+		switch {
+		case value >= fail:
+			state = Fail
+			part += " (critical)"
+		case value >= warn:
+			if state == Pass {
+				state = Warn
+			}
+			part += " (high)"
+		}
+		parts = append(parts, part)
+	}
+	if disk, ok := metrics.diskUsage("/"); ok {
+		measure(fmt.Sprintf("disk %.0f%%", disk*100), disk, orDefault(p.DiskWarn, 0.85), orDefault(p.DiskFail, 0.95))
+	}
+	if memory, ok := metrics.memoryUsage(); ok {
+		measure(fmt.Sprintf("memory %.0f%%", memory*100), memory, orDefault(p.MemoryWarn, 0.90), orDefault(p.MemoryFail, 0.97))
+	}
+	if load, ok := metrics.loadPerCPU(); ok {
+		measure(fmt.Sprintf("load %.2f/cpu", load), load, orDefault(p.LoadWarn, 2), orDefault(p.LoadFail, 4))
+	}
+	if len(parts) == 0 {
+		return Check{State: Skip, Message: "no disk, memory or load metrics"}
+	}
+	return Check{State: state, Message: strings.Join(parts, ", ")}
+}
+
+// DockerProbe checks that the Docker daemon of the node answers
+type DockerProbe struct{}
+
+func (p *DockerProbe) Name() string       { return "docker" }
+func (p *DockerProbe) Severity() Severity { return Critical }
+
+func (p *DockerProbe) Run(ctx context.Context, s *Session) Check {
+	if _, err := s.Client(ctx); err != nil {
+		return Check{State: Skip, Message: "no SSH session: " + err.Error()}
+	}
+	output, err := s.Run(ctx, "docker info --format '{{.ServerVersion}} {{.ContainersRunning}}'")
+	if err != nil {
+		return Check{State: Fail, Message: "docker daemon not answering: " + commandError(err)}
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return Check{State: Fail, Message: fmt.Sprintf("unexpected docker info output %q", bytes.TrimSpace(output))}
+	}
+	return Check{State: Pass, Message: fmt.Sprintf("docker %s, %s containers running", fields[0], fields[1])}
+}
+
+// wireGuardCommand prints the last handshake of every peer followed by the
+// clock of the node, so ages do not depend on the local clock. Exit status
+// 127 means WireGuard is not installed.
+const wireGuardCommand = "command -v wg >/dev/null || exit 127; sudo -n wg show all latest-handshakes && date +%s"
+
+// WireGuardProbe checks how long ago each WireGuard peer of the node
+// completed a handshake. WireGuard renews sessions every two minutes, so an
+// older handshake means the tunnel is down.
+type WireGuardProbe struct {
+	// MaxAge defaults to 3 minutes
+	MaxAge time.Duration
+}
+
+func (p *WireGuardProbe) Name() string       { return "wireguard" }
+func (p *WireGuardProbe) Severity() Severity { return Warning }
+
+func (p *WireGuardProbe) Run(ctx context.Context, s *Session) Check {
+	maxAge := p.MaxAge
+	if maxAge <= 0 {
+		maxAge = 3 * time.Minute
+	}
+	if _, err := s.Client(ctx); err != nil {
+		return Check{State: Skip, Message: "no SSH session: " + err.Error()}
+	}
+	output, err := s.Run(ctx, wireGuardCommand)
+	var exit *ssh.ExitError
+	if errors.As(err, &exit) && exit.ExitStatus() == 127 {
+		return Check{State: Skip, Message: "WireGuard not installed"}
+	}
+	if err != nil {
+		return Check{State: Fail, Message: "wg show failed: " + commandError(err)}
+	}
+
+	ages, err := handshakeAges(output)
+	if err != nil {
+		return Check{State: Fail, Message: err.Error()}
+	}
+	if len(ages) == 0 {
+		return Check{State: Skip, Message: "no WireGuard peers"}
+	}
+	stale := 0
+	oldest := time.Duration(0)
+	for _, age := range ages {
+		if age < 0 || age > maxAge {
+			stale++
+		}
+		oldest = max(oldest, age)
+	}
+	switch {
+	case stale == 0:
+		return Check{State: Pass, Message: fmt.Sprintf("%d peers, oldest handshake %s ago", len(ages), oldest.Round(time.Second))}
+	case stale == len(ages):
+		return Check{State: Fail, Message: fmt.Sprintf("no handshake within %s with any of %d peers", maxAge, len(ages))}
+	default:
+		return Check{State: Warn, Message: fmt.Sprintf("%d of %d peers without a handshake within %s", stale, len(ages), maxAge)}
+	}
+}
+
+// handshakeAges parses the output of wireGuardCommand. A peer that never
+// completed a handshake has a negative age.
+func handshakeAges(output []byte) ([]time.Duration, error) {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("empty wg show output")
+	}
+	now, err := strconv.ParseInt(lines[len(lines)-1], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid node clock %q", lines[len(lines)-1])
+	}
+
+	var ages []time.Duration
+	for _, line := range lines[:len(lines)-1] {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("unexpected wg show line %q", line)
+		}
+		last, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected wg show line %q", line)
+		}
+		if last == 0 {
+			ages = append(ages, -1)
+			continue
+		}
+		ages = append(ages, time.Duration(now-last)*time.Second)
+	}
+	return ages, nil
+}
+
+// CertProbe checks the expiry of the node certificate issued by the grid CA
+type CertProbe struct {
+	// Window is how long before expiry the probe warns
+	Window time.Duration
+	// Now defaults to time.Now
+	Now func() time.Time
+}
+
+func (p *CertProbe) Name() string       { return "certificate" }
+func (p *CertProbe) Severity() Severity { return Critical }
+
+func (p *CertProbe) Run(ctx context.Context, s *Session) Check {
+	notAfter := s.Target.CertNotAfter
+	if notAfter.IsZero() {
+		return Check{State: Skip, Message: "no certificate issued by the grid CA"}
+	}
+	now := time.Now()
+	if p.Now != nil {
+		now = p.Now()
+	}
+	switch left := notAfter.Sub(now); {
+	case left <= 0:
+		return Check{State: Fail, Message: "expired on " + notAfter.Format("2006-01-02")}
+	case left <= p.Window:
+		return Check{State: Warn, Message: fmt.Sprintf("expires in %d days (%s)", int(left.Hours()/24), notAfter.Format("2006-01-02"))}
+	default:
+		return Check{State: Pass, Message: "valid until " + notAfter.Format("2006-01-02")}
+	}
+}
+
+func orDefault(v, def float64) float64 {
+	if v > 0 {
+		return v
+	}
+	return def
+}
+
+// commandError adds the exit status to the error of a remote command
+func commandError(err error) string {
+	var exit *ssh.ExitError
+	if errors.As(err, &exit) {
+		return fmt.Sprintf("exit status %d", exit.ExitStatus())
+	}
+	return err.Error()
+}
@@ -0,0 +1,258 @@
+package health_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"syntropy-cc/cooperative-grid/core/health"
+)
+
+func newSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+// remoteCommand is the canned answer of the fake node to a command
+type remoteCommand struct {
+	output string
+	status uint32
+}
+
+// serveSSH starts an SSH server that lets "syntropy" in with key and
+// answers the commands in commands; any other command exits with 1
+func serveSSH(t *testing.T, key ssh.PublicKey, hostKey ssh.Signer, commands map[string]remoteCommand) int {
+	t.Helper()
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, offered ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "syntropy" && string(offered.Marshal()) == string(key.Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key")
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go handleSSH(conn, config, commands)
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func handleSSH(conn net.Conn, config *ssh.ServerConfig, commands map[string]remoteCommand) {
+	defer conn.Close()
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				var exec struct{ Command string }
+				if req.Type != "exec" || ssh.Unmarshal(req.Payload, &exec) != nil {
+					req.Reply(false, nil)
+					continue
+				}
+				req.Reply(true, nil)
+				answer, ok := commands[exec.Command]
+				if !ok {
+					answer.status = 1
+				}
+				channel.Write([]byte(answer.output))
+				channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{answer.status}))
+				channel.Close()
+			}
+		}()
+	}
+}
+
+const (
+	dockerCommand    = "docker info --format '{{.ServerVersion}} {{.ContainersRunning}}'"
+	wireGuardCommand = "command -v wg >/dev/null || exit 127; sudo -n wg show all latest-handshakes && date +%s"
+)
+
+func sshChecker(key ssh.Signer, port int) *health.Checker {
+	return &health.Checker{
+		Probes: []health.Probe{&health.SSHProbe{}, &health.DockerProbe{}, &health.WireGuardProbe{}},
+		SSH: health.SSHConfig{
+			Port:    port,
+			Users:   []string{"admin", "syntropy"},
+			Signers: []ssh.Signer{key},
+			Timeout: 2 * time.Second,
+		},
+	}
+}
+
+func states(result health.Result) string {
+	var s []string
+	for _, c := range result.Checks {
+		s = append(s, fmt.Sprintf("%s=%s", c.Probe, c.State))
+	}
+	return strings.Join(s, " ")
+}
+
+func TestSSHProbes(t *testing.T) {
+	key, hostKey := newSigner(t), newSigner(t)
+	fingerprint := ssh.FingerprintSHA256(hostKey.PublicKey())
+	port := serveSSH(t, key.PublicKey(), hostKey, map[string]remoteCommand{
+		dockerCommand: {output: "24.0.7 3\n"},
+		wireGuardCommand: {output: "wg0\tpeerA=\t1767225570\n" +
+			"wg0\tpeerB=\t1767225000\n" +
+			"1767225600\n"},
+	})
+	checker := sshChecker(key, port)
+
+	// An unpinned key is trusted and reported for pinning
+	result := checker.Check(context.Background(), health.Target{Name: "node-01", Addr: "127.0.0.1"})
+	if got := states(result); got != "ssh=pass docker=pass wireguard=warn" {
+		t.Fatalf("states = %s (%s)", got, result.Problems())
+	}
+	if result.HostKey != fingerprint || !strings.Contains(result.Checks[0].Message, "trusted on first use") {
+		t.Errorf("host key %s, ssh check %+v", result.HostKey, result.Checks[0])
+	}
+	if msg := result.Checks[1].Message; msg != "docker 24.0.7, 3 containers running" {
+		t.Errorf("docker = %q", msg)
+	}
+	if msg := result.Checks[2].Message; msg != "1 of 2 peers without a handshake within 3m0s" {
+		t.Errorf("wireguard = %q", msg)
+	}
+
+	// The pinned key is verified
+	result = checker.Check(context.Background(), health.Target{Name: "node-01", Addr: "127.0.0.1", HostKey: fingerprint})
+	if result.Checks[0].State != health.Pass || !strings.Contains(result.Checks[0].Message, "verified") {
+		t.Errorf("pinned: %+v", result.Checks[0])
+	}
+
+	// A different key fails and no command runs on that host
+	other := ssh.FingerprintSHA256(newSigner(t).PublicKey())
+	result = checker.Check(context.Background(), health.Target{Name: "node-01", Addr: "127.0.0.1", HostKey: other})
+	if got := states(result); got != "ssh=fail docker=skip wireguard=skip" {
+		t.Errorf("mismatch states = %s", got)
+	}
+	if !strings.Contains(result.Checks[0].Message, "host key mismatch") {
+		t.Errorf("mismatch: %+v", result.Checks[0])
+	}
+
+	// The right host refusing the login only warns
+	checker.SSH.Signers = []ssh.Signer{newSigner(t)}
+	result = checker.Check(context.Background(), health.Target{Name: "node-01", Addr: "127.0.0.1", HostKey: fingerprint})
+	if got := states(result); got != "ssh=warn docker=skip wireguard=skip" {
+		t.Errorf("login refused states = %s", got)
+	}
+}
+
+func TestSSHProbesFailures(t *testing.T) {
+	key := newSigner(t)
+	port := serveSSH(t, key.PublicKey(), newSigner(t), map[string]remoteCommand{
+		dockerCommand:    {output: "Cannot connect to the Docker daemon\n", status: 1},
+		wireGuardCommand: {status: 127},
+	})
+	result := sshChecker(key, port).Check(context.Background(), health.Target{Name: "node-01", Addr: "127.0.0.1"})
+	if got := states(result); got != "ssh=pass docker=fail wireguard=skip" {
+		t.Errorf("states = %s (%s)", got, result.Problems())
+	}
+	if result.Status != health.StatusUnhealthy {
+		t.Errorf("status = %s, score %d", result.Status, result.Score)
+	}
+}
+
+func TestSSHProbesWithoutCredentials(t *testing.T) {
+	key := newSigner(t)
+	port := serveSSH(t, key.PublicKey(), newSigner(t), nil)
+	for _, cfg := range []health.SSHConfig{
+		{Port: port, Users: []string{"admin"}},
+		{Port: port, Signers: []ssh.Signer{key}},
+	} {
+		checker := sshChecker(key, port)
+		checker.SSH = cfg
+		result := checker.Check(context.Background(), health.Target{Name: "node-01", Addr: "127.0.0.1"})
+		if got := states(result); got != "ssh=skip docker=skip wireguard=skip" {
+			t.Errorf("states = %s (%s)", got, result.Problems())
+		}
+		if msg := result.Checks[0].Message; msg != health.ErrNoSSHCredentials.Error() {
+			t.Errorf("ssh = %q", msg)
+		}
+		if result.Score != 100 || result.Status != health.StatusOnline {
+			t.Errorf("status = %s, score %d", result.Status, result.Score)
+		}
+	}
+}
+
+const nodeMetrics = `# HELP node_load1 1m load average.
+# TYPE node_load1 gauge
+node_load1 9.5
+node_cpu_seconds_total{cpu="0",mode="idle"} 1000
+node_cpu_seconds_total{cpu="0",mode="user"} 10
+node_cpu_seconds_total{cpu="1",mode="idle"} 1000
+node_filesystem_avail_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/"} 1e+10
+node_filesystem_size_bytes{device="/dev/sda1",fstype="ext4",mountpoint="/"} 1e+11
+node_filesystem_avail_bytes{device="tmpfs",fstype="tmpfs",mountpoint="/run"} 1
+node_memory_MemAvailable_bytes 1.5e+09
+node_memory_MemTotal_bytes 8e+09
+`
+
+func TestNodeExporterProbe(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/metrics" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, nodeMetrics)
+	}))
+	defer server.Close()
+	host, portText, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portText)
+
+	checker := &health.Checker{Probes: []health.Probe{&health.NodeExporterProbe{Port: port}}}
+	check := checker.Check(context.Background(), health.Target{Addr: host}).Checks[0]
+	if check.State != health.Fail {
+		t.Errorf("state = %s", check.State)
+	}
+	if want := "disk 90% (high), memory 81%, load 4.75/cpu (critical)"; check.Message != want {
+		t.Errorf("message = %q, want %q", check.Message, want)
+	}
+
+	checker.Probes = []health.Probe{&health.NodeExporterProbe{Port: port, DiskWarn: 0.95, LoadWarn: 5, LoadFail: 10}}
+	if check := checker.Check(context.Background(), health.Target{Addr: host}).Checks[0]; check.State != health.Pass {
+		t.Errorf("relaxed thresholds: %+v", check)
+	}
+
+	server.Close()
+	if check := checker.Check(context.Background(), health.Target{Addr: host}).Checks[0]; check.State != health.Fail {
+		t.Errorf("exporter down: %+v", check)
+	}
+}
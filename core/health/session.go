@@ -0,0 +1,182 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// maxKeysPerAttempt stays under the default MaxAuthTries of sshd
+const maxKeysPerAttempt = 5
+
+// SSHConfig is how probes log in to nodes
+type SSHConfig struct {
+	// Port defaults to 22
+	Port    int
+	Users   []string
+	Signers []ssh.Signer
+	// Timeout bounds the connection and handshake; defaults to 5 seconds
+	Timeout time.Duration
+}
+
+// ErrNoSSHCredentials is returned by Session.Client when the checker has no
+// SSH user or key to log in with
+var ErrNoSSHCredentials = errors.New("no SSH credentials configured")
+
+// HostKeyError reports a node presenting a host key other than the pinned one
+type HostKeyError struct {
+	Pinned, Got string
+}
+
+func (e *HostKeyError) Error() string {
+	return fmt.Sprintf("host key mismatch: got %s, pinned %s", e.Got, e.Pinned)
+}
+
+// Session is the state shared by the probes of one node check. The SSH
+// connection is opened by the first probe that needs it.
+type Session struct {
+	Target Target
+	ssh    SSHConfig
+
+	mu      sync.Mutex
+	rtt     time.Duration
+	hostKey string
+
+	once      sync.Once
+	client    *ssh.Client
+	clientErr error
+}
+
+// RTT is the round trip recorded by the reachability probe
+func (s *Session) RTT() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rtt
+}
+
+func (s *Session) setRTT(rtt time.Duration) {
+	s.mu.Lock()
+	s.rtt = rtt
+	s.mu.Unlock()
+}
+
+// HostKey is the fingerprint of the host key the node presented, empty
+// until an SSH handshake got that far
+func (s *Session) HostKey() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hostKey
+}
+
+// Client returns the SSH connection to the node, opening it on first use.
+// The host key is checked against Target.HostKey before logging in.
+func (s *Session) Client(ctx context.Context) (*ssh.Client, error) {
+	s.once.Do(func() {
+		s.client, s.clientErr = s.dial(ctx)
+	})
+	return s.client, s.clientErr
+}
+
+func (s *Session) dial(ctx context.Context) (*ssh.Client, error) {
+	if len(s.ssh.Users) == 0 || len(s.ssh.Signers) == 0 {
+		return nil, ErrNoSSHCredentials
+	}
+	var lastErr error
+	for _, user := range s.ssh.Users {
+		for start := 0; start < len(s.ssh.Signers); start += maxKeysPerAttempt {
+			signers := s.ssh.Signers[start:min(start+maxKeysPerAttempt, len(s.ssh.Signers))]
+			client, err := s.login(ctx, user, signers)
+			if err == nil {
+				return client, nil
+			}
+			var mismatch *HostKeyError
+			if errors.As(err, &mismatch) || ctx.Err() != nil || s.HostKey() == "" {
+				// Another user or key will not get past this
+				return nil, err
+			}
+			lastErr = err
+		}
+	}
+	return nil, lastErr
+}
+
+func (s *Session) login(ctx context.Context, user string, signers []ssh.Signer) (*ssh.Client, error) {
+	port, timeout := s.ssh.Port, s.ssh.Timeout
+	if port == 0 {
+		port = 22
+	}
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	config := &ssh.ClientConfig{
+		User: user,
+		Auth: []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			fingerprint := ssh.FingerprintSHA256(key)
+			s.mu.Lock()
+			s.hostKey = fingerprint
+			s.mu.Unlock()
+			if pinned := s.Target.HostKey; pinned != "" && pinned != fingerprint {
+				return &HostKeyError{Pinned: pinned, Got: fingerprint}
+			}
+			return nil
+		},
+		Timeout: timeout,
+	}
+
+	address := net.JoinHostPort(s.Target.Addr, strconv.Itoa(port))
+	dialer := net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	clientConn, chans, reqs, err := ssh.NewClientConn(conn, address, config)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return ssh.NewClient(clientConn, chans, reqs), nil
+}
+
+// Run executes command on the node and returns its standard output
+func (s *Session) Run(ctx context.Context, command string) ([]byte, error) {
+	client, err := s.Client(ctx)
+	if err != nil {
+		return nil, err
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	// Closing the session unblocks Output when ctx ends first
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			session.Close()
+		case <-done:
+		}
+	}()
+	output, err := session.Output(command)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return output, err
+}
+
+func (s *Session) close() {
+	if s.client != nil {
+		s.client.Close()
+	}
+}
@@ -0,0 +1,149 @@
+package iso
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// indexFile lists the cached images inside the cache directory
+const indexFile = "index.json"
+
+// Entry is a cached, verified image
+type Entry struct {
+	Version  string `json:"version"`
+	Name     string `json:"name"`
+	Filename string `json:"filename"`
+	URL      string `json:"url"`
+	SHA256   string `json:"sha256"`
+	Size     int64  `json:"size"`
+	// Signer is the fingerprint of the key that signed SHA256SUMS, empty
+	// when the image was accepted without a signature
+	Signer       string    `json:"signer,omitempty"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+	LastUsed     time.Time `json:"last_used"`
+
+	// Path is where the image is, filled in when the index is loaded
+	Path string `json:"-"`
+}
+
+// index is the on-disk list of cached images, keyed by release version
+type index struct {
+	Entries map[string]*Entry `json:"entries"`
+}
+
+func (m *Manager) loadIndex() (*index, error) {
+	idx := &index{Entries: make(map[string]*Entry)}
+	data, err := os.ReadFile(filepath.Join(m.dir, indexFile))
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ISO cache index: %w", err)
+	}
+	if err := json.Unmarshal(data, idx); err != nil {
+		return nil, fmt.Errorf("failed to parse ISO cache index: %w", err)
+	}
+	if idx.Entries == nil {
+		idx.Entries = make(map[string]*Entry)
+	}
+	for _, e := range idx.Entries {
+		e.Path = filepath.Join(m.dir, e.Filename)
+	}
+	return idx, nil
+}
+
+// saveIndex writes the index atomically
+func (m *Manager) saveIndex(idx *index) error {
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(m.dir, indexFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write ISO cache index: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(m.dir, indexFile))
+}
+
+// List returns the cached images, most recently used first
+func (m *Manager) List() ([]*Entry, error) {
+	idx, err := m.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	return sortedEntries(idx), nil
+}
+
+// Remove deletes a cached image
+func (m *Manager) Remove(version string) error {
+	idx, err := m.loadIndex()
+	if err != nil {
+		return err
+	}
+	e, ok := idx.Entries[version]
+	if !ok {
+		return fmt.Errorf("ISO %s is not cached", version)
+	}
+	if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	delete(idx.Entries, version)
+	return m.saveIndex(idx)
+}
+
+// Evict removes the least recently used images until the cache holds at
+// most maxBytes, never removing the versions in keep. It returns what was
+// removed.
+func (m *Manager) Evict(maxBytes int64, keep ...string) ([]*Entry, error) {
+	idx, err := m.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	kept := make(map[string]bool, len(keep))
+	for _, v := range keep {
+		kept[v] = true
+	}
+
+	var total int64
+	entries := sortedEntries(idx)
+	for _, e := range entries {
+		total += e.Size
+	}
+
+	var removed []*Entry
+	for i := len(entries) - 1; i >= 0 && total > maxBytes; i-- {
+		e := entries[i]
+		if kept[e.Version] {
+			continue
+		}
+		if err := os.Remove(e.Path); err != nil && !os.IsNotExist(err) {
+			return removed, err
+		}
+		delete(idx.Entries, e.Version)
+		total -= e.Size
+		removed = append(removed, e)
+	}
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, m.saveIndex(idx)
+}
+
+// sortedEntries orders entries by last use, newest first
+func sortedEntries(idx *index) []*Entry {
+	entries := make([]*Entry, 0, len(idx.Entries))
+	for _, e := range idx.Entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].LastUsed.Equal(entries[j].LastUsed) {
+			return entries[i].LastUsed.After(entries[j].LastUsed)
+		}
+		return entries[i].Version > entries[j].Version
+	})
+	return entries
+}
@@ -0,0 +1,190 @@
+package iso
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Progress reports what the manager is doing. Done and Total are bytes;
+// Total is 0 when the size is unknown.
+type Progress struct {
+	Stage string
+	File  string
+	Done  int64
+	Total int64
+}
+
+// Progress stages
+const (
+	StageManifest = "manifest"
+	StageDownload = "download"
+	StageVerify   = "verify"
+)
+
+// ProgressFunc receives progress updates
+type ProgressFunc func(Progress)
+
+// fetchBytes downloads a small file into memory
+func (m *Manager) fetchBytes(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	// Manifests are a few KB; anything bigger is not a manifest
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// fetchFile downloads url into dest, resuming from dest.part when a
+// previous attempt was interrupted. Failed attempts are retried with a
+// growing delay; the partial file is kept so the next run resumes too.
+func (m *Manager) fetchFile(ctx context.Context, url, dest string) error {
+	part := dest + ".part"
+	var err error
+	for attempt := 1; attempt <= m.attempts; attempt++ {
+		if err = m.fetchOnce(ctx, url, part); err == nil {
+			os.Remove(part + ".etag")
+			return os.Rename(part, dest)
+		}
+		if ctx.Err() != nil || attempt == m.attempts {
+			break
+		}
+		select {
+		case <-time.After(m.retryDelay * time.Duration(attempt)):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
+
+// fetchOnce makes one request, appending to part when the server honours
+// the range. The ETag of the first response is kept next to part and sent
+// as If-Range, so a file that changed on the mirror restarts from scratch.
+func (m *Manager) fetchOnce(ctx context.Context, url, part string) error {
+	var offset int64
+	if info, err := os.Stat(part); err == nil {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if etag, err := os.ReadFile(part + ".etag"); err == nil && len(etag) > 0 {
+			req.Header.Set("If-Range", string(etag))
+		}
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	total := resp.ContentLength
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, size, ok := parseContentRange(resp.Header.Get("Content-Range"))
+		if !ok || start != offset {
+			return fmt.Errorf("GET %s: unexpected Content-Range %q", url, resp.Header.Get("Content-Range"))
+		}
+		flags |= os.O_APPEND
+		total = size
+	case http.StatusOK:
+		// No range support, or the file changed: start over
+		offset = 0
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is not a prefix of the current one
+		os.Remove(part)
+		os.Remove(part + ".etag")
+		return fmt.Errorf("GET %s: partial download is stale, restarting", url)
+	default:
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+
+	if etag := resp.Header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		if err := os.WriteFile(part+".etag", []byte(etag), 0644); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(part, flags, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := &progressWriter{w: f, done: offset, total: total, report: func(done, total int64) {
+		m.report(Progress{Stage: StageDownload, File: url, Done: done, Total: total})
+	}}
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return fmt.Errorf("GET %s: %w", url, err)
+	}
+	if total > 0 && w.done != total {
+		return fmt.Errorf("GET %s: got %d of %d bytes", url, w.done, total)
+	}
+	return f.Close()
+}
+
+// parseContentRange reads "bytes start-end/size"
+func parseContentRange(value string) (start, size int64, ok bool) {
+	spec, found := strings.CutPrefix(value, "bytes ")
+	if !found {
+		return 0, 0, false
+	}
+	rng, total, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, false
+	}
+	first, _, found := strings.Cut(rng, "-")
+	if !found {
+		return 0, 0, false
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	if total == "*" {
+		return start, 0, true
+	}
+	size, err = strconv.ParseInt(total, 10, 64)
+	return start, size, err == nil
+}
+
+// progressWriter counts bytes written and reports at most every 256 KB
+type progressWriter struct {
+	w        io.Writer
+	done     int64
+	total    int64
+	reported int64
+	report   func(done, total int64)
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.done += int64(n)
+	if p.done-p.reported >= 256<<10 || (p.total > 0 && p.done == p.total) {
+		p.reported = p.done
+		p.report(p.done, p.total)
+	}
+	return n, err
+}
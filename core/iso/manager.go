@@ -0,0 +1,267 @@
+package iso
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+)
+
+// DefaultMaxCacheSize is how much the cache keeps before evicting images
+const DefaultMaxCacheSize int64 = 10 << 30
+
+// Manager downloads, verifies and caches images
+type Manager struct {
+	dir           string
+	client        *http.Client
+	verifier      SignatureVerifier
+	allowUnsigned bool
+	maxBytes      int64
+	attempts      int
+	retryDelay    time.Duration
+	progress      ProgressFunc
+}
+
+// NewManager creates a manager caching images in dir. Without a verifier,
+// Acquire refuses to download unless SetAllowUnsigned is used.
+func NewManager(dir string, verifier SignatureVerifier) *Manager {
+	return &Manager{
+		dir:        dir,
+		client:     &http.Client{Transport: http.DefaultTransport},
+		verifier:   verifier,
+		maxBytes:   DefaultMaxCacheSize,
+		attempts:   3,
+		retryDelay: 2 * time.Second,
+	}
+}
+
+// SetHTTPClient replaces the client used to reach the mirrors
+func (m *Manager) SetHTTPClient(c *http.Client) {
+	m.client = c
+}
+
+// SetAllowUnsigned accepts checksums without a signature; they only
+// protect against transfer errors then, not against a tampered mirror
+func (m *Manager) SetAllowUnsigned(allow bool) {
+	m.allowUnsigned = allow
+}
+
+// SetMaxCacheSize sets the cache size enforced after each download, 0
+// disables eviction
+func (m *Manager) SetMaxCacheSize(bytes int64) {
+	m.maxBytes = bytes
+}
+
+// SetRetryPolicy sets how many times a download is attempted and the base
+// delay between attempts
+func (m *Manager) SetRetryPolicy(attempts int, delay time.Duration) {
+	if attempts < 1 {
+		attempts = 1
+	}
+	m.attempts = attempts
+	m.retryDelay = delay
+}
+
+// SetProgress sets the progress callback
+func (m *Manager) SetProgress(fn ProgressFunc) {
+	m.progress = fn
+}
+
+// Dir returns the cache directory
+func (m *Manager) Dir() string {
+	return m.dir
+}
+
+// Cached returns the cached image of a release after checking its hash,
+// or nil when there is none. A corrupted image is removed.
+func (m *Manager) Cached(ctx context.Context, version string) (*Entry, error) {
+	idx, err := m.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	e, ok := idx.Entries[version]
+	if !ok {
+		return nil, nil
+	}
+
+	if err := m.verifyFile(ctx, e.Path, e.SHA256); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		os.Remove(e.Path)
+		delete(idx.Entries, version)
+		if err := m.saveIndex(idx); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	e.LastUsed = time.Now().UTC()
+	return e, m.saveIndex(idx)
+}
+
+// Verify re-hashes a cached image against the checksum it was downloaded with
+func (m *Manager) Verify(ctx context.Context, version string) (*Entry, error) {
+	idx, err := m.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	e, ok := idx.Entries[version]
+	if !ok {
+		return nil, fmt.Errorf("ISO %s is not cached", version)
+	}
+	return e, m.verifyFile(ctx, e.Path, e.SHA256)
+}
+
+// Acquire returns a verified image of the release, from the cache when it
+// is there and intact, otherwise from the mirror:
+//
+//  1. SHA256SUMS and its signature are fetched and the signature checked
+//  2. the image is downloaded, resuming an interrupted download
+//  3. the image is hashed and compared with SHA256SUMS
+//
+// An image that fails the check is deleted, never cached.
+func (m *Manager) Acquire(ctx context.Context, r Release) (*Entry, error) {
+	if e, err := m.Cached(ctx, r.Version); err != nil || e != nil {
+		return e, err
+	}
+	if m.verifier == nil && !m.allowUnsigned {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeISOSignatureInvalid, "No trusted keyring",
+			"a keyring is needed to verify "+r.url(SignatureFile))
+	}
+	if err := os.MkdirAll(m.dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create ISO cache: %w", err)
+	}
+
+	m.report(Progress{Stage: StageManifest, File: r.url(ChecksumsFile)})
+	manifest, err := m.fetchBytes(ctx, r.url(ChecksumsFile))
+	if err != nil {
+		return nil, downloadFailed(r, err)
+	}
+
+	var signer string
+	if m.verifier != nil {
+		signature, err := m.fetchBytes(ctx, r.url(SignatureFile))
+		if err != nil {
+			return nil, downloadFailed(r, err)
+		}
+		if signer, err = m.verifier.Verify(manifest, signature); err != nil {
+			return nil, coreerrors.NewAPIError(coreerrors.ErrCodeISOSignatureInvalid, "Invalid checksum signature",
+				fmt.Sprintf("%s: %v", r.url(SignatureFile), err))
+		}
+	}
+
+	sums, err := ParseChecksums(manifest)
+	if err != nil {
+		return nil, downloadFailed(r, err)
+	}
+	filename, ok := r.selectImage(sums)
+	if !ok {
+		return nil, downloadFailed(r, fmt.Errorf("no *%s image in %s", r.Suffix, ChecksumsFile))
+	}
+
+	path := filepath.Join(m.dir, filename)
+	if err := m.fetchFile(ctx, r.url(filename), path); err != nil {
+		return nil, downloadFailed(r, err)
+	}
+	if err := m.verifyFile(ctx, path, sums[filename]); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	e := &Entry{
+		Version:      r.Version,
+		Name:         r.Name,
+		Filename:     filename,
+		URL:          r.url(filename),
+		SHA256:       sums[filename],
+		Size:         info.Size(),
+		Signer:       signer,
+		DownloadedAt: now,
+		LastUsed:     now,
+		Path:         path,
+	}
+
+	idx, err := m.loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	if old, ok := idx.Entries[r.Version]; ok && old.Filename != filename {
+		os.Remove(old.Path)
+	}
+	idx.Entries[r.Version] = e
+	if err := m.saveIndex(idx); err != nil {
+		return nil, err
+	}
+
+	if m.maxBytes > 0 {
+		if _, err := m.Evict(m.maxBytes, r.Version); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// verifyFile hashes path and compares it with the expected hex digest
+func (m *Manager) verifyFile(ctx context.Context, path, expected string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var total int64
+	if info, err := f.Stat(); err == nil {
+		total = info.Size()
+	}
+	h := sha256.New()
+	w := &progressWriter{w: h, total: total, report: func(done, total int64) {
+		m.report(Progress{Stage: StageVerify, File: path, Done: done, Total: total})
+	}}
+	if _, err := io.Copy(w, &contextReader{ctx: ctx, r: f}); err != nil {
+		return err
+	}
+
+	if actual := hex.EncodeToString(h.Sum(nil)); actual != expected {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeISOChecksumMismatch, "ISO checksum mismatch",
+			fmt.Sprintf("%s: expected %s, got %s", filepath.Base(path), expected, actual))
+	}
+	return nil
+}
+
+func (m *Manager) report(p Progress) {
+	if m.progress != nil {
+		m.progress(p)
+	}
+}
+
+func downloadFailed(r Release, err error) error {
+	return coreerrors.NewAPIError(coreerrors.ErrCodeISODownloadFailed, "ISO download failed",
+		fmt.Sprintf("%s: %v", r.Name, err))
+}
+
+// contextReader stops reading once the context is done, so hashing a
+// multi-GB image can be interrupted
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
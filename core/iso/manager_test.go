@@ -0,0 +1,342 @@
+package iso_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/iso"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+const imageName = "ubuntu-24.04.1-live-server-amd64.iso"
+
+// mirror serves a release directory. The first download of the image is
+// cut halfway so the manager has to resume it.
+type mirror struct {
+	image    string
+	files    map[string][]byte
+	truncate bool
+
+	mu     sync.Mutex
+	ranges []string
+}
+
+func (m *mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := path.Base(r.URL.Path)
+	data, ok := m.files[name]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("ETag", fmt.Sprintf(`"%x"`, sha256.Sum256(data)))
+
+	if name == m.image {
+		m.mu.Lock()
+		m.ranges = append(m.ranges, r.Header.Get("Range"))
+		cut := m.truncate
+		m.truncate = false
+		m.mu.Unlock()
+
+		if cut {
+			w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+			w.WriteHeader(http.StatusOK)
+			w.Write(data[:len(data)/2])
+			w.(http.Flusher).Flush()
+			panic(http.ErrAbortHandler)
+		}
+	}
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(data))
+}
+
+func newKey(t *testing.T) (*openpgp.Entity, []byte) {
+	t.Helper()
+	entity, err := openpgp.NewEntity("Test CD Image Signing", "", "cdimage@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keyring bytes.Buffer
+	if err := entity.Serialize(&keyring); err != nil {
+		t.Fatal(err)
+	}
+	return entity, keyring.Bytes()
+}
+
+func sign(t *testing.T, signer *openpgp.Entity, data []byte) []byte {
+	t.Helper()
+	var sig bytes.Buffer
+	if err := openpgp.DetachSign(&sig, signer, bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	return sig.Bytes()
+}
+
+// newMirror publishes a 24.04 image with a SHA256SUMS signed by signer
+func newMirror(t *testing.T, image []byte, signer *openpgp.Entity) (*mirror, iso.Release) {
+	return newMirrorFor(t, "24.04", image, signer)
+}
+
+func newMirrorFor(t *testing.T, version string, image []byte, signer *openpgp.Entity) (*mirror, iso.Release) {
+	t.Helper()
+	name := "ubuntu-" + version + ".1-live-server-amd64.iso"
+	digest := sha256.Sum256(image)
+	sums := []byte(hex.EncodeToString(digest[:]) + " *" + name + "\n" +
+		strings.Repeat("0", 64) + " *ubuntu-" + version + ".1-desktop-amd64.iso\n")
+
+	m := &mirror{image: name, files: map[string][]byte{
+		name:              image,
+		iso.ChecksumsFile: sums,
+		iso.SignatureFile: sign(t, signer, sums),
+	}}
+	srv := httptest.NewServer(m)
+	t.Cleanup(srv.Close)
+
+	return m, iso.Release{
+		Version: version,
+		Name:    "Ubuntu " + version + " LTS Server",
+		BaseURL: srv.URL + "/" + version + "/",
+		Suffix:  "-live-server-amd64.iso",
+	}
+}
+
+func newManager(t *testing.T, dir string, keyring []byte) *iso.Manager {
+	t.Helper()
+	verifier, err := iso.NewGPGVerifier(keyring)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := iso.NewManager(dir, verifier)
+	m.SetRetryPolicy(3, 0)
+	return m
+}
+
+func testImage(size int) []byte {
+	image := make([]byte, size)
+	for i := range image {
+		image[i] = byte(i * 7)
+	}
+	return image
+}
+
+func TestAcquireResumesAndCaches(t *testing.T) {
+	signer, keyring := newKey(t)
+	image := testImage(1 << 20)
+	srv, release := newMirror(t, image, signer)
+	srv.truncate = true
+
+	dir := t.TempDir()
+	m := newManager(t, dir, keyring)
+	var stages []string
+	m.SetProgress(func(p iso.Progress) {
+		if len(stages) == 0 || stages[len(stages)-1] != p.Stage {
+			stages = append(stages, p.Stage)
+		}
+	})
+
+	e, err := m.Acquire(context.Background(), release)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if e.Filename != imageName || e.Size != int64(len(image)) {
+		t.Fatalf("entry = %+v", e)
+	}
+	if e.Signer != fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint) {
+		t.Errorf("signer = %s", e.Signer)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, imageName))
+	if err != nil || !bytes.Equal(data, image) {
+		t.Fatalf("cached image differs from the mirror (err %v)", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, imageName+".part")); !os.IsNotExist(err) {
+		t.Errorf("partial file left behind")
+	}
+
+	want := []string{"", fmt.Sprintf("bytes=%d-", len(image)/2)}
+	if fmt.Sprint(srv.ranges) != fmt.Sprint(want) {
+		t.Errorf("range requests = %q, want %q", srv.ranges, want)
+	}
+	if fmt.Sprint(stages) != "[manifest download verify]" {
+		t.Errorf("stages = %v", stages)
+	}
+
+	// A second acquire is served from the cache
+	if _, err := m.Acquire(context.Background(), release); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.ranges) != 2 {
+		t.Errorf("cache hit downloaded again: %q", srv.ranges)
+	}
+
+	entries, err := m.List()
+	if err != nil || len(entries) != 1 || entries[0].Version != "24.04" {
+		t.Fatalf("List = %v, %v", entries, err)
+	}
+	if _, err := m.Verify(context.Background(), "24.04"); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+}
+
+func TestAcquireRedownloadsCorruptedCache(t *testing.T) {
+	signer, keyring := newKey(t)
+	image := testImage(4096)
+	srv, release := newMirror(t, image, signer)
+
+	dir := t.TempDir()
+	m := newManager(t, dir, keyring)
+	if _, err := m.Acquire(context.Background(), release); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, imageName), []byte("corrupted"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Verify(context.Background(), "24.04"); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeISOChecksumMismatch {
+		t.Errorf("Verify of corrupted image = %v", err)
+	}
+	if _, err := m.Acquire(context.Background(), release); err != nil {
+		t.Fatal(err)
+	}
+	if len(srv.ranges) != 2 {
+		t.Errorf("corrupted image was not downloaded again: %q", srv.ranges)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, imageName)); !bytes.Equal(data, image) {
+		t.Errorf("image not restored")
+	}
+}
+
+func TestAcquireRejectsChecksumMismatch(t *testing.T) {
+	signer, keyring := newKey(t)
+	srv, release := newMirror(t, testImage(4096), signer)
+	// The mirror serves a different image than the one that was signed
+	srv.files[imageName] = []byte("tampered image")
+
+	dir := t.TempDir()
+	_, err := newManager(t, dir, keyring).Acquire(context.Background(), release)
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeISOChecksumMismatch {
+		t.Fatalf("err = %v, want checksum mismatch", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, imageName)); !os.IsNotExist(err) {
+		t.Errorf("mismatched image was kept")
+	}
+}
+
+func TestAcquireRejectsBadSignature(t *testing.T) {
+	signer, keyring := newKey(t)
+	_, otherKeyring := newKey(t)
+	srv, release := newMirror(t, testImage(4096), signer)
+
+	_, err := newManager(t, t.TempDir(), otherKeyring).Acquire(context.Background(), release)
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeISOSignatureInvalid {
+		t.Fatalf("untrusted signer: err = %v", err)
+	}
+
+	// Manifest edited after signing
+	srv.files[iso.ChecksumsFile] = append(srv.files[iso.ChecksumsFile], '\n')
+	_, err = newManager(t, t.TempDir(), keyring).Acquire(context.Background(), release)
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeISOSignatureInvalid {
+		t.Fatalf("edited manifest: err = %v", err)
+	}
+	if len(srv.ranges) != 0 {
+		t.Errorf("image downloaded despite the bad signature")
+	}
+}
+
+func TestAcquireWithoutKeyring(t *testing.T) {
+	signer, _ := newKey(t)
+	srv, release := newMirror(t, testImage(4096), signer)
+
+	m := iso.NewManager(t.TempDir(), nil)
+	if _, err := m.Acquire(context.Background(), release); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeISOSignatureInvalid {
+		t.Fatalf("err = %v, want refusal without keyring", err)
+	}
+	if len(srv.ranges) != 0 {
+		t.Fatalf("image downloaded without keyring")
+	}
+
+	m.SetAllowUnsigned(true)
+	e, err := m.Acquire(context.Background(), release)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Signer != "" {
+		t.Errorf("unsigned entry has signer %q", e.Signer)
+	}
+}
+
+func TestAcquireReportsMissingImage(t *testing.T) {
+	signer, keyring := newKey(t)
+	_, release := newMirror(t, testImage(4096), signer)
+	release.Suffix = "-arm64.iso"
+
+	_, err := newManager(t, t.TempDir(), keyring).Acquire(context.Background(), release)
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeISODownloadFailed {
+		t.Fatalf("err = %v, want download failure", err)
+	}
+}
+
+func TestEvictLeastRecentlyUsed(t *testing.T) {
+	signer, keyring := newKey(t)
+	dir := t.TempDir()
+	m := newManager(t, dir, keyring)
+	m.SetMaxCacheSize(0)
+
+	for i, v := range []string{"20.04", "22.04", "24.04"} {
+		_, release := newMirrorFor(t, v, testImage(1000*(i+1)), signer)
+		if _, err := m.Acquire(context.Background(), release); err != nil {
+			t.Fatal(err)
+		}
+		// LastUsed has to differ between entries
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Touch 20.04 so 22.04 becomes the least recently used
+	if e, err := m.Cached(context.Background(), "20.04"); err != nil || e == nil {
+		t.Fatalf("Cached(20.04) = %v, %v", e, err)
+	}
+
+	removed, err := m.Evict(4500)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].Version != "22.04" {
+		t.Fatalf("removed = %v", removed)
+	}
+
+	removed, err = m.Evict(0, "24.04")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].Version != "20.04" {
+		t.Fatalf("removed = %v", removed)
+	}
+	entries, _ := m.List()
+	if len(entries) != 1 || entries[0].Version != "24.04" {
+		t.Fatalf("remaining = %v", entries)
+	}
+}
+
+func TestParseChecksums(t *testing.T) {
+	hash := strings.Repeat("ab", 32)
+	sums, err := iso.ParseChecksums([]byte("# comment\n" + hash + " *a.iso\n" + strings.ToUpper(hash) + "  b.iso\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sums["a.iso"] != hash || sums["b.iso"] != hash {
+		t.Errorf("sums = %v", sums)
+	}
+	if _, err := iso.ParseChecksums([]byte("nothex a.iso\n")); err == nil {
+		t.Error("invalid line accepted")
+	}
+}
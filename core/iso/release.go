@@ -0,0 +1,106 @@
+// Package iso downloads Ubuntu images, verifies them against the signed
+// SHA256SUMS manifest and keeps them in a local cache.
+package iso
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Manifest files published next to every Ubuntu image
+const (
+	ChecksumsFile = "SHA256SUMS"
+	SignatureFile = "SHA256SUMS.gpg"
+)
+
+// Release is an image series on a mirror. The exact file is looked up in
+// the release's SHA256SUMS, so point releases are picked up automatically.
+type Release struct {
+	// Version identifies the release in the cache, e.g. "24.04"
+	Version string `json:"version"`
+	Name    string `json:"name"`
+	// BaseURL is the directory holding the image, SHA256SUMS and SHA256SUMS.gpg
+	BaseURL string `json:"base_url"`
+	// Suffix selects the image in SHA256SUMS, e.g. "-live-server-amd64.iso"
+	Suffix string `json:"suffix"`
+}
+
+// DefaultReleases are the supported Ubuntu Server releases, newest first
+var DefaultReleases = []Release{
+	{
+		Version: "24.04",
+		Name:    "Ubuntu 24.04 LTS Server",
+		BaseURL: "https://releases.ubuntu.com/24.04/",
+		Suffix:  "-live-server-amd64.iso",
+	},
+	{
+		Version: "22.04",
+		Name:    "Ubuntu 22.04 LTS Server",
+		BaseURL: "https://releases.ubuntu.com/22.04/",
+		Suffix:  "-live-server-amd64.iso",
+	},
+	{
+		Version: "20.04",
+		Name:    "Ubuntu 20.04 LTS Server",
+		BaseURL: "https://releases.ubuntu.com/20.04/",
+		Suffix:  "-live-server-amd64.iso",
+	},
+}
+
+// FindRelease returns the default release with the given version
+func FindRelease(version string) (Release, bool) {
+	for _, r := range DefaultReleases {
+		if r.Version == version {
+			return r, true
+		}
+	}
+	return Release{}, false
+}
+
+// url returns the address of a file in the release directory
+func (r Release) url(file string) string {
+	return strings.TrimRight(r.BaseURL, "/") + "/" + file
+}
+
+// ParseChecksums reads a SHA256SUMS file into a map of file name to
+// lowercase hex digest. Both the text (" ") and binary ("*") markers are
+// accepted.
+func ParseChecksums(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash, name, ok := strings.Cut(text, " ")
+		name = strings.TrimPrefix(strings.TrimLeft(name, " "), "*")
+		if !ok || len(hash) != 64 || name == "" {
+			return nil, fmt.Errorf("invalid %s line %d", ChecksumsFile, line)
+		}
+		sums[name] = strings.ToLower(hash)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return sums, nil
+}
+
+// selectImage picks the release's image from the checksums. When several
+// point releases are listed the last one in name order wins.
+func (r Release) selectImage(sums map[string]string) (string, bool) {
+	var names []string
+	for name := range sums {
+		if strings.HasSuffix(name, r.Suffix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", false
+	}
+	sort.Strings(names)
+	return names[len(names)-1], true
+}
@@ -0,0 +1,74 @@
+package iso
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/openpgp"
+)
+
+// SignatureVerifier checks the detached signature of the checksums manifest
+type SignatureVerifier interface {
+	// Verify returns the fingerprint of the key that signed manifest
+	Verify(manifest, signature []byte) (signer string, err error)
+}
+
+// GPGVerifier verifies OpenPGP signatures against a trusted keyring. The
+// x/crypto openpgp package is frozen, but checking detached signatures is
+// all it is needed for here.
+type GPGVerifier struct {
+	keyring openpgp.EntityList
+}
+
+// NewGPGVerifier creates a verifier from an armored or binary keyring,
+// such as the output of "gpg --export"
+func NewGPGVerifier(keyring []byte) (*GPGVerifier, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(keyring))
+	if err != nil {
+		entities, err = openpgp.ReadKeyRing(bytes.NewReader(keyring))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("keyring has no keys")
+	}
+	return &GPGVerifier{keyring: entities}, nil
+}
+
+// LoadGPGVerifier reads the keyring from the first of paths that exists
+func LoadGPGVerifier(paths ...string) (*GPGVerifier, string, error) {
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		v, err := NewGPGVerifier(data)
+		if err != nil {
+			return nil, "", fmt.Errorf("%s: %w", path, err)
+		}
+		return v, path, nil
+	}
+	return nil, "", os.ErrNotExist
+}
+
+// Verify checks an armored or binary detached signature
+func (v *GPGVerifier) Verify(manifest, signature []byte) (string, error) {
+	var (
+		signer *openpgp.Entity
+		err    error
+	)
+	if bytes.HasPrefix(bytes.TrimSpace(signature), []byte("-----BEGIN")) {
+		signer, err = openpgp.CheckArmoredDetachedSignature(v.keyring, bytes.NewReader(manifest), bytes.NewReader(signature))
+	} else {
+		signer, err = openpgp.CheckDetachedSignature(v.keyring, bytes.NewReader(manifest), bytes.NewReader(signature))
+	}
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint), nil
+}
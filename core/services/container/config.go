@@ -0,0 +1,219 @@
+package container
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"syntropy-cc/cooperative-grid/core/types/constants"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// ParsePorts parses port flags of the form "host:container[/protocol]" or
+// "container[/protocol]", where the host port defaults to the container port
+func ParsePorts(specs []string) ([]models.PortMapping, error) {
+	var ports []models.PortMapping
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		protocol := constants.NetworkProtocolTCP
+		if i := strings.LastIndex(spec, "/"); i >= 0 {
+			protocol = strings.ToLower(spec[i+1:])
+			spec = spec[:i]
+		}
+		if protocol != constants.NetworkProtocolTCP && protocol != constants.NetworkProtocolUDP {
+			return nil, invalidFlag("port", spec, "protocol must be tcp or udp")
+		}
+
+		parts := strings.Split(spec, ":")
+		if len(parts) > 2 {
+			return nil, invalidFlag("port", spec, "expected host:container")
+		}
+		containerPort, err := parsePort(parts[len(parts)-1])
+		if err != nil {
+			return nil, invalidFlag("port", spec, err.Error())
+		}
+		hostPort := containerPort
+		if len(parts) == 2 {
+			if hostPort, err = parsePort(parts[0]); err != nil {
+				return nil, invalidFlag("port", spec, err.Error())
+			}
+		}
+
+		ports = append(ports, models.PortMapping{
+			HostPort:      hostPort,
+			ContainerPort: containerPort,
+			Protocol:      protocol,
+		})
+	}
+	return ports, nil
+}
+
+// ParseEnv parses environment flags of the form "KEY=VALUE"
+func ParseEnv(specs []string) (map[string]string, error) {
+	env := make(map[string]string, len(specs))
+	for _, spec := range specs {
+		key, value, ok := strings.Cut(spec, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, invalidFlag("env", spec, "expected KEY=VALUE")
+		}
+		env[key] = value
+	}
+	return env, nil
+}
+
+// ParseVolumes parses volume flags of the form "host:container[:ro|rw]".
+// Both paths must be absolute.
+func ParseVolumes(specs []string) ([]models.VolumeMapping, error) {
+	var volumes []models.VolumeMapping
+	for _, spec := range specs {
+		parts := strings.Split(spec, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, invalidFlag("volume", spec, "expected host:container[:ro]")
+		}
+
+		v := models.VolumeMapping{HostPath: parts[0], ContainerPath: parts[1]}
+		if !strings.HasPrefix(v.HostPath, "/") || !strings.HasPrefix(v.ContainerPath, "/") {
+			return nil, invalidFlag("volume", spec, "paths must be absolute")
+		}
+		if len(parts) == 3 {
+			switch parts[2] {
+			case "ro":
+				v.ReadOnly = true
+			case "rw":
+			default:
+				return nil, invalidFlag("volume", spec, "mode must be ro or rw")
+			}
+		}
+		volumes = append(volumes, v)
+	}
+	return volumes, nil
+}
+
+// ParseCPU converts a CPU quantity such as "500m", "0.5" or "2" to millicores
+func ParseCPU(quantity string) (int64, error) {
+	q := strings.TrimSpace(quantity)
+	if strings.HasSuffix(q, "m") {
+		n, err := strconv.ParseInt(strings.TrimSuffix(q, "m"), 10, 64)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid CPU quantity %q", quantity)
+		}
+		return n, nil
+	}
+	f, err := strconv.ParseFloat(q, 64)
+	if err != nil || f <= 0 {
+		return 0, fmt.Errorf("invalid CPU quantity %q", quantity)
+	}
+	return int64(math.Round(f * 1000)), nil
+}
+
+// memoryUnits are the suffixes accepted by ParseMemory, longest first
+var memoryUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"Ki", 1 << 10}, {"Mi", 1 << 20}, {"Gi", 1 << 30}, {"Ti", 1 << 40},
+	{"K", 1000}, {"M", 1000 * 1000}, {"G", 1000 * 1000 * 1000}, {"T", 1000 * 1000 * 1000 * 1000},
+}
+
+// ParseMemory converts a size such as "128Mi", "4Gi", "512M" or "1048576" to bytes
+func ParseMemory(quantity string) (int64, error) {
+	q := strings.TrimSpace(quantity)
+	factor := int64(1)
+	for _, u := range memoryUnits {
+		if strings.HasSuffix(q, u.suffix) {
+			factor = u.factor
+			q = strings.TrimSuffix(q, u.suffix)
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(q, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid memory quantity %q", quantity)
+	}
+	return int64(n * float64(factor)), nil
+}
+
+// NormalizeResources fills in default limits and rejects anything above
+// constants.MaxCPU or constants.MaxMemory. Disk is validated but not
+// enforced, since most container storage drivers cannot cap it.
+func NormalizeResources(r models.ResourceLimits) (models.ResourceLimits, error) {
+	if r.CPU == "" {
+		r.CPU = constants.DefaultCPU
+	}
+	if r.Memory == "" {
+		r.Memory = constants.DefaultMemory
+	}
+
+	cpu, err := ParseCPU(r.CPU)
+	if err != nil {
+		return r, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid resource limits", err.Error())
+	}
+	maxCPU, _ := ParseCPU(constants.MaxCPU)
+	if cpu > maxCPU {
+		return r, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Resource limit exceeded",
+			fmt.Sprintf("cpu %s is above the maximum of %s", r.CPU, constants.MaxCPU))
+	}
+
+	memory, err := ParseMemory(r.Memory)
+	if err != nil {
+		return r, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid resource limits", err.Error())
+	}
+	maxMemory, _ := ParseMemory(constants.MaxMemory)
+	if memory > maxMemory {
+		return r, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Resource limit exceeded",
+			fmt.Sprintf("memory %s is above the maximum of %s", r.Memory, constants.MaxMemory))
+	}
+
+	if r.Disk != "" {
+		if _, err := ParseMemory(r.Disk); err != nil {
+			return r, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid resource limits", err.Error())
+		}
+	}
+	return r, nil
+}
+
+// EncodeConfig converts a ContainerConfig to the generic map stored on models.Container
+func EncodeConfig(cfg *models.ContainerConfig) (map[string]interface{}, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode container config: %w", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to encode container config: %w", err)
+	}
+	return m, nil
+}
+
+// DecodeConfig reads the ContainerConfig stored on a container
+func DecodeConfig(c *models.Container) (*models.ContainerConfig, error) {
+	cfg := &models.ContainerConfig{}
+	if c.Config == nil {
+		return cfg, nil
+	}
+	data, err := json.Marshal(c.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode container config: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode container config: %w", err)
+	}
+	return cfg, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %q must be between 1 and 65535", s)
+	}
+	return port, nil
+}
+
+func invalidFlag(flag, spec, reason string) error {
+	return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput,
+		fmt.Sprintf("Invalid --%s value", flag), fmt.Sprintf("%q: %s", spec, reason))
+}
@@ -0,0 +1,78 @@
+package container_test
+
+import (
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/services/container"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+func TestParsePorts(t *testing.T) {
+	ports, err := container.ParsePorts([]string{"8080:80", "53/udp", "9000:9001/TCP"})
+	if err != nil {
+		t.Fatalf("ParsePorts: %v", err)
+	}
+	want := []models.PortMapping{
+		{HostPort: 8080, ContainerPort: 80, Protocol: "tcp"},
+		{HostPort: 53, ContainerPort: 53, Protocol: "udp"},
+		{HostPort: 9000, ContainerPort: 9001, Protocol: "tcp"},
+	}
+	if len(ports) != len(want) {
+		t.Fatalf("got %d ports, want %d", len(ports), len(want))
+	}
+	for i := range want {
+		if ports[i] != want[i] {
+			t.Errorf("port %d = %+v, want %+v", i, ports[i], want[i])
+		}
+	}
+
+	for _, bad := range []string{"80:70000", "1:2:3", "http", "80/sctp"} {
+		if _, err := container.ParsePorts([]string{bad}); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeInvalidInput {
+			t.Errorf("ParsePorts(%q) error = %v, want invalid input", bad, err)
+		}
+	}
+}
+
+func TestParseEnvAndVolumes(t *testing.T) {
+	env, err := container.ParseEnv([]string{"A=1", "URL=http://x?a=b"})
+	if err != nil {
+		t.Fatalf("ParseEnv: %v", err)
+	}
+	if env["A"] != "1" || env["URL"] != "http://x?a=b" {
+		t.Fatalf("unexpected env: %v", env)
+	}
+	if _, err := container.ParseEnv([]string{"NOVALUE"}); err == nil {
+		t.Fatal("ParseEnv accepted a flag without '='")
+	}
+
+	volumes, err := container.ParseVolumes([]string{"/data:/var/lib/data:ro", "/tmp:/tmp"})
+	if err != nil {
+		t.Fatalf("ParseVolumes: %v", err)
+	}
+	if !volumes[0].ReadOnly || volumes[1].ReadOnly || volumes[0].ContainerPath != "/var/lib/data" {
+		t.Fatalf("unexpected volumes: %+v", volumes)
+	}
+	if _, err := container.ParseVolumes([]string{"data:/data"}); err == nil {
+		t.Fatal("ParseVolumes accepted a relative host path")
+	}
+}
+
+func TestNormalizeResources(t *testing.T) {
+	r, err := container.NormalizeResources(models.ResourceLimits{})
+	if err != nil {
+		t.Fatalf("NormalizeResources: %v", err)
+	}
+	if r.CPU != "100m" || r.Memory != "128Mi" {
+		t.Fatalf("defaults not applied: %+v", r)
+	}
+
+	if _, err := container.NormalizeResources(models.ResourceLimits{CPU: "1.5", Memory: "4Gi"}); err != nil {
+		t.Fatalf("limits at the maximum rejected: %v", err)
+	}
+	for _, r := range []models.ResourceLimits{{CPU: "3"}, {Memory: "5Gi"}, {CPU: "abc"}} {
+		if _, err := container.NormalizeResources(r); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeInvalidInput {
+			t.Errorf("NormalizeResources(%+v) error = %v, want invalid input", r, err)
+		}
+	}
+}
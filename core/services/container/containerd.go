@@ -0,0 +1,167 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+const (
+	// containerdNamespace keeps grid containers apart from other containerd users
+	containerdNamespace = "syntropy"
+	// containerdLogDir holds task output, since containerd keeps no logs itself
+	containerdLogDir = "/var/log/syntropy/containers"
+)
+
+// ContainerdRuntime drives containerd through the ctr CLI on the nodes.
+// ctr has no port publishing, so containers with ports use the host
+// network and their host and container ports must match.
+type ContainerdRuntime struct {
+	exec Executor
+}
+
+var _ Runtime = (*ContainerdRuntime)(nil)
+
+// NewContainerdRuntime creates a containerd driver running commands through exec
+func NewContainerdRuntime(exec Executor) *ContainerdRuntime {
+	return &ContainerdRuntime{exec: exec}
+}
+
+// Name implements Runtime
+func (r *ContainerdRuntime) Name() string { return RuntimeContainerd }
+
+// Pull implements Runtime
+func (r *ContainerdRuntime) Pull(ctx context.Context, nodeID, image string) error {
+	_, err := run(ctx, r.exec, r.Name(), "pull", nodeID, r.ctr("images", "pull", qualifyImage(image)))
+	return err
+}
+
+// Create implements Runtime
+func (r *ContainerdRuntime) Create(ctx context.Context, c *models.Container, cfg *models.ContainerConfig) error {
+	cpu, err := ParseCPU(cfg.Resources.CPU)
+	if err != nil {
+		return runtimeFailure(r.Name(), "create", c.NodeID, err, "")
+	}
+	memory, err := ParseMemory(cfg.Resources.Memory)
+	if err != nil {
+		return runtimeFailure(r.Name(), "create", c.NodeID, err, "")
+	}
+
+	args := []string{
+		"containers", "create",
+		"--label", labelContainerID + "=" + c.ID,
+		"--cpus", fmt.Sprintf("%.3f", float64(cpu)/1000),
+		"--memory-limit", fmt.Sprintf("%d", memory),
+	}
+	if len(cfg.Ports) > 0 {
+		for _, p := range cfg.Ports {
+			if p.HostPort != p.ContainerPort {
+				return runtimeFailure(r.Name(), "create", c.NodeID,
+					fmt.Errorf("port %d:%d cannot be remapped, containerd containers use the host network", p.HostPort, p.ContainerPort), "")
+			}
+		}
+		args = append(args, "--net-host")
+	}
+	for _, k := range sortedKeys(cfg.EnvVars) {
+		args = append(args, "--env", k+"="+cfg.EnvVars[k])
+	}
+	for _, v := range cfg.Volumes {
+		mode := "rw"
+		if v.ReadOnly {
+			mode = "ro"
+		}
+		args = append(args, "--mount",
+			fmt.Sprintf("type=bind,src=%s,dst=%s,options=rbind:%s", v.HostPath, v.ContainerPath, mode))
+	}
+	args = append(args, qualifyImage(c.Image), c.Name)
+
+	_, err = run(ctx, r.exec, r.Name(), "create", c.NodeID, r.ctr(args...))
+	return err
+}
+
+// Start implements Runtime. A task left over from a previous run is
+// removed first, since containerd only allows one task per container.
+func (r *ContainerdRuntime) Start(ctx context.Context, nodeID, name string) error {
+	command := strings.Join([]string{
+		r.ctr("tasks", "delete", name) + " >/dev/null 2>&1",
+		shellJoin("sudo", "-n", "mkdir", "-p", containerdLogDir),
+		r.ctr("tasks", "start", "-d", "--log-uri", "file://"+r.logFile(name), name),
+	}, "; ")
+	_, err := run(ctx, r.exec, r.Name(), "start", nodeID, command)
+	return err
+}
+
+// Stop implements Runtime. ctr has no graceful stop with a timeout, so the
+// task is killed and removed.
+func (r *ContainerdRuntime) Stop(ctx context.Context, nodeID, name string) error {
+	_, err := run(ctx, r.exec, r.Name(), "stop", nodeID, r.ctr("tasks", "delete", "-f", name))
+	return err
+}
+
+// Logs implements Runtime
+func (r *ContainerdRuntime) Logs(ctx context.Context, nodeID, name string, tail int, follow bool, w io.Writer) error {
+	args := []string{"sudo", "-n", "tail", "-n", fmt.Sprintf("%d", tail)}
+	if follow {
+		args = append(args, "-F")
+	}
+	if err := r.exec.Stream(ctx, nodeID, shellJoin(append(args, r.logFile(name))...), w); err != nil {
+		return runtimeFailure(r.Name(), "logs", nodeID, err, "")
+	}
+	return nil
+}
+
+// Inspect implements Runtime. A container without a task has never been
+// started or was stopped, and is reported as created.
+func (r *ContainerdRuntime) Inspect(ctx context.Context, nodeID, name string) (models.ContainerStatus, error) {
+	command := fmt.Sprintf(`s=$(%s | awk -v n=%s '$1 == n {print $3}'); if [ -n "$s" ]; then echo "$s"; else %s >/dev/null && echo CREATED; fi`,
+		r.ctr("tasks", "ls"), shellQuote(name), r.ctr("containers", "info", name))
+	out, err := run(ctx, r.exec, r.Name(), "inspect", nodeID, command)
+	if err != nil {
+		return models.ContainerStatusError, err
+	}
+	return mapContainerdState(out), nil
+}
+
+// ctr builds a ctr command in the grid namespace. ctr talks to the
+// containerd socket directly, which requires root.
+func (r *ContainerdRuntime) ctr(args ...string) string {
+	return shellJoin(append([]string{"sudo", "-n", "ctr", "-n", containerdNamespace}, args...)...)
+}
+
+func (r *ContainerdRuntime) logFile(name string) string {
+	return containerdLogDir + "/" + name + ".log"
+}
+
+// mapContainerdState maps a containerd task status to a container status
+func mapContainerdState(state string) models.ContainerStatus {
+	switch strings.ToUpper(strings.TrimSpace(state)) {
+	case "RUNNING":
+		return models.ContainerStatusRunning
+	case "CREATED", "STOPPED", "PAUSED", "PAUSING":
+		return models.ContainerStatusStopped
+	default:
+		return models.ContainerStatusError
+	}
+}
+
+// qualifyImage expands short docker image references, which ctr does not
+// resolve, e.g. "nginx" becomes "docker.io/library/nginx:latest"
+func qualifyImage(image string) string {
+	ref := image
+	first, rest, found := strings.Cut(ref, "/")
+	switch {
+	case !found:
+		ref = "docker.io/library/" + ref
+	case !strings.ContainsAny(first, ".:") && first != "localhost":
+		ref = "docker.io/" + first + "/" + rest
+	}
+
+	name := ref[strings.LastIndex(ref, "/")+1:]
+	if !strings.ContainsAny(name, ":@") {
+		ref += ":latest"
+	}
+	return ref
+}
@@ -0,0 +1,128 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// DockerRuntime drives the docker CLI on the nodes
+type DockerRuntime struct {
+	exec Executor
+}
+
+var _ Runtime = (*DockerRuntime)(nil)
+
+// NewDockerRuntime creates a Docker driver running commands through exec
+func NewDockerRuntime(exec Executor) *DockerRuntime {
+	return &DockerRuntime{exec: exec}
+}
+
+// Name implements Runtime
+func (d *DockerRuntime) Name() string { return RuntimeDocker }
+
+// Pull implements Runtime
+func (d *DockerRuntime) Pull(ctx context.Context, nodeID, image string) error {
+	_, err := run(ctx, d.exec, d.Name(), "pull", nodeID, shellJoin("docker", "pull", image))
+	return err
+}
+
+// Create implements Runtime
+func (d *DockerRuntime) Create(ctx context.Context, c *models.Container, cfg *models.ContainerConfig) error {
+	cpu, err := ParseCPU(cfg.Resources.CPU)
+	if err != nil {
+		return runtimeFailure(d.Name(), "create", c.NodeID, err, "")
+	}
+	memory, err := ParseMemory(cfg.Resources.Memory)
+	if err != nil {
+		return runtimeFailure(d.Name(), "create", c.NodeID, err, "")
+	}
+
+	args := []string{
+		"docker", "create",
+		"--name", c.Name,
+		"--label", labelContainerID + "=" + c.ID,
+		"--restart", "unless-stopped",
+		"--cpus", fmt.Sprintf("%.3f", float64(cpu)/1000),
+		"--memory", fmt.Sprintf("%d", memory),
+	}
+	for _, p := range cfg.Ports {
+		args = append(args, "-p", fmt.Sprintf("%d:%d/%s", p.HostPort, p.ContainerPort, p.Protocol))
+	}
+	for _, k := range sortedKeys(cfg.EnvVars) {
+		args = append(args, "-e", k+"="+cfg.EnvVars[k])
+	}
+	for _, v := range cfg.Volumes {
+		mount := v.HostPath + ":" + v.ContainerPath
+		if v.ReadOnly {
+			mount += ":ro"
+		}
+		args = append(args, "-v", mount)
+	}
+	args = append(args, c.Image)
+
+	_, err = run(ctx, d.exec, d.Name(), "create", c.NodeID, shellJoin(args...))
+	return err
+}
+
+// Start implements Runtime
+func (d *DockerRuntime) Start(ctx context.Context, nodeID, name string) error {
+	_, err := run(ctx, d.exec, d.Name(), "start", nodeID, shellJoin("docker", "start", name))
+	return err
+}
+
+// Stop implements Runtime
+func (d *DockerRuntime) Stop(ctx context.Context, nodeID, name string) error {
+	_, err := run(ctx, d.exec, d.Name(), "stop", nodeID, shellJoin("docker", "stop", name))
+	return err
+}
+
+// Logs implements Runtime
+func (d *DockerRuntime) Logs(ctx context.Context, nodeID, name string, tail int, follow bool, w io.Writer) error {
+	args := []string{"docker", "logs", "--tail", fmt.Sprintf("%d", tail)}
+	if follow {
+		args = append(args, "-f")
+	}
+	if err := d.exec.Stream(ctx, nodeID, shellJoin(append(args, name)...), w); err != nil {
+		return runtimeFailure(d.Name(), "logs", nodeID, err, "")
+	}
+	return nil
+}
+
+// Inspect implements Runtime
+func (d *DockerRuntime) Inspect(ctx context.Context, nodeID, name string) (models.ContainerStatus, error) {
+	out, err := run(ctx, d.exec, d.Name(), "inspect", nodeID,
+		shellJoin("docker", "inspect", "-f", "{{.State.Status}}", name))
+	if err != nil {
+		return models.ContainerStatusError, err
+	}
+	return mapDockerState(out), nil
+}
+
+// mapDockerState maps a docker state to a container status
+func mapDockerState(state string) models.ContainerStatus {
+	switch strings.TrimSpace(state) {
+	case "running":
+		return models.ContainerStatusRunning
+	case "restarting":
+		return models.ContainerStatusRestarting
+	case "created", "exited", "paused":
+		return models.ContainerStatusStopped
+	default:
+		return models.ContainerStatusError
+	}
+}
+
+// sortedKeys returns the map keys in order, so generated commands are stable
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
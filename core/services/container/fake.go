@@ -0,0 +1,243 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// RuntimeFake names the in-memory driver
+const RuntimeFake = "fake"
+
+// FakeRuntime is an in-memory Runtime for tests and local development.
+// It behaves like a daemon that never fails unless told to with Fail.
+type FakeRuntime struct {
+	mu         sync.Mutex
+	images     map[string]map[string]bool
+	containers map[string]map[string]*fakeContainer
+	failures   map[string]error
+	calls      []string
+}
+
+type fakeContainer struct {
+	container *models.Container
+	config    models.ContainerConfig
+	status    models.ContainerStatus
+	logs      []string
+}
+
+var _ Runtime = (*FakeRuntime)(nil)
+
+// NewFakeRuntime creates an empty in-memory runtime
+func NewFakeRuntime() *FakeRuntime {
+	return &FakeRuntime{
+		images:     make(map[string]map[string]bool),
+		containers: make(map[string]map[string]*fakeContainer),
+		failures:   make(map[string]error),
+	}
+}
+
+// Name implements Runtime
+func (f *FakeRuntime) Name() string { return RuntimeFake }
+
+// Fail makes every later op ("pull", "create", "start", "stop", "logs" or
+// "inspect") on the node fail with err. An empty op fails all operations;
+// a nil err clears the failure.
+func (f *FakeRuntime) Fail(nodeID, op string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := nodeID + "/" + op
+	if err == nil {
+		delete(f.failures, key)
+		return
+	}
+	f.failures[key] = err
+}
+
+// SetStatus changes a container's status behind the service's back, as a
+// crash or an operator on the node would
+func (f *FakeRuntime) SetStatus(nodeID, name string, status models.ContainerStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.get(nodeID, name)
+	if err != nil {
+		return err
+	}
+	c.status = status
+	return nil
+}
+
+// AppendLogs adds lines to a container's output
+func (f *FakeRuntime) AppendLogs(nodeID, name string, lines ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.get(nodeID, name)
+	if err != nil {
+		return err
+	}
+	c.logs = append(c.logs, lines...)
+	return nil
+}
+
+// Container returns the container created on the node with its config
+func (f *FakeRuntime) Container(nodeID, name string) (*models.Container, *models.ContainerConfig, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, err := f.get(nodeID, name)
+	if err != nil {
+		return nil, nil, false
+	}
+	cfg := c.config
+	return c.container, &cfg, true
+}
+
+// Names returns the names of the containers created on the node
+func (f *FakeRuntime) Names(nodeID string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	names := make([]string, 0, len(f.containers[nodeID]))
+	for name := range f.containers[nodeID] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Calls returns every operation received, as "op node target"
+func (f *FakeRuntime) Calls() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.calls...)
+}
+
+// Pull implements Runtime
+func (f *FakeRuntime) Pull(ctx context.Context, nodeID, image string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("pull", nodeID, image); err != nil {
+		return err
+	}
+	if f.images[nodeID] == nil {
+		f.images[nodeID] = make(map[string]bool)
+	}
+	f.images[nodeID][image] = true
+	return nil
+}
+
+// Create implements Runtime
+func (f *FakeRuntime) Create(ctx context.Context, c *models.Container, cfg *models.ContainerConfig) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("create", c.NodeID, c.Name); err != nil {
+		return err
+	}
+	if !f.images[c.NodeID][c.Image] {
+		return runtimeFailure(f.Name(), "create", c.NodeID, fmt.Errorf("image %s not pulled", c.Image), "")
+	}
+	if _, exists := f.containers[c.NodeID][c.Name]; exists {
+		return runtimeFailure(f.Name(), "create", c.NodeID, fmt.Errorf("name %s is already in use", c.Name), "")
+	}
+	if f.containers[c.NodeID] == nil {
+		f.containers[c.NodeID] = make(map[string]*fakeContainer)
+	}
+	copied := *c
+	f.containers[c.NodeID][c.Name] = &fakeContainer{
+		container: &copied,
+		config:    *cfg,
+		status:    models.ContainerStatusStopped,
+	}
+	return nil
+}
+
+// Start implements Runtime
+func (f *FakeRuntime) Start(ctx context.Context, nodeID, name string) error {
+	return f.setStatus("start", nodeID, name, models.ContainerStatusRunning)
+}
+
+// Stop implements Runtime
+func (f *FakeRuntime) Stop(ctx context.Context, nodeID, name string) error {
+	return f.setStatus("stop", nodeID, name, models.ContainerStatusStopped)
+}
+
+// Logs implements Runtime. Following returns once the stored lines are written.
+func (f *FakeRuntime) Logs(ctx context.Context, nodeID, name string, tail int, follow bool, w io.Writer) error {
+	f.mu.Lock()
+	if err := f.begin("logs", nodeID, name); err != nil {
+		f.mu.Unlock()
+		return err
+	}
+	c, err := f.get(nodeID, name)
+	var lines []string
+	if err == nil {
+		lines = c.logs
+		if tail >= 0 && len(lines) > tail {
+			lines = lines[len(lines)-tail:]
+		}
+		lines = append([]string(nil), lines...)
+	}
+	f.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Inspect implements Runtime
+func (f *FakeRuntime) Inspect(ctx context.Context, nodeID, name string) (models.ContainerStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin("inspect", nodeID, name); err != nil {
+		return models.ContainerStatusError, err
+	}
+	c, err := f.get(nodeID, name)
+	if err != nil {
+		return models.ContainerStatusError, err
+	}
+	return c.status, nil
+}
+
+func (f *FakeRuntime) setStatus(op, nodeID, name string, status models.ContainerStatus) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.begin(op, nodeID, name); err != nil {
+		return err
+	}
+	c, err := f.get(nodeID, name)
+	if err != nil {
+		return err
+	}
+	c.status = status
+	return nil
+}
+
+// begin records a call and returns the failure injected for it, if any.
+// Callers must hold f.mu.
+func (f *FakeRuntime) begin(op, nodeID, target string) error {
+	f.calls = append(f.calls, op+" "+nodeID+" "+target)
+	for _, key := range []string{nodeID + "/" + op, nodeID + "/"} {
+		if err, ok := f.failures[key]; ok {
+			return runtimeFailure(f.Name(), op, nodeID, err, "")
+		}
+	}
+	return nil
+}
+
+// get returns a container; callers must hold f.mu
+func (f *FakeRuntime) get(nodeID, name string) (*fakeContainer, error) {
+	c, ok := f.containers[nodeID][name]
+	if !ok {
+		return nil, runtimeFailure(f.Name(), "lookup", nodeID, errors.New("no such container: "+name), "")
+	}
+	return c, nil
+}
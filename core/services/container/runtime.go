@@ -0,0 +1,97 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Runtime drives a container engine on the grid nodes. Drivers report
+// engine states as models.ContainerStatus and failures as APIErrors with
+// ErrCodeContainerDeployFailed.
+type Runtime interface {
+	// Name identifies the driver, e.g. "docker"
+	Name() string
+	// Pull makes the image available on the node
+	Pull(ctx context.Context, nodeID, image string) error
+	// Create creates the container without starting it
+	Create(ctx context.Context, c *models.Container, cfg *models.ContainerConfig) error
+	Start(ctx context.Context, nodeID, name string) error
+	Stop(ctx context.Context, nodeID, name string) error
+	// Logs writes the last tail lines to w, then keeps following them when
+	// follow is set until the context is cancelled
+	Logs(ctx context.Context, nodeID, name string, tail int, follow bool, w io.Writer) error
+	// Inspect returns the container's current status
+	Inspect(ctx context.Context, nodeID, name string) (models.ContainerStatus, error)
+}
+
+// Executor runs shell commands on a node, usually over SSH. The Docker and
+// containerd drivers are built on top of it.
+type Executor interface {
+	// Run executes the command and returns its combined output
+	Run(ctx context.Context, nodeID string, command string) (string, error)
+	// Stream executes the command, copying its output to w as it arrives
+	Stream(ctx context.Context, nodeID string, command string, w io.Writer) error
+}
+
+// Runtime driver names accepted by NewRuntime
+const (
+	RuntimeDocker     = "docker"
+	RuntimeContainerd = "containerd"
+)
+
+// NewRuntime returns the named driver running its commands through exec
+func NewRuntime(name string, exec Executor) (Runtime, error) {
+	switch name {
+	case RuntimeDocker, "":
+		return NewDockerRuntime(exec), nil
+	case RuntimeContainerd:
+		return NewContainerdRuntime(exec), nil
+	default:
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Unknown container runtime",
+			fmt.Sprintf("'%s' is not one of %s, %s", name, RuntimeDocker, RuntimeContainerd))
+	}
+}
+
+// labelContainerID marks containers started by the grid with their record ID
+const labelContainerID = "syntropy.container.id"
+
+// runtimeFailure wraps a failed runtime operation, including the command
+// output, which usually holds the engine's own error message
+func runtimeFailure(runtime, op, nodeID string, err error, out string) error {
+	details := fmt.Sprintf("%s %s on %s: %v", runtime, op, nodeID, err)
+	if out = strings.TrimSpace(out); out != "" {
+		details += ": " + out
+	}
+	return coreerrors.NewAPIError(coreerrors.ErrCodeContainerDeployFailed, "Container runtime failed", details)
+}
+
+// run executes a command through the executor and maps failures
+func run(ctx context.Context, exec Executor, runtime, op, nodeID, command string) (string, error) {
+	out, err := exec.Run(ctx, nodeID, command)
+	if err != nil {
+		return out, runtimeFailure(runtime, op, nodeID, err, out)
+	}
+	return out, nil
+}
+
+// shellJoin quotes each argument for a POSIX shell and joins them
+func shellJoin(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes s for a POSIX shell unless it only has safe characters
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=@,+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,128 @@
+package container_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/services/container"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// recordingExecutor records commands and answers with a canned output
+type recordingExecutor struct {
+	commands []string
+	output   string
+	err      error
+}
+
+func (e *recordingExecutor) Run(ctx context.Context, nodeID, command string) (string, error) {
+	e.commands = append(e.commands, command)
+	return e.output, e.err
+}
+
+func (e *recordingExecutor) Stream(ctx context.Context, nodeID, command string, w io.Writer) error {
+	e.commands = append(e.commands, command)
+	return e.err
+}
+
+func testContainer() (*models.Container, *models.ContainerConfig) {
+	ports, _ := container.ParsePorts([]string{"8080:80"})
+	volumes, _ := container.ParseVolumes([]string{"/srv/www:/usr/share/nginx/html:ro"})
+	c := &models.Container{ID: "c1", NodeID: "node-a", Name: "web", Image: "nginx"}
+	cfg := &models.ContainerConfig{
+		Ports:     ports,
+		Volumes:   volumes,
+		EnvVars:   map[string]string{"B": "2", "A": "it's"},
+		Resources: models.ResourceLimits{CPU: "500m", Memory: "256Mi"},
+	}
+	return c, cfg
+}
+
+func TestDockerRuntimeCommands(t *testing.T) {
+	exec := &recordingExecutor{}
+	runtime := container.NewDockerRuntime(exec)
+	ctx := context.Background()
+
+	c, cfg := testContainer()
+	if err := runtime.Create(ctx, c, cfg); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	want := `docker create --name web --label syntropy.container.id=c1 --restart unless-stopped --cpus 0.500 --memory 268435456 ` +
+		`-p 8080:80/tcp -e 'A=it'"'"'s' -e B=2 -v /srv/www:/usr/share/nginx/html:ro nginx`
+	if exec.commands[0] != want {
+		t.Fatalf("create command:\n got %s\nwant %s", exec.commands[0], want)
+	}
+
+	runtime.Logs(ctx, "node-a", "web", 10, true, io.Discard)
+	if exec.commands[1] != "docker logs --tail 10 -f web" {
+		t.Fatalf("logs command: %s", exec.commands[1])
+	}
+
+	for state, want := range map[string]models.ContainerStatus{
+		"running\n": models.ContainerStatusRunning,
+		"exited\n":  models.ContainerStatusStopped,
+		"dead\n":    models.ContainerStatusError,
+	} {
+		exec.output = state
+		if got, err := runtime.Inspect(ctx, "node-a", "web"); err != nil || got != want {
+			t.Errorf("Inspect(%q) = %s, %v; want %s", state, got, err, want)
+		}
+	}
+
+	exec.output, exec.err = "Error: No such container: web", errors.New("exit status 1")
+	_, err := runtime.Inspect(ctx, "node-a", "web")
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeContainerDeployFailed || !strings.Contains(err.Error(), "No such container") {
+		t.Fatalf("Inspect failure = %v", err)
+	}
+}
+
+func TestContainerdRuntimeCommands(t *testing.T) {
+	exec := &recordingExecutor{}
+	runtime := container.NewContainerdRuntime(exec)
+	ctx := context.Background()
+
+	if err := runtime.Pull(ctx, "node-a", "nginx"); err != nil {
+		t.Fatalf("Pull: %v", err)
+	}
+	if exec.commands[0] != "sudo -n ctr -n syntropy images pull docker.io/library/nginx:latest" {
+		t.Fatalf("pull command: %s", exec.commands[0])
+	}
+
+	c, cfg := testContainer()
+	err := runtime.Create(ctx, c, cfg)
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeContainerDeployFailed {
+		t.Fatalf("remapped port should be refused, got %v", err)
+	}
+
+	cfg.Ports[0].HostPort = 80
+	if err := runtime.Create(ctx, c, cfg); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	create := exec.commands[len(exec.commands)-1]
+	for _, part := range []string{"containers create", "--net-host", "--memory-limit 268435456",
+		"--mount type=bind,src=/srv/www,dst=/usr/share/nginx/html,options=rbind:ro", "docker.io/library/nginx:latest web"} {
+		if !strings.Contains(create, part) {
+			t.Errorf("create command %q is missing %q", create, part)
+		}
+	}
+
+	exec.output = "CREATED\n"
+	if got, _ := runtime.Inspect(ctx, "node-a", "web"); got != models.ContainerStatusStopped {
+		t.Fatalf("Inspect = %s, want stopped", got)
+	}
+}
+
+func TestNewRuntime(t *testing.T) {
+	for _, name := range []string{"", "docker", "containerd"} {
+		if _, err := container.NewRuntime(name, &recordingExecutor{}); err != nil {
+			t.Errorf("NewRuntime(%q): %v", name, err)
+		}
+	}
+	if _, err := container.NewRuntime("podman", &recordingExecutor{}); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeInvalidInput {
+		t.Fatalf("unknown runtime: error = %v", err)
+	}
+}
@@ -0,0 +1,393 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Service deploys and manages containers on grid nodes
+type Service struct {
+	repo    Repository
+	runtime Runtime
+	log     Logger
+}
+
+// Repository defines the interface for container data access
+type Repository interface {
+	Create(ctx context.Context, c *models.Container) error
+	GetByID(ctx context.Context, id string) (*models.Container, error)
+	List(ctx context.Context, filter *Filter) ([]*models.Container, error)
+	Update(ctx context.Context, c *models.Container) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Logger defines the interface for logging
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+	Debug(args ...interface{})
+	WithFields(fields map[string]interface{}) Logger
+}
+
+// Filter defines filtering options for container queries
+type Filter struct {
+	NodeID string
+	Name   string
+	Status string
+	Limit  int
+	Offset int
+}
+
+// DeployRequest represents a request to deploy one or more replicas
+type DeployRequest struct {
+	Name  string   `json:"name,omitempty"`
+	Image string   `json:"image"`
+	Nodes []string `json:"nodes"`
+	// Config.Replicas replicas are spread over Nodes, least loaded first
+	Config models.ContainerConfig `json:"config"`
+}
+
+// validName matches names docker accepts for containers
+var validName = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
+// NewService creates a new container service on top of a runtime driver
+func NewService(repo Repository, runtime Runtime, log Logger) *Service {
+	return &Service{
+		repo:    repo,
+		runtime: runtime,
+		log:     log,
+	}
+}
+
+// Deploy places the requested replicas on the target nodes and starts
+// them. Every replica gets its own record; replicas that fail to start are
+// kept in the error status and reported in the returned error.
+func (s *Service) Deploy(ctx context.Context, req *DeployRequest) ([]*models.Container, error) {
+	s.log.WithFields(map[string]interface{}{
+		"image":    req.Image,
+		"nodes":    req.Nodes,
+		"replicas": req.Config.Replicas,
+	}).Info("Deploying container")
+
+	cfg, err := s.validate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	placement, err := s.place(ctx, req.Nodes, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	base := req.Name
+	if base == "" {
+		base = nameFromImage(req.Image)
+	}
+
+	// Replica configs are stored without the replica count
+	replicaCfg := *cfg
+	replicaCfg.Replicas = 0
+	encoded, err := EncodeConfig(&replicaCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]*models.Container, 0, len(placement))
+	for i, nodeID := range placement {
+		name := base
+		if len(placement) > 1 {
+			name = fmt.Sprintf("%s-%d", base, i+1)
+		}
+		if err := s.checkNameFree(ctx, nodeID, name); err != nil {
+			return containers, err
+		}
+
+		c := &models.Container{
+			NodeID: nodeID,
+			Name:   name,
+			Image:  req.Image,
+			Status: string(models.ContainerStatusCreating),
+			Config: encoded,
+		}
+		if err := s.repo.Create(ctx, c); err != nil {
+			return containers, fmt.Errorf("failed to create container: %w", err)
+		}
+		containers = append(containers, c)
+	}
+
+	var failed []string
+	for _, c := range containers {
+		if err := s.start(ctx, c, &replicaCfg); err != nil {
+			reason := err.Error()
+			if apiErr, ok := err.(*coreerrors.APIError); ok && apiErr.Details != "" {
+				reason = apiErr.Details
+			}
+			failed = append(failed, fmt.Sprintf("%s: %s", c.Name, reason))
+		}
+	}
+	if len(failed) > 0 {
+		return containers, coreerrors.NewAPIError(coreerrors.ErrCodeContainerDeployFailed,
+			"Failed to deploy container", strings.Join(failed, "; "))
+	}
+
+	return containers, nil
+}
+
+// GetContainer returns a container by ID, or by name when the name is unique
+func (s *Service) GetContainer(ctx context.Context, ref string) (*models.Container, error) {
+	c, err := s.repo.GetByID(ctx, ref)
+	if err == nil {
+		return c, nil
+	}
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeContainerNotFound {
+		return nil, err
+	}
+
+	matches, err := s.repo.List(ctx, &Filter{Name: ref})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	switch len(matches) {
+	case 0:
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeContainerNotFound,
+			"Container not found", fmt.Sprintf("no container with id or name '%s'", ref))
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeConflict, "Ambiguous container name",
+			fmt.Sprintf("%d containers are named '%s', use the container ID", len(matches), ref))
+	}
+}
+
+// ListContainers returns containers matching the filter
+func (s *Service) ListContainers(ctx context.Context, filter *Filter) ([]*models.Container, error) {
+	containers, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	return containers, nil
+}
+
+// Status asks the node for the container's current state and stores it
+func (s *Service) Status(ctx context.Context, ref string) (*models.Container, error) {
+	c, err := s.GetContainer(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := s.runtime.Inspect(ctx, c.NodeID, c.Name)
+	if err != nil {
+		s.log.WithFields(map[string]interface{}{
+			"container_id": c.ID,
+			"node_id":      c.NodeID,
+		}).Error("Failed to inspect container: ", err)
+		status = models.ContainerStatusError
+	}
+
+	if string(status) != c.Status {
+		c.Status = string(status)
+		if err := s.repo.Update(ctx, c); err != nil {
+			return nil, fmt.Errorf("failed to update container: %w", err)
+		}
+	}
+	return c, nil
+}
+
+// Stop stops a running container
+func (s *Service) Stop(ctx context.Context, ref string) (*models.Container, error) {
+	c, err := s.GetContainer(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if models.ContainerStatus(c.Status) == models.ContainerStatusStopped {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeContainerInvalidStatus,
+			"Container is already stopped", c.Name)
+	}
+
+	if err := s.runtime.Stop(ctx, c.NodeID, c.Name); err != nil {
+		return nil, err
+	}
+	c.Status = string(models.ContainerStatusStopped)
+	if err := s.repo.Update(ctx, c); err != nil {
+		return nil, fmt.Errorf("failed to update container: %w", err)
+	}
+	return c, nil
+}
+
+// Start starts a stopped container
+func (s *Service) Start(ctx context.Context, ref string) (*models.Container, error) {
+	c, err := s.GetContainer(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if models.ContainerStatus(c.Status) == models.ContainerStatusRunning {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeContainerInvalidStatus,
+			"Container is already running", c.Name)
+	}
+
+	if err := s.runtime.Start(ctx, c.NodeID, c.Name); err != nil {
+		return nil, err
+	}
+	c.Status = string(models.ContainerStatusRunning)
+	if err := s.repo.Update(ctx, c); err != nil {
+		return nil, fmt.Errorf("failed to update container: %w", err)
+	}
+	return c, nil
+}
+
+// Logs streams a container's logs to w, following them when follow is set
+// until the context is cancelled
+func (s *Service) Logs(ctx context.Context, ref string, tail int, follow bool, w io.Writer) error {
+	c, err := s.GetContainer(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if tail < 0 {
+		tail = 0
+	}
+	return s.runtime.Logs(ctx, c.NodeID, c.Name, tail, follow, w)
+}
+
+// validate checks the request and returns its normalized config
+func (s *Service) validate(req *DeployRequest) (*models.ContainerConfig, error) {
+	if strings.TrimSpace(req.Image) == "" {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid deployment", "image is required")
+	}
+	if len(req.Nodes) == 0 {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid deployment", "at least one target node is required")
+	}
+	if req.Name != "" && !validName.MatchString(req.Name) {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid deployment",
+			fmt.Sprintf("invalid container name '%s'", req.Name))
+	}
+
+	cfg := req.Config
+	if cfg.Replicas <= 0 {
+		cfg.Replicas = 1
+	}
+	resources, err := NormalizeResources(cfg.Resources)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Resources = resources
+
+	seen := make(map[string]bool)
+	for _, p := range cfg.Ports {
+		key := fmt.Sprintf("%d/%s", p.HostPort, p.Protocol)
+		if seen[key] {
+			return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid deployment",
+				fmt.Sprintf("host port %s is mapped twice", key))
+		}
+		seen[key] = true
+	}
+
+	return &cfg, nil
+}
+
+// place picks a node for each replica, always using the node that runs the
+// fewest grid containers. Replicas only share a node when there are more
+// replicas than nodes, which is refused when host ports are published.
+func (s *Service) place(ctx context.Context, nodes []string, cfg *models.ContainerConfig) ([]string, error) {
+	unique := make([]string, 0, len(nodes))
+	seen := make(map[string]bool)
+	for _, n := range nodes {
+		n = strings.TrimSpace(n)
+		if n != "" && !seen[n] {
+			seen[n] = true
+			unique = append(unique, n)
+		}
+	}
+	if len(unique) == 0 {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid deployment", "at least one target node is required")
+	}
+	if cfg.Replicas > len(unique) && len(cfg.Ports) > 0 {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid deployment",
+			fmt.Sprintf("%d replicas with published ports need at least %d nodes, got %d",
+				cfg.Replicas, cfg.Replicas, len(unique)))
+	}
+
+	load := make(map[string]int, len(unique))
+	for _, n := range unique {
+		existing, err := s.repo.List(ctx, &Filter{NodeID: n})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list containers: %w", err)
+		}
+		load[n] = len(existing)
+	}
+
+	placement := make([]string, 0, cfg.Replicas)
+	for i := 0; i < cfg.Replicas; i++ {
+		candidates := append([]string(nil), unique...)
+		sort.SliceStable(candidates, func(a, b int) bool {
+			return load[candidates[a]] < load[candidates[b]]
+		})
+		target := candidates[0]
+		placement = append(placement, target)
+		load[target]++
+	}
+	return placement, nil
+}
+
+// start pulls, creates and starts a container on its node and records the outcome
+func (s *Service) start(ctx context.Context, c *models.Container, cfg *models.ContainerConfig) error {
+	err := s.runtime.Pull(ctx, c.NodeID, c.Image)
+	if err == nil {
+		err = s.runtime.Create(ctx, c, cfg)
+	}
+	if err == nil {
+		err = s.runtime.Start(ctx, c.NodeID, c.Name)
+	}
+
+	status := models.ContainerStatusRunning
+	if err != nil {
+		status = models.ContainerStatusError
+		s.log.WithFields(map[string]interface{}{
+			"container_id": c.ID,
+			"node_id":      c.NodeID,
+			"runtime":      s.runtime.Name(),
+		}).Error("Container failed to start: ", err)
+	}
+
+	c.Status = string(status)
+	if updateErr := s.repo.Update(ctx, c); updateErr != nil {
+		return fmt.Errorf("failed to update container: %w", updateErr)
+	}
+	return err
+}
+
+// checkNameFree returns ErrCodeContainerAlreadyExists if the node already runs a container with that name
+func (s *Service) checkNameFree(ctx context.Context, nodeID, name string) error {
+	existing, err := s.repo.List(ctx, &Filter{NodeID: nodeID, Name: name})
+	if err != nil {
+		return fmt.Errorf("failed to list containers: %w", err)
+	}
+	if len(existing) > 0 {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeContainerAlreadyExists, "Container already exists",
+			fmt.Sprintf("node %s already has a container named '%s'", nodeID, name))
+	}
+	return nil
+}
+
+// nameFromImage derives a container name from an image reference,
+// e.g. "docker.io/library/nginx:1.25" becomes "nginx"
+func nameFromImage(image string) string {
+	name := image
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.IndexAny(name, ":@"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.Trim(regexp.MustCompile(`[^a-zA-Z0-9_.-]+`).ReplaceAllString(name, "-"), "-._")
+	if name == "" {
+		return "container"
+	}
+	return name
+}
@@ -0,0 +1,160 @@
+package container_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/services/container"
+	"syntropy-cc/cooperative-grid/core/storage"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(args ...interface{})                                    {}
+func (nopLogger) Error(args ...interface{})                                   {}
+func (nopLogger) Debug(args ...interface{})                                   {}
+func (l nopLogger) WithFields(fields map[string]interface{}) container.Logger { return l }
+
+func newTestService(t *testing.T) (*container.Service, *container.FakeRuntime) {
+	t.Helper()
+	store, err := storage.Open(filepath.Join(t.TempDir(), storage.DefaultFileName))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	runtime := container.NewFakeRuntime()
+	return container.NewService(storage.NewContainerRepository(store), runtime, nopLogger{}), runtime
+}
+
+func TestDeploySpreadsReplicasAcrossNodes(t *testing.T) {
+	svc, runtime := newTestService(t)
+	ctx := context.Background()
+
+	ports, _ := container.ParsePorts([]string{"8080:80"})
+	containers, err := svc.Deploy(ctx, &container.DeployRequest{
+		Name:   "web",
+		Image:  "nginx:1.25",
+		Nodes:  []string{"node-a", "node-b", "node-c"},
+		Config: models.ContainerConfig{Replicas: 3, Ports: ports, EnvVars: map[string]string{"MODE": "prod"}},
+	})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	nodes := map[string]bool{}
+	for i, c := range containers {
+		if c.Name != fmt.Sprintf("web-%d", i+1) || c.Status != string(models.ContainerStatusRunning) {
+			t.Errorf("container %d = %s (%s)", i, c.Name, c.Status)
+		}
+		nodes[c.NodeID] = true
+
+		created, cfg, ok := runtime.Container(c.NodeID, c.Name)
+		if !ok {
+			t.Fatalf("%s was not created on %s", c.Name, c.NodeID)
+		}
+		if created.ID != c.ID || cfg.Resources.CPU != "100m" || cfg.EnvVars["MODE"] != "prod" {
+			t.Errorf("runtime got %+v with %+v", created, cfg)
+		}
+	}
+	if len(nodes) != 3 {
+		t.Fatalf("replicas placed on %d nodes, want 3", len(nodes))
+	}
+}
+
+func TestDeployRejectsPortConflictsAndDuplicates(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	ports, _ := container.ParsePorts([]string{"80"})
+	_, err := svc.Deploy(ctx, &container.DeployRequest{
+		Image: "nginx", Nodes: []string{"node-a"},
+		Config: models.ContainerConfig{Replicas: 2, Ports: ports},
+	})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeInvalidInput {
+		t.Fatalf("two replicas on one node with a host port: error = %v", err)
+	}
+
+	req := &container.DeployRequest{Image: "redis:7", Nodes: []string{"node-a"}}
+	if _, err := svc.Deploy(ctx, req); err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+	if _, err := svc.Deploy(ctx, req); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeContainerAlreadyExists {
+		t.Fatalf("duplicate name: error = %v, want already exists", err)
+	}
+}
+
+func TestDeployFailureMarksContainerError(t *testing.T) {
+	svc, runtime := newTestService(t)
+	runtime.Fail("node-b", "pull", errors.New("manifest unknown"))
+
+	containers, err := svc.Deploy(context.Background(), &container.DeployRequest{
+		Name: "api", Image: "api:latest", Nodes: []string{"node-a", "node-b"},
+		Config: models.ContainerConfig{Replicas: 2},
+	})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeContainerDeployFailed {
+		t.Fatalf("error = %v, want deploy failed", err)
+	}
+	if !strings.Contains(err.Error(), "manifest unknown") {
+		t.Fatalf("error should include the runtime failure: %v", err)
+	}
+
+	statuses := map[string]string{}
+	for _, c := range containers {
+		statuses[c.NodeID] = c.Status
+	}
+	if statuses["node-a"] != "running" || statuses["node-b"] != "error" {
+		t.Fatalf("unexpected statuses: %v", statuses)
+	}
+	if names := runtime.Names("node-b"); len(names) != 0 {
+		t.Fatalf("nothing should be created after a failed pull, got %v", names)
+	}
+}
+
+func TestLifecycleByName(t *testing.T) {
+	svc, runtime := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.Deploy(ctx, &container.DeployRequest{Name: "db", Image: "postgres:16", Nodes: []string{"node-a"}}); err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+
+	if _, err := svc.Start(ctx, "db"); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeContainerInvalidStatus {
+		t.Fatalf("Start on running container: error = %v", err)
+	}
+	c, err := svc.Stop(ctx, "db")
+	if err != nil || c.Status != "stopped" {
+		t.Fatalf("Stop = %v, %v", c, err)
+	}
+
+	// Restarted on the node without going through the service
+	runtime.SetStatus("node-a", "db", models.ContainerStatusRestarting)
+	if c, err = svc.Status(ctx, "db"); err != nil || c.Status != "restarting" {
+		t.Fatalf("Status = %v, %v", c, err)
+	}
+
+	runtime.AppendLogs("node-a", "db", "one", "two", "three")
+	var buf bytes.Buffer
+	if err := svc.Logs(ctx, c.ID, 2, false, &buf); err != nil {
+		t.Fatalf("Logs: %v", err)
+	}
+	if buf.String() != "two\nthree\n" {
+		t.Fatalf("unexpected logs %q", buf.String())
+	}
+
+	runtime.Fail("node-a", "", errors.New("connection refused"))
+	if c, err = svc.Status(ctx, "db"); err != nil || c.Status != "error" {
+		t.Fatalf("Status with unreachable runtime = %v, %v", c, err)
+	}
+
+	if _, err := svc.Status(ctx, "missing"); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeContainerNotFound {
+		t.Fatalf("Status on unknown container: error = %v", err)
+	}
+}
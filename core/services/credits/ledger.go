@@ -0,0 +1,135 @@
+package credits
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// TreasuryAccountID is the cooperative's own account. Rewards and bonuses
+// are issued from it and penalties and resource usage are paid into it, so
+// it is the only account allowed to hold a negative balance.
+const TreasuryAccountID = "cooperative"
+
+// Entry is one side of a double-entry posting in the append-only ledger.
+// Every completed transaction produces exactly two entries whose amounts
+// sum to zero. Entries are chained by hash so that any later edit to the
+// ledger is detectable.
+type Entry struct {
+	Sequence      uint64                            `json:"sequence"`
+	TransactionID string                            `json:"transaction_id"`
+	AccountID     string                            `json:"account_id"`
+	Amount        float64                           `json:"amount"`
+	BalanceAfter  float64                           `json:"balance_after"`
+	Type          models.CooperativeTransactionType `json:"type"`
+	CreatedAt     time.Time                         `json:"created_at"`
+	PrevHash      string                            `json:"prev_hash"`
+	Hash          string                            `json:"hash"`
+}
+
+// ComputeHash returns the chain hash of the entry
+func (e *Entry) ComputeHash() string {
+	payload := fmt.Sprintf("%d|%s|%s|%s|%.2f|%.2f|%s|%s",
+		e.Sequence,
+		e.TransactionID,
+		e.AccountID,
+		e.Type,
+		e.Amount,
+		e.BalanceAfter,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+		e.PrevHash,
+	)
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditReport summarizes a verification of the ledger against stored balances
+type AuditReport struct {
+	Entries      int                `json:"entries"`
+	Transactions int                `json:"transactions"`
+	HeadHash     string             `json:"head_hash"`
+	Balances     map[string]float64 `json:"balances"`
+	Mismatches   []BalanceMismatch  `json:"mismatches,omitempty"`
+	Errors       []string           `json:"errors,omitempty"`
+}
+
+// BalanceMismatch describes an account whose stored balance differs from the ledger
+type BalanceMismatch struct {
+	AccountID string  `json:"account_id"`
+	Stored    float64 `json:"stored"`
+	Ledger    float64 `json:"ledger"`
+}
+
+// Valid reports whether the audit found no problems
+func (r *AuditReport) Valid() bool {
+	return len(r.Errors) == 0 && len(r.Mismatches) == 0
+}
+
+// replay walks the ledger in order, verifying the hash chain, the
+// double-entry invariant and running balances, and returns the report
+// with balances rebuilt from the ledger alone.
+func replay(entries []*Entry) *AuditReport {
+	report := &AuditReport{
+		Balances: make(map[string]float64),
+	}
+
+	prevHash := ""
+	sums := make(map[string]float64)
+	var order []string
+
+	for i, e := range entries {
+		report.Entries++
+
+		if e.Sequence != uint64(i+1) {
+			report.Errors = append(report.Errors,
+				fmt.Sprintf("entry %d: expected sequence %d", e.Sequence, i+1))
+		}
+		if e.PrevHash != prevHash {
+			report.Errors = append(report.Errors,
+				fmt.Sprintf("entry %d: broken chain, prev_hash does not match previous entry", e.Sequence))
+		}
+		if e.ComputeHash() != e.Hash {
+			report.Errors = append(report.Errors,
+				fmt.Sprintf("entry %d: hash mismatch, entry was modified", e.Sequence))
+		}
+
+		balance := round(report.Balances[e.AccountID] + e.Amount)
+		if balance != round(e.BalanceAfter) {
+			report.Errors = append(report.Errors,
+				fmt.Sprintf("entry %d: balance_after %.2f does not match running balance %.2f",
+					e.Sequence, e.BalanceAfter, balance))
+		}
+		if balance < 0 && e.AccountID != TreasuryAccountID {
+			report.Errors = append(report.Errors,
+				fmt.Sprintf("entry %d: account %s went negative", e.Sequence, e.AccountID))
+		}
+		report.Balances[e.AccountID] = balance
+
+		if _, seen := sums[e.TransactionID]; !seen {
+			order = append(order, e.TransactionID)
+		}
+		sums[e.TransactionID] = round(sums[e.TransactionID] + e.Amount)
+
+		prevHash = e.Hash
+	}
+
+	for _, id := range order {
+		if sums[id] != 0 {
+			report.Errors = append(report.Errors,
+				fmt.Sprintf("transaction %s: entries do not balance (%.2f)", id, sums[id]))
+		}
+	}
+
+	report.Transactions = len(order)
+	report.HeadHash = prevHash
+	return report
+}
+
+// round rounds an amount to whole cents so float drift never accumulates
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,416 @@
+package credits
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/types/constants"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Service moves cooperative credits between accounts through the ledger
+type Service struct {
+	repo Repository
+	log  Logger
+
+	// mu serializes postings so balance checks and the hash chain
+	// always see the latest ledger head
+	mu sync.Mutex
+}
+
+// Repository defines the interface for credit data access
+type Repository interface {
+	GetBalance(ctx context.Context, accountID string) (*models.CooperativeCredits, error)
+	ListBalances(ctx context.Context) ([]*models.CooperativeCredits, error)
+	CreateTransaction(ctx context.Context, tx *models.CooperativeTransaction) error
+	GetTransaction(ctx context.Context, id string) (*models.CooperativeTransaction, error)
+	UpdateTransaction(ctx context.Context, tx *models.CooperativeTransaction) error
+	ListTransactions(ctx context.Context, filter *Filter) ([]*models.CooperativeTransaction, error)
+	LastEntry(ctx context.Context) (*Entry, error)
+	ListEntries(ctx context.Context) ([]*Entry, error)
+	// Apply atomically appends the entries, stores the balances and saves
+	// the transaction. It must fail if the ledger head moved past
+	// entries[0].Sequence-1 since the entries were built.
+	Apply(ctx context.Context, tx *models.CooperativeTransaction, entries []*Entry, balances []*models.CooperativeCredits) error
+	ReplaceBalances(ctx context.Context, balances []*models.CooperativeCredits) error
+}
+
+// Logger defines the interface for logging
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+	Debug(args ...interface{})
+	WithFields(fields map[string]interface{}) Logger
+}
+
+// Filter defines filtering options for transaction queries
+type Filter struct {
+	NodeID string
+	Status string
+	Type   string
+	Limit  int
+	Offset int
+}
+
+// TransactionRequest represents a request to move credits
+type TransactionRequest struct {
+	Type        models.CooperativeTransactionType `json:"type"`
+	FromNodeID  string                            `json:"from_node_id,omitempty"`
+	ToNodeID    string                            `json:"to_node_id,omitempty"`
+	Amount      float64                           `json:"amount"`
+	Description string                            `json:"description,omitempty"`
+}
+
+// NewService creates a new credits service
+func NewService(repo Repository, log Logger) *Service {
+	return &Service{
+		repo: repo,
+		log:  log,
+	}
+}
+
+// Transfer submits a transaction and completes it immediately
+func (s *Service) Transfer(ctx context.Context, req *TransactionRequest) (*models.CooperativeTransaction, error) {
+	tx, err := s.Submit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return s.Complete(ctx, tx.ID)
+}
+
+// Submit validates a request and records it as a pending transaction
+func (s *Service) Submit(ctx context.Context, req *TransactionRequest) (*models.CooperativeTransaction, error) {
+	s.log.WithFields(map[string]interface{}{
+		"type":   req.Type,
+		"from":   req.FromNodeID,
+		"to":     req.ToNodeID,
+		"amount": req.Amount,
+	}).Info("Submitting credit transaction")
+
+	if err := normalizeRequest(req); err != nil {
+		return nil, err
+	}
+
+	tx := &models.CooperativeTransaction{
+		FromNodeID:  req.FromNodeID,
+		ToNodeID:    req.ToNodeID,
+		Amount:      req.Amount,
+		Type:        string(req.Type),
+		Description: req.Description,
+		Status:      string(models.TransactionStatusPending),
+	}
+
+	if err := s.repo.CreateTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	}
+
+	return tx, nil
+}
+
+// Complete posts a pending transaction to the ledger. When the source
+// account cannot cover the amount the transaction is marked failed and
+// ErrCodeInsufficientCredits is returned.
+func (s *Service) Complete(ctx context.Context, id string) (*models.CooperativeTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.repo.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if err := checkStatusChange(tx, models.TransactionStatusCompleted); err != nil {
+		return nil, err
+	}
+
+	from, err := s.repo.GetBalance(ctx, tx.FromNodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+	to, err := s.repo.GetBalance(ctx, tx.ToNodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	amount := round(tx.Amount)
+	if tx.FromNodeID != TreasuryAccountID && round(from.Balance-amount) < 0 {
+		tx.Status = string(models.TransactionStatusFailed)
+		if err := s.repo.UpdateTransaction(ctx, tx); err != nil {
+			return nil, fmt.Errorf("failed to update transaction: %w", err)
+		}
+		return tx, coreerrors.NewAPIError(coreerrors.ErrCodeInsufficientCredits, "Insufficient credits",
+			fmt.Sprintf("account %s has %.2f, needs %.2f", tx.FromNodeID, from.Balance, amount))
+	}
+
+	head, err := s.repo.LastEntry(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ledger head: %w", err)
+	}
+
+	now := time.Now().UTC()
+	from.Balance = round(from.Balance - amount)
+	from.LastTransactionAt = now
+	to.Balance = round(to.Balance + amount)
+	to.LastTransactionAt = now
+
+	debit := &Entry{
+		TransactionID: tx.ID,
+		AccountID:     tx.FromNodeID,
+		Amount:        -amount,
+		BalanceAfter:  from.Balance,
+		Type:          models.CooperativeTransactionType(tx.Type),
+		CreatedAt:     now,
+	}
+	credit := &Entry{
+		TransactionID: tx.ID,
+		AccountID:     tx.ToNodeID,
+		Amount:        amount,
+		BalanceAfter:  to.Balance,
+		Type:          models.CooperativeTransactionType(tx.Type),
+		CreatedAt:     now,
+	}
+	chain(head, debit, credit)
+
+	tx.Status = string(models.TransactionStatusCompleted)
+	if err := s.repo.Apply(ctx, tx, []*Entry{debit, credit}, []*models.CooperativeCredits{from, to}); err != nil {
+		return nil, coreerrors.WrapAPIError(err, coreerrors.ErrCodeTransactionFailed, "Transaction failed")
+	}
+
+	s.log.WithFields(map[string]interface{}{
+		"transaction_id": tx.ID,
+		"type":           tx.Type,
+		"amount":         amount,
+		"ledger_head":    credit.Hash,
+	}).Info("Credit transaction completed")
+
+	return tx, nil
+}
+
+// Cancel cancels a pending transaction
+func (s *Service) Cancel(ctx context.Context, id string) (*models.CooperativeTransaction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.repo.GetTransaction(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction: %w", err)
+	}
+	if err := checkStatusChange(tx, models.TransactionStatusCancelled); err != nil {
+		return nil, err
+	}
+
+	tx.Status = string(models.TransactionStatusCancelled)
+	if err := s.repo.UpdateTransaction(ctx, tx); err != nil {
+		return nil, fmt.Errorf("failed to update transaction: %w", err)
+	}
+
+	return tx, nil
+}
+
+// GetBalance returns the credit balance of an account
+func (s *Service) GetBalance(ctx context.Context, nodeID string) (*models.CooperativeCredits, error) {
+	balance, err := s.repo.GetBalance(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+	return balance, nil
+}
+
+// ListBalances returns the balances of all accounts that ever held credits
+func (s *Service) ListBalances(ctx context.Context) ([]*models.CooperativeCredits, error) {
+	balances, err := s.repo.ListBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list balances: %w", err)
+	}
+	return balances, nil
+}
+
+// ListTransactions returns transactions matching the filter, newest first
+func (s *Service) ListTransactions(ctx context.Context, filter *Filter) ([]*models.CooperativeTransaction, error) {
+	txs, err := s.repo.ListTransactions(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	return txs, nil
+}
+
+// History returns the ledger entries of an account, or of every account
+// when accountID is empty, oldest first
+func (s *Service) History(ctx context.Context, accountID string) ([]*Entry, error) {
+	entries, err := s.repo.ListEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	if accountID == "" {
+		return entries, nil
+	}
+
+	var result []*Entry
+	for _, e := range entries {
+		if e.AccountID == accountID {
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+// Verify replays the whole ledger and compares it with the stored balances
+func (s *Service) Verify(ctx context.Context) (*AuditReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.repo.ListEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	report := replay(entries)
+
+	stored, err := s.repo.ListBalances(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list balances: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, b := range stored {
+		seen[b.NodeID] = true
+		if round(b.Balance) != report.Balances[b.NodeID] {
+			report.Mismatches = append(report.Mismatches, BalanceMismatch{
+				AccountID: b.NodeID,
+				Stored:    b.Balance,
+				Ledger:    report.Balances[b.NodeID],
+			})
+		}
+	}
+	for account, balance := range report.Balances {
+		if !seen[account] {
+			report.Mismatches = append(report.Mismatches, BalanceMismatch{
+				AccountID: account,
+				Ledger:    balance,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// RebuildBalances recomputes every balance from the ledger and overwrites
+// the stored balances. It refuses to run on a ledger whose chain is broken.
+func (s *Service) RebuildBalances(ctx context.Context) (*AuditReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.repo.ListEntries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger entries: %w", err)
+	}
+	report := replay(entries)
+	if len(report.Errors) > 0 {
+		return report, coreerrors.NewAPIError(coreerrors.ErrCodeTransactionFailed,
+			"Ledger verification failed", report.Errors[0])
+	}
+
+	last := make(map[string]time.Time)
+	for _, e := range entries {
+		last[e.AccountID] = e.CreatedAt
+	}
+
+	balances := make([]*models.CooperativeCredits, 0, len(report.Balances))
+	for account, balance := range report.Balances {
+		balances = append(balances, &models.CooperativeCredits{
+			NodeID:            account,
+			Balance:           balance,
+			LastTransactionAt: last[account],
+		})
+	}
+
+	if err := s.repo.ReplaceBalances(ctx, balances); err != nil {
+		return nil, fmt.Errorf("failed to store balances: %w", err)
+	}
+
+	s.log.WithFields(map[string]interface{}{
+		"accounts":  len(balances),
+		"entries":   report.Entries,
+		"head_hash": report.HeadHash,
+	}).Info("Balances rebuilt from ledger")
+
+	return report, nil
+}
+
+// chain assigns sequences and hashes to new entries after the ledger head
+func chain(head *Entry, entries ...*Entry) {
+	var seq uint64
+	prevHash := ""
+	if head != nil {
+		seq = head.Sequence
+		prevHash = head.Hash
+	}
+
+	for _, e := range entries {
+		seq++
+		e.Sequence = seq
+		e.PrevHash = prevHash
+		e.Hash = e.ComputeHash()
+		prevHash = e.Hash
+	}
+}
+
+// normalizeRequest validates a request and fills in the treasury side for
+// transaction types that issue credits to, or collect credits from, a node
+func normalizeRequest(req *TransactionRequest) error {
+	if !req.Type.IsValid() {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput,
+			"Invalid transaction type", fmt.Sprintf("unknown type '%s'", req.Type))
+	}
+
+	req.Amount = round(req.Amount)
+	if req.Amount < constants.MinCreditAmount || req.Amount > constants.MaxCreditAmount {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid amount",
+			fmt.Sprintf("amount must be between %.2f and %.2f", constants.MinCreditAmount, constants.MaxCreditAmount))
+	}
+
+	switch req.Type {
+	case models.TransactionTypeServiceReward, models.TransactionTypeParticipation, models.TransactionTypeBonus:
+		if req.FromNodeID == "" {
+			req.FromNodeID = TreasuryAccountID
+		}
+		if req.FromNodeID != TreasuryAccountID {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid source account",
+				fmt.Sprintf("%s transactions are issued by the cooperative treasury", req.Type))
+		}
+	case models.TransactionTypeResourceUsage, models.TransactionTypePenalty:
+		if req.ToNodeID == "" {
+			req.ToNodeID = TreasuryAccountID
+		}
+		if req.ToNodeID != TreasuryAccountID {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid destination account",
+				fmt.Sprintf("%s transactions are paid to the cooperative treasury", req.Type))
+		}
+	case models.TransactionTypeTransfer:
+		if req.FromNodeID == TreasuryAccountID || req.ToNodeID == TreasuryAccountID {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid account",
+				"transfers are only allowed between nodes")
+		}
+	}
+
+	if req.FromNodeID == "" || req.ToNodeID == "" {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid account",
+			"source and destination accounts are required")
+	}
+	if req.FromNodeID == req.ToNodeID {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid account",
+			"source and destination accounts must differ")
+	}
+
+	return nil
+}
+
+// checkStatusChange enforces the pending -> completed/failed/cancelled lifecycle
+func checkStatusChange(tx *models.CooperativeTransaction, to models.CooperativeTransactionStatus) error {
+	if models.CooperativeTransactionStatus(tx.Status) != models.TransactionStatusPending {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeTransactionFailed, "Transaction is not pending",
+			fmt.Sprintf("transaction %s is %s, cannot become %s", tx.ID, tx.Status, to))
+	}
+	return nil
+}
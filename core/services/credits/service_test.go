@@ -0,0 +1,188 @@
+package credits_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"syntropy-cc/cooperative-grid/core/services/credits"
+	"syntropy-cc/cooperative-grid/core/storage"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(args ...interface{})                                  {}
+func (nopLogger) Error(args ...interface{})                                 {}
+func (nopLogger) Debug(args ...interface{})                                 {}
+func (l nopLogger) WithFields(fields map[string]interface{}) credits.Logger { return l }
+
+func newTestService(t *testing.T) (*credits.Service, *storage.Store) {
+	t.Helper()
+	store, err := storage.Open(filepath.Join(t.TempDir(), storage.DefaultFileName))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return credits.NewService(storage.NewCreditsRepository(store), nopLogger{}), store
+}
+
+func balance(t *testing.T, svc *credits.Service, account string) float64 {
+	t.Helper()
+	b, err := svc.GetBalance(context.Background(), account)
+	if err != nil {
+		t.Fatalf("GetBalance: %v", err)
+	}
+	return b.Balance
+}
+
+func TestTransferMovesCreditsAndKeepsLedgerBalanced(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	if _, err := svc.Transfer(ctx, &credits.TransactionRequest{
+		Type: models.TransactionTypeServiceReward, ToNodeID: "node-a", Amount: 100,
+	}); err != nil {
+		t.Fatalf("reward: %v", err)
+	}
+	if _, err := svc.Transfer(ctx, &credits.TransactionRequest{
+		Type: models.TransactionTypeTransfer, FromNodeID: "node-a", ToNodeID: "node-b", Amount: 40.5,
+	}); err != nil {
+		t.Fatalf("transfer: %v", err)
+	}
+	if _, err := svc.Transfer(ctx, &credits.TransactionRequest{
+		Type: models.TransactionTypePenalty, FromNodeID: "node-b", Amount: 0.5,
+	}); err != nil {
+		t.Fatalf("penalty: %v", err)
+	}
+
+	if got := balance(t, svc, "node-a"); got != 59.5 {
+		t.Errorf("node-a balance = %.2f, want 59.50", got)
+	}
+	if got := balance(t, svc, "node-b"); got != 40 {
+		t.Errorf("node-b balance = %.2f, want 40.00", got)
+	}
+	if got := balance(t, svc, credits.TreasuryAccountID); got != -99.5 {
+		t.Errorf("treasury balance = %.2f, want -99.50", got)
+	}
+
+	report, err := svc.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !report.Valid() || report.Entries != 6 || report.Transactions != 3 {
+		t.Fatalf("unexpected audit report: %+v", report)
+	}
+}
+
+func TestTransferRejectsOverdraft(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	tx, err := svc.Transfer(ctx, &credits.TransactionRequest{
+		Type: models.TransactionTypeTransfer, FromNodeID: "node-a", ToNodeID: "node-b", Amount: 1,
+	})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeInsufficientCredits {
+		t.Fatalf("expected %s, got %v", coreerrors.ErrCodeInsufficientCredits, err)
+	}
+	if tx.Status != string(models.TransactionStatusFailed) {
+		t.Fatalf("status = %s, want failed", tx.Status)
+	}
+	if _, err := svc.Complete(ctx, tx.ID); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeTransactionFailed {
+		t.Fatalf("completing a failed transaction should be rejected, got %v", err)
+	}
+
+	entries, _ := svc.History(ctx, "")
+	if len(entries) != 0 {
+		t.Fatalf("failed transfer must not touch the ledger, got %d entries", len(entries))
+	}
+}
+
+func TestCancelPendingTransaction(t *testing.T) {
+	svc, _ := newTestService(t)
+	ctx := context.Background()
+
+	tx, err := svc.Submit(ctx, &credits.TransactionRequest{
+		Type: models.TransactionTypeBonus, ToNodeID: "node-a", Amount: 10,
+	})
+	if err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	if _, err := svc.Cancel(ctx, tx.ID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if _, err := svc.Complete(ctx, tx.ID); err == nil {
+		t.Fatal("completing a cancelled transaction should fail")
+	}
+	if got := balance(t, svc, "node-a"); got != 0 {
+		t.Fatalf("balance = %.2f, want 0", got)
+	}
+}
+
+func TestVerifyDetectsTamperingAndRebuildRestoresBalances(t *testing.T) {
+	svc, store := newTestService(t)
+	ctx := context.Background()
+
+	for _, amount := range []float64{25, 75} {
+		if _, err := svc.Transfer(ctx, &credits.TransactionRequest{
+			Type: models.TransactionTypeParticipation, ToNodeID: "node-a", Amount: amount,
+		}); err != nil {
+			t.Fatalf("participation: %v", err)
+		}
+	}
+
+	// Corrupt the stored balance; the ledger is still intact
+	repo := storage.NewCreditsRepository(store)
+	if err := repo.ReplaceBalances(ctx, []*models.CooperativeCredits{{NodeID: "node-a", Balance: 1}}); err != nil {
+		t.Fatalf("ReplaceBalances: %v", err)
+	}
+
+	report, err := svc.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if report.Valid() || len(report.Mismatches) == 0 {
+		t.Fatalf("expected balance mismatches, got %+v", report)
+	}
+
+	if _, err := svc.RebuildBalances(ctx); err != nil {
+		t.Fatalf("RebuildBalances: %v", err)
+	}
+	if got := balance(t, svc, "node-a"); got != 100 {
+		t.Fatalf("rebuilt balance = %.2f, want 100", got)
+	}
+
+	// Now tamper with a ledger entry directly
+	err = store.Update(ctx, func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("cooperative_ledger"))
+		k, v := b.Cursor().First()
+		var entry credits.Entry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return err
+		}
+		entry.Amount = -1000
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		return b.Put(k, data)
+	})
+	if err != nil {
+		t.Fatalf("tamper: %v", err)
+	}
+
+	report, err = svc.Verify(ctx)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(report.Errors) == 0 {
+		t.Fatal("tampered ledger should fail verification")
+	}
+	if _, err := svc.RebuildBalances(ctx); err == nil {
+		t.Fatal("rebuild must refuse a broken ledger")
+	}
+}
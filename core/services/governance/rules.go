@@ -0,0 +1,123 @@
+package governance
+
+import (
+	"fmt"
+	"time"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Weighting selects how much each vote counts
+type Weighting string
+
+const (
+	// WeightingEqual gives every member one vote
+	WeightingEqual Weighting = "equal"
+	// WeightingReputation weights each vote by the voter's NodeReputation.Score
+	WeightingReputation Weighting = "reputation"
+)
+
+// IsValid checks if the weighting mode is valid
+func (w Weighting) IsValid() bool {
+	switch w {
+	case WeightingEqual, WeightingReputation:
+		return true
+	default:
+		return false
+	}
+}
+
+// Rules decide when a proposal is valid and when it passes. A snapshot of
+// the rules is stored with each proposal when it is created, so changing
+// the defaults never affects a vote already under way.
+type Rules struct {
+	// Quorum is the fraction (0-1] of the electorate's total weight that
+	// must take part, abstentions included, for the result to count
+	Quorum float64 `json:"quorum"`
+	// Threshold is the fraction [0-1) of the yes+no weight that yes votes
+	// must strictly exceed for the proposal to pass
+	Threshold float64 `json:"threshold"`
+	// Weighting selects equal or reputation-weighted voting
+	Weighting Weighting `json:"weighting"`
+	// MinReputation is the reputation score required to vote
+	MinReputation float64 `json:"min_reputation"`
+	// VotingPeriod is the window length used when a proposal has no end date
+	VotingPeriod time.Duration `json:"voting_period"`
+}
+
+// DefaultRules returns simple-majority rules with a 50% quorum and a
+// seven day voting window
+func DefaultRules() *Rules {
+	return &Rules{
+		Quorum:       0.5,
+		Threshold:    0.5,
+		Weighting:    WeightingEqual,
+		VotingPeriod: 7 * 24 * time.Hour,
+	}
+}
+
+// Validate checks that the rules are consistent
+func (r *Rules) Validate() error {
+	switch {
+	case r.Quorum <= 0 || r.Quorum > 1:
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid governance rules",
+			fmt.Sprintf("quorum must be in (0, 1], got %.2f", r.Quorum))
+	case r.Threshold < 0 || r.Threshold >= 1:
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid governance rules",
+			fmt.Sprintf("threshold must be in [0, 1), got %.2f", r.Threshold))
+	case !r.Weighting.IsValid():
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid governance rules",
+			fmt.Sprintf("unknown weighting '%s'", r.Weighting))
+	case r.MinReputation < 0:
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid governance rules",
+			"min_reputation must not be negative")
+	case r.VotingPeriod <= 0:
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid governance rules",
+			"voting_period must be positive")
+	}
+	return nil
+}
+
+// weight returns the weight of a voter, or false when the voter may not vote
+func (r *Rules) weight(rep *models.NodeReputation) (float64, bool) {
+	score := 0.0
+	if rep != nil {
+		score = rep.Score
+	}
+	if score < r.MinReputation {
+		return 0, false
+	}
+	if r.Weighting == WeightingReputation {
+		return score, score > 0
+	}
+	return 1, true
+}
+
+// Tally is the weighted count of a proposal's votes
+type Tally struct {
+	ProposalID    string    `json:"proposal_id"`
+	Rules         Rules     `json:"rules"`
+	For           float64   `json:"for"`
+	Against       float64   `json:"against"`
+	Abstain       float64   `json:"abstain"`
+	Cast          float64   `json:"cast"`
+	Eligible      float64   `json:"eligible"`
+	Participation float64   `json:"participation"`
+	Approval      float64   `json:"approval"`
+	QuorumReached bool      `json:"quorum_reached"`
+	Passed        bool      `json:"passed"`
+	CountedAt     time.Time `json:"counted_at"`
+}
+
+// Outcome returns the status a proposal closes with under this tally
+func (t *Tally) Outcome() models.GovernanceProposalStatus {
+	switch {
+	case !t.QuorumReached:
+		return models.ProposalStatusExpired
+	case t.Passed:
+		return models.ProposalStatusPassed
+	default:
+		return models.ProposalStatusRejected
+	}
+}
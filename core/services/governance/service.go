@@ -0,0 +1,458 @@
+package governance
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Service runs cooperative proposals from creation to their final tally
+type Service struct {
+	repo       Repository
+	electorate Electorate
+	log        Logger
+	rules      *Rules
+	now        func() time.Time
+
+	// mu serializes votes and status changes
+	mu sync.Mutex
+}
+
+// Repository defines the interface for governance data access
+type Repository interface {
+	// CreateProposal stores a proposal together with the rules it is decided by
+	CreateProposal(ctx context.Context, p *models.GovernanceProposal, rules *Rules) error
+	GetProposal(ctx context.Context, id string) (*models.GovernanceProposal, error)
+	GetRules(ctx context.Context, proposalID string) (*Rules, error)
+	UpdateProposal(ctx context.Context, p *models.GovernanceProposal) error
+	ListProposals(ctx context.Context, filter *Filter) ([]*models.GovernanceProposal, error)
+	// ListOpenProposals returns every active or voting proposal, unpaginated
+	ListOpenProposals(ctx context.Context) ([]*models.GovernanceProposal, error)
+	// CastVote atomically stores the vote and the proposal's updated
+	// counters. It must fail with ErrCodeVoteAlreadyCast when the voter
+	// already voted on the proposal.
+	CastVote(ctx context.Context, v *models.GovernanceVote, p *models.GovernanceProposal) error
+	ListVotes(ctx context.Context, proposalID string) ([]*models.GovernanceVote, error)
+}
+
+// Electorate tells the service who may vote and how much reputation they hold
+type Electorate interface {
+	// Members returns the IDs of every node entitled to vote
+	Members(ctx context.Context) ([]string, error)
+	// Reputation returns a member's reputation, or nil when none is recorded
+	Reputation(ctx context.Context, nodeID string) (*models.NodeReputation, error)
+}
+
+// Logger defines the interface for logging
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+	Debug(args ...interface{})
+	WithFields(fields map[string]interface{}) Logger
+}
+
+// Filter defines filtering options for proposal queries
+type Filter struct {
+	Status     string
+	ProposerID string
+	Limit      int
+	Offset     int
+}
+
+// ProposalRequest represents a request to open a new proposal
+type ProposalRequest struct {
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	ProposerID  string    `json:"proposer_id"`
+	StartDate   time.Time `json:"start_date,omitempty"`
+	EndDate     time.Time `json:"end_date,omitempty"`
+	// Rules overrides the service defaults for this proposal
+	Rules *Rules `json:"rules,omitempty"`
+}
+
+// VoteRequest represents a member's vote on a proposal
+type VoteRequest struct {
+	ProposalID string                    `json:"proposal_id"`
+	VoterID    string                    `json:"voter_id"`
+	Vote       models.GovernanceVoteType `json:"vote"`
+	Reason     string                    `json:"reason,omitempty"`
+}
+
+// NewService creates a new governance service using DefaultRules
+func NewService(repo Repository, electorate Electorate, log Logger) *Service {
+	return &Service{
+		repo:       repo,
+		electorate: electorate,
+		log:        log,
+		rules:      DefaultRules(),
+		now:        time.Now,
+	}
+}
+
+// SetRules replaces the default rules applied to new proposals
+func (s *Service) SetRules(rules *Rules) error {
+	if err := rules.Validate(); err != nil {
+		return err
+	}
+	copied := *rules
+	s.rules = &copied
+	return nil
+}
+
+// SetClock replaces the time source, mainly for tests
+func (s *Service) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// CreateProposal opens a new proposal. Without a start date voting opens
+// immediately; without an end date the window lasts Rules.VotingPeriod.
+func (s *Service) CreateProposal(ctx context.Context, req *ProposalRequest) (*models.GovernanceProposal, error) {
+	s.log.WithFields(map[string]interface{}{
+		"title":    req.Title,
+		"proposer": req.ProposerID,
+	}).Info("Creating governance proposal")
+
+	if strings.TrimSpace(req.Title) == "" || strings.TrimSpace(req.Description) == "" {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput,
+			"Invalid proposal", "title and description are required")
+	}
+
+	rules := *s.rules
+	if req.Rules != nil {
+		rules = *req.Rules
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkMember(ctx, req.ProposerID); err != nil {
+		return nil, err
+	}
+
+	now := s.now().UTC()
+	start := req.StartDate.UTC()
+	if req.StartDate.IsZero() {
+		start = now
+	}
+	end := req.EndDate.UTC()
+	if req.EndDate.IsZero() {
+		end = start.Add(rules.VotingPeriod)
+	}
+	if !end.After(start) || !end.After(now) {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid voting window",
+			fmt.Sprintf("end date %s must be after the start date and in the future", end.Format(time.RFC3339)))
+	}
+
+	p := &models.GovernanceProposal{
+		Title:       req.Title,
+		Description: req.Description,
+		ProposerID:  req.ProposerID,
+		Status:      string(models.ProposalStatusActive),
+		StartDate:   start,
+		EndDate:     end,
+	}
+	if !now.Before(start) {
+		p.Status = string(models.ProposalStatusVoting)
+	}
+
+	if err := s.repo.CreateProposal(ctx, p, &rules); err != nil {
+		return nil, fmt.Errorf("failed to create proposal: %w", err)
+	}
+
+	return p, nil
+}
+
+// GetProposal returns a proposal, closing it first if its window has ended
+func (s *Service) GetProposal(ctx context.Context, id string) (*models.GovernanceProposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, err := s.repo.GetProposal(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.advance(ctx, p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ListProposals closes any expired proposals and returns those matching the filter
+func (s *Service) ListProposals(ctx context.Context, filter *Filter) ([]*models.GovernanceProposal, error) {
+	if _, err := s.Sync(ctx); err != nil {
+		return nil, err
+	}
+
+	proposals, err := s.repo.ListProposals(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list proposals: %w", err)
+	}
+	return proposals, nil
+}
+
+// CastVote records a member's vote. Each member votes once per proposal,
+// and only while the proposal's voting window is open.
+func (s *Service) CastVote(ctx context.Context, req *VoteRequest) (*models.GovernanceVote, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !req.Vote.IsValid() {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid vote",
+			fmt.Sprintf("vote must be yes, no or abstain, got '%s'", req.Vote))
+	}
+
+	p, err := s.repo.GetProposal(ctx, req.ProposalID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.advance(ctx, p); err != nil {
+		return nil, err
+	}
+	if models.GovernanceProposalStatus(p.Status) != models.ProposalStatusVoting {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Proposal is not open for voting",
+			fmt.Sprintf("proposal %s is %s, voting runs from %s to %s", p.ID, p.Status,
+				p.StartDate.Format(time.RFC3339), p.EndDate.Format(time.RFC3339)))
+	}
+
+	rules, err := s.repo.GetRules(ctx, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proposal rules: %w", err)
+	}
+	if err := s.checkMember(ctx, req.VoterID); err != nil {
+		return nil, err
+	}
+	rep, err := s.electorate.Reputation(ctx, req.VoterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voter reputation: %w", err)
+	}
+	if _, ok := rules.weight(rep); !ok {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeForbidden, "Voter is not eligible",
+			fmt.Sprintf("%s needs a reputation score of at least %.1f", req.VoterID, rules.MinReputation))
+	}
+
+	switch req.Vote {
+	case models.VoteTypeYes:
+		p.VotesFor++
+	case models.VoteTypeNo:
+		p.VotesAgainst++
+	case models.VoteTypeAbstain:
+		p.VotesAbstain++
+	}
+
+	v := &models.GovernanceVote{
+		ProposalID: p.ID,
+		VoterID:    req.VoterID,
+		Vote:       string(req.Vote),
+		Reason:     req.Reason,
+	}
+	if err := s.repo.CastVote(ctx, v, p); err != nil {
+		if coreerrors.GetErrorCode(err) == coreerrors.ErrCodeVoteAlreadyCast {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to cast vote: %w", err)
+	}
+
+	s.log.WithFields(map[string]interface{}{
+		"proposal_id": p.ID,
+		"voter_id":    req.VoterID,
+		"vote":        req.Vote,
+	}).Info("Vote cast")
+
+	return v, nil
+}
+
+// ListVotes returns the votes cast on a proposal
+func (s *Service) ListVotes(ctx context.Context, proposalID string) ([]*models.GovernanceVote, error) {
+	votes, err := s.repo.ListVotes(ctx, proposalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list votes: %w", err)
+	}
+	return votes, nil
+}
+
+// Tally counts a proposal's votes under its rules. Weights come from the
+// electorate at the time of counting.
+func (s *Service) Tally(ctx context.Context, proposalID string) (*Tally, error) {
+	p, err := s.repo.GetProposal(ctx, proposalID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := s.repo.GetRules(ctx, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proposal rules: %w", err)
+	}
+	return s.count(ctx, p, rules)
+}
+
+// Sync moves proposals whose window opened to voting and closes those whose
+// window ended, returning the proposals it closed
+func (s *Service) Sync(ctx context.Context) ([]*models.GovernanceProposal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	open, err := s.repo.ListOpenProposals(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list open proposals: %w", err)
+	}
+
+	var closed []*models.GovernanceProposal
+	for _, p := range open {
+		if err := s.advance(ctx, p); err != nil {
+			return closed, err
+		}
+		if !isOpen(p) {
+			closed = append(closed, p)
+		}
+	}
+	return closed, nil
+}
+
+// Run calls Sync every interval until the context is cancelled
+func (s *Service) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Sync(ctx); err != nil {
+			s.log.Error("Failed to sync proposals: ", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// advance applies the time-based status changes to a proposal and stores it
+// if anything changed
+func (s *Service) advance(ctx context.Context, p *models.GovernanceProposal) error {
+	if !isOpen(p) {
+		return nil
+	}
+
+	now := s.now().UTC()
+	from := p.Status
+
+	if models.GovernanceProposalStatus(p.Status) == models.ProposalStatusActive && !now.Before(p.StartDate) {
+		p.Status = string(models.ProposalStatusVoting)
+	}
+
+	var tally *Tally
+	if !now.Before(p.EndDate) {
+		rules, err := s.repo.GetRules(ctx, p.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get proposal rules: %w", err)
+		}
+		tally, err = s.count(ctx, p, rules)
+		if err != nil {
+			return err
+		}
+		p.Status = string(tally.Outcome())
+	}
+
+	if p.Status == from {
+		return nil
+	}
+	if err := s.repo.UpdateProposal(ctx, p); err != nil {
+		return fmt.Errorf("failed to update proposal: %w", err)
+	}
+
+	fields := map[string]interface{}{
+		"proposal_id": p.ID,
+		"from":        from,
+		"to":          p.Status,
+	}
+	if tally != nil {
+		fields["participation"] = tally.Participation
+		fields["approval"] = tally.Approval
+	}
+	s.log.WithFields(fields).Info("Proposal status changed")
+
+	return nil
+}
+
+// count computes the weighted tally of a proposal
+func (s *Service) count(ctx context.Context, p *models.GovernanceProposal, rules *Rules) (*Tally, error) {
+	members, err := s.electorate.Members(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list members: %w", err)
+	}
+
+	tally := &Tally{
+		ProposalID: p.ID,
+		Rules:      *rules,
+		CountedAt:  s.now().UTC(),
+	}
+
+	weights := make(map[string]float64, len(members))
+	for _, id := range members {
+		rep, err := s.electorate.Reputation(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get reputation of %s: %w", id, err)
+		}
+		if w, ok := rules.weight(rep); ok {
+			weights[id] = w
+			tally.Eligible += w
+		}
+	}
+
+	votes, err := s.repo.ListVotes(ctx, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list votes: %w", err)
+	}
+	for _, v := range votes {
+		// Members who left or fell below the minimum reputation no longer count
+		w := weights[v.VoterID]
+		switch models.GovernanceVoteType(v.Vote) {
+		case models.VoteTypeYes:
+			tally.For += w
+		case models.VoteTypeNo:
+			tally.Against += w
+		case models.VoteTypeAbstain:
+			tally.Abstain += w
+		}
+	}
+
+	tally.Cast = tally.For + tally.Against + tally.Abstain
+	if tally.Eligible > 0 {
+		tally.Participation = tally.Cast / tally.Eligible
+	}
+	if decided := tally.For + tally.Against; decided > 0 {
+		tally.Approval = tally.For / decided
+	}
+	tally.QuorumReached = tally.Eligible > 0 && tally.Participation >= rules.Quorum
+	tally.Passed = tally.QuorumReached && tally.Approval > rules.Threshold
+
+	return tally, nil
+}
+
+// checkMember returns ErrCodeForbidden unless nodeID belongs to the electorate
+func (s *Service) checkMember(ctx context.Context, nodeID string) error {
+	members, err := s.electorate.Members(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list members: %w", err)
+	}
+	for _, id := range members {
+		if id == nodeID {
+			return nil
+		}
+	}
+	return coreerrors.NewAPIError(coreerrors.ErrCodeForbidden, "Not a cooperative member",
+		fmt.Sprintf("node '%s' is not part of the electorate", nodeID))
+}
+
+// isOpen reports whether a proposal can still change status on its own
+func isOpen(p *models.GovernanceProposal) bool {
+	switch models.GovernanceProposalStatus(p.Status) {
+	case models.ProposalStatusActive, models.ProposalStatusVoting:
+		return true
+	default:
+		return false
+	}
+}
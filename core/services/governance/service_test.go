@@ -0,0 +1,220 @@
+package governance_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/services/governance"
+	"syntropy-cc/cooperative-grid/core/storage"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(args ...interface{})                                     {}
+func (nopLogger) Error(args ...interface{})                                    {}
+func (nopLogger) Debug(args ...interface{})                                    {}
+func (l nopLogger) WithFields(fields map[string]interface{}) governance.Logger { return l }
+
+// electorate is a fixed set of members with reputation scores
+type electorate map[string]float64
+
+func (e electorate) Members(ctx context.Context) ([]string, error) {
+	var ids []string
+	for id := range e {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (e electorate) Reputation(ctx context.Context, nodeID string) (*models.NodeReputation, error) {
+	score, ok := e[nodeID]
+	if !ok {
+		return nil, nil
+	}
+	return &models.NodeReputation{NodeID: nodeID, Score: score}, nil
+}
+
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newTestService(t *testing.T, members electorate) (*governance.Service, *clock) {
+	t.Helper()
+	store, err := storage.Open(filepath.Join(t.TempDir(), storage.DefaultFileName))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	c := &clock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	svc := governance.NewService(storage.NewGovernanceRepository(store), members, nopLogger{})
+	svc.SetClock(c.Now)
+	return svc, c
+}
+
+func vote(t *testing.T, svc *governance.Service, proposalID, voter string, v models.GovernanceVoteType) {
+	t.Helper()
+	if _, err := svc.CastVote(context.Background(), &governance.VoteRequest{
+		ProposalID: proposalID, VoterID: voter, Vote: v,
+	}); err != nil {
+		t.Fatalf("vote %s by %s: %v", v, voter, err)
+	}
+}
+
+func TestOneVotePerMember(t *testing.T) {
+	svc, _ := newTestService(t, electorate{"a": 5, "b": 5})
+	ctx := context.Background()
+
+	p, err := svc.CreateProposal(ctx, &governance.ProposalRequest{
+		Title: "Raise rewards", Description: "Double service rewards", ProposerID: "a",
+	})
+	if err != nil {
+		t.Fatalf("CreateProposal: %v", err)
+	}
+	if p.Status != string(models.ProposalStatusVoting) {
+		t.Fatalf("status = %s, want voting", p.Status)
+	}
+
+	vote(t, svc, p.ID, "a", models.VoteTypeYes)
+	_, err = svc.CastVote(ctx, &governance.VoteRequest{ProposalID: p.ID, VoterID: "a", Vote: models.VoteTypeNo})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeVoteAlreadyCast {
+		t.Fatalf("expected %s, got %v", coreerrors.ErrCodeVoteAlreadyCast, err)
+	}
+
+	_, err = svc.CastVote(ctx, &governance.VoteRequest{ProposalID: p.ID, VoterID: "outsider", Vote: models.VoteTypeYes})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeForbidden {
+		t.Fatalf("non-members must not vote, got %v", err)
+	}
+
+	got, err := svc.GetProposal(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("GetProposal: %v", err)
+	}
+	if got.VotesFor != 1 || got.VotesAgainst != 0 {
+		t.Fatalf("counters = %d/%d, want 1/0", got.VotesFor, got.VotesAgainst)
+	}
+}
+
+func TestVotingWindowIsEnforced(t *testing.T) {
+	svc, c := newTestService(t, electorate{"a": 5, "b": 5})
+	ctx := context.Background()
+
+	p, err := svc.CreateProposal(ctx, &governance.ProposalRequest{
+		Title: "Later", Description: "Opens tomorrow", ProposerID: "a",
+		StartDate: c.now.Add(24 * time.Hour), EndDate: c.now.Add(48 * time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("CreateProposal: %v", err)
+	}
+	if p.Status != string(models.ProposalStatusActive) {
+		t.Fatalf("status = %s, want active", p.Status)
+	}
+
+	_, err = svc.CastVote(ctx, &governance.VoteRequest{ProposalID: p.ID, VoterID: "a", Vote: models.VoteTypeYes})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeInvalidInput {
+		t.Fatalf("voting before the window must fail, got %v", err)
+	}
+
+	c.now = c.now.Add(25 * time.Hour)
+	vote(t, svc, p.ID, "a", models.VoteTypeYes)
+	vote(t, svc, p.ID, "b", models.VoteTypeYes)
+
+	c.now = c.now.Add(24 * time.Hour)
+	closed, err := svc.Sync(ctx)
+	if err != nil {
+		t.Fatalf("Sync: %v", err)
+	}
+	if len(closed) != 1 || closed[0].Status != string(models.ProposalStatusPassed) {
+		t.Fatalf("expected proposal to close as passed, got %+v", closed)
+	}
+
+	_, err = svc.CastVote(ctx, &governance.VoteRequest{ProposalID: p.ID, VoterID: "b", Vote: models.VoteTypeNo})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeInvalidInput {
+		t.Fatalf("voting after close must fail, got %v", err)
+	}
+}
+
+func TestQuorumAndThreshold(t *testing.T) {
+	members := electorate{"a": 5, "b": 5, "c": 5, "d": 5}
+	svc, c := newTestService(t, members)
+	ctx := context.Background()
+
+	create := func(title string) *models.GovernanceProposal {
+		p, err := svc.CreateProposal(ctx, &governance.ProposalRequest{
+			Title: title, Description: title, ProposerID: "a", EndDate: c.now.Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("CreateProposal: %v", err)
+		}
+		return p
+	}
+
+	noQuorum := create("no quorum")
+	vote(t, svc, noQuorum.ID, "a", models.VoteTypeYes)
+
+	tie := create("tie")
+	vote(t, svc, tie.ID, "a", models.VoteTypeYes)
+	vote(t, svc, tie.ID, "b", models.VoteTypeNo)
+
+	passed := create("passed")
+	vote(t, svc, passed.ID, "a", models.VoteTypeYes)
+	vote(t, svc, passed.ID, "b", models.VoteTypeYes)
+	vote(t, svc, passed.ID, "c", models.VoteTypeAbstain)
+
+	c.now = c.now.Add(2 * time.Hour)
+	want := map[string]models.GovernanceProposalStatus{
+		noQuorum.ID: models.ProposalStatusExpired,
+		tie.ID:      models.ProposalStatusRejected,
+		passed.ID:   models.ProposalStatusPassed,
+	}
+	for id, status := range want {
+		p, err := svc.GetProposal(ctx, id)
+		if err != nil {
+			t.Fatalf("GetProposal: %v", err)
+		}
+		if p.Status != string(status) {
+			t.Errorf("%s: status = %s, want %s", p.Title, p.Status, status)
+		}
+	}
+}
+
+func TestReputationWeightedVoting(t *testing.T) {
+	svc, c := newTestService(t, electorate{"veteran": 9, "new-a": 1, "new-b": 1, "untrusted": 0.5})
+	ctx := context.Background()
+
+	p, err := svc.CreateProposal(ctx, &governance.ProposalRequest{
+		Title: "Weighted", Description: "Reputation decides", ProposerID: "veteran",
+		EndDate: c.now.Add(time.Hour),
+		Rules: &governance.Rules{
+			Quorum: 0.5, Threshold: 0.5, Weighting: governance.WeightingReputation,
+			MinReputation: 1, VotingPeriod: time.Hour,
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateProposal: %v", err)
+	}
+
+	_, err = svc.CastVote(ctx, &governance.VoteRequest{ProposalID: p.ID, VoterID: "untrusted", Vote: models.VoteTypeNo})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeForbidden {
+		t.Fatalf("members below the minimum reputation must not vote, got %v", err)
+	}
+
+	vote(t, svc, p.ID, "veteran", models.VoteTypeYes)
+	vote(t, svc, p.ID, "new-a", models.VoteTypeNo)
+	vote(t, svc, p.ID, "new-b", models.VoteTypeNo)
+
+	tally, err := svc.Tally(ctx, p.ID)
+	if err != nil {
+		t.Fatalf("Tally: %v", err)
+	}
+	if tally.For != 9 || tally.Against != 2 || tally.Eligible != 11 {
+		t.Fatalf("unexpected tally: %+v", tally)
+	}
+	if !tally.Passed {
+		t.Fatalf("one high-reputation yes should outweigh two low-reputation no votes: %+v", tally)
+	}
+}
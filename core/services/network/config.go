@@ -0,0 +1,145 @@
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	"syntropy-cc/cooperative-grid/core/types/constants"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// WireGuard overlay defaults, matching what cloud-init sets up on the nodes
+const (
+	DefaultSubnet     = "172.20.0.0/16"
+	DefaultListenPort = 51820
+	DefaultInterface  = "wg0"
+	DefaultKeepalive  = 25
+	// DefaultMeshName names the grid's single service mesh record
+	DefaultMeshName = "default"
+)
+
+// MeshConfig is the stored configuration of the service mesh: the policy
+// settings of models.ServiceMeshConfig plus the WireGuard overlay they run on
+type MeshConfig struct {
+	models.ServiceMeshConfig
+	// Subnet is the overlay network node addresses are allocated from
+	Subnet     string `json:"subnet"`
+	ListenPort int    `json:"listen_port"`
+	Interface  string `json:"interface"`
+	// Keepalive is the PersistentKeepalive interval in seconds, 0 disables it
+	Keepalive int `json:"persistent_keepalive"`
+}
+
+// DefaultMeshConfig returns the overlay settings used until the mesh is configured
+func DefaultMeshConfig() *MeshConfig {
+	return &MeshConfig{
+		Subnet:     DefaultSubnet,
+		ListenPort: DefaultListenPort,
+		Interface:  DefaultInterface,
+		Keepalive:  DefaultKeepalive,
+	}
+}
+
+// Validate checks the overlay settings
+func (c *MeshConfig) Validate() error {
+	ip, subnet, err := net.ParseCIDR(c.Subnet)
+	if err != nil || ip.To4() == nil {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid mesh configuration",
+			fmt.Sprintf("subnet %q must be an IPv4 CIDR", c.Subnet))
+	}
+	if ones, _ := subnet.Mask.Size(); ones > 30 {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid mesh configuration",
+			fmt.Sprintf("subnet %s is too small for a mesh", c.Subnet))
+	}
+	if c.ListenPort < 1 || c.ListenPort > 65535 {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid mesh configuration",
+			fmt.Sprintf("listen port %d must be between 1 and 65535", c.ListenPort))
+	}
+	if c.Interface == "" || len(c.Interface) > 15 || strings.ContainsAny(c.Interface, " /") {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid mesh configuration",
+			fmt.Sprintf("invalid interface name %q", c.Interface))
+	}
+	if c.Keepalive < 0 {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid mesh configuration",
+			"persistent keepalive must not be negative")
+	}
+	return nil
+}
+
+// DecodeMeshConfig reads the configuration stored on a mesh record,
+// filling in defaults for missing settings
+func DecodeMeshConfig(m *models.ServiceMesh) (*MeshConfig, error) {
+	cfg := DefaultMeshConfig()
+	if m == nil || m.Config == nil {
+		return cfg, nil
+	}
+	if err := convert(m.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode mesh config: %w", err)
+	}
+	return cfg, nil
+}
+
+// DecodeRouteConfig reads the configuration stored on a route
+func DecodeRouteConfig(r *models.NetworkRoute) (*models.NetworkRouteConfig, error) {
+	cfg := &models.NetworkRouteConfig{}
+	if r.Config == nil {
+		return cfg, nil
+	}
+	if err := convert(r.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to decode route config: %w", err)
+	}
+	return cfg, nil
+}
+
+// validateRouteConfig checks and normalizes a route configuration
+func validateRouteConfig(cfg *models.NetworkRouteConfig) error {
+	if cfg.Priority < 0 || cfg.Priority > 1000 {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid route",
+			fmt.Sprintf("priority %d must be between 0 and 1000", cfg.Priority))
+	}
+
+	cfg.Protocol = strings.ToLower(cfg.Protocol)
+	switch cfg.Protocol {
+	case "":
+		if len(cfg.Ports) > 0 {
+			cfg.Protocol = constants.NetworkProtocolTCP
+		}
+	case constants.NetworkProtocolTCP, constants.NetworkProtocolUDP:
+	default:
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid route",
+			fmt.Sprintf("protocol %q must be tcp or udp", cfg.Protocol))
+	}
+
+	for _, p := range cfg.Ports {
+		if p < 1 || p > 65535 {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid route",
+				fmt.Sprintf("port %d must be between 1 and 65535", p))
+		}
+	}
+	if len(cfg.Ports) > 0 && !cfg.Encryption {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid route",
+			"port filtering is applied on the WireGuard interface and needs encryption")
+	}
+	return nil
+}
+
+// encode converts a config struct to the generic map stored on the models
+func encode(v interface{}) (map[string]interface{}, error) {
+	m := make(map[string]interface{})
+	if err := convert(v, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// convert round-trips a value through JSON into out
+func convert(in, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,72 @@
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+)
+
+// allocator hands out overlay addresses from the mesh subnet, lowest free
+// address first, so a node keeps its address as long as it stays in the mesh
+type allocator struct {
+	first, last uint32
+	used        map[uint32]bool
+}
+
+// newAllocator creates an allocator for the subnet with the given addresses taken
+func newAllocator(subnet string, taken []string) (*allocator, error) {
+	_, ipnet, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return nil, fmt.Errorf("invalid subnet %q: %w", subnet, err)
+	}
+	base := ipToUint(ipnet.IP)
+	ones, bits := ipnet.Mask.Size()
+	size := uint32(1) << uint(bits-ones)
+
+	a := &allocator{
+		// Skip the network and broadcast addresses
+		first: base + 1,
+		last:  base + size - 2,
+		used:  make(map[uint32]bool),
+	}
+	for _, t := range taken {
+		if ip := net.ParseIP(t); ip != nil && ip.To4() != nil {
+			a.used[ipToUint(ip)] = true
+		}
+	}
+	return a, nil
+}
+
+// next allocates the lowest free address
+func (a *allocator) next() (string, error) {
+	for n := a.first; n <= a.last; n++ {
+		if !a.used[n] {
+			a.used[n] = true
+			return uintToIP(n).String(), nil
+		}
+	}
+	return "", coreerrors.NewAPIError(coreerrors.ErrCodeConflict, "Mesh subnet exhausted",
+		fmt.Sprintf("no free address left in %s", uintToIP(a.first-1)))
+}
+
+// contains reports whether addr belongs to the allocatable range
+func (a *allocator) contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil || ip.To4() == nil {
+		return false
+	}
+	n := ipToUint(ip)
+	return n >= a.first && n <= a.last
+}
+
+func ipToUint(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+func uintToIP(n uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, n)
+	return ip
+}
@@ -0,0 +1,541 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"syntropy-cc/cooperative-grid/core/types/constants"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Mesh policy types
+const (
+	PolicyAllow     = "allow"
+	PolicyDeny      = "deny"
+	PolicyRateLimit = "rate_limit"
+	PolicyReroute   = "reroute"
+)
+
+// Traffic describes a connection to check against the mesh policies
+type Traffic struct {
+	SourceNode string `json:"source_node"`
+	// SourceContainer is the container opening the connection, if any
+	SourceContainer string `json:"source_container,omitempty"`
+	// SourceIP is checked against the security lists and source_ips
+	SourceIP        string `json:"source_ip,omitempty"`
+	DestinationNode string `json:"destination_node"`
+	Port            int    `json:"port"`
+	Protocol        string `json:"protocol"`
+}
+
+// Decision is the outcome of evaluating traffic against the policies
+type Decision struct {
+	// Action is allow, deny, rate_limit or reroute
+	Action string `json:"action"`
+	// Rule names what decided: a policy name, security.blocked_ips,
+	// security.allowed_ips or default
+	Rule      string     `json:"rule"`
+	Reason    string     `json:"reason"`
+	RateLimit *RateLimit `json:"rate_limit,omitempty"`
+	// Via is the relay node for rerouted traffic
+	Via string `json:"via,omitempty"`
+	// Checks lists every rule evaluated, in order, up to the one that decided
+	Checks []PolicyCheck `json:"checks"`
+}
+
+// RateLimit is the limit applied by a rate_limit policy
+type RateLimit struct {
+	RequestsPerSecond int `json:"requests_per_second"`
+	Burst             int `json:"burst"`
+}
+
+// PolicyCheck is one step of an evaluation
+type PolicyCheck struct {
+	Rule    string `json:"rule"`
+	Matched bool   `json:"matched"`
+	Reason  string `json:"reason"`
+}
+
+// PolicyMatch is the part of a policy config that selects traffic. Empty
+// fields match everything; node and container names accept * globs.
+type PolicyMatch struct {
+	SourceNodes      []string    `json:"source_nodes,omitempty"`
+	SourceContainers []string    `json:"source_containers,omitempty"`
+	SourceIPs        []string    `json:"source_ips,omitempty"`
+	DestinationNodes []string    `json:"destination_nodes,omitempty"`
+	Ports            []PortRange `json:"ports,omitempty"`
+	Protocol         string      `json:"protocol,omitempty"`
+}
+
+// PolicyConfig is the decoded MeshPolicy.Config
+type PolicyConfig struct {
+	PolicyMatch
+	RequestsPerSecond int    `json:"requests_per_second,omitempty"`
+	Burst             int    `json:"burst,omitempty"`
+	Via               string `json:"via,omitempty"`
+}
+
+// PortRange is a port or an inclusive range, written as 443 or "8000-8100"
+type PortRange struct {
+	From int
+	To   int
+}
+
+// UnmarshalJSON accepts a number or a "from-to" string
+func (p *PortRange) UnmarshalJSON(data []byte) error {
+	var n int
+	if err := json.Unmarshal(data, &n); err == nil {
+		p.From, p.To = n, n
+		return nil
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("port must be a number or a range like \"8000-8100\"")
+	}
+	r, err := ParsePortRange(s)
+	if err != nil {
+		return err
+	}
+	*p = r
+	return nil
+}
+
+// MarshalJSON writes single ports as numbers and ranges as strings
+func (p PortRange) MarshalJSON() ([]byte, error) {
+	if p.From == p.To {
+		return json.Marshal(p.From)
+	}
+	return json.Marshal(p.String())
+}
+
+func (p PortRange) String() string {
+	if p.From == p.To {
+		return strconv.Itoa(p.From)
+	}
+	return fmt.Sprintf("%d-%d", p.From, p.To)
+}
+
+// ParsePortRange parses "443" or "8000-8100"
+func ParsePortRange(s string) (PortRange, error) {
+	from, to, isRange := strings.Cut(strings.TrimSpace(s), "-")
+	a, err := strconv.Atoi(from)
+	if err != nil {
+		return PortRange{}, fmt.Errorf("invalid port %q", s)
+	}
+	b := a
+	if isRange {
+		if b, err = strconv.Atoi(to); err != nil {
+			return PortRange{}, fmt.Errorf("invalid port range %q", s)
+		}
+	}
+	if a < 1 || b > 65535 || a > b {
+		return PortRange{}, fmt.Errorf("invalid port range %q", s)
+	}
+	return PortRange{From: a, To: b}, nil
+}
+
+// PolicyEngine evaluates traffic against a mesh configuration
+type PolicyEngine struct {
+	allowed  []*net.IPNet
+	blocked  []*net.IPNet
+	policies []compiledPolicy
+}
+
+type compiledPolicy struct {
+	policy  models.MeshPolicy
+	config  PolicyConfig
+	sources []*net.IPNet
+}
+
+// NewPolicyEngine validates the policies and security lists of cfg.
+// Disabled policies are validated but never match.
+func NewPolicyEngine(cfg models.ServiceMeshConfig) (*PolicyEngine, error) {
+	e := &PolicyEngine{}
+	var err error
+	if e.allowed, err = parseNets(cfg.Security.AllowedIPs); err != nil {
+		return nil, invalidPolicy("security.allowed_ips", err.Error())
+	}
+	if e.blocked, err = parseNets(cfg.Security.BlockedIPs); err != nil {
+		return nil, invalidPolicy("security.blocked_ips", err.Error())
+	}
+
+	names := make(map[string]bool, len(cfg.Policies))
+	for _, p := range cfg.Policies {
+		if names[p.Name] {
+			return nil, invalidPolicy(p.Name, "duplicate policy name")
+		}
+		names[p.Name] = true
+
+		compiled, err := compilePolicy(p)
+		if err != nil {
+			return nil, err
+		}
+		if p.Enabled {
+			e.policies = append(e.policies, compiled)
+		}
+	}
+
+	// Highest priority first, like routes; ties are broken by name so the
+	// order never depends on how the policies were stored
+	sort.SliceStable(e.policies, func(i, j int) bool {
+		a, b := e.policies[i].policy, e.policies[j].policy
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		return a.Name < b.Name
+	})
+	return e, nil
+}
+
+// ValidatePolicy checks a single policy
+func ValidatePolicy(p models.MeshPolicy) error {
+	_, err := compilePolicy(p)
+	return err
+}
+
+func compilePolicy(p models.MeshPolicy) (compiledPolicy, error) {
+	if p.Name == "" {
+		return compiledPolicy{}, invalidPolicy("", "policy name is required")
+	}
+	c := compiledPolicy{policy: p}
+	if p.Config != nil {
+		// Unknown keys are refused: a misspelled match field would
+		// otherwise make the policy match everything
+		data, err := json.Marshal(p.Config)
+		if err != nil {
+			return compiledPolicy{}, invalidPolicy(p.Name, err.Error())
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&c.config); err != nil {
+			return compiledPolicy{}, invalidPolicy(p.Name, err.Error())
+		}
+	}
+
+	switch p.Type {
+	case PolicyAllow, PolicyDeny:
+	case PolicyRateLimit:
+		if c.config.RequestsPerSecond <= 0 {
+			return compiledPolicy{}, invalidPolicy(p.Name, "rate_limit needs requests_per_second > 0")
+		}
+		if c.config.Burst == 0 {
+			c.config.Burst = c.config.RequestsPerSecond
+		}
+		if c.config.Burst < c.config.RequestsPerSecond {
+			return compiledPolicy{}, invalidPolicy(p.Name, "burst must be at least requests_per_second")
+		}
+	case PolicyReroute:
+		if c.config.Via == "" {
+			return compiledPolicy{}, invalidPolicy(p.Name, "reroute needs a via node")
+		}
+	default:
+		return compiledPolicy{}, invalidPolicy(p.Name,
+			fmt.Sprintf("unknown type %q, expected allow, deny, rate_limit or reroute", p.Type))
+	}
+
+	switch proto := strings.ToLower(c.config.Protocol); proto {
+	case "", constants.NetworkProtocolTCP, constants.NetworkProtocolUDP:
+		c.config.Protocol = proto
+	default:
+		return compiledPolicy{}, invalidPolicy(p.Name, fmt.Sprintf("protocol %q must be tcp or udp", c.config.Protocol))
+	}
+	for _, pattern := range append(append(append([]string{}, c.config.SourceNodes...),
+		c.config.SourceContainers...), c.config.DestinationNodes...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return compiledPolicy{}, invalidPolicy(p.Name, fmt.Sprintf("invalid pattern %q", pattern))
+		}
+	}
+
+	var err error
+	if c.sources, err = parseNets(c.config.SourceIPs); err != nil {
+		return compiledPolicy{}, invalidPolicy(p.Name, err.Error())
+	}
+	return c, nil
+}
+
+// Evaluate decides what happens to the traffic. Blocked IPs are denied
+// first, then sources outside a non-empty allow list; after that the
+// enabled policies are tried in priority order and the first match
+// decides. Traffic no policy matches is allowed.
+func (e *PolicyEngine) Evaluate(t Traffic) *Decision {
+	d := &Decision{}
+	ip := net.ParseIP(t.SourceIP)
+
+	if len(e.blocked) > 0 {
+		if n := containing(e.blocked, ip); n != nil {
+			return d.decide(PolicyDeny, "security.blocked_ips", fmt.Sprintf("source %s is in blocked range %s", t.SourceIP, n))
+		}
+		d.check("security.blocked_ips", false, "source is not in a blocked range")
+	}
+	if len(e.allowed) > 0 {
+		n := containing(e.allowed, ip)
+		if n == nil {
+			source := t.SourceIP
+			if source == "" {
+				source = "unknown address"
+			}
+			return d.decide(PolicyDeny, "security.allowed_ips", fmt.Sprintf("source %s is not in an allowed range", source))
+		}
+		d.check("security.allowed_ips", false, fmt.Sprintf("source is in allowed range %s", n))
+	}
+
+	for _, p := range e.policies {
+		if reason, ok := p.matches(t); !ok {
+			d.check(p.policy.Name, false, reason)
+			continue
+		}
+		reason := fmt.Sprintf("%s policy with priority %d matched", p.policy.Type, p.policy.Priority)
+		switch p.policy.Type {
+		case PolicyRateLimit:
+			d.RateLimit = &RateLimit{RequestsPerSecond: p.config.RequestsPerSecond, Burst: p.config.Burst}
+		case PolicyReroute:
+			d.Via = p.config.Via
+			reason += ", via " + p.config.Via
+		}
+		return d.decide(p.policy.Type, p.policy.Name, reason)
+	}
+
+	return d.decide(PolicyAllow, "default", "no policy matched")
+}
+
+func (d *Decision) check(rule string, matched bool, reason string) {
+	d.Checks = append(d.Checks, PolicyCheck{Rule: rule, Matched: matched, Reason: reason})
+}
+
+func (d *Decision) decide(action, rule, reason string) *Decision {
+	d.check(rule, true, reason)
+	d.Action, d.Rule, d.Reason = action, rule, reason
+	return d
+}
+
+// matches reports whether the policy selects the traffic, or why not
+func (p compiledPolicy) matches(t Traffic) (string, bool) {
+	c := p.config
+	if len(c.SourceNodes) > 0 && !matchAny(c.SourceNodes, t.SourceNode) {
+		return fmt.Sprintf("source node %q is not in %v", t.SourceNode, c.SourceNodes), false
+	}
+	if len(c.SourceContainers) > 0 && (t.SourceContainer == "" || !matchAny(c.SourceContainers, t.SourceContainer)) {
+		return fmt.Sprintf("source container %q is not in %v", t.SourceContainer, c.SourceContainers), false
+	}
+	if len(p.sources) > 0 && containing(p.sources, net.ParseIP(t.SourceIP)) == nil {
+		return fmt.Sprintf("source IP %q is not in %v", t.SourceIP, c.SourceIPs), false
+	}
+	if len(c.DestinationNodes) > 0 && !matchAny(c.DestinationNodes, t.DestinationNode) {
+		return fmt.Sprintf("destination node %q is not in %v", t.DestinationNode, c.DestinationNodes), false
+	}
+	if c.Protocol != "" && !strings.EqualFold(c.Protocol, t.Protocol) {
+		return fmt.Sprintf("protocol %s is not %s", t.Protocol, c.Protocol), false
+	}
+	if len(c.Ports) > 0 {
+		found := false
+		for _, r := range c.Ports {
+			if t.Port >= r.From && t.Port <= r.To {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("port %d is not in %v", t.Port, c.Ports), false
+		}
+	}
+	return "", true
+}
+
+// EvaluatePolicy checks traffic against the stored mesh policies. When the
+// source IP is not given, the source node's overlay address is used, or
+// its host address before it joins the overlay.
+func (s *Service) EvaluatePolicy(ctx context.Context, t Traffic) (*Decision, error) {
+	_, cfg, err := s.Mesh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.EvaluatePolicyWith(ctx, cfg.ServiceMeshConfig, t)
+}
+
+// EvaluatePolicyWith checks traffic against the given policies instead of
+// the stored ones, to try changes before saving them
+func (s *Service) EvaluatePolicyWith(ctx context.Context, cfg models.ServiceMeshConfig, t Traffic) (*Decision, error) {
+	engine, err := NewPolicyEngine(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes, err := s.directory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range []string{t.SourceNode, t.DestinationNode} {
+		if _, ok := nodes[id]; !ok {
+			return nil, coreerrors.NewAPIError(coreerrors.ErrCodeNodeNotFound, "Node not found", id)
+		}
+	}
+	if t.Port < 1 || t.Port > 65535 {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid traffic",
+			fmt.Sprintf("port %d must be between 1 and 65535", t.Port))
+	}
+	if t.Protocol == "" {
+		t.Protocol = constants.NetworkProtocolTCP
+	}
+
+	if t.SourceIP == "" {
+		peers, err := s.Peers(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range peers {
+			if p.NodeID == t.SourceNode {
+				t.SourceIP = p.Address
+			}
+		}
+		if t.SourceIP == "" {
+			t.SourceIP = nodes[t.SourceNode].Host
+		}
+	}
+	return engine.Evaluate(t), nil
+}
+
+// Policies returns the stored mesh policies, highest priority first
+func (s *Service) Policies(ctx context.Context) ([]models.MeshPolicy, error) {
+	_, cfg, err := s.Mesh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	policies := append([]models.MeshPolicy(nil), cfg.Policies...)
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].Priority != policies[j].Priority {
+			return policies[i].Priority > policies[j].Priority
+		}
+		return policies[i].Name < policies[j].Name
+	})
+	return policies, nil
+}
+
+// SetPolicy adds a policy or replaces the one with the same name
+func (s *Service) SetPolicy(ctx context.Context, p models.MeshPolicy) error {
+	if err := ValidatePolicy(p); err != nil {
+		return err
+	}
+	mesh, cfg, err := s.Mesh(ctx)
+	if err != nil {
+		return err
+	}
+
+	replaced := false
+	for i := range cfg.Policies {
+		if cfg.Policies[i].Name == p.Name {
+			cfg.Policies[i] = p
+			replaced = true
+		}
+	}
+	if !replaced {
+		cfg.Policies = append(cfg.Policies, p)
+	}
+
+	s.log.WithFields(map[string]interface{}{"policy": p.Name, "type": p.Type}).Info("Saving mesh policy")
+	_, err = s.saveMesh(ctx, mesh, cfg, models.ServiceMeshStatus(mesh.Status))
+	return err
+}
+
+// RemovePolicy deletes a policy by name
+func (s *Service) RemovePolicy(ctx context.Context, name string) error {
+	mesh, cfg, err := s.Mesh(ctx)
+	if err != nil {
+		return err
+	}
+
+	kept := cfg.Policies[:0]
+	for _, p := range cfg.Policies {
+		if p.Name != name {
+			kept = append(kept, p)
+		}
+	}
+	if len(kept) == len(cfg.Policies) {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeNotFound, "Policy not found", name)
+	}
+	cfg.Policies = kept
+
+	s.log.WithFields(map[string]interface{}{"policy": name}).Info("Removing mesh policy")
+	_, err = s.saveMesh(ctx, mesh, cfg, models.ServiceMeshStatus(mesh.Status))
+	return err
+}
+
+// SetSecurityLists replaces the mesh's allowed and blocked IP lists
+func (s *Service) SetSecurityLists(ctx context.Context, allowed, blocked []string) error {
+	if _, err := parseNets(allowed); err != nil {
+		return invalidPolicy("security.allowed_ips", err.Error())
+	}
+	if _, err := parseNets(blocked); err != nil {
+		return invalidPolicy("security.blocked_ips", err.Error())
+	}
+	mesh, cfg, err := s.Mesh(ctx)
+	if err != nil {
+		return err
+	}
+	cfg.Security.AllowedIPs = allowed
+	cfg.Security.BlockedIPs = blocked
+	_, err = s.saveMesh(ctx, mesh, cfg, models.ServiceMeshStatus(mesh.Status))
+	return err
+}
+
+// parseNets parses IPs and CIDRs; a bare IP becomes a single-host network
+func parseNets(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, v := range values {
+		if !strings.Contains(v, "/") {
+			ip := net.ParseIP(v)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid IP %q", v)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q", v)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+// containing returns the first network that contains ip
+func containing(nets []*net.IPNet, ip net.IP) *net.IPNet {
+	if ip == nil {
+		return nil
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return n
+		}
+	}
+	return nil
+}
+
+// matchAny reports whether value matches one of the glob patterns
+func matchAny(patterns []string, value string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, value); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func invalidPolicy(name, details string) error {
+	if name != "" {
+		details = name + ": " + details
+	}
+	return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid mesh policy", details)
+}
@@ -0,0 +1,152 @@
+package network_test
+
+import (
+	"context"
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/services/network"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+func samplePolicies() models.ServiceMeshConfig {
+	return models.ServiceMeshConfig{
+		Security: models.SecurityConfig{
+			AllowedIPs: []string{"172.20.0.0/16", "192.0.2.0/24"},
+			BlockedIPs: []string{"172.20.0.66"},
+		},
+		Policies: []models.MeshPolicy{
+			{Name: "allow-ssh", Type: network.PolicyAllow, Priority: 10, Enabled: true,
+				Config: map[string]interface{}{"ports": []interface{}{22}}},
+			{Name: "deny-db", Type: network.PolicyDeny, Priority: 100, Enabled: true,
+				Config: map[string]interface{}{"destination_nodes": []interface{}{"db-*"}, "ports": []interface{}{"5432-5433"}}},
+			{Name: "web-via-relay", Type: network.PolicyReroute, Priority: 50, Enabled: true,
+				Config: map[string]interface{}{"source_nodes": []interface{}{"edge-*"}, "via": "relay-1"}},
+			{Name: "api-limit", Type: network.PolicyRateLimit, Priority: 50, Enabled: true,
+				Config: map[string]interface{}{"source_containers": []interface{}{"api-*"}, "requests_per_second": 100}},
+			{Name: "staged", Type: network.PolicyDeny, Priority: 1000, Enabled: false},
+		},
+	}
+}
+
+func TestPolicyEngineEvaluatesInPriorityOrder(t *testing.T) {
+	engine, err := network.NewPolicyEngine(samplePolicies())
+	if err != nil {
+		t.Fatalf("NewPolicyEngine: %v", err)
+	}
+
+	for _, tc := range []struct {
+		name    string
+		traffic network.Traffic
+		action  string
+		rule    string
+		checks  int
+	}{
+		{"blocked ip wins over policies",
+			network.Traffic{SourceNode: "a", SourceIP: "172.20.0.66", DestinationNode: "b", Port: 22, Protocol: "tcp"},
+			network.PolicyDeny, "security.blocked_ips", 1},
+		{"outside the allow list",
+			network.Traffic{SourceNode: "a", SourceIP: "10.0.0.1", DestinationNode: "b", Port: 22, Protocol: "tcp"},
+			network.PolicyDeny, "security.allowed_ips", 2},
+		{"highest priority match",
+			network.Traffic{SourceNode: "edge-1", SourceIP: "172.20.0.2", DestinationNode: "db-main", Port: 5433, Protocol: "tcp"},
+			network.PolicyDeny, "deny-db", 3},
+		// api-limit and web-via-relay share a priority; the name breaks the tie
+		{"ties broken by name",
+			network.Traffic{SourceNode: "edge-1", SourceContainer: "api-7", SourceIP: "172.20.0.2", DestinationNode: "web", Port: 80, Protocol: "tcp"},
+			network.PolicyRateLimit, "api-limit", 4},
+		{"reroute",
+			network.Traffic{SourceNode: "edge-2", SourceIP: "192.0.2.9", DestinationNode: "web", Port: 80, Protocol: "tcp"},
+			network.PolicyReroute, "web-via-relay", 5},
+		{"default allow",
+			network.Traffic{SourceNode: "core", SourceIP: "172.20.0.3", DestinationNode: "web", Port: 80, Protocol: "tcp"},
+			network.PolicyAllow, "default", 7},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			d := engine.Evaluate(tc.traffic)
+			if d.Action != tc.action || d.Rule != tc.rule {
+				t.Fatalf("decision = %s by %s (%s), want %s by %s", d.Action, d.Rule, d.Reason, tc.action, tc.rule)
+			}
+			if len(d.Checks) != tc.checks || !d.Checks[len(d.Checks)-1].Matched {
+				t.Fatalf("checks = %+v, want %d ending with the match", d.Checks, tc.checks)
+			}
+		})
+	}
+
+	d := engine.Evaluate(network.Traffic{SourceNode: "edge-1", SourceContainer: "api-1", SourceIP: "172.20.0.2", DestinationNode: "web", Port: 80, Protocol: "tcp"})
+	if d.RateLimit == nil || d.RateLimit.RequestsPerSecond != 100 || d.RateLimit.Burst != 100 {
+		t.Fatalf("rate limit = %+v, want 100/s with burst 100", d.RateLimit)
+	}
+	d = engine.Evaluate(network.Traffic{SourceNode: "edge-1", SourceIP: "172.20.0.2", DestinationNode: "web", Port: 80, Protocol: "tcp"})
+	if d.Via != "relay-1" {
+		t.Fatalf("via = %q, want relay-1", d.Via)
+	}
+}
+
+func TestPolicyValidation(t *testing.T) {
+	for _, p := range []models.MeshPolicy{
+		{Name: "", Type: network.PolicyAllow},
+		{Name: "x", Type: "block"},
+		{Name: "x", Type: network.PolicyRateLimit},
+		{Name: "x", Type: network.PolicyRateLimit, Config: map[string]interface{}{"requests_per_second": 10, "burst": 5}},
+		{Name: "x", Type: network.PolicyReroute},
+		{Name: "x", Type: network.PolicyAllow, Config: map[string]interface{}{"ports": []interface{}{"90-80"}}},
+		{Name: "x", Type: network.PolicyAllow, Config: map[string]interface{}{"source_ips": []interface{}{"10.0.0.0/33"}}},
+		{Name: "x", Type: network.PolicyAllow, Config: map[string]interface{}{"protocol": "icmp"}},
+		{Name: "x", Type: network.PolicyDeny, Config: map[string]interface{}{"destination_node": []interface{}{"db"}}},
+	} {
+		if err := network.ValidatePolicy(p); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeInvalidInput {
+			t.Errorf("ValidatePolicy(%+v) error = %v, want invalid input", p, err)
+		}
+	}
+
+	cfg := samplePolicies()
+	cfg.Policies = append(cfg.Policies, models.MeshPolicy{Name: "allow-ssh", Type: network.PolicyAllow})
+	if _, err := network.NewPolicyEngine(cfg); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeInvalidInput {
+		t.Fatalf("duplicate names: error = %v", err)
+	}
+}
+
+func TestServicePolicies(t *testing.T) {
+	svc, _ := newTestService(t, "node-a", "node-b")
+	ctx := context.Background()
+
+	if err := svc.SetPolicy(ctx, models.MeshPolicy{Name: "no-http", Type: network.PolicyDeny, Priority: 5, Enabled: true,
+		Config: map[string]interface{}{"ports": []interface{}{80}}}); err != nil {
+		t.Fatalf("SetPolicy: %v", err)
+	}
+	if err := svc.SetSecurityLists(ctx, nil, []string{"192.0.2.2"}); err != nil {
+		t.Fatalf("SetSecurityLists: %v", err)
+	}
+
+	// Before the overlay exists the source is identified by its host address
+	d, err := svc.EvaluatePolicy(ctx, network.Traffic{SourceNode: "node-b", DestinationNode: "node-a", Port: 22})
+	if err != nil {
+		t.Fatalf("EvaluatePolicy: %v", err)
+	}
+	if d.Rule != "security.blocked_ips" {
+		t.Fatalf("node-b decision = %s by %s", d.Action, d.Rule)
+	}
+	d, _ = svc.EvaluatePolicy(ctx, network.Traffic{SourceNode: "node-a", DestinationNode: "node-b", Port: 80})
+	if d.Action != network.PolicyDeny || d.Rule != "no-http" {
+		t.Fatalf("node-a decision = %s by %s", d.Action, d.Rule)
+	}
+
+	// Policies survive enabling the mesh and can be removed
+	if _, err := svc.EnableMesh(ctx, nil); err != nil {
+		t.Fatalf("EnableMesh: %v", err)
+	}
+	if policies, _ := svc.Policies(ctx); len(policies) != 1 {
+		t.Fatalf("policies after EnableMesh = %v", policies)
+	}
+	if err := svc.RemovePolicy(ctx, "no-http"); err != nil {
+		t.Fatalf("RemovePolicy: %v", err)
+	}
+	if err := svc.RemovePolicy(ctx, "no-http"); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNotFound {
+		t.Fatalf("second RemovePolicy: error = %v", err)
+	}
+
+	if _, err := svc.EvaluatePolicy(ctx, network.Traffic{SourceNode: "node-a", DestinationNode: "node-x", Port: 80}); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNodeNotFound {
+		t.Fatalf("unknown destination: error = %v", err)
+	}
+}
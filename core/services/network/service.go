@@ -0,0 +1,705 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/types/constants"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Service manages routes and the service mesh, and turns them into
+// WireGuard configurations for the nodes
+type Service struct {
+	repo    Repository
+	keys    KeyProvider
+	nodes   Directory
+	applier Applier
+	log     Logger
+}
+
+// Repository defines the interface for network data access
+type Repository interface {
+	// CreateRoute fails with ErrCodeNetworkRouteExists when a route between
+	// the same source and destination exists
+	CreateRoute(ctx context.Context, r *models.NetworkRoute) error
+	GetRoute(ctx context.Context, id string) (*models.NetworkRoute, error)
+	ListRoutes(ctx context.Context, filter *RouteFilter) ([]*models.NetworkRoute, error)
+	UpdateRoute(ctx context.Context, r *models.NetworkRoute) error
+	DeleteRoute(ctx context.Context, id string) error
+
+	// GetMesh returns the named mesh, or nil when it was never configured
+	GetMesh(ctx context.Context, name string) (*models.ServiceMesh, error)
+	SaveMesh(ctx context.Context, m *models.ServiceMesh) error
+
+	ListPeers(ctx context.Context) ([]*Peer, error)
+	SavePeer(ctx context.Context, p *Peer) error
+	DeletePeer(ctx context.Context, nodeID string) error
+
+	ListAppliedConfigs(ctx context.Context) ([]*AppliedConfig, error)
+	SaveAppliedConfig(ctx context.Context, c *AppliedConfig) error
+	DeleteAppliedConfig(ctx context.Context, nodeID string) error
+}
+
+// KeyProvider supplies the nodes' WireGuard keys
+type KeyProvider interface {
+	// WireGuardKey returns the node's base64 private and public keys,
+	// creating them on first use
+	WireGuardKey(nodeID string) (privateKey, publicKey string, err error)
+}
+
+// Directory lists the nodes that can join the overlay
+type Directory interface {
+	Nodes(ctx context.Context) ([]Node, error)
+}
+
+// Applier installs WireGuard configurations on the nodes
+type Applier interface {
+	Apply(ctx context.Context, nodeID, iface, config string) error
+	Remove(ctx context.Context, nodeID, iface string) error
+}
+
+// Logger defines the interface for logging
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+	Debug(args ...interface{})
+	WithFields(fields map[string]interface{}) Logger
+}
+
+// Node is a grid node as seen by the network service
+type Node struct {
+	ID string `json:"id"`
+	// Host is the address other nodes reach this node on, without a port
+	Host string `json:"host"`
+	// Status is the node's last known status (online, offline, unknown)
+	Status string `json:"status,omitempty"`
+	// Latency is the last measured round trip to the node in ms, 0 when unknown
+	Latency int `json:"latency_ms,omitempty"`
+}
+
+// Peer is a node's membership in the overlay
+type Peer struct {
+	NodeID    string    `json:"node_id"`
+	Address   string    `json:"address"`
+	PublicKey string    `json:"public_key"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// AppliedConfig is the last configuration installed on a node, with its
+// private key redacted
+type AppliedConfig struct {
+	NodeID    string    `json:"node_id"`
+	Config    string    `json:"config"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// RouteFilter defines filtering options for route queries
+type RouteFilter struct {
+	// NodeID matches routes with the node at either end
+	NodeID string
+	Status string
+	Limit  int
+	Offset int
+}
+
+// RouteRequest represents a request to create a route
+type RouteRequest struct {
+	SourceNodeID      string                    `json:"source_node_id"`
+	DestinationNodeID string                    `json:"destination_node_id"`
+	Config            models.NetworkRouteConfig `json:"config"`
+}
+
+// PlanAction describes what applying a plan does on a node
+type PlanAction string
+
+const (
+	PlanCreate    PlanAction = "create"
+	PlanUpdate    PlanAction = "update"
+	PlanRemove    PlanAction = "remove"
+	PlanUnchanged PlanAction = "unchanged"
+)
+
+// NodePlan is the pending change for one node
+type NodePlan struct {
+	NodeID  string     `json:"node_id"`
+	Action  PlanAction `json:"action"`
+	Address string     `json:"address,omitempty"`
+	Peers   []string   `json:"peers,omitempty"`
+	// Diff compares the applied and the new config, see diffLines
+	Diff []string `json:"diff,omitempty"`
+	// Config is the new config with the private key redacted
+	Config string `json:"config,omitempty"`
+
+	full string
+	peer *Peer
+}
+
+// NewService creates a new network service
+func NewService(repo Repository, keys KeyProvider, nodes Directory, log Logger) *Service {
+	return &Service{
+		repo:  repo,
+		keys:  keys,
+		nodes: nodes,
+		log:   log,
+	}
+}
+
+// SetApplier sets how configurations reach the nodes; Apply fails without one
+func (s *Service) SetApplier(a Applier) {
+	s.applier = a
+}
+
+// CreateRoute creates a route between two nodes. Routes start inactive and
+// become active once applied to both nodes.
+func (s *Service) CreateRoute(ctx context.Context, req *RouteRequest) (*models.NetworkRoute, error) {
+	s.log.WithFields(map[string]interface{}{
+		"source":      req.SourceNodeID,
+		"destination": req.DestinationNodeID,
+	}).Info("Creating network route")
+
+	if req.SourceNodeID == "" || req.DestinationNodeID == "" {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid route",
+			"source and destination are required")
+	}
+	if req.SourceNodeID == req.DestinationNodeID {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid route",
+			"source and destination must be different nodes")
+	}
+
+	nodes, err := s.directory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range []string{req.SourceNodeID, req.DestinationNodeID} {
+		if _, ok := nodes[id]; !ok {
+			return nil, coreerrors.NewAPIError(coreerrors.ErrCodeNodeNotFound, "Node not found", id)
+		}
+	}
+
+	cfg := req.Config
+	if err := validateRouteConfig(&cfg); err != nil {
+		return nil, err
+	}
+	encoded, err := encode(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode route config: %w", err)
+	}
+
+	route := &models.NetworkRoute{
+		SourceNodeID:      req.SourceNodeID,
+		DestinationNodeID: req.DestinationNodeID,
+		Config:            encoded,
+		Status:            string(models.NetworkRouteStatusInactive),
+	}
+	if err := s.repo.CreateRoute(ctx, route); err != nil {
+		return nil, err
+	}
+	return route, nil
+}
+
+// GetRoute returns a route by ID
+func (s *Service) GetRoute(ctx context.Context, id string) (*models.NetworkRoute, error) {
+	return s.repo.GetRoute(ctx, id)
+}
+
+// ListRoutes returns routes matching the filter
+func (s *Service) ListRoutes(ctx context.Context, filter *RouteFilter) ([]*models.NetworkRoute, error) {
+	routes, err := s.repo.ListRoutes(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list routes: %w", err)
+	}
+	return routes, nil
+}
+
+// DeleteRoute removes a route. The nodes keep it until the next Apply.
+func (s *Service) DeleteRoute(ctx context.Context, id string) error {
+	s.log.WithFields(map[string]interface{}{"route_id": id}).Info("Deleting network route")
+	return s.repo.DeleteRoute(ctx, id)
+}
+
+// Mesh returns the service mesh and its configuration. A mesh that was
+// never enabled is reported as disabled with the default settings.
+func (s *Service) Mesh(ctx context.Context) (*models.ServiceMesh, *MeshConfig, error) {
+	mesh, err := s.repo.GetMesh(ctx, DefaultMeshName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load mesh: %w", err)
+	}
+	if mesh == nil {
+		mesh = &models.ServiceMesh{Name: DefaultMeshName, Status: string(models.ServiceMeshStatusDisabled)}
+	}
+	cfg, err := DecodeMeshConfig(mesh)
+	if err != nil {
+		return nil, nil, err
+	}
+	return mesh, cfg, nil
+}
+
+// EnableMesh connects every node with every other node. A nil cfg keeps
+// the current settings.
+func (s *Service) EnableMesh(ctx context.Context, cfg *MeshConfig) (*models.ServiceMesh, error) {
+	mesh, current, err := s.Mesh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		cfg = current
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	s.log.WithFields(map[string]interface{}{"subnet": cfg.Subnet}).Info("Enabling service mesh")
+	return s.saveMesh(ctx, mesh, cfg, models.ServiceMeshStatusEnabled)
+}
+
+// DisableMesh stops connecting every node pair; explicit routes remain
+func (s *Service) DisableMesh(ctx context.Context) (*models.ServiceMesh, error) {
+	mesh, cfg, err := s.Mesh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if mesh.Status != string(models.ServiceMeshStatusEnabled) {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeNetworkMeshDisabled, "Service mesh is already disabled")
+	}
+
+	s.log.Info("Disabling service mesh")
+	return s.saveMesh(ctx, mesh, cfg, models.ServiceMeshStatusDisabled)
+}
+
+// Peers returns the nodes currently on the overlay, ordered by node ID
+func (s *Service) Peers(ctx context.Context) ([]*Peer, error) {
+	peers, err := s.repo.ListPeers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list peers: %w", err)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].NodeID < peers[j].NodeID })
+	return peers, nil
+}
+
+func (s *Service) saveMesh(ctx context.Context, mesh *models.ServiceMesh, cfg *MeshConfig, status models.ServiceMeshStatus) (*models.ServiceMesh, error) {
+	encoded, err := encode(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode mesh config: %w", err)
+	}
+	mesh.Config = encoded
+	mesh.Status = string(status)
+	if err := s.repo.SaveMesh(ctx, mesh); err != nil {
+		return nil, fmt.Errorf("failed to save mesh: %w", err)
+	}
+	return mesh, nil
+}
+
+// Plan computes the WireGuard configuration of every node from the mesh
+// and the routes, and compares it with what was last applied. Nodes that
+// no longer take part in the overlay are planned for removal.
+//
+// With the mesh enabled every pair of nodes is connected; otherwise only
+// the pairs with a route. For each pair the highest priority route wins:
+// a route without encryption keeps the pair off the overlay, and a route
+// with ports only lets its source reach those ports on its destination.
+// Peers and filters are written highest priority first.
+func (s *Service) Plan(ctx context.Context) ([]*NodePlan, error) {
+	o, err := s.overlay(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg, nodes, pairs, members := o.cfg, o.nodes, o.pairs, o.members
+
+	peers, err := s.assignAddresses(ctx, cfg, members)
+	if err != nil {
+		return nil, err
+	}
+
+	applied, err := s.repo.ListAppliedConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied configs: %w", err)
+	}
+	previous := make(map[string]string, len(applied))
+	for _, a := range applied {
+		previous[a.NodeID] = a.Config
+	}
+
+	_, subnet, _ := net.ParseCIDR(cfg.Subnet)
+	prefix, _ := subnet.Mask.Size()
+
+	var plans []*NodePlan
+	for id := range members {
+		privateKey, _, err := s.keys.WireGuardKey(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load WireGuard key for %s: %w", id, err)
+		}
+
+		wg := &wgConfig{
+			NodeID:     id,
+			Address:    peers[id].Address,
+			PrefixLen:  prefix,
+			ListenPort: cfg.ListenPort,
+			PrivateKey: privateKey,
+			Keepalive:  cfg.Keepalive,
+		}
+		for key, p := range pairs {
+			other := key[0]
+			if other == id {
+				other = key[1]
+			} else if key[1] != id {
+				continue
+			}
+			endpoint := ""
+			if host := nodes[other].Host; host != "" {
+				endpoint = net.JoinHostPort(host, fmt.Sprintf("%d", cfg.ListenPort))
+			}
+			wg.Peers = append(wg.Peers, wgPeer{
+				NodeID:    other,
+				PublicKey: peers[other].PublicKey,
+				Endpoint:  endpoint,
+				Address:   peers[other].Address,
+				Priority:  p.priority,
+			})
+		}
+		sort.Slice(wg.Peers, func(i, j int) bool {
+			if wg.Peers[i].Priority != wg.Peers[j].Priority {
+				return wg.Peers[i].Priority > wg.Peers[j].Priority
+			}
+			return wg.Peers[i].NodeID < wg.Peers[j].NodeID
+		})
+
+		wg.Filters = nodeFilters(id, o.filters, pairs, peers)
+
+		plan := &NodePlan{
+			NodeID:  id,
+			Address: wg.Address,
+			Config:  wg.render(true),
+			full:    wg.render(false),
+			peer:    peers[id],
+		}
+		for _, p := range wg.Peers {
+			plan.Peers = append(plan.Peers, p.NodeID)
+		}
+		old, wasApplied := previous[id]
+		switch {
+		case !wasApplied:
+			plan.Action = PlanCreate
+		case old == plan.Config:
+			plan.Action = PlanUnchanged
+		default:
+			plan.Action = PlanUpdate
+		}
+		plan.Diff = diffLines(old, plan.Config)
+		plans = append(plans, plan)
+	}
+
+	for id, old := range previous {
+		if !members[id] {
+			plans = append(plans, &NodePlan{NodeID: id, Action: PlanRemove, Diff: diffLines(old, "")})
+		}
+	}
+
+	sort.Slice(plans, func(i, j int) bool { return plans[i].NodeID < plans[j].NodeID })
+	return plans, nil
+}
+
+// overlay is the effective set of connections between the nodes
+type overlay struct {
+	mesh    *models.ServiceMesh
+	cfg     *MeshConfig
+	nodes   map[string]Node
+	pairs   map[[2]string]*pairState
+	members map[string]bool
+	filters []routeFilter
+}
+
+// overlay works out which node pairs are connected, see Plan for the rules
+func (s *Service) overlay(ctx context.Context) (*overlay, error) {
+	mesh, cfg, err := s.Mesh(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := s.directory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	routes, err := s.allRoutes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	o := &overlay{
+		mesh:    mesh,
+		cfg:     cfg,
+		nodes:   nodes,
+		pairs:   make(map[[2]string]*pairState),
+		members: make(map[string]bool),
+	}
+	if mesh.Status == string(models.ServiceMeshStatusEnabled) {
+		for a := range nodes {
+			o.members[a] = true
+			for b := range nodes {
+				if a < b {
+					o.pairs[[2]string{a, b}] = &pairState{encrypted: true}
+				}
+			}
+		}
+	}
+
+	for _, r := range routes {
+		if _, ok := nodes[r.SourceNodeID]; !ok {
+			continue
+		}
+		if _, ok := nodes[r.DestinationNodeID]; !ok {
+			continue
+		}
+		rc, err := DecodeRouteConfig(r)
+		if err != nil {
+			return nil, err
+		}
+
+		key := pairKey(r.SourceNodeID, r.DestinationNodeID)
+		p, ok := o.pairs[key]
+		if !ok {
+			p = &pairState{}
+			o.pairs[key] = p
+		}
+		if p.route == nil || rc.Priority > p.priority {
+			p.route = r
+			p.priority = rc.Priority
+			p.encrypted = rc.Encryption
+		}
+		if len(rc.Ports) > 0 {
+			o.filters = append(o.filters, routeFilter{route: r, config: rc})
+		}
+	}
+	for key, p := range o.pairs {
+		if !p.encrypted {
+			delete(o.pairs, key)
+			continue
+		}
+		o.members[key[0]] = true
+		o.members[key[1]] = true
+	}
+	return o, nil
+}
+
+// Apply installs the changed configurations from plans, records them and
+// updates the route statuses. Nodes that fail are reported together;
+// the others are applied regardless.
+func (s *Service) Apply(ctx context.Context, plans []*NodePlan) error {
+	if s.applier == nil {
+		return fmt.Errorf("no applier configured for the network service")
+	}
+	_, cfg, err := s.Mesh(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := make(map[string]bool)
+	var failures []string
+	for _, plan := range plans {
+		if plan.Action == PlanUnchanged {
+			continue
+		}
+		logger := s.log.WithFields(map[string]interface{}{
+			"node_id": plan.NodeID,
+			"action":  plan.Action,
+		})
+		logger.Info("Applying network config")
+
+		if err := s.applyPlan(ctx, cfg, plan); err != nil {
+			logger.Error("Failed to apply network config: ", err)
+			failed[plan.NodeID] = true
+			failures = append(failures, fmt.Sprintf("%s: %v", plan.NodeID, err))
+		}
+	}
+
+	if err := s.updateRouteStatuses(ctx, failed); err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to apply network config on %d node(s): %s",
+			len(failures), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+func (s *Service) applyPlan(ctx context.Context, cfg *MeshConfig, plan *NodePlan) error {
+	if plan.Action == PlanRemove {
+		if err := s.applier.Remove(ctx, plan.NodeID, cfg.Interface); err != nil {
+			return err
+		}
+		if err := s.repo.DeleteAppliedConfig(ctx, plan.NodeID); err != nil {
+			return err
+		}
+		return s.repo.DeletePeer(ctx, plan.NodeID)
+	}
+
+	if err := s.applier.Apply(ctx, plan.NodeID, cfg.Interface, plan.full); err != nil {
+		return err
+	}
+	plan.peer.UpdatedAt = time.Now().UTC()
+	if err := s.repo.SavePeer(ctx, plan.peer); err != nil {
+		return err
+	}
+	return s.repo.SaveAppliedConfig(ctx, &AppliedConfig{
+		NodeID:    plan.NodeID,
+		Config:    plan.Config,
+		AppliedAt: time.Now().UTC(),
+	})
+}
+
+// updateRouteStatuses marks routes active, or error when one of their
+// nodes failed to apply or has left the grid
+func (s *Service) updateRouteStatuses(ctx context.Context, failed map[string]bool) error {
+	nodes, err := s.directory(ctx)
+	if err != nil {
+		return err
+	}
+	routes, err := s.allRoutes(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range routes {
+		status := models.NetworkRouteStatusActive
+		for _, id := range []string{r.SourceNodeID, r.DestinationNodeID} {
+			if _, ok := nodes[id]; !ok || failed[id] {
+				status = models.NetworkRouteStatusError
+			}
+		}
+		if r.Status == string(status) {
+			continue
+		}
+		r.Status = string(status)
+		if err := s.repo.UpdateRoute(ctx, r); err != nil {
+			return fmt.Errorf("failed to update route: %w", err)
+		}
+	}
+	return nil
+}
+
+// assignAddresses returns the peer of every member, keeping known
+// addresses that still fit the subnet and allocating the others
+func (s *Service) assignAddresses(ctx context.Context, cfg *MeshConfig, members map[string]bool) (map[string]*Peer, error) {
+	known, err := s.repo.ListPeers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list peers: %w", err)
+	}
+	var taken []string
+	byNode := make(map[string]*Peer, len(known))
+	for _, p := range known {
+		byNode[p.NodeID] = p
+		taken = append(taken, p.Address)
+	}
+
+	alloc, err := newAllocator(cfg.Subnet, taken)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(members))
+	for id := range members {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	peers := make(map[string]*Peer, len(ids))
+	for _, id := range ids {
+		_, publicKey, err := s.keys.WireGuardKey(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load WireGuard key for %s: %w", id, err)
+		}
+		p := &Peer{NodeID: id, PublicKey: publicKey}
+		if existing, ok := byNode[id]; ok && alloc.contains(existing.Address) {
+			p.Address = existing.Address
+		} else if p.Address, err = alloc.next(); err != nil {
+			return nil, err
+		}
+		peers[id] = p
+	}
+	return peers, nil
+}
+
+// directory returns the known nodes by ID
+func (s *Service) directory(ctx context.Context) (map[string]Node, error) {
+	list, err := s.nodes.Nodes(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	nodes := make(map[string]Node, len(list))
+	for _, n := range list {
+		nodes[n.ID] = n
+	}
+	return nodes, nil
+}
+
+// allRoutes pages through every stored route
+func (s *Service) allRoutes(ctx context.Context) ([]*models.NetworkRoute, error) {
+	var routes []*models.NetworkRoute
+	for offset := 0; ; offset += constants.MaxPageSize {
+		page, err := s.repo.ListRoutes(ctx, &RouteFilter{Limit: constants.MaxPageSize, Offset: offset})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list routes: %w", err)
+		}
+		routes = append(routes, page...)
+		if len(page) < constants.MaxPageSize {
+			return routes, nil
+		}
+	}
+}
+
+// pairState is the effective connection between two nodes
+type pairState struct {
+	// route is the winning route, nil when the pair only comes from the mesh
+	route     *models.NetworkRoute
+	priority  int
+	encrypted bool
+}
+
+// routeFilter is a route that restricts ports
+type routeFilter struct {
+	route  *models.NetworkRoute
+	config *models.NetworkRouteConfig
+}
+
+// nodeFilters returns the port filters enforced on a node for routes that
+// end there, highest priority first
+func nodeFilters(nodeID string, filters []routeFilter, pairs map[[2]string]*pairState, peers map[string]*Peer) []portFilter {
+	var selected []routeFilter
+	for _, f := range filters {
+		if f.route.DestinationNodeID != nodeID {
+			continue
+		}
+		if _, ok := pairs[pairKey(f.route.SourceNodeID, nodeID)]; !ok {
+			continue
+		}
+		selected = append(selected, f)
+	}
+	sort.Slice(selected, func(i, j int) bool {
+		if selected[i].config.Priority != selected[j].config.Priority {
+			return selected[i].config.Priority > selected[j].config.Priority
+		}
+		return selected[i].route.SourceNodeID < selected[j].route.SourceNodeID
+	})
+
+	result := make([]portFilter, 0, len(selected))
+	for _, f := range selected {
+		result = append(result, portFilter{
+			Source:   peers[f.route.SourceNodeID].Address,
+			Protocol: f.config.Protocol,
+			Ports:    f.config.Ports,
+		})
+	}
+	return result
+}
+
+// pairKey orders two node IDs so both directions share a key
+func pairKey(a, b string) [2]string {
+	if a > b {
+		a, b = b, a
+	}
+	return [2]string{a, b}
+}
@@ -0,0 +1,279 @@
+package network_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/services/network"
+	"syntropy-cc/cooperative-grid/core/storage"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(args ...interface{})                                  {}
+func (nopLogger) Error(args ...interface{})                                 {}
+func (nopLogger) Debug(args ...interface{})                                 {}
+func (l nopLogger) WithFields(fields map[string]interface{}) network.Logger { return l }
+
+// fakeKeys derives readable keys from the node ID
+type fakeKeys struct{}
+
+func (fakeKeys) WireGuardKey(nodeID string) (string, string, error) {
+	return "priv-" + nodeID, "pub-" + nodeID, nil
+}
+
+type directory []network.Node
+
+func (d directory) Nodes(ctx context.Context) ([]network.Node, error) { return d, nil }
+
+// recordingApplier keeps the configs applied to each node
+type recordingApplier struct {
+	configs map[string]string
+	failOn  string
+}
+
+func (a *recordingApplier) Apply(ctx context.Context, nodeID, iface, config string) error {
+	if nodeID == a.failOn {
+		return errors.New("ssh: connection refused")
+	}
+	a.configs[nodeID] = config
+	return nil
+}
+
+func (a *recordingApplier) Remove(ctx context.Context, nodeID, iface string) error {
+	delete(a.configs, nodeID)
+	return nil
+}
+
+func newTestService(t *testing.T, nodes ...string) (*network.Service, *recordingApplier) {
+	t.Helper()
+	store, err := storage.Open(filepath.Join(t.TempDir(), storage.DefaultFileName))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	var dir directory
+	for i, n := range nodes {
+		dir = append(dir, network.Node{ID: n, Host: "192.0.2." + string(rune('1'+i))})
+	}
+	applier := &recordingApplier{configs: map[string]string{}}
+	svc := network.NewService(storage.NewNetworkRepository(store), fakeKeys{}, dir, nopLogger{})
+	svc.SetApplier(applier)
+	return svc, applier
+}
+
+func planFor(plans []*network.NodePlan, nodeID string) *network.NodePlan {
+	for _, p := range plans {
+		if p.NodeID == nodeID {
+			return p
+		}
+	}
+	return nil
+}
+
+func TestRouteWithPortsFiltersOnDestination(t *testing.T) {
+	svc, applier := newTestService(t, "node-a", "node-b", "node-c")
+	ctx := context.Background()
+
+	route, err := svc.CreateRoute(ctx, &network.RouteRequest{
+		SourceNodeID: "node-a", DestinationNodeID: "node-b",
+		Config: models.NetworkRouteConfig{Priority: 10, Encryption: true, Ports: []int{80, 443}},
+	})
+	if err != nil {
+		t.Fatalf("CreateRoute: %v", err)
+	}
+	if route.Status != "inactive" {
+		t.Fatalf("new route status = %s, want inactive", route.Status)
+	}
+
+	plans, err := svc.Plan(ctx)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(plans) != 2 || planFor(plans, "node-c") != nil {
+		t.Fatalf("only the routed nodes should be planned, got %d plans", len(plans))
+	}
+	a, b := planFor(plans, "node-a"), planFor(plans, "node-b")
+	if a.Action != network.PlanCreate || a.Address != "172.20.0.1" || b.Address != "172.20.0.2" {
+		t.Fatalf("unexpected plans: %+v %+v", a, b)
+	}
+	for _, want := range []string{
+		"PublicKey = pub-node-a",
+		"Endpoint = 192.0.2.1:51820",
+		"PostUp = iptables -A INPUT -i %i -s 172.20.0.1 -p tcp --dport 443 -j ACCEPT",
+		"PostUp = iptables -A INPUT -i %i -s 172.20.0.1 -j DROP",
+		"PrivateKey = (hidden)",
+	} {
+		if !strings.Contains(b.Config, want) {
+			t.Errorf("node-b config is missing %q:\n%s", want, b.Config)
+		}
+	}
+	if strings.Contains(a.Config, "iptables") {
+		t.Errorf("the source should not filter:\n%s", a.Config)
+	}
+
+	if err := svc.Apply(ctx, plans); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if !strings.Contains(applier.configs["node-a"], "PrivateKey = priv-node-a") {
+		t.Fatalf("applied config should carry the private key:\n%s", applier.configs["node-a"])
+	}
+	route, _ = svc.GetRoute(ctx, route.ID)
+	if route.Status != "active" {
+		t.Fatalf("applied route status = %s, want active", route.Status)
+	}
+
+	plans, _ = svc.Plan(ctx)
+	for _, p := range plans {
+		if p.Action != network.PlanUnchanged {
+			t.Errorf("%s should be unchanged after apply, got %s", p.NodeID, p.Action)
+		}
+	}
+}
+
+func TestMeshConnectsAllPairsExceptUnencryptedRoutes(t *testing.T) {
+	svc, _ := newTestService(t, "node-a", "node-b", "node-c")
+	ctx := context.Background()
+
+	if _, err := svc.EnableMesh(ctx, nil); err != nil {
+		t.Fatalf("EnableMesh: %v", err)
+	}
+	if _, err := svc.CreateRoute(ctx, &network.RouteRequest{
+		SourceNodeID: "node-c", DestinationNodeID: "node-a",
+		Config: models.NetworkRouteConfig{Encryption: false},
+	}); err != nil {
+		t.Fatalf("CreateRoute: %v", err)
+	}
+
+	plans, err := svc.Plan(ctx)
+	if err != nil {
+		t.Fatalf("Plan: %v", err)
+	}
+	if len(plans) != 3 {
+		t.Fatalf("got %d plans, want 3", len(plans))
+	}
+	if peers := planFor(plans, "node-a").Peers; len(peers) != 1 || peers[0] != "node-b" {
+		t.Fatalf("node-a peers = %v, want [node-b]", peers)
+	}
+	if peers := planFor(plans, "node-b").Peers; len(peers) != 2 {
+		t.Fatalf("node-b peers = %v, want both others", peers)
+	}
+}
+
+func TestDiffAndRemovalAfterMeshDisabled(t *testing.T) {
+	svc, applier := newTestService(t, "node-a", "node-b")
+	ctx := context.Background()
+
+	if _, err := svc.EnableMesh(ctx, nil); err != nil {
+		t.Fatalf("EnableMesh: %v", err)
+	}
+	plans, _ := svc.Plan(ctx)
+	if err := svc.Apply(ctx, plans); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	// A port restriction only changes the destination
+	if _, err := svc.CreateRoute(ctx, &network.RouteRequest{
+		SourceNodeID: "node-a", DestinationNodeID: "node-b",
+		Config: models.NetworkRouteConfig{Encryption: true, Ports: []int{22}},
+	}); err != nil {
+		t.Fatalf("CreateRoute: %v", err)
+	}
+	plans, _ = svc.Plan(ctx)
+	if planFor(plans, "node-a").Action != network.PlanUnchanged || planFor(plans, "node-b").Action != network.PlanUpdate {
+		t.Fatalf("unexpected actions: %s, %s", planFor(plans, "node-a").Action, planFor(plans, "node-b").Action)
+	}
+	var added []string
+	for _, line := range planFor(plans, "node-b").Diff {
+		if strings.HasPrefix(line, "+ ") {
+			added = append(added, line)
+		}
+	}
+	if len(added) != 4 || !strings.Contains(added[0], "--dport 22 -j ACCEPT") {
+		t.Fatalf("unexpected added lines: %v", added)
+	}
+
+	// Without the mesh and the route, both nodes leave the overlay
+	routes, _ := svc.ListRoutes(ctx, nil)
+	svc.DeleteRoute(ctx, routes[0].ID)
+	if _, err := svc.DisableMesh(ctx); err != nil {
+		t.Fatalf("DisableMesh: %v", err)
+	}
+	plans, _ = svc.Plan(ctx)
+	if len(plans) != 2 || plans[0].Action != network.PlanRemove || !strings.HasPrefix(plans[0].Diff[0], "- ") {
+		t.Fatalf("unexpected removal plans: %+v", plans)
+	}
+	if err := svc.Apply(ctx, plans); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if len(applier.configs) != 0 {
+		t.Fatalf("configs left on nodes: %v", applier.configs)
+	}
+
+	if _, err := svc.DisableMesh(ctx); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNetworkMeshDisabled {
+		t.Fatalf("second DisableMesh: error = %v", err)
+	}
+}
+
+func TestApplyFailureMarksRoutesError(t *testing.T) {
+	svc, applier := newTestService(t, "node-a", "node-b")
+	ctx := context.Background()
+	applier.failOn = "node-b"
+
+	route, err := svc.CreateRoute(ctx, &network.RouteRequest{
+		SourceNodeID: "node-a", DestinationNodeID: "node-b",
+		Config: models.NetworkRouteConfig{Encryption: true},
+	})
+	if err != nil {
+		t.Fatalf("CreateRoute: %v", err)
+	}
+	plans, _ := svc.Plan(ctx)
+	if err := svc.Apply(ctx, plans); err == nil || !strings.Contains(err.Error(), "connection refused") {
+		t.Fatalf("Apply error = %v", err)
+	}
+	route, _ = svc.GetRoute(ctx, route.ID)
+	if route.Status != "error" {
+		t.Fatalf("route status = %s, want error", route.Status)
+	}
+
+	// node-a was applied and stays so; node-b is still pending
+	plans, _ = svc.Plan(ctx)
+	if planFor(plans, "node-a").Action != network.PlanUnchanged || planFor(plans, "node-b").Action != network.PlanCreate {
+		t.Fatalf("unexpected actions after partial apply")
+	}
+}
+
+func TestRouteValidation(t *testing.T) {
+	svc, _ := newTestService(t, "node-a", "node-b")
+	ctx := context.Background()
+
+	req := &network.RouteRequest{SourceNodeID: "node-a", DestinationNodeID: "node-b", Config: models.NetworkRouteConfig{Encryption: true}}
+	if _, err := svc.CreateRoute(ctx, req); err != nil {
+		t.Fatalf("CreateRoute: %v", err)
+	}
+
+	for _, tc := range []struct {
+		req  *network.RouteRequest
+		code coreerrors.ErrorCode
+	}{
+		{req, coreerrors.ErrCodeNetworkRouteExists},
+		{&network.RouteRequest{SourceNodeID: "node-a", DestinationNodeID: "node-x"}, coreerrors.ErrCodeNodeNotFound},
+		{&network.RouteRequest{SourceNodeID: "node-a", DestinationNodeID: "node-a"}, coreerrors.ErrCodeInvalidInput},
+		{&network.RouteRequest{SourceNodeID: "node-b", DestinationNodeID: "node-a",
+			Config: models.NetworkRouteConfig{Ports: []int{80}}}, coreerrors.ErrCodeInvalidInput},
+	} {
+		if _, err := svc.CreateRoute(ctx, tc.req); coreerrors.GetErrorCode(err) != tc.code {
+			t.Errorf("CreateRoute(%s -> %s) error = %v, want %s", tc.req.SourceNodeID, tc.req.DestinationNodeID, err, tc.code)
+		}
+	}
+
+	if _, err := svc.EnableMesh(ctx, &network.MeshConfig{Subnet: "10.0.0.0/31", ListenPort: 51820, Interface: "wg0"}); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeInvalidInput {
+		t.Fatalf("tiny subnet: error = %v", err)
+	}
+}
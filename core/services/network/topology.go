@@ -0,0 +1,516 @@
+package network
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Heuristics used when no better measurement exists. Latencies are measured
+// from this machine, so a link is estimated as the sum of both ends.
+const (
+	// DefaultNodeLatency stands in for nodes that were never measured, in ms
+	DefaultNodeLatency = 50
+
+	availabilityOnline  = 0.99
+	availabilityUnknown = 0.9
+	availabilityOffline = 0.5
+
+	reliabilityActive  = 0.99
+	reliabilityPending = 0.9
+	reliabilityError   = 0.5
+)
+
+// LinkStatus is the state of a connection between two nodes
+type LinkStatus string
+
+const (
+	// LinkActive links are configured on both nodes
+	LinkActive LinkStatus = "active"
+	// LinkPending links exist in the plan but were not applied yet
+	LinkPending LinkStatus = "pending"
+	// LinkError links failed to apply on one of the nodes
+	LinkError LinkStatus = "error"
+)
+
+// TopologyNode is a vertex of the topology
+type TopologyNode struct {
+	ID      string `json:"id"`
+	Host    string `json:"host,omitempty"`
+	Address string `json:"address,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Latency int    `json:"latency_ms"`
+	// Availability is the estimated probability that the node is up
+	Availability float64 `json:"availability"`
+}
+
+// Link is an undirected overlay connection between two nodes
+type Link struct {
+	A string `json:"a"`
+	B string `json:"b"`
+	// RouteID is the route that created the link, empty for mesh links
+	RouteID  string     `json:"route_id,omitempty"`
+	Priority int        `json:"priority,omitempty"`
+	Status   LinkStatus `json:"status"`
+	// Latency is the estimated round trip across the link in ms
+	Latency int `json:"latency_ms"`
+	// Reliability is the estimated probability that the link works
+	Reliability float64 `json:"reliability"`
+}
+
+// Topology is the graph of nodes and overlay links
+type Topology struct {
+	Nodes []TopologyNode `json:"nodes"`
+	Links []Link         `json:"links"`
+
+	index map[string]int
+	adj   [][]int
+}
+
+// Path is a sequence of nodes from a source to a destination
+type Path struct {
+	Nodes   []string `json:"nodes"`
+	Latency int      `json:"latency_ms"`
+	// Reliability includes every node and link on the path
+	Reliability float64 `json:"reliability"`
+}
+
+// Analysis summarizes the weak spots of a topology
+type Analysis struct {
+	// Partitions are the groups of nodes that can reach each other, largest first
+	Partitions [][]string `json:"partitions"`
+	// CriticalNodes split their partition when they go down
+	CriticalNodes []string `json:"critical_nodes"`
+	// CriticalLinks split their partition when they fail
+	CriticalLinks [][2]string `json:"critical_links"`
+	// Transit counts, for each node, the node pairs whose shortest path
+	// crosses it; the busiest nodes are the natural relays
+	Transit map[string]int `json:"transit"`
+}
+
+// NewTopology builds a topology, sorting nodes and links by ID. Links to
+// unknown nodes are dropped.
+func NewTopology(nodes []TopologyNode, links []Link) *Topology {
+	t := &Topology{
+		Nodes: append([]TopologyNode(nil), nodes...),
+		index: make(map[string]int, len(nodes)),
+	}
+	sort.Slice(t.Nodes, func(i, j int) bool { return t.Nodes[i].ID < t.Nodes[j].ID })
+	for i, n := range t.Nodes {
+		t.index[n.ID] = i
+	}
+
+	for _, l := range links {
+		_, okA := t.index[l.A]
+		_, okB := t.index[l.B]
+		if !okA || !okB || l.A == l.B {
+			continue
+		}
+		if l.A > l.B {
+			l.A, l.B = l.B, l.A
+		}
+		t.Links = append(t.Links, l)
+	}
+	sort.Slice(t.Links, func(i, j int) bool {
+		if t.Links[i].A != t.Links[j].A {
+			return t.Links[i].A < t.Links[j].A
+		}
+		return t.Links[i].B < t.Links[j].B
+	})
+
+	t.adj = make([][]int, len(t.Nodes))
+	for i, l := range t.Links {
+		a, b := t.index[l.A], t.index[l.B]
+		t.adj[a] = append(t.adj[a], i)
+		t.adj[b] = append(t.adj[b], i)
+	}
+	return t
+}
+
+// Topology builds the current overlay graph: the connected pairs from the
+// mesh and the routes, with each node's measured latency and status
+func (s *Service) Topology(ctx context.Context) (*Topology, error) {
+	o, err := s.overlay(ctx)
+	if err != nil {
+		return nil, err
+	}
+	peers, err := s.Peers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := s.repo.ListAppliedConfigs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applied configs: %w", err)
+	}
+	addresses := make(map[string]string, len(peers))
+	for _, p := range peers {
+		addresses[p.NodeID] = p.Address
+	}
+	configured := make(map[string]bool, len(applied))
+	for _, a := range applied {
+		configured[a.NodeID] = true
+	}
+
+	nodes := make([]TopologyNode, 0, len(o.nodes))
+	for _, n := range o.nodes {
+		nodes = append(nodes, TopologyNode{
+			ID:           n.ID,
+			Host:         n.Host,
+			Address:      addresses[n.ID],
+			Status:       n.Status,
+			Latency:      n.Latency,
+			Availability: availability(n.Status),
+		})
+	}
+
+	links := make([]Link, 0, len(o.pairs))
+	for key, p := range o.pairs {
+		l := Link{A: key[0], B: key[1], Priority: p.priority, Latency: linkLatency(o.nodes[key[0]], o.nodes[key[1]])}
+		switch {
+		case p.route != nil && p.route.Status == string(models.NetworkRouteStatusError):
+			l.Status = LinkError
+		case configured[key[0]] && configured[key[1]]:
+			l.Status = LinkActive
+		default:
+			l.Status = LinkPending
+		}
+		if p.route != nil {
+			l.RouteID = p.route.ID
+		}
+		l.Reliability = linkReliability(l.Status)
+		links = append(links, l)
+	}
+	return NewTopology(nodes, links), nil
+}
+
+// ShortestPath returns the path with the lowest total latency
+func (t *Topology) ShortestPath(from, to string) (*Path, error) {
+	return t.path(from, to, func(l Link, next TopologyNode) float64 {
+		return float64(l.Latency)
+	})
+}
+
+// MostReliablePath returns the path most likely to work, taking both the
+// links and the nodes it crosses into account
+func (t *Topology) MostReliablePath(from, to string) (*Path, error) {
+	return t.path(from, to, func(l Link, next TopologyNode) float64 {
+		return -math.Log(l.Reliability) - math.Log(next.Availability)
+	})
+}
+
+// path runs Dijkstra with the given edge cost, which must not be negative
+func (t *Topology) path(from, to string, cost func(l Link, next TopologyNode) float64) (*Path, error) {
+	src, ok := t.index[from]
+	if !ok {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeNodeNotFound, "Node not found", from)
+	}
+	dst, ok := t.index[to]
+	if !ok {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeNodeNotFound, "Node not found", to)
+	}
+
+	prev := t.dijkstra(src, cost)
+	if src != dst && prev[dst] < 0 {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeNetworkRouteNotFound, "No path between nodes",
+			fmt.Sprintf("%s and %s are in different partitions", from, to))
+	}
+
+	var order []int
+	for n := dst; n != src; n = t.other(prev[n], n) {
+		order = append(order, n)
+	}
+	order = append(order, src)
+
+	p := &Path{Reliability: t.Nodes[src].Availability}
+	for i := len(order) - 1; i >= 0; i-- {
+		n := order[i]
+		p.Nodes = append(p.Nodes, t.Nodes[n].ID)
+		if n != src {
+			l := t.Links[prev[n]]
+			p.Latency += l.Latency
+			p.Reliability *= l.Reliability * t.Nodes[n].Availability
+		}
+	}
+	return p, nil
+}
+
+// dijkstra returns, for each node, the link it is reached through on the
+// cheapest path from src, or -1 when it is unreachable
+func (t *Topology) dijkstra(src int, cost func(l Link, next TopologyNode) float64) []int {
+	dist := make([]float64, len(t.Nodes))
+	prev := make([]int, len(t.Nodes))
+	for i := range dist {
+		dist[i] = math.Inf(1)
+		prev[i] = -1
+	}
+	dist[src] = 0
+
+	queue := &distQueue{{node: src}}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(distItem)
+		if item.dist > dist[item.node] {
+			continue
+		}
+		for _, li := range t.adj[item.node] {
+			next := t.other(li, item.node)
+			d := item.dist + cost(t.Links[li], t.Nodes[next])
+			// Ties go to the lower link index so results are deterministic
+			if d < dist[next] || (d == dist[next] && prev[next] > li) {
+				dist[next] = d
+				prev[next] = li
+				heap.Push(queue, distItem{node: next, dist: d})
+			}
+		}
+	}
+	return prev
+}
+
+// Analyze finds the partitions, the single points of failure and how much
+// traffic each node would relay on shortest paths
+func (t *Topology) Analyze() *Analysis {
+	a := &Analysis{
+		Partitions:    t.partitions(),
+		CriticalNodes: []string{},
+		CriticalLinks: [][2]string{},
+		Transit:       make(map[string]int, len(t.Nodes)),
+	}
+
+	cut := newCutFinder(t)
+	for i, isCut := range cut.nodes {
+		if isCut {
+			a.CriticalNodes = append(a.CriticalNodes, t.Nodes[i].ID)
+		}
+	}
+	for _, li := range cut.links {
+		a.CriticalLinks = append(a.CriticalLinks, [2]string{t.Links[li].A, t.Links[li].B})
+	}
+	sort.Slice(a.CriticalLinks, func(i, j int) bool {
+		if a.CriticalLinks[i][0] != a.CriticalLinks[j][0] {
+			return a.CriticalLinks[i][0] < a.CriticalLinks[j][0]
+		}
+		return a.CriticalLinks[i][1] < a.CriticalLinks[j][1]
+	})
+
+	latency := func(l Link, next TopologyNode) float64 { return float64(l.Latency) }
+	for _, n := range t.Nodes {
+		a.Transit[n.ID] = 0
+	}
+	for src := range t.Nodes {
+		prev := t.dijkstra(src, latency)
+		for dst := src + 1; dst < len(t.Nodes); dst++ {
+			if prev[dst] < 0 {
+				continue
+			}
+			for n := t.other(prev[dst], dst); n != src; n = t.other(prev[n], n) {
+				a.Transit[t.Nodes[n].ID]++
+			}
+		}
+	}
+	return a
+}
+
+// partitions returns the connected components, largest first
+func (t *Topology) partitions() [][]string {
+	seen := make([]bool, len(t.Nodes))
+	var result [][]string
+	for start := range t.Nodes {
+		if seen[start] {
+			continue
+		}
+		var group []string
+		stack := []int{start}
+		seen[start] = true
+		for len(stack) > 0 {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			group = append(group, t.Nodes[n].ID)
+			for _, li := range t.adj[n] {
+				if next := t.other(li, n); !seen[next] {
+					seen[next] = true
+					stack = append(stack, next)
+				}
+			}
+		}
+		sort.Strings(group)
+		result = append(result, group)
+	}
+	sort.SliceStable(result, func(i, j int) bool { return len(result[i]) > len(result[j]) })
+	return result
+}
+
+// WriteDOT renders the topology in Graphviz DOT. Critical nodes are filled,
+// critical links drawn bold, and links that are not active are dashed.
+func (t *Topology) WriteDOT(w io.Writer) error {
+	a := t.Analyze()
+	critical := make(map[string]bool, len(a.CriticalNodes))
+	for _, id := range a.CriticalNodes {
+		critical[id] = true
+	}
+	bridges := make(map[[2]string]bool, len(a.CriticalLinks))
+	for _, l := range a.CriticalLinks {
+		bridges[l] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("graph syntropy {\n")
+	b.WriteString("  node [shape=box, style=rounded];\n")
+	for _, n := range t.Nodes {
+		label := n.ID
+		if n.Address != "" {
+			label += `\n` + n.Address
+		}
+		attrs := []string{fmt.Sprintf("label=%q", label)}
+		if critical[n.ID] {
+			attrs = append(attrs, `style="rounded,filled"`, `fillcolor="orange"`)
+		}
+		if n.Availability <= availabilityOffline {
+			attrs = append(attrs, `color="red"`)
+		}
+		fmt.Fprintf(&b, "  %q [%s];\n", n.ID, strings.Join(attrs, ", "))
+	}
+	for _, l := range t.Links {
+		attrs := []string{fmt.Sprintf(`label="%dms %.0f%%"`, l.Latency, l.Reliability*100)}
+		if bridges[[2]string{l.A, l.B}] {
+			attrs = append(attrs, "penwidth=2")
+		}
+		switch l.Status {
+		case LinkPending:
+			attrs = append(attrs, "style=dashed")
+		case LinkError:
+			attrs = append(attrs, "style=dashed", `color="red"`)
+		}
+		fmt.Fprintf(&b, "  %q -- %q [%s];\n", l.A, l.B, strings.Join(attrs, ", "))
+	}
+	b.WriteString("}\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// other returns the end of link li that is not node n
+func (t *Topology) other(li, n int) int {
+	l := t.Links[li]
+	if t.index[l.A] == n {
+		return t.index[l.B]
+	}
+	return t.index[l.A]
+}
+
+// cutFinder finds articulation points and bridges with Tarjan's algorithm
+type cutFinder struct {
+	t     *Topology
+	timer int
+	disc  []int
+	low   []int
+	nodes []bool
+	links []int
+}
+
+func newCutFinder(t *Topology) *cutFinder {
+	c := &cutFinder{
+		t:     t,
+		disc:  make([]int, len(t.Nodes)),
+		low:   make([]int, len(t.Nodes)),
+		nodes: make([]bool, len(t.Nodes)),
+	}
+	for n := range t.Nodes {
+		if c.disc[n] == 0 {
+			c.visit(n, -1)
+		}
+	}
+	return c
+}
+
+func (c *cutFinder) visit(n, parentLink int) {
+	c.timer++
+	c.disc[n] = c.timer
+	c.low[n] = c.timer
+
+	children := 0
+	for _, li := range c.t.adj[n] {
+		if li == parentLink {
+			continue
+		}
+		next := c.t.other(li, n)
+		if c.disc[next] != 0 {
+			c.low[n] = min(c.low[n], c.disc[next])
+			continue
+		}
+		children++
+		c.visit(next, li)
+		c.low[n] = min(c.low[n], c.low[next])
+
+		if c.low[next] > c.disc[n] {
+			c.links = append(c.links, li)
+		}
+		if parentLink >= 0 && c.low[next] >= c.disc[n] {
+			c.nodes[n] = true
+		}
+	}
+	if parentLink < 0 && children > 1 {
+		c.nodes[n] = true
+	}
+}
+
+// availability estimates how likely a node is to be up from its status
+func availability(status string) float64 {
+	switch strings.ToLower(status) {
+	case "online", "running", "active":
+		return availabilityOnline
+	case "offline", "error", "stopped":
+		return availabilityOffline
+	default:
+		return availabilityUnknown
+	}
+}
+
+// linkReliability estimates how likely a link is to work from its status
+func linkReliability(status LinkStatus) float64 {
+	switch status {
+	case LinkActive:
+		return reliabilityActive
+	case LinkError:
+		return reliabilityError
+	default:
+		return reliabilityPending
+	}
+}
+
+// linkLatency estimates the latency between two nodes from their measured
+// latencies to this machine
+func linkLatency(a, b Node) int {
+	la, lb := a.Latency, b.Latency
+	if la <= 0 {
+		la = DefaultNodeLatency
+	}
+	if lb <= 0 {
+		lb = DefaultNodeLatency
+	}
+	return la + lb
+}
+
+type distItem struct {
+	node int
+	dist float64
+}
+
+// distQueue is a min-heap of tentative distances for dijkstra
+type distQueue []distItem
+
+func (q distQueue) Len() int            { return len(q) }
+func (q distQueue) Less(i, j int) bool  { return q[i].dist < q[j].dist }
+func (q distQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *distQueue) Push(x interface{}) { *q = append(*q, x.(distItem)) }
+func (q *distQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,142 @@
+package network_test
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/services/network"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+func topoNode(id string) network.TopologyNode {
+	return network.TopologyNode{ID: id, Availability: 0.99}
+}
+
+func topoLink(a, b string, latency int, reliability float64) network.Link {
+	return network.Link{A: a, B: b, Status: network.LinkActive, Latency: latency, Reliability: reliability}
+}
+
+// Two sites joined through hub: a-b-hub triangle, hub-c-d chain, and an
+// isolated node e
+func sampleTopology() *network.Topology {
+	return network.NewTopology(
+		[]network.TopologyNode{topoNode("a"), topoNode("b"), topoNode("hub"), topoNode("c"), topoNode("d"), topoNode("e")},
+		[]network.Link{
+			topoLink("a", "b", 10, 0.5),
+			topoLink("a", "hub", 40, 0.99),
+			topoLink("b", "hub", 10, 0.99),
+			topoLink("hub", "c", 5, 0.99),
+			topoLink("c", "d", 5, 0.99),
+			topoLink("d", "unknown", 1, 1),
+		},
+	)
+}
+
+func TestShortestAndMostReliablePaths(t *testing.T) {
+	topo := sampleTopology()
+
+	shortest, err := topo.ShortestPath("a", "d")
+	if err != nil {
+		t.Fatalf("ShortestPath: %v", err)
+	}
+	if want := []string{"a", "b", "hub", "c", "d"}; !reflect.DeepEqual(shortest.Nodes, want) || shortest.Latency != 30 {
+		t.Fatalf("shortest = %v (%dms), want %v (30ms)", shortest.Nodes, shortest.Latency, want)
+	}
+
+	reliable, err := topo.MostReliablePath("a", "d")
+	if err != nil {
+		t.Fatalf("MostReliablePath: %v", err)
+	}
+	if want := []string{"a", "hub", "c", "d"}; !reflect.DeepEqual(reliable.Nodes, want) || reliable.Latency != 50 {
+		t.Fatalf("most reliable = %v (%dms), want %v (50ms)", reliable.Nodes, reliable.Latency, want)
+	}
+	if want := math.Pow(0.99, 7); math.Abs(reliable.Reliability-want) > 1e-9 {
+		t.Fatalf("reliability = %f, want %f", reliable.Reliability, want)
+	}
+
+	if _, err := topo.ShortestPath("a", "e"); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNetworkRouteNotFound {
+		t.Fatalf("path to an isolated node: error = %v", err)
+	}
+	if _, err := topo.ShortestPath("a", "zz"); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNodeNotFound {
+		t.Fatalf("path to an unknown node: error = %v", err)
+	}
+}
+
+func TestAnalyzeFindsPartitionsAndSinglePointsOfFailure(t *testing.T) {
+	a := sampleTopology().Analyze()
+
+	if want := [][]string{{"a", "b", "c", "d", "hub"}, {"e"}}; !reflect.DeepEqual(a.Partitions, want) {
+		t.Fatalf("partitions = %v, want %v", a.Partitions, want)
+	}
+	if want := []string{"c", "hub"}; !reflect.DeepEqual(a.CriticalNodes, want) {
+		t.Fatalf("critical nodes = %v, want %v", a.CriticalNodes, want)
+	}
+	if want := [][2]string{{"c", "d"}, {"c", "hub"}}; !reflect.DeepEqual(a.CriticalLinks, want) {
+		t.Fatalf("critical links = %v, want %v", a.CriticalLinks, want)
+	}
+	// hub relays a and b to c and d; c relays everyone to d; b relays a to the rest
+	if a.Transit["hub"] != 4 || a.Transit["c"] != 3 || a.Transit["b"] != 3 || a.Transit["a"] != 0 {
+		t.Fatalf("transit = %v", a.Transit)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var buf bytes.Buffer
+	if err := sampleTopology().WriteDOT(&buf); err != nil {
+		t.Fatalf("WriteDOT: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"graph syntropy {",
+		`"hub" [label="hub", style="rounded,filled", fillcolor="orange"];`,
+		`"e" [label="e"];`,
+		`"c" -- "hub" [label="5ms 99%", penwidth=2];`,
+		`"a" -- "b" [label="10ms 50%"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("DOT output is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestServiceTopology(t *testing.T) {
+	svc, _ := newTestService(t, "node-a", "node-b", "node-c")
+	ctx := context.Background()
+
+	if _, err := svc.CreateRoute(ctx, &network.RouteRequest{
+		SourceNodeID: "node-a", DestinationNodeID: "node-b",
+		Config: models.NetworkRouteConfig{Encryption: true},
+	}); err != nil {
+		t.Fatalf("CreateRoute: %v", err)
+	}
+	topo, err := svc.Topology(ctx)
+	if err != nil {
+		t.Fatalf("Topology: %v", err)
+	}
+	if len(topo.Nodes) != 3 || len(topo.Links) != 1 {
+		t.Fatalf("got %d nodes and %d links, want 3 and 1", len(topo.Nodes), len(topo.Links))
+	}
+	if l := topo.Links[0]; l.Status != network.LinkPending || l.RouteID == "" || l.Latency != 2*network.DefaultNodeLatency {
+		t.Fatalf("unexpected link before apply: %+v", l)
+	}
+
+	plans, _ := svc.Plan(ctx)
+	if err := svc.Apply(ctx, plans); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	topo, _ = svc.Topology(ctx)
+	if l := topo.Links[0]; l.Status != network.LinkActive {
+		t.Fatalf("link status after apply = %s, want active", l.Status)
+	}
+	if topo.Nodes[0].Address != "172.20.0.1" {
+		t.Fatalf("node-a address = %q", topo.Nodes[0].Address)
+	}
+	if parts := topo.Analyze().Partitions; len(parts) != 2 {
+		t.Fatalf("partitions = %v, want node-c apart", parts)
+	}
+}
@@ -0,0 +1,151 @@
+package network
+
+import (
+	"fmt"
+	"strings"
+)
+
+// redactedKey replaces private keys in configs that are shown or stored
+const redactedKey = "(hidden)"
+
+// wgConfig is the WireGuard configuration of one node
+type wgConfig struct {
+	NodeID     string
+	Address    string
+	PrefixLen  int
+	ListenPort int
+	PrivateKey string
+	Keepalive  int
+	// Filters are applied by iptables on the interface, in order
+	Filters []portFilter
+	// Peers are written in order, highest priority first
+	Peers []wgPeer
+}
+
+// wgPeer is one [Peer] section
+type wgPeer struct {
+	NodeID    string
+	PublicKey string
+	Endpoint  string
+	Address   string
+	Priority  int
+}
+
+// portFilter limits what a peer may reach on this node through the overlay
+type portFilter struct {
+	Source   string
+	Protocol string
+	Ports    []int
+}
+
+// render writes the config in wg-quick format. Private keys are replaced
+// when redact is set, so the output can be shown and stored.
+func (c *wgConfig) render(redact bool) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Managed by syntropy for %s, manual changes are overwritten\n", c.NodeID)
+	b.WriteString("[Interface]\n")
+	fmt.Fprintf(&b, "Address = %s/%d\n", c.Address, c.PrefixLen)
+	fmt.Fprintf(&b, "ListenPort = %d\n", c.ListenPort)
+	key := c.PrivateKey
+	if redact {
+		key = redactedKey
+	}
+	fmt.Fprintf(&b, "PrivateKey = %s\n", key)
+
+	var up, down []string
+	for _, f := range c.Filters {
+		for _, rule := range f.rules() {
+			up = append(up, "iptables -A "+rule)
+			down = append(down, "iptables -D "+rule)
+		}
+	}
+	for _, cmd := range up {
+		fmt.Fprintf(&b, "PostUp = %s\n", cmd)
+	}
+	for _, cmd := range down {
+		fmt.Fprintf(&b, "PostDown = %s\n", cmd)
+	}
+
+	for _, p := range c.Peers {
+		fmt.Fprintf(&b, "\n# %s", p.NodeID)
+		if p.Priority > 0 {
+			fmt.Fprintf(&b, ", priority %d", p.Priority)
+		}
+		b.WriteString("\n[Peer]\n")
+		fmt.Fprintf(&b, "PublicKey = %s\n", p.PublicKey)
+		if p.Endpoint != "" {
+			fmt.Fprintf(&b, "Endpoint = %s\n", p.Endpoint)
+		}
+		fmt.Fprintf(&b, "AllowedIPs = %s/32\n", p.Address)
+		if c.Keepalive > 0 {
+			fmt.Fprintf(&b, "PersistentKeepalive = %d\n", c.Keepalive)
+		}
+	}
+	return b.String()
+}
+
+// rules returns the iptables rule specs: accept each allowed port, then
+// drop everything else from the source
+func (f portFilter) rules() []string {
+	rules := make([]string, 0, len(f.Ports)+1)
+	for _, port := range f.Ports {
+		rules = append(rules, fmt.Sprintf("INPUT -i %%i -s %s -p %s --dport %d -j ACCEPT", f.Source, f.Protocol, port))
+	}
+	return append(rules, fmt.Sprintf("INPUT -i %%i -s %s -j DROP", f.Source))
+}
+
+// diffLines compares two configs line by line. Lines only in old are
+// prefixed with "- ", lines only in new with "+ " and shared lines with
+// two spaces.
+func diffLines(old, new string) []string {
+	a, b := splitLines(old), splitLines(new)
+
+	// lcs[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			out = append(out, "  "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, "- "+a[i])
+			i++
+		default:
+			out = append(out, "+ "+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		out = append(out, "- "+a[i])
+	}
+	for ; j < len(b); j++ {
+		out = append(out, "+ "+b[j])
+	}
+	return out
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimRight(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,272 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// allowedTransitions defines the node lifecycle state machine.
+// A node starts in "creating" and may only move along these edges.
+var allowedTransitions = map[models.NodeStatus][]models.NodeStatus{
+	models.NodeStatusCreating: {
+		models.NodeStatusRunning,
+		models.NodeStatusStopped,
+		models.NodeStatusError,
+	},
+	models.NodeStatusRunning: {
+		models.NodeStatusStopped,
+		models.NodeStatusRestarting,
+		models.NodeStatusUpdating,
+		models.NodeStatusError,
+	},
+	models.NodeStatusStopped: {
+		models.NodeStatusRunning,
+		models.NodeStatusRestarting,
+		models.NodeStatusUpdating,
+		models.NodeStatusError,
+	},
+	models.NodeStatusRestarting: {
+		models.NodeStatusRunning,
+		models.NodeStatusStopped,
+		models.NodeStatusError,
+	},
+	models.NodeStatusUpdating: {
+		models.NodeStatusRunning,
+		models.NodeStatusStopped,
+		models.NodeStatusError,
+	},
+	models.NodeStatusError: {
+		models.NodeStatusStopped,
+		models.NodeStatusRestarting,
+		models.NodeStatusUpdating,
+	},
+}
+
+// Transition records a single status change of a node
+type Transition struct {
+	NodeID    string            `json:"node_id"`
+	From      models.NodeStatus `json:"from"`
+	To        models.NodeStatus `json:"to"`
+	Reason    string            `json:"reason"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// TransitionStore persists the transition history of nodes
+type TransitionStore interface {
+	RecordTransition(ctx context.Context, t *Transition) error
+	ListTransitions(ctx context.Context, nodeID string) ([]*Transition, error)
+}
+
+// Driver performs the actual operations on a node's services.
+// Implementations typically reach the node over SSH.
+type Driver interface {
+	StopServices(ctx context.Context, node *models.Node) error
+	Reboot(ctx context.Context, node *models.Node) error
+	StartServices(ctx context.Context, node *models.Node) error
+}
+
+// CanTransition reports whether a node may move from one status to another
+func CanTransition(from, to models.NodeStatus) bool {
+	for _, next := range allowedTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStatuses returns the statuses reachable from the given status
+func NextStatuses(from models.NodeStatus) []models.NodeStatus {
+	next := make([]models.NodeStatus, len(allowedTransitions[from]))
+	copy(next, allowedTransitions[from])
+	return next
+}
+
+// validateTransition returns an ErrCodeNodeInvalidStatus error for illegal transitions
+func validateTransition(from, to models.NodeStatus) error {
+	if !to.IsValid() {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeNodeInvalidStatus,
+			"Invalid node status", fmt.Sprintf("unknown status '%s'", to))
+	}
+	if !CanTransition(from, to) {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeNodeInvalidStatus,
+			"Invalid node status transition", fmt.Sprintf("cannot move node from '%s' to '%s'", from, to))
+	}
+	return nil
+}
+
+// SetDriver configures the driver used to stop and start node services
+func (s *Service) SetDriver(driver Driver) {
+	s.driver = driver
+}
+
+// SetTransitionStore configures where status transitions are recorded
+func (s *Service) SetTransitionStore(store TransitionStore) {
+	s.transitions = store
+}
+
+// TransitionNode moves a node to a new status, enforcing the lifecycle state machine
+func (s *Service) TransitionNode(ctx context.Context, id string, to models.NodeStatus, reason string) (*models.Node, error) {
+	node, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	if err := s.transition(ctx, node, to, reason); err != nil {
+		return nil, err
+	}
+
+	return node, nil
+}
+
+// GetTransitions returns the recorded status transitions of a node, oldest first
+func (s *Service) GetTransitions(ctx context.Context, id string) ([]*Transition, error) {
+	transitions, err := s.transitions.ListTransitions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list transitions: %w", err)
+	}
+	return transitions, nil
+}
+
+// StopNode stops the services of a running node
+func (s *Service) StopNode(ctx context.Context, id string) error {
+	node, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get node: %w", err)
+	}
+
+	if err := validateTransition(models.NodeStatus(node.Status), models.NodeStatusStopped); err != nil {
+		return err
+	}
+
+	if err := s.driver.StopServices(ctx, node); err != nil {
+		return s.fail(ctx, node, fmt.Errorf("failed to stop services: %w", err))
+	}
+
+	return s.transition(ctx, node, models.NodeStatusStopped, "node stopped")
+}
+
+// StartNode starts the services of a stopped node
+func (s *Service) StartNode(ctx context.Context, id string) error {
+	node, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to get node: %w", err)
+	}
+
+	if err := validateTransition(models.NodeStatus(node.Status), models.NodeStatusRunning); err != nil {
+		return err
+	}
+
+	if err := s.driver.StartServices(ctx, node); err != nil {
+		return s.fail(ctx, node, fmt.Errorf("failed to start services: %w", err))
+	}
+
+	return s.transition(ctx, node, models.NodeStatusRunning, "node started")
+}
+
+// transition validates, applies, persists and records a status change
+func (s *Service) transition(ctx context.Context, node *models.Node, to models.NodeStatus, reason string) error {
+	from := models.NodeStatus(node.Status)
+	if err := validateTransition(from, to); err != nil {
+		return err
+	}
+
+	node.Status = string(to)
+	if err := s.repo.Update(ctx, node); err != nil {
+		node.Status = string(from)
+		return fmt.Errorf("failed to update node status: %w", err)
+	}
+
+	s.record(ctx, node.ID, from, to, reason)
+	return nil
+}
+
+// record stores a transition; failures are logged but never block the status change
+func (s *Service) record(ctx context.Context, nodeID string, from, to models.NodeStatus, reason string) {
+	t := &Transition{
+		NodeID:    nodeID,
+		From:      from,
+		To:        to,
+		Reason:    reason,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if err := s.transitions.RecordTransition(ctx, t); err != nil {
+		s.log.WithFields(map[string]interface{}{
+			"node_id": nodeID,
+			"error":   err.Error(),
+		}).Error("Failed to record node transition")
+	}
+
+	s.log.WithFields(map[string]interface{}{
+		"node_id": nodeID,
+		"from":    from,
+		"to":      to,
+		"reason":  reason,
+	}).Info("Node status changed")
+}
+
+// fail moves a node into the error status after a driver failure and returns the cause
+func (s *Service) fail(ctx context.Context, node *models.Node, cause error) error {
+	if err := s.transition(ctx, node, models.NodeStatusError, cause.Error()); err != nil {
+		s.log.WithFields(map[string]interface{}{
+			"node_id": node.ID,
+			"error":   err.Error(),
+		}).Error("Failed to mark node as errored")
+	}
+	return cause
+}
+
+// noopDriver is used when no driver is configured; it only tracks state
+type noopDriver struct{}
+
+func (noopDriver) StopServices(ctx context.Context, node *models.Node) error  { return nil }
+func (noopDriver) Reboot(ctx context.Context, node *models.Node) error        { return nil }
+func (noopDriver) StartServices(ctx context.Context, node *models.Node) error { return nil }
+
+// MemoryTransitionStore keeps transitions in memory
+type MemoryTransitionStore struct {
+	mu          sync.RWMutex
+	transitions map[string][]*Transition
+}
+
+// NewMemoryTransitionStore creates an empty in-memory transition store
+func NewMemoryTransitionStore() *MemoryTransitionStore {
+	return &MemoryTransitionStore{
+		transitions: make(map[string][]*Transition),
+	}
+}
+
+// RecordTransition appends a transition to the node's history
+func (m *MemoryTransitionStore) RecordTransition(ctx context.Context, t *Transition) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copied := *t
+	m.transitions[t.NodeID] = append(m.transitions[t.NodeID], &copied)
+	return nil
+}
+
+// ListTransitions returns the node's transitions ordered by timestamp
+func (m *MemoryTransitionStore) ListTransitions(ctx context.Context, nodeID string) ([]*Transition, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*Transition, 0, len(m.transitions[nodeID]))
+	for _, t := range m.transitions[nodeID] {
+		copied := *t
+		result = append(result, &copied)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Timestamp.Before(result[j].Timestamp)
+	})
+
+	return result, nil
+}
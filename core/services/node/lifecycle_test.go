@@ -0,0 +1,218 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+type memoryRepo struct {
+	nodes map[string]*models.Node
+	next  int
+}
+
+func newMemoryRepo() *memoryRepo {
+	return &memoryRepo{nodes: make(map[string]*models.Node)}
+}
+
+func (r *memoryRepo) Create(ctx context.Context, node *models.Node) error {
+	r.next++
+	node.ID = fmt.Sprintf("node-%d", r.next)
+	copied := *node
+	r.nodes[node.ID] = &copied
+	return nil
+}
+
+func (r *memoryRepo) GetByID(ctx context.Context, id string) (*models.Node, error) {
+	node, ok := r.nodes[id]
+	if !ok {
+		return nil, coreerrors.ErrNodeNotFound
+	}
+	copied := *node
+	return &copied, nil
+}
+
+func (r *memoryRepo) GetByName(ctx context.Context, name string) (*models.Node, error) {
+	for _, node := range r.nodes {
+		if node.Name == name {
+			copied := *node
+			return &copied, nil
+		}
+	}
+	return nil, coreerrors.ErrNodeNotFound
+}
+
+func (r *memoryRepo) List(ctx context.Context, filter *Filter) ([]*models.Node, error) {
+	var nodes []*models.Node
+	for _, node := range r.nodes {
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+func (r *memoryRepo) Update(ctx context.Context, node *models.Node) error {
+	copied := *node
+	r.nodes[node.ID] = &copied
+	return nil
+}
+
+func (r *memoryRepo) Delete(ctx context.Context, id string) error {
+	delete(r.nodes, id)
+	return nil
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(args ...interface{})                          {}
+func (nopLogger) Error(args ...interface{})                         {}
+func (nopLogger) Debug(args ...interface{})                         {}
+func (l nopLogger) WithFields(fields map[string]interface{}) Logger { return l }
+
+type recordingDriver struct {
+	calls  []string
+	failOn string
+}
+
+func (d *recordingDriver) do(op string) error {
+	d.calls = append(d.calls, op)
+	if op == d.failOn {
+		return fmt.Errorf("%s failed", op)
+	}
+	return nil
+}
+
+func (d *recordingDriver) StopServices(ctx context.Context, node *models.Node) error {
+	return d.do("stop")
+}
+
+func (d *recordingDriver) Reboot(ctx context.Context, node *models.Node) error {
+	return d.do("reboot")
+}
+
+func (d *recordingDriver) StartServices(ctx context.Context, node *models.Node) error {
+	return d.do("start")
+}
+
+func newTestNode(t *testing.T, svc *Service) *models.Node {
+	t.Helper()
+	node, err := svc.CreateNode(context.Background(), &CreateNodeRequest{Name: "node-01"})
+	if err != nil {
+		t.Fatalf("CreateNode: %v", err)
+	}
+	return node
+}
+
+func TestCanTransition(t *testing.T) {
+	cases := []struct {
+		from, to models.NodeStatus
+		want     bool
+	}{
+		{models.NodeStatusCreating, models.NodeStatusRunning, true},
+		{models.NodeStatusRunning, models.NodeStatusRestarting, true},
+		{models.NodeStatusError, models.NodeStatusRestarting, true},
+		{models.NodeStatusCreating, models.NodeStatusUpdating, false},
+		{models.NodeStatusError, models.NodeStatusRunning, false},
+		{models.NodeStatusRunning, models.NodeStatusCreating, false},
+	}
+
+	for _, c := range cases {
+		if got := CanTransition(c.from, c.to); got != c.want {
+			t.Errorf("CanTransition(%s, %s) = %t, want %t", c.from, c.to, got, c.want)
+		}
+	}
+}
+
+func TestUpdateNodeRejectsIllegalStatus(t *testing.T) {
+	svc := NewService(newMemoryRepo(), nopLogger{})
+	node := newTestNode(t, svc)
+
+	_, err := svc.UpdateNode(context.Background(), node.ID, map[string]interface{}{"status": "updating"})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNodeInvalidStatus {
+		t.Fatalf("expected %s, got %v", coreerrors.ErrCodeNodeInvalidStatus, err)
+	}
+
+	_, err = svc.UpdateNode(context.Background(), node.ID, map[string]interface{}{"status": "bogus"})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNodeInvalidStatus {
+		t.Fatalf("expected %s for unknown status, got %v", coreerrors.ErrCodeNodeInvalidStatus, err)
+	}
+
+	updated, err := svc.UpdateNode(context.Background(), node.ID, map[string]interface{}{
+		"status": "running",
+		"reason": "first boot",
+	})
+	if err != nil {
+		t.Fatalf("UpdateNode: %v", err)
+	}
+	if updated.Status != string(models.NodeStatusRunning) {
+		t.Fatalf("status = %s, want running", updated.Status)
+	}
+}
+
+func TestRestartNodeRunsDriverAndRecordsTransitions(t *testing.T) {
+	driver := &recordingDriver{}
+	svc := NewService(newMemoryRepo(), nopLogger{})
+	svc.SetDriver(driver)
+	node := newTestNode(t, svc)
+	ctx := context.Background()
+
+	if _, err := svc.TransitionNode(ctx, node.ID, models.NodeStatusRunning, "booted"); err != nil {
+		t.Fatalf("TransitionNode: %v", err)
+	}
+	if err := svc.RestartNode(ctx, node.ID); err != nil {
+		t.Fatalf("RestartNode: %v", err)
+	}
+
+	if fmt.Sprint(driver.calls) != "[stop reboot start]" {
+		t.Fatalf("driver calls = %v", driver.calls)
+	}
+
+	history, err := svc.GetTransitions(ctx, node.ID)
+	if err != nil {
+		t.Fatalf("GetTransitions: %v", err)
+	}
+
+	want := []models.NodeStatus{
+		models.NodeStatusCreating,
+		models.NodeStatusRunning,
+		models.NodeStatusRestarting,
+		models.NodeStatusRunning,
+	}
+	if len(history) != len(want) {
+		t.Fatalf("got %d transitions, want %d", len(history), len(want))
+	}
+	for i, tr := range history {
+		if tr.To != want[i] {
+			t.Errorf("transition %d: to = %s, want %s", i, tr.To, want[i])
+		}
+		if tr.Reason == "" || tr.Timestamp.IsZero() {
+			t.Errorf("transition %d is missing reason or timestamp", i)
+		}
+	}
+}
+
+func TestRestartNodeDriverFailureMovesToError(t *testing.T) {
+	driver := &recordingDriver{failOn: "reboot"}
+	svc := NewService(newMemoryRepo(), nopLogger{})
+	svc.SetDriver(driver)
+	node := newTestNode(t, svc)
+	ctx := context.Background()
+
+	if _, err := svc.TransitionNode(ctx, node.ID, models.NodeStatusRunning, "booted"); err != nil {
+		t.Fatalf("TransitionNode: %v", err)
+	}
+	if err := svc.RestartNode(ctx, node.ID); err == nil {
+		t.Fatal("expected restart to fail")
+	}
+
+	current, _ := svc.GetNode(ctx, node.ID)
+	if current.Status != string(models.NodeStatusError) {
+		t.Fatalf("status = %s, want error", current.Status)
+	}
+
+	if err := svc.StartNode(ctx, node.ID); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNodeInvalidStatus {
+		t.Fatalf("starting an errored node should be rejected, got %v", err)
+	}
+}
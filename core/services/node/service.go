@@ -4,13 +4,15 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/syntropy-cc/cooperative-grid/core/types/models"
+	"syntropy-cc/cooperative-grid/core/types/models"
 )
 
 // Service handles node management operations
 type Service struct {
-	repo Repository
-	log  Logger
+	repo        Repository
+	log         Logger
+	driver      Driver
+	transitions TransitionStore
 }
 
 // Repository defines the interface for node data access
@@ -50,8 +52,10 @@ type CreateNodeRequest struct {
 // NewService creates a new node service
 func NewService(repo Repository, log Logger) *Service {
 	return &Service{
-		repo: repo,
-		log:  log,
+		repo:        repo,
+		log:         log,
+		driver:      noopDriver{},
+		transitions: NewMemoryTransitionStore(),
 	}
 }
 
@@ -78,7 +82,7 @@ func (s *Service) CreateNode(ctx context.Context, req *CreateNodeRequest) (*mode
 	node := &models.Node{
 		Name:        req.Name,
 		Description: req.Description,
-		Status:      "creating",
+		Status:      string(models.NodeStatusCreating),
 		HardwareInfo: map[string]interface{}{
 			"usb_device": req.USBDevice,
 			"auto_detect": req.AutoDetect,
@@ -90,6 +94,8 @@ func (s *Service) CreateNode(ctx context.Context, req *CreateNodeRequest) (*mode
 		return nil, fmt.Errorf("failed to create node: %w", err)
 	}
 
+	s.record(ctx, node.ID, "", models.NodeStatusCreating, "node created")
+
 	s.log.WithFields(map[string]interface{}{
 		"node_id": node.ID,
 		"name":    node.Name,
@@ -139,6 +145,16 @@ func (s *Service) UpdateNode(ctx context.Context, id string, updates map[string]
 		return nil, fmt.Errorf("failed to get node: %w", err)
 	}
 
+	// Validate status change before touching anything else
+	from := models.NodeStatus(node.Status)
+	to := from
+	if status, ok := updates["status"].(string); ok && status != node.Status {
+		to = models.NodeStatus(status)
+		if err := validateTransition(from, to); err != nil {
+			return nil, err
+		}
+	}
+
 	// Apply updates
 	if name, ok := updates["name"].(string); ok {
 		node.Name = name
@@ -146,15 +162,21 @@ func (s *Service) UpdateNode(ctx context.Context, id string, updates map[string]
 	if description, ok := updates["description"].(string); ok {
 		node.Description = description
 	}
-	if status, ok := updates["status"].(string); ok {
-		node.Status = status
-	}
+	node.Status = string(to)
 
 	// Save updated node
 	if err := s.repo.Update(ctx, node); err != nil {
 		return nil, fmt.Errorf("failed to update node: %w", err)
 	}
 
+	if to != from {
+		reason, _ := updates["reason"].(string)
+		if reason == "" {
+			reason = "status updated"
+		}
+		s.record(ctx, node.ID, from, to, reason)
+	}
+
 	s.log.WithFields(map[string]interface{}{
 		"node_id": node.ID,
 		"name":    node.Name,
@@ -200,22 +222,23 @@ func (s *Service) RestartNode(ctx context.Context, id string) error {
 	}
 
 	// Update status to restarting
-	node.Status = "restarting"
-	if err := s.repo.Update(ctx, node); err != nil {
-		return fmt.Errorf("failed to update node status: %w", err)
+	if err := s.transition(ctx, node, models.NodeStatusRestarting, "restart requested"); err != nil {
+		return err
 	}
 
-	// TODO: Implement actual restart logic
-	// This would involve:
-	// 1. Stopping all services on the node
-	// 2. Restarting the node
-	// 3. Starting services again
-	// 4. Updating status to "running"
+	// Stop services, reboot the node and start services again
+	if err := s.driver.StopServices(ctx, node); err != nil {
+		return s.fail(ctx, node, fmt.Errorf("failed to stop services: %w", err))
+	}
+	if err := s.driver.Reboot(ctx, node); err != nil {
+		return s.fail(ctx, node, fmt.Errorf("failed to reboot node: %w", err))
+	}
+	if err := s.driver.StartServices(ctx, node); err != nil {
+		return s.fail(ctx, node, fmt.Errorf("failed to start services: %w", err))
+	}
 
-	// For now, just update status to running
-	node.Status = "running"
-	if err := s.repo.Update(ctx, node); err != nil {
-		return fmt.Errorf("failed to update node status: %w", err)
+	if err := s.transition(ctx, node, models.NodeStatusRunning, "restart completed"); err != nil {
+		return err
 	}
 
 	s.log.WithFields(map[string]interface{}{
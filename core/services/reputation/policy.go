@@ -0,0 +1,270 @@
+package reputation
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Policy weighs the reputation components and controls how fast old
+// evidence fades. Weights are relative; they do not need to sum to one.
+type Policy struct {
+	UptimeWeight      float64
+	TransactionWeight float64
+	PenaltyWeight     float64
+	GovernanceWeight  float64
+	// HalfLife is the age at which an event counts half as much as a new one
+	HalfLife time.Duration
+	// Window is how far back events are considered at all
+	Window time.Duration
+}
+
+// DefaultPolicy returns the weighting used by the cooperative unless configured otherwise
+func DefaultPolicy() *Policy {
+	return &Policy{
+		UptimeWeight:      0.40,
+		TransactionWeight: 0.25,
+		PenaltyWeight:     0.20,
+		GovernanceWeight:  0.15,
+		HalfLife:          30 * 24 * time.Hour,
+		Window:            180 * 24 * time.Hour,
+	}
+}
+
+// policyJSON is the on-disk form of Policy, with durations written as
+// strings such as "720h"
+type policyJSON struct {
+	UptimeWeight      float64 `json:"uptime_weight"`
+	TransactionWeight float64 `json:"transaction_weight"`
+	PenaltyWeight     float64 `json:"penalty_weight"`
+	GovernanceWeight  float64 `json:"governance_weight"`
+	HalfLife          string  `json:"half_life"`
+	Window            string  `json:"window"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (p Policy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(policyJSON{
+		UptimeWeight:      p.UptimeWeight,
+		TransactionWeight: p.TransactionWeight,
+		PenaltyWeight:     p.PenaltyWeight,
+		GovernanceWeight:  p.GovernanceWeight,
+		HalfLife:          p.HalfLife.String(),
+		Window:            p.Window.String(),
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Fields missing from the
+// input keep their current values.
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	raw := policyJSON{
+		UptimeWeight:      p.UptimeWeight,
+		TransactionWeight: p.TransactionWeight,
+		PenaltyWeight:     p.PenaltyWeight,
+		GovernanceWeight:  p.GovernanceWeight,
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	p.UptimeWeight = raw.UptimeWeight
+	p.TransactionWeight = raw.TransactionWeight
+	p.PenaltyWeight = raw.PenaltyWeight
+	p.GovernanceWeight = raw.GovernanceWeight
+
+	for _, d := range []struct {
+		value  string
+		target *time.Duration
+	}{{raw.HalfLife, &p.HalfLife}, {raw.Window, &p.Window}} {
+		if d.value == "" {
+			continue
+		}
+		parsed, err := time.ParseDuration(d.value)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", d.value, err)
+		}
+		*d.target = parsed
+	}
+	return nil
+}
+
+// Validate checks that the policy can produce a score
+func (p *Policy) Validate() error {
+	for name, w := range map[string]float64{
+		"uptime_weight":      p.UptimeWeight,
+		"transaction_weight": p.TransactionWeight,
+		"penalty_weight":     p.PenaltyWeight,
+		"governance_weight":  p.GovernanceWeight,
+	} {
+		if w < 0 {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid reputation policy",
+				fmt.Sprintf("%s must not be negative", name))
+		}
+	}
+	if p.totalWeight() == 0 {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid reputation policy",
+			"at least one weight must be positive")
+	}
+	if p.HalfLife <= 0 || p.Window <= 0 {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid reputation policy",
+			"half_life and window must be positive")
+	}
+	return nil
+}
+
+func (p *Policy) totalWeight() float64 {
+	return p.UptimeWeight + p.TransactionWeight + p.PenaltyWeight + p.GovernanceWeight
+}
+
+// decay returns the weight of an event of the given age
+func (p *Policy) decay(age time.Duration) float64 {
+	if age <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, float64(age)/float64(p.HalfLife))
+}
+
+// Components are the per-signal sub-scores, each between 0 and 1.
+// A signal without any evidence scores 0.5, except penalties, where
+// no evidence means a clean record.
+type Components struct {
+	Uptime       float64 `json:"uptime"`
+	Transactions float64 `json:"transactions"`
+	Penalties    float64 `json:"penalties"`
+	Governance   float64 `json:"governance"`
+}
+
+// Evidence counts the raw events that went into a score
+type Evidence struct {
+	HealthChecks          int `json:"health_checks"`
+	HealthChecksFailed    int `json:"health_checks_failed"`
+	TransactionsCompleted int `json:"transactions_completed"`
+	TransactionsFailed    int `json:"transactions_failed"`
+	Penalties             int `json:"penalties"`
+	VotesCast             int `json:"votes_cast"`
+	VotesMissed           int `json:"votes_missed"`
+}
+
+// evaluate computes the score (0-10), its components and evidence from the events
+func (p *Policy) evaluate(events []*Event, now time.Time) (float64, Components, Evidence) {
+	var (
+		ev                     Evidence
+		upSum, upWeight        float64
+		completed, failed      float64
+		penalties              float64
+		votesCast, votesMissed float64
+	)
+
+	for _, e := range events {
+		age := now.Sub(e.At)
+		if age > p.Window {
+			continue
+		}
+		w := p.decay(age)
+
+		switch e.Kind {
+		case EventHealthCheck:
+			ev.HealthChecks++
+			if e.Value <= 0 {
+				ev.HealthChecksFailed++
+			}
+			upSum += w * math.Max(0, math.Min(1, e.Value))
+			upWeight += w
+		case EventTransactionCompleted:
+			ev.TransactionsCompleted++
+			completed += w
+		case EventTransactionFailed:
+			ev.TransactionsFailed++
+			failed += w
+		case EventPenalty:
+			ev.Penalties++
+			penalties += w
+		case EventVoteCast:
+			ev.VotesCast++
+			votesCast += w
+		case EventVoteMissed:
+			ev.VotesMissed++
+			votesMissed += w
+		}
+	}
+
+	c := Components{
+		Uptime:       0.5,
+		Transactions: smoothed(completed, failed),
+		Penalties:    1 / (1 + penalties),
+		Governance:   smoothed(votesCast, votesMissed),
+	}
+	if upWeight > 0 {
+		c.Uptime = upSum / upWeight
+	}
+
+	total := p.UptimeWeight*c.Uptime +
+		p.TransactionWeight*c.Transactions +
+		p.PenaltyWeight*c.Penalties +
+		p.GovernanceWeight*c.Governance
+	score := math.Round(total/p.totalWeight()*10*100) / 100
+
+	return score, c, ev
+}
+
+// smoothed returns the share of good outcomes with a Laplace prior, so a
+// single event does not swing the component to 0 or 1
+func smoothed(good, bad float64) float64 {
+	return (good + 1) / (good + bad + 2)
+}
+
+// explain lists the reasons a score moved, largest impact first
+func (p *Policy) explain(prev *ScoreChange, score float64, c Components) []string {
+	if prev == nil {
+		return []string{fmt.Sprintf("initial score %.2f", score)}
+	}
+
+	var reasons []string
+	prevLevel := (&models.NodeReputation{Score: prev.Score}).GetTrustLevel()
+	level := (&models.NodeReputation{Score: score}).GetTrustLevel()
+	if prevLevel != level {
+		verb := "rose"
+		if score < prev.Score {
+			verb = "dropped"
+		}
+		reasons = append(reasons, fmt.Sprintf("trust level %s from %s to %s", verb, prevLevel, level))
+	}
+
+	type impact struct {
+		name     string
+		from, to float64
+		points   float64
+	}
+	scale := 10 / p.totalWeight()
+	impacts := []impact{
+		{"uptime", prev.Components.Uptime, c.Uptime, scale * p.UptimeWeight * (c.Uptime - prev.Components.Uptime)},
+		{"transaction success", prev.Components.Transactions, c.Transactions, scale * p.TransactionWeight * (c.Transactions - prev.Components.Transactions)},
+		{"penalty record", prev.Components.Penalties, c.Penalties, scale * p.PenaltyWeight * (c.Penalties - prev.Components.Penalties)},
+		{"governance participation", prev.Components.Governance, c.Governance, scale * p.GovernanceWeight * (c.Governance - prev.Components.Governance)},
+	}
+	sort.SliceStable(impacts, func(i, j int) bool {
+		return math.Abs(impacts[i].points) > math.Abs(impacts[j].points)
+	})
+
+	for _, im := range impacts {
+		if math.Abs(im.points) < 0.01 {
+			continue
+		}
+		verb := "improved"
+		if im.points < 0 {
+			verb = "fell"
+		}
+		reasons = append(reasons, fmt.Sprintf("%s %s from %.0f%% to %.0f%% (%+.2f)",
+			im.name, verb, im.from*100, im.to*100, im.points))
+	}
+
+	if len(reasons) == 0 {
+		reasons = append(reasons, "older evidence decayed")
+	}
+	return reasons
+}
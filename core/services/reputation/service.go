@@ -0,0 +1,259 @@
+package reputation
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Service derives node reputation scores from health, transaction and
+// governance events and keeps a history of every score change
+type Service struct {
+	repo    Repository
+	log     Logger
+	policy  *Policy
+	sources []EventSource
+	now     func() time.Time
+}
+
+// Repository defines the interface for reputation data access
+type Repository interface {
+	// GetReputation returns the node's reputation, or nil when none is recorded
+	GetReputation(ctx context.Context, nodeID string) (*models.NodeReputation, error)
+	ListReputations(ctx context.Context) ([]*models.NodeReputation, error)
+	// SaveScore stores the reputation and appends the change to the node's
+	// history in one transaction
+	SaveScore(ctx context.Context, rep *models.NodeReputation, change *ScoreChange) error
+	ListChanges(ctx context.Context, nodeID string) ([]*ScoreChange, error)
+	// RecordEvent stores an event observed directly, such as a health check
+	RecordEvent(ctx context.Context, e *Event) error
+	ListEvents(ctx context.Context, nodeID string, since time.Time) ([]*Event, error)
+}
+
+// EventSource supplies reputation events kept elsewhere, such as the
+// credit ledger or governance votes
+type EventSource interface {
+	Events(ctx context.Context, nodeID string, since time.Time) ([]*Event, error)
+}
+
+// Logger defines the interface for logging
+type Logger interface {
+	Info(args ...interface{})
+	Error(args ...interface{})
+	Debug(args ...interface{})
+	WithFields(fields map[string]interface{}) Logger
+}
+
+// EventKind identifies what an event says about a node
+type EventKind string
+
+const (
+	EventHealthCheck          EventKind = "health_check"
+	EventTransactionCompleted EventKind = "transaction_completed"
+	EventTransactionFailed    EventKind = "transaction_failed"
+	EventPenalty              EventKind = "penalty"
+	EventVoteCast             EventKind = "vote_cast"
+	EventVoteMissed           EventKind = "vote_missed"
+)
+
+// Event is a single observation that affects a node's reputation
+type Event struct {
+	NodeID string    `json:"node_id"`
+	Kind   EventKind `json:"kind"`
+	// Value is 1 for a healthy check and 0 for a failed one; other kinds use 1
+	Value float64   `json:"value"`
+	At    time.Time `json:"at"`
+	// Ref points at the transaction or proposal behind the event
+	Ref string `json:"ref,omitempty"`
+}
+
+// ScoreChange records a reputation change and why it happened
+type ScoreChange struct {
+	NodeID             string                `json:"node_id"`
+	Score              float64               `json:"score"`
+	PreviousScore      float64               `json:"previous_score"`
+	TrustLevel         models.NodeTrustLevel `json:"trust_level"`
+	PreviousTrustLevel models.NodeTrustLevel `json:"previous_trust_level,omitempty"`
+	Components         Components            `json:"components"`
+	Evidence           Evidence              `json:"evidence"`
+	Reasons            []string              `json:"reasons"`
+	At                 time.Time             `json:"at"`
+}
+
+// NewService creates a new reputation service using DefaultPolicy
+func NewService(repo Repository, log Logger) *Service {
+	return &Service{
+		repo:   repo,
+		log:    log,
+		policy: DefaultPolicy(),
+		now:    time.Now,
+	}
+}
+
+// SetPolicy replaces the weighting policy
+func (s *Service) SetPolicy(policy *Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	copied := *policy
+	s.policy = &copied
+	return nil
+}
+
+// Policy returns the weighting policy in use
+func (s *Service) Policy() Policy {
+	return *s.policy
+}
+
+// AddSource registers an additional event source
+func (s *Service) AddSource(src EventSource) {
+	s.sources = append(s.sources, src)
+}
+
+// SetClock replaces the time source, mainly for tests
+func (s *Service) SetClock(now func() time.Time) {
+	s.now = now
+}
+
+// RecordHealthCheck stores the outcome of a health check against a node
+func (s *Service) RecordHealthCheck(ctx context.Context, nodeID string, healthy bool, at time.Time) error {
+	value := 0.0
+	if healthy {
+		value = 1
+	}
+	if err := s.repo.RecordEvent(ctx, &Event{
+		NodeID: nodeID,
+		Kind:   EventHealthCheck,
+		Value:  value,
+		At:     at.UTC(),
+	}); err != nil {
+		return fmt.Errorf("failed to record health check: %w", err)
+	}
+	return nil
+}
+
+// Update recomputes a node's score from its events. The change is added to
+// the history only when the score moved or the node had no score yet.
+func (s *Service) Update(ctx context.Context, nodeID string) (*ScoreChange, error) {
+	if strings.TrimSpace(nodeID) == "" {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeInvalidInput, "Invalid node", "node ID is required")
+	}
+
+	now := s.now().UTC()
+	events, err := s.collect(ctx, nodeID, now.Add(-s.policy.Window))
+	if err != nil {
+		return nil, err
+	}
+	score, components, evidence := s.policy.evaluate(events, now)
+
+	history, err := s.repo.ListChanges(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list score history: %w", err)
+	}
+	var prev *ScoreChange
+	if len(history) > 0 {
+		prev = history[len(history)-1]
+	}
+
+	rep := &models.NodeReputation{NodeID: nodeID, Score: score, LastUpdated: now}
+	level := rep.GetTrustLevel()
+	rep.TrustLevel = string(level)
+
+	change := &ScoreChange{
+		NodeID:     nodeID,
+		Score:      score,
+		TrustLevel: level,
+		Components: components,
+		Evidence:   evidence,
+		Reasons:    s.policy.explain(prev, score, components),
+		At:         now,
+	}
+	if prev != nil {
+		change.PreviousScore = prev.Score
+		change.PreviousTrustLevel = prev.TrustLevel
+		if prev.Score == score {
+			return change, nil
+		}
+	}
+
+	if err := s.repo.SaveScore(ctx, rep, change); err != nil {
+		return nil, fmt.Errorf("failed to save reputation: %w", err)
+	}
+
+	s.log.WithFields(map[string]interface{}{
+		"node_id":     nodeID,
+		"score":       score,
+		"previous":    change.PreviousScore,
+		"trust_level": level,
+	}).Info("Reputation updated")
+
+	return change, nil
+}
+
+// UpdateAll recomputes the score of every given node
+func (s *Service) UpdateAll(ctx context.Context, nodeIDs []string) ([]*ScoreChange, error) {
+	changes := make([]*ScoreChange, 0, len(nodeIDs))
+	for _, id := range nodeIDs {
+		change, err := s.Update(ctx, id)
+		if err != nil {
+			return changes, fmt.Errorf("node %s: %w", id, err)
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// GetReputation returns a node's stored reputation
+func (s *Service) GetReputation(ctx context.Context, nodeID string) (*models.NodeReputation, error) {
+	rep, err := s.repo.GetReputation(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reputation: %w", err)
+	}
+	if rep == nil {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeNotFound, "Reputation not found",
+			fmt.Sprintf("no score computed for node '%s' yet", nodeID))
+	}
+	return rep, nil
+}
+
+// ListReputations returns every stored reputation, highest score first
+func (s *Service) ListReputations(ctx context.Context) ([]*models.NodeReputation, error) {
+	reps, err := s.repo.ListReputations(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reputations: %w", err)
+	}
+	sort.SliceStable(reps, func(i, j int) bool {
+		return reps[i].Score > reps[j].Score
+	})
+	return reps, nil
+}
+
+// History returns a node's score changes, oldest first
+func (s *Service) History(ctx context.Context, nodeID string) ([]*ScoreChange, error) {
+	changes, err := s.repo.ListChanges(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list score history: %w", err)
+	}
+	return changes, nil
+}
+
+// collect gathers a node's events from the repository and every source
+func (s *Service) collect(ctx context.Context, nodeID string, since time.Time) ([]*Event, error) {
+	events, err := s.repo.ListEvents(ctx, nodeID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	for _, src := range s.sources {
+		more, err := src.Events(ctx, nodeID, since)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect events: %w", err)
+		}
+		events = append(events, more...)
+	}
+	return events, nil
+}
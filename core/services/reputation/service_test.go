@@ -0,0 +1,210 @@
+package reputation_test
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/services/credits"
+	"syntropy-cc/cooperative-grid/core/services/reputation"
+	"syntropy-cc/cooperative-grid/core/storage"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(args ...interface{})                                     {}
+func (nopLogger) Error(args ...interface{})                                    {}
+func (nopLogger) Debug(args ...interface{})                                    {}
+func (l nopLogger) WithFields(fields map[string]interface{}) reputation.Logger { return l }
+
+type creditsLogger struct{ nopLogger }
+
+func (l creditsLogger) WithFields(fields map[string]interface{}) credits.Logger { return l }
+
+type clock struct{ now time.Time }
+
+func (c *clock) Now() time.Time { return c.now }
+
+func newTestService(t *testing.T) (*reputation.Service, *storage.Store, *clock) {
+	t.Helper()
+	store, err := storage.Open(filepath.Join(t.TempDir(), storage.DefaultFileName))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	c := &clock{now: time.Now().UTC()}
+	svc := reputation.NewService(storage.NewReputationRepository(store), nopLogger{})
+	svc.SetClock(c.Now)
+	return svc, store, c
+}
+
+func recordChecks(t *testing.T, svc *reputation.Service, node string, at time.Time, up, down int) {
+	t.Helper()
+	for i := 0; i < up+down; i++ {
+		if err := svc.RecordHealthCheck(context.Background(), node, i < up, at); err != nil {
+			t.Fatalf("RecordHealthCheck: %v", err)
+		}
+	}
+}
+
+func TestScoreWithoutEvidenceIsNeutral(t *testing.T) {
+	svc, _, _ := newTestService(t)
+
+	change, err := svc.Update(context.Background(), "node-a")
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	// 0.4*0.5 + 0.25*0.5 + 0.2*1 + 0.15*0.5 = 0.6
+	if change.Score != 6 || change.TrustLevel != models.TrustLevelMedium {
+		t.Fatalf("neutral score = %.2f (%s), want 6.00 (medium)", change.Score, change.TrustLevel)
+	}
+}
+
+func TestHistoryExplainsTrustLevelDrop(t *testing.T) {
+	svc, _, c := newTestService(t)
+	ctx := context.Background()
+
+	if err := svc.SetPolicy(&reputation.Policy{
+		UptimeWeight: 1, HalfLife: 24 * time.Hour, Window: 30 * 24 * time.Hour,
+	}); err != nil {
+		t.Fatalf("SetPolicy: %v", err)
+	}
+
+	recordChecks(t, svc, "node-a", c.now, 20, 0)
+	first, err := svc.Update(ctx, "node-a")
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if first.TrustLevel != models.TrustLevelVeryHigh {
+		t.Fatalf("trust level = %s, want very_high", first.TrustLevel)
+	}
+
+	// Unchanged score does not add to the history
+	if _, err := svc.Update(ctx, "node-a"); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+
+	recordChecks(t, svc, "node-a", c.now, 0, 10)
+	second, err := svc.Update(ctx, "node-a")
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if second.TrustLevel != models.TrustLevelMedium || second.PreviousTrustLevel != models.TrustLevelVeryHigh {
+		t.Fatalf("unexpected levels: %s -> %s", second.PreviousTrustLevel, second.TrustLevel)
+	}
+	if len(second.Reasons) < 2 ||
+		!strings.Contains(second.Reasons[0], "trust level dropped from very_high") ||
+		!strings.Contains(second.Reasons[1], "uptime fell") {
+		t.Fatalf("unexpected reasons: %v", second.Reasons)
+	}
+
+	history, err := svc.History(ctx, "node-a")
+	if err != nil {
+		t.Fatalf("History: %v", err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("history has %d entries, want 2", len(history))
+	}
+
+	rep, err := svc.GetReputation(ctx, "node-a")
+	if err != nil {
+		t.Fatalf("GetReputation: %v", err)
+	}
+	if rep.Score != second.Score || rep.TrustLevel != string(models.TrustLevelMedium) {
+		t.Fatalf("stored reputation = %+v", rep)
+	}
+}
+
+func TestOldEvidenceDecays(t *testing.T) {
+	svc, _, c := newTestService(t)
+	ctx := context.Background()
+
+	if err := svc.SetPolicy(&reputation.Policy{
+		UptimeWeight: 1, HalfLife: 24 * time.Hour, Window: 30 * 24 * time.Hour,
+	}); err != nil {
+		t.Fatalf("SetPolicy: %v", err)
+	}
+
+	// Ten failures a week ago, ten successes today
+	recordChecks(t, svc, "node-a", c.now.Add(-7*24*time.Hour), 0, 10)
+	recordChecks(t, svc, "node-a", c.now, 10, 0)
+	// Outside the window entirely
+	recordChecks(t, svc, "node-a", c.now.Add(-60*24*time.Hour), 0, 100)
+
+	change, err := svc.Update(ctx, "node-a")
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if change.Score < 9.9 {
+		t.Fatalf("score = %.2f, old failures should have decayed", change.Score)
+	}
+	if change.Evidence.HealthChecks != 20 {
+		t.Fatalf("evidence counted %d checks, want 20", change.Evidence.HealthChecks)
+	}
+}
+
+func TestTransactionSource(t *testing.T) {
+	svc, store, _ := newTestService(t)
+	ctx := context.Background()
+
+	ledger := credits.NewService(storage.NewCreditsRepository(store), creditsLogger{})
+	svc.AddSource(reputation.TransactionSource{Transactions: ledger})
+	if err := svc.SetPolicy(&reputation.Policy{
+		TransactionWeight: 1, PenaltyWeight: 1, HalfLife: 24 * time.Hour, Window: 24 * time.Hour,
+	}); err != nil {
+		t.Fatalf("SetPolicy: %v", err)
+	}
+
+	requests := []*credits.TransactionRequest{
+		{Type: models.TransactionTypeServiceReward, ToNodeID: "node-a", Amount: 10},
+		{Type: models.TransactionTypePenalty, FromNodeID: "node-a", Amount: 5},
+		{Type: models.TransactionTypeTransfer, FromNodeID: "node-a", ToNodeID: "node-b", Amount: 50},
+	}
+	for _, req := range requests {
+		ledger.Transfer(ctx, req)
+	}
+
+	change, err := svc.Update(ctx, "node-a")
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	ev := change.Evidence
+	if ev.TransactionsCompleted != 1 || ev.Penalties != 1 || ev.TransactionsFailed != 1 {
+		t.Fatalf("unexpected evidence: %+v", ev)
+	}
+
+	// node-b was only the target of a failed transfer
+	change, err = svc.Update(ctx, "node-b")
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if change.Evidence != (reputation.Evidence{}) {
+		t.Fatalf("node-b should have no evidence, got %+v", change.Evidence)
+	}
+}
+
+func TestPolicyJSONKeepsDefaultsForMissingFields(t *testing.T) {
+	policy := reputation.DefaultPolicy()
+	if err := json.Unmarshal([]byte(`{"uptime_weight": 0.7, "half_life": "72h"}`), policy); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if policy.UptimeWeight != 0.7 || policy.HalfLife != 72*time.Hour {
+		t.Fatalf("overrides not applied: %+v", policy)
+	}
+	if policy.PenaltyWeight != reputation.DefaultPolicy().PenaltyWeight || policy.Window != reputation.DefaultPolicy().Window {
+		t.Fatalf("defaults lost: %+v", policy)
+	}
+
+	data, err := json.Marshal(policy)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(data), `"half_life":"72h0m0s"`) {
+		t.Fatalf("durations should be written as strings: %s", data)
+	}
+}
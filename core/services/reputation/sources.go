@@ -0,0 +1,133 @@
+package reputation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/services/credits"
+	"syntropy-cc/cooperative-grid/core/services/governance"
+	"syntropy-cc/cooperative-grid/core/types/constants"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// TransactionLister is the part of credits.Service used by TransactionSource
+type TransactionLister interface {
+	ListTransactions(ctx context.Context, filter *credits.Filter) ([]*models.CooperativeTransaction, error)
+}
+
+// TransactionSource turns a node's credit transactions into events.
+// Completed transactions count in the node's favour, penalties it paid
+// count against it, and failed transactions count against the node that
+// could not pay.
+type TransactionSource struct {
+	Transactions TransactionLister
+}
+
+// Events implements EventSource
+func (src TransactionSource) Events(ctx context.Context, nodeID string, since time.Time) ([]*Event, error) {
+	var events []*Event
+	for offset := 0; ; offset += constants.MaxPageSize {
+		txs, err := src.Transactions.ListTransactions(ctx, &credits.Filter{
+			NodeID: nodeID,
+			Limit:  constants.MaxPageSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list transactions: %w", err)
+		}
+
+		for _, tx := range txs {
+			// Transactions are listed newest first
+			if tx.CreatedAt.Before(since) {
+				return events, nil
+			}
+			if e := transactionEvent(nodeID, tx); e != nil {
+				events = append(events, e)
+			}
+		}
+
+		if len(txs) < constants.MaxPageSize {
+			return events, nil
+		}
+	}
+}
+
+// transactionEvent maps a transaction to an event for nodeID, or nil if it says nothing about the node
+func transactionEvent(nodeID string, tx *models.CooperativeTransaction) *Event {
+	e := &Event{NodeID: nodeID, Value: 1, At: tx.UpdatedAt, Ref: tx.ID}
+
+	switch models.CooperativeTransactionStatus(tx.Status) {
+	case models.TransactionStatusCompleted:
+		if models.CooperativeTransactionType(tx.Type) == models.TransactionTypePenalty {
+			if tx.FromNodeID != nodeID {
+				return nil
+			}
+			e.Kind = EventPenalty
+		} else {
+			e.Kind = EventTransactionCompleted
+		}
+	case models.TransactionStatusFailed:
+		if tx.FromNodeID != nodeID {
+			return nil
+		}
+		e.Kind = EventTransactionFailed
+	default:
+		return nil
+	}
+	return e
+}
+
+// ProposalLister is the part of governance.Service used by GovernanceSource
+type ProposalLister interface {
+	ListProposals(ctx context.Context, filter *governance.Filter) ([]*models.GovernanceProposal, error)
+	ListVotes(ctx context.Context, proposalID string) ([]*models.GovernanceVote, error)
+}
+
+// GovernanceSource turns governance participation into events: one for
+// every closed proposal, depending on whether the node voted on it
+type GovernanceSource struct {
+	Proposals ProposalLister
+}
+
+// Events implements EventSource
+func (src GovernanceSource) Events(ctx context.Context, nodeID string, since time.Time) ([]*Event, error) {
+	var events []*Event
+	for offset := 0; ; offset += constants.MaxPageSize {
+		proposals, err := src.Proposals.ListProposals(ctx, &governance.Filter{
+			Limit:  constants.MaxPageSize,
+			Offset: offset,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list proposals: %w", err)
+		}
+
+		for _, p := range proposals {
+			switch models.GovernanceProposalStatus(p.Status) {
+			case models.ProposalStatusActive, models.ProposalStatusVoting:
+				continue
+			}
+			if p.EndDate.Before(since) {
+				continue
+			}
+
+			votes, err := src.Proposals.ListVotes(ctx, p.ID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to list votes: %w", err)
+			}
+			e := &Event{NodeID: nodeID, Kind: EventVoteMissed, Value: 1, At: p.EndDate, Ref: p.ID}
+			for _, v := range votes {
+				if v.VoterID == nodeID {
+					e.Kind = EventVoteCast
+					e.At = v.CreatedAt
+					break
+				}
+			}
+			events = append(events, e)
+		}
+
+		if len(proposals) < constants.MaxPageSize {
+			return events, nil
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"syntropy-cc/cooperative-grid/core/services/container"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// ContainerRepository implements container.Repository
+type ContainerRepository struct {
+	store *Store
+}
+
+var _ container.Repository = (*ContainerRepository)(nil)
+
+// NewContainerRepository creates a container repository on top of the store
+func NewContainerRepository(store *Store) *ContainerRepository {
+	return &ContainerRepository{store: store}
+}
+
+// Create inserts a new container, assigning an ID and timestamps when missing
+func (r *ContainerRepository) Create(ctx context.Context, c *models.Container) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		containers, err := bucket(tx, models.Container{}.TableName())
+		if err != nil {
+			return err
+		}
+
+		if c.ID == "" {
+			c.ID = NewID()
+		}
+		if containers.Get([]byte(c.ID)) != nil {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeContainerAlreadyExists,
+				"Container already exists", fmt.Sprintf("id '%s'", c.ID))
+		}
+
+		now := time.Now().UTC()
+		if c.CreatedAt.IsZero() {
+			c.CreatedAt = now
+		}
+		c.UpdatedAt = now
+		if c.Status == "" {
+			c.Status = string(models.ContainerStatusCreating)
+		}
+		return putJSON(containers, c.ID, c)
+	})
+}
+
+// GetByID returns the container with the given ID
+func (r *ContainerRepository) GetByID(ctx context.Context, id string) (*models.Container, error) {
+	var c *models.Container
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		containers, err := bucket(tx, models.Container{}.TableName())
+		if err != nil {
+			return err
+		}
+		c, err = getContainer(containers, id)
+		return err
+	})
+	return c, err
+}
+
+// List returns containers ordered by creation time, honoring the filter.
+// Unlike nodes, Name must match exactly since it is used to resolve
+// containers by name.
+func (r *ContainerRepository) List(ctx context.Context, filter *container.Filter) ([]*models.Container, error) {
+	if filter == nil {
+		filter = &container.Filter{}
+	}
+
+	var result []*models.Container
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		containers, err := bucket(tx, models.Container{}.TableName())
+		if err != nil {
+			return err
+		}
+		return containers.ForEach(func(k, v []byte) error {
+			c, err := getContainer(containers, string(k))
+			if err != nil {
+				return err
+			}
+			if matchesContainerFilter(c, filter) {
+				result = append(result, c)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return paginate(result, filter.Limit, filter.Offset), nil
+}
+
+// Update replaces an existing container
+func (r *ContainerRepository) Update(ctx context.Context, c *models.Container) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		containers, err := bucket(tx, models.Container{}.TableName())
+		if err != nil {
+			return err
+		}
+		existing, err := getContainer(containers, c.ID)
+		if err != nil {
+			return err
+		}
+
+		c.CreatedAt = existing.CreatedAt
+		c.UpdatedAt = time.Now().UTC()
+		return putJSON(containers, c.ID, c)
+	})
+}
+
+// Delete removes a container record
+func (r *ContainerRepository) Delete(ctx context.Context, id string) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		containers, err := bucket(tx, models.Container{}.TableName())
+		if err != nil {
+			return err
+		}
+		if _, err := getContainer(containers, id); err != nil {
+			return err
+		}
+		return containers.Delete([]byte(id))
+	})
+}
+
+// getContainer decodes a container, returning ErrCodeContainerNotFound when absent
+func getContainer(containers *bolt.Bucket, id string) (*models.Container, error) {
+	c := &models.Container{}
+	found, err := getJSON(containers, id, c)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeContainerNotFound,
+			"Container not found", fmt.Sprintf("id '%s'", id))
+	}
+	return c, nil
+}
+
+// matchesContainerFilter reports whether a container satisfies the filter
+func matchesContainerFilter(c *models.Container, filter *container.Filter) bool {
+	if filter.NodeID != "" && c.NodeID != filter.NodeID {
+		return false
+	}
+	if filter.Name != "" && c.Name != filter.Name {
+		return false
+	}
+	if filter.Status != "" && !strings.EqualFold(c.Status, filter.Status) {
+		return false
+	}
+	return true
+}
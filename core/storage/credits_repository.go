@@ -0,0 +1,299 @@
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"syntropy-cc/cooperative-grid/core/services/credits"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// CreditsRepository implements credits.Repository
+type CreditsRepository struct {
+	store *Store
+}
+
+var _ credits.Repository = (*CreditsRepository)(nil)
+
+// NewCreditsRepository creates a credits repository on top of the store
+func NewCreditsRepository(store *Store) *CreditsRepository {
+	return &CreditsRepository{store: store}
+}
+
+// GetBalance returns the account balance; unknown accounts have a zero balance
+func (r *CreditsRepository) GetBalance(ctx context.Context, accountID string) (*models.CooperativeCredits, error) {
+	balance := &models.CooperativeCredits{NodeID: accountID}
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, models.CooperativeCredits{}.TableName())
+		if err != nil {
+			return err
+		}
+		_, err = getJSON(b, accountID, balance)
+		return err
+	})
+	return balance, err
+}
+
+// ListBalances returns all stored balances ordered by account
+func (r *CreditsRepository) ListBalances(ctx context.Context) ([]*models.CooperativeCredits, error) {
+	var result []*models.CooperativeCredits
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, models.CooperativeCredits{}.TableName())
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			balance := &models.CooperativeCredits{}
+			if _, err := getJSON(b, string(k), balance); err != nil {
+				return err
+			}
+			result = append(result, balance)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// CreateTransaction stores a new transaction, assigning an ID and timestamps
+func (r *CreditsRepository) CreateTransaction(ctx context.Context, t *models.CooperativeTransaction) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, models.CooperativeTransaction{}.TableName())
+		if err != nil {
+			return err
+		}
+		if t.ID == "" {
+			t.ID = NewID()
+		}
+		now := time.Now().UTC()
+		t.CreatedAt = now
+		t.UpdatedAt = now
+		return putJSON(b, t.ID, t)
+	})
+}
+
+// GetTransaction returns the transaction with the given ID
+func (r *CreditsRepository) GetTransaction(ctx context.Context, id string) (*models.CooperativeTransaction, error) {
+	t := &models.CooperativeTransaction{}
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, models.CooperativeTransaction{}.TableName())
+		if err != nil {
+			return err
+		}
+		found, err := getJSON(b, id, t)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeNotFound,
+				"Transaction not found", fmt.Sprintf("id '%s'", id))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// UpdateTransaction replaces a stored transaction
+func (r *CreditsRepository) UpdateTransaction(ctx context.Context, t *models.CooperativeTransaction) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		return putTransaction(tx, t)
+	})
+}
+
+// ListTransactions returns transactions matching the filter, newest first
+func (r *CreditsRepository) ListTransactions(ctx context.Context, filter *credits.Filter) ([]*models.CooperativeTransaction, error) {
+	if filter == nil {
+		filter = &credits.Filter{}
+	}
+
+	var result []*models.CooperativeTransaction
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, models.CooperativeTransaction{}.TableName())
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			t := &models.CooperativeTransaction{}
+			if _, err := getJSON(b, string(k), t); err != nil {
+				return err
+			}
+			if filter.NodeID != "" && t.FromNodeID != filter.NodeID && t.ToNodeID != filter.NodeID {
+				return nil
+			}
+			if filter.Status != "" && t.Status != filter.Status {
+				return nil
+			}
+			if filter.Type != "" && t.Type != filter.Type {
+				return nil
+			}
+			result = append(result, t)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+
+	return paginate(result, filter.Limit, filter.Offset), nil
+}
+
+// LastEntry returns the head of the ledger, or nil when it is empty
+func (r *CreditsRepository) LastEntry(ctx context.Context) (*credits.Entry, error) {
+	var head *credits.Entry
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, creditLedgerBucket)
+		if err != nil {
+			return err
+		}
+		k, _ := b.Cursor().Last()
+		if k == nil {
+			return nil
+		}
+		head = &credits.Entry{}
+		_, err = getJSON(b, string(k), head)
+		return err
+	})
+	return head, err
+}
+
+// ListEntries returns the whole ledger in sequence order
+func (r *CreditsRepository) ListEntries(ctx context.Context) ([]*credits.Entry, error) {
+	var result []*credits.Entry
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, creditLedgerBucket)
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			e := &credits.Entry{}
+			if _, err := getJSON(b, string(k), e); err != nil {
+				return err
+			}
+			result = append(result, e)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// Apply appends entries, stores balances and saves the transaction in one
+// bbolt transaction, so a posting is either fully applied or not at all
+func (r *CreditsRepository) Apply(ctx context.Context, t *models.CooperativeTransaction, entries []*credits.Entry, balances []*models.CooperativeCredits) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		ledger, err := bucket(tx, creditLedgerBucket)
+		if err != nil {
+			return err
+		}
+
+		var head uint64
+		if k, _ := ledger.Cursor().Last(); k != nil {
+			head = binary.BigEndian.Uint64(k)
+		}
+		if len(entries) > 0 && entries[0].Sequence != head+1 {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeConflict, "Ledger head moved",
+				fmt.Sprintf("expected sequence %d, ledger is at %d", entries[0].Sequence, head))
+		}
+
+		for _, e := range entries {
+			key := sequenceKey(e.Sequence)
+			if ledger.Get(key) != nil {
+				return coreerrors.NewAPIError(coreerrors.ErrCodeConflict, "Ledger entry exists",
+					fmt.Sprintf("sequence %d", e.Sequence))
+			}
+			if err := putJSON(ledger, string(key), e); err != nil {
+				return err
+			}
+		}
+
+		if err := putBalances(tx, balances); err != nil {
+			return err
+		}
+		return putTransaction(tx, t)
+	})
+}
+
+// ReplaceBalances stores the given balances and drops every other account
+func (r *CreditsRepository) ReplaceBalances(ctx context.Context, balances []*models.CooperativeCredits) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, models.CooperativeCredits{}.TableName())
+		if err != nil {
+			return err
+		}
+
+		keep := make(map[string]bool, len(balances))
+		for _, balance := range balances {
+			keep[balance.NodeID] = true
+		}
+
+		var stale [][]byte
+		if err := b.ForEach(func(k, v []byte) error {
+			if !keep[string(k)] {
+				stale = append(stale, append([]byte(nil), k...))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, k := range stale {
+			if err := b.Delete(k); err != nil {
+				return err
+			}
+		}
+
+		return putBalances(tx, balances)
+	})
+}
+
+// putBalances stores balances, keeping CreatedAt from existing records
+func putBalances(tx *bolt.Tx, balances []*models.CooperativeCredits) error {
+	b, err := bucket(tx, models.CooperativeCredits{}.TableName())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, balance := range balances {
+		existing := &models.CooperativeCredits{}
+		found, err := getJSON(b, balance.NodeID, existing)
+		if err != nil {
+			return err
+		}
+		switch {
+		case found:
+			balance.CreatedAt = existing.CreatedAt
+		case balance.CreatedAt.IsZero():
+			balance.CreatedAt = now
+		}
+		balance.UpdatedAt = now
+		if err := putJSON(b, balance.NodeID, balance); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putTransaction replaces an existing transaction
+func putTransaction(tx *bolt.Tx, t *models.CooperativeTransaction) error {
+	b, err := bucket(tx, models.CooperativeTransaction{}.TableName())
+	if err != nil {
+		return err
+	}
+	if b.Get([]byte(t.ID)) == nil {
+		return coreerrors.NewAPIError(coreerrors.ErrCodeNotFound,
+			"Transaction not found", fmt.Sprintf("id '%s'", t.ID))
+	}
+	t.UpdatedAt = time.Now().UTC()
+	return putJSON(b, t.ID, t)
+}
@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"syntropy-cc/cooperative-grid/core/services/governance"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// GovernanceRepository implements governance.Repository
+type GovernanceRepository struct {
+	store *Store
+}
+
+var _ governance.Repository = (*GovernanceRepository)(nil)
+
+// NewGovernanceRepository creates a governance repository on top of the store
+func NewGovernanceRepository(store *Store) *GovernanceRepository {
+	return &GovernanceRepository{store: store}
+}
+
+// CreateProposal stores a new proposal and its rules snapshot
+func (r *GovernanceRepository) CreateProposal(ctx context.Context, p *models.GovernanceProposal, rules *governance.Rules) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		proposals, err := bucket(tx, models.GovernanceProposal{}.TableName())
+		if err != nil {
+			return err
+		}
+		rulesBucket, err := bucket(tx, proposalRulesBucket)
+		if err != nil {
+			return err
+		}
+
+		if p.ID == "" {
+			p.ID = NewID()
+		}
+		if proposals.Get([]byte(p.ID)) != nil {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeConflict,
+				"Proposal already exists", fmt.Sprintf("id '%s'", p.ID))
+		}
+
+		now := time.Now().UTC()
+		p.CreatedAt = now
+		p.UpdatedAt = now
+
+		if err := putJSON(rulesBucket, p.ID, rules); err != nil {
+			return err
+		}
+		return putJSON(proposals, p.ID, p)
+	})
+}
+
+// GetProposal returns the proposal with the given ID
+func (r *GovernanceRepository) GetProposal(ctx context.Context, id string) (*models.GovernanceProposal, error) {
+	var p *models.GovernanceProposal
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		proposals, err := bucket(tx, models.GovernanceProposal{}.TableName())
+		if err != nil {
+			return err
+		}
+		p, err = getProposal(proposals, id)
+		return err
+	})
+	return p, err
+}
+
+// GetRules returns the rules a proposal was created with
+func (r *GovernanceRepository) GetRules(ctx context.Context, proposalID string) (*governance.Rules, error) {
+	rules := &governance.Rules{}
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, proposalRulesBucket)
+		if err != nil {
+			return err
+		}
+		found, err := getJSON(b, proposalID, rules)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeProposalNotFound,
+				"Proposal not found", fmt.Sprintf("no rules for id '%s'", proposalID))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// UpdateProposal replaces an existing proposal
+func (r *GovernanceRepository) UpdateProposal(ctx context.Context, p *models.GovernanceProposal) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		return putProposal(tx, p)
+	})
+}
+
+// ListProposals returns proposals matching the filter, newest first
+func (r *GovernanceRepository) ListProposals(ctx context.Context, filter *governance.Filter) ([]*models.GovernanceProposal, error) {
+	if filter == nil {
+		filter = &governance.Filter{}
+	}
+
+	result, err := r.listProposals(ctx, func(p *models.GovernanceProposal) bool {
+		if filter.Status != "" && !strings.EqualFold(p.Status, filter.Status) {
+			return false
+		}
+		return filter.ProposerID == "" || p.ProposerID == filter.ProposerID
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return paginate(result, filter.Limit, filter.Offset), nil
+}
+
+// ListOpenProposals returns every active or voting proposal
+func (r *GovernanceRepository) ListOpenProposals(ctx context.Context) ([]*models.GovernanceProposal, error) {
+	return r.listProposals(ctx, func(p *models.GovernanceProposal) bool {
+		switch models.GovernanceProposalStatus(p.Status) {
+		case models.ProposalStatusActive, models.ProposalStatusVoting:
+			return true
+		default:
+			return false
+		}
+	})
+}
+
+// CastVote stores a vote and the proposal's counters in one transaction,
+// using the (proposal, voter) index to enforce a single vote per member
+func (r *GovernanceRepository) CastVote(ctx context.Context, v *models.GovernanceVote, p *models.GovernanceProposal) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		votes, err := bucket(tx, models.GovernanceVote{}.TableName())
+		if err != nil {
+			return err
+		}
+		index, err := bucket(tx, voteIndexBucket)
+		if err != nil {
+			return err
+		}
+
+		key := voteKey(v.ProposalID, v.VoterID)
+		if index.Get(key) != nil {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeVoteAlreadyCast, "Vote already cast",
+				fmt.Sprintf("%s already voted on proposal %s", v.VoterID, v.ProposalID))
+		}
+
+		if v.ID == "" {
+			v.ID = NewID()
+		}
+		now := time.Now().UTC()
+		v.CreatedAt = now
+		v.UpdatedAt = now
+
+		if err := index.Put(key, []byte(v.ID)); err != nil {
+			return err
+		}
+		if err := putJSON(votes, v.ID, v); err != nil {
+			return err
+		}
+		return putProposal(tx, p)
+	})
+}
+
+// ListVotes returns the votes cast on a proposal in the order they were cast
+func (r *GovernanceRepository) ListVotes(ctx context.Context, proposalID string) ([]*models.GovernanceVote, error) {
+	var result []*models.GovernanceVote
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		votes, err := bucket(tx, models.GovernanceVote{}.TableName())
+		if err != nil {
+			return err
+		}
+		index, err := bucket(tx, voteIndexBucket)
+		if err != nil {
+			return err
+		}
+
+		prefix := []byte(proposalID + "/")
+		c := index.Cursor()
+		for k, id := c.Seek(prefix); k != nil && strings.HasPrefix(string(k), string(prefix)); k, id = c.Next() {
+			v := &models.GovernanceVote{}
+			found, err := getJSON(votes, string(id), v)
+			if err != nil {
+				return err
+			}
+			if found {
+				result = append(result, v)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// listProposals returns every proposal accepted by keep, newest first
+func (r *GovernanceRepository) listProposals(ctx context.Context, keep func(*models.GovernanceProposal) bool) ([]*models.GovernanceProposal, error) {
+	var result []*models.GovernanceProposal
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		proposals, err := bucket(tx, models.GovernanceProposal{}.TableName())
+		if err != nil {
+			return err
+		}
+		return proposals.ForEach(func(k, v []byte) error {
+			p, err := getProposal(proposals, string(k))
+			if err != nil {
+				return err
+			}
+			if keep(p) {
+				result = append(result, p)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// getProposal decodes a proposal, returning ErrCodeProposalNotFound when absent
+func getProposal(proposals *bolt.Bucket, id string) (*models.GovernanceProposal, error) {
+	p := &models.GovernanceProposal{}
+	found, err := getJSON(proposals, id, p)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeProposalNotFound,
+			"Proposal not found", fmt.Sprintf("id '%s'", id))
+	}
+	return p, nil
+}
+
+// putProposal replaces an existing proposal, keeping its creation time
+func putProposal(tx *bolt.Tx, p *models.GovernanceProposal) error {
+	proposals, err := bucket(tx, models.GovernanceProposal{}.TableName())
+	if err != nil {
+		return err
+	}
+	existing, err := getProposal(proposals, p.ID)
+	if err != nil {
+		return err
+	}
+	p.CreatedAt = existing.CreatedAt
+	p.UpdatedAt = time.Now().UTC()
+	return putJSON(proposals, p.ID, p)
+}
+
+// voteKey returns the unique index key for a member's vote on a proposal
+func voteKey(proposalID, voterID string) []byte {
+	return []byte(proposalID + "/" + voterID)
+}
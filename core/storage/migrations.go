@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// Bucket names for indexes and auxiliary data
+const (
+	migrationsBucket      = "schema_migrations"
+	nodeNameIndexBucket   = "nodes_name_idx"
+	nodeTransitionsBucket = "node_transitions"
+	creditLedgerBucket    = "cooperative_ledger"
+	voteIndexBucket       = "governance_votes_idx"
+	proposalRulesBucket   = "governance_rules"
+	reputationEventBucket = "reputation_events"
+	reputationHistBucket  = "reputation_history"
+	networkPeersBucket    = "network_peers"
+	networkConfigsBucket  = "network_configs"
+)
+
+// Migration is a single, ordered schema change
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *bolt.Tx) error
+}
+
+// migrations lists every schema change in order. Append new entries,
+// never edit or reorder existing ones.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "create model buckets",
+		Up: createBuckets(
+			models.Node{}.TableName(),
+			models.Container{}.TableName(),
+			models.NetworkRoute{}.TableName(),
+			models.ServiceMesh{}.TableName(),
+			models.CooperativeCredits{}.TableName(),
+			models.CooperativeTransaction{}.TableName(),
+			models.GovernanceProposal{}.TableName(),
+			models.GovernanceVote{}.TableName(),
+			models.NodeReputation{}.TableName(),
+		),
+	},
+	{
+		Version:     2,
+		Description: "create node name index and transition history",
+		Up:          createBuckets(nodeNameIndexBucket, nodeTransitionsBucket),
+	},
+	{
+		Version:     3,
+		Description: "create cooperative credit ledger",
+		Up:          createBuckets(creditLedgerBucket),
+	},
+	{
+		Version:     4,
+		Description: "create governance vote index and proposal rules",
+		Up:          createBuckets(voteIndexBucket, proposalRulesBucket),
+	},
+	{
+		Version:     5,
+		Description: "create reputation events and score history",
+		Up:          createBuckets(reputationEventBucket, reputationHistBucket),
+	},
+	{
+		Version:     6,
+		Description: "create network peers and applied configs",
+		Up:          createBuckets(networkPeersBucket, networkConfigsBucket),
+	},
+}
+
+// createBuckets returns a migration step that creates the given buckets
+func createBuckets(names ...string) func(tx *bolt.Tx) error {
+	return func(tx *bolt.Tx) error {
+		for _, name := range names {
+			if _, err := tx.CreateBucketIfNotExists([]byte(name)); err != nil {
+				return fmt.Errorf("failed to create bucket %s: %w", name, err)
+			}
+		}
+		return nil
+	}
+}
+
+// appliedMigration is the record kept for each applied migration
+type appliedMigration struct {
+	Version     int       `json:"version"`
+	Description string    `json:"description"`
+	AppliedAt   time.Time `json:"applied_at"`
+}
+
+// Migrate applies all pending migrations, each in its own transaction
+func (s *Store) Migrate() error {
+	current, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+
+		err := s.db.Update(func(tx *bolt.Tx) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			b, err := tx.CreateBucketIfNotExists([]byte(migrationsBucket))
+			if err != nil {
+				return err
+			}
+			return putJSON(b, string(sequenceKey(uint64(m.Version))), appliedMigration{
+				Version:     m.Version,
+				Description: m.Description,
+				AppliedAt:   time.Now().UTC(),
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", m.Version, m.Description, err)
+		}
+	}
+
+	return nil
+}
+
+// SchemaVersion returns the highest applied migration version
+func (s *Store) SchemaVersion() (int, error) {
+	version := 0
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(migrationsBucket))
+		if b == nil {
+			return nil
+		}
+		k, _ := b.Cursor().Last()
+		if k == nil {
+			return nil
+		}
+		var applied appliedMigration
+		if _, err := getJSON(b, string(k), &applied); err != nil {
+			return err
+		}
+		version = applied.Version
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version, nil
+}
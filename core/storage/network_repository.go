@@ -0,0 +1,291 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"syntropy-cc/cooperative-grid/core/services/network"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// NetworkRepository implements network.Repository
+type NetworkRepository struct {
+	store *Store
+}
+
+var _ network.Repository = (*NetworkRepository)(nil)
+
+// NewNetworkRepository creates a network repository on top of the store
+func NewNetworkRepository(store *Store) *NetworkRepository {
+	return &NetworkRepository{store: store}
+}
+
+// CreateRoute inserts a new route, refusing a second route with the same
+// source and destination
+func (r *NetworkRepository) CreateRoute(ctx context.Context, route *models.NetworkRoute) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		routes, err := bucket(tx, models.NetworkRoute{}.TableName())
+		if err != nil {
+			return err
+		}
+
+		err = routes.ForEach(func(k, v []byte) error {
+			existing, err := getRoute(routes, string(k))
+			if err != nil {
+				return err
+			}
+			if existing.SourceNodeID == route.SourceNodeID && existing.DestinationNodeID == route.DestinationNodeID {
+				return coreerrors.NewAPIError(coreerrors.ErrCodeNetworkRouteExists, "Route already exists",
+					fmt.Sprintf("%s -> %s is route %s", route.SourceNodeID, route.DestinationNodeID, existing.ID))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if route.ID == "" {
+			route.ID = NewID()
+		}
+		now := time.Now().UTC()
+		if route.CreatedAt.IsZero() {
+			route.CreatedAt = now
+		}
+		route.UpdatedAt = now
+		if route.Status == "" {
+			route.Status = string(models.NetworkRouteStatusActive)
+		}
+		return putJSON(routes, route.ID, route)
+	})
+}
+
+// GetRoute returns the route with the given ID
+func (r *NetworkRepository) GetRoute(ctx context.Context, id string) (*models.NetworkRoute, error) {
+	var route *models.NetworkRoute
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		routes, err := bucket(tx, models.NetworkRoute{}.TableName())
+		if err != nil {
+			return err
+		}
+		route, err = getRoute(routes, id)
+		return err
+	})
+	return route, err
+}
+
+// ListRoutes returns routes ordered by creation time, honoring the filter
+func (r *NetworkRepository) ListRoutes(ctx context.Context, filter *network.RouteFilter) ([]*models.NetworkRoute, error) {
+	if filter == nil {
+		filter = &network.RouteFilter{}
+	}
+
+	var result []*models.NetworkRoute
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		routes, err := bucket(tx, models.NetworkRoute{}.TableName())
+		if err != nil {
+			return err
+		}
+		return routes.ForEach(func(k, v []byte) error {
+			route, err := getRoute(routes, string(k))
+			if err != nil {
+				return err
+			}
+			if filter.NodeID != "" && route.SourceNodeID != filter.NodeID && route.DestinationNodeID != filter.NodeID {
+				return nil
+			}
+			if filter.Status != "" && !strings.EqualFold(route.Status, filter.Status) {
+				return nil
+			}
+			result = append(result, route)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return paginate(result, filter.Limit, filter.Offset), nil
+}
+
+// UpdateRoute replaces an existing route
+func (r *NetworkRepository) UpdateRoute(ctx context.Context, route *models.NetworkRoute) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		routes, err := bucket(tx, models.NetworkRoute{}.TableName())
+		if err != nil {
+			return err
+		}
+		existing, err := getRoute(routes, route.ID)
+		if err != nil {
+			return err
+		}
+		route.CreatedAt = existing.CreatedAt
+		route.UpdatedAt = time.Now().UTC()
+		return putJSON(routes, route.ID, route)
+	})
+}
+
+// DeleteRoute removes a route
+func (r *NetworkRepository) DeleteRoute(ctx context.Context, id string) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		routes, err := bucket(tx, models.NetworkRoute{}.TableName())
+		if err != nil {
+			return err
+		}
+		if _, err := getRoute(routes, id); err != nil {
+			return err
+		}
+		return routes.Delete([]byte(id))
+	})
+}
+
+// GetMesh returns the mesh with the given name, or nil if there is none
+func (r *NetworkRepository) GetMesh(ctx context.Context, name string) (*models.ServiceMesh, error) {
+	var mesh *models.ServiceMesh
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		meshes, err := bucket(tx, models.ServiceMesh{}.TableName())
+		if err != nil {
+			return err
+		}
+		return meshes.ForEach(func(k, v []byte) error {
+			m := &models.ServiceMesh{}
+			if _, err := getJSON(meshes, string(k), m); err != nil {
+				return err
+			}
+			if m.Name == name {
+				mesh = m
+			}
+			return nil
+		})
+	})
+	return mesh, err
+}
+
+// SaveMesh inserts or replaces a mesh, assigning an ID and timestamps when missing
+func (r *NetworkRepository) SaveMesh(ctx context.Context, m *models.ServiceMesh) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		meshes, err := bucket(tx, models.ServiceMesh{}.TableName())
+		if err != nil {
+			return err
+		}
+		if m.ID == "" {
+			m.ID = NewID()
+		}
+		now := time.Now().UTC()
+		if m.CreatedAt.IsZero() {
+			m.CreatedAt = now
+		}
+		m.UpdatedAt = now
+		return putJSON(meshes, m.ID, m)
+	})
+}
+
+// ListPeers returns every node's overlay membership
+func (r *NetworkRepository) ListPeers(ctx context.Context) ([]*network.Peer, error) {
+	var result []*network.Peer
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		peers, err := bucket(tx, networkPeersBucket)
+		if err != nil {
+			return err
+		}
+		return peers.ForEach(func(k, v []byte) error {
+			p := &network.Peer{}
+			if _, err := getJSON(peers, string(k), p); err != nil {
+				return err
+			}
+			result = append(result, p)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// SavePeer stores a node's overlay membership
+func (r *NetworkRepository) SavePeer(ctx context.Context, p *network.Peer) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		peers, err := bucket(tx, networkPeersBucket)
+		if err != nil {
+			return err
+		}
+		return putJSON(peers, p.NodeID, p)
+	})
+}
+
+// DeletePeer releases a node's overlay address
+func (r *NetworkRepository) DeletePeer(ctx context.Context, nodeID string) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		peers, err := bucket(tx, networkPeersBucket)
+		if err != nil {
+			return err
+		}
+		return peers.Delete([]byte(nodeID))
+	})
+}
+
+// ListAppliedConfigs returns the last config applied to each node
+func (r *NetworkRepository) ListAppliedConfigs(ctx context.Context) ([]*network.AppliedConfig, error) {
+	var result []*network.AppliedConfig
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		configs, err := bucket(tx, networkConfigsBucket)
+		if err != nil {
+			return err
+		}
+		return configs.ForEach(func(k, v []byte) error {
+			c := &network.AppliedConfig{}
+			if _, err := getJSON(configs, string(k), c); err != nil {
+				return err
+			}
+			result = append(result, c)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// SaveAppliedConfig records the config applied to a node
+func (r *NetworkRepository) SaveAppliedConfig(ctx context.Context, c *network.AppliedConfig) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		configs, err := bucket(tx, networkConfigsBucket)
+		if err != nil {
+			return err
+		}
+		return putJSON(configs, c.NodeID, c)
+	})
+}
+
+// DeleteAppliedConfig forgets the config applied to a node
+func (r *NetworkRepository) DeleteAppliedConfig(ctx context.Context, nodeID string) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		configs, err := bucket(tx, networkConfigsBucket)
+		if err != nil {
+			return err
+		}
+		return configs.Delete([]byte(nodeID))
+	})
+}
+
+// getRoute decodes a route, returning ErrCodeNetworkRouteNotFound when absent
+func getRoute(routes *bolt.Bucket, id string) (*models.NetworkRoute, error) {
+	route := &models.NetworkRoute{}
+	found, err := getJSON(routes, id, route)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeNetworkRouteNotFound,
+			"Route not found", fmt.Sprintf("id '%s'", id))
+	}
+	return route, nil
+}
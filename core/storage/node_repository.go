@@ -0,0 +1,310 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"syntropy-cc/cooperative-grid/core/services/node"
+	"syntropy-cc/cooperative-grid/core/types/constants"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// NodeRepository implements node.Repository and node.TransitionStore
+type NodeRepository struct {
+	store *Store
+}
+
+var (
+	_ node.Repository      = (*NodeRepository)(nil)
+	_ node.TransitionStore = (*NodeRepository)(nil)
+)
+
+// NewNodeRepository creates a node repository on top of the store
+func NewNodeRepository(store *Store) *NodeRepository {
+	return &NodeRepository{store: store}
+}
+
+// Create inserts a new node, assigning an ID and timestamps when missing
+func (r *NodeRepository) Create(ctx context.Context, n *models.Node) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		nodes, names, err := nodeBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		if n.ID == "" {
+			n.ID = NewID()
+		}
+		if nodes.Get([]byte(n.ID)) != nil {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeNodeAlreadyExists,
+				"Node already exists", fmt.Sprintf("id '%s'", n.ID))
+		}
+		if names.Get(nameKey(n.Name)) != nil {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeNodeAlreadyExists,
+				"Node already exists", fmt.Sprintf("name '%s'", n.Name))
+		}
+
+		now := time.Now().UTC()
+		if n.CreatedAt.IsZero() {
+			n.CreatedAt = now
+		}
+		n.UpdatedAt = now
+		if n.Status == "" {
+			n.Status = string(models.NodeStatusCreating)
+		}
+
+		if err := names.Put(nameKey(n.Name), []byte(n.ID)); err != nil {
+			return err
+		}
+		return putJSON(nodes, n.ID, n)
+	})
+}
+
+// GetByID returns the node with the given ID
+func (r *NodeRepository) GetByID(ctx context.Context, id string) (*models.Node, error) {
+	var n *models.Node
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		nodes, _, err := nodeBuckets(tx)
+		if err != nil {
+			return err
+		}
+		n, err = getNode(nodes, id)
+		return err
+	})
+	return n, err
+}
+
+// GetByName returns the node with the given name
+func (r *NodeRepository) GetByName(ctx context.Context, name string) (*models.Node, error) {
+	var n *models.Node
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		nodes, names, err := nodeBuckets(tx)
+		if err != nil {
+			return err
+		}
+		id := names.Get(nameKey(name))
+		if id == nil {
+			return coreerrors.NewAPIError(coreerrors.ErrCodeNodeNotFound,
+				"Node not found", fmt.Sprintf("name '%s'", name))
+		}
+		n, err = getNode(nodes, string(id))
+		return err
+	})
+	return n, err
+}
+
+// List returns nodes ordered by creation time, honoring the filter.
+// Name matches case-insensitively as a substring; Limit defaults to
+// constants.DefaultPageSize and is capped at constants.MaxPageSize.
+func (r *NodeRepository) List(ctx context.Context, filter *node.Filter) ([]*models.Node, error) {
+	if filter == nil {
+		filter = &node.Filter{}
+	}
+
+	var result []*models.Node
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		nodes, _, err := nodeBuckets(tx)
+		if err != nil {
+			return err
+		}
+		return nodes.ForEach(func(k, v []byte) error {
+			n, err := getNode(nodes, string(k))
+			if err != nil {
+				return err
+			}
+			if matchesFilter(n, filter) {
+				result = append(result, n)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CreatedAt.Equal(result[j].CreatedAt) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+
+	return paginate(result, filter.Limit, filter.Offset), nil
+}
+
+// Update replaces an existing node, keeping the name index consistent
+func (r *NodeRepository) Update(ctx context.Context, n *models.Node) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		nodes, names, err := nodeBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		existing, err := getNode(nodes, n.ID)
+		if err != nil {
+			return err
+		}
+
+		if existing.Name != n.Name {
+			if owner := names.Get(nameKey(n.Name)); owner != nil && string(owner) != n.ID {
+				return coreerrors.NewAPIError(coreerrors.ErrCodeNodeAlreadyExists,
+					"Node already exists", fmt.Sprintf("name '%s'", n.Name))
+			}
+			if err := names.Delete(nameKey(existing.Name)); err != nil {
+				return err
+			}
+			if err := names.Put(nameKey(n.Name), []byte(n.ID)); err != nil {
+				return err
+			}
+		}
+
+		n.CreatedAt = existing.CreatedAt
+		n.UpdatedAt = time.Now().UTC()
+		return putJSON(nodes, n.ID, n)
+	})
+}
+
+// Delete removes a node together with its name index entry and transition history
+func (r *NodeRepository) Delete(ctx context.Context, id string) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		nodes, names, err := nodeBuckets(tx)
+		if err != nil {
+			return err
+		}
+
+		existing, err := getNode(nodes, id)
+		if err != nil {
+			return err
+		}
+
+		if err := names.Delete(nameKey(existing.Name)); err != nil {
+			return err
+		}
+		if err := nodes.Delete([]byte(id)); err != nil {
+			return err
+		}
+
+		history, err := bucket(tx, nodeTransitionsBucket)
+		if err != nil {
+			return err
+		}
+		if history.Bucket([]byte(id)) != nil {
+			return history.DeleteBucket([]byte(id))
+		}
+		return nil
+	})
+}
+
+// RecordTransition appends a status transition to the node's history
+func (r *NodeRepository) RecordTransition(ctx context.Context, t *node.Transition) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		history, err := bucket(tx, nodeTransitionsBucket)
+		if err != nil {
+			return err
+		}
+		b, err := history.CreateBucketIfNotExists([]byte(t.NodeID))
+		if err != nil {
+			return err
+		}
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+		return putJSON(b, string(sequenceKey(seq)), t)
+	})
+}
+
+// ListTransitions returns the node's status transitions in the order they were recorded
+func (r *NodeRepository) ListTransitions(ctx context.Context, nodeID string) ([]*node.Transition, error) {
+	var result []*node.Transition
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		history, err := bucket(tx, nodeTransitionsBucket)
+		if err != nil {
+			return err
+		}
+		b := history.Bucket([]byte(nodeID))
+		if b == nil {
+			return nil
+		}
+		return b.ForEach(func(k, v []byte) error {
+			t := &node.Transition{}
+			if _, err := getJSON(b, string(k), t); err != nil {
+				return err
+			}
+			result = append(result, t)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// nodeBuckets returns the node bucket and the unique name index
+func nodeBuckets(tx *bolt.Tx) (*bolt.Bucket, *bolt.Bucket, error) {
+	nodes, err := bucket(tx, models.Node{}.TableName())
+	if err != nil {
+		return nil, nil, err
+	}
+	names, err := bucket(tx, nodeNameIndexBucket)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nodes, names, nil
+}
+
+// getNode decodes a node, returning ErrCodeNodeNotFound when absent
+func getNode(nodes *bolt.Bucket, id string) (*models.Node, error) {
+	n := &models.Node{}
+	found, err := getJSON(nodes, id, n)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, coreerrors.NewAPIError(coreerrors.ErrCodeNodeNotFound,
+			"Node not found", fmt.Sprintf("id '%s'", id))
+	}
+	return n, nil
+}
+
+// nameKey returns the unique index key for a node name
+func nameKey(name string) []byte {
+	return []byte(name)
+}
+
+// matchesFilter reports whether a node satisfies the status and name filters
+func matchesFilter(n *models.Node, filter *node.Filter) bool {
+	if filter.Status != "" && !strings.EqualFold(n.Status, filter.Status) {
+		return false
+	}
+	if filter.Name != "" && !strings.Contains(strings.ToLower(n.Name), strings.ToLower(filter.Name)) {
+		return false
+	}
+	return true
+}
+
+// paginate applies offset and limit, clamping the page size
+func paginate[T any](items []T, limit, offset int) []T {
+	if limit <= 0 {
+		limit = constants.DefaultPageSize
+	}
+	if limit > constants.MaxPageSize {
+		limit = constants.MaxPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(items) {
+		return []T{}
+	}
+
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"syntropy-cc/cooperative-grid/core/services/reputation"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+// ReputationRepository implements reputation.Repository
+type ReputationRepository struct {
+	store *Store
+}
+
+var _ reputation.Repository = (*ReputationRepository)(nil)
+
+// NewReputationRepository creates a reputation repository on top of the store
+func NewReputationRepository(store *Store) *ReputationRepository {
+	return &ReputationRepository{store: store}
+}
+
+// GetReputation returns the reputation of a node, or nil when none is recorded
+func (r *ReputationRepository) GetReputation(ctx context.Context, nodeID string) (*models.NodeReputation, error) {
+	var rep *models.NodeReputation
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, models.NodeReputation{}.TableName())
+		if err != nil {
+			return err
+		}
+		stored := &models.NodeReputation{}
+		found, err := getJSON(b, nodeID, stored)
+		if found && err == nil {
+			rep = stored
+		}
+		return err
+	})
+	return rep, err
+}
+
+// ListReputations returns every recorded reputation ordered by node ID
+func (r *ReputationRepository) ListReputations(ctx context.Context) ([]*models.NodeReputation, error) {
+	var result []*models.NodeReputation
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, models.NodeReputation{}.TableName())
+		if err != nil {
+			return err
+		}
+		return b.ForEach(func(k, v []byte) error {
+			rep := &models.NodeReputation{}
+			if _, err := getJSON(b, string(k), rep); err != nil {
+				return err
+			}
+			result = append(result, rep)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// SaveScore creates or replaces the reputation of a node and appends the
+// change to its history
+func (r *ReputationRepository) SaveScore(ctx context.Context, rep *models.NodeReputation, change *reputation.ScoreChange) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		b, err := bucket(tx, models.NodeReputation{}.TableName())
+		if err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		existing := &models.NodeReputation{}
+		found, err := getJSON(b, rep.NodeID, existing)
+		if err != nil {
+			return err
+		}
+		if found {
+			rep.CreatedAt = existing.CreatedAt
+		} else {
+			rep.CreatedAt = now
+		}
+		rep.UpdatedAt = now
+		if err := putJSON(b, rep.NodeID, rep); err != nil {
+			return err
+		}
+
+		return appendNested(tx, reputationHistBucket, rep.NodeID, change)
+	})
+}
+
+// ListChanges returns a node's score changes in the order they were recorded
+func (r *ReputationRepository) ListChanges(ctx context.Context, nodeID string) ([]*reputation.ScoreChange, error) {
+	var result []*reputation.ScoreChange
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		return forEachNested(tx, reputationHistBucket, nodeID, func(b *bolt.Bucket, k []byte) error {
+			change := &reputation.ScoreChange{}
+			if _, err := getJSON(b, string(k), change); err != nil {
+				return err
+			}
+			result = append(result, change)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// RecordEvent appends an event to the node's event log
+func (r *ReputationRepository) RecordEvent(ctx context.Context, e *reputation.Event) error {
+	return r.store.Update(ctx, func(tx *bolt.Tx) error {
+		return appendNested(tx, reputationEventBucket, e.NodeID, e)
+	})
+}
+
+// ListEvents returns the node's recorded events at or after since
+func (r *ReputationRepository) ListEvents(ctx context.Context, nodeID string, since time.Time) ([]*reputation.Event, error) {
+	var result []*reputation.Event
+	err := r.store.View(ctx, func(tx *bolt.Tx) error {
+		return forEachNested(tx, reputationEventBucket, nodeID, func(b *bolt.Bucket, k []byte) error {
+			e := &reputation.Event{}
+			if _, err := getJSON(b, string(k), e); err != nil {
+				return err
+			}
+			if !e.At.Before(since) {
+				result = append(result, e)
+			}
+			return nil
+		})
+	})
+	return result, err
+}
+
+// appendNested stores v under the next sequence of the per-node bucket inside parent
+func appendNested(tx *bolt.Tx, parent, nodeID string, v interface{}) error {
+	p, err := bucket(tx, parent)
+	if err != nil {
+		return err
+	}
+	b, err := p.CreateBucketIfNotExists([]byte(nodeID))
+	if err != nil {
+		return err
+	}
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	return putJSON(b, string(sequenceKey(seq)), v)
+}
+
+// forEachNested calls fn for every key of the per-node bucket inside parent, in order
+func forEachNested(tx *bolt.Tx, parent, nodeID string, fn func(b *bolt.Bucket, k []byte) error) error {
+	p, err := bucket(tx, parent)
+	if err != nil {
+		return err
+	}
+	b := p.Bucket([]byte(nodeID))
+	if b == nil {
+		return nil
+	}
+	return b.ForEach(func(k, v []byte) error {
+		return fn(b, k)
+	})
+}
@@ -0,0 +1,120 @@
+// Package storage provides an embedded, on-disk persistence layer for the
+// core models backed by bbolt. Each model is stored as JSON in the bucket
+// named after its TableName.
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// DefaultFileName is the database file name used under the Syntropy directory
+const DefaultFileName = "syntropy.db"
+
+// Store wraps an embedded bbolt database
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens (or creates) the database at path and applies pending migrations
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	store := &Store{db: db}
+	if err := store.Migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// Close closes the underlying database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Path returns the database file path
+func (s *Store) Path() string {
+	return s.db.Path()
+}
+
+// Update runs fn inside a read-write transaction
+func (s *Store) Update(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.Update(fn)
+}
+
+// View runs fn inside a read-only transaction
+func (s *Store) View(ctx context.Context, fn func(tx *bolt.Tx) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return s.db.View(fn)
+}
+
+// bucket returns a bucket created by the migrations
+func bucket(tx *bolt.Tx, name string) (*bolt.Bucket, error) {
+	b := tx.Bucket([]byte(name))
+	if b == nil {
+		return nil, fmt.Errorf("bucket %s not found, database not migrated", name)
+	}
+	return b, nil
+}
+
+// putJSON stores v as JSON under key
+func putJSON(b *bolt.Bucket, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+	return b.Put([]byte(key), data)
+}
+
+// getJSON decodes the value stored under key into v and reports whether it existed
+func getJSON(b *bolt.Bucket, key string, v interface{}) (bool, error) {
+	data := b.Get([]byte(key))
+	if data == nil {
+		return false, nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return true, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// sequenceKey encodes a bucket sequence as a sortable key
+func sequenceKey(seq uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	return key
+}
+
+// NewID generates a random RFC 4122 version 4 UUID, matching the
+// gen_random_uuid() default declared on the models
+func NewID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,157 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/services/node"
+	coreerrors "syntropy-cc/cooperative-grid/core/types/errors"
+	"syntropy-cc/cooperative-grid/core/types/models"
+)
+
+func openTestStore(t *testing.T) *Store {
+	t.Helper()
+	store, err := Open(filepath.Join(t.TempDir(), DefaultFileName))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestMigrateIsIdempotent(t *testing.T) {
+	store := openTestStore(t)
+
+	if err := store.Migrate(); err != nil {
+		t.Fatalf("second Migrate: %v", err)
+	}
+
+	version, err := store.SchemaVersion()
+	if err != nil {
+		t.Fatalf("SchemaVersion: %v", err)
+	}
+	if version != migrations[len(migrations)-1].Version {
+		t.Fatalf("schema version = %d, want %d", version, migrations[len(migrations)-1].Version)
+	}
+}
+
+func TestNodeRepositoryUniqueName(t *testing.T) {
+	repo := NewNodeRepository(openTestStore(t))
+	ctx := context.Background()
+
+	first := &models.Node{Name: "node-01"}
+	if err := repo.Create(ctx, first); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if first.ID == "" || first.CreatedAt.IsZero() {
+		t.Fatal("Create should assign an ID and timestamps")
+	}
+
+	err := repo.Create(ctx, &models.Node{Name: "node-01"})
+	if coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNodeAlreadyExists {
+		t.Fatalf("duplicate create: got %v", err)
+	}
+
+	second := &models.Node{Name: "node-02"}
+	if err := repo.Create(ctx, second); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	second.Name = "node-01"
+	if err := repo.Update(ctx, second); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNodeAlreadyExists {
+		t.Fatalf("rename onto existing name: got %v", err)
+	}
+
+	second.Name = "node-03"
+	if err := repo.Update(ctx, second); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if _, err := repo.GetByName(ctx, "node-02"); coreerrors.GetErrorCode(err) != coreerrors.ErrCodeNodeNotFound {
+		t.Fatalf("old name should be released, got %v", err)
+	}
+	if err := repo.Create(ctx, &models.Node{Name: "node-02"}); err != nil {
+		t.Fatalf("reuse released name: %v", err)
+	}
+}
+
+func TestNodeRepositoryListFilter(t *testing.T) {
+	repo := NewNodeRepository(openTestStore(t))
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		status := string(models.NodeStatusRunning)
+		if i%2 == 1 {
+			status = string(models.NodeStatusStopped)
+		}
+		if err := repo.Create(ctx, &models.Node{Name: fmt.Sprintf("site-a-%d", i), Status: status}); err != nil {
+			t.Fatalf("Create: %v", err)
+		}
+	}
+	if err := repo.Create(ctx, &models.Node{Name: "site-b-0", Status: "running"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	running, err := repo.List(ctx, &node.Filter{Status: "running"})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(running) != 4 {
+		t.Fatalf("running nodes = %d, want 4", len(running))
+	}
+
+	page, err := repo.List(ctx, &node.Filter{Name: "SITE-A", Limit: 2, Offset: 1})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(page) != 2 || page[0].Name != "site-a-1" || page[1].Name != "site-a-2" {
+		t.Fatalf("unexpected page: %v", page)
+	}
+
+	empty, err := repo.List(ctx, &node.Filter{Offset: 100})
+	if err != nil || len(empty) != 0 {
+		t.Fatalf("offset past end: %v %v", empty, err)
+	}
+}
+
+func TestNodeRepositoryPersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), DefaultFileName)
+	ctx := context.Background()
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	repo := NewNodeRepository(store)
+	n := &models.Node{Name: "node-01"}
+	if err := repo.Create(ctx, n); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := repo.RecordTransition(ctx, &node.Transition{NodeID: n.ID, To: models.NodeStatusCreating, Reason: "created"}); err != nil {
+		t.Fatalf("RecordTransition: %v", err)
+	}
+	store.Close()
+
+	store, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer store.Close()
+	repo = NewNodeRepository(store)
+
+	got, err := repo.GetByName(ctx, "node-01")
+	if err != nil || got.ID != n.ID {
+		t.Fatalf("GetByName after reopen: %v %v", got, err)
+	}
+	history, err := repo.ListTransitions(ctx, n.ID)
+	if err != nil || len(history) != 1 {
+		t.Fatalf("ListTransitions after reopen: %v %v", history, err)
+	}
+
+	if err := repo.Delete(ctx, n.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if history, _ := repo.ListTransitions(ctx, n.ID); len(history) != 0 {
+		t.Fatal("Delete should drop transition history")
+	}
+}
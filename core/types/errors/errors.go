@@ -45,6 +45,20 @@ const (
 	ErrCodeUSBDeviceNotFound ErrorCode = "USB_DEVICE_NOT_FOUND"
 	ErrCodeUSBDeviceInUse    ErrorCode = "USB_DEVICE_IN_USE"
 	ErrCodeUSBFormatFailed   ErrorCode = "USB_FORMAT_FAILED"
+
+	// ISO errors
+	ErrCodeISODownloadFailed   ErrorCode = "ISO_DOWNLOAD_FAILED"
+	ErrCodeISOChecksumMismatch ErrorCode = "ISO_CHECKSUM_MISMATCH"
+	ErrCodeISOSignatureInvalid ErrorCode = "ISO_SIGNATURE_INVALID"
+
+	// Certificate authority errors
+	ErrCodeCANotInitialized     ErrorCode = "CA_NOT_INITIALIZED"
+	ErrCodeCAAlreadyInitialized ErrorCode = "CA_ALREADY_INITIALIZED"
+	ErrCodeCertificateNotFound  ErrorCode = "CERTIFICATE_NOT_FOUND"
+	ErrCodeCertificateRevoked   ErrorCode = "CERTIFICATE_REVOKED"
+
+	// Offline bundle errors
+	ErrCodeBundleInvalid ErrorCode = "BUNDLE_INVALID"
 )
 
 // APIError represents an API error with additional context
@@ -91,7 +105,8 @@ func WrapAPIError(err error, code ErrorCode, message string) *APIError {
 func getHTTPStatus(code ErrorCode) int {
 	switch code {
 	case ErrCodeNotFound, ErrCodeNodeNotFound, ErrCodeContainerNotFound, 
-		 ErrCodeNetworkRouteNotFound, ErrCodeProposalNotFound, ErrCodeUSBDeviceNotFound:
+		 ErrCodeNetworkRouteNotFound, ErrCodeProposalNotFound, ErrCodeUSBDeviceNotFound,
+		 ErrCodeCertificateNotFound:
 		return http.StatusNotFound
 		
 	case ErrCodeInvalidInput, ErrCodeNodeInvalidStatus, ErrCodeContainerInvalidStatus:
@@ -104,17 +119,22 @@ func getHTTPStatus(code ErrorCode) int {
 		return http.StatusForbidden
 		
 	case ErrCodeConflict, ErrCodeNodeAlreadyExists, ErrCodeContainerAlreadyExists, 
-		 ErrCodeNetworkRouteExists, ErrCodeVoteAlreadyCast, ErrCodeUSBDeviceInUse:
+		 ErrCodeNetworkRouteExists, ErrCodeVoteAlreadyCast, ErrCodeUSBDeviceInUse,
+		 ErrCodeCAAlreadyInitialized, ErrCodeCertificateRevoked:
 		return http.StatusConflict
 		
 	case ErrCodeTimeout:
 		return http.StatusRequestTimeout
 		
 	case ErrCodeInsufficientCredits, ErrCodeTransactionFailed, ErrCodeUSBFormatFailed,
-		 ErrCodeNodeCreationFailed, ErrCodeContainerDeployFailed:
+		 ErrCodeNodeCreationFailed, ErrCodeContainerDeployFailed,
+		 ErrCodeISOChecksumMismatch, ErrCodeISOSignatureInvalid, ErrCodeBundleInvalid:
 		return http.StatusUnprocessableEntity
+
+	case ErrCodeISODownloadFailed:
+		return http.StatusBadGateway
 		
-	case ErrCodeNetworkMeshDisabled:
+	case ErrCodeNetworkMeshDisabled, ErrCodeCANotInitialized:
 		return http.StatusServiceUnavailable
 		
 	default:
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package usb
+
+import "syscall"
+
+// isBlockDevice verifica se o caminho é um dispositivo de bloco
+func isBlockDevice(path string) (bool, error) {
+	var stat syscall.Stat_t
+	if err := syscall.Stat(path, &stat); err != nil {
+		return false, err
+	}
+	return stat.Mode&syscall.S_IFMT == syscall.S_IFBLK, nil
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package usb
+
+// isBlockDevice não se aplica ao Windows, onde discos são PHYSICALDRIVEn
+func isBlockDevice(path string) (bool, error) {
+	return false, nil
+}
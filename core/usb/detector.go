@@ -1,44 +1,99 @@
 package usb
 
 import (
-	"bufio"
-	"encoding/json"
 	"fmt"
 	"os"
-	"os/exec"
-	"regexp"
+	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
-	"syscall"
 )
 
 // USBDevice representa um dispositivo USB detectado
 type USBDevice struct {
-	Path      string `json:"path"`
-	Size      string `json:"size"`
-	SizeGB    int    `json:"size_gb"`
-	Model     string `json:"model"`
-	Vendor    string `json:"vendor"`
-	Serial    string `json:"serial"`
-	Removable bool   `json:"removable"`
-	Platform  string `json:"platform"`
+	Path string `json:"path"`
+	// ID identifica o disco de forma estável: o link em /dev/disk/by-id no
+	// Linux e o UniqueId do disco no Windows
+	ID         string `json:"id,omitempty"`
+	Size       string `json:"size"`
+	SizeGB     int    `json:"size_gb"`
+	SizeBytes  int64  `json:"size_bytes"`
+	Model      string `json:"model"`
+	Vendor     string `json:"vendor"`
+	Serial     string `json:"serial"`
+	Transport  string `json:"transport,omitempty"`
+	Removable  bool   `json:"removable"`
+	Platform   string `json:"platform"`
+	DiskNumber int    `json:"disk_number,omitempty"`
+	// SystemReason diz por que o disco sustenta o sistema em execução;
+	// vazio para discos que podem ser gravados
+	SystemReason string `json:"system_reason,omitempty"`
 }
 
 // Detector interface para detecção de dispositivos USB
 type Detector interface {
+	// DetectDevices lista os discos removíveis que podem ser gravados
 	DetectDevices() ([]USBDevice, error)
+	// ValidateDevice recusa o que não for um disco inteiro ou que sustente
+	// a raiz, o boot ou a swap do sistema em execução
 	ValidateDevice(devicePath string) error
 	IsSystemDisk(devicePath string) bool
+	// Identify lê a identidade atual do disco
+	Identify(devicePath string) (*Identity, error)
+}
+
+// Identity identifica um disco entre a escolha e a gravação. Se outro disco
+// assumir o mesmo caminho nesse intervalo, a identidade muda.
+type Identity struct {
+	// Device é o caminho usado na gravação: /dev/sdX ou PHYSICALDRIVEn
+	Device    string `json:"device"`
+	ID        string `json:"id,omitempty"`
+	Serial    string `json:"serial,omitempty"`
+	Model     string `json:"model,omitempty"`
+	SizeBytes int64  `json:"size_bytes"`
+}
+
+func (i *Identity) String() string {
+	name := i.ID
+	if name == "" {
+		name = strings.TrimSpace(i.Model + " " + i.Serial)
+	}
+	if name == "" {
+		name = i.Device
+	}
+	return fmt.Sprintf("%s (%s)", name, humanSize(i.SizeBytes))
+}
+
+// Check confere se current é o mesmo disco identificado antes
+func (i *Identity) Check(current *Identity) error {
+	if i.ID != current.ID || i.Serial != current.Serial || i.Model != current.Model || i.SizeBytes != current.SizeBytes {
+		return fmt.Errorf("o disco em %s mudou desde a seleção: era %s, agora é %s", i.Device, i, current)
+	}
+	return nil
+}
+
+// IdentityGuard cria um Guard que, antes da gravação, valida de novo o
+// dispositivo e confere se ele ainda é o disco identificado em want
+func IdentityGuard(d Detector, want *Identity) Guard {
+	return func(device string) error {
+		if err := d.ValidateDevice(device); err != nil {
+			return err
+		}
+		current, err := d.Identify(device)
+		if err != nil {
+			return err
+		}
+		return want.Check(current)
+	}
 }
 
-// LinuxDetector implementa detecção para Linux nativo
+// LinuxDetector detecta discos pelo lsblk e pelos links em /dev/disk/by-id
 type LinuxDetector struct{}
 
-// WSLDetector implementa detecção para WSL (Windows Subsystem for Linux)
+// WSLDetector detecta discos do Windows a partir do WSL, onde eles só
+// aparecem depois de anexados com "wsl --mount"
 type WSLDetector struct{}
 
-// WindowsDetector implementa detecção para Windows nativo
+// WindowsDetector detecta discos pelo Get-Disk do PowerShell
 type WindowsDetector struct{}
 
 // NewDetector cria um detector apropriado para a plataforma atual
@@ -65,392 +120,147 @@ func isWSL() bool {
 	return strings.Contains(strings.ToLower(string(data)), "microsoft")
 }
 
-// DetectDevices implementa a detecção para Linux nativo
+// DetectDevices lista discos removíveis, hotplug ou USB, exceto os que
+// sustentam o sistema
 func (d *LinuxDetector) DetectDevices() ([]USBDevice, error) {
-	var devices []USBDevice
-
-	// Usar lsblk para obter informações dos dispositivos
-	cmd := exec.Command("lsblk", "-d", "-n", "-o", "NAME,SIZE,TYPE,RM,MODEL,SERIAL,VENDOR")
-	output, err := cmd.Output()
+	disks, err := lsblk()
 	if err != nil {
-		return nil, fmt.Errorf("falha ao executar lsblk: %w", err)
+		return nil, err
 	}
+	ids := byIDLinks(byIDDir)
 
-	scanner := bufio.NewScanner(strings.NewReader(string(output)))
-	for scanner.Scan() {
-		line := scanner.Text()
-		fields := strings.Fields(line)
-		
-		if len(fields) < 4 {
+	var devices []USBDevice
+	for _, disk := range disks {
+		if disk.Type != "disk" || disk.Size == 0 {
 			continue
 		}
-
-		// Verificar se é um disco
-		if fields[2] != "disk" {
+		device := disk.device(ids)
+		if !bool(disk.Removable) && !bool(disk.Hotplug) && device.Transport != "usb" {
 			continue
 		}
-
-		devicePath := "/dev/" + fields[0]
-		
-		// Verificar se é removível
-		removable := fields[3] == "1"
-		
-		// Verificar se não é disco do sistema
-		if !removable && fields[0] == "sda" {
+		if device.SystemReason != "" {
 			continue
 		}
-
-		// Calcular tamanho em GB
-		sizeGB := parseSizeToGB(fields[1])
-		
-		// Filtrar dispositivos muito pequenos ou muito grandes
-		if sizeGB < 1 || sizeGB > 1024 {
-			continue
-		}
-
-		// Construir informações do dispositivo
-		device := USBDevice{
-			Path:      devicePath,
-			Size:      fields[1],
-			SizeGB:    sizeGB,
-			Model:     getField(fields, 4, "Unknown"),
-			Vendor:    getField(fields, 5, "Unknown"),
-			Serial:    getField(fields, 6, "Unknown"),
-			Removable: removable,
-			Platform:  "linux",
-		}
-
-		// Validar se o dispositivo existe e é acessível
-		if d.ValidateDevice(devicePath) == nil {
-			devices = append(devices, device)
-		}
+		devices = append(devices, device)
 	}
-
-	return devices, scanner.Err()
-}
-
-// DetectDevices implementa a detecção para WSL
-func (d *WSLDetector) DetectDevices() ([]USBDevice, error) {
-	var devices []USBDevice
-
-	// Primeiro, tentar obter dispositivos via PowerShell (Windows)
-	windowsDevices, err := d.getWindowsUSBDevices()
-	if err == nil && len(windowsDevices) > 0 {
-		// Mapear dispositivos Windows para WSL
-		for _, winDevice := range windowsDevices {
-			wslDevice := d.convertWindowsToWSL(winDevice)
-			if wslDevice != nil {
-				devices = append(devices, *wslDevice)
-			}
-		}
-	}
-
-	// Se não encontrou via Windows, usar detecção Linux como fallback
-	if len(devices) == 0 {
-		linuxDetector := &LinuxDetector{}
-		linuxDevices, err := linuxDetector.DetectDevices()
-		if err == nil {
-			// Marcar como WSL
-			for _, device := range linuxDevices {
-				device.Platform = "wsl"
-				devices = append(devices, device)
-			}
-		}
-	}
-
 	return devices, nil
 }
 
-// getWindowsUSBDevices obtém dispositivos USB via PowerShell
-func (d *WSLDetector) getWindowsUSBDevices() ([]WindowsUSBDevice, error) {
-	psCommand := `
-	$ErrorActionPreference = "SilentlyContinue"
-	$usbDrives = Get-WmiObject Win32_DiskDrive | Where-Object { $_.InterfaceType -eq "USB" }
-	
-	if ($usbDrives.Count -eq 0) {
-		Write-Output "NO_USB_FOUND"
-		exit 1
-	}
-	
-	foreach ($disk in $usbDrives) {
-		$diskNum = $disk.Index
-		$model = $disk.Model
-		$size = [math]::Round($disk.Size / 1GB, 2)
-		Write-Output "USB|$diskNum|$model|$size"
-	}`
-
-	cmd := exec.Command("powershell.exe", "-ExecutionPolicy", "Bypass", "-Command", psCommand)
-	output, err := cmd.Output()
+// ValidateDevice aceita o caminho do kernel ou um link em /dev/disk/by-id
+func (d *LinuxDetector) ValidateDevice(devicePath string) error {
+	_, disk, err := inspectDisk(devicePath)
 	if err != nil {
-		return nil, fmt.Errorf("falha ao executar PowerShell: %w", err)
+		return err
 	}
-
-	var devices []WindowsUSBDevice
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	
-	for _, line := range lines {
-		if strings.HasPrefix(line, "USB|") {
-			parts := strings.Split(line, "|")
-			if len(parts) >= 4 {
-				diskNum, _ := strconv.Atoi(parts[1])
-				size, _ := strconv.ParseFloat(parts[3], 64)
-				
-				device := WindowsUSBDevice{
-					DiskNumber: diskNum,
-					Model:      parts[2],
-					SizeGB:     int(size),
-				}
-				devices = append(devices, device)
-			}
-		}
+	if reason := disk.systemReason(); reason != "" {
+		return fmt.Errorf("%s é um disco do sistema (%s) - operação cancelada por segurança", devicePath, reason)
 	}
-
-	return devices, nil
+	return nil
 }
 
-// WindowsUSBDevice representa um dispositivo USB detectado no Windows
-type WindowsUSBDevice struct {
-	DiskNumber int    `json:"disk_number"`
-	Model      string `json:"model"`
-	SizeGB     int    `json:"size_gb"`
+// IsSystemDisk trata como do sistema o disco que não puder ser inspecionado
+func (d *LinuxDetector) IsSystemDisk(devicePath string) bool {
+	_, disk, err := inspectDisk(devicePath)
+	return err != nil || disk.systemReason() != ""
 }
 
-// convertWindowsToWSL converte dispositivo Windows para formato WSL
-func (d *WSLDetector) convertWindowsToWSL(winDevice WindowsUSBDevice) *USBDevice {
-	// PhysicalDrive0 = /dev/sda, PhysicalDrive1 = /dev/sdb, etc.
-	letterASCII := 97 + winDevice.DiskNumber // 'a' + disk_number
-	deviceLetter := string(rune(letterASCII))
-	wslPath := "/dev/sd" + deviceLetter
-
-	// Verificar se o dispositivo existe no WSL
-	if _, err := os.Stat(wslPath); os.IsNotExist(err) {
-		return nil
-	}
-
-	return &USBDevice{
-		Path:      wslPath,
-		Size:      fmt.Sprintf("%dG", winDevice.SizeGB),
-		SizeGB:    winDevice.SizeGB,
-		Model:     winDevice.Model,
-		Vendor:    "Unknown",
-		Serial:    "Unknown",
-		Removable: true, // Assumir que dispositivos USB são removíveis
-		Platform:  "wsl",
-	}
+// Identify implementa Detector
+func (d *LinuxDetector) Identify(devicePath string) (*Identity, error) {
+	path, disk, err := inspectDisk(devicePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Device:    path,
+		ID:        byIDLinks(byIDDir)[path],
+		Serial:    disk.Serial,
+		Model:     disk.Model,
+		SizeBytes: int64(disk.Size),
+	}, nil
 }
 
-// DetectDevices implementa a detecção para Windows nativo
-func (d *WindowsDetector) DetectDevices() ([]USBDevice, error) {
-	// Implementação para Windows nativo usando PowerShell
-	psCommand := `
-	$ErrorActionPreference = "SilentlyContinue"
-	$usbDrives = Get-WmiObject Win32_DiskDrive | Where-Object { $_.InterfaceType -eq "USB" }
-	
-	foreach ($disk in $usbDrives) {
-		$device = @{
-			Path = $disk.DeviceID
-			Size = [math]::Round($disk.Size / 1GB, 2)
-			Model = $disk.Model
-			Vendor = $disk.Manufacturer
-			Serial = $disk.SerialNumber
-			Removable = $true
-			Platform = "windows"
-		}
-		$device | ConvertTo-Json -Compress
-	}`
-
-	cmd := exec.Command("powershell.exe", "-ExecutionPolicy", "Bypass", "-Command", psCommand)
-	output, err := cmd.Output()
+// inspectDisk resolve links como os de /dev/disk/by-id e retorna o caminho
+// do kernel e a árvore do disco
+func inspectDisk(devicePath string) (string, *lsblkDevice, error) {
+	path, err := filepath.EvalSymlinks(devicePath)
 	if err != nil {
-		return nil, fmt.Errorf("falha ao executar PowerShell: %w", err)
+		return "", nil, fmt.Errorf("dispositivo não encontrado: %s", devicePath)
 	}
-
-	var devices []USBDevice
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
-		}
-		
-		var device USBDevice
-		if err := json.Unmarshal([]byte(line), &device); err == nil {
-			devices = append(devices, device)
-		}
+	block, err := isBlockDevice(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("erro ao acessar dispositivo: %w", err)
 	}
-
-	return devices, nil
-}
-
-// ValidateDevice valida se um dispositivo é acessível e seguro para uso
-func (d *LinuxDetector) ValidateDevice(devicePath string) error {
-	// Verificar se o dispositivo existe
-	if _, err := os.Stat(devicePath); os.IsNotExist(err) {
-		return fmt.Errorf("dispositivo não encontrado: %s", devicePath)
+	if !block {
+		return "", nil, fmt.Errorf("não é um dispositivo de bloco: %s", devicePath)
 	}
 
-	// Verificar se é um dispositivo de bloco
-	var stat syscall.Stat_t
-	if err := syscall.Stat(devicePath, &stat); err != nil {
-		return fmt.Errorf("erro ao acessar dispositivo: %w", err)
+	disks, err := lsblk(path)
+	if err != nil {
+		return "", nil, err
 	}
-
-	if stat.Mode&syscall.S_IFBLK == 0 {
-		return fmt.Errorf("não é um dispositivo de bloco: %s", devicePath)
+	if len(disks) != 1 {
+		return "", nil, fmt.Errorf("lsblk não retornou %s", path)
 	}
-
-	// Verificar se não é disco do sistema
-	if d.IsSystemDisk(devicePath) {
-		return fmt.Errorf("dispositivo parece ser um disco do sistema: %s", devicePath)
+	if disks[0].Type != "disk" {
+		return "", nil, fmt.Errorf("%s é do tipo %s; informe o disco inteiro, não uma partição", devicePath, disks[0].Type)
 	}
+	return path, &disks[0], nil
+}
 
-	return nil
+// DetectDevices lista os discos USB do Windows
+func (d *WSLDetector) DetectDevices() ([]USBDevice, error) {
+	return detectWindowsDisks("wsl")
 }
 
-// ValidateDevice para WSL
+// ValidateDevice implementa Detector
 func (d *WSLDetector) ValidateDevice(devicePath string) error {
-	// Usar validação Linux como base
-	linuxDetector := &LinuxDetector{}
-	if err := linuxDetector.ValidateDevice(devicePath); err != nil {
-		return err
-	}
-
-	// Validações específicas do WSL
-	deviceName := strings.TrimPrefix(devicePath, "/dev/")
-	removablePath := fmt.Sprintf("/sys/block/%s/removable", deviceName)
-	
-	if removableData, err := os.ReadFile(removablePath); err == nil {
-		if strings.TrimSpace(string(removableData)) == "0" {
-			// Dispositivo não é removível, aplicar validação mais rigorosa
-			if deviceName == "sda" {
-				return fmt.Errorf("dispositivo sda não pode ser usado no WSL")
-			}
-		}
-	}
-
-	return nil
+	_, err := findWindowsDisk(devicePath)
+	return err
 }
 
-// ValidateDevice para Windows
-func (d *WindowsDetector) ValidateDevice(devicePath string) error {
-	// Implementação básica para Windows
-	if !strings.HasPrefix(devicePath, "\\\\.\\PHYSICALDRIVE") && 
-	   !strings.HasPrefix(devicePath, "PhysicalDrive") {
-		return fmt.Errorf("formato de dispositivo inválido para Windows: %s", devicePath)
-	}
-	return nil
+// IsSystemDisk implementa Detector
+func (d *WSLDetector) IsSystemDisk(devicePath string) bool {
+	return d.ValidateDevice(devicePath) != nil
 }
 
-// IsSystemDisk verifica se um dispositivo é um disco do sistema
-func (d *LinuxDetector) IsSystemDisk(devicePath string) bool {
-	// Verificar se está em /etc/fstab
-	if fstabData, err := os.ReadFile("/etc/fstab"); err == nil {
-		if strings.Contains(string(fstabData), devicePath) {
-			return true
-		}
-	}
-
-	// Verificar pontos de montagem críticos
-	criticalMounts := []string{"/", "/boot", "/boot/efi", "/usr", "/var", "/home", "/opt"}
-	
-	cmd := exec.Command("lsblk", "-n", "-o", "NAME,MOUNTPOINT", devicePath)
-	output, err := cmd.Output()
-	if err != nil {
-		return false
-	}
-
-	lines := strings.Split(string(output), "\n")
-	for _, line := range lines {
-		fields := strings.Fields(line)
-		if len(fields) >= 2 {
-			for _, mount := range criticalMounts {
-				if fields[1] == mount {
-					return true
-				}
-			}
-		}
-	}
-
-	return false
+// Identify implementa Detector
+func (d *WSLDetector) Identify(devicePath string) (*Identity, error) {
+	return identifyWindowsDisk(devicePath)
 }
 
-// IsSystemDisk para WSL
-func (d *WSLDetector) IsSystemDisk(devicePath string) bool {
-	// Usar validação Linux como base
-	linuxDetector := &LinuxDetector{}
-	if linuxDetector.IsSystemDisk(devicePath) {
-		return true
-	}
-
-	// Validações específicas do WSL
-	deviceName := strings.TrimPrefix(devicePath, "/dev/")
-	
-	// Verificar se é sda (disco principal do WSL)
-	if deviceName == "sda" {
-		return true
-	}
-
-	// Verificar se é um dispositivo de swap
-	cmd := exec.Command("lsblk", "-n", "-o", "FSTYPE", devicePath)
-	output, err := cmd.Output()
-	if err == nil && strings.Contains(string(output), "swap") {
-		return true
-	}
+// DetectDevices lista os discos USB do Windows
+func (d *WindowsDetector) DetectDevices() ([]USBDevice, error) {
+	return detectWindowsDisks("windows")
+}
 
-	return false
+// ValidateDevice implementa Detector
+func (d *WindowsDetector) ValidateDevice(devicePath string) error {
+	_, err := findWindowsDisk(devicePath)
+	return err
 }
 
-// IsSystemDisk para Windows
+// IsSystemDisk implementa Detector
 func (d *WindowsDetector) IsSystemDisk(devicePath string) bool {
-	// Implementação básica para Windows
-	// Verificar se é PhysicalDrive0 (disco principal)
-	if strings.Contains(devicePath, "PhysicalDrive0") {
-		return true
-	}
-	return false
+	return d.ValidateDevice(devicePath) != nil
 }
 
-// Funções auxiliares
-
-// parseSizeToGB converte string de tamanho para GB
-func parseSizeToGB(sizeStr string) int {
-	// Remover espaços e converter para minúsculas
-	sizeStr = strings.ToLower(strings.TrimSpace(sizeStr))
-	
-	// Regex para extrair número e unidade
-	re := regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*([kmgt]?b?)$`)
-	matches := re.FindStringSubmatch(sizeStr)
-	
-	if len(matches) != 3 {
-		return 0
-	}
-	
-	number, err := strconv.ParseFloat(matches[1], 64)
-	if err != nil {
-		return 0
-	}
-	
-	unit := matches[2]
-	switch unit {
-	case "k", "kb":
-		return int(number / 1024 / 1024)
-	case "m", "mb":
-		return int(number / 1024)
-	case "g", "gb":
-		return int(number)
-	case "t", "tb":
-		return int(number * 1024)
-	default:
-		return int(number) // Assumir GB se não especificado
-	}
+// Identify implementa Detector
+func (d *WindowsDetector) Identify(devicePath string) (*Identity, error) {
+	return identifyWindowsDisk(devicePath)
 }
 
-// getField obtém um campo de um slice com fallback
-func getField(fields []string, index int, fallback string) string {
-	if index < len(fields) {
-		return fields[index]
+// humanSize formata bytes como o lsblk (ex: 14.9G)
+func humanSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%dB", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
 	}
-	return fallback
+	return fmt.Sprintf("%.1f%c", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
 // ListDevices lista todos os dispositivos USB disponíveis
@@ -459,29 +269,25 @@ func ListDevices() ([]USBDevice, error) {
 	return detector.DetectDevices()
 }
 
-// SelectDevice permite ao usuário selecionar um dispositivo
+// SelectDevice retorna o único dispositivo USB conectado. Com mais de um,
+// o dispositivo precisa ser informado: escolher por conta própria poderia
+// apagar o disco errado.
 func SelectDevice() (*USBDevice, error) {
 	devices, err := ListDevices()
 	if err != nil {
 		return nil, fmt.Errorf("falha ao detectar dispositivos: %w", err)
 	}
 
-	if len(devices) == 0 {
+	switch len(devices) {
+	case 0:
 		return nil, fmt.Errorf("nenhum dispositivo USB encontrado")
-	}
-
-	if len(devices) == 1 {
+	case 1:
 		return &devices[0], nil
 	}
 
-	// Implementar seleção interativa
-	fmt.Println("Dispositivos USB disponíveis:")
-	for i, device := range devices {
-		fmt.Printf("[%d] %s (%s) - %s - %s\n", 
-			i+1, device.Path, device.Size, device.Model, device.Vendor)
+	var names []string
+	for _, device := range devices {
+		names = append(names, fmt.Sprintf("%s (%s, %s)", device.Path, device.Model, device.Size))
 	}
-
-	// Por enquanto, retornar o primeiro dispositivo
-	// TODO: Implementar seleção interativa
-	return &devices[0], nil
+	return nil, fmt.Errorf("%d dispositivos USB encontrados, informe qual usar: %s", len(devices), strings.Join(names, "; "))
 }
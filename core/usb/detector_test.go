@@ -0,0 +1,206 @@
+package usb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// lsblkOutput segue o "lsblk -J -b" do util-linux 2.37+: um NVMe com a
+// raiz em LVM sobre dm-crypt, um disco SATA com swap e um pendrive montado
+// pelo ambiente gráfico
+const lsblkOutput = `{
+   "blockdevices": [
+      {"name":"nvme0n1", "path":"/dev/nvme0n1", "type":"disk", "size":512110190592, "rm":false, "hotplug":false, "tran":"nvme", "model":"Samsung SSD 980", "vendor":null, "serial":"S64ANS0R", "fstype":null, "mountpoints":[null],
+         "children": [
+            {"name":"nvme0n1p1", "path":"/dev/nvme0n1p1", "type":"part", "size":536870912, "rm":false, "hotplug":false, "tran":null, "model":null, "vendor":null, "serial":null, "fstype":"vfat", "mountpoints":["/boot/efi"]},
+            {"name":"nvme0n1p2", "path":"/dev/nvme0n1p2", "type":"part", "size":511571509248, "rm":false, "hotplug":false, "tran":null, "model":null, "vendor":null, "serial":null, "fstype":"crypto_LUKS", "mountpoints":[null],
+               "children": [
+                  {"name":"luks-root", "path":"/dev/mapper/luks-root", "type":"crypt", "size":511554732032, "rm":false, "hotplug":false, "tran":null, "model":null, "vendor":null, "serial":null, "fstype":"LVM2_member", "mountpoints":[null],
+                     "children": [
+                        {"name":"vg-root", "path":"/dev/mapper/vg-root", "type":"lvm", "size":511553683456, "rm":false, "hotplug":false, "tran":null, "model":null, "vendor":null, "serial":null, "fstype":"ext4", "mountpoints":["/var/snap/firefox/common/host-hunspell", "/"]}
+                     ]
+                  }
+               ]
+            }
+         ]
+      },
+      {"name":"sda", "path":"/dev/sda", "type":"disk", "size":2000398934016, "rm":false, "hotplug":false, "tran":"sata", "model":"WDC WD20EZRZ", "vendor":"ATA     ", "serial":"WD-WCC4M", "fstype":null, "mountpoints":[null],
+         "children": [
+            {"name":"sda1", "path":"/dev/sda1", "type":"part", "size":2000397885440, "rm":false, "hotplug":false, "tran":null, "model":null, "vendor":null, "serial":null, "fstype":"swap", "mountpoints":["[SWAP]"]}
+         ]
+      },
+      {"name":"sdb", "path":"/dev/sdb", "type":"disk", "size":15376000000, "rm":true, "hotplug":true, "tran":"usb", "model":"Cruzer Blade", "vendor":"SanDisk ", "serial":"4C530001", "fstype":null, "mountpoints":[null],
+         "children": [
+            {"name":"sdb1", "path":"/dev/sdb1", "type":"part", "size":15374000000, "rm":true, "hotplug":true, "tran":null, "model":null, "vendor":null, "serial":null, "fstype":"vfat", "mountpoints":["/media/ana/CIDATA"]}
+         ]
+      }
+   ]
+}`
+
+// lsblkLegacyOutput segue o util-linux 2.32, sem PATH e com todos os
+// valores como strings
+const lsblkLegacyOutput = `{
+   "blockdevices": [
+      {"name": "sda", "type": "disk", "size": "128035676160", "rm": "0", "hotplug": "0", "tran": "sata", "model": "KINGSTON SA400", "vendor": "ATA", "serial": "50026B76", "fstype": null, "mountpoint": null,
+         "children": [
+            {"name": "sda1", "type": "part", "size": "128034627584", "rm": "0", "hotplug": "0", "tran": null, "model": null, "vendor": null, "serial": null, "fstype": "ext4", "mountpoint": "/"}
+         ]
+      },
+      {"name": "sdc", "type": "disk", "size": "31914983424", "rm": "1", "hotplug": "1", "tran": "usb", "model": "DataTraveler 3.0", "vendor": "Kingston", "serial": "E0D55EA5", "fstype": null, "mountpoint": null}
+   ]
+}`
+
+func TestParseLsblk(t *testing.T) {
+	disks, err := parseLsblk([]byte(lsblkOutput))
+	if err != nil {
+		t.Fatalf("parseLsblk: %v", err)
+	}
+	if len(disks) != 3 {
+		t.Fatalf("got %d disks, want 3", len(disks))
+	}
+
+	for i, want := range []string{
+		"/dev/nvme0n1p1 montado em /boot/efi",
+		"/dev/sda1 em uso como swap",
+		"",
+	} {
+		if got := disks[i].systemReason(); got != want {
+			t.Errorf("%s: systemReason = %q, want %q", disks[i].Name, got, want)
+		}
+	}
+
+	// A raiz é encontrada através do dm-crypt e do LVM
+	disks[0].Children = disks[0].Children[1:]
+	if got := disks[0].systemReason(); got != "/dev/mapper/vg-root montado em /" {
+		t.Errorf("root through LVM: systemReason = %q", got)
+	}
+
+	ids := map[string]string{"/dev/sdb": "/dev/disk/by-id/usb-SanDisk_Cruzer_Blade_4C530001-0:0"}
+	dev := disks[2].device(ids)
+	if dev.Path != "/dev/sdb" || dev.ID != ids["/dev/sdb"] || dev.SizeBytes != 15376000000 ||
+		dev.Vendor != "SanDisk" || dev.Transport != "usb" || !dev.Removable || dev.SystemReason != "" {
+		t.Errorf("device = %+v", dev)
+	}
+	if dev.Size != "14.3G" || dev.SizeGB != 14 {
+		t.Errorf("size = %s (%d GB)", dev.Size, dev.SizeGB)
+	}
+}
+
+func TestParseLsblkLegacy(t *testing.T) {
+	disks, err := parseLsblk([]byte(lsblkLegacyOutput))
+	if err != nil {
+		t.Fatalf("parseLsblk: %v", err)
+	}
+	if len(disks) != 2 {
+		t.Fatalf("got %d disks, want 2", len(disks))
+	}
+	if got := disks[0].systemReason(); got != "/dev/sda1 montado em /" {
+		t.Errorf("systemReason = %q", got)
+	}
+	usb := disks[1]
+	if usb.Path != "/dev/sdc" || usb.Size != 31914983424 || !bool(usb.Removable) || !bool(usb.Hotplug) {
+		t.Errorf("legacy disk = %+v", usb)
+	}
+
+	if _, err := parseLsblk([]byte(`{"blockdevices": [{"name": "sda", "size": "14,9G"}]}`)); err == nil {
+		t.Error("expected error for a human-readable size")
+	}
+}
+
+func TestByIDLinks(t *testing.T) {
+	dir := t.TempDir()
+	dev := filepath.Join(dir, "dev")
+	byID := filepath.Join(dir, "by-id")
+	for _, d := range []string{dev, byID} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, name := range []string{"sda", "sda1", "sdb", "sdb1"} {
+		if err := os.WriteFile(filepath.Join(dev, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		"wwn-0x50014ee2b5":                            "sda",
+		"ata-WDC_WD20EZRZ_WD-WCC4M":                   "sda",
+		"ata-WDC_WD20EZRZ_WD-WCC4M-part1":             "sda1",
+		"usb-SanDisk_Cruzer_Blade_4C530001-0:0":       "sdb",
+		"usb-SanDisk_Cruzer_Blade_4C530001-0:0-part1": "sdb1",
+		"scsi-SSanDisk_Cruzer_Blade_4C530001":         "sdb",
+	} {
+		if err := os.Symlink(filepath.Join("..", "dev", target), filepath.Join(byID, link)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := byIDLinks(byID)
+	if len(links) != 2 {
+		t.Errorf("got %d links, want 2 (partitions ignored): %v", len(links), links)
+	}
+	sda, _ := filepath.EvalSymlinks(filepath.Join(dev, "sda"))
+	sdb, _ := filepath.EvalSymlinks(filepath.Join(dev, "sdb"))
+	if got := filepath.Base(links[sda]); got != "ata-WDC_WD20EZRZ_WD-WCC4M" {
+		t.Errorf("sda: got %s", got)
+	}
+	if got := filepath.Base(links[sdb]); got != "usb-SanDisk_Cruzer_Blade_4C530001-0:0" {
+		t.Errorf("sdb: got %s", got)
+	}
+
+	if links := byIDLinks(filepath.Join(dir, "missing")); len(links) != 0 {
+		t.Errorf("missing dir: %v", links)
+	}
+}
+
+func TestParseWindowsDisks(t *testing.T) {
+	disks, err := parseWindowsDisks([]byte(`[
+		{"Number":0,"UniqueId":"eui.0025385B","SerialNumber":"S64A_0000","FriendlyName":"Samsung SSD 980","Size":500107862016,"BusType":"NVMe","SystemReason":"disco de boot do Windows"},
+		{"Number":1,"UniqueId":"USBSTOR\\DISK&VEN_SANDISK","SerialNumber":"4C530001 ","FriendlyName":"SanDisk Cruzer Blade","Size":15376000000,"BusType":"USB","SystemReason":""}
+	]`))
+	if err != nil {
+		t.Fatalf("parseWindowsDisks: %v", err)
+	}
+	if len(disks) != 2 || disks[0].SystemReason == "" {
+		t.Fatalf("disks = %+v", disks)
+	}
+
+	dev := disks[1].device("windows")
+	if dev.Path != `\\.\PHYSICALDRIVE1` || dev.DiskNumber != 1 || dev.Serial != "4C530001" || !dev.Removable || dev.Transport != "usb" {
+		t.Errorf("windows device = %+v", dev)
+	}
+	if dev := disks[1].device("wsl"); dev.Path != "PHYSICALDRIVE1" {
+		t.Errorf("wsl path = %s", dev.Path)
+	}
+
+	// Com um único disco, versões antigas do PowerShell escrevem um objeto
+	single, err := parseWindowsDisks([]byte(`{"Number":2,"UniqueId":"X","Size":1,"BusType":"USB"}` + "\r\n"))
+	if err != nil || len(single) != 1 || single[0].Number != 2 {
+		t.Errorf("single disk = %+v, %v", single, err)
+	}
+	if empty, err := parseWindowsDisks([]byte("\r\n")); err != nil || len(empty) != 0 {
+		t.Errorf("empty output = %+v, %v", empty, err)
+	}
+}
+
+func TestIdentityCheck(t *testing.T) {
+	chosen := &Identity{Device: "/dev/sdb", ID: "/dev/disk/by-id/usb-SanDisk_Cruzer_Blade_4C530001-0:0", Serial: "4C530001", Model: "Cruzer Blade", SizeBytes: 15376000000}
+	same := *chosen
+	if err := chosen.Check(&same); err != nil {
+		t.Errorf("same disk: %v", err)
+	}
+
+	swapped := *chosen
+	swapped.ID, swapped.Serial, swapped.SizeBytes = "/dev/disk/by-id/usb-Kingston_DataTraveler_3.0_E0D55EA5-0:0", "E0D55EA5", 31914983424
+	err := chosen.Check(&swapped)
+	if err == nil || !strings.Contains(err.Error(), "usb-Kingston_DataTraveler") || !strings.Contains(err.Error(), "29.7G") {
+		t.Errorf("swapped disk: %v", err)
+	}
+
+	resized := *chosen
+	resized.SizeBytes = 0
+	if err := chosen.Check(&resized); err == nil {
+		t.Error("expected error when the size changes")
+	}
+}
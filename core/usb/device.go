@@ -0,0 +1,217 @@
+package usb
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DeviceBackend grava dispositivos de bloco do Linux com dd, sgdisk,
+// mkfs.vfat e mount. O Runner decide onde os comandos rodam: nesta
+// máquina ou no WSL.
+type DeviceBackend struct {
+	Runner Runner
+}
+
+// NewDeviceBackend cria um backend para dispositivos do Linux
+func NewDeviceBackend(runner Runner) *DeviceBackend {
+	return &DeviceBackend{Runner: runner}
+}
+
+// Name implementa Backend
+func (b *DeviceBackend) Name() string {
+	return "device"
+}
+
+// Attach desmonta as partições montadas do dispositivo
+func (b *DeviceBackend) Attach(device string) (string, error) {
+	out, err := b.Runner.Output(15*time.Second, "lsblk", "-ln", "-o", "PATH,MOUNTPOINT", device)
+	if err != nil {
+		return "", fmt.Errorf("dispositivo não encontrado: %w", err)
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		if err := b.Runner.Run(30*time.Second, "umount", fields[0]); err != nil {
+			return "", fmt.Errorf("falha ao desmontar %s: %w", fields[0], err)
+		}
+	}
+	return device, nil
+}
+
+// Detach não faz nada: o dispositivo já foi sincronizado
+func (b *DeviceBackend) Detach(device string) error {
+	return nil
+}
+
+// Wipe implementa Backend
+func (b *DeviceBackend) Wipe(device string) error {
+	return b.Runner.Run(60*time.Second, "wipefs", "-a", device)
+}
+
+// WriteImage implementa Backend
+func (b *DeviceBackend) WriteImage(device, image string) error {
+	return b.Runner.Run(30*time.Minute, "dd",
+		"if="+b.Runner.Path(image), "of="+device,
+		"bs=4M", "conv=fsync", "status=progress")
+}
+
+// NewTable implementa Backend
+func (b *DeviceBackend) NewTable(device string) error {
+	return b.Runner.Run(30*time.Second, "sgdisk", "-o", device)
+}
+
+// RelocateTable implementa Backend
+func (b *DeviceBackend) RelocateTable(device string) error {
+	return b.Runner.Run(30*time.Second, "sgdisk", "-e", device)
+}
+
+// AddPartition implementa Backend
+func (b *DeviceBackend) AddPartition(device, label string, sizeMiB int64) (string, error) {
+	end := "0"
+	if sizeMiB > 0 {
+		end = fmt.Sprintf("+%dMiB", sizeMiB)
+	}
+	if err := b.Runner.Run(30*time.Second, "sgdisk", "-n", "0:0:"+end, "-t", "0:0700", "-c", "0:"+label, device); err != nil {
+		return "", err
+	}
+
+	out, err := b.Runner.Output(30*time.Second, "sgdisk", "-p", device)
+	if err != nil {
+		return "", err
+	}
+	part, ok := findPartition(string(out), label)
+	if !ok {
+		return "", fmt.Errorf("partição %s não encontrada na tabela", label)
+	}
+
+	// O kernel precisa reler a tabela antes de a partição aparecer
+	b.Runner.Run(30*time.Second, "partprobe", device)
+	b.Runner.Run(30*time.Second, "udevadm", "settle")
+	return b.waitPartition(device, label, part.Number)
+}
+
+// FindPartition implementa Backend
+func (b *DeviceBackend) FindPartition(device, label string) (string, error) {
+	out, err := b.Runner.Output(30*time.Second, "sgdisk", "-p", device)
+	if err != nil {
+		return "", err
+	}
+	part, ok := findPartition(string(out), label)
+	if !ok {
+		return "", fmt.Errorf("partição %s não encontrada na tabela", label)
+	}
+	return b.waitPartition(device, label, part.Number)
+}
+
+// waitPartition aguarda o dispositivo da partição n aparecer
+func (b *DeviceBackend) waitPartition(device, label string, n int) (string, error) {
+	partition := PartitionDevice(device, n)
+	for i := 0; i < 20; i++ {
+		if b.Runner.Run(5*time.Second, "test", "-b", partition) == nil {
+			return partition, nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return "", fmt.Errorf("partição %s não apareceu em %s", label, partition)
+}
+
+// Format implementa Backend
+func (b *DeviceBackend) Format(partition, label string) error {
+	return b.Runner.Run(2*time.Minute, "mkfs.vfat", "-F", "32", "-n", label, partition)
+}
+
+// CopyFiles implementa Backend
+func (b *DeviceBackend) CopyFiles(partition string, sources []string) error {
+	return b.withMount(partition, false, func(dir string) error {
+		args := []string{"-r"}
+		for _, src := range sources {
+			args = append(args, b.Runner.Path(src))
+		}
+		return b.Runner.Run(60*time.Minute, "cp", append(args, dir+"/")...)
+	})
+}
+
+// Sync implementa Backend
+func (b *DeviceBackend) Sync(device string) error {
+	return b.Runner.Run(10*time.Minute, "sync")
+}
+
+// HashFiles implementa Backend
+func (b *DeviceBackend) HashFiles(partition string) (map[string]string, error) {
+	var sums map[string]string
+	err := b.withMount(partition, true, func(dir string) error {
+		out, err := b.Runner.Output(60*time.Minute, "sh", "-c",
+			`cd "$1" && find . -type f -exec sha256sum {} +`, "sh", dir)
+		if err != nil {
+			return err
+		}
+		sums, err = parseSHA256Sums(string(out))
+		return err
+	})
+	return sums, err
+}
+
+// ReadAt implementa Backend
+func (b *DeviceBackend) ReadAt(device string, offset, length int64) ([]byte, error) {
+	return b.Runner.Output(60*time.Second, "dd", "if="+device, "bs=64K", "status=none",
+		"iflag=skip_bytes,count_bytes",
+		"skip="+strconv.FormatInt(offset, 10), "count="+strconv.FormatInt(length, 10))
+}
+
+// HashRange implementa Backend
+func (b *DeviceBackend) HashRange(device string, offset, length int64) (string, error) {
+	out, err := b.Runner.Output(60*time.Minute, "sh", "-c",
+		`dd if="$1" bs=4M status=none iflag=skip_bytes,count_bytes skip="$2" count="$3" | sha256sum`,
+		"sh", device, strconv.FormatInt(offset, 10), strconv.FormatInt(length, 10))
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("saída inesperada do sha256sum")
+	}
+	return fields[0], nil
+}
+
+// ReadFile implementa Backend
+func (b *DeviceBackend) ReadFile(partition, name string) ([]byte, error) {
+	if name == "" || strings.Contains(name, "..") {
+		return nil, fmt.Errorf("nome de arquivo inválido: %s", name)
+	}
+	var data []byte
+	err := b.withMount(partition, true, func(dir string) error {
+		var err error
+		data, err = b.Runner.Output(60*time.Second, "cat", path.Join(dir, name))
+		return err
+	})
+	return data, err
+}
+
+// withMount monta a partição em um diretório temporário durante fn
+func (b *DeviceBackend) withMount(partition string, readOnly bool, fn func(dir string) error) error {
+	dir := "/tmp/syntropy-usb-" + path.Base(partition)
+	if err := b.Runner.Run(15*time.Second, "mkdir", "-p", dir); err != nil {
+		return err
+	}
+	defer b.Runner.Run(15*time.Second, "rmdir", dir)
+
+	args := []string{partition, dir}
+	if readOnly {
+		args = append([]string{"-o", "ro"}, args...)
+	}
+	if err := b.Runner.Run(30*time.Second, "mount", args...); err != nil {
+		return fmt.Errorf("falha ao montar %s: %w", partition, err)
+	}
+
+	err := fn(dir)
+	// A desmontagem grava o cache de escrita, que pode ser grande
+	if umountErr := b.Runner.Run(10*time.Minute, "umount", dir); umountErr != nil && err == nil {
+		err = fmt.Errorf("falha ao desmontar %s: %w", partition, umountErr)
+	}
+	return err
+}
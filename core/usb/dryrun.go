@@ -0,0 +1,170 @@
+package usb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Op é uma operação primitiva registrada pelo DryRun
+type Op struct {
+	Name string
+	Args []string
+}
+
+func (o Op) String() string {
+	return fmt.Sprintf("%s %v", o.Name, o.Args)
+}
+
+// DryRun é um Backend que só registra as operações, sem tocar em nenhum
+// dispositivo. Serve para testes e para mostrar o que seria feito.
+type DryRun struct {
+	Ops []Op
+	// Fail faz falhar a operação com este nome
+	Fail string
+
+	partitions int
+	labels     map[string]string
+	image      string
+	copied     map[string][]string
+}
+
+// NewDryRun cria um backend de simulação
+func NewDryRun() *DryRun {
+	return &DryRun{labels: map[string]string{}, copied: map[string][]string{}}
+}
+
+func (d *DryRun) record(name string, args ...string) error {
+	d.Ops = append(d.Ops, Op{Name: name, Args: args})
+	if name == d.Fail {
+		return fmt.Errorf("falha simulada em %s", name)
+	}
+	return nil
+}
+
+// Names retorna apenas os nomes das operações registradas
+func (d *DryRun) Names() []string {
+	names := make([]string, len(d.Ops))
+	for i, op := range d.Ops {
+		names[i] = op.Name
+	}
+	return names
+}
+
+// Name implementa Backend
+func (d *DryRun) Name() string {
+	return "dry-run"
+}
+
+// Attach implementa Backend
+func (d *DryRun) Attach(device string) (string, error) {
+	return device, d.record("attach", device)
+}
+
+// Detach implementa Backend
+func (d *DryRun) Detach(device string) error {
+	return d.record("detach", device)
+}
+
+// Wipe implementa Backend
+func (d *DryRun) Wipe(device string) error {
+	return d.record("wipe", device)
+}
+
+// WriteImage implementa Backend
+func (d *DryRun) WriteImage(device, image string) error {
+	d.image = image
+	return d.record("write-image", device, image)
+}
+
+// NewTable implementa Backend
+func (d *DryRun) NewTable(device string) error {
+	d.partitions = 0
+	return d.record("new-table", device)
+}
+
+// RelocateTable implementa Backend. A imagem gravada é tratada como se
+// ocupasse a primeira partição.
+func (d *DryRun) RelocateTable(device string) error {
+	d.partitions = 1
+	return d.record("relocate-table", device)
+}
+
+// AddPartition implementa Backend
+func (d *DryRun) AddPartition(device, label string, sizeMiB int64) (string, error) {
+	if err := d.record("add-partition", device, label, strconv.FormatInt(sizeMiB, 10)); err != nil {
+		return "", err
+	}
+	d.partitions++
+	d.labels[label] = PartitionDevice(device, d.partitions)
+	return d.labels[label], nil
+}
+
+// Format implementa Backend
+func (d *DryRun) Format(partition, label string) error {
+	delete(d.copied, partition)
+	return d.record("format", partition, label)
+}
+
+// CopyFiles implementa Backend
+func (d *DryRun) CopyFiles(partition string, sources []string) error {
+	if err := d.record("copy-files", append([]string{partition}, sources...)...); err != nil {
+		return err
+	}
+	d.copied[partition] = append(d.copied[partition], sources...)
+	return nil
+}
+
+// Sync implementa Backend
+func (d *DryRun) Sync(device string) error {
+	return d.record("sync", device)
+}
+
+// HashFiles devolve o hash das origens copiadas para a partição, como se
+// tivessem sido gravadas sem erros
+func (d *DryRun) HashFiles(partition string) (map[string]string, error) {
+	if err := d.record("hash-files", partition); err != nil {
+		return nil, err
+	}
+	return HashSources(d.copied[partition])
+}
+
+// FindPartition devolve as partições criadas por AddPartition
+func (d *DryRun) FindPartition(device, label string) (string, error) {
+	if err := d.record("find-partition", device, label); err != nil {
+		return "", err
+	}
+	partition, ok := d.labels[label]
+	if !ok {
+		return "", fmt.Errorf("partição %s não encontrada", label)
+	}
+	return partition, nil
+}
+
+// ReadAt lê da imagem gravada, como se o dispositivo fosse uma cópia dela.
+// As leituras não são registradas: o ISO 9660 faz várias.
+func (d *DryRun) ReadAt(device string, offset, length int64) ([]byte, error) {
+	return (&ImageBackend{}).ReadAt(d.image, offset, length)
+}
+
+// HashRange calcula o hash da imagem gravada
+func (d *DryRun) HashRange(device string, offset, length int64) (string, error) {
+	if err := d.record("hash-range", device, strconv.FormatInt(offset, 10), strconv.FormatInt(length, 10)); err != nil {
+		return "", err
+	}
+	return (&ImageBackend{}).HashRange(d.image, offset, length)
+}
+
+// ReadFile lê a origem copiada para a partição com esse nome
+func (d *DryRun) ReadFile(partition, name string) ([]byte, error) {
+	if err := d.record("read-file", partition, name); err != nil {
+		return nil, err
+	}
+	for _, src := range d.copied[partition] {
+		if filepath.Base(src) == name {
+			return os.ReadFile(src)
+		}
+	}
+	return nil, fmt.Errorf("%s não encontrado em %s", name, partition)
+}
@@ -0,0 +1,295 @@
+package usb
+
+import "fmt"
+
+// Step é uma etapa do pipeline de provisionamento
+type Step string
+
+// Etapas na ordem em que o Engine as executa. A cópia da imagem vem antes
+// do particionamento porque a ISO híbrida traz sua própria tabela GPT, à
+// qual as partições do plano são acrescentadas.
+const (
+	StepWipe      Step = "wipe"
+	StepCopy      Step = "copy"
+	StepPartition Step = "partition"
+	StepFormat    Step = "format"
+	StepSeed      Step = "seed"
+	StepVerify    Step = "verify"
+)
+
+// Steps lista as etapas na ordem de execução
+var Steps = []Step{StepWipe, StepCopy, StepPartition, StepFormat, StepSeed, StepVerify}
+
+// PartitionSpec descreve uma partição FAT32 criada pelo plano
+type PartitionSpec struct {
+	Label string
+	// SizeMiB é o tamanho da partição; zero ocupa o restante do dispositivo
+	SizeMiB int64
+	// Sources são arquivos e diretórios copiados para a raiz da partição
+	Sources []string
+}
+
+// Plan descreve o que gravar em um dispositivo
+type Plan struct {
+	// Image é gravada byte a byte no início do dispositivo. Sem ela, o
+	// dispositivo recebe uma tabela de partições nova.
+	Image      string
+	Partitions []PartitionSpec
+	// Checks validam o conteúdo de arquivos gravados nas partições
+	Checks []FileCheck
+	// SkipVerify pula a releitura da mídia gravada
+	SkipVerify bool
+}
+
+// Backend implementa as operações primitivas de uma plataforma. A ordem das
+// operações é decidida pelo Engine, igual para todas as plataformas.
+type Backend interface {
+	// Name identifica o backend nas mensagens
+	Name() string
+	// Attach libera o dispositivo para escrita e retorna o caminho usado
+	// pelas operações seguintes; Detach o devolve ao sistema
+	Attach(device string) (string, error)
+	Detach(device string) error
+	// Wipe apaga assinaturas de sistemas de arquivos e tabelas de partição
+	Wipe(device string) error
+	// WriteImage grava a imagem no início do dispositivo
+	WriteImage(device, image string) error
+	// NewTable cria uma tabela GPT vazia; RelocateTable move a cópia da GPT
+	// de uma imagem gravada para o fim do dispositivo
+	NewTable(device string) error
+	RelocateTable(device string) error
+	// AddPartition cria uma partição e retorna seu caminho
+	AddPartition(device, label string, sizeMiB int64) (string, error)
+	// Format cria um sistema de arquivos FAT32 na partição
+	Format(partition, label string) error
+	// CopyFiles copia arquivos e diretórios para a raiz da partição
+	CopyFiles(partition string, sources []string) error
+	// Sync garante que tudo foi gravado no dispositivo
+	Sync(device string) error
+	// HashFiles lê de volta a partição e retorna o SHA256 de cada arquivo,
+	// indexado pelo caminho relativo à raiz
+	HashFiles(partition string) (map[string]string, error)
+	// FindPartition localiza pelo nome GPT uma partição já existente
+	FindPartition(device, label string) (string, error)
+	// ReadAt lê length bytes do dispositivo a partir de offset
+	ReadAt(device string, offset, length int64) ([]byte, error)
+	// HashRange calcula o SHA256 de length bytes a partir de offset
+	HashRange(device string, offset, length int64) (string, error)
+	// ReadFile lê um arquivo da raiz da partição
+	ReadFile(partition, name string) ([]byte, error)
+}
+
+// ProgressFunc recebe o início de cada etapa
+type ProgressFunc func(step Step, message string)
+
+// Guard confere o dispositivo imediatamente antes da gravação; um erro
+// cancela o plano sem tocar no dispositivo
+type Guard func(device string) error
+
+// StepError indica a etapa em que o provisionamento falhou
+type StepError struct {
+	Step Step
+	Err  error
+}
+
+func (e *StepError) Error() string {
+	return fmt.Sprintf("etapa %s: %v", e.Step, e.Err)
+}
+
+func (e *StepError) Unwrap() error {
+	return e.Err
+}
+
+// Result descreve o que foi gravado
+type Result struct {
+	Device string
+	// Partitions mapeia o rótulo de cada partição criada ao seu caminho
+	Partitions map[string]string
+	// Report é o resultado da releitura da mídia; nil com SkipVerify
+	Report *Report
+}
+
+// Engine executa planos de provisionamento sobre um Backend
+type Engine struct {
+	backend  Backend
+	progress ProgressFunc
+	guard    Guard
+}
+
+// NewEngine cria um Engine para o backend
+func NewEngine(backend Backend) *Engine {
+	return &Engine{backend: backend}
+}
+
+// SetProgress define a função que recebe o andamento do pipeline
+func (e *Engine) SetProgress(fn ProgressFunc) {
+	e.progress = fn
+}
+
+// SetGuard define a conferência feita antes de gravar o dispositivo, como
+// a da identidade do disco escolhido (IdentityGuard)
+func (e *Engine) SetGuard(fn Guard) {
+	e.guard = fn
+}
+
+// Backend retorna o backend do Engine
+func (e *Engine) Backend() Backend {
+	return e.backend
+}
+
+func (e *Engine) report(step Step, format string, args ...interface{}) {
+	if e.progress != nil {
+		e.progress(step, fmt.Sprintf(format, args...))
+	}
+}
+
+// Run executa o plano no dispositivo: wipe, copy, partition, format, seed
+// e verify. Um erro interrompe o pipeline e vem como *StepError.
+func (e *Engine) Run(device string, plan *Plan) (*Result, error) {
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	if e.guard != nil {
+		if err := e.guard(device); err != nil {
+			return nil, &StepError{StepWipe, err}
+		}
+	}
+
+	dev, err := e.backend.Attach(device)
+	if err != nil {
+		return nil, &StepError{StepWipe, fmt.Errorf("falha ao preparar dispositivo: %w", err)}
+	}
+	result, err := e.run(dev, plan)
+	if detachErr := e.backend.Detach(dev); detachErr != nil && err == nil {
+		err = fmt.Errorf("falha ao liberar dispositivo: %w", detachErr)
+	}
+	return result, err
+}
+
+func (e *Engine) run(dev string, plan *Plan) (*Result, error) {
+	b := e.backend
+	result := &Result{Device: dev, Partitions: map[string]string{}}
+	fail := func(step Step, err error) (*Result, error) {
+		return result, &StepError{step, err}
+	}
+
+	e.report(StepWipe, "Apagando %s", dev)
+	if err := b.Wipe(dev); err != nil {
+		return fail(StepWipe, err)
+	}
+
+	if plan.Image != "" {
+		e.report(StepCopy, "Gravando %s", plan.Image)
+		if err := b.WriteImage(dev, plan.Image); err != nil {
+			return fail(StepCopy, err)
+		}
+	}
+
+	if plan.Image != "" {
+		e.report(StepPartition, "Ajustando tabela de partições")
+		if err := b.RelocateTable(dev); err != nil {
+			return fail(StepPartition, err)
+		}
+	} else {
+		e.report(StepPartition, "Criando tabela de partições")
+		if err := b.NewTable(dev); err != nil {
+			return fail(StepPartition, err)
+		}
+	}
+	for _, p := range plan.Partitions {
+		e.report(StepPartition, "Criando partição %s", p.Label)
+		path, err := b.AddPartition(dev, p.Label, p.SizeMiB)
+		if err != nil {
+			return fail(StepPartition, fmt.Errorf("partição %s: %w", p.Label, err))
+		}
+		result.Partitions[p.Label] = path
+	}
+
+	for _, p := range plan.Partitions {
+		e.report(StepFormat, "Formatando %s", p.Label)
+		if err := b.Format(result.Partitions[p.Label], p.Label); err != nil {
+			return fail(StepFormat, fmt.Errorf("partição %s: %w", p.Label, err))
+		}
+	}
+
+	for _, p := range plan.Partitions {
+		if len(p.Sources) == 0 {
+			continue
+		}
+		e.report(StepSeed, "Copiando arquivos para %s", p.Label)
+		if err := b.CopyFiles(result.Partitions[p.Label], p.Sources); err != nil {
+			return fail(StepSeed, fmt.Errorf("partição %s: %w", p.Label, err))
+		}
+	}
+	if err := b.Sync(dev); err != nil {
+		return fail(StepSeed, err)
+	}
+
+	if plan.SkipVerify {
+		return result, nil
+	}
+	result.Report = &Report{Device: dev}
+	e.inspect(result.Report, dev, result.Partitions, plan, plan.Image != "")
+	if !result.Report.Passed() {
+		return fail(StepVerify, &VerifyError{result.Report})
+	}
+	return result, nil
+}
+
+// Validate confere o plano antes de tocar no dispositivo
+func (p *Plan) Validate() error {
+	if len(p.Partitions) == 0 {
+		return fmt.Errorf("plano sem partições")
+	}
+	seen := map[string]bool{}
+	for i, part := range p.Partitions {
+		if !validLabel(part.Label) {
+			return fmt.Errorf("rótulo de partição inválido: %q (até 11 caracteres A-Z, 0-9, _ ou -)", part.Label)
+		}
+		if seen[part.Label] {
+			return fmt.Errorf("rótulo de partição repetido: %s", part.Label)
+		}
+		seen[part.Label] = true
+		if part.SizeMiB < 0 || (part.SizeMiB == 0 && i != len(p.Partitions)-1) {
+			return fmt.Errorf("partição %s: só a última partição pode ocupar o restante do dispositivo", part.Label)
+		}
+	}
+	return nil
+}
+
+// validLabel aceita rótulos FAT válidos que também servem como nome de
+// partição GPT sem aspas
+func validLabel(label string) bool {
+	if label == "" || len(label) > 11 {
+		return false
+	}
+	for _, c := range label {
+		if !(c >= 'A' && c <= 'Z') && !(c >= '0' && c <= '9') && c != '_' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateUSB implementa Creator
+func (e *Engine) CreateUSB(devicePath string, plan *Plan) error {
+	_, err := e.Run(devicePath, plan)
+	return err
+}
+
+// FormatDevice implementa Formatter: uma tabela GPT nova com uma única
+// partição FAT32 ocupando o dispositivo
+func (e *Engine) FormatDevice(devicePath, label string) error {
+	_, err := e.Run(devicePath, &Plan{Partitions: []PartitionSpec{{Label: label}}})
+	return err
+}
+
+// Creator grava um plano de provisionamento em um dispositivo
+type Creator interface {
+	CreateUSB(devicePath string, plan *Plan) error
+}
+
+// Formatter formata um dispositivo com uma única partição FAT32
+type Formatter interface {
+	FormatDevice(devicePath, label string) error
+}
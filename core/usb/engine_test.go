@@ -0,0 +1,300 @@
+package usb_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/usb"
+)
+
+func seedFiles(t *testing.T) (files []string, bundleDir string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"user-data", "meta-data", "network-config"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name+" content"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+	usb.WriteTestISO(t, filepath.Join(dir, "ubuntu.iso"), map[string]string{
+		"boot/grub/grub.cfg": "menuentry \"Ubuntu\" {\n\tlinux /casper/vmlinuz\n\tinitrd /casper/initrd\n}\n",
+		"casper/vmlinuz":     "kernel",
+		"casper/initrd":      "initrd",
+	})
+	bundleDir = filepath.Join(dir, "offline")
+	if err := os.MkdirAll(filepath.Join(bundleDir, "apt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(bundleDir, "apt", "Packages"), []byte("index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return files, bundleDir
+}
+
+func noCloudPlan(files []string, bundleDir string) *usb.Plan {
+	return &usb.Plan{
+		Image: filepath.Join(filepath.Dir(files[0]), "ubuntu.iso"),
+		Partitions: []usb.PartitionSpec{
+			{Label: "CIDATA", SizeMiB: 128, Sources: files},
+			{Label: "SYNOFFLINE", SizeMiB: 512, Sources: []string{filepath.Join(bundleDir, "apt")}},
+		},
+	}
+}
+
+func TestRunNoCloudPlan(t *testing.T) {
+	files, bundleDir := seedFiles(t)
+	dry := usb.NewDryRun()
+	var steps []usb.Step
+	engine := usb.NewEngine(dry)
+	engine.SetProgress(func(step usb.Step, _ string) {
+		if len(steps) == 0 || steps[len(steps)-1] != step {
+			steps = append(steps, step)
+		}
+	})
+
+	result, err := engine.Run("/dev/sdb", noCloudPlan(files, bundleDir))
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	want := []string{
+		"attach", "wipe", "write-image", "relocate-table",
+		"add-partition", "add-partition", "format", "format",
+		"copy-files", "copy-files", "sync", "hash-range", "hash-files", "hash-files", "detach",
+	}
+	if got := dry.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ops = %v, want %v", got, want)
+	}
+	if !reflect.DeepEqual(steps, usb.Steps) {
+		t.Errorf("steps = %v, want %v", steps, usb.Steps)
+	}
+	if result.Partitions["CIDATA"] != "/dev/sdb2" || result.Partitions["SYNOFFLINE"] != "/dev/sdb3" {
+		t.Errorf("partitions = %v", result.Partitions)
+	}
+	var checks []string
+	for _, c := range result.Report.Checks {
+		checks = append(checks, c.Name)
+	}
+	if !reflect.DeepEqual(checks, []string{"image", "grub", "CIDATA", "SYNOFFLINE"}) || !result.Report.Passed() {
+		t.Errorf("report = %+v", result.Report)
+	}
+}
+
+func TestFormatDevice(t *testing.T) {
+	dry := usb.NewDryRun()
+	if err := usb.NewEngine(dry).FormatDevice("/dev/nvme1n1", "SYNTROPY"); err != nil {
+		t.Fatalf("FormatDevice: %v", err)
+	}
+
+	want := []string{"attach", "wipe", "new-table", "add-partition", "format", "sync", "detach"}
+	if got := dry.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ops = %v, want %v", got, want)
+	}
+	if format := dry.Ops[4]; format.Args[0] != "/dev/nvme1n1p1" {
+		t.Errorf("formatted %v, want /dev/nvme1n1p1", format.Args)
+	}
+}
+
+func TestRunReportsFailedStep(t *testing.T) {
+	files, bundleDir := seedFiles(t)
+	dry := usb.NewDryRun()
+	dry.Fail = "format"
+
+	_, err := usb.NewEngine(dry).Run("/dev/sdb", noCloudPlan(files, bundleDir))
+	var stepErr *usb.StepError
+	if !errors.As(err, &stepErr) || stepErr.Step != usb.StepFormat {
+		t.Fatalf("err = %v, want StepError at format", err)
+	}
+	names := dry.Names()
+	if names[len(names)-1] != "detach" {
+		t.Errorf("device not detached after failure: %v", names)
+	}
+	for _, name := range names {
+		if name == "copy-files" {
+			t.Errorf("pipeline continued after failure: %v", names)
+		}
+	}
+}
+
+// corrupting simula uma gravação que alterou um arquivo
+type corrupting struct {
+	*usb.DryRun
+}
+
+func (c corrupting) HashFiles(partition string) (map[string]string, error) {
+	sums, err := c.DryRun.HashFiles(partition)
+	if _, ok := sums["user-data"]; ok {
+		sums["user-data"] = strings.Repeat("0", 64)
+	}
+	delete(sums, "meta-data")
+	return sums, err
+}
+
+func TestRunDetectsBadWrite(t *testing.T) {
+	files, bundleDir := seedFiles(t)
+	_, err := usb.NewEngine(corrupting{usb.NewDryRun()}).Run("/dev/sdb", noCloudPlan(files, bundleDir))
+
+	var stepErr *usb.StepError
+	if !errors.As(err, &stepErr) || stepErr.Step != usb.StepVerify {
+		t.Fatalf("err = %v, want StepError at verify", err)
+	}
+	for _, want := range []string{"CIDATA", "user-data: conteúdo diferente", "meta-data: ausente"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+// seedCheck confere se o user-data começa com #cloud-config
+var seedCheck = usb.FileCheck{
+	Name:      "user-data",
+	Partition: "CIDATA",
+	File:      "user-data",
+	Check: func(data []byte) (string, error) {
+		if !strings.HasPrefix(string(data), "#cloud-config") {
+			return "", errors.New("sem #cloud-config")
+		}
+		return "ok", nil
+	},
+}
+
+func TestRunFileChecks(t *testing.T) {
+	files, bundleDir := seedFiles(t)
+	plan := noCloudPlan(files, bundleDir)
+	plan.Checks = []usb.FileCheck{seedCheck}
+
+	_, err := usb.NewEngine(usb.NewDryRun()).Run("/dev/sdb", plan)
+	var verifyErr *usb.VerifyError
+	if !errors.As(err, &verifyErr) {
+		t.Fatalf("err = %v, want VerifyError", err)
+	}
+	failed := verifyErr.Report.Failed()
+	if len(failed) != 1 || failed[0].Name != "user-data" || failed[0].Detail != "sem #cloud-config" {
+		t.Errorf("failed = %+v", failed)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	files, bundleDir := seedFiles(t)
+	plan := noCloudPlan(files, bundleDir)
+	dry := usb.NewDryRun()
+	engine := usb.NewEngine(dry)
+	if _, err := engine.Run("/dev/sdb", plan); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	dry.Ops = nil
+	report, err := engine.Verify("/dev/sdb", plan)
+	if err != nil || !report.Passed() {
+		t.Fatalf("Verify = %+v, %v", report, err)
+	}
+	for _, name := range dry.Names() {
+		if name == "wipe" || name == "write-image" || name == "format" || name == "copy-files" {
+			t.Errorf("Verify wrote to the device: %v", dry.Names())
+		}
+	}
+
+	// Uma mídia sem a partição offline falha só nessa verificação
+	plan.Partitions = append(plan.Partitions, usb.PartitionSpec{Label: "EXTRA", Sources: files})
+	report, err = engine.Verify("/dev/sdb", plan)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Name != "EXTRA" {
+		t.Errorf("failed = %+v", failed)
+	}
+
+	// Uma ISO diferente da gravada não confere
+	other := filepath.Join(t.TempDir(), "other.iso")
+	usb.WriteTestISO(t, other, map[string]string{"boot/grub/grub.cfg": "set timeout=5\n"})
+	plan.Image = other
+	plan.Partitions = plan.Partitions[:2]
+	report, _ = engine.Verify("/dev/sdb", plan)
+	if failed := report.Failed(); len(failed) != 1 || failed[0].Name != "image" {
+		t.Errorf("failed = %+v", failed)
+	}
+}
+
+func TestPlanValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		plan usb.Plan
+	}{
+		{"no partitions", usb.Plan{}},
+		{"lowercase label", usb.Plan{Partitions: []usb.PartitionSpec{{Label: "cidata"}}}},
+		{"long label", usb.Plan{Partitions: []usb.PartitionSpec{{Label: "SYNTROPYOFFLINE"}}}},
+		{"duplicate label", usb.Plan{Partitions: []usb.PartitionSpec{{Label: "A", SizeMiB: 1}, {Label: "A"}}}},
+		{"fill before last", usb.Plan{Partitions: []usb.PartitionSpec{{Label: "A"}, {Label: "B", SizeMiB: 1}}}},
+	}
+	for _, tt := range tests {
+		dry := usb.NewDryRun()
+		if _, err := usb.NewEngine(dry).Run("/dev/sdb", &tt.plan); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+		if len(dry.Ops) != 0 {
+			t.Errorf("%s: device touched by invalid plan: %v", tt.name, dry.Names())
+		}
+	}
+}
+
+func TestRunGuard(t *testing.T) {
+	chosen := &usb.Identity{Device: "/dev/sdb", ID: "/dev/disk/by-id/usb-SanDisk_Cruzer-0:0", Serial: "4C53", SizeBytes: 16 << 30}
+	current := *chosen
+	current.ID, current.Serial = "/dev/disk/by-id/usb-Kingston_DT-0:0", "0019"
+
+	dry := usb.NewDryRun()
+	engine := usb.NewEngine(dry)
+	engine.SetGuard(func(device string) error { return chosen.Check(&current) })
+	err := engine.FormatDevice("/dev/sdb", "DATA")
+	var stepErr *usb.StepError
+	if !errors.As(err, &stepErr) || !strings.Contains(err.Error(), "mudou desde a seleção") {
+		t.Fatalf("expected identity error, got %v", err)
+	}
+	if len(dry.Ops) != 0 {
+		t.Errorf("device touched after identity changed: %v", dry.Names())
+	}
+
+	engine.SetGuard(func(device string) error { return chosen.Check(chosen) })
+	if err := engine.FormatDevice("/dev/sdb", "DATA"); err != nil {
+		t.Errorf("same identity: %v", err)
+	}
+}
+
+func TestPartitionDevice(t *testing.T) {
+	tests := map[string]string{
+		"/dev/sdb":     "/dev/sdb2",
+		"/dev/nvme0n1": "/dev/nvme0n1p2",
+		"/dev/mmcblk0": "/dev/mmcblk0p2",
+		"/dev/loop3":   "/dev/loop3p2",
+	}
+	for device, want := range tests {
+		if got := usb.PartitionDevice(device, 2); got != want {
+			t.Errorf("PartitionDevice(%s) = %s, want %s", device, got, want)
+		}
+	}
+}
+
+func TestParsePhysicalDrive(t *testing.T) {
+	for _, device := range []string{"PHYSICALDRIVE2", `\\.\PHYSICALDRIVE2`, "PhysicalDrive2"} {
+		if n, err := usb.ParsePhysicalDrive(device); err != nil || n != 2 {
+			t.Errorf("ParsePhysicalDrive(%s) = %d, %v", device, n, err)
+		}
+	}
+	if _, err := usb.ParsePhysicalDrive("/dev/sdb"); err == nil {
+		t.Error("expected error for a Linux device")
+	}
+}
+
+func TestWindowsToWSLPath(t *testing.T) {
+	if got := usb.WindowsToWSLPath(`C:\Users\ana\.syntropy\cache\ubuntu.iso`); got != "/mnt/c/Users/ana/.syntropy/cache/ubuntu.iso" {
+		t.Errorf("got %s", got)
+	}
+	if got := usb.WindowsToWSLPath("/home/ana/ubuntu.iso"); got != "/home/ana/ubuntu.iso" {
+		t.Errorf("got %s", got)
+	}
+}
@@ -0,0 +1,66 @@
+package usb
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// gptPartition é uma linha da saída de "sgdisk -p"
+type gptPartition struct {
+	Number     int
+	Start, End int64
+	Name       string
+}
+
+// parseSgdisk interpreta a tabela impressa por "sgdisk -p"
+func parseSgdisk(output string) []gptPartition {
+	var parts []gptPartition
+	for _, line := range strings.Split(output, "\n") {
+		// Number  Start  End  Size Unit  Code  Name
+		fields := strings.Fields(line)
+		if len(fields) < 7 {
+			continue
+		}
+		number, err1 := strconv.Atoi(fields[0])
+		start, err2 := strconv.ParseInt(fields[1], 10, 64)
+		end, err3 := strconv.ParseInt(fields[2], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil || end < start {
+			continue
+		}
+		parts = append(parts, gptPartition{number, start, end, fields[len(fields)-1]})
+	}
+	return parts
+}
+
+// findPartition procura pelo nome a partição na saída de "sgdisk -p"
+func findPartition(output, name string) (gptPartition, bool) {
+	for _, p := range parseSgdisk(output) {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return gptPartition{}, false
+}
+
+// PartitionDevice retorna o dispositivo da partição n, com o "p" usado
+// por discos NVMe, cartões MMC e dispositivos loop
+func PartitionDevice(device string, n int) string {
+	if len(device) > 0 && device[len(device)-1] >= '0' && device[len(device)-1] <= '9' {
+		return fmt.Sprintf("%sp%d", device, n)
+	}
+	return fmt.Sprintf("%s%d", device, n)
+}
+
+var physicalDrivePattern = regexp.MustCompile(`(?i)^(?:\\\\\.\\)?PHYSICALDRIVE(\d+)$`)
+
+// ParsePhysicalDrive extrai o número do disco de PHYSICALDRIVEn ou
+// \\.\PHYSICALDRIVEn
+func ParsePhysicalDrive(device string) (int, error) {
+	m := physicalDrivePattern.FindStringSubmatch(device)
+	if m == nil {
+		return 0, fmt.Errorf("formato de dispositivo inválido para Windows: %s (use PHYSICALDRIVEn)", device)
+	}
+	return strconv.Atoi(m[1])
+}
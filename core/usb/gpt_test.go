@@ -0,0 +1,45 @@
+package usb
+
+import "testing"
+
+const sgdiskOutput = `Disk /dev/sdb: 60437492 sectors, 28.8 GiB
+Model: Flash Drive
+Sector size (logical/physical): 512/512 bytes
+Disk identifier (GUID): 6A6D3C7E-1F0B-4E0A-9E43-2C9A3B0D2E11
+Partition table holds up to 248 entries
+First usable sector is 64, last usable sector is 60437428
+Total free space is 57010000 sectors (27.2 GiB)
+
+Number  Start (sector)    End (sector)  Size       Code  Name
+   1              64         5408723   2.6 GiB     0700  ISO9660
+   2         5408724         5418883   5.0 MiB     EF00  Appended2
+   3         5419008         5681151   128.0 MiB   0700  CIDATA
+   4         5681152         6729727   512.0 MiB   0700  SYNOFFLINE
+`
+
+func TestFindPartition(t *testing.T) {
+	p, ok := findPartition(sgdiskOutput, "CIDATA")
+	if !ok || p.Number != 3 || p.Start != 5419008 || p.End != 5681151 {
+		t.Fatalf("CIDATA = %+v, %v", p, ok)
+	}
+	if p, ok := findPartition(sgdiskOutput, "SYNOFFLINE"); !ok || p.Number != 4 {
+		t.Fatalf("SYNOFFLINE = %+v, %v", p, ok)
+	}
+	if _, ok := findPartition(sgdiskOutput, "MISSING"); ok {
+		t.Fatal("found a partition that does not exist")
+	}
+}
+
+func TestParseSHA256Sums(t *testing.T) {
+	sum := "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	sums, err := parseSHA256Sums(sum + "  ./user-data\n" + sum + "  ./apt/Packages\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sums["user-data"] != sum || sums["apt/Packages"] != sum || len(sums) != 2 {
+		t.Fatalf("sums = %v", sums)
+	}
+	if _, err := parseSHA256Sums("garbage\n"); err == nil {
+		t.Fatal("expected error for malformed line")
+	}
+}
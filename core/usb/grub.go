@@ -0,0 +1,177 @@
+package usb
+
+import (
+	"fmt"
+	"strings"
+)
+
+// grubConfigPath é o menu de boot das ISOs do Ubuntu, usado tanto em BIOS
+// quanto em UEFI
+const grubConfigPath = "boot/grub/grub.cfg"
+
+// grubEntry é um menuentry do GRUB com o kernel e o initrd que carrega
+type grubEntry struct {
+	Title  string
+	Kernel string
+	Initrd []string
+}
+
+// parseGrubConfig confere a sintaxe do grub.cfg (aspas e chaves
+// balanceadas) e retorna suas entradas de menu
+func parseGrubConfig(data string) ([]grubEntry, error) {
+	var entries []grubEntry
+	var current *grubEntry
+	depth := 0
+	entryDepth := -1
+
+	for i, line := range strings.Split(data, "\n") {
+		words, err := grubWords(line)
+		if err != nil {
+			return nil, fmt.Errorf("linha %d: %w", i+1, err)
+		}
+		for len(words) > 0 {
+			// "}" fecha o bloco mesmo quando seguido de outro comando
+			if words[0] == "}" {
+				depth--
+				if depth < 0 {
+					return nil, fmt.Errorf("linha %d: \"}\" sem bloco aberto", i+1)
+				}
+				if depth == entryDepth {
+					entries = append(entries, *current)
+					current, entryDepth = nil, -1
+				}
+				words = words[1:]
+				continue
+			}
+
+			cmd := words
+			for j, w := range words {
+				if w == ";" || w == "}" {
+					cmd = words[:j]
+					break
+				}
+			}
+			words = words[len(cmd):]
+			if len(words) > 0 && words[0] == ";" {
+				words = words[1:]
+			}
+			if len(cmd) == 0 {
+				continue
+			}
+
+			opens := cmd[len(cmd)-1] == "{"
+			if opens {
+				cmd = cmd[:len(cmd)-1]
+			}
+			switch {
+			case len(cmd) == 0:
+			case cmd[0] == "menuentry":
+				if current != nil {
+					return nil, fmt.Errorf("linha %d: menuentry dentro de outro menuentry", i+1)
+				}
+				if len(cmd) < 2 {
+					return nil, fmt.Errorf("linha %d: menuentry sem título", i+1)
+				}
+				if !opens {
+					return nil, fmt.Errorf("linha %d: menuentry sem \"{\"", i+1)
+				}
+				current, entryDepth = &grubEntry{Title: cmd[1]}, depth
+			case current != nil && (cmd[0] == "linux" || cmd[0] == "linuxefi") && len(cmd) > 1:
+				current.Kernel = cmd[1]
+			case current != nil && (cmd[0] == "initrd" || cmd[0] == "initrdefi"):
+				current.Initrd = append(current.Initrd, cmd[1:]...)
+			}
+			if opens {
+				depth++
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("%d bloco(s) sem \"}\"", depth)
+	}
+	return entries, nil
+}
+
+// grubWords divide uma linha em palavras como o shell do GRUB: aspas
+// simples e duplas, barra invertida e comentários iniciados por "#". ";",
+// "{" e "}" isolados viram palavras próprias.
+func grubWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r':
+			flush()
+		case c == '#' && !inWord:
+			return words, nil
+		case c == ';' || ((c == '{' || c == '}') && !inWord):
+			flush()
+			words = append(words, string(c))
+		case c == '\\' && i+1 < len(line):
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case c == '\'' || c == '"':
+			end := strings.IndexByte(line[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("aspas %c sem fechamento", c)
+			}
+			word.WriteString(line[i+1 : i+1+end])
+			i += end + 1
+			inWord = true
+		default:
+			word.WriteByte(c)
+			inWord = true
+		}
+	}
+	flush()
+	return words, nil
+}
+
+// checkGrub confere se o grub.cfg da ISO é válido e se o kernel e o initrd
+// de cada entrada existem na ISO
+func checkGrub(fs *isoFS) (string, error) {
+	data, err := fs.ReadFile(grubConfigPath)
+	if err != nil {
+		return "", err
+	}
+	entries, err := parseGrubConfig(string(data))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", grubConfigPath, err)
+	}
+
+	bootable := 0
+	var problems []string
+	for _, entry := range entries {
+		if entry.Kernel == "" {
+			continue
+		}
+		bootable++
+		for _, path := range append([]string{entry.Kernel}, entry.Initrd...) {
+			// Caminhos com variáveis ou dispositivos só se resolvem no boot
+			if strings.ContainsAny(path, "$(") {
+				continue
+			}
+			if !fs.Exists(path) {
+				problems = append(problems, fmt.Sprintf("%q: %s não encontrado", entry.Title, path))
+			}
+		}
+	}
+	if bootable == 0 {
+		return "", fmt.Errorf("%s sem entradas de boot com kernel", grubConfigPath)
+	}
+	if len(problems) > 0 {
+		return "", fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return fmt.Sprintf("%d entradas de boot", bootable), nil
+}
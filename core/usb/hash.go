@@ -0,0 +1,96 @@
+package usb
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HashSources calcula o SHA256 dos arquivos que CopyFiles grava a partir de
+// sources: um arquivo fica na raiz com seu nome, um diretório mantém o nome
+// e a estrutura interna
+func HashSources(sources []string) (map[string]string, error) {
+	sums := map[string]string{}
+	for _, src := range sources {
+		info, err := os.Stat(src)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			sum, err := hashFile(src)
+			if err != nil {
+				return nil, err
+			}
+			sums[filepath.Base(src)] = sum
+			continue
+		}
+		tree, err := hashTree(src)
+		if err != nil {
+			return nil, err
+		}
+		for path, sum := range tree {
+			sums[filepath.Base(src)+"/"+path] = sum
+		}
+	}
+	return sums, nil
+}
+
+// hashTree calcula o SHA256 de todos os arquivos sob root
+func hashTree(root string) (map[string]string, error) {
+	sums := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		sums[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	return sums, err
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parseSHA256Sums interpreta a saída de "sha256sum", com caminhos relativos
+// ao diretório em que foi executado
+func parseSHA256Sums(output string) (map[string]string, error) {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		sum, path, ok := strings.Cut(line, "  ")
+		if !ok || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("linha inválida na saída do sha256sum: %q", line)
+		}
+		sums[strings.TrimPrefix(path, "./")] = sum
+	}
+	return sums, scanner.Err()
+}
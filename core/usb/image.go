@@ -0,0 +1,279 @@
+package usb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	// imageSectorSize é o setor lógico usado pelo sgdisk em arquivos
+	imageSectorSize = 512
+	mib             = 1 << 20
+)
+
+// ImageBackend grava o plano em um arquivo de imagem raw sem montar nada,
+// portanto sem root. Cada partição é formatada em um arquivo à parte com
+// mkfs.vfat e mtools e copiada para seu deslocamento no Sync.
+type ImageBackend struct {
+	Runner Runner
+	// WorkDir guarda os sistemas de arquivos intermediários
+	WorkDir string
+
+	path  string
+	parts map[string]*imagePartition
+}
+
+// imagePartition é uma partição da imagem, identificada no formato
+// "<imagem>@@<deslocamento>" aceito pelo mtools
+type imagePartition struct {
+	offset int64
+	size   int64
+	fat    string
+}
+
+// NewImageBackend cria um backend para imagens de disco
+func NewImageBackend(workDir string) *ImageBackend {
+	return &ImageBackend{Runner: &LocalRunner{}, WorkDir: workDir}
+}
+
+// Name implementa Backend
+func (b *ImageBackend) Name() string {
+	return "image"
+}
+
+// Attach implementa Backend
+func (b *ImageBackend) Attach(device string) (string, error) {
+	path, err := filepath.Abs(device)
+	if err != nil {
+		return "", err
+	}
+	b.path = path
+	b.parts = map[string]*imagePartition{}
+	return path, os.MkdirAll(b.WorkDir, 0755)
+}
+
+// Detach remove os sistemas de arquivos intermediários
+func (b *ImageBackend) Detach(device string) error {
+	for _, p := range b.parts {
+		if p.fat != "" {
+			os.Remove(p.fat)
+		}
+	}
+	return nil
+}
+
+// Wipe implementa Backend
+func (b *ImageBackend) Wipe(device string) error {
+	return os.WriteFile(device, nil, 0644)
+}
+
+// WriteImage implementa Backend
+func (b *ImageBackend) WriteImage(device, image string) error {
+	_, err := copyFile(image, device)
+	return err
+}
+
+// NewTable implementa Backend
+func (b *ImageBackend) NewTable(device string) error {
+	// Espaço para as duas cópias da GPT com alinhamento de 1MiB
+	if err := os.Truncate(device, 2*mib); err != nil {
+		return err
+	}
+	return b.Runner.Run(30*time.Second, "sgdisk", "-o", device)
+}
+
+// RelocateTable implementa Backend
+func (b *ImageBackend) RelocateTable(device string) error {
+	return b.grow(device, 0)
+}
+
+// AddPartition aumenta a imagem para caber a partição e a cria
+func (b *ImageBackend) AddPartition(device, label string, sizeMiB int64) (string, error) {
+	if sizeMiB <= 0 {
+		return "", fmt.Errorf("partições de imagens precisam de tamanho")
+	}
+	// 1MiB de folga para o alinhamento e a cópia da GPT no fim
+	if err := b.grow(device, (sizeMiB+1)*mib); err != nil {
+		return "", err
+	}
+	if err := b.Runner.Run(30*time.Second, "sgdisk", "-n", fmt.Sprintf("0:0:+%dMiB", sizeMiB),
+		"-t", "0:0700", "-c", "0:"+label, device); err != nil {
+		return "", err
+	}
+
+	return b.FindPartition(device, label)
+}
+
+// FindPartition implementa Backend
+func (b *ImageBackend) FindPartition(device, label string) (string, error) {
+	out, err := b.Runner.Output(30*time.Second, "sgdisk", "-p", device)
+	if err != nil {
+		return "", err
+	}
+	part, ok := findPartition(string(out), label)
+	if !ok {
+		return "", fmt.Errorf("partição %s não encontrada na imagem", label)
+	}
+	offset := part.Start * imageSectorSize
+	handle := fmt.Sprintf("%s@@%d", device, offset)
+	b.parts[handle] = &imagePartition{offset: offset, size: (part.End - part.Start + 1) * imageSectorSize}
+	return handle, nil
+}
+
+// Format cria o sistema de arquivos em um arquivo intermediário
+func (b *ImageBackend) Format(partition, label string) error {
+	p, ok := b.parts[partition]
+	if !ok {
+		return fmt.Errorf("partição desconhecida: %s", partition)
+	}
+	p.fat = filepath.Join(b.WorkDir, label+".fat")
+	os.Remove(p.fat)
+	return b.Runner.Run(2*time.Minute, "mkfs.vfat", "-C", "-F", "32", "-n", label,
+		p.fat, strconv.FormatInt(p.size/1024, 10))
+}
+
+// CopyFiles implementa Backend
+func (b *ImageBackend) CopyFiles(partition string, sources []string) error {
+	p, ok := b.parts[partition]
+	if !ok || p.fat == "" {
+		return fmt.Errorf("partição não formatada: %s", partition)
+	}
+	args := append([]string{"-s", "-i", p.fat}, sources...)
+	return b.Runner.Run(60*time.Minute, "mcopy", append(args, "::")...)
+}
+
+// Sync grava os sistemas de arquivos intermediários na imagem
+func (b *ImageBackend) Sync(device string) error {
+	for _, p := range b.parts {
+		if p.fat == "" {
+			continue
+		}
+		if err := writeAt(device, p.fat, p.offset); err != nil {
+			return err
+		}
+		os.Remove(p.fat)
+		p.fat = ""
+	}
+	return nil
+}
+
+// HashFiles extrai a partição direto da imagem com o mtools
+func (b *ImageBackend) HashFiles(partition string) (map[string]string, error) {
+	dir, err := os.MkdirTemp(b.WorkDir, "verify-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err := b.Runner.Run(60*time.Minute, "mcopy", "-s", "-n", "-i", partition, "::*", dir); err != nil {
+		return nil, err
+	}
+	return hashTree(dir)
+}
+
+// ReadAt implementa Backend
+func (b *ImageBackend) ReadAt(device string, offset, length int64) ([]byte, error) {
+	f, err := os.Open(device)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data := make([]byte, length)
+	n, err := f.ReadAt(data, offset)
+	if err == io.EOF {
+		err = nil
+	}
+	return data[:n], err
+}
+
+// HashRange implementa Backend
+func (b *ImageBackend) HashRange(device string, offset, length int64) (string, error) {
+	f, err := os.Open(device)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, offset, length)); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ReadFile extrai um arquivo da partição com o mtools
+func (b *ImageBackend) ReadFile(partition, name string) ([]byte, error) {
+	dir, err := os.MkdirTemp(b.WorkDir, "read-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	dst := filepath.Join(dir, filepath.Base(name))
+	if err := b.Runner.Run(60*time.Second, "mcopy", "-n", "-i", partition, "::"+name, dst); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(dst)
+}
+
+// grow arredonda a imagem para MiB, acrescenta extra bytes e move a cópia
+// da GPT para o novo fim
+func (b *ImageBackend) grow(device string, extra int64) error {
+	info, err := os.Stat(device)
+	if err != nil {
+		return err
+	}
+	size := (info.Size()+mib-1)/mib*mib + extra
+	if err := os.Truncate(device, size); err != nil {
+		return fmt.Errorf("falha ao redimensionar imagem: %w", err)
+	}
+	return b.Runner.Run(30*time.Second, "sgdisk", "-e", device)
+}
+
+// copyFile copia src para dst e retorna o número de bytes copiados
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return n, err
+	}
+	return n, out.Close()
+}
+
+// writeAt grava o conteúdo de src em dst a partir de offset, sem truncar dst
+func writeAt(dst, src string, offset int64) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		out.Close()
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
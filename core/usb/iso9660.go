@@ -0,0 +1,187 @@
+package usb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// isoSectorSize é o tamanho do bloco lógico do ISO 9660
+const isoSectorSize = 2048
+
+// isoFS lê arquivos de um sistema de arquivos ISO 9660 gravado no início
+// do dispositivo, como o de uma ISO híbrida do Ubuntu. Os nomes Rock Ridge
+// (NM) têm prioridade sobre os nomes ISO em maiúsculas.
+type isoFS struct {
+	r    io.ReaderAt
+	root isoEntry
+}
+
+// isoEntry é um registro de diretório
+type isoEntry struct {
+	name   string
+	extent int64
+	size   int64
+	dir    bool
+}
+
+// openISO lê o descritor de volume primário
+func openISO(r io.ReaderAt) (*isoFS, error) {
+	for sector := int64(16); sector < 32; sector++ {
+		desc := make([]byte, isoSectorSize)
+		if _, err := r.ReadAt(desc, sector*isoSectorSize); err != nil {
+			return nil, fmt.Errorf("falha ao ler descritor ISO 9660: %w", err)
+		}
+		if string(desc[1:6]) != "CD001" {
+			return nil, fmt.Errorf("sistema de arquivos ISO 9660 não encontrado")
+		}
+		switch desc[0] {
+		case 1:
+			root, ok := parseDirRecord(desc[156:190])
+			if !ok {
+				return nil, fmt.Errorf("diretório raiz ISO 9660 inválido")
+			}
+			return &isoFS{r: r, root: root}, nil
+		case 255:
+			return nil, fmt.Errorf("descritor primário ISO 9660 ausente")
+		}
+	}
+	return nil, fmt.Errorf("descritor primário ISO 9660 ausente")
+}
+
+// ReadFile lê o arquivo em path, relativo à raiz
+func (fs *isoFS) ReadFile(path string) ([]byte, error) {
+	entry, err := fs.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+	if entry.dir {
+		return nil, fmt.Errorf("%s é um diretório", path)
+	}
+	data := make([]byte, entry.size)
+	if _, err := fs.r.ReadAt(data, entry.extent*isoSectorSize); err != nil {
+		return nil, fmt.Errorf("falha ao ler %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// Exists informa se path existe na ISO
+func (fs *isoFS) Exists(path string) bool {
+	_, err := fs.lookup(path)
+	return err == nil
+}
+
+func (fs *isoFS) lookup(path string) (isoEntry, error) {
+	entry := fs.root
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		if !entry.dir {
+			return isoEntry{}, fmt.Errorf("%s não encontrado na ISO", path)
+		}
+		entries, err := fs.readDir(entry)
+		if err != nil {
+			return isoEntry{}, err
+		}
+		found := false
+		for _, e := range entries {
+			if isoNameMatches(e.name, part) {
+				entry, found = e, true
+				break
+			}
+		}
+		if !found {
+			return isoEntry{}, fmt.Errorf("%s não encontrado na ISO", path)
+		}
+	}
+	return entry, nil
+}
+
+// readDir lista um diretório, sem as entradas "." e ".."
+func (fs *isoFS) readDir(dir isoEntry) ([]isoEntry, error) {
+	data := make([]byte, dir.size)
+	if _, err := fs.r.ReadAt(data, dir.extent*isoSectorSize); err != nil {
+		return nil, fmt.Errorf("falha ao ler diretório ISO 9660: %w", err)
+	}
+
+	var entries []isoEntry
+	for off := 0; off < len(data); {
+		n := int(data[off])
+		if n == 0 {
+			// Registros não cruzam setores: o restante do setor é preenchimento
+			off = (off/isoSectorSize + 1) * isoSectorSize
+			continue
+		}
+		if off+n > len(data) {
+			break
+		}
+		if e, ok := parseDirRecord(data[off : off+n]); ok && e.name != "" {
+			entries = append(entries, e)
+		}
+		off += n
+	}
+	return entries, nil
+}
+
+// parseDirRecord decodifica um registro de diretório; "." e ".." voltam
+// com nome vazio
+func parseDirRecord(rec []byte) (isoEntry, bool) {
+	if len(rec) < 34 || int(rec[0]) > len(rec) {
+		return isoEntry{}, false
+	}
+	nameLen := int(rec[32])
+	if 33+nameLen > len(rec) {
+		return isoEntry{}, false
+	}
+	e := isoEntry{
+		extent: int64(binary.LittleEndian.Uint32(rec[2:6])),
+		size:   int64(binary.LittleEndian.Uint32(rec[10:14])),
+		dir:    rec[25]&0x02 != 0,
+	}
+	name := rec[33 : 33+nameLen]
+	if nameLen == 1 && (name[0] == 0 || name[0] == 1) {
+		return e, true
+	}
+	e.name = string(name)
+
+	// A área de uso do sistema começa após o nome, alinhada em 2 bytes
+	su := 33 + nameLen
+	if nameLen%2 == 0 {
+		su++
+	}
+	if rr := rockRidgeName(rec[su:rec[0]]); rr != "" {
+		e.name = rr
+	}
+	return e, true
+}
+
+// rockRidgeName extrai o nome das entradas NM da área de uso do sistema
+func rockRidgeName(su []byte) string {
+	var name strings.Builder
+	for len(su) >= 4 {
+		n := int(su[2])
+		if n < 4 || n > len(su) {
+			break
+		}
+		if string(su[0:2]) == "NM" && n >= 5 && su[4]&0x06 == 0 {
+			name.Write(su[5:n])
+		}
+		su = su[n:]
+	}
+	return name.String()
+}
+
+// isoNameMatches compara um nome da ISO com um componente do caminho.
+// Nomes ISO sem Rock Ridge são maiúsculos e terminam em ".;1".
+func isoNameMatches(isoName, want string) bool {
+	if isoName == want {
+		return true
+	}
+	if i := strings.IndexByte(isoName, ';'); i >= 0 {
+		isoName = isoName[:i]
+	}
+	isoName = strings.TrimSuffix(isoName, ".")
+	return strings.EqualFold(isoName, want)
+}
@@ -0,0 +1,250 @@
+package usb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ubuntuGrubCfg segue o menu das ISOs do Ubuntu Server
+const ubuntuGrubCfg = `set timeout=30
+
+loadfont unicode
+
+set menu_color_normal=white/black
+set menu_color_highlight=black/light-gray
+
+menuentry "Try or Install Ubuntu Server" {
+	set gfxpayload=keep
+	linux	/casper/vmlinuz  ---
+	initrd	/casper/initrd
+}
+grub_platform
+if [ "$grub_platform" = "efi" ]; then
+menuentry 'Boot from next volume' {
+	exit 1
+}
+menuentry 'UEFI Firmware Settings' {
+	fwsetup
+}
+fi
+`
+
+// WriteTestISO grava em path um ISO 9660 mínimo com os arquivos dados,
+// seguido de espaço para a cópia da GPT, como em uma ISO híbrida
+func WriteTestISO(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	if err := os.WriteFile(path, buildISO(files, nil), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// buildISO monta a imagem. Os nomes em rockRidge são gravados como Rock
+// Ridge (NM), com um nome ISO truncado.
+func buildISO(files map[string]string, rockRidge map[string]string) []byte {
+	dirs := map[string][]string{"": nil}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for p := name; p != ""; p = parentDir(p) {
+			parent := parentDir(p)
+			if !contains(dirs[parent], p) {
+				dirs[parent] = append(dirs[parent], p)
+			}
+			if _, ok := files[p]; !ok {
+				if _, ok := dirs[p]; !ok {
+					dirs[p] = nil
+				}
+			}
+		}
+	}
+
+	// Um setor por diretório, depois os arquivos
+	lba := map[string]int64{}
+	next := int64(18)
+	var dirNames []string
+	for d := range dirs {
+		dirNames = append(dirNames, d)
+	}
+	sort.Strings(dirNames)
+	for _, d := range dirNames {
+		lba[d] = next
+		next++
+	}
+	for _, name := range names {
+		lba[name] = next
+		next += (int64(len(files[name])) + isoSectorSize - 1) / isoSectorSize
+		if len(files[name]) == 0 {
+			next++
+		}
+	}
+
+	img := make([]byte, next*isoSectorSize+isoBackupGPT)
+	pvd := img[16*isoSectorSize:]
+	pvd[0] = 1
+	copy(pvd[1:6], "CD001")
+	pvd[6] = 1
+	copy(pvd[156:], dirRecord("\x00", lba[""], isoSectorSize, true, ""))
+	term := img[17*isoSectorSize:]
+	term[0] = 255
+	copy(term[1:6], "CD001")
+
+	for _, d := range dirNames {
+		var buf bytes.Buffer
+		buf.Write(dirRecord("\x00", lba[d], isoSectorSize, true, ""))
+		buf.Write(dirRecord("\x01", lba[parentDir(d)], isoSectorSize, true, ""))
+		for _, child := range dirs[d] {
+			base := path.Base(child)
+			isoName := strings.ToUpper(base)
+			_, isDir := dirs[child]
+			if !isDir {
+				if !strings.Contains(isoName, ".") {
+					isoName += "."
+				}
+				isoName += ";1"
+			}
+			rr := ""
+			if short, ok := rockRidge[child]; ok {
+				isoName, rr = short, base
+			}
+			size := int64(isoSectorSize)
+			if !isDir {
+				size = int64(len(files[child]))
+			}
+			buf.Write(dirRecord(isoName, lba[child], size, isDir, rr))
+		}
+		copy(img[lba[d]*isoSectorSize:], buf.Bytes())
+	}
+	for _, name := range names {
+		copy(img[lba[name]*isoSectorSize:], files[name])
+	}
+	return img
+}
+
+func dirRecord(name string, extent, size int64, dir bool, rr string) []byte {
+	n := 33 + len(name)
+	if len(name)%2 == 0 {
+		n++
+	}
+	su := n
+	if rr != "" {
+		n += 5 + len(rr)
+	}
+	rec := make([]byte, n)
+	rec[0] = byte(n)
+	binary.LittleEndian.PutUint32(rec[2:], uint32(extent))
+	binary.BigEndian.PutUint32(rec[6:], uint32(extent))
+	binary.LittleEndian.PutUint32(rec[10:], uint32(size))
+	binary.BigEndian.PutUint32(rec[14:], uint32(size))
+	if dir {
+		rec[25] = 0x02
+	}
+	rec[32] = byte(len(name))
+	copy(rec[33:], name)
+	if rr != "" {
+		copy(rec[su:], "NM")
+		rec[su+2] = byte(5 + len(rr))
+		rec[su+3] = 1
+		copy(rec[su+5:], rr)
+	}
+	return rec
+}
+
+// parentDir retorna o diretório de p, com "" para a raiz
+func parentDir(p string) string {
+	if dir := path.Dir(p); dir != "." && dir != "/" {
+		return dir
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func TestISOReadFile(t *testing.T) {
+	img := buildISO(map[string]string{
+		"boot/grub/grub.cfg": ubuntuGrubCfg,
+		"casper/vmlinuz":     "kernel",
+		"casper/hwe-vmlinuz": "hwe kernel",
+		"md5sum.txt":         "sums",
+	}, map[string]string{"casper/hwe-vmlinuz": "HWE_VMLI.;1"})
+	fs, err := openISO(bytes.NewReader(img))
+	if err != nil {
+		t.Fatalf("openISO: %v", err)
+	}
+
+	data, err := fs.ReadFile("/boot/grub/grub.cfg")
+	if err != nil || string(data) != ubuntuGrubCfg {
+		t.Fatalf("ReadFile(grub.cfg) = %q, %v", data, err)
+	}
+	if data, err := fs.ReadFile("casper/hwe-vmlinuz"); err != nil || string(data) != "hwe kernel" {
+		t.Errorf("Rock Ridge name: %q, %v", data, err)
+	}
+	if !fs.Exists("casper/vmlinuz") || fs.Exists("casper/initrd") {
+		t.Error("Exists returned wrong result")
+	}
+	if _, err := fs.ReadFile("casper"); err == nil {
+		t.Error("reading a directory should fail")
+	}
+
+	if _, err := openISO(bytes.NewReader(make([]byte, 64*isoSectorSize))); err == nil {
+		t.Error("expected error for a device without ISO 9660")
+	}
+}
+
+func TestParseGrubConfig(t *testing.T) {
+	entries, err := parseGrubConfig(ubuntuGrubCfg)
+	if err != nil {
+		t.Fatalf("parseGrubConfig: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3: %+v", len(entries), entries)
+	}
+	first := entries[0]
+	if first.Title != "Try or Install Ubuntu Server" || first.Kernel != "/casper/vmlinuz" ||
+		len(first.Initrd) != 1 || first.Initrd[0] != "/casper/initrd" {
+		t.Errorf("first entry = %+v", first)
+	}
+
+	for _, bad := range []string{
+		"menuentry \"Ubuntu\" {\n\tlinux /casper/vmlinuz\n",
+		"menuentry \"Ubuntu {\n}\n",
+		"}\n",
+		"menuentry {\n}\n",
+	} {
+		if _, err := parseGrubConfig(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCheckGrub(t *testing.T) {
+	files := map[string]string{
+		"boot/grub/grub.cfg": ubuntuGrubCfg,
+		"casper/vmlinuz":     "kernel",
+		"casper/initrd":      "initrd",
+	}
+	fs, _ := openISO(bytes.NewReader(buildISO(files, nil)))
+	if _, err := checkGrub(fs); err != nil {
+		t.Fatalf("checkGrub: %v", err)
+	}
+
+	delete(files, "casper/initrd")
+	fs, _ = openISO(bytes.NewReader(buildISO(files, nil)))
+	if _, err := checkGrub(fs); err == nil || !strings.Contains(err.Error(), "/casper/initrd") {
+		t.Errorf("missing initrd: got %v", err)
+	}
+}
@@ -0,0 +1,232 @@
+package usb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// byIDDir contém os links estáveis do udev para cada disco
+const byIDDir = "/dev/disk/by-id"
+
+// partLinkPattern casa os links de partições, como usb-...-0:0-part1
+var partLinkPattern = regexp.MustCompile(`-part\d+$`)
+
+// lsblkColumns são as colunas lidas do lsblk, seguidas de MOUNTPOINTS
+// (util-linux 2.37+), que lista todas as montagens de cada partição, ou de
+// MOUNTPOINT nas versões anteriores
+const lsblkColumns = "NAME,PATH,TYPE,SIZE,RM,HOTPLUG,TRAN,MODEL,VENDOR,SERIAL,FSTYPE"
+
+// systemMounts são as montagens que tornam um disco intocável: gravá-lo
+// derrubaria o sistema em execução
+var systemMounts = map[string]bool{
+	"/": true, "/boot": true, "/boot/efi": true, "/efi": true,
+	"/usr": true, "/var": true, "/home": true, "/opt": true,
+	"[SWAP]": true,
+}
+
+// lsblkDevice é um nó da árvore do "lsblk -J": discos, partições e os
+// dispositivos construídos sobre elas (LVM, dm-crypt, RAID)
+type lsblkDevice struct {
+	Name        string        `json:"name"`
+	Path        string        `json:"path"`
+	Type        string        `json:"type"`
+	Size        lsblkInt      `json:"size"`
+	Removable   lsblkBool     `json:"rm"`
+	Hotplug     lsblkBool     `json:"hotplug"`
+	Transport   string        `json:"tran"`
+	Model       string        `json:"model"`
+	Vendor      string        `json:"vendor"`
+	Serial      string        `json:"serial"`
+	FSType      string        `json:"fstype"`
+	Mountpoint  string        `json:"mountpoint"`
+	Mountpoints []string      `json:"mountpoints"`
+	Children    []lsblkDevice `json:"children"`
+}
+
+// lsblkInt aceita números e strings: versões antigas do lsblk escrevem
+// todos os valores como strings
+type lsblkInt int64
+
+func (n *lsblkInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" || s == "" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("tamanho inválido: %s", data)
+	}
+	*n = lsblkInt(v)
+	return nil
+}
+
+// lsblkBool aceita true/false e as strings "1"/"0" das versões antigas
+type lsblkBool bool
+
+func (b *lsblkBool) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	*b = s == "true" || s == "1"
+	return nil
+}
+
+// lsblk lista os discos informados, ou todos, com suas partições e
+// dependentes
+func lsblk(devices ...string) ([]lsblkDevice, error) {
+	out, err := runLsblk(append([]string{"-J", "-b", "-o", lsblkColumns + ",MOUNTPOINTS"}, devices...))
+	if err != nil {
+		out, err = runLsblk(append([]string{"-J", "-b", "-o", lsblkColumns + ",MOUNTPOINT"}, devices...))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseLsblk(out)
+}
+
+func runLsblk(args []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "lsblk", args...)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao executar lsblk: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// parseLsblk lê a saída de "lsblk -J -b"
+func parseLsblk(data []byte) ([]lsblkDevice, error) {
+	var out struct {
+		BlockDevices []lsblkDevice `json:"blockdevices"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("saída inválida do lsblk: %w", err)
+	}
+	for i := range out.BlockDevices {
+		out.BlockDevices[i].fillPaths()
+	}
+	return out.BlockDevices, nil
+}
+
+// fillPaths completa PATH, ausente antes do util-linux 2.33
+func (d *lsblkDevice) fillPaths() {
+	if d.Path == "" {
+		d.Path = "/dev/" + d.Name
+	}
+	for i := range d.Children {
+		d.Children[i].fillPaths()
+	}
+}
+
+// mounts retorna onde o dispositivo está montado; a swap ativa aparece
+// como "[SWAP]"
+func (d *lsblkDevice) mounts() []string {
+	var mounts []string
+	for _, m := range append([]string{d.Mountpoint}, d.Mountpoints...) {
+		if m != "" {
+			mounts = append(mounts, m)
+		}
+	}
+	return mounts
+}
+
+// systemReason procura na árvore do disco uma montagem do sistema, também
+// através de LVM, dm-crypt e RAID, e descreve a primeira encontrada
+func (d *lsblkDevice) systemReason() string {
+	for _, m := range d.mounts() {
+		if !systemMounts[m] {
+			continue
+		}
+		if m == "[SWAP]" {
+			return d.Path + " em uso como swap"
+		}
+		return d.Path + " montado em " + m
+	}
+	for i := range d.Children {
+		if reason := d.Children[i].systemReason(); reason != "" {
+			return reason
+		}
+	}
+	return ""
+}
+
+// device converte o disco para USBDevice, com o link by-id de ids
+func (d *lsblkDevice) device(ids map[string]string) USBDevice {
+	size := int64(d.Size)
+	return USBDevice{
+		Path:         d.Path,
+		ID:           ids[d.Path],
+		Size:         humanSize(size),
+		SizeGB:       int(size >> 30),
+		SizeBytes:    size,
+		Model:        strings.TrimSpace(d.Model),
+		Vendor:       strings.TrimSpace(d.Vendor),
+		Serial:       strings.TrimSpace(d.Serial),
+		Transport:    d.Transport,
+		Removable:    bool(d.Removable),
+		Platform:     "linux",
+		SystemReason: d.systemReason(),
+	}
+}
+
+// byIDLinks mapeia o caminho de cada disco ao seu link preferido em dir.
+// Links de partições são ignorados.
+func byIDLinks(dir string) map[string]string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return map[string]string{}
+	}
+	candidates := map[string][]string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if partLinkPattern.MatchString(name) {
+			continue
+		}
+		target, err := filepath.EvalSymlinks(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		candidates[target] = append(candidates[target], name)
+	}
+
+	links := map[string]string{}
+	for target, names := range candidates {
+		sort.Slice(names, func(i, j int) bool {
+			if ri, rj := byIDRank(names[i]), byIDRank(names[j]); ri != rj {
+				return ri < rj
+			}
+			return names[i] < names[j]
+		})
+		links[target] = filepath.Join(dir, names[0])
+	}
+	return links
+}
+
+// byIDRank ordena os links de um disco: os que trazem barramento, modelo e
+// serial (usb-, ata-, ...) antes dos que só trazem o WWN
+func byIDRank(name string) int {
+	for rank, prefixes := range [][]string{
+		{"usb-"},
+		{"ata-", "nvme-", "scsi-", "mmc-", "ieee1394-"},
+		{"wwn-"},
+	} {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return rank
+			}
+		}
+	}
+	return 3
+}
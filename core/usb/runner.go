@@ -0,0 +1,134 @@
+package usb
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// Runner executa os comandos usados pelos backends
+type Runner interface {
+	Run(timeout time.Duration, name string, args ...string) error
+	Output(timeout time.Duration, name string, args ...string) ([]byte, error)
+	// Path converte um caminho desta máquina para o caminho visto pelos
+	// comandos
+	Path(path string) string
+}
+
+// LocalRunner executa os comandos nesta máquina
+type LocalRunner struct {
+	// Sudo prefixa os comandos com sudo quando o processo não é root
+	Sudo bool
+	// Stream recebe a saída dos comandos; sem ele, a saída só aparece nas
+	// mensagens de erro
+	Stream io.Writer
+}
+
+// Run executa o comando
+func (r *LocalRunner) Run(timeout time.Duration, name string, args ...string) error {
+	_, err := r.exec(timeout, false, name, args...)
+	return err
+}
+
+// Output executa o comando e retorna sua saída padrão
+func (r *LocalRunner) Output(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	return r.exec(timeout, true, name, args...)
+}
+
+// Path retorna o caminho sem alterações
+func (r *LocalRunner) Path(path string) string {
+	return path
+}
+
+func (r *LocalRunner) exec(timeout time.Duration, capture bool, name string, args ...string) ([]byte, error) {
+	if r.Sudo && os.Geteuid() != 0 {
+		args = append([]string{name}, args...)
+		name = "sudo"
+	}
+	return runCommand(timeout, r.Stream, capture, name, args...)
+}
+
+// WSLRunner executa os comandos dentro do WSL a partir do Windows
+type WSLRunner struct {
+	// Distro é a distribuição usada; vazia usa a padrão
+	Distro string
+	Stream io.Writer
+}
+
+// Run executa o comando como root no WSL
+func (r *WSLRunner) Run(timeout time.Duration, name string, args ...string) error {
+	_, err := runCommand(timeout, r.Stream, false, "wsl.exe", r.args(name, args)...)
+	return err
+}
+
+// Output executa o comando como root no WSL e retorna sua saída padrão
+func (r *WSLRunner) Output(timeout time.Duration, name string, args ...string) ([]byte, error) {
+	return runCommand(timeout, r.Stream, true, "wsl.exe", r.args(name, args)...)
+}
+
+// Path converte um caminho do Windows (C:\...) para /mnt/c/...
+func (r *WSLRunner) Path(path string) string {
+	return WindowsToWSLPath(path)
+}
+
+func (r *WSLRunner) args(name string, args []string) []string {
+	prefix := []string{"-u", "root"}
+	if r.Distro != "" {
+		prefix = append(prefix, "-d", r.Distro)
+	}
+	prefix = append(prefix, "--", name)
+	return append(prefix, args...)
+}
+
+// WindowsToWSLPath converte um caminho do Windows para o caminho montado
+// no WSL; caminhos que já são do Linux são mantidos
+func WindowsToWSLPath(path string) string {
+	if len(path) >= 2 && path[1] == ':' {
+		drive := strings.ToLower(path[:1])
+		rest := strings.TrimLeft(strings.ReplaceAll(path[2:], `\`, "/"), "/")
+		return "/mnt/" + drive + "/" + rest
+	}
+	return strings.ReplaceAll(path, `\`, "/")
+}
+
+// runCommand executa o comando com timeout. Com capture, a saída padrão é
+// retornada; a saída restante vai para stream ou, sem ele, para a mensagem
+// de erro.
+func runCommand(timeout time.Duration, stream io.Writer, capture bool, name string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if stream != nil {
+		if !capture {
+			cmd.Stdout = stream
+		}
+		cmd.Stderr = io.MultiWriter(stream, &stderr)
+	}
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("comando %s excedeu o timeout de %v", name, timeout)
+		}
+		if msg := lastLine(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("erro ao executar %s: %w: %s", name, err, msg)
+		}
+		return nil, fmt.Errorf("erro ao executar %s: %w", name, err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// lastLine retorna a última linha não vazia de s, em geral a mensagem de
+// erro do comando
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
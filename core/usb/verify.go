@@ -0,0 +1,227 @@
+package usb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Acrescentar partições reescreve a tabela GPT da ISO híbrida: a primária
+// fica na área de sistema do ISO 9660 e a cópia de segurança nos últimos
+// setores da ISO. O restante tem de ser idêntico no dispositivo.
+const (
+	isoSystemArea = 16 * isoSectorSize
+	isoBackupGPT  = 64 << 10
+)
+
+// FileCheck valida o conteúdo de um arquivo gravado em uma partição
+type FileCheck struct {
+	// Name identifica a verificação no relatório
+	Name      string
+	Partition string
+	File      string
+	// Check retorna um resumo do que foi conferido ou o problema encontrado
+	Check func(data []byte) (string, error)
+}
+
+// Check é o resultado de uma verificação da mídia
+type Check struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Report é o resultado da verificação de uma mídia gravada
+type Report struct {
+	Device string  `json:"device"`
+	Checks []Check `json:"checks"`
+}
+
+// Passed informa se todas as verificações passaram
+func (r *Report) Passed() bool {
+	return len(r.Failed()) == 0
+}
+
+// Failed retorna as verificações que falharam
+func (r *Report) Failed() []Check {
+	var failed []Check
+	for _, c := range r.Checks {
+		if !c.Passed {
+			failed = append(failed, c)
+		}
+	}
+	return failed
+}
+
+func (r *Report) add(name, detail string, err error) {
+	if err != nil {
+		r.Checks = append(r.Checks, Check{Name: name, Detail: err.Error()})
+		return
+	}
+	r.Checks = append(r.Checks, Check{Name: name, Passed: true, Detail: detail})
+}
+
+// VerifyError indica que a mídia gravada não passou na verificação
+type VerifyError struct {
+	Report *Report
+}
+
+func (e *VerifyError) Error() string {
+	var problems []string
+	for _, c := range e.Report.Failed() {
+		problems = append(problems, c.Name+": "+c.Detail)
+	}
+	return "verificação falhou: " + strings.Join(problems, "; ")
+}
+
+// Verify relê uma mídia já gravada e a confere contra o plano: a imagem,
+// quando informada, o menu do GRUB, os arquivos de cada partição e as
+// verificações do plano. O erro só é retornado quando não é possível ler a
+// mídia; o resultado de cada verificação vem no relatório.
+func (e *Engine) Verify(device string, plan *Plan) (*Report, error) {
+	dev, err := e.backend.Attach(device)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao preparar dispositivo: %w", err)
+	}
+
+	report := &Report{Device: device}
+	partitions := map[string]string{}
+	for _, p := range plan.Partitions {
+		path, err := e.backend.FindPartition(dev, p.Label)
+		if err != nil {
+			report.add(p.Label, "", err)
+			continue
+		}
+		partitions[p.Label] = path
+	}
+	e.inspect(report, dev, partitions, plan, true)
+
+	if err := e.backend.Detach(dev); err != nil {
+		return report, fmt.Errorf("falha ao liberar dispositivo: %w", err)
+	}
+	return report, nil
+}
+
+// inspect executa as verificações do plano sobre as partições encontradas.
+// Com boot, confere também o menu do GRUB da ISO gravada.
+func (e *Engine) inspect(report *Report, dev string, partitions map[string]string, plan *Plan, boot bool) {
+	b := e.backend
+	if plan.Image != "" {
+		e.report(StepVerify, "Conferindo %s", plan.Image)
+		report.add("image", "", verifyImage(b, dev, plan.Image))
+	}
+	if boot {
+		e.report(StepVerify, "Conferindo %s", grubConfigPath)
+		var detail string
+		fs, err := openISO(&deviceReader{b, dev})
+		if err == nil {
+			detail, err = checkGrub(fs)
+		}
+		report.add("grub", detail, err)
+	}
+
+	for _, p := range plan.Partitions {
+		path, ok := partitions[p.Label]
+		if !ok || len(p.Sources) == 0 {
+			continue
+		}
+		e.report(StepVerify, "Conferindo %s", p.Label)
+		n, err := verifyPartition(b, path, p.Sources)
+		report.add(p.Label, fmt.Sprintf("%d arquivos conferidos", n), err)
+	}
+
+	for _, c := range plan.Checks {
+		path, ok := partitions[c.Partition]
+		if !ok {
+			continue
+		}
+		e.report(StepVerify, "Conferindo %s", c.Name)
+		data, err := b.ReadFile(path, c.File)
+		if err != nil {
+			report.add(c.Name, "", fmt.Errorf("falha ao ler %s: %w", c.File, err))
+			continue
+		}
+		detail, err := c.Check(data)
+		report.add(c.Name, detail, err)
+	}
+}
+
+// verifyImage compara a imagem gravada com o arquivo de origem, exceto as
+// áreas da tabela GPT
+func verifyImage(b Backend, dev, image string) error {
+	f, err := os.Open(image)
+	if err != nil {
+		return fmt.Errorf("falha ao ler origem: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	length := info.Size() - isoSystemArea - isoBackupGPT
+	if length <= 0 {
+		return fmt.Errorf("imagem muito pequena: %d bytes", info.Size())
+	}
+
+	h := sha256.New()
+	if _, err := io.Copy(h, io.NewSectionReader(f, isoSystemArea, length)); err != nil {
+		return fmt.Errorf("falha ao ler origem: %w", err)
+	}
+	want := hex.EncodeToString(h.Sum(nil))
+
+	got, err := b.HashRange(dev, isoSystemArea, length)
+	if err != nil {
+		return fmt.Errorf("falha ao reler imagem: %w", err)
+	}
+	if got != want {
+		return fmt.Errorf("conteúdo diferente de %s", image)
+	}
+	return nil
+}
+
+// verifyPartition compara os arquivos lidos da partição com as origens e
+// retorna quantos foram conferidos
+func verifyPartition(b Backend, partition string, sources []string) (int, error) {
+	want, err := HashSources(sources)
+	if err != nil {
+		return 0, fmt.Errorf("falha ao ler origem: %w", err)
+	}
+	got, err := b.HashFiles(partition)
+	if err != nil {
+		return 0, fmt.Errorf("falha ao reler partição: %w", err)
+	}
+
+	var problems []string
+	for path, sum := range want {
+		switch g, ok := got[path]; {
+		case !ok:
+			problems = append(problems, path+": ausente")
+		case g != sum:
+			problems = append(problems, path+": conteúdo diferente")
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return 0, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return len(want), nil
+}
+
+// deviceReader lê o dispositivo pelo backend, como um io.ReaderAt
+type deviceReader struct {
+	b   Backend
+	dev string
+}
+
+func (r *deviceReader) ReadAt(p []byte, off int64) (int, error) {
+	data, err := r.b.ReadAt(r.dev, off, int64(len(p)))
+	n := copy(p, data)
+	if err == nil && n < len(p) {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
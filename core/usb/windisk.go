@@ -0,0 +1,161 @@
+package usb
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// windowsDisksScript lista os discos do Get-Disk e, para cada um, o motivo
+// de ele sustentar o Windows em execução: disco ou partição de boot, a
+// unidade do sistema ou uma unidade com arquivo de paginação
+const windowsDisksScript = `
+$ErrorActionPreference = 'SilentlyContinue'
+$sys = $env:SystemDrive.Substring(0, 1)
+$paging = @(Get-CimInstance Win32_PageFileUsage | ForEach-Object { $_.Name.Substring(0, 1) })
+$disks = @(Get-Disk | ForEach-Object {
+	$reason = ''
+	if ($_.IsBoot -or $_.IsSystem) { $reason = 'disco de boot do Windows' }
+	foreach ($p in @(Get-Partition -DiskNumber $_.Number)) {
+		if ($reason) { break }
+		$letter = [string]$p.DriveLetter
+		if ($p.IsBoot -or $p.IsSystem) { $reason = "partição $($p.PartitionNumber) de boot do Windows" }
+		elseif ($letter -and $letter -eq $sys) { $reason = "unidade ${letter}: do sistema" }
+		elseif ($letter -and $paging -contains $letter) { $reason = "unidade ${letter}: com arquivo de paginação" }
+	}
+	[pscustomobject]@{
+		Number = $_.Number; UniqueId = $_.UniqueId; SerialNumber = $_.SerialNumber
+		FriendlyName = $_.FriendlyName; Size = $_.Size; BusType = [string]$_.BusType
+		SystemReason = $reason
+	}
+})
+ConvertTo-Json -Compress -InputObject $disks
+`
+
+// windowsDisk é um disco listado por windowsDisksScript
+type windowsDisk struct {
+	Number       int    `json:"Number"`
+	UniqueID     string `json:"UniqueId"`
+	SerialNumber string `json:"SerialNumber"`
+	FriendlyName string `json:"FriendlyName"`
+	Size         int64  `json:"Size"`
+	BusType      string `json:"BusType"`
+	SystemReason string `json:"SystemReason"`
+}
+
+// windowsDisks executa windowsDisksScript pelo powershell.exe, acessível
+// também de dentro do WSL
+func windowsDisks() ([]windowsDisk, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "powershell.exe", "-NoProfile", "-NonInteractive", "-Command", windowsDisksScript)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("falha ao executar PowerShell: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseWindowsDisks(out)
+}
+
+// parseWindowsDisks lê a saída do script, que é um array, um objeto único
+// ou vazia, conforme a versão do PowerShell e o número de discos
+func parseWindowsDisks(data []byte) ([]windowsDisk, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 {
+		return nil, nil
+	}
+	var disks []windowsDisk
+	if data[0] == '{' {
+		var disk windowsDisk
+		if err := json.Unmarshal(data, &disk); err != nil {
+			return nil, fmt.Errorf("saída inválida do Get-Disk: %w", err)
+		}
+		return []windowsDisk{disk}, nil
+	}
+	if err := json.Unmarshal(data, &disks); err != nil {
+		return nil, fmt.Errorf("saída inválida do Get-Disk: %w", err)
+	}
+	return disks, nil
+}
+
+// device converte o disco para USBDevice no formato de caminho da
+// plataforma
+func (d *windowsDisk) device(platform string) USBDevice {
+	path := fmt.Sprintf(`\\.\PHYSICALDRIVE%d`, d.Number)
+	if platform == "wsl" {
+		path = fmt.Sprintf("PHYSICALDRIVE%d", d.Number)
+	}
+	return USBDevice{
+		Path:         path,
+		ID:           strings.TrimSpace(d.UniqueID),
+		Size:         humanSize(d.Size),
+		SizeGB:       int(d.Size >> 30),
+		SizeBytes:    d.Size,
+		Model:        strings.TrimSpace(d.FriendlyName),
+		Serial:       strings.TrimSpace(d.SerialNumber),
+		Transport:    strings.ToLower(d.BusType),
+		Removable:    strings.EqualFold(d.BusType, "USB"),
+		Platform:     platform,
+		DiskNumber:   d.Number,
+		SystemReason: d.SystemReason,
+	}
+}
+
+// detectWindowsDisks lista os discos USB que não sustentam o Windows
+func detectWindowsDisks(platform string) ([]USBDevice, error) {
+	disks, err := windowsDisks()
+	if err != nil {
+		return nil, err
+	}
+	var devices []USBDevice
+	for _, disk := range disks {
+		if !strings.EqualFold(disk.BusType, "USB") || disk.Size == 0 || disk.SystemReason != "" {
+			continue
+		}
+		devices = append(devices, disk.device(platform))
+	}
+	return devices, nil
+}
+
+// findWindowsDisk localiza o disco PHYSICALDRIVEn e recusa os que
+// sustentam o Windows
+func findWindowsDisk(devicePath string) (*windowsDisk, error) {
+	number, err := ParsePhysicalDrive(devicePath)
+	if err != nil {
+		return nil, err
+	}
+	disks, err := windowsDisks()
+	if err != nil {
+		return nil, err
+	}
+	for i := range disks {
+		if disks[i].Number != number {
+			continue
+		}
+		if reason := disks[i].SystemReason; reason != "" {
+			return nil, fmt.Errorf("%s é um disco do sistema (%s) - operação cancelada por segurança", devicePath, reason)
+		}
+		return &disks[i], nil
+	}
+	return nil, fmt.Errorf("dispositivo não encontrado: %s", devicePath)
+}
+
+// identifyWindowsDisk lê a identidade atual do disco PHYSICALDRIVEn
+func identifyWindowsDisk(devicePath string) (*Identity, error) {
+	disk, err := findWindowsDisk(devicePath)
+	if err != nil {
+		return nil, err
+	}
+	return &Identity{
+		Device:    devicePath,
+		ID:        strings.TrimSpace(disk.UniqueID),
+		Serial:    strings.TrimSpace(disk.SerialNumber),
+		Model:     strings.TrimSpace(disk.FriendlyName),
+		SizeBytes: disk.Size,
+	}, nil
+}
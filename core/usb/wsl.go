@@ -0,0 +1,105 @@
+package usb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WSLBackend grava discos do Windows anexando-os ao WSL com
+// "wsl --mount --bare" e executando lá as mesmas operações do
+// DeviceBackend. Serve tanto ao Windows quanto ao WSL: só muda o Runner.
+type WSLBackend struct {
+	*DeviceBackend
+	// Host executa powershell.exe e wsl.exe no lado Windows
+	Host Runner
+
+	physical string
+	disk     int
+}
+
+// NewWSLBackend cria o backend. linux executa os comandos no WSL e host
+// executa os comandos do Windows.
+func NewWSLBackend(linux, host Runner) *WSLBackend {
+	return &WSLBackend{DeviceBackend: NewDeviceBackend(linux), Host: host}
+}
+
+// Name implementa Backend
+func (b *WSLBackend) Name() string {
+	return "wsl"
+}
+
+// Attach tira o disco do Windows, anexa-o ao WSL e retorna o dispositivo
+// que ele recebeu lá
+func (b *WSLBackend) Attach(device string) (string, error) {
+	disk, err := ParsePhysicalDrive(device)
+	if err != nil {
+		return "", err
+	}
+	b.disk = disk
+	b.physical = fmt.Sprintf(`\\.\PHYSICALDRIVE%d`, disk)
+
+	before, err := b.disks()
+	if err != nil {
+		return "", err
+	}
+
+	// O Windows não pode manter volumes do disco montados enquanto o WSL
+	// escreve nele
+	if err := b.setOffline(true); err != nil {
+		return "", fmt.Errorf("falha ao colocar o disco %d offline: %w", disk, err)
+	}
+	if err := b.Host.Run(2*time.Minute, "wsl.exe", "--mount", b.physical, "--bare"); err != nil {
+		b.setOffline(false)
+		return "", fmt.Errorf("falha ao anexar o disco ao WSL (execute como administrador): %w", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		after, err := b.disks()
+		if err == nil {
+			for name := range after {
+				if !before[name] {
+					return "/dev/" + name, nil
+				}
+			}
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	b.Detach("")
+	return "", fmt.Errorf("o disco %d não apareceu no WSL", disk)
+}
+
+// Detach desanexa o disco do WSL e o devolve ao Windows
+func (b *WSLBackend) Detach(device string) error {
+	if b.physical == "" {
+		return nil
+	}
+	err := b.Host.Run(2*time.Minute, "wsl.exe", "--unmount", b.physical)
+	if onlineErr := b.setOffline(false); onlineErr != nil && err == nil {
+		err = onlineErr
+	}
+	b.physical = ""
+	return err
+}
+
+// disks lista os discos vistos pelo WSL
+func (b *WSLBackend) disks() (map[string]bool, error) {
+	out, err := b.Runner.Output(15*time.Second, "lsblk", "-dn", "-o", "NAME")
+	if err != nil {
+		return nil, fmt.Errorf("falha ao listar discos no WSL: %w", err)
+	}
+	names := map[string]bool{}
+	for _, name := range strings.Fields(string(out)) {
+		names[name] = true
+	}
+	return names, nil
+}
+
+func (b *WSLBackend) setOffline(offline bool) error {
+	value := "$false"
+	if offline {
+		value = "$true"
+	}
+	return b.Host.Run(30*time.Second, "powershell.exe", "-NoProfile", "-NonInteractive", "-Command",
+		fmt.Sprintf("Set-Disk -Number %d -IsOffline %s -ErrorAction Stop", b.disk, value))
+}
@@ -41,6 +41,30 @@ fi
 
 log "Iniciando processo de conexão ao cluster Syntropy..."
 
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
+
+# Função para reencontrar o líder via mDNS quando o endereço salvo pela
+# descoberta de rede não responde mais (ex.: o líder trocou de IP)
+refresh_leader_host() {
+    if [ "$LEADER_HOST" = "self" ]; then
+        return 0
+    fi
+    
+    if curl -s -k --connect-timeout 5 "https://$LEADER_HOST:8080/health" &> /dev/null; then
+        return 0
+    fi
+    
+    warn "Líder $LEADER_HOST não responde, procurando via mDNS..."
+    local name addr port role
+    if read -r name addr port role < <("$SCRIPT_DIR/mdns-browse.sh" leader 2> /dev/null) && [ -n "$addr" ]; then
+        log "Líder $name anunciado via mDNS em $addr"
+        LEADER_HOST="$addr"
+        return 0
+    fi
+    
+    warn "Nenhum líder anunciado via mDNS, mantendo $LEADER_HOST"
+}
+
 # Função para obter informações do cluster
 get_cluster_info() {
     local leader_host="$1"
@@ -421,6 +445,9 @@ main() {
         exit 1
     fi
     
+    # Confirmar o endereço do líder
+    refresh_leader_host
+    
     # Obter informações do cluster
     if ! get_cluster_info "$LEADER_HOST"; then
         error "Falha ao obter informações do cluster"
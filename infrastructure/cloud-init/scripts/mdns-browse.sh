@@ -0,0 +1,37 @@
+#!/bin/bash
+# Syntropy Cooperative Grid - mDNS Browse Script
+# Lista os nós Syntropy anunciados na rede local (_syntropy._tcp)
+#
+# Uso: mdns-browse.sh [papel]
+# Saída: uma linha "<nome> <endereço> <porta> <papel>" por nó anunciado,
+# filtrada pelo papel quando informado (ex.: leader)
+
+set -euo pipefail
+
+SERVICE_TYPE="_syntropy._tcp"
+ROLE_FILTER="${1:-}"
+
+if ! command -v avahi-browse &> /dev/null; then
+    echo "avahi-browse não encontrado (pacote avahi-utils)" >&2
+    exit 2
+fi
+
+# -r resolve os serviços, -p gera saída separada por ';' e -t termina
+# após a listagem inicial. Só as linhas resolvidas ('=') em IPv4 interessam:
+# =;iface;IPv4;instância;tipo;domínio;host;endereço;porta;"txt" "txt"...
+avahi-browse -rpt "$SERVICE_TYPE" 2> /dev/null |
+    awk -F';' -v filter="$ROLE_FILTER" '
+        $1 == "=" && $3 == "IPv4" {
+            name = $4
+            role = ""
+            # Os registros TXT vêm entre aspas, separados por espaço
+            n = split($10, txt, "\" \"")
+            for (i = 1; i <= n; i++) {
+                gsub(/"/, "", txt[i])
+                if (txt[i] ~ /^name=/) name = substr(txt[i], 6)
+                if (txt[i] ~ /^role=/) role = substr(txt[i], 6)
+            }
+            if (filter != "" && role != filter) next
+            if (seen[name]++) next
+            print name, $8, $9, (role == "" ? "-" : role)
+        }'
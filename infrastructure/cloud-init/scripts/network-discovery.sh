@@ -35,6 +35,7 @@ fi
 log "Iniciando descoberta de rede Syntropy..."
 
 # Configurações de descoberta
+SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
 DISCOVERY_TIMEOUT=30
 DISCOVERY_RETRIES=3
 DISCOVERY_INTERVAL=5
@@ -113,32 +114,35 @@ discover_via_broadcast() {
     return 1
 }
 
-discover_via_multicast() {
-    log "Tentando descoberta via multicast..."
+discover_via_mdns() {
+    log "Tentando descoberta via mDNS ($SCRIPT_DIR/mdns-browse.sh)..." >&2
     
-    # Configurar multicast para descoberta Syntropy
-    local multicast_group="224.0.0.251"
-    local multicast_port="5353"
-    
-    # Criar arquivo temporário para capturar respostas
-    local temp_file=$(mntemp)
-    
-    # Enviar requisição de descoberta via multicast
-    echo "SYNTROPY_DISCOVERY_REQUEST" | nc -u -w 2 "$multicast_group" "$multicast_port" &> /dev/null &
-    
-    # Aguardar respostas
-    sleep 2
-    
-    # Capturar respostas (implementação simplificada)
-    if [ -f "$temp_file" ] && [ -s "$temp_file" ]; then
-        local host=$(head -1 "$temp_file")
-        log "Nó Syntropy encontrado via multicast: $host"
-        rm -f "$temp_file"
-        echo "$host"
-        return 0
+    # Nós anunciados pelo agente em _syntropy._tcp, o líder primeiro
+    local nodes
+    if ! nodes=$("$SCRIPT_DIR/mdns-browse.sh"); then
+        warn "Descoberta via mDNS indisponível" >&2
+        return 1
     fi
     
-    rm -f "$temp_file"
+    local name addr port role
+    for pass in leader others; do
+        while read -r name addr port role; do
+            [ -n "$name" ] || continue
+            [ "$name" != "${NODE_NAME:-}" ] || continue
+            if [ "$pass" = "leader" ]; then
+                [ "$role" = "leader" ] || continue
+            else
+                [ "$role" != "leader" ] || continue
+            fi
+            
+            log "Nó anunciado via mDNS: $name ($addr:$port, papel $role)" >&2
+            if curl -s -k --connect-timeout 5 "https://$addr:$port/health" &> /dev/null; then
+                echo "$addr"
+                return 0
+            fi
+        done <<< "$nodes"
+    done
+    
     return 1
 }
 
@@ -170,20 +174,20 @@ discover_network() {
     local discovered_host=""
     
     # Tentar diferentes métodos de descoberta
-    if discovered_host=$(discover_via_dns); then
-        log "Descoberta bem-sucedida via DNS: $discovered_host"
+    if discovered_host=$(discover_via_mdns); then
+        log "Descoberta bem-sucedida via mDNS: $discovered_host"
         echo "$discovered_host"
         return 0
     fi
     
-    if discovered_host=$(discover_via_broadcast); then
-        log "Descoberta bem-sucedida via broadcast: $discovered_host"
+    if discovered_host=$(discover_via_dns); then
+        log "Descoberta bem-sucedida via DNS: $discovered_host"
         echo "$discovered_host"
         return 0
     fi
     
-    if discovered_host=$(discover_via_multicast); then
-        log "Descoberta bem-sucedida via multicast: $discovered_host"
+    if discovered_host=$(discover_via_broadcast); then
+        log "Descoberta bem-sucedida via broadcast: $discovered_host"
         echo "$discovered_host"
         return 0
     fi
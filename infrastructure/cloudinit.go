@@ -0,0 +1,132 @@
+package infrastructure
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CloudInitData contém os dados de um nó usados nos templates de cloud-init
+type CloudInitData struct {
+	NodeName         string
+	NodeDescription  string
+	Coordinates      string
+	OwnerKey         string
+	DiscoveryServer  string
+	SSHPublicKey     string
+	CreatedBy        string
+	CreatedAt        string
+	InstanceID       string
+	Interface        string
+	Gateway          string
+	NodeIPSuffix     string
+	PrimaryInterface string
+	MeshGateway      string
+	MgmtGateway      string
+	HTTPProxy        string
+	HTTPSProxy       string
+	NodeType         string
+	HardwareType     string
+	CPUCores         int
+	MemoryGB         int
+	StorageGB        int
+	InitialRole      string
+	CanBeLeader      bool
+	CanBeWorker      bool
+	NodeCertPath     string
+	NodeKeyPath      string
+	CACertPath       string
+	// Certificados PEM para write_files
+	CACertPEM   string
+	NodeCertPEM string
+	NodeKeyPEM  string
+	// Profile define idioma, fuso horário, pacotes e firewall do nó
+	Profile CloudInitProfile
+	// OfflineLabel é o rótulo da partição do pacote offline. Com ele, pacotes,
+	// imagens e o agente são instalados da partição em vez da rede.
+	OfflineLabel string
+	// OwnerFingerprint é a impressão SHA256 da chave SSH do dono, publicada
+	// no node.json e no anúncio mDNS do nó
+	OwnerFingerprint string
+}
+
+// CloudInitFiles são os arquivos de cloud-init renderizados de um nó
+type CloudInitFiles struct {
+	UserData      string
+	MetaData      string
+	NetworkConfig string
+}
+
+// cloudInitFile associa um arquivo de saída ao seu template e esquema
+type cloudInitFile struct {
+	name     string
+	template string
+	schema   *schemaNode
+	content  *string
+}
+
+func (f *CloudInitFiles) files() []cloudInitFile {
+	return []cloudInitFile{
+		{"user-data", UserDataTemplate, userDataSchema, &f.UserData},
+		{"meta-data", MetaDataTemplate, metaDataSchema, &f.MetaData},
+		{"network-config", NetworkConfigTemplate, networkConfigSchema, &f.NetworkConfig},
+	}
+}
+
+// RenderCloudInitFiles renderiza user-data, meta-data e network-config e
+// valida cada um contra o esquema do cloud-init
+func (tm *TemplateManager) RenderCloudInitFiles(data *CloudInitData) (*CloudInitFiles, error) {
+	rendered := &CloudInitFiles{}
+	for _, f := range rendered.files() {
+		content, err := tm.RenderTemplate(f.template, data)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao renderizar %s: %w", f.name, err)
+		}
+		*f.content = content
+	}
+	if err := rendered.Validate(); err != nil {
+		return nil, err
+	}
+	return rendered, nil
+}
+
+// Validate confere os três arquivos contra o esquema do cloud-init
+func (f *CloudInitFiles) Validate() error {
+	for _, file := range f.files() {
+		if err := validateCloudInit(file.name, *file.content, file.schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateCloudInitFile confere um arquivo de cloud-init já gravado, como
+// o user-data lido de uma mídia, contra o esquema do seu nome
+func ValidateCloudInitFile(name, content string) error {
+	for _, f := range (&CloudInitFiles{}).files() {
+		if f.name == name {
+			return validateCloudInit(name, content, f.schema)
+		}
+	}
+	return fmt.Errorf("arquivo de cloud-init desconhecido: %s", name)
+}
+
+// SaveCloudInitFiles renderiza e valida os arquivos de cloud-init do nó e
+// só então os grava em dir. Um erro de template ou de esquema não deixa
+// nenhum arquivo escrito.
+func (tm *TemplateManager) SaveCloudInitFiles(dir string, data *CloudInitData) error {
+	rendered, err := tm.RenderCloudInitFiles(data)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("erro ao criar diretório: %w", err)
+	}
+	for _, f := range rendered.files() {
+		if err := os.WriteFile(filepath.Join(dir, f.name), []byte(*f.content), 0644); err != nil {
+			return fmt.Errorf("erro ao criar %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
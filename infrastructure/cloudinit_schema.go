@@ -0,0 +1,462 @@
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaError lista os problemas de um arquivo de cloud-init que não segue
+// o esquema esperado pelo cloud-init
+type SchemaError struct {
+	File     string
+	Problems []string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s inválido segundo o esquema do cloud-init:\n  - %s",
+		e.File, strings.Join(e.Problems, "\n  - "))
+}
+
+// valueKind são os tipos YAML aceitos por um nó do esquema
+type valueKind uint8
+
+const (
+	kindString valueKind = 1 << iota
+	kindInt
+	kindBool
+	kindFloat
+	kindList
+	kindMap
+
+	kindScalar = kindString | kindInt | kindBool | kindFloat
+	kindAny    = kindScalar | kindList | kindMap
+)
+
+// schemaNode descreve um valor do esquema. Em mapas, fields lista as chaves
+// conhecidas; chaves fora dela são erro, a menos que o mapa seja open.
+type schemaNode struct {
+	kind     valueKind
+	fields   map[string]*schemaNode
+	open     bool
+	values   *schemaNode
+	items    *schemaNode
+	required []string
+	enum     []string
+}
+
+func kindOf(k valueKind) *schemaNode { return &schemaNode{kind: k} }
+
+func listOf(items *schemaNode) *schemaNode { return &schemaNode{kind: kindList, items: items} }
+
+func mapOf(fields map[string]*schemaNode, required ...string) *schemaNode {
+	return &schemaNode{kind: kindMap, fields: fields, required: required}
+}
+
+// dictOf é um mapa de chaves livres, como os dispositivos de rede
+func dictOf(values *schemaNode) *schemaNode {
+	return &schemaNode{kind: kindMap, open: true, values: values}
+}
+
+var (
+	str      = kindOf(kindString)
+	integer  = kindOf(kindInt)
+	boolean  = kindOf(kindBool)
+	anyValue = kindOf(kindAny)
+	anyMap   = dictOf(anyValue)
+	strList  = listOf(str)
+	// command é um comando de runcmd/bootcmd: texto ou lista de argumentos
+	command = &schemaNode{kind: kindString | kindList, items: kindOf(kindScalar)}
+)
+
+// userDataSchema cobre os módulos do cloud-config usados em nós Ubuntu
+var userDataSchema = mapOf(map[string]*schemaNode{
+	"apt":                        anyMap,
+	"apt_pipelining":             anyValue,
+	"autoinstall":                anyMap,
+	"bootcmd":                    listOf(command),
+	"ca_certs":                   anyMap,
+	"chpasswd":                   anyMap,
+	"create_hostname_file":       boolean,
+	"device_aliases":             anyMap,
+	"disable_root":               boolean,
+	"disable_root_opts":          str,
+	"disk_setup":                 anyMap,
+	"final_message":              str,
+	"fqdn":                       str,
+	"fs_setup":                   listOf(anyMap),
+	"groups":                     kindOf(kindString | kindList | kindMap),
+	"growpart":                   anyMap,
+	"hostname":                   str,
+	"keyboard":                   anyMap,
+	"locale":                     str,
+	"locale_configfile":          str,
+	"manage_etc_hosts":           kindOf(kindBool | kindString),
+	"manage_resolv_conf":         boolean,
+	"merge_how":                  anyValue,
+	"mounts":                     listOf(listOf(kindOf(kindScalar))),
+	"mount_default_fields":       listOf(kindOf(kindScalar)),
+	"no_ssh_fingerprints":        boolean,
+	"ntp":                        anyMap,
+	"output":                     anyMap,
+	"package_reboot_if_required": boolean,
+	"package_update":             boolean,
+	"package_upgrade":            boolean,
+	"packages":                   listOf(&schemaNode{kind: kindString | kindList, items: str}),
+	"phone_home":                 anyMap,
+	"power_state":                anyMap,
+	"prefer_fqdn_over_hostname":  boolean,
+	"preserve_hostname":          boolean,
+	"random_seed":                anyMap,
+	"resize_rootfs":              kindOf(kindBool | kindString),
+	"resolv_conf":                anyMap,
+	"rsyslog":                    anyMap,
+	"runcmd":                     listOf(command),
+	"snap":                       anyMap,
+	"ssh":                        anyMap,
+	"ssh_authorized_keys":        strList,
+	"ssh_deletekeys":             boolean,
+	"ssh_genkeytypes":            strList,
+	"ssh_import_id":              strList,
+	"ssh_keys":                   anyMap,
+	"ssh_publish_hostkeys":       anyMap,
+	"ssh_pwauth":                 kindOf(kindBool | kindString),
+	"ssh_quiet_keygen":           boolean,
+	"swap":                       anyMap,
+	"system_info":                anyMap,
+	"timezone":                   str,
+	"ubuntu_pro":                 anyMap,
+	"updates":                    anyMap,
+	"user":                       kindOf(kindString | kindMap),
+	"users": listOf(&schemaNode{kind: kindString | kindMap, required: []string{"name"}, fields: map[string]*schemaNode{
+		"name":                str,
+		"gecos":               str,
+		"groups":              kindOf(kindString | kindList),
+		"homedir":             str,
+		"inactive":            str,
+		"lock_passwd":         boolean,
+		"no_create_home":      boolean,
+		"no_log_init":         boolean,
+		"no_user_group":       boolean,
+		"passwd":              str,
+		"hashed_passwd":       str,
+		"plain_text_passwd":   str,
+		"create_groups":       boolean,
+		"primary_group":       str,
+		"selinux_user":        str,
+		"shell":               str,
+		"snapuser":            str,
+		"ssh_authorized_keys": strList,
+		"ssh_import_id":       strList,
+		"ssh_redirect_user":   boolean,
+		"system":              boolean,
+		"sudo":                kindOf(kindString | kindList | kindBool),
+		"uid":                 kindOf(kindInt | kindString),
+		"doas":                strList,
+		"expiredate":          str,
+	}}),
+	"vendor_data": anyMap,
+	"wireguard":   anyMap,
+	"write_files": listOf(mapOf(map[string]*schemaNode{
+		"path":        str,
+		"content":     str,
+		"source":      anyMap,
+		"owner":       str,
+		"permissions": str,
+		"encoding":    str,
+		"append":      boolean,
+		"defer":       boolean,
+	}, "path")),
+})
+
+// metaDataSchema exige o instance-id; as demais chaves são livres
+var metaDataSchema = &schemaNode{
+	kind: kindMap,
+	fields: map[string]*schemaNode{
+		"instance-id":        str,
+		"local-hostname":     str,
+		"hostname":           str,
+		"network-interfaces": str,
+		"public-keys":        anyValue,
+	},
+	open:     true,
+	values:   anyValue,
+	required: []string{"instance-id"},
+}
+
+var (
+	routeSchema = mapOf(map[string]*schemaNode{
+		"to":      str,
+		"via":     str,
+		"from":    str,
+		"on-link": boolean,
+		"metric":  integer,
+		"type":    str,
+		"scope":   str,
+		"table":   integer,
+		"mtu":     integer,
+	}, "to")
+
+	routingPolicySchema = mapOf(map[string]*schemaNode{
+		"from":            str,
+		"to":              str,
+		"table":           integer,
+		"priority":        integer,
+		"mark":            integer,
+		"type-of-service": integer,
+	})
+
+	dhcpOverridesSchema = mapOf(map[string]*schemaNode{
+		"hostname":      str,
+		"route-metric":  integer,
+		"send-hostname": boolean,
+		"use-dns":       boolean,
+		"use-domains":   kindOf(kindBool | kindString),
+		"use-hostname":  boolean,
+		"use-mtu":       boolean,
+		"use-ntp":       boolean,
+		"use-routes":    boolean,
+	})
+
+	// deviceSchema reúne as chaves de ethernets, bonds, bridges, vlans,
+	// wifis e tunnels do netplan
+	deviceSchema = mapOf(map[string]*schemaNode{
+		"accept-ra":       boolean,
+		"access-points":   anyMap,
+		"addresses":       listOf(kindOf(kindString | kindMap)),
+		"critical":        boolean,
+		"dhcp-identifier": str,
+		"dhcp4":           boolean,
+		"dhcp4-overrides": dhcpOverridesSchema,
+		"dhcp6":           boolean,
+		"dhcp6-overrides": dhcpOverridesSchema,
+		"gateway4":        str,
+		"gateway6":        str,
+		"id":              integer,
+		"interfaces":      strList,
+		"ipv6-privacy":    boolean,
+		"link":            str,
+		"link-local":      strList,
+		"local":           str,
+		"macaddress":      str,
+		"match": mapOf(map[string]*schemaNode{
+			"name":       str,
+			"macaddress": str,
+			"driver":     kindOf(kindString | kindList),
+		}),
+		"mode": str,
+		"mtu":  integer,
+		"nameservers": mapOf(map[string]*schemaNode{
+			"addresses": strList,
+			"search":    strList,
+		}),
+		"optional":       boolean,
+		"parameters":     anyMap,
+		"remote":         str,
+		"renderer":       str,
+		"routes":         listOf(routeSchema),
+		"routing-policy": listOf(routingPolicySchema),
+		"set-name":       str,
+		"table":          integer,
+		"wakeonlan":      boolean,
+	})
+)
+
+// networkConfigSchema é o formato de rede versão 2 (netplan)
+var networkConfigSchema = mapOf(map[string]*schemaNode{
+	"version":   {kind: kindInt, enum: []string{"2"}},
+	"renderer":  {kind: kindString, enum: []string{"networkd", "NetworkManager"}},
+	"ethernets": dictOf(deviceSchema),
+	"bonds":     dictOf(deviceSchema),
+	"bridges":   dictOf(deviceSchema),
+	"vlans":     dictOf(deviceSchema),
+	"wifis":     dictOf(deviceSchema),
+	"tunnels":   dictOf(deviceSchema),
+	"vrfs":      dictOf(deviceSchema),
+}, "version")
+
+// validateCloudInit confere o conteúdo de um arquivo de cloud-init contra
+// o esquema, reunindo todos os problemas encontrados
+func validateCloudInit(file, content string, schema *schemaNode) error {
+	var problems []string
+	if file == "user-data" && !strings.HasPrefix(content, "#cloud-config\n") {
+		problems = append(problems, "a primeira linha deve ser #cloud-config")
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+		problems = append(problems, fmt.Sprintf("YAML inválido: %s", strings.TrimPrefix(err.Error(), "yaml: ")))
+		return &SchemaError{File: file, Problems: problems}
+	}
+	if len(doc.Content) == 0 {
+		problems = append(problems, "documento vazio")
+	} else {
+		checkNode(doc.Content[0], schema, "", &problems)
+	}
+
+	if len(problems) > 0 {
+		return &SchemaError{File: file, Problems: problems}
+	}
+	return nil
+}
+
+func checkNode(node *yaml.Node, schema *schemaNode, path string, problems *[]string) {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	report := func(format string, args ...interface{}) {
+		where := path
+		if where == "" {
+			where = "raiz"
+		}
+		*problems = append(*problems, fmt.Sprintf("linha %d: %s: %s", node.Line, where, fmt.Sprintf(format, args...)))
+	}
+
+	kind := nodeKind(node)
+	if kind == 0 {
+		report("valor vazio, esperado %s", kindName(schema.kind))
+		return
+	}
+	if kind&schema.kind == 0 {
+		report("esperado %s, encontrado %s", kindName(schema.kind), kindName(kind))
+		return
+	}
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if len(schema.enum) > 0 && !contains(schema.enum, node.Value) {
+			report("valor %q não permitido, use %s", node.Value, strings.Join(schema.enum, ", "))
+		}
+	case yaml.SequenceNode:
+		if schema.items == nil {
+			return
+		}
+		for i, item := range node.Content {
+			checkNode(item, schema.items, fmt.Sprintf("%s[%d]", path, i), problems)
+		}
+	case yaml.MappingNode:
+		seen := make(map[string]bool)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			child := key.Value
+			if path != "" {
+				child = path + "." + key.Value
+			}
+			if seen[key.Value] {
+				*problems = append(*problems, fmt.Sprintf("linha %d: %s: chave duplicada", key.Line, child))
+				continue
+			}
+			seen[key.Value] = true
+
+			fieldSchema, known := schema.fields[key.Value]
+			switch {
+			case known:
+				checkNode(value, fieldSchema, child, problems)
+			case schema.open:
+				if schema.values != nil {
+					checkNode(value, schema.values, child, problems)
+				}
+			case schema.fields != nil:
+				msg := fmt.Sprintf("linha %d: %s: chave desconhecida", key.Line, child)
+				if s := suggestKey(key.Value, schema.fields); s != "" {
+					msg += fmt.Sprintf(" (quis dizer %q?)", s)
+				}
+				*problems = append(*problems, msg)
+			}
+		}
+		for _, req := range schema.required {
+			if !seen[req] {
+				report("chave obrigatória %q ausente", req)
+			}
+		}
+	}
+}
+
+// nodeKind retorna o tipo YAML de um nó, ou 0 para null
+func nodeKind(node *yaml.Node) valueKind {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return kindMap
+	case yaml.SequenceNode:
+		return kindList
+	case yaml.ScalarNode:
+		switch node.Tag {
+		case "!!null":
+			return 0
+		case "!!int":
+			return kindInt
+		case "!!bool":
+			return kindBool
+		case "!!float":
+			return kindFloat
+		}
+		return kindString
+	}
+	return 0
+}
+
+func kindName(k valueKind) string {
+	names := []struct {
+		kind valueKind
+		name string
+	}{
+		{kindString, "texto"},
+		{kindInt, "inteiro"},
+		{kindBool, "booleano"},
+		{kindFloat, "número"},
+		{kindList, "lista"},
+		{kindMap, "mapa"},
+	}
+	var parts []string
+	for _, n := range names {
+		if k&n.kind != 0 {
+			parts = append(parts, n.name)
+		}
+	}
+	if len(parts) == 0 {
+		return "nulo"
+	}
+	return strings.Join(parts, " ou ")
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// suggestKey sugere a chave conhecida mais próxima de um erro de digitação
+func suggestKey(key string, fields map[string]*schemaNode) string {
+	best, bestDist := "", 3
+	for name := range fields {
+		if d := editDistance(key, name); d < bestDist || (d == bestDist && best != "" && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best
+}
+
+// editDistance é a distância de Levenshtein entre a e b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,88 @@
+package infrastructure
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateCloudInitFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []string
+	}{
+		{"user-data válido", "user-data", "#cloud-config\npackage_update: true\nruncmd:\n  - echo ok\n  - [ufw, enable]\n", nil},
+		{"sem cabeçalho", "user-data", "package_update: true\n", []string{"#cloud-config"}},
+		{"chave desconhecida", "user-data", "#cloud-config\npackage_updte: true\n",
+			[]string{`linha 2: package_updte: chave desconhecida (quis dizer "package_update"?)`}},
+		{"chave aninhada desconhecida", "user-data", "#cloud-config\nusers:\n  - name: syntropy\n    shel: /bin/bash\n",
+			[]string{`users[0].shel: chave desconhecida (quis dizer "shell"?)`}},
+		{"chave sem parecida", "user-data", "#cloud-config\nfoobarbaz: 1\n", []string{"foobarbaz: chave desconhecida"}},
+		{"texto no lugar de booleano", "user-data", "#cloud-config\npackage_upgrade: sim\n",
+			[]string{"package_upgrade: esperado booleano, encontrado texto"}},
+		{"texto no lugar de lista", "user-data", "#cloud-config\nruncmd: ls\n",
+			[]string{"runcmd: esperado lista, encontrado texto"}},
+		{"lista no lugar de texto", "user-data", "#cloud-config\nhostname: [a, b]\n",
+			[]string{"hostname: esperado texto, encontrado lista"}},
+		{"valor vazio", "user-data", "#cloud-config\ntimezone:\n", []string{"timezone: valor vazio"}},
+		{"obrigatória ausente", "user-data", "#cloud-config\nwrite_files:\n  - content: x\n",
+			[]string{`write_files[0]: chave obrigatória "path" ausente`}},
+		{"chave duplicada", "user-data", "#cloud-config\nhostname: a\nhostname: b\n", []string{"hostname: chave duplicada"}},
+		{"YAML inválido", "user-data", "#cloud-config\nruncmd: [\n", []string{"YAML inválido"}},
+		{"vários problemas", "user-data", "#cloud-config\nlocal: pt_BR\nssh_pwauth: [x]\n",
+			[]string{`(quis dizer "locale"?)`, "ssh_pwauth: esperado texto ou booleano, encontrado lista"}},
+		{"meta-data sem instance-id", "meta-data", "local-hostname: node-01\nsyntropy: {}\n",
+			[]string{`chave obrigatória "instance-id" ausente`}},
+		{"meta-data com chaves livres", "meta-data", "instance-id: i-1\nsyntropy:\n  node: {name: a}\n", nil},
+		{"versão de rede", "network-config", "version: 1\n", []string{`version: valor "1" não permitido, use 2`}},
+		{"texto no lugar de inteiro na rede", "network-config", "version: 2\nethernets:\n  eth0:\n    dhcp4: true\n    mtu: grande\n",
+			[]string{"ethernets.eth0.mtu: esperado inteiro, encontrado texto"}},
+		{"dispositivo com erro de digitação", "network-config", "version: 2\nethernets:\n  eth0:\n    dhcp: true\n",
+			[]string{`ethernets.eth0.dhcp: chave desconhecida (quis dizer "dhcp4"?)`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCloudInitFile(tt.file, tt.content)
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("erro inesperado: %v", err)
+				}
+				return
+			}
+			var schemaErr *SchemaError
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("erro = %v, esperado *SchemaError", err)
+			}
+			if schemaErr.File != tt.file || len(schemaErr.Problems) != len(tt.want) {
+				t.Fatalf("problemas de %s = %q", schemaErr.File, schemaErr.Problems)
+			}
+			for i, want := range tt.want {
+				if !strings.Contains(schemaErr.Problems[i], want) {
+					t.Errorf("problema %q não contém %q", schemaErr.Problems[i], want)
+				}
+			}
+		})
+	}
+
+	if err := ValidateCloudInitFile("vendor-data", ""); err == nil {
+		t.Error("esperado erro para arquivo desconhecido")
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	fields := map[string]*schemaNode{"packages": str, "package_update": boolean, "runcmd": str, "bootcmd": str}
+	tests := []struct{ key, want string }{
+		{"pacakges", "packages"},
+		{"package_updates", "package_update"},
+		{"runcm", "runcmd"},
+		{"bootcm", "bootcmd"},
+		{"hostname", ""},
+	}
+	for _, tt := range tests {
+		if got := suggestKey(tt.key, fields); got != tt.want {
+			t.Errorf("suggestKey(%q) = %q, esperado %q", tt.key, got, tt.want)
+		}
+	}
+}
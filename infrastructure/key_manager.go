@@ -4,7 +4,9 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/pem"
 	"fmt"
 	"os"
@@ -12,6 +14,7 @@ import (
 	"strings"
 	"time"
 
+	"golang.org/x/crypto/curve25519"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -36,6 +39,7 @@ const (
 	OwnerKey     KeyPurpose = "owner"     // Chave do proprietário (SSH access)
 	CommunityKey KeyPurpose = "community" // Chave da comunidade (inter-node communication)
 	NodeKey      KeyPurpose = "node"      // Chave específica do nó
+	WireGuardKey KeyPurpose = "wireguard" // Chave do túnel WireGuard da mesh
 )
 
 // NewKeyManager cria um novo gerenciador de chaves
@@ -122,6 +126,34 @@ func (km *KeyManager) GenerateRSAKeyPair(purpose KeyPurpose, nodeName string, bi
 	}, nil
 }
 
+// GenerateWireGuardKeyPair gera um par de chaves Curve25519 no formato do
+// WireGuard (base64, como "wg genkey" e "wg pubkey")
+func (km *KeyManager) GenerateWireGuardKeyPair(nodeName string) (*KeyPair, error) {
+	privateKey := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(privateKey); err != nil {
+		return nil, fmt.Errorf("falha ao gerar chave WireGuard: %w", err)
+	}
+
+	// Clamping da chave privada, como faz "wg genkey"
+	privateKey[0] &= 248
+	privateKey[31] = (privateKey[31] & 127) | 64
+
+	publicKey, err := curve25519.X25519(privateKey, curve25519.Basepoint)
+	if err != nil {
+		return nil, fmt.Errorf("falha ao derivar chave pública WireGuard: %w", err)
+	}
+
+	sum := sha256.Sum256(publicKey)
+
+	return &KeyPair{
+		PrivateKey:  base64.StdEncoding.EncodeToString(privateKey),
+		PublicKey:   base64.StdEncoding.EncodeToString(publicKey),
+		Fingerprint: "SHA256:" + base64.RawStdEncoding.EncodeToString(sum[:]),
+		Algorithm:   "curve25519",
+		CreatedAt:   time.Now(),
+	}, nil
+}
+
 // SaveKeyPair salva um par de chaves em arquivos
 func (km *KeyManager) SaveKeyPair(keyPair *KeyPair, purpose KeyPurpose, nodeName string) error {
 	// Criar diretório se não existir
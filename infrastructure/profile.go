@@ -0,0 +1,236 @@
+package infrastructure
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultProfile é o perfil usado quando nenhum é informado
+const DefaultProfile = "default"
+
+// CloudInitProfile agrupa as escolhas de sistema de um nó que variam entre
+// sites: idioma, fuso horário, pacotes, imagens, regras de firewall e papel
+type CloudInitProfile struct {
+	Name     string         `yaml:"name"`
+	Locale   string         `yaml:"locale"`
+	Timezone string         `yaml:"timezone"`
+	Role     string         `yaml:"role"`
+	Packages []string       `yaml:"packages"`
+	Firewall []FirewallRule `yaml:"firewall"`
+	// Images são as imagens de contêiner baixadas no primeiro boot
+	Images []string `yaml:"images"`
+	// AgentURL é de onde o binário do syntropy-agent é baixado
+	AgentURL string `yaml:"agent_url"`
+	// ExtraPackages, ExtraFirewall e ExtraImages são acrescentados às
+	// listas herdadas
+	ExtraPackages []string       `yaml:"extra_packages"`
+	ExtraFirewall []FirewallRule `yaml:"extra_firewall"`
+	ExtraImages   []string       `yaml:"extra_images"`
+}
+
+// FirewallRule é uma porta liberada no UFW do nó
+type FirewallRule struct {
+	// Port é um número, um intervalo "inicio:fim" ou um serviço como "ssh"
+	Port     string `yaml:"port"`
+	Protocol string `yaml:"protocol"`
+}
+
+// Spec retorna a regra no formato aceito por "ufw allow"
+func (r FirewallRule) Spec() string {
+	if r.Protocol == "" {
+		return r.Port
+	}
+	return r.Port + "/" + r.Protocol
+}
+
+var (
+	profileNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+	localePattern      = regexp.MustCompile(`^[a-zA-Z]{2,3}(_[A-Z]{2})?(\.[A-Za-z0-9-]+)?(@[a-z]+)?$|^C(\.UTF-8)?$|^POSIX$`)
+	timezonePattern    = regexp.MustCompile(`^[A-Za-z0-9_+-]+(/[A-Za-z0-9_+-]+)*$`)
+	packagePattern     = regexp.MustCompile(`^[a-z0-9][a-z0-9+.-]*(=[A-Za-z0-9.+~:-]+)?$`)
+	servicePattern     = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]*$`)
+	imagePattern       = regexp.MustCompile(`^[a-z0-9][a-z0-9._/-]*(:[A-Za-z0-9._-]+)?(@sha256:[a-f0-9]{64})?$`)
+)
+
+// LoadProfile carrega um perfil pelo nome. Perfis ficam em
+// <templateDir>/profiles/<nome>.yaml e herdam do perfil padrão os campos
+// que não definem.
+func (tm *TemplateManager) LoadProfile(name string) (*CloudInitProfile, error) {
+	if name == "" {
+		name = DefaultProfile
+	}
+	if !profileNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("nome de perfil inválido: %s", name)
+	}
+
+	base, err := tm.readProfile(DefaultProfile)
+	if err != nil {
+		return nil, err
+	}
+	profile := base
+	if name != DefaultProfile {
+		if profile, err = tm.readProfile(name); err != nil {
+			return nil, err
+		}
+		profile.inherit(base)
+	}
+	profile.Name = name
+
+	profile.Packages = append(profile.Packages, profile.ExtraPackages...)
+	profile.Firewall = append(profile.Firewall, profile.ExtraFirewall...)
+	profile.Images = append(profile.Images, profile.ExtraImages...)
+	profile.ExtraPackages, profile.ExtraFirewall, profile.ExtraImages = nil, nil, nil
+
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+// Profiles lista os perfis disponíveis, padrões e do diretório de templates
+func (tm *TemplateManager) Profiles() ([]string, error) {
+	seen := map[string]bool{}
+	var names []string
+	add := func(file string) {
+		name := strings.TrimSuffix(filepath.Base(file), ".yaml")
+		if strings.HasSuffix(file, ".yaml") && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	entries, err := defaultTemplates.ReadDir("cloud-init/profiles")
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		add(e.Name())
+	}
+	if tm.templateDir != "" {
+		entries, err := os.ReadDir(filepath.Join(tm.templateDir, "profiles"))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, e := range entries {
+			add(e.Name())
+		}
+	}
+	return names, nil
+}
+
+func (tm *TemplateManager) readProfile(name string) (*CloudInitProfile, error) {
+	file := filepath.Join("profiles", name+".yaml")
+	data, err := tm.readFile(file)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("perfil não encontrado: %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler perfil %s: %w", name, err)
+	}
+
+	var profile CloudInitProfile
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&profile); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar perfil %s: %w", name, err)
+	}
+	return &profile, nil
+}
+
+// inherit preenche os campos vazios do perfil com os de base
+func (p *CloudInitProfile) inherit(base *CloudInitProfile) {
+	if p.Locale == "" {
+		p.Locale = base.Locale
+	}
+	if p.Timezone == "" {
+		p.Timezone = base.Timezone
+	}
+	if p.Role == "" {
+		p.Role = base.Role
+	}
+	if p.Packages == nil {
+		p.Packages = base.Packages
+	}
+	if p.Firewall == nil {
+		p.Firewall = base.Firewall
+	}
+	if p.Images == nil {
+		p.Images = base.Images
+	}
+	if p.AgentURL == "" {
+		p.AgentURL = base.AgentURL
+	}
+}
+
+// Validate confere os valores do perfil antes de usá-los nos templates
+func (p *CloudInitProfile) Validate() error {
+	var problems []string
+	if !localePattern.MatchString(p.Locale) {
+		problems = append(problems, fmt.Sprintf("locale inválido: %q", p.Locale))
+	}
+	if !timezonePattern.MatchString(p.Timezone) {
+		problems = append(problems, fmt.Sprintf("timezone inválido: %q", p.Timezone))
+	}
+	if p.Role != "" && !servicePattern.MatchString(p.Role) {
+		problems = append(problems, fmt.Sprintf("role inválido: %q", p.Role))
+	}
+	for _, pkg := range p.Packages {
+		if !packagePattern.MatchString(pkg) {
+			problems = append(problems, fmt.Sprintf("pacote inválido: %q", pkg))
+		}
+	}
+	for _, rule := range p.Firewall {
+		if err := rule.validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	for _, image := range p.Images {
+		if !imagePattern.MatchString(image) {
+			problems = append(problems, fmt.Sprintf("imagem inválida: %q", image))
+		}
+	}
+	if u, err := url.Parse(p.AgentURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		problems = append(problems, fmt.Sprintf("agent_url inválida: %q", p.AgentURL))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("perfil %s: %s", p.Name, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (r FirewallRule) validate() error {
+	switch r.Protocol {
+	case "", "tcp", "udp":
+	default:
+		return fmt.Errorf("protocolo inválido na regra %q: use tcp ou udp", r.Spec())
+	}
+
+	if servicePattern.MatchString(r.Port) {
+		if r.Protocol != "" {
+			return fmt.Errorf("regra %q: serviços nomeados não aceitam protocolo", r.Spec())
+		}
+		return nil
+	}
+	ports := strings.Split(r.Port, ":")
+	if len(ports) > 2 {
+		return fmt.Errorf("porta inválida: %q", r.Port)
+	}
+	for _, p := range ports {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("porta inválida: %q", r.Port)
+		}
+	}
+	if len(ports) == 2 && r.Protocol == "" {
+		// O UFW exige o protocolo em intervalos de portas
+		return fmt.Errorf("regra %q: intervalos de portas precisam de protocolo", r.Port)
+	}
+	return nil
+}
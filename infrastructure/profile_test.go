@@ -0,0 +1,133 @@
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// profileDir grava os perfis em um diretório de templates temporário
+func profileDir(t *testing.T, profiles map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "profiles"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range profiles {
+		if err := os.WriteFile(filepath.Join(dir, "profiles", name+".yaml"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadProfileInherits(t *testing.T) {
+	tm := NewTemplateManager(profileDir(t, map[string]string{
+		"edge": `
+locale: en_US.UTF-8
+timezone: UTC
+role: edge
+extra_packages: [nginx, haproxy]
+extra_firewall:
+  - port: "443"
+    protocol: tcp
+`,
+		"minimal": `
+packages: [curl]
+firewall:
+  - port: ssh
+`,
+	}))
+	base, err := tm.LoadProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.Name != DefaultProfile || base.Locale != "pt_BR.UTF-8" || base.Role != "worker" {
+		t.Fatalf("perfil padrão = %+v", base)
+	}
+
+	edge, err := tm.LoadProfile("edge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edge.Name != "edge" || edge.Locale != "en_US.UTF-8" || edge.Timezone != "UTC" || edge.Role != "edge" {
+		t.Errorf("edge = %+v", edge)
+	}
+	// Os extras se somam às listas herdadas do padrão
+	wantPackages := append(append([]string{}, base.Packages...), "nginx", "haproxy")
+	if !reflect.DeepEqual(edge.Packages, wantPackages) {
+		t.Errorf("packages = %v, esperado %v", edge.Packages, wantPackages)
+	}
+	wantFirewall := append(append([]FirewallRule{}, base.Firewall...), FirewallRule{Port: "443", Protocol: "tcp"})
+	if !reflect.DeepEqual(edge.Firewall, wantFirewall) {
+		t.Errorf("firewall = %v, esperado %v", edge.Firewall, wantFirewall)
+	}
+
+	// Listas definidas substituem as herdadas; o resto vem do padrão
+	minimal, err := tm.LoadProfile("minimal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(minimal.Packages, []string{"curl"}) || len(minimal.Firewall) != 1 ||
+		minimal.Locale != base.Locale || minimal.Role != base.Role {
+		t.Errorf("minimal = %+v", minimal)
+	}
+}
+
+func TestLoadProfileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		profiles map[string]string
+		load     string
+		want     string
+	}{
+		{"perfil inexistente", nil, "b", "perfil não encontrado: b"},
+		{"nome inválido", nil, "../etc", "nome de perfil inválido"},
+		{"campo desconhecido", map[string]string{"a": "timezon: UTC"}, "a", "erro ao interpretar perfil a"},
+		{"locale inválido", map[string]string{"a": "locale: pt BR"}, "a", "locale inválido"},
+		{"pacote inválido", map[string]string{"a": "extra_packages: [\"curl; rm -rf /\"]"}, "a", "pacote inválido"},
+		{"firewall inválido", map[string]string{"a": "extra_firewall:\n  - port: \"70000\"\n    protocol: tcp"}, "a", `porta inválida: "70000"`},
+		{"agent_url inválida", map[string]string{"a": "agent_url: ftp://example.com/agent"}, "a", "agent_url inválida"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewTemplateManager(profileDir(t, tt.profiles)).LoadProfile(tt.load)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("erro = %v, esperado contendo %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirewallRuleValidate(t *testing.T) {
+	tests := []struct {
+		rule FirewallRule
+		want string
+	}{
+		{FirewallRule{Port: "ssh"}, ""},
+		{FirewallRule{Port: "443", Protocol: "tcp"}, ""},
+		{FirewallRule{Port: "51820", Protocol: "udp"}, ""},
+		{FirewallRule{Port: "8080"}, ""},
+		{FirewallRule{Port: "30000:32767", Protocol: "tcp"}, ""},
+		{FirewallRule{Port: "ssh", Protocol: "tcp"}, "serviços nomeados não aceitam protocolo"},
+		{FirewallRule{Port: "443", Protocol: "icmp"}, "protocolo inválido"},
+		{FirewallRule{Port: "0", Protocol: "tcp"}, "porta inválida"},
+		{FirewallRule{Port: "65536", Protocol: "tcp"}, "porta inválida"},
+		{FirewallRule{Port: "1:2:3", Protocol: "tcp"}, "porta inválida"},
+		{FirewallRule{Port: "1000:", Protocol: "tcp"}, "porta inválida"},
+		{FirewallRule{Port: "2379:2380"}, "intervalos de portas precisam de protocolo"},
+		{FirewallRule{Port: "22 && reboot"}, "porta inválida"},
+		{FirewallRule{Port: ""}, "porta inválida"},
+	}
+	for _, tt := range tests {
+		err := tt.rule.validate()
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%q: erro inesperado: %v", tt.rule.Spec(), err)
+		case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%q: erro = %v, esperado contendo %q", tt.rule.Spec(), err, tt.want)
+		}
+	}
+}
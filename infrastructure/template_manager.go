@@ -2,12 +2,30 @@ package infrastructure
 
 import (
 	"bytes"
+	"embed"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"text/template"
 )
 
+// Nomes dos templates de cloud-init
+const (
+	UserDataTemplate      = "user-data-template.yaml"
+	MetaDataTemplate      = "meta-data-template.yaml"
+	NetworkConfigTemplate = "network-config-template.yaml"
+)
+
+// defaultTemplates contém os templates e perfis padrão, usados quando o
+// diretório de templates não os sobrescreve
+//
+//go:embed cloud-init/*-template.yaml cloud-init/profiles/*.yaml
+var defaultTemplates embed.FS
+
 // TemplateManager gerencia templates de cloud-init
 type TemplateManager struct {
 	templateDir string
@@ -26,30 +44,51 @@ type TemplateData struct {
 	MetadataCreationCmds string
 }
 
-// NewTemplateManager cria um novo gerenciador de templates
+// NewTemplateManager cria um novo gerenciador de templates. Arquivos em
+// templateDir sobrescrevem os templates padrão de mesmo nome; um diretório
+// vazio ou inexistente usa apenas os padrões.
 func NewTemplateManager(templateDir string) *TemplateManager {
 	return &TemplateManager{
 		templateDir: templateDir,
 	}
 }
 
-// RenderTemplate renderiza um template com os dados fornecidos
-func (tm *TemplateManager) RenderTemplate(templateName string, data *TemplateData) (string, error) {
-	templatePath := filepath.Join(tm.templateDir, templateName)
+// TemplateDir retorna o diretório de templates sobrescritos
+func (tm *TemplateManager) TemplateDir() string {
+	return tm.templateDir
+}
 
-	// Verificar se o arquivo existe
-	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
-		return "", fmt.Errorf("template não encontrado: %s", templatePath)
+// readFile lê um arquivo do diretório de templates ou, se não existir lá,
+// dos padrões embutidos
+func (tm *TemplateManager) readFile(name string) ([]byte, error) {
+	if tm.templateDir != "" {
+		data, err := os.ReadFile(filepath.Join(tm.templateDir, name))
+		if err == nil {
+			return data, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	data, err := defaultTemplates.ReadFile("cloud-init/" + filepath.ToSlash(name))
+	if err != nil {
+		return nil, os.ErrNotExist
 	}
+	return data, nil
+}
 
-	// Ler o template
-	templateContent, err := os.ReadFile(templatePath)
+// RenderTemplate renderiza um template com os dados fornecidos
+func (tm *TemplateManager) RenderTemplate(templateName string, data interface{}) (string, error) {
+	templateContent, err := tm.readFile(templateName)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("template não encontrado: %s", templateName)
+	}
 	if err != nil {
 		return "", fmt.Errorf("erro ao ler template: %w", err)
 	}
 
 	// Criar e executar o template
-	tmpl, err := template.New(templateName).Parse(string(templateContent))
+	tmpl, err := template.New(templateName).Funcs(templateFuncs).Option("missingkey=error").Parse(string(templateContent))
 	if err != nil {
 		return "", fmt.Errorf("erro ao fazer parse do template: %w", err)
 	}
@@ -64,7 +103,7 @@ func (tm *TemplateManager) RenderTemplate(templateName string, data *TemplateDat
 }
 
 // SaveTemplate salva um template renderizado em um arquivo
-func (tm *TemplateManager) SaveTemplate(templateName string, data *TemplateData, outputPath string) error {
+func (tm *TemplateManager) SaveTemplate(templateName string, data interface{}, outputPath string) error {
 	content, err := tm.RenderTemplate(templateName, data)
 	if err != nil {
 		return err
@@ -83,3 +122,59 @@ func (tm *TemplateManager) SaveTemplate(templateName string, data *TemplateData,
 
 	return nil
 }
+
+// ExportDefaults copia os templates e perfis padrão para dir, como ponto
+// de partida para sobrescrevê-los. Arquivos existentes são preservados.
+func (tm *TemplateManager) ExportDefaults(dir string) ([]string, error) {
+	var written []string
+	err := fs.WalkDir(defaultTemplates, "cloud-init", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(path, "cloud-init/")))
+		if _, err := os.Stat(dst); err == nil {
+			return nil
+		}
+		data, err := defaultTemplates.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return fmt.Errorf("erro ao criar diretório: %w", err)
+		}
+		if err := os.WriteFile(dst, data, 0644); err != nil {
+			return fmt.Errorf("erro ao salvar template: %w", err)
+		}
+		written = append(written, dst)
+		return nil
+	})
+	return written, err
+}
+
+// templateFuncs são as funções disponíveis nos templates
+var templateFuncs = template.FuncMap{
+	// quote gera uma string YAML entre aspas, segura para qualquer texto
+	"quote": func(s string) string {
+		data, _ := json.Marshal(s)
+		return string(data)
+	},
+	// join une uma lista, como os pacotes passados ao apt-get
+	"join": strings.Join,
+	// xml escapa texto para arquivos XML, como o serviço do avahi
+	"xml": func(s string) string {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(s))
+		return buf.String()
+	},
+	// indent recua todas as linhas de s, para blocos como certificados PEM
+	"indent": func(n int, s string) string {
+		pad := strings.Repeat(" ", n)
+		lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = pad + line
+			}
+		}
+		return strings.Join(lines, "\n")
+	},
+}
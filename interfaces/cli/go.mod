@@ -5,15 +5,23 @@ go 1.24.0
 toolchain go1.24.7
 
 require (
+	filippo.io/age v1.2.1
 	github.com/spf13/cobra v1.8.0
+	golang.org/x/crypto v0.42.0
+	golang.org/x/term v0.35.0
+	gopkg.in/yaml.v3 v3.0.1
+	syntropy-cc/cooperative-grid/core v0.0.0
 	syntropy-cc/cooperative-grid/infrastructure v0.0.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/klauspost/compress v1.18.0 // indirect
 	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/crypto v0.42.0 // indirect
+	go.etcd.io/bbolt v1.3.10 // indirect
 	golang.org/x/sys v0.36.0 // indirect
 )
 
+replace syntropy-cc/cooperative-grid/core => ../../core
+
 replace syntropy-cc/cooperative-grid/infrastructure => ../../infrastructure
@@ -0,0 +1,494 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"golang.org/x/term"
+
+	"syntropy-cc/cooperative-grid/core/backup"
+)
+
+// backupPassphraseEnv guarda a frase secreta dos backups para uso sem
+// terminal, como no cron
+const backupPassphraseEnv = "SYNTROPY_BACKUP_PASSPHRASE"
+
+// defaultBackupComponents são os diretórios de ~/.syntropy copiados por
+// padrão. A CA entra porque sem a chave raiz não há como renovar os
+// certificados dos nós.
+var defaultBackupComponents = []string{"nodes", "keys", "config", "cache", "ca"}
+
+// backupOptions são as opções do "manager backup"
+type backupOptions struct {
+	dir            string
+	include        []string
+	full           bool
+	recipients     []string
+	recipientsFile string
+	passphraseFile string
+	noPrune        bool
+}
+
+// restoreOptions são as opções do "manager restore"
+type restoreOptions struct {
+	dir            string
+	verify         bool
+	components     []string
+	nodes          []string
+	identities     []string
+	passphraseFile string
+	force          bool
+}
+
+func defaultBackupConfig() BackupConfig {
+	return BackupConfig{
+		AutoBackup:          true,
+		BackupFrequencyDays: 7,
+		MaxBackups:          30,
+		CompressBackups:     true,
+		Retention: backup.Retention{
+			KeepLast:    7,
+			KeepDaily:   14,
+			KeepWeekly:  8,
+			KeepMonthly: 12,
+		},
+	}
+}
+
+// loadBackupConfig lê a seção "backup" de ~/.syntropy/config/manager.json,
+// ou a padrão se ela não existir
+func loadBackupConfig() (BackupConfig, error) {
+	path := filepath.Join(getSyntropyDir(), "config", "manager.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return defaultBackupConfig(), nil
+	}
+	if err != nil {
+		return BackupConfig{}, err
+	}
+
+	var config struct {
+		Backup *BackupConfig `json:"backup"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return BackupConfig{}, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	if config.Backup == nil {
+		return defaultBackupConfig(), nil
+	}
+	cfg := *config.Backup
+	// Configurações anteriores à política de retenção só têm max_backups
+	if cfg.Retention.IsZero() && cfg.MaxBackups > 0 {
+		cfg.Retention.KeepLast = cfg.MaxBackups
+	}
+	if err := cfg.Retention.Validate(); err != nil {
+		return BackupConfig{}, fmt.Errorf("invalid backup retention in %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+func backupDir(dir string) string {
+	if dir != "" {
+		return dir
+	}
+	return filepath.Join(getSyntropyDir(), "backups")
+}
+
+// backupRecipients escolhe a cifragem do snapshot: as chaves age de
+// --recipient, --recipients-file e da configuração ou, sem nenhuma, uma
+// frase secreta
+func backupRecipients(opts backupOptions, cfg BackupConfig) ([]age.Recipient, error) {
+	keys := append(append([]string{}, opts.recipients...), cfg.Recipients...)
+	if opts.recipientsFile != "" {
+		data, err := os.ReadFile(opts.recipientsFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, string(data))
+	}
+	if len(keys) > 0 {
+		recipients, err := age.ParseRecipients(strings.NewReader(strings.Join(keys, "\n")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid backup recipient: %w", err)
+		}
+		return recipients, nil
+	}
+
+	passphrase, err := readBackupPassphrase(opts.passphraseFile, true)
+	if err != nil {
+		return nil, err
+	}
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return []age.Recipient{recipient}, nil
+}
+
+// backupIdentities carrega as identidades age de --identity ou, sem elas,
+// a frase secreta do backup
+func backupIdentities(opts restoreOptions) ([]age.Identity, error) {
+	if len(opts.identities) == 0 {
+		passphrase, err := readBackupPassphrase(opts.passphraseFile, false)
+		if err != nil {
+			return nil, err
+		}
+		identity, err := age.NewScryptIdentity(passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return []age.Identity{identity}, nil
+	}
+
+	var identities []age.Identity
+	for _, path := range opts.identities {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		parsed, err := age.ParseIdentities(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid identity file %s: %w", path, err)
+		}
+		identities = append(identities, parsed...)
+	}
+	return identities, nil
+}
+
+// readBackupPassphrase lê a frase secreta do arquivo, da variável de
+// ambiente ou do terminal, pedindo confirmação ao cifrar
+func readBackupPassphrase(file string, confirm bool) (string, error) {
+	var passphrase string
+	switch {
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", err
+		}
+		passphrase = strings.TrimRight(string(data), "\r\n")
+	case os.Getenv(backupPassphraseEnv) != "":
+		passphrase = os.Getenv(backupPassphraseEnv)
+	case term.IsTerminal(int(os.Stdin.Fd())):
+		var err error
+		passphrase, err = promptPassphrase("Backup passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if confirm {
+			again, err := promptPassphrase("Confirm passphrase: ")
+			if err != nil {
+				return "", err
+			}
+			if again != passphrase {
+				return "", errors.New("passphrases do not match")
+			}
+		}
+	default:
+		return "", fmt.Errorf("backups are encrypted: use --recipient/--identity, --passphrase-file or set %s", backupPassphraseEnv)
+	}
+	if passphrase == "" {
+		return "", errors.New("empty backup passphrase")
+	}
+	return passphrase, nil
+}
+
+func promptPassphrase(prompt string) (string, error) {
+	fmt.Fprint(os.Stderr, prompt)
+	data, err := term.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Fprintln(os.Stderr)
+	return string(data), err
+}
+
+func backupNodes(opts backupOptions) error {
+	cfg, err := loadBackupConfig()
+	if err != nil {
+		return err
+	}
+	recipients, err := backupRecipients(opts, cfg)
+	if err != nil {
+		return err
+	}
+	repo, err := backup.Open(backupDir(opts.dir))
+	if err != nil {
+		return fmt.Errorf("failed to open backup directory: %w", err)
+	}
+
+	fmt.Println("💾 Creating backup of node configurations...")
+
+	m, err := repo.Create(backup.CreateOptions{
+		Root:        getSyntropyDir(),
+		Components:  opts.include,
+		Recipients:  recipients,
+		Incremental: !opts.full,
+		Host:        getHostname(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create backup: %w", err)
+	}
+
+	stored, size := m.Stored()
+	kind := "full"
+	if !m.Full() {
+		kind = "incremental to " + m.Parent
+	}
+	fmt.Printf("✅ Backup created: %s\n", repo.ArchivePath(m.ID))
+	fmt.Printf("   Snapshot %s (%s): %d files, %d stored (%s)\n", m.ID, kind, len(m.Files), stored, formatSize(size))
+
+	if opts.noPrune {
+		return nil
+	}
+	removed, err := repo.Prune(cfg.Retention)
+	if err != nil {
+		return fmt.Errorf("failed to apply backup retention: %w", err)
+	}
+	if len(removed) > 0 {
+		fmt.Printf("🗑️  Removed %d old snapshots: %s\n", len(removed), strings.Join(removed, ", "))
+	}
+	return nil
+}
+
+func listBackups(dir string) error {
+	repo, err := backup.Open(backupDir(dir))
+	if err != nil {
+		return err
+	}
+	snapshots, err := repo.List()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Printf("No snapshots in %s\n", repo.Dir())
+		return nil
+	}
+
+	fmt.Printf("%-20s %-20s %-22s %-7s %-7s %-10s %s\n", "ID", "CREATED", "PARENT", "FILES", "STORED", "SIZE", "COMPONENTS")
+	for _, m := range snapshots {
+		parent := m.Parent
+		if parent == "" {
+			parent = "(full)"
+		}
+		stored, size := m.Stored()
+		fmt.Printf("%-20s %-20s %-22s %-7d %-7d %-10s %s\n",
+			m.ID, m.Created.Local().Format("2006-01-02 15:04:05"), parent,
+			len(m.Files), stored, formatSize(size), strings.Join(m.Components, ","))
+	}
+	return nil
+}
+
+// openSnapshot aceita o caminho de um arquivo de snapshot ou o ID de um
+// snapshot do diretório de backups
+func openSnapshot(ref, dir string) (*backup.Repository, string, error) {
+	if strings.ContainsRune(ref, os.PathSeparator) || strings.HasSuffix(ref, ".age") {
+		return backup.OpenArchive(ref)
+	}
+	repo, err := backup.Open(backupDir(dir))
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := os.Stat(repo.ArchivePath(ref)); err != nil {
+		return nil, "", fmt.Errorf("%w: %s in %s", backup.ErrNotFound, ref, repo.Dir())
+	}
+	return repo, ref, nil
+}
+
+// nodeBackupFiles são os caminhos, relativos a ~/.syntropy, dos metadados e
+// das chaves de um nó
+func nodeBackupFiles(name string) []string {
+	files := []string{
+		"nodes/" + name + ".json",
+		"keys/" + name + "_owner.key",
+		"keys/" + name + "_owner.key.pub",
+	}
+	for _, purpose := range []string{"owner", "community", "node", "wireguard"} {
+		base := "keys/" + name + "-" + purpose
+		files = append(files, base+".key", base+".key.pub", base+".fingerprint")
+	}
+	return files
+}
+
+func restoreNodes(ref string, opts restoreOptions) error {
+	repo, id, err := openSnapshot(ref, opts.dir)
+	if err != nil {
+		return err
+	}
+	identities, err := backupIdentities(opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.verify {
+		return verifyBackup(repo, id, identities)
+	}
+
+	// Seleção do que restaurar: componentes inteiros, arquivos de nós ou tudo
+	var match func(backup.File) bool
+	wantedComponents := map[string]bool{}
+	for _, c := range opts.components {
+		wantedComponents[c] = true
+	}
+	nodeOf := map[string]string{}
+	for _, name := range opts.nodes {
+		for _, p := range nodeBackupFiles(name) {
+			nodeOf[p] = name
+		}
+	}
+	switch {
+	case len(opts.nodes) > 0:
+		match = func(f backup.File) bool { _, ok := nodeOf[f.Path]; return ok }
+	case len(opts.components) > 0:
+		match = func(f backup.File) bool { return wantedComponents[f.Component()] }
+	}
+
+	if !opts.force {
+		what := "all components"
+		if len(opts.nodes) > 0 {
+			what = "nodes " + strings.Join(opts.nodes, ", ")
+		} else if len(opts.components) > 0 {
+			what = strings.Join(opts.components, ", ")
+		}
+		fmt.Printf("⚠️  This will restore %s from snapshot %s\n", what, id)
+		fmt.Print("Are you sure? (y/N): ")
+		var response string
+		fmt.Scanln(&response)
+		if response != "y" && response != "Y" {
+			fmt.Println("Restore cancelled.")
+			return nil
+		}
+	}
+
+	fmt.Println("🔄 Restoring node configurations from backup...")
+
+	// Os arquivos são verificados numa área temporária dentro de
+	// ~/.syntropy, para que a troca seja um rename no mesmo sistema de
+	// arquivos; nada do ambiente atual é tocado antes disso
+	syntropyDir := getSyntropyDir()
+	staging := filepath.Join(syntropyDir, ".restore-"+id)
+	if err := os.RemoveAll(staging); err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	files, err := repo.Restore(id, staging, match, identities...)
+	if err != nil {
+		return fmt.Errorf("restore aborted, nothing was changed: %w", err)
+	}
+
+	restoredNodes := map[string]bool{}
+	restoredComponents := map[string]bool{}
+	for _, f := range files {
+		restoredComponents[f.Component()] = true
+		if name, ok := nodeOf[f.Path]; ok {
+			restoredNodes[name] = true
+		}
+	}
+	for _, name := range opts.nodes {
+		if !restoredNodes[name] {
+			return fmt.Errorf("node %s is not in snapshot %s", name, id)
+		}
+	}
+	for _, c := range opts.components {
+		if !restoredComponents[c] {
+			return fmt.Errorf("component %s is not in snapshot %s", c, id)
+		}
+	}
+
+	// Por nó são trocados só os arquivos; por componente, o diretório todo
+	suffix := ".backup." + time.Now().Format("20060102_150405")
+	var paths []string
+	if len(opts.nodes) > 0 {
+		for _, f := range files {
+			paths = append(paths, f.Path)
+		}
+	} else {
+		for c := range restoredComponents {
+			paths = append(paths, c)
+		}
+		sort.Strings(paths)
+	}
+	for _, p := range paths {
+		rel := filepath.FromSlash(p)
+		if err := replacePath(filepath.Join(staging, rel), filepath.Join(syntropyDir, rel), suffix); err != nil {
+			return fmt.Errorf("failed to restore %s: %w", p, err)
+		}
+		fmt.Printf("  ✓ %s\n", p)
+	}
+
+	fmt.Printf("✅ Restored %d files from snapshot %s (previous versions kept as *%s)\n", len(files), id, suffix)
+	return nil
+}
+
+// replacePath põe src no lugar de dst, guardando o dst atual com o sufixo
+func replacePath(src, dst, suffix string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return err
+	}
+	saved := ""
+	if _, err := os.Lstat(dst); err == nil {
+		saved = dst + suffix
+		if err := os.Rename(dst, saved); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(src, dst); err != nil {
+		if saved != "" {
+			os.Rename(saved, dst)
+		}
+		return err
+	}
+	return nil
+}
+
+func verifyBackup(repo *backup.Repository, id string, identities []age.Identity) error {
+	fmt.Printf("🔍 Verifying snapshot %s...\n", id)
+
+	m, err := repo.Verify(id, identities...)
+	var integrity *backup.IntegrityError
+	if errors.As(err, &integrity) {
+		for _, problem := range integrity.Problems {
+			fmt.Printf("  ❌ %s\n", problem)
+		}
+		return fmt.Errorf("snapshot %s failed verification with %d problems", id, len(integrity.Problems))
+	}
+	if err != nil {
+		return err
+	}
+
+	stored, size := m.Stored()
+	fmt.Printf("✅ Snapshot %s is intact\n", m.ID)
+	fmt.Printf("   Created:    %s on %s\n", m.Created.Local().Format(time.RFC3339), m.Host)
+	fmt.Printf("   Components: %s\n", strings.Join(m.Components, ", "))
+	fmt.Printf("   Files:      %d (%d stored, %s)\n", len(m.Files), stored, formatSize(size))
+	if deps := m.Depends(); len(deps) > 0 {
+		fmt.Printf("   Depends on: %s\n", strings.Join(deps, ", "))
+	}
+	return nil
+}
+
+// formatSize formata bytes em uma string legível
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	units := []string{"KB", "MB", "GB", "TB"}
+	if exp >= len(units) {
+		exp = len(units) - 1
+	}
+
+	return fmt.Sprintf("%.1f %s", float64(bytes)/float64(div), units[exp])
+}
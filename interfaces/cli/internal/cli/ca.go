@@ -0,0 +1,289 @@
+package cli
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+)
+
+// NewCACommand creates the grid certificate authority command
+func NewCACommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ca",
+		Short: "Manage the grid certificate authority",
+		Long: `Manage the grid certificate authority.
+
+Every node certificate is issued by a persistent root CA kept in
+~/.syntropy/ca, so all nodes share one trust root. Sites can get their
+own intermediate CA. Revoking a certificate republishes the CRL of its
+issuer in ~/.syntropy/ca/crl.`,
+	}
+
+	cmd.AddCommand(newCAInitCommand())
+	cmd.AddCommand(newCAIssueCommand())
+	cmd.AddCommand(newCARevokeCommand())
+	cmd.AddCommand(newCAListCommand())
+
+	return cmd
+}
+
+// newCAInitCommand creates the ca init command
+func newCAInitCommand() *cobra.Command {
+	var (
+		organization string
+		rootLifetime time.Duration
+		nodeLifetime time.Duration
+		intermediate string
+		lifetime     time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Create the root CA or an intermediate CA",
+		Long: `Create the root CA of the grid, or an intermediate CA signed by it.
+
+Examples:
+  # Create the root CA, node certificates valid for 90 days
+  syntropy ca init --node-lifetime 2160h
+
+  # Create an intermediate CA for a site
+  syntropy ca init --intermediate site-a`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := caDir()
+			if intermediate != "" {
+				authority, err := ca.Open(dir)
+				if err != nil {
+					return err
+				}
+				rec, err := authority.CreateIntermediate(intermediate, lifetime)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("Intermediate CA %s created (serial %s, expires %s)\n",
+					intermediate, rec.Serial, rec.NotAfter.Format("2006-01-02"))
+				fmt.Printf("Issue from it with: syntropy ca issue <node> --issuer %s\n", intermediate)
+				return nil
+			}
+
+			authority, err := ca.Init(dir, ca.Options{
+				Organization: organization,
+				RootLifetime: rootLifetime,
+				NodeLifetime: nodeLifetime,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Root CA created in %s\n", authority.Dir())
+			fmt.Printf("CRL published at %s\n", authority.CRLPath(ca.RootName))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&organization, "organization", ca.DefaultOrganization, "Subject organization of the CA")
+	cmd.Flags().DurationVar(&rootLifetime, "root-lifetime", ca.DefaultRootLifetime, "Lifetime of the root CA")
+	cmd.Flags().DurationVar(&nodeLifetime, "node-lifetime", ca.DefaultNodeLifetime, "Default lifetime of node certificates")
+	cmd.Flags().StringVar(&intermediate, "intermediate", "", "Create an intermediate CA with this name instead of the root")
+	cmd.Flags().DurationVar(&lifetime, "lifetime", ca.DefaultIntermediateLifetime, "Lifetime of the intermediate CA")
+
+	return cmd
+}
+
+// newCAIssueCommand creates the ca issue command
+func newCAIssueCommand() *cobra.Command {
+	var (
+		dnsNames []string
+		ips      []string
+		lifetime time.Duration
+		issuer   string
+		outDir   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "issue <node-name>",
+		Short: "Issue a node certificate",
+		Long: `Issue a certificate for a node. The node name and <name>.local are
+always included as DNS names.
+
+Examples:
+  syntropy ca issue node-01 --ip 172.20.0.11
+  syntropy ca issue node-02 --issuer site-a --lifetime 720h --out ./node-02`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			authority, err := ca.Open(caDir())
+			if err != nil {
+				return err
+			}
+
+			name := args[0]
+			req := ca.IssueRequest{
+				CommonName: name,
+				DNSNames:   append([]string{name, name + ".local"}, dnsNames...),
+				Lifetime:   lifetime,
+				Issuer:     issuer,
+			}
+			for _, s := range ips {
+				ip := net.ParseIP(s)
+				if ip == nil {
+					return fmt.Errorf("invalid IP address: %s", s)
+				}
+				req.IPAddresses = append(req.IPAddresses, ip)
+			}
+
+			issued, err := authority.Issue(req)
+			if err != nil {
+				return err
+			}
+
+			if outDir == "" {
+				outDir = filepath.Join(getSyntropyDir(), "nodes", name, "certs")
+			}
+			if err := os.MkdirAll(outDir, 0700); err != nil {
+				return err
+			}
+			files := []struct {
+				name string
+				data []byte
+				mode os.FileMode
+			}{
+				{"node.crt", issued.CertPEM, 0644},
+				{"node.key", issued.KeyPEM, 0600},
+				{"ca.crt", issued.RootPEM, 0644},
+			}
+			for _, f := range files {
+				if err := os.WriteFile(filepath.Join(outDir, f.name), f.data, f.mode); err != nil {
+					return err
+				}
+			}
+
+			fmt.Printf("Certificate issued for %s\n", name)
+			fmt.Printf("  Serial:  %s\n", issued.Record.Serial)
+			fmt.Printf("  Issuer:  %s\n", issued.Record.Issuer)
+			fmt.Printf("  Expires: %s\n", issued.Record.NotAfter.Format("2006-01-02 15:04"))
+			fmt.Printf("  Files:   %s\n", outDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&dnsNames, "dns", nil, "Additional DNS names")
+	cmd.Flags().StringSliceVar(&ips, "ip", nil, "IP addresses, such as the mesh IP of the node")
+	cmd.Flags().DurationVar(&lifetime, "lifetime", 0, "Certificate lifetime (default: node lifetime of the CA)")
+	cmd.Flags().StringVar(&issuer, "issuer", "", "Intermediate CA to issue from (default: root)")
+	cmd.Flags().StringVar(&outDir, "out", "", "Output directory (default: ~/.syntropy/nodes/<name>/certs)")
+
+	return cmd
+}
+
+// newCARevokeCommand creates the ca revoke command
+func newCARevokeCommand() *cobra.Command {
+	var reason string
+
+	cmd := &cobra.Command{
+		Use:   "revoke <serial>",
+		Short: "Revoke a certificate and publish the CRL",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			authority, err := ca.Open(caDir())
+			if err != nil {
+				return err
+			}
+			rec, err := authority.Revoke(args[0], reason)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Certificate %s (%s) revoked\n", rec.Serial, rec.CommonName)
+			fmt.Printf("CRL published at %s\n", authority.CRLPath(rec.Issuer))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&reason, "reason", "", "Reason for the revocation")
+
+	return cmd
+}
+
+// newCAListCommand creates the ca list command
+func newCAListCommand() *cobra.Command {
+	var (
+		format string
+		issuer string
+		status string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List certificates issued by the CA",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			authority, err := ca.Open(caDir())
+			if err != nil {
+				return err
+			}
+			records, err := authority.List()
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			filtered := records[:0]
+			for _, r := range records {
+				if issuer != "" && r.Issuer != issuer {
+					continue
+				}
+				if status != "" && r.Status(now) != status {
+					continue
+				}
+				filtered = append(filtered, r)
+			}
+
+			switch format {
+			case "json":
+				return printJSON(filtered)
+			case "yaml":
+				fmt.Println("certificates:")
+				for _, r := range filtered {
+					fmt.Printf("- serial: %s\n", r.Serial)
+					fmt.Printf("  common_name: %s\n", r.CommonName)
+					fmt.Printf("  issuer: %s\n", r.Issuer)
+					fmt.Printf("  is_ca: %t\n", r.IsCA)
+					fmt.Printf("  not_after: %s\n", r.NotAfter.Format(time.RFC3339))
+					fmt.Printf("  status: %s\n", r.Status(now))
+				}
+			default:
+				if len(filtered) == 0 {
+					fmt.Println("No certificates found")
+					return nil
+				}
+				fmt.Printf("%-34s %-24s %-12s %-4s %-12s %-8s\n",
+					"SERIAL", "COMMON NAME", "ISSUER", "CA", "EXPIRES", "STATUS")
+				fmt.Println(strings.Repeat("-", 100))
+				for _, r := range filtered {
+					isCA := ""
+					if r.IsCA {
+						isCA = "yes"
+					}
+					fmt.Printf("%-34s %-24s %-12s %-4s %-12s %-8s\n",
+						r.Serial, truncate(r.CommonName, 24), truncate(r.Issuer, 12), isCA,
+						r.NotAfter.Format("2006-01-02"), r.Status(now))
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, yaml)")
+	cmd.Flags().StringVar(&issuer, "issuer", "", "Only certificates issued by this CA")
+	cmd.Flags().StringVar(&status, "status", "", "Filter by status (valid, revoked, expired)")
+
+	return cmd
+}
+
+// caDir returns the directory of the grid certificate authority
+func caDir() string {
+	return filepath.Join(getSyntropyDir(), "ca")
+}
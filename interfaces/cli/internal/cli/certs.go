@@ -0,0 +1,316 @@
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+)
+
+const (
+	// nodeCertDir é onde o cloud-init instala os certificados do nó
+	nodeCertDir = "/opt/syntropy/certs"
+	// agentAPIPort é a porta TLS do syntropy-agent, usada para confirmar
+	// qual certificado o serviço está apresentando
+	agentAPIPort = 8080
+	// certVerifyTimeout é quanto esperar o agente voltar após o restart
+	certVerifyTimeout = 60 * time.Second
+)
+
+// newManagerCertsCommand cria o grupo de comandos de certificados dos nós
+func newManagerCertsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "certs",
+		Short: "Track and renew node certificates",
+		Long: `Track the expiry of node certificates issued by the grid CA and rotate
+them before they expire.
+
+A renewal issues a new certificate with the same names and addresses,
+installs it on the node over SSH, restarts syntropy-agent and checks that
+the agent serves the new certificate. Only then is the old one revoked;
+otherwise the node is rolled back.`,
+	}
+
+	cmd.AddCommand(newManagerCertsStatusCommand())
+	cmd.AddCommand(newManagerCertsRenewCommand())
+
+	return cmd
+}
+
+// newManagerCertsStatusCommand cria o comando de status dos certificados
+func newManagerCertsStatusCommand() *cobra.Command {
+	var (
+		format string
+		window time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show certificate expiry for every node",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			authority, err := ca.Open(caDir())
+			if err != nil {
+				return err
+			}
+			records, err := authority.NodeCertificates()
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			type certStatus struct {
+				Node      string    `json:"node"`
+				Serial    string    `json:"serial"`
+				ExpiresAt time.Time `json:"expires_at"`
+				DaysLeft  int       `json:"days_left"`
+				Status    string    `json:"status"`
+			}
+			statuses := make([]certStatus, 0, len(records))
+			for _, r := range records {
+				statuses = append(statuses, certStatus{
+					Node:      r.CommonName,
+					Serial:    r.Serial,
+					ExpiresAt: r.NotAfter,
+					DaysLeft:  int(r.NotAfter.Sub(now).Hours() / 24),
+					Status:    certExpiryStatus(r, now, window),
+				})
+			}
+
+			switch format {
+			case "json":
+				return printJSON(statuses)
+			case "yaml":
+				fmt.Println("certificates:")
+				for _, s := range statuses {
+					fmt.Printf("- node: %s\n", s.Node)
+					fmt.Printf("  serial: %s\n", s.Serial)
+					fmt.Printf("  expires_at: %s\n", s.ExpiresAt.Format(time.RFC3339))
+					fmt.Printf("  days_left: %d\n", s.DaysLeft)
+					fmt.Printf("  status: %s\n", s.Status)
+				}
+			default:
+				if len(statuses) == 0 {
+					fmt.Println("No node certificates found")
+					return nil
+				}
+				fmt.Printf("%-20s %-34s %-12s %-10s %s\n", "NODE", "SERIAL", "EXPIRES", "DAYS LEFT", "STATUS")
+				fmt.Println(strings.Repeat("-", 90))
+				for _, s := range statuses {
+					fmt.Printf("%-20s %-34s %-12s %-10d %s\n",
+						truncate(s.Node, 20), s.Serial, s.ExpiresAt.Format("2006-01-02"), s.DaysLeft, s.Status)
+				}
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, yaml)")
+	cmd.Flags().DurationVar(&window, "window", ca.DefaultRenewalWindow, "Renewal window before expiry")
+
+	return cmd
+}
+
+// newManagerCertsRenewCommand cria o comando de renovação dos certificados
+func newManagerCertsRenewCommand() *cobra.Command {
+	var (
+		window time.Duration
+		dryRun bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "renew [node...]",
+		Short: "Renew certificates inside the renewal window",
+		Long: `Renew every node certificate that expires within the renewal window,
+or the certificates of the given nodes regardless of the window.
+
+Examples:
+  # Renew certificates expiring in the next 30 days
+  syntropy manager certs renew
+
+  # Use a 60-day window, showing what would be renewed
+  syntropy manager certs renew --window 1440h --dry-run
+
+  # Rotate the certificate of a node now
+  syntropy manager certs renew node-01`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			authority, err := ca.Open(caDir())
+			if err != nil {
+				return err
+			}
+			renewer := ca.NewRenewer(authority, newSSHCertDeployer(authority), window)
+
+			if dryRun {
+				due, err := renewer.Due()
+				if err != nil {
+					return err
+				}
+				if len(due) == 0 {
+					fmt.Println("No certificates inside the renewal window")
+					return nil
+				}
+				for _, r := range due {
+					fmt.Printf("%s: %s expires %s\n", r.CommonName, r.Serial, r.NotAfter.Format("2006-01-02"))
+				}
+				return nil
+			}
+
+			ctx := cmd.Context()
+			var results []ca.RenewalResult
+			if len(args) == 0 {
+				if results, err = renewer.RenewDue(ctx); err != nil {
+					return err
+				}
+			} else {
+				for _, node := range args {
+					result, err := renewer.RenewNode(ctx, node)
+					if err != nil {
+						return err
+					}
+					results = append(results, result)
+				}
+			}
+
+			if len(results) == 0 {
+				fmt.Println("No certificates inside the renewal window")
+				return nil
+			}
+			failed := 0
+			for _, r := range results {
+				if r.Renewed {
+					fmt.Printf("✅ %s: %s -> %s, expires %s\n", r.Node, r.OldSerial, r.NewSerial, r.ExpiresAt.Format("2006-01-02"))
+				} else {
+					failed++
+					fmt.Printf("❌ %s: %s\n", r.Node, r.Error)
+				}
+			}
+			if failed > 0 {
+				return fmt.Errorf("%d of %d renewals failed", failed, len(results))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVar(&window, "window", ca.DefaultRenewalWindow, "Renew certificates expiring within this window")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Only list the certificates that would be renewed")
+
+	return cmd
+}
+
+// certExpiryStatus classifica um certificado em ok, renew ou expired
+func certExpiryStatus(r *ca.Record, now time.Time, window time.Duration) string {
+	switch {
+	case now.After(r.NotAfter):
+		return "expired"
+	case r.NotAfter.Sub(now) <= window:
+		return "renew"
+	default:
+		return "ok"
+	}
+}
+
+// certWarning descreve um certificado vencido ou perto de vencer
+func certWarning(r *ca.Record, now time.Time) string {
+	if now.After(r.NotAfter) {
+		return fmt.Sprintf("certificate %s expired on %s", r.Serial, r.NotAfter.Format("2006-01-02"))
+	}
+	days := int(r.NotAfter.Sub(now).Hours() / 24)
+	return fmt.Sprintf("certificate %s expires in %d days (%s)", r.Serial, days, r.NotAfter.Format("2006-01-02"))
+}
+
+// expiringCertificates devolve, por nó, os certificados vencidos ou dentro
+// da janela de renovação. Sem CA inicializada não há nenhum.
+func expiringCertificates(window time.Duration) (map[string]*ca.Record, error) {
+	dir := caDir()
+	if !ca.Exists(dir) {
+		return nil, nil
+	}
+	authority, err := ca.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	due, err := authority.Expiring(window)
+	if err != nil {
+		return nil, err
+	}
+	expiring := make(map[string]*ca.Record, len(due))
+	for _, r := range due {
+		expiring[r.CommonName] = r
+	}
+	return expiring, nil
+}
+
+// sshCertDeployer instala certificados nos nós via ssh e confirma pelo
+// handshake TLS com o syntropy-agent
+type sshCertDeployer struct {
+	roots *x509.CertPool
+}
+
+func newSSHCertDeployer(authority *ca.Authority) *sshCertDeployer {
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(authority.RootPEM())
+	return &sshCertDeployer{roots: roots}
+}
+
+func (d *sshCertDeployer) Install(ctx context.Context, node string, issued *ca.Issued) error {
+	files := []struct {
+		name string
+		data []byte
+	}{
+		{"node.crt", issued.CertPEM},
+		{"node.key", issued.KeyPEM},
+		{"ca.crt", issued.RootPEM},
+	}
+	// Os arquivos novos são enviados primeiro e trocados de uma só vez
+	for _, f := range files {
+		command := fmt.Sprintf("sudo -n sh -c 'umask 077; cat > %s/%s.new'", nodeCertDir, f.name)
+		if err := runOnNode(ctx, node, command, string(f.data)); err != nil {
+			return err
+		}
+	}
+	command := fmt.Sprintf("sudo -n sh -c 'cd %s && for f in node.crt node.key ca.crt; do "+
+		"cp -p $f $f.bak 2>/dev/null; mv $f.new $f; chown syntropy:syntropy $f; chmod 600 $f; done' && "+
+		"sudo -n systemctl restart syntropy-agent", nodeCertDir)
+	return runOnNode(ctx, node, command, "")
+}
+
+func (d *sshCertDeployer) Rollback(ctx context.Context, node string) error {
+	command := fmt.Sprintf("sudo -n sh -c 'cd %s && for f in node.crt node.key ca.crt; do "+
+		"[ -f $f.bak ] && mv $f.bak $f; done' && sudo -n systemctl restart syntropy-agent", nodeCertDir)
+	return runOnNode(ctx, node, command, "")
+}
+
+// ServingSerial conecta na API TLS do agente, tentando até o serviço voltar
+func (d *sshCertDeployer) ServingSerial(ctx context.Context, node string) (string, error) {
+	info, err := loadNode(node)
+	if err != nil {
+		return "", fmt.Errorf("node not found: %w", err)
+	}
+	if info.Network.IPAddress == "" {
+		return "", fmt.Errorf("no IP address for node %s", node)
+	}
+	addr := net.JoinHostPort(info.Network.IPAddress, fmt.Sprint(agentAPIPort))
+
+	ctx, cancel := context.WithTimeout(ctx, certVerifyTimeout)
+	defer cancel()
+	dialer := &tls.Dialer{Config: &tls.Config{RootCAs: d.roots, ServerName: node}}
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", addr)
+		if err == nil {
+			state := conn.(*tls.Conn).ConnectionState()
+			conn.Close()
+			return fmt.Sprintf("%x", state.PeerCertificates[0].SerialNumber), nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("agent on %s did not answer: %w", addr, err)
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
@@ -1,22 +1,37 @@
 package cli
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"syntropy-cc/cooperative-grid/core/services/container"
+	"syntropy-cc/cooperative-grid/core/storage"
+	"syntropy-cc/cooperative-grid/core/types/models"
 )
 
 // NewContainerCommand creates the container management command
 func NewContainerCommand() *cobra.Command {
+	var runtimeName string
+
 	cmd := &cobra.Command{
 		Use:   "container",
 		Short: "Manage containers",
 		Long: `Manage containers and containerized applications.
 
-Containers are isolated environments that run applications within the 
+Containers are isolated environments that run applications within the
 Syntropy Cooperative Grid network.`,
 	}
 
+	cmd.PersistentFlags().StringVar(&runtimeName, "runtime", container.RuntimeDocker,
+		"Container runtime on the nodes (docker, containerd)")
+
 	// Add subcommands
 	cmd.AddCommand(newContainerListCommand())
 	cmd.AddCommand(newContainerDeployCommand())
@@ -33,6 +48,7 @@ func newContainerListCommand() *cobra.Command {
 	var (
 		format string
 		nodeID string
+		status string
 	)
 
 	cmd := &cobra.Command{
@@ -40,18 +56,49 @@ func newContainerListCommand() *cobra.Command {
 		Short: "List containers",
 		Long:  `List all containers or containers on a specific node.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement container listing
-			fmt.Println("Listing containers...")
-			if nodeID != "" {
-				fmt.Printf("Node ID: %s\n", nodeID)
+			svc, closeStore, err := openContainerService(cmd)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			containers, err := svc.ListContainers(cmd.Context(), &container.Filter{NodeID: nodeID, Status: status})
+			if err != nil {
+				return err
+			}
+
+			switch format {
+			case "json":
+				return printJSON(containers)
+			case "yaml":
+				fmt.Println("containers:")
+				for _, c := range containers {
+					fmt.Printf("- id: %s\n", c.ID)
+					fmt.Printf("  name: %s\n", c.Name)
+					fmt.Printf("  node_id: %s\n", c.NodeID)
+					fmt.Printf("  image: %s\n", c.Image)
+					fmt.Printf("  status: %s\n", c.Status)
+				}
+				return nil
+			}
+
+			if len(containers) == 0 {
+				fmt.Println("No containers deployed yet. Try: syntropy container deploy --image <image> --node <node>")
+				return nil
+			}
+			fmt.Printf("%-36s %-20s %-15s %-30s %-10s %s\n", "ID", "NAME", "NODE", "IMAGE", "STATUS", "CREATED")
+			fmt.Println(strings.Repeat("-", 135))
+			for _, c := range containers {
+				fmt.Printf("%-36s %-20s %-15s %-30s %-10s %s\n",
+					c.ID, truncate(c.Name, 20), truncate(c.NodeID, 15), truncate(c.Image, 30), c.Status, formatTime(c.CreatedAt))
 			}
-			fmt.Printf("Format: %s\n", format)
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, yaml)")
 	cmd.Flags().StringVarP(&nodeID, "node", "n", "", "Filter by node ID")
+	cmd.Flags().StringVar(&status, "status", "", "Filter by status (running, stopped, error, ...)")
 
 	return cmd
 }
@@ -59,62 +106,88 @@ func newContainerListCommand() *cobra.Command {
 // newContainerDeployCommand creates the container deploy command
 func newContainerDeployCommand() *cobra.Command {
 	var (
-		image     string
-		nodeID    string
-		name      string
-		ports     []string
-		envVars   []string
-		volumes   []string
-		replicas  int
+		image    string
+		nodeIDs  []string
+		name     string
+		ports    []string
+		envVars  []string
+		volumes  []string
+		replicas int
+		cpu      string
+		memory   string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "deploy",
 		Short: "Deploy a container",
-		Long: `Deploy a containerized application to a node.
+		Long: `Deploy a containerized application to one or more nodes.
 
 This command will pull the specified image and start the container
-with the provided configuration.`,
+with the provided configuration. Replicas are spread across the
+target nodes, least loaded first.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Validate inputs
 			if image == "" {
 				return fmt.Errorf("container image is required")
 			}
-			if nodeID == "" {
+			if len(nodeIDs) == 0 {
 				return fmt.Errorf("target node ID is required")
 			}
+			for _, n := range nodeIDs {
+				if _, err := loadNode(n); err != nil {
+					return fmt.Errorf("node %s not found. Try: syntropy manager list", n)
+				}
+			}
 
-			// TODO: Implement container deployment
-			fmt.Printf("Deploying container...\n")
-			fmt.Printf("Image: %s\n", image)
-			fmt.Printf("Node: %s\n", nodeID)
-			if name != "" {
-				fmt.Printf("Name: %s\n", name)
+			cfg := models.ContainerConfig{
+				Replicas:  replicas,
+				Resources: models.ResourceLimits{CPU: cpu, Memory: memory},
+			}
+			var err error
+			if cfg.Ports, err = container.ParsePorts(ports); err != nil {
+				return err
 			}
-			if len(ports) > 0 {
-				fmt.Printf("Ports: %v\n", ports)
+			if cfg.EnvVars, err = container.ParseEnv(envVars); err != nil {
+				return err
 			}
-			if len(envVars) > 0 {
-				fmt.Printf("Environment: %v\n", envVars)
+			if cfg.Volumes, err = container.ParseVolumes(volumes); err != nil {
+				return err
 			}
-			if len(volumes) > 0 {
-				fmt.Printf("Volumes: %v\n", volumes)
+
+			svc, closeStore, err := openContainerService(cmd)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			fmt.Printf("Deploying %s to %s...\n", image, strings.Join(nodeIDs, ", "))
+			containers, err := svc.Deploy(cmd.Context(), &container.DeployRequest{
+				Name:   name,
+				Image:  image,
+				Nodes:  nodeIDs,
+				Config: cfg,
+			})
+			for _, c := range containers {
+				fmt.Printf("  %-20s %-15s %-10s %s\n", c.Name, c.NodeID, c.Status, c.ID)
 			}
-			if replicas > 1 {
-				fmt.Printf("Replicas: %d\n", replicas)
+			if err != nil {
+				return err
 			}
 
+			fmt.Printf("✅ Deployed %d container(s)\n", len(containers))
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&image, "image", "i", "", "Container image (required)")
-	cmd.Flags().StringVarP(&nodeID, "node", "n", "", "Target node ID (required)")
-	cmd.Flags().StringVar(&name, "name", "", "Container name")
-	cmd.Flags().StringSliceVarP(&ports, "port", "p", []string{}, "Port mappings (host:container)")
-	cmd.Flags().StringSliceVarP(&envVars, "env", "e", []string{}, "Environment variables")
-	cmd.Flags().StringSliceVarP(&volumes, "volume", "v", []string{}, "Volume mappings (host:container)")
+	cmd.Flags().StringSliceVarP(&nodeIDs, "node", "n", []string{}, "Target node IDs (required, repeat or comma-separate for several nodes)")
+	cmd.Flags().StringVar(&name, "name", "", "Container name (defaults to the image name)")
+	cmd.Flags().StringSliceVarP(&ports, "port", "p", []string{}, "Port mappings (host:container[/udp])")
+	cmd.Flags().StringSliceVarP(&envVars, "env", "e", []string{}, "Environment variables (KEY=VALUE)")
+	cmd.Flags().StringSliceVarP(&volumes, "volume", "v", []string{}, "Volume mappings (host:container[:ro])")
 	cmd.Flags().IntVar(&replicas, "replicas", 1, "Number of replicas")
+	cmd.Flags().StringVar(&cpu, "cpu", "", "CPU limit per replica, e.g. 500m or 1.5 (default 100m)")
+	cmd.Flags().StringVar(&memory, "memory", "", "Memory limit per replica, e.g. 256Mi (default 128Mi)")
 
 	cmd.MarkFlagRequired("image")
 	cmd.MarkFlagRequired("node")
@@ -129,17 +202,59 @@ func newContainerStatusCommand() *cobra.Command {
 	)
 
 	cmd := &cobra.Command{
-		Use:   "status <container-id>",
+		Use:   "status <container>",
 		Short: "Show container status",
-		Long:  `Show detailed status information for a specific container.`,
-		Args:  cobra.ExactArgs(1),
+		Long: `Show detailed status information for a specific container.
+
+The container is looked up by ID or name and its state is refreshed
+from the node it runs on.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			containerID := args[0]
+			svc, closeStore, err := openContainerService(cmd)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			c, err := svc.Status(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			cfg, err := container.DecodeConfig(c)
+			if err != nil {
+				return err
+			}
 
-			// TODO: Implement container status
-			fmt.Printf("Showing status for container: %s\n", containerID)
-			fmt.Printf("Format: %s\n", format)
+			switch format {
+			case "json":
+				return printJSON(c)
+			case "yaml":
+				fmt.Printf("id: %s\n", c.ID)
+				fmt.Printf("name: %s\n", c.Name)
+				fmt.Printf("node_id: %s\n", c.NodeID)
+				fmt.Printf("image: %s\n", c.Image)
+				fmt.Printf("status: %s\n", c.Status)
+				return nil
+			}
 
+			fmt.Printf("Container: %s\n", c.Name)
+			fmt.Printf("ID:        %s\n", c.ID)
+			fmt.Printf("Node:      %s\n", c.NodeID)
+			fmt.Printf("Image:     %s\n", c.Image)
+			fmt.Printf("Status:    %s\n", c.Status)
+			fmt.Printf("Resources: cpu=%s memory=%s\n", cfg.Resources.CPU, cfg.Resources.Memory)
+			for _, p := range cfg.Ports {
+				fmt.Printf("Port:      %d -> %d/%s\n", p.HostPort, p.ContainerPort, p.Protocol)
+			}
+			for _, v := range cfg.Volumes {
+				mode := "rw"
+				if v.ReadOnly {
+					mode = "ro"
+				}
+				fmt.Printf("Volume:    %s -> %s (%s)\n", v.HostPath, v.ContainerPath, mode)
+			}
+			fmt.Printf("Created:   %s\n", formatTime(c.CreatedAt))
+			fmt.Printf("Updated:   %s\n", formatTime(c.UpdatedAt))
 			return nil
 		},
 	}
@@ -157,19 +272,26 @@ func newContainerLogsCommand() *cobra.Command {
 	)
 
 	cmd := &cobra.Command{
-		Use:   "logs <container-id>",
+		Use:   "logs <container>",
 		Short: "Show container logs",
-		Long:  `Show logs for a specific container.`,
+		Long:  `Show logs for a specific container, looked up by ID or name.`,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			containerID := args[0]
+			svc, closeStore, err := openContainerService(cmd)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
 
-			// TODO: Implement container logs
-			fmt.Printf("Showing logs for container: %s\n", containerID)
-			fmt.Printf("Follow: %t\n", follow)
-			fmt.Printf("Tail: %d\n", tail)
+			// Ctrl+C encerra o --follow sem tratar como erro
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
 
-			return nil
+			err = svc.Logs(ctx, args[0], tail, follow, os.Stdout)
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
 		},
 	}
 
@@ -182,16 +304,22 @@ func newContainerLogsCommand() *cobra.Command {
 // newContainerStopCommand creates the container stop command
 func newContainerStopCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "stop <container-id>",
+		Use:   "stop <container>",
 		Short: "Stop a container",
-		Long:  `Stop a running container.`,
+		Long:  `Stop a running container, looked up by ID or name.`,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			containerID := args[0]
-
-			// TODO: Implement container stop
-			fmt.Printf("Stopping container: %s\n", containerID)
+			svc, closeStore, err := openContainerService(cmd)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
 
+			c, err := svc.Stop(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✅ Stopped %s on %s\n", c.Name, c.NodeID)
 			return nil
 		},
 	}
@@ -202,19 +330,75 @@ func newContainerStopCommand() *cobra.Command {
 // newContainerStartCommand creates the container start command
 func newContainerStartCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "start <container-id>",
+		Use:   "start <container>",
 		Short: "Start a container",
-		Long:  `Start a stopped container.`,
+		Long:  `Start a stopped container, looked up by ID or name.`,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			containerID := args[0]
-
-			// TODO: Implement container start
-			fmt.Printf("Starting container: %s\n", containerID)
+			svc, closeStore, err := openContainerService(cmd)
+			if err != nil {
+				return err
+			}
+			defer closeStore()
 
+			c, err := svc.Start(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✅ Started %s on %s\n", c.Name, c.NodeID)
 			return nil
 		},
 	}
 
 	return cmd
 }
+
+// sshExecutor executa comandos nos nós via ssh, com as mesmas chaves
+// usadas por "syntropy manager connect"
+type sshExecutor struct{}
+
+func (sshExecutor) Run(ctx context.Context, nodeID string, command string) (string, error) {
+	args, err := nodeSSHArgs(nodeID, true)
+	if err != nil {
+		return "", err
+	}
+	out, err := exec.CommandContext(ctx, "ssh", append(args, command)...).CombinedOutput()
+	return string(out), err
+}
+
+func (sshExecutor) Stream(ctx context.Context, nodeID string, command string, w io.Writer) error {
+	args, err := nodeSSHArgs(nodeID, true)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "ssh", append(args, command)...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
+
+// openContainerService abre o serviço de containers usando o runtime
+// escolhido com --runtime
+func openContainerService(cmd *cobra.Command) (*container.Service, func(), error) {
+	runtimeName := container.RuntimeDocker
+	if flag := cmd.Flags().Lookup("runtime"); flag != nil {
+		runtimeName = flag.Value.String()
+	}
+	return newContainerService(runtimeName)
+}
+
+// newContainerService cria o serviço de containers sobre o banco local,
+// executando o runtime nos nós via ssh
+func newContainerService(runtimeName string) (*container.Service, func(), error) {
+	runtime, err := container.NewRuntime(runtimeName, sshExecutor{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	store, err := openStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	svc := container.NewService(storage.NewContainerRepository(store), runtime, containerLogger{})
+	return svc, func() { store.Close() }, nil
+}
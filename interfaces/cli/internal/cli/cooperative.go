@@ -1,9 +1,22 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
+
+	"syntropy-cc/cooperative-grid/core/services/credits"
+	"syntropy-cc/cooperative-grid/core/services/governance"
+	"syntropy-cc/cooperative-grid/core/services/reputation"
+	"syntropy-cc/cooperative-grid/core/storage"
+	"syntropy-cc/cooperative-grid/core/types/constants"
+	"syntropy-cc/cooperative-grid/core/types/models"
 )
 
 // NewCooperativeCommand creates the cooperative services command
@@ -31,13 +44,18 @@ func newCooperativeCreditsCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "credits",
 		Short: "Manage cooperative credits",
-		Long:  `Manage cooperative credits and transactions.`,
+		Long: `Manage cooperative credits and transactions.
+
+Credits are kept in a double-entry ledger stored locally. Rewards, bonuses
+and participation credits are issued by the cooperative treasury; penalties
+and resource usage are paid back into it.`,
 	}
 
 	// Add subcommands
 	cmd.AddCommand(newCooperativeCreditsBalanceCommand())
 	cmd.AddCommand(newCooperativeCreditsTransferCommand())
 	cmd.AddCommand(newCooperativeCreditsHistoryCommand())
+	cmd.AddCommand(newCooperativeCreditsAuditCommand())
 
 	return cmd
 }
@@ -54,19 +72,47 @@ func newCooperativeCreditsBalanceCommand() *cobra.Command {
 		Short: "Show credit balance",
 		Long:  `Show credit balance for a specific node or all nodes.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement credits balance
+			svc, closeStore, err := openCreditsService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			var balances []*models.CooperativeCredits
 			if nodeID != "" {
-				fmt.Printf("Credit balance for node %s:\n", nodeID)
-				fmt.Println("  Balance: 1,250.50 credits")
-				fmt.Println("  Last transaction: 2024-01-15 14:30:00")
+				balance, err := svc.GetBalance(cmd.Context(), nodeID)
+				if err != nil {
+					return err
+				}
+				balances = append(balances, balance)
 			} else {
-				fmt.Println("Credit balances for all nodes:")
-				fmt.Println("  Node-01: 1,250.50 credits")
-				fmt.Println("  Node-02: 890.25 credits")
-				fmt.Println("  Node-03: 2,100.75 credits")
+				balances, err = svc.ListBalances(cmd.Context())
+				if err != nil {
+					return err
+				}
+			}
+
+			switch format {
+			case "json":
+				return printJSON(balances)
+			case "yaml":
+				fmt.Println("balances:")
+				for _, b := range balances {
+					fmt.Printf("- node_id: %s\n", b.NodeID)
+					fmt.Printf("  balance: %.2f\n", b.Balance)
+				}
+				return nil
 			}
-			fmt.Printf("Format: %s\n", format)
 
+			if len(balances) == 0 {
+				fmt.Println("No credit balances recorded yet.")
+				return nil
+			}
+			fmt.Printf("%-36s %15s %s\n", "ACCOUNT", "BALANCE", "LAST TRANSACTION")
+			fmt.Println(strings.Repeat("-", 80))
+			for _, b := range balances {
+				fmt.Printf("%-36s %15.2f %s\n", b.NodeID, b.Balance, formatTime(b.LastTransactionAt))
+			}
 			return nil
 		},
 	}
@@ -80,30 +126,46 @@ func newCooperativeCreditsBalanceCommand() *cobra.Command {
 // newCooperativeCreditsTransferCommand creates the credits transfer command
 func newCooperativeCreditsTransferCommand() *cobra.Command {
 	var (
-		from   string
-		to     string
-		amount float64
-		reason string
+		from    string
+		to      string
+		amount  float64
+		reason  string
+		txnType string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "transfer",
 		Short: "Transfer credits between nodes",
-		Long:  `Transfer credits from one node to another.`,
+		Long: `Transfer credits from one node to another.
+
+Use --type to record rewards, bonuses and participation credits (issued by
+the cooperative treasury, --from is not needed) or penalties and resource
+usage (paid to the treasury, --to is not needed).`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Validate inputs
-			if from == "" {
-				return fmt.Errorf("source node is required")
-			}
-			if to == "" {
-				return fmt.Errorf("destination node is required")
-			}
 			if amount <= 0 {
 				return fmt.Errorf("amount must be greater than 0")
 			}
 
-			// TODO: Implement credits transfer
-			fmt.Printf("Transferring %.2f credits from %s to %s\n", amount, from, to)
+			svc, closeStore, err := openCreditsService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			tx, err := svc.Transfer(cmd.Context(), &credits.TransactionRequest{
+				Type:        models.CooperativeTransactionType(txnType),
+				FromNodeID:  from,
+				ToNodeID:    to,
+				Amount:      amount,
+				Description: reason,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("✅ Transferred %.2f credits from %s to %s\n", tx.Amount, tx.FromNodeID, tx.ToNodeID)
+			fmt.Printf("Transaction: %s (%s)\n", tx.ID, tx.Type)
 			if reason != "" {
 				fmt.Printf("Reason: %s\n", reason)
 			}
@@ -112,13 +174,13 @@ func newCooperativeCreditsTransferCommand() *cobra.Command {
 		},
 	}
 
-	cmd.Flags().StringVarP(&from, "from", "f", "", "Source node ID (required)")
-	cmd.Flags().StringVarP(&to, "to", "t", "", "Destination node ID (required)")
+	cmd.Flags().StringVarP(&from, "from", "f", "", "Source node ID")
+	cmd.Flags().StringVarP(&to, "to", "t", "", "Destination node ID")
 	cmd.Flags().Float64VarP(&amount, "amount", "a", 0, "Amount to transfer (required)")
 	cmd.Flags().StringVarP(&reason, "reason", "r", "", "Transfer reason")
+	cmd.Flags().StringVar(&txnType, "type", string(models.TransactionTypeTransfer),
+		"Transaction type (transfer, service_reward, resource_usage, participation, penalty, bonus)")
 
-	cmd.MarkFlagRequired("from")
-	cmd.MarkFlagRequired("to")
 	cmd.MarkFlagRequired("amount")
 
 	return cmd
@@ -135,20 +197,51 @@ func newCooperativeCreditsHistoryCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "history",
 		Short: "Show credit transaction history",
-		Long:  `Show credit transaction history for a specific node.`,
+		Long:  `Show credit ledger entries for a specific node, newest first.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement credits history
-			if nodeID != "" {
-				fmt.Printf("Credit history for node %s:\n", nodeID)
-			} else {
-				fmt.Println("Credit transaction history:")
+			svc, closeStore, err := openCreditsService()
+			if err != nil {
+				return err
 			}
-			fmt.Println("  2024-01-15 14:30:00 | +50.00 | Service reward")
-			fmt.Println("  2024-01-15 10:15:00 | -25.00 | Resource usage")
-			fmt.Println("  2024-01-14 16:45:00 | +100.00 | Participation bonus")
-			fmt.Printf("Format: %s\n", format)
-			fmt.Printf("Limit: %d\n", limit)
+			defer closeStore()
 
+			entries, err := svc.History(cmd.Context(), nodeID)
+			if err != nil {
+				return err
+			}
+
+			// Mais recentes primeiro
+			for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+				entries[i], entries[j] = entries[j], entries[i]
+			}
+			if limit > 0 && len(entries) > limit {
+				entries = entries[:limit]
+			}
+
+			switch format {
+			case "json":
+				return printJSON(entries)
+			case "yaml":
+				fmt.Println("entries:")
+				for _, e := range entries {
+					fmt.Printf("- sequence: %d\n", e.Sequence)
+					fmt.Printf("  account_id: %s\n", e.AccountID)
+					fmt.Printf("  amount: %.2f\n", e.Amount)
+					fmt.Printf("  type: %s\n", e.Type)
+				}
+				return nil
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No credit transactions recorded yet.")
+				return nil
+			}
+			fmt.Printf("%-6s %-20s %-36s %12s %12s %s\n", "SEQ", "TIME", "ACCOUNT", "AMOUNT", "BALANCE", "TYPE")
+			fmt.Println(strings.Repeat("-", 110))
+			for _, e := range entries {
+				fmt.Printf("%-6d %-20s %-36s %+12.2f %12.2f %s\n",
+					e.Sequence, formatTime(e.CreatedAt), e.AccountID, e.Amount, e.BalanceAfter, e.Type)
+			}
 			return nil
 		},
 	}
@@ -160,16 +253,92 @@ func newCooperativeCreditsHistoryCommand() *cobra.Command {
 	return cmd
 }
 
+// newCooperativeCreditsAuditCommand creates the credits audit command
+func newCooperativeCreditsAuditCommand() *cobra.Command {
+	var (
+		rebuild bool
+		format  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Verify the credit ledger",
+		Long: `Replay the credit ledger, verifying the hash chain and that every
+transaction balances, and compare the result with the stored balances.
+
+With --rebuild, stored balances are recomputed from the ledger. A ledger
+whose hash chain is broken is never used to rebuild balances.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, closeStore, err := openCreditsService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			var report *credits.AuditReport
+			if rebuild {
+				report, err = svc.RebuildBalances(cmd.Context())
+			} else {
+				report, err = svc.Verify(cmd.Context())
+			}
+			if err != nil && report == nil {
+				return err
+			}
+
+			if format == "json" {
+				if jsonErr := printJSON(report); jsonErr != nil {
+					return jsonErr
+				}
+				return err
+			}
+
+			fmt.Printf("Entries: %d\n", report.Entries)
+			fmt.Printf("Transactions: %d\n", report.Transactions)
+			fmt.Printf("Head hash: %s\n", report.HeadHash)
+			for _, m := range report.Mismatches {
+				fmt.Printf("⚠️  %s: stored %.2f, ledger %.2f\n", m.AccountID, m.Stored, m.Ledger)
+			}
+			for _, e := range report.Errors {
+				fmt.Printf("❌ %s\n", e)
+			}
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case rebuild:
+				fmt.Printf("✅ Rebuilt %d balances from the ledger\n", len(report.Balances))
+			case report.Valid():
+				fmt.Println("✅ Ledger is consistent")
+			default:
+				return fmt.Errorf("ledger audit failed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&rebuild, "rebuild", false, "Recompute stored balances from the ledger")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json)")
+
+	return cmd
+}
+
 // newCooperativeGovernanceCommand creates the governance command
 func newCooperativeGovernanceCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "governance",
 		Short: "Manage cooperative governance",
-		Long:  `Manage cooperative governance including proposals and voting.`,
+		Long: `Manage cooperative governance including proposals and voting.
+
+Every managed node is a member and votes once per proposal. Proposals close
+automatically when their voting window ends: they pass when quorum is
+reached and yes votes exceed the threshold, are rejected otherwise, and
+expire when quorum is not reached.`,
 	}
 
 	// Add subcommands
 	cmd.AddCommand(newCooperativeGovernanceProposalsCommand())
+	cmd.AddCommand(newCooperativeGovernanceShowCommand())
 	cmd.AddCommand(newCooperativeGovernanceVoteCommand())
 	cmd.AddCommand(newCooperativeGovernanceCreateCommand())
 
@@ -188,22 +357,105 @@ func newCooperativeGovernanceProposalsCommand() *cobra.Command {
 		Short: "List governance proposals",
 		Long:  `List all governance proposals and their status.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement governance proposals
-			fmt.Println("Governance Proposals:")
-			fmt.Println("  Proposal-01: Increase credit rewards (Active)")
-			fmt.Println("  Proposal-02: Update network policies (Voting)")
-			fmt.Println("  Proposal-03: Add new node requirements (Passed)")
-			fmt.Printf("Format: %s\n", format)
-			if status != "" {
-				fmt.Printf("Status filter: %s\n", status)
+			svc, closeStore, err := openGovernanceService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			proposals, err := svc.ListProposals(cmd.Context(), &governance.Filter{
+				Status: status,
+				Limit:  constants.MaxPageSize,
+			})
+			if err != nil {
+				return err
 			}
 
+			switch format {
+			case "json":
+				return printJSON(proposals)
+			case "yaml":
+				fmt.Println("proposals:")
+				for _, p := range proposals {
+					fmt.Printf("- id: %s\n", p.ID)
+					fmt.Printf("  title: %s\n", p.Title)
+					fmt.Printf("  status: %s\n", p.Status)
+				}
+				return nil
+			}
+
+			if len(proposals) == 0 {
+				fmt.Println("No governance proposals found.")
+				return nil
+			}
+			fmt.Printf("%-36s %-30s %-9s %-13s %s\n", "ID", "TITLE", "STATUS", "YES/NO/ABST", "ENDS")
+			fmt.Println(strings.Repeat("-", 110))
+			for _, p := range proposals {
+				fmt.Printf("%-36s %-30s %-9s %-13s %s\n", p.ID, truncate(p.Title, 30), p.Status,
+					fmt.Sprintf("%d/%d/%d", p.VotesFor, p.VotesAgainst, p.VotesAbstain), formatTime(p.EndDate))
+			}
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, yaml)")
-	cmd.Flags().StringVarP(&status, "status", "s", "", "Filter by status (active, voting, passed, rejected)")
+	cmd.Flags().StringVarP(&status, "status", "s", "", "Filter by status (active, voting, passed, rejected, expired)")
+
+	return cmd
+}
+
+// newCooperativeGovernanceShowCommand creates the governance show command
+func newCooperativeGovernanceShowCommand() *cobra.Command {
+	var format string
+
+	cmd := &cobra.Command{
+		Use:   "show <proposal-id>",
+		Short: "Show a proposal and its tally",
+		Long:  `Show a governance proposal, its rules and the current weighted tally.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, closeStore, err := openGovernanceService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			p, err := svc.GetProposal(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			tally, err := svc.Tally(cmd.Context(), p.ID)
+			if err != nil {
+				return err
+			}
+
+			if format == "json" {
+				return printJSON(map[string]interface{}{
+					"proposal": p,
+					"tally":    tally,
+				})
+			}
+
+			fmt.Printf("Proposal: %s\n", p.Title)
+			fmt.Printf("ID: %s\n", p.ID)
+			fmt.Printf("Proposer: %s\n", p.ProposerID)
+			fmt.Printf("Status: %s\n", p.Status)
+			fmt.Printf("Voting: %s -> %s\n", formatTime(p.StartDate), formatTime(p.EndDate))
+			fmt.Printf("Rules: quorum %.0f%%, threshold %.0f%%, %s weighting\n",
+				tally.Rules.Quorum*100, tally.Rules.Threshold*100, tally.Rules.Weighting)
+			fmt.Println()
+			fmt.Println(p.Description)
+			fmt.Println()
+			fmt.Printf("Yes: %.2f  No: %.2f  Abstain: %.2f\n", tally.For, tally.Against, tally.Abstain)
+			fmt.Printf("Participation: %.1f%% of %.2f (quorum reached: %t)\n",
+				tally.Participation*100, tally.Eligible, tally.QuorumReached)
+			fmt.Printf("Approval: %.1f%%\n", tally.Approval*100)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json)")
 
 	return cmd
 }
@@ -212,6 +464,7 @@ func newCooperativeGovernanceProposalsCommand() *cobra.Command {
 func newCooperativeGovernanceVoteCommand() *cobra.Command {
 	var (
 		proposalID string
+		voterID    string
 		vote       string
 		reason     string
 	)
@@ -219,7 +472,7 @@ func newCooperativeGovernanceVoteCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "vote",
 		Short: "Vote on a governance proposal",
-		Long:  `Vote on a governance proposal.`,
+		Long:  `Vote on a governance proposal. Each node votes once per proposal.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Validate inputs
 			if proposalID == "" {
@@ -229,8 +482,23 @@ func newCooperativeGovernanceVoteCommand() *cobra.Command {
 				return fmt.Errorf("vote is required (yes/no/abstain)")
 			}
 
-			// TODO: Implement governance voting
-			fmt.Printf("Voting %s on proposal %s\n", vote, proposalID)
+			svc, closeStore, err := openGovernanceService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			v, err := svc.CastVote(cmd.Context(), &governance.VoteRequest{
+				ProposalID: proposalID,
+				VoterID:    voterID,
+				Vote:       models.GovernanceVoteType(strings.ToLower(vote)),
+				Reason:     reason,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("✅ %s voted %s on proposal %s\n", v.VoterID, v.Vote, v.ProposalID)
 			if reason != "" {
 				fmt.Printf("Reason: %s\n", reason)
 			}
@@ -240,10 +508,12 @@ func newCooperativeGovernanceVoteCommand() *cobra.Command {
 	}
 
 	cmd.Flags().StringVarP(&proposalID, "proposal", "p", "", "Proposal ID (required)")
+	cmd.Flags().StringVar(&voterID, "voter", "", "Voting node name (required)")
 	cmd.Flags().StringVarP(&vote, "vote", "v", "", "Vote (yes/no/abstain) (required)")
 	cmd.Flags().StringVarP(&reason, "reason", "r", "", "Vote reason")
 
 	cmd.MarkFlagRequired("proposal")
+	cmd.MarkFlagRequired("voter")
 	cmd.MarkFlagRequired("vote")
 
 	return cmd
@@ -252,41 +522,96 @@ func newCooperativeGovernanceVoteCommand() *cobra.Command {
 // newCooperativeGovernanceCreateCommand creates the governance create command
 func newCooperativeGovernanceCreateCommand() *cobra.Command {
 	var (
-		title       string
-		description string
-		proposal    string
+		title         string
+		description   string
+		proposal      string
+		proposer      string
+		start         string
+		duration      time.Duration
+		quorum        float64
+		threshold     float64
+		weighting     string
+		minReputation float64
 	)
 
+	defaults := governance.DefaultRules()
+
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create a governance proposal",
-		Long:  `Create a new governance proposal.`,
+		Long: `Create a new governance proposal.
+
+The quorum, threshold and weighting in effect when the proposal is created
+are stored with it and used for its final tally.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Validate inputs
 			if title == "" {
 				return fmt.Errorf("proposal title is required")
 			}
+			if proposal != "" {
+				data, err := os.ReadFile(proposal)
+				if err != nil {
+					return fmt.Errorf("failed to read proposal file: %w", err)
+				}
+				description = strings.TrimSpace(string(data))
+			}
 			if description == "" {
 				return fmt.Errorf("proposal description is required")
 			}
 
-			// TODO: Implement governance proposal creation
-			fmt.Printf("Creating governance proposal: %s\n", title)
-			fmt.Printf("Description: %s\n", description)
-			if proposal != "" {
-				fmt.Printf("Proposal file: %s\n", proposal)
+			req := &governance.ProposalRequest{
+				Title:       title,
+				Description: description,
+				ProposerID:  proposer,
+				Rules: &governance.Rules{
+					Quorum:        quorum,
+					Threshold:     threshold,
+					Weighting:     governance.Weighting(weighting),
+					MinReputation: minReputation,
+					VotingPeriod:  duration,
+				},
 			}
+			if start != "" {
+				startDate, err := time.Parse(time.RFC3339, start)
+				if err != nil {
+					return fmt.Errorf("invalid start date (expected RFC3339): %w", err)
+				}
+				req.StartDate = startDate
+			}
+
+			svc, closeStore, err := openGovernanceService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			p, err := svc.CreateProposal(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("✅ Created governance proposal: %s\n", p.Title)
+			fmt.Printf("ID: %s\n", p.ID)
+			fmt.Printf("Status: %s\n", p.Status)
+			fmt.Printf("Voting: %s -> %s\n", formatTime(p.StartDate), formatTime(p.EndDate))
 
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&title, "title", "t", "", "Proposal title (required)")
-	cmd.Flags().StringVarP(&description, "description", "d", "", "Proposal description (required)")
-	cmd.Flags().StringVarP(&proposal, "proposal", "p", "", "Proposal file path")
+	cmd.Flags().StringVarP(&description, "description", "d", "", "Proposal description (required unless --proposal is given)")
+	cmd.Flags().StringVarP(&proposal, "proposal", "p", "", "Proposal file path, used as the description")
+	cmd.Flags().StringVar(&proposer, "proposer", "", "Proposing node name (required)")
+	cmd.Flags().StringVar(&start, "start", "", "Voting start time in RFC3339 (default: now)")
+	cmd.Flags().DurationVar(&duration, "duration", defaults.VotingPeriod, "Voting window length")
+	cmd.Flags().Float64Var(&quorum, "quorum", defaults.Quorum, "Fraction of member weight that must vote (0-1)")
+	cmd.Flags().Float64Var(&threshold, "threshold", defaults.Threshold, "Fraction of yes+no weight that yes must exceed (0-1)")
+	cmd.Flags().StringVar(&weighting, "weighting", string(defaults.Weighting), "Vote weighting (equal, reputation)")
+	cmd.Flags().Float64Var(&minReputation, "min-reputation", defaults.MinReputation, "Reputation score required to vote")
 
 	cmd.MarkFlagRequired("title")
-	cmd.MarkFlagRequired("description")
+	cmd.MarkFlagRequired("proposer")
 
 	return cmd
 }
@@ -296,12 +621,19 @@ func newCooperativeReputationCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "reputation",
 		Short: "Manage node reputation",
-		Long:  `Manage node reputation and trust scores.`,
+		Long: `Manage node reputation and trust scores.
+
+Scores (0-10) are derived from uptime seen by manager health checks,
+completed versus failed credit transactions, penalties and governance
+participation. Older evidence fades with a configurable half-life, and the
+weighting policy can be overridden in ~/.syntropy/config/reputation.json.`,
 	}
 
 	// Add subcommands
 	cmd.AddCommand(newCooperativeReputationShowCommand())
 	cmd.AddCommand(newCooperativeReputationUpdateCommand())
+	cmd.AddCommand(newCooperativeReputationHistoryCommand())
+	cmd.AddCommand(newCooperativeReputationPolicyCommand())
 
 	return cmd
 }
@@ -318,20 +650,48 @@ func newCooperativeReputationShowCommand() *cobra.Command {
 		Short: "Show node reputation",
 		Long:  `Show reputation score for a specific node or all nodes.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement reputation show
+			svc, closeStore, err := openReputationService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			var reps []*models.NodeReputation
 			if nodeID != "" {
-				fmt.Printf("Reputation for node %s:\n", nodeID)
-				fmt.Println("  Score: 8.5/10")
-				fmt.Println("  Trust Level: High")
-				fmt.Println("  Last Updated: 2024-01-15 14:30:00")
+				rep, err := svc.GetReputation(cmd.Context(), nodeID)
+				if err != nil {
+					return err
+				}
+				reps = append(reps, rep)
 			} else {
-				fmt.Println("Reputation scores for all nodes:")
-				fmt.Println("  Node-01: 8.5/10 (High)")
-				fmt.Println("  Node-02: 7.2/10 (Medium)")
-				fmt.Println("  Node-03: 9.1/10 (High)")
+				reps, err = svc.ListReputations(cmd.Context())
+				if err != nil {
+					return err
+				}
+			}
+
+			switch format {
+			case "json":
+				return printJSON(reps)
+			case "yaml":
+				fmt.Println("reputations:")
+				for _, r := range reps {
+					fmt.Printf("- node_id: %s\n", r.NodeID)
+					fmt.Printf("  score: %.2f\n", r.Score)
+					fmt.Printf("  trust_level: %s\n", r.TrustLevel)
+				}
+				return nil
 			}
-			fmt.Printf("Format: %s\n", format)
 
+			if len(reps) == 0 {
+				fmt.Println("No reputation scores yet. Run: syntropy cooperative reputation update")
+				return nil
+			}
+			fmt.Printf("%-30s %-8s %-12s %s\n", "NODE", "SCORE", "TRUST LEVEL", "LAST UPDATED")
+			fmt.Println(strings.Repeat("-", 80))
+			for _, r := range reps {
+				fmt.Printf("%-30s %-8s %-12s %s\n", r.NodeID, fmt.Sprintf("%.2f", r.Score), r.TrustLevel, formatTime(r.LastUpdated))
+			}
 			return nil
 		},
 	}
@@ -344,41 +704,222 @@ func newCooperativeReputationShowCommand() *cobra.Command {
 
 // newCooperativeReputationUpdateCommand creates the reputation update command
 func newCooperativeReputationUpdateCommand() *cobra.Command {
+	var nodeID string
+
+	cmd := &cobra.Command{
+		Use:   "update",
+		Short: "Recompute node reputation",
+		Long: `Recompute the reputation score of a node, or of every managed node,
+from its health checks, transactions, penalties and governance votes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, closeStore, err := openReputationService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			nodeIDs := []string{nodeID}
+			if nodeID == "" {
+				nodeIDs, err = nodeElectorate{}.Members(cmd.Context())
+				if err != nil {
+					return fmt.Errorf("failed to load nodes: %w", err)
+				}
+			}
+			if len(nodeIDs) == 0 {
+				fmt.Println("No nodes found.")
+				return nil
+			}
+
+			changes, err := svc.UpdateAll(cmd.Context(), nodeIDs)
+			for _, c := range changes {
+				fmt.Printf("%-30s %5.2f -> %5.2f (%s)\n", c.NodeID, c.PreviousScore, c.Score, c.TrustLevel)
+				if c.Score != c.PreviousScore {
+					for _, reason := range c.Reasons {
+						fmt.Printf("  - %s\n", reason)
+					}
+				}
+			}
+			return err
+		},
+	}
+
+	cmd.Flags().StringVarP(&nodeID, "node", "n", "", "Node ID (default: all managed nodes)")
+
+	return cmd
+}
+
+// newCooperativeReputationHistoryCommand creates the reputation history command
+func newCooperativeReputationHistoryCommand() *cobra.Command {
 	var (
 		nodeID string
-		score  float64
-		reason string
+		format string
+		limit  int
 	)
 
 	cmd := &cobra.Command{
-		Use:   "update",
-		Short: "Update node reputation",
-		Long:  `Update reputation score for a specific node.`,
+		Use:   "history",
+		Short: "Show reputation history",
+		Long:  `Show how a node's reputation changed over time and why.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// Validate inputs
-			if nodeID == "" {
-				return fmt.Errorf("node ID is required")
+			svc, closeStore, err := openReputationService()
+			if err != nil {
+				return err
 			}
-			if score < 0 || score > 10 {
-				return fmt.Errorf("score must be between 0 and 10")
+			defer closeStore()
+
+			changes, err := svc.History(cmd.Context(), nodeID)
+			if err != nil {
+				return err
+			}
+			if limit > 0 && len(changes) > limit {
+				changes = changes[len(changes)-limit:]
 			}
 
-			// TODO: Implement reputation update
-			fmt.Printf("Updating reputation for node %s to %.1f\n", nodeID, score)
-			if reason != "" {
-				fmt.Printf("Reason: %s\n", reason)
+			if format == "json" {
+				return printJSON(changes)
 			}
 
+			if len(changes) == 0 {
+				fmt.Printf("No reputation history for node %s.\n", nodeID)
+				return nil
+			}
+			for i := len(changes) - 1; i >= 0; i-- {
+				c := changes[i]
+				fmt.Printf("%s  %.2f (%s)\n", formatTime(c.At), c.Score, c.TrustLevel)
+				for _, reason := range c.Reasons {
+					fmt.Printf("  - %s\n", reason)
+				}
+			}
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&nodeID, "node", "n", "", "Node ID (required)")
-	cmd.Flags().Float64VarP(&score, "score", "s", 0, "Reputation score (0-10) (required)")
-	cmd.Flags().StringVarP(&reason, "reason", "r", "", "Update reason")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json)")
+	cmd.Flags().IntVarP(&limit, "limit", "l", 20, "Number of changes to show")
 
 	cmd.MarkFlagRequired("node")
-	cmd.MarkFlagRequired("score")
 
 	return cmd
 }
+
+// newCooperativeReputationPolicyCommand creates the reputation policy command
+func newCooperativeReputationPolicyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Show the reputation weighting policy",
+		Long:  `Show the weighting policy used to compute reputation scores.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			policy, err := loadReputationPolicy()
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Policy file: %s\n", reputationPolicyFile())
+			return printJSON(policy)
+		},
+	}
+
+	return cmd
+}
+
+// nodeElectorate trata os nós gerenciados em ~/.syntropy/nodes como
+// membros da cooperativa, com a reputação lida do banco local
+type nodeElectorate struct {
+	reputations *storage.ReputationRepository
+}
+
+func (e nodeElectorate) Members(ctx context.Context) ([]string, error) {
+	nodes, err := loadAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	members := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		members = append(members, node.Name)
+	}
+	return members, nil
+}
+
+func (e nodeElectorate) Reputation(ctx context.Context, nodeID string) (*models.NodeReputation, error) {
+	return e.reputations.GetReputation(ctx, nodeID)
+}
+
+// openGovernanceService abre o banco local e cria o serviço de governança
+func openGovernanceService() (*governance.Service, func(), error) {
+	store, err := openStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	svc := governance.NewService(
+		storage.NewGovernanceRepository(store),
+		nodeElectorate{reputations: storage.NewReputationRepository(store)},
+		governanceLogger{},
+	)
+	return svc, func() { store.Close() }, nil
+}
+
+// reputationPolicyFile retorna o caminho do arquivo de política de reputação
+func reputationPolicyFile() string {
+	return filepath.Join(getSyntropyDir(), "config", "reputation.json")
+}
+
+// loadReputationPolicy carrega a política de reputação, usando os valores
+// padrão para o que não estiver no arquivo
+func loadReputationPolicy() (*reputation.Policy, error) {
+	policy := reputation.DefaultPolicy()
+	data, err := os.ReadFile(reputationPolicyFile())
+	if os.IsNotExist(err) {
+		return policy, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read reputation policy: %w", err)
+	}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid reputation policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// openReputationService abre o banco local e cria o serviço de reputação,
+// alimentado pelo ledger de créditos e pelas votações
+func openReputationService() (*reputation.Service, func(), error) {
+	policy, err := loadReputationPolicy()
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := openStore()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reputations := storage.NewReputationRepository(store)
+	ledger := credits.NewService(storage.NewCreditsRepository(store), creditsLogger{})
+	votes := governance.NewService(
+		storage.NewGovernanceRepository(store),
+		nodeElectorate{reputations: reputations},
+		governanceLogger{},
+	)
+
+	svc := reputation.NewService(reputations, reputationLogger{})
+	if err := svc.SetPolicy(policy); err != nil {
+		store.Close()
+		return nil, nil, err
+	}
+	svc.AddSource(reputation.TransactionSource{Transactions: ledger})
+	svc.AddSource(reputation.GovernanceSource{Proposals: votes})
+
+	return svc, func() { store.Close() }, nil
+}
+
+// openCreditsService abre o banco local e cria o serviço de créditos
+func openCreditsService() (*credits.Service, func(), error) {
+	store, err := openStore()
+	if err != nil {
+		return nil, nil, err
+	}
+	svc := credits.NewService(storage.NewCreditsRepository(store), creditsLogger{})
+	return svc, func() { store.Close() }, nil
+}
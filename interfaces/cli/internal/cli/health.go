@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+	"syntropy-cc/cooperative-grid/core/health"
+)
+
+// nodeHealthChecker verifica os nós com as sondas de core/health: RTT, SSH
+// com a chave do host fixada, node-exporter, Docker, WireGuard e validade
+// do certificado
+type nodeHealthChecker struct {
+	checker *health.Checker
+	// certs é o certificado atual de cada nó emitido pela CA
+	certs map[string]*ca.Record
+}
+
+func newNodeHealthChecker(certWindow time.Duration) (*nodeHealthChecker, error) {
+	policy, err := loadHealthPolicy()
+	if err != nil {
+		return nil, err
+	}
+	certs, err := nodeCertificates()
+	if err != nil {
+		return nil, fmt.Errorf("could not read node certificates: %w", err)
+	}
+
+	return &nodeHealthChecker{
+		checker: &health.Checker{
+			Reachability: &health.RTTProbe{},
+			Probes: []health.Probe{
+				&health.SSHProbe{},
+				&health.NodeExporterProbe{},
+				&health.DockerProbe{},
+				&health.WireGuardProbe{},
+				&health.CertProbe{Window: certWindow},
+			},
+			SSH: health.SSHConfig{
+				Users:   []string{"syntropy", "admin"},
+				Signers: discoverySigners(),
+			},
+			Policy: &policy,
+		},
+		certs: certs,
+	}, nil
+}
+
+// check verifica um nó. A chave SSH do host é fixada no primeiro contato,
+// como no known_hosts, e verificada nos seguintes.
+func (h *nodeHealthChecker) check(ctx context.Context, node NodeInfo) HealthResult {
+	result := HealthResult{
+		NodeName:  node.Name,
+		IPAddress: node.Network.IPAddress,
+		LastSeen:  node.LastSeen,
+	}
+
+	if node.Network.IPAddress == "" {
+		result.Status = "unknown"
+		result.Error = "No IP address"
+		return result
+	}
+
+	target := health.Target{
+		Name:    node.Name,
+		Addr:    node.Network.IPAddress,
+		HostKey: node.Metadata["ssh_host_key"],
+	}
+	if cert, ok := h.certs[node.Name]; ok {
+		target.CertNotAfter = cert.NotAfter
+	}
+	checked := h.checker.Check(ctx, target)
+
+	result.Status = checked.Status
+	result.Score = checked.Score
+	result.Checks = checked.Checks
+	result.Latency = int(checked.RTT.Milliseconds())
+	result.Error = checked.Problems()
+	for _, c := range checked.Checks {
+		if c.Probe == "ssh" {
+			result.SSH = c.State == health.Pass || c.State == health.Warn
+		}
+	}
+
+	if target.HostKey == "" && checked.HostKey != "" {
+		if err := pinHostKey(node, checked.HostKey); err != nil {
+			result.Error = joinDetail(fmt.Sprintf("could not pin host key: %v", err), result.Error)
+		}
+	}
+	return result
+}
+
+// pinHostKey grava a impressão da chave SSH do host nos metadados do nó
+func pinHostKey(node NodeInfo, fingerprint string) error {
+	if node.Metadata == nil {
+		node.Metadata = map[string]string{}
+	}
+	node.Metadata["ssh_host_key"] = fingerprint
+	return saveNode(node)
+}
+
+// nodeCertificates devolve o certificado atual de cada nó. Sem CA
+// inicializada não há nenhum.
+func nodeCertificates() (map[string]*ca.Record, error) {
+	dir := caDir()
+	if !ca.Exists(dir) {
+		return nil, nil
+	}
+	authority, err := ca.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	records, err := authority.NodeCertificates()
+	if err != nil {
+		return nil, err
+	}
+	certs := make(map[string]*ca.Record, len(records))
+	for _, r := range records {
+		certs[r.CommonName] = r
+	}
+	return certs, nil
+}
+
+// loadHealthPolicy lê a política de pontuação da seção "health" de
+// ~/.syntropy/config/manager.json, ou a padrão se ela não existir
+func loadHealthPolicy() (health.Policy, error) {
+	path := filepath.Join(getSyntropyDir(), "config", "manager.json")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return health.DefaultPolicy(), nil
+	}
+	if err != nil {
+		return health.Policy{}, err
+	}
+	return parseHealthPolicy(path, data)
+}
+
+// parseHealthPolicy aplica a seção "health" do manager.json sobre a política
+// padrão, de modo que uma seção parcial mantém os demais valores
+func parseHealthPolicy(path string, data []byte) (health.Policy, error) {
+	var config struct {
+		Health json.RawMessage `json:"health"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return health.Policy{}, fmt.Errorf("invalid %s: %w", path, err)
+	}
+	policy := health.DefaultPolicy()
+	if len(config.Health) == 0 || string(config.Health) == "null" {
+		return policy, nil
+	}
+	if err := json.Unmarshal(config.Health, &policy); err != nil {
+		return health.Policy{}, fmt.Errorf("invalid health policy in %s: %w", path, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return health.Policy{}, fmt.Errorf("invalid health policy in %s: %w", path, err)
+	}
+	return policy, nil
+}
+
+// reachable diz se o nó respondeu, mesmo que com problemas
+func reachable(status string) bool {
+	return status != health.StatusOffline && status != "unknown"
+}
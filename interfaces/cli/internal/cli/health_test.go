@@ -0,0 +1,41 @@
+package cli
+
+import (
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/health"
+)
+
+func TestParseHealthPolicy(t *testing.T) {
+	defaults := health.DefaultPolicy()
+	partial := defaults
+	partial.Healthy = 90
+
+	tests := []struct {
+		name    string
+		config  string
+		want    health.Policy
+		wantErr bool
+	}{
+		{"sem seção", `{"other": 1}`, defaults, false},
+		{"seção nula", `{"health": null}`, defaults, false},
+		{"seção parcial mantém os pesos", `{"health": {"healthy": 90}}`, partial, false},
+		{"seção completa", `{"health": {"critical_weight": 50, "warning_weight": 20, "info_weight": 1, "healthy": 70, "degraded": 40}}`,
+			health.Policy{CriticalWeight: 50, WarningWeight: 20, InfoWeight: 1, Healthy: 70, Degraded: 40}, false},
+		{"limiares fora de ordem", `{"health": {"degraded": 95}}`, health.Policy{}, true},
+		{"peso negativo", `{"health": {"warning_weight": -1}}`, health.Policy{}, true},
+		{"tipo errado", `{"health": {"healthy": "90"}}`, health.Policy{}, true},
+		{"JSON inválido", `{"health": `, health.Policy{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHealthPolicy("manager.json", []byte(tt.config))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("erro = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("política = %+v, esperado %+v", got, tt.want)
+			}
+		})
+	}
+}
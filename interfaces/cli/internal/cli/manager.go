@@ -1,17 +1,27 @@
 package cli
 
 import (
+	"context"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
+	"net/netip"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+	"syntropy-cc/cooperative-grid/core/discovery"
+	"syntropy-cc/cooperative-grid/core/health"
 )
 
 // NodeInfo representa informações de um nó
@@ -76,6 +86,7 @@ This command provides comprehensive node management capabilities:
 	cmd.AddCommand(newManagerBackupCommand())
 	cmd.AddCommand(newManagerRestoreCommand())
 	cmd.AddCommand(newManagerHealthCommand())
+	cmd.AddCommand(newManagerCertsCommand())
 
 	return cmd
 }
@@ -141,8 +152,10 @@ You can also execute a single command remotely.`,
 // newManagerStatusCommand cria o comando de status
 func newManagerStatusCommand() *cobra.Command {
 	var (
-		format string
-		watch  bool
+		format   string
+		watch    bool
+		events   bool
+		interval time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -151,19 +164,26 @@ func newManagerStatusCommand() *cobra.Command {
 		Long: `Show detailed status information for nodes.
 
 If no node name is provided, shows status for all nodes.
-Use --watch to continuously monitor status changes.`,
+Use --watch to poll every node at --interval and redraw the table;
+nodes that went online or offline, or whose latency spiked, are
+highlighted. Use --events to stream those changes as JSON lines instead.`,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			nodeName := ""
 			if len(args) > 0 {
 				nodeName = args[0]
 			}
-			return showNodeStatus(nodeName, format, watch)
+			if watch && !events && format != "table" {
+				return fmt.Errorf("--watch draws a table; use --events for machine-readable output")
+			}
+			return showNodeStatus(nodeName, format, watch || events, events, interval)
 		},
 	}
 
 	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, yaml)")
 	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Watch for changes")
+	cmd.Flags().BoolVar(&events, "events", false, "Watch and stream state changes as JSON lines")
+	cmd.Flags().DurationVar(&interval, "interval", defaultWatchInterval, "Polling interval for --watch and --events")
 
 	return cmd
 }
@@ -176,6 +196,8 @@ func newManagerDiscoverCommand() *cobra.Command {
 		timeout     int
 		parallel    int
 		updateCache bool
+		mdns        bool
+		mdnsWait    time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -184,20 +206,31 @@ func newManagerDiscoverCommand() *cobra.Command {
 		Long: `Discover Syntropy nodes on the local network.
 
 This command will:
-1. Scan specified networks for SSH-enabled devices
-2. Attempt to identify Syntropy nodes
+1. Scan specified networks for SSH-enabled devices, in parallel, or with
+   --mdns browse for nodes announcing the _syntropy._tcp service
+2. Identify Syntropy nodes: through a handshake signed with the node
+   certificate of the grid CA (verified), or by reading
+   /opt/syntropy/metadata/node.json over SSH with the keys in
+   ~/.syntropy/keys (unverified). A node found over mDNS that cannot be
+   identified is kept with what it announced, as unverified.
 3. Update node metadata with discovered information
-4. Cache results for faster future lookups`,
+4. Record every host seen, with first and last seen times, in
+   ~/.syntropy/cache/discovery.json`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return discoverNodes(networks, port, timeout, parallel, updateCache)
+			if mdns && len(networks) > 0 {
+				return fmt.Errorf("--mdns browses the local link and cannot be combined with --networks")
+			}
+			return discoverNodes(networks, port, timeout, parallel, updateCache, mdns, mdnsWait)
 		},
 	}
 
 	cmd.Flags().StringSliceVarP(&networks, "networks", "n", []string{}, "Networks to scan (e.g., 192.168.1.0/24)")
 	cmd.Flags().IntVarP(&port, "port", "p", 22, "SSH port to check")
-	cmd.Flags().IntVarP(&timeout, "timeout", "t", 10, "Connection timeout in seconds")
-	cmd.Flags().IntVar(&parallel, "parallel", 5, "Number of parallel scans")
+	cmd.Flags().IntVarP(&timeout, "timeout", "t", 2, "Connection timeout in seconds")
+	cmd.Flags().IntVar(&parallel, "parallel", 64, "Number of addresses probed at once")
 	cmd.Flags().BoolVar(&updateCache, "update-cache", true, "Update discovery cache")
+	cmd.Flags().BoolVar(&mdns, "mdns", false, "Browse for nodes announced over mDNS instead of scanning")
+	cmd.Flags().DurationVar(&mdnsWait, "mdns-wait", 3*time.Second, "How long to collect mDNS announcements")
 
 	return cmd
 }
@@ -205,57 +238,103 @@ This command will:
 // newManagerBackupCommand cria o comando de backup
 func newManagerBackupCommand() *cobra.Command {
 	var (
-		output   string
+		opts     backupOptions
 		compress bool
-		include  []string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "backup",
 		Short: "Backup node configurations",
-		Long: `Backup all node configurations and metadata.
+		Long: `Take an encrypted snapshot of node configurations and metadata.
 
-This creates a complete backup of:
+A snapshot covers:
 - Node configurations and metadata
 - SSH keys and certificates
 - Manager configuration
-- Discovery cache`,
+- Discovery cache
+- Certificate authority
+
+Snapshots are zstd-compressed tar archives encrypted with age, with a
+manifest holding the SHA-256 of every file. They are incremental: only files
+changed since the previous snapshot are stored, and every 8th snapshot is
+full again (or use --full).
+
+The archive is encrypted to the age public keys given with --recipient or
+listed under "recipients" in the "backup" section of manager.json. Without
+any, a passphrase is read from --passphrase-file, $SYNTROPY_BACKUP_PASSPHRASE
+or the terminal.
+
+After each snapshot the "retention" policy of manager.json (keep_last,
+keep_daily, keep_weekly, keep_monthly) removes snapshots no longer needed.
+Snapshots that a kept one depends on are never removed.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return backupNodes(output, compress, include)
+			return backupNodes(opts)
 		},
 	}
 
-	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file path (default: auto-generated)")
+	cmd.Flags().StringVarP(&opts.dir, "output", "o", "", "Backup directory (default: ~/.syntropy/backups)")
 	cmd.Flags().BoolVarP(&compress, "compress", "c", true, "Compress backup")
-	cmd.Flags().StringSliceVar(&include, "include", []string{"nodes", "keys", "config", "cache"}, "Components to include")
+	cmd.Flags().MarkDeprecated("compress", "snapshots are always zstd-compressed")
+	cmd.Flags().StringSliceVar(&opts.include, "include", defaultBackupComponents, "Components to include")
+	cmd.Flags().BoolVar(&opts.full, "full", false, "Take a full snapshot instead of an incremental one")
+	cmd.Flags().StringArrayVar(&opts.recipients, "recipient", nil, "age public key to encrypt to (repeatable)")
+	cmd.Flags().StringVar(&opts.recipientsFile, "recipients-file", "", "File with age public keys to encrypt to")
+	cmd.Flags().StringVar(&opts.passphraseFile, "passphrase-file", "", "File holding the backup passphrase")
+	cmd.Flags().BoolVar(&opts.noPrune, "no-prune", false, "Keep every snapshot regardless of the retention policy")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List backup snapshots",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return listBackups(opts.dir)
+		},
+	})
 
 	return cmd
 }
 
 // newManagerRestoreCommand cria o comando de restore
 func newManagerRestoreCommand() *cobra.Command {
-	var (
-		force bool
-	)
+	var opts restoreOptions
 
 	cmd := &cobra.Command{
-		Use:   "restore <backup-file>",
+		Use:   "restore <snapshot>",
 		Short: "Restore node configurations",
-		Long: `Restore node configurations from a backup file.
+		Long: `Restore node configurations from a backup snapshot.
+
+<snapshot> is an archive (*.tar.zst.age) or a snapshot ID from
+"syntropy manager backup list". An incremental snapshot also needs the older
+snapshots it depends on, in the same directory.
+
+Every file is checked against the SHA-256 in the manifest before anything is
+replaced; if any check fails nothing is changed. Replaced files and
+directories are kept with a .backup.<timestamp> suffix.
 
 This will restore:
 - Node configurations and metadata
 - SSH keys and certificates
 - Manager configuration
-- Discovery cache`,
+- Discovery cache
+- Certificate authority
+
+Use --component to restore only some of them, --node to restore only the
+metadata and keys of some nodes, and --verify to check the snapshot without
+restoring anything.`,
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			backupFile := args[0]
-			return restoreNodes(backupFile, force)
+			return restoreNodes(args[0], opts)
 		},
 	}
 
-	cmd.Flags().BoolVar(&force, "force", false, "Force restore without confirmation")
+	cmd.Flags().BoolVar(&opts.verify, "verify", false, "Only verify the integrity of the snapshot")
+	cmd.Flags().StringSliceVar(&opts.components, "component", nil, "Components to restore (nodes, keys, config, cache, ca)")
+	cmd.Flags().StringSliceVar(&opts.nodes, "node", nil, "Nodes whose metadata and keys to restore")
+	cmd.Flags().StringArrayVarP(&opts.identities, "identity", "i", nil, "age identity file to decrypt with (repeatable)")
+	cmd.Flags().StringVar(&opts.passphraseFile, "passphrase-file", "", "File holding the backup passphrase")
+	cmd.Flags().StringVar(&opts.dir, "dir", "", "Backup directory to look up snapshot IDs (default: ~/.syntropy/backups)")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Force restore without confirmation")
+	cmd.MarkFlagsMutuallyExclusive("component", "node")
 
 	return cmd
 }
@@ -263,8 +342,11 @@ This will restore:
 // newManagerHealthCommand cria o comando de health check
 func newManagerHealthCommand() *cobra.Command {
 	var (
-		format string
-		watch  bool
+		format     string
+		watch      bool
+		events     bool
+		interval   time.Duration
+		certWindow time.Duration
 	)
 
 	cmd := &cobra.Command{
@@ -272,19 +354,35 @@ func newManagerHealthCommand() *cobra.Command {
 		Short: "Check health of all nodes",
 		Long: `Perform comprehensive health check on all managed nodes.
 
-This will check:
-- Network connectivity
-- SSH accessibility
-- Service status
-- Resource usage
-- System health`,
+Every node is checked in parallel by these probes:
+- rtt (critical): round trip to the SSH port; a node that does not answer
+  is offline and the other probes are skipped
+- ssh (critical): SSH handshake, with the host key pinned on first contact;
+  skipped when no SSH key is configured
+- node_exporter (warning): disk, memory and load from port 9100
+- docker (critical): the Docker daemon answers
+- wireguard (warning): age of the last handshake with each peer
+- certificate (critical): expiry of the node certificate issued by the CA
+
+A failed probe costs the weight of its severity and a warning half of it.
+The resulting score (0-100) makes the node online, degraded or unhealthy
+according to the "health" policy in ~/.syntropy/config/manager.json.
+
+Use --watch to repeat the checks at --interval and redraw the table, or
+--events to stream the changes as JSON lines.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return checkNodeHealth(format, watch)
+			if watch && !events && format != "table" {
+				return fmt.Errorf("--watch draws a table; use --events for machine-readable output")
+			}
+			return checkNodeHealth(format, watch || events, events, interval, certWindow)
 		},
 	}
 
 	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, yaml)")
 	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Watch for changes")
+	cmd.Flags().BoolVar(&events, "events", false, "Watch and stream state changes as JSON lines")
+	cmd.Flags().DurationVar(&interval, "interval", defaultWatchInterval, "Polling interval for --watch and --events")
+	cmd.Flags().DurationVar(&certWindow, "cert-window", ca.DefaultRenewalWindow, "Warn about certificates expiring within this window")
 
 	return cmd
 }
@@ -317,18 +415,37 @@ func listNodes(format, filter, sortBy string) error {
 }
 
 func connectToNode(nodeName string, interactive bool, command string) error {
+	sshArgs, err := nodeSSHArgs(nodeName, !interactive)
+	if err != nil {
+		return err
+	}
+	if command != "" {
+		sshArgs = append(sshArgs, command)
+	}
+
+	cmd := exec.Command("ssh", sshArgs...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// nodeSSHArgs monta os argumentos do ssh para acessar o nó como admin,
+// terminando no destino para que o chamador possa acrescentar um comando
+func nodeSSHArgs(nodeName string, batch bool) ([]string, error) {
 	node, err := loadNode(nodeName)
 	if err != nil {
-		return fmt.Errorf("node not found: %w", err)
+		return nil, fmt.Errorf("node not found: %w", err)
 	}
 
 	if node.Network.IPAddress == "" {
-		return fmt.Errorf("no IP address for node %s. Try: syntropy manager discover", nodeName)
+		return nil, fmt.Errorf("no IP address for node %s. Try: syntropy manager discover", nodeName)
 	}
 
 	keyFile := getNodeKeyFile(nodeName)
 	if _, err := os.Stat(keyFile); os.IsNotExist(err) {
-		return fmt.Errorf("SSH key not found for node %s: %s", nodeName, keyFile)
+		return nil, fmt.Errorf("SSH key not found for node %s: %s", nodeName, keyFile)
 	}
 
 	sshArgs := []string{
@@ -338,25 +455,18 @@ func connectToNode(nodeName string, interactive bool, command string) error {
 		"-o", "LogLevel=ERROR",
 	}
 
-	if !interactive {
+	if batch {
 		sshArgs = append(sshArgs, "-o", "BatchMode=yes")
 	}
 
-	if command != "" {
-		sshArgs = append(sshArgs, "admin@"+node.Network.IPAddress, command)
-	} else {
-		sshArgs = append(sshArgs, "admin@"+node.Network.IPAddress)
-	}
-
-	cmd := exec.Command("ssh", sshArgs...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
+	return append(sshArgs, "admin@"+node.Network.IPAddress), nil
 }
 
-func showNodeStatus(nodeName, format string, watch bool) error {
+func showNodeStatus(nodeName, format string, watch, events bool, interval time.Duration) error {
+	if watch {
+		return watchNodeStatus(nodeName, events, interval)
+	}
+
 	if nodeName != "" {
 		// Status de um nó específico
 		node, err := loadNode(nodeName)
@@ -387,10 +497,6 @@ func showNodeStatus(nodeName, format string, watch bool) error {
 			updateNodeStatus(&nodes[i])
 		}
 
-		if watch {
-			return watchNodeStatus(nodes, format)
-		}
-
 		switch format {
 		case "json":
 			return outputNodesJSON(nodes)
@@ -402,169 +508,94 @@ func showNodeStatus(nodeName, format string, watch bool) error {
 	}
 }
 
-func discoverNodes(networks []string, port, timeout, parallel int, updateCache bool) error {
+func discoverNodes(networks []string, port, timeout, parallel int, updateCache, mdns bool, mdnsWait time.Duration) error {
 	fmt.Println("🔍 Discovering Syntropy nodes on network...")
 
-	// Usar redes padrão se não especificadas
-	if len(networks) == 0 {
-		networks = getDefaultNetworks()
-	}
-
-	fmt.Printf("Scanning networks: %v\n", networks)
-	fmt.Printf("SSH port: %d, Timeout: %ds, Parallel: %d\n", port, timeout, parallel)
-
-	discoveredNodes := []DiscoveredNode{}
-
-	// Executar descoberta em paralelo
-	for _, network := range networks {
-		fmt.Printf("Scanning %s...\n", network)
-		nodes := scanNetwork(network, port, timeout)
-		discoveredNodes = append(discoveredNodes, nodes...)
+	identifiers, err := discoveryIdentifiers(time.Duration(timeout) * time.Second)
+	if err != nil {
+		return err
 	}
-
-	fmt.Printf("Found %d potential nodes\n", len(discoveredNodes))
-
-	// Identificar nós Syntropy
-	syntropyNodes := identifySyntropyNodes(discoveredNodes)
-
-	fmt.Printf("Identified %d Syntropy nodes\n", len(syntropyNodes))
-
-	// Atualizar ou criar nós
-	for _, node := range syntropyNodes {
-		if err := updateOrCreateNode(node); err != nil {
-			fmt.Printf("⚠️  Failed to update node %s: %v\n", node.IP, err)
-		} else {
-			fmt.Printf("✅ Updated node: %s (%s)\n", node.Hostname, node.IP)
-		}
+	if len(identifiers) == 0 {
+		fmt.Println("⚠️  No grid CA or SSH keys found: hosts will be listed but not identified")
 	}
 
-	// Atualizar cache se solicitado
-	if updateCache {
-		updateDiscoveryCache(discoveredNodes)
+	scanner := &discovery.Scanner{
+		Port:        port,
+		Timeout:     time.Duration(timeout) * time.Second,
+		Workers:     parallel,
+		Identifiers: identifiers,
+		Progress: func(done, total int) {
+			fmt.Printf("\rScanning... %d/%d", done, total)
+		},
 	}
 
-	return nil
-}
-
-func backupNodes(output string, compress bool, include []string) error {
-	fmt.Println("💾 Creating backup of node configurations...")
+	// Ctrl+C interrompe a varredura mantendo o que já foi encontrado
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	syntropyDir := getSyntropyDir()
-	timestamp := time.Now().Format("20060102_150405")
-
-	if output == "" {
-		output = filepath.Join(syntropyDir, "backups", fmt.Sprintf("backup_%s", timestamp))
-		if compress {
-			output += ".tar.gz"
+	var hosts []discovery.Host
+	if mdns {
+		hosts, err = discoverAnnouncedNodes(ctx, scanner, mdnsWait)
+	} else {
+		// Usar redes padrão se não especificadas
+		if len(networks) == 0 {
+			networks = getDefaultNetworks()
 		}
+		fmt.Printf("Scanning networks: %v\n", networks)
+		fmt.Printf("SSH port: %d, Timeout: %ds, Parallel: %d\n", port, timeout, parallel)
+		hosts, err = scanner.Discover(ctx, networks)
 	}
-
-	// Criar diretório de backup temporário
-	tempDir := filepath.Join(syntropyDir, "backups", fmt.Sprintf("temp_%s", timestamp))
-	if err := os.MkdirAll(tempDir, 0755); err != nil {
-		return fmt.Errorf("failed to create temp directory: %w", err)
+	fmt.Println()
+	if errors.Is(err, context.Canceled) {
+		fmt.Println("⚠️  Scan interrupted, keeping partial results")
+	} else if err != nil {
+		return err
 	}
-	defer os.RemoveAll(tempDir)
 
-	// Copiar componentes incluídos
-	for _, component := range include {
-		src := filepath.Join(syntropyDir, component)
-		dst := filepath.Join(tempDir, component)
-		
-		if _, err := os.Stat(src); err == nil {
-			cmd := exec.Command("cp", "-r", src, dst)
-			if err := cmd.Run(); err != nil {
-				return fmt.Errorf("failed to copy %s: %w", component, err)
-			}
+	sshHosts := 0
+	for _, host := range hosts {
+		if host.SSH {
+			sshHosts++
 		}
 	}
-
-	// Criar arquivo de informações do backup
-	backupInfo := map[string]interface{}{
-		"created":      time.Now().UTC().Format(time.RFC3339),
-		"system":       getHostname(),
-		"user":         os.Getenv("USER"),
-		"components":   include,
-		"backup_type":  "full",
-	}
-
-	infoData, _ := json.MarshalIndent(backupInfo, "", "  ")
-	infoFile := filepath.Join(tempDir, "backup_info.json")
-	os.WriteFile(infoFile, infoData, 0644)
-
-	// Comprimir se solicitado
-	if compress {
-		cmd := exec.Command("tar", "-czf", output, "-C", filepath.Dir(tempDir), filepath.Base(tempDir))
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to compress backup: %w", err)
+	fmt.Printf("Found %d hosts with SSH\n", sshHosts)
+
+	// Atualizar ou criar os nós identificados
+	now := time.Now()
+	identified := 0
+	for _, host := range hosts {
+		if host.Node == nil {
+			continue
 		}
-	} else {
-		cmd := exec.Command("cp", "-r", tempDir, output)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to copy backup: %w", err)
+		identified++
+		if err := updateOrCreateNode(host, now); err != nil {
+			fmt.Printf("⚠️  Failed to update node %s: %v\n", host.Addr, err)
+			continue
 		}
-	}
-
-	fmt.Printf("✅ Backup created: %s\n", output)
-	return nil
-}
-
-func restoreNodes(backupFile string, force bool) error {
-	if !force {
-		fmt.Printf("⚠️  This will restore from backup: %s\n", backupFile)
-		fmt.Print("Are you sure? (y/N): ")
-		var response string
-		fmt.Scanln(&response)
-		if response != "y" && response != "Y" {
-			fmt.Println("Restore cancelled.")
-			return nil
+		trust := "verified"
+		if !host.Node.Verified {
+			trust = "unverified"
 		}
+		fmt.Printf("✅ Updated node: %s (%s, %s via %s)\n", host.Node.Name, host.Addr, trust, host.Node.Method)
 	}
 
-	fmt.Println("🔄 Restoring node configurations from backup...")
-
-	syntropyDir := getSyntropyDir()
-	tempDir := filepath.Join(syntropyDir, "backups", "restore_temp")
+	fmt.Printf("Identified %d Syntropy nodes\n", identified)
 
-	// Extrair backup
-	if strings.HasSuffix(backupFile, ".tar.gz") {
-		cmd := exec.Command("tar", "-xzf", backupFile, "-C", filepath.Dir(tempDir))
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to extract backup: %w", err)
-		}
-	} else {
-		cmd := exec.Command("cp", "-r", backupFile, tempDir)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to copy backup: %w", err)
-		}
-	}
-	defer os.RemoveAll(tempDir)
-
-	// Restaurar componentes
-	components := []string{"nodes", "keys", "config", "cache"}
-	for _, component := range components {
-		src := filepath.Join(tempDir, component)
-		dst := filepath.Join(syntropyDir, component)
-		
-		if _, err := os.Stat(src); err == nil {
-			// Fazer backup do existente
-			if _, err := os.Stat(dst); err == nil {
-				backupDst := dst + ".backup." + time.Now().Format("20060102_150405")
-				exec.Command("mv", dst, backupDst).Run()
-			}
-			
-			cmd := exec.Command("cp", "-r", src, dst)
-			if err := cmd.Run(); err != nil {
-				return fmt.Errorf("failed to restore %s: %w", component, err)
-			}
+	// Atualizar cache se solicitado
+	if updateCache {
+		if err := updateDiscoveryCache(hosts, now); err != nil {
+			fmt.Printf("⚠️  Failed to update discovery cache: %v\n", err)
 		}
 	}
 
-	fmt.Println("✅ Restore completed successfully")
 	return nil
 }
 
-func checkNodeHealth(format string, watch bool) error {
+func checkNodeHealth(format string, watch, events bool, interval, certWindow time.Duration) error {
+	if watch {
+		return watchHealthResults(events, interval, certWindow)
+	}
+
 	fmt.Println("❤️  Checking health of all nodes...")
 
 	nodes, err := loadAllNodes()
@@ -572,15 +603,49 @@ func checkNodeHealth(format string, watch bool) error {
 		return fmt.Errorf("failed to load nodes: %w", err)
 	}
 
-	healthResults := []HealthResult{}
+	checker, err := newNodeHealthChecker(certWindow)
+	if err != nil {
+		return err
+	}
 
-	for _, node := range nodes {
-		health := checkSingleNodeHealth(node)
-		healthResults = append(healthResults, health)
+	expiring, err := expiringCertificates(certWindow)
+	if err != nil {
+		fmt.Printf("⚠️  Could not check certificates: %v\n", err)
 	}
 
-	if watch {
-		return watchHealthResults(healthResults, format)
+	healthResults := make([]HealthResult, len(nodes))
+	parallel(len(nodes), func(i int) {
+		healthResults[i] = checker.check(context.Background(), nodes[i])
+	})
+	for i := range healthResults {
+		if cert, ok := expiring[nodes[i].Name]; ok {
+			healthResults[i].CertExpiresAt = cert.NotAfter.Format(time.RFC3339)
+			healthResults[i].CertWarning = certWarning(cert, time.Now())
+			delete(expiring, nodes[i].Name)
+		}
+	}
+
+	// Nós provisionados que ainda não foram registrados no manager também
+	// precisam ter o certificado renovado
+	unregistered := make([]string, 0, len(expiring))
+	for name := range expiring {
+		unregistered = append(unregistered, name)
+	}
+	sort.Strings(unregistered)
+	for _, name := range unregistered {
+		cert := expiring[name]
+		healthResults = append(healthResults, HealthResult{
+			NodeName:      name,
+			Status:        "unknown",
+			Error:         "Node not registered",
+			CertExpiresAt: cert.NotAfter.Format(time.RFC3339),
+			CertWarning:   certWarning(cert, time.Now()),
+		})
+	}
+
+	// Registrar os resultados como evidência para a reputação dos nós
+	if err := recordHealthChecks(healthResults); err != nil {
+		fmt.Printf("⚠️  Could not record health checks: %v\n", err)
 	}
 
 	switch format {
@@ -593,24 +658,43 @@ func checkNodeHealth(format string, watch bool) error {
 	}
 }
 
-// Estruturas e funções auxiliares
+// recordHealthChecks salva o resultado de cada verificação no banco local,
+// alimentando o cálculo de uptime da reputação. Nós sem IP são ignorados.
+func recordHealthChecks(results []HealthResult) error {
+	svc, closeStore, err := openReputationService()
+	if err != nil {
+		return err
+	}
+	defer closeStore()
 
-type DiscoveredNode struct {
-	IP       string
-	Hostname string
-	Port     int
-	SSH      bool
-	Latency  int
+	now := time.Now()
+	for _, result := range results {
+		if result.Status == "unknown" {
+			continue
+		}
+		if err := svc.RecordHealthCheck(context.Background(), result.NodeName, reachable(result.Status), now); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
+// Estruturas e funções auxiliares
+
 type HealthResult struct {
-	NodeName    string `json:"node_name"`
-	Status      string `json:"status"`
-	IPAddress   string `json:"ip_address"`
-	SSH         bool   `json:"ssh_accessible"`
-	Latency     int    `json:"latency_ms"`
-	LastSeen    string `json:"last_seen"`
-	Error       string `json:"error,omitempty"`
+	NodeName  string `json:"node_name"`
+	Status    string `json:"status"`
+	IPAddress string `json:"ip_address"`
+	SSH       bool   `json:"ssh_accessible"`
+	Latency   int    `json:"latency_ms"`
+	LastSeen  string `json:"last_seen"`
+	Error     string `json:"error,omitempty"`
+	// Score (0-100) e resultado de cada sonda de core/health
+	Score  int            `json:"score"`
+	Checks []health.Check `json:"checks,omitempty"`
+	// Preenchidos quando o certificado está vencido ou perto de vencer
+	CertExpiresAt string `json:"cert_expires_at,omitempty"`
+	CertWarning   string `json:"cert_warning,omitempty"`
 }
 
 func loadAllNodes() ([]NodeInfo, error) {
@@ -705,22 +789,40 @@ func sortNodes(nodes []NodeInfo, sortBy string) {
 }
 
 func updateNodeStatus(node *NodeInfo) {
+	updateNodeStatusContext(context.Background(), node)
+}
+
+// updateNodeStatusContext é updateNodeStatus interrompível por ctx
+func updateNodeStatusContext(ctx context.Context, node *NodeInfo) error {
 	if node.Network.IPAddress == "" {
 		node.Status = "unknown"
-		return
+		return nil
 	}
 
 	// Testar conectividade SSH
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:22", node.Network.IPAddress), 5*time.Second)
+	latency, err := probeSSH(ctx, node.Network.IPAddress)
+	node.LastSeen = time.Now().Format(time.RFC3339)
 	if err != nil {
 		node.Status = "offline"
-		node.LastSeen = time.Now().Format(time.RFC3339)
-		return
+		return err
 	}
-	conn.Close()
 
 	node.Status = "online"
-	node.LastSeen = time.Now().Format(time.RFC3339)
+	node.Network.Latency = int(latency.Milliseconds())
+	return nil
+}
+
+// probeSSH mede o tempo para abrir uma conexão com a porta SSH do nó
+func probeSSH(ctx context.Context, address string) (time.Duration, error) {
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(address, "22"))
+	if err != nil {
+		return 0, err
+	}
+	latency := time.Since(start)
+	conn.Close()
+	return latency, nil
 }
 
 func getDefaultNetworks() []string {
@@ -750,57 +852,165 @@ func getDefaultNetworks() []string {
 	return networks[:min(len(networks), 3)] // Limitar a 3 redes
 }
 
-func scanNetwork(network string, port, timeout int) []DiscoveredNode {
-	// Implementação simplificada - em produção usar nmap
-	nodes := []DiscoveredNode{}
-	
-	// Para demonstração, retornar alguns IPs fictícios
-	// Em produção, usar nmap para scan real
-	return nodes
-}
+// discoverAnnouncedNodes procura os nós anunciados via mDNS e identifica
+// cada endereço anunciado como na varredura. Quem não puder ser
+// identificado fica com o que anunciou, sem verificação.
+func discoverAnnouncedNodes(ctx context.Context, scanner *discovery.Scanner, wait time.Duration) ([]discovery.Host, error) {
+	fmt.Printf("Browsing for %s services for %s...\n", discovery.ServiceType, wait)
+	services, err := (&discovery.Browser{}).Browse(ctx, wait)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("Found %d nodes announced over mDNS\n", len(services))
+
+	var targets []netip.Addr
+	announced := map[string]discovery.Service{}
+	for _, service := range services {
+		for _, ip := range service.Addrs {
+			addr, ok := netip.AddrFromSlice(ip)
+			if !ok {
+				continue
+			}
+			addr = addr.Unmap()
+			if _, seen := announced[addr.String()]; !seen {
+				targets = append(targets, addr)
+				announced[addr.String()] = service
+			}
+		}
+	}
 
-func identifySyntropyNodes(discovered []DiscoveredNode) []DiscoveredNode {
-	// Implementação simplificada - em produção verificar se é nó Syntropy
-	return discovered
+	hosts, err := scanner.Probe(ctx, targets)
+	reached := map[string]bool{}
+	for i := range hosts {
+		reached[hosts[i].Addr] = true
+		if hosts[i].Node == nil {
+			hosts[i].Node = announced[hosts[i].Addr].Node()
+		}
+	}
+	for _, addr := range targets {
+		if !reached[addr.String()] {
+			fmt.Printf("⚠️  %s announced %s but did not answer on port %d\n",
+				announced[addr.String()].Instance, addr, scanner.Port)
+		}
+	}
+	return hosts, err
 }
 
-func updateOrCreateNode(discovered DiscoveredNode) error {
-	// Implementação simplificada
-	return nil
+// discoveryIdentifiers monta os meios de reconhecer um nó: o handshake
+// assinado com o certificado do nó, quando há CA do grid, e a leitura do
+// node.json via ssh com as chaves em ~/.syntropy/keys
+func discoveryIdentifiers(timeout time.Duration) ([]discovery.Identifier, error) {
+	var identifiers []discovery.Identifier
+
+	if dir := caDir(); ca.Exists(dir) {
+		authority, err := ca.Open(dir)
+		if err != nil {
+			return nil, err
+		}
+		roots := x509.NewCertPool()
+		roots.AppendCertsFromPEM(authority.RootPEM())
+		identifiers = append(identifiers, &discovery.Handshake{
+			Port:    agentAPIPort,
+			Roots:   roots,
+			Timeout: timeout,
+			// Certificados revogados não identificam ninguém
+			Check: func(chainPEM []byte) error {
+				_, err := authority.Verify(chainPEM)
+				return err
+			},
+		})
+	}
+
+	if signers := discoverySigners(); len(signers) > 0 {
+		identifiers = append(identifiers, &discovery.SSHIdentifier{
+			Users:   []string{"syntropy", "admin"},
+			Signers: signers,
+			Timeout: timeout,
+		})
+	}
+
+	return identifiers, nil
 }
 
-func updateDiscoveryCache(discovered []DiscoveredNode) {
-	// Implementação simplificada
+// discoverySigners carrega as chaves privadas sem senha de ~/.syntropy/keys
+func discoverySigners() []ssh.Signer {
+	files, _ := filepath.Glob(filepath.Join(getSyntropyDir(), "keys", "*.key"))
+	var signers []ssh.Signer
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			continue // Chaves protegidas por senha ou em outro formato
+		}
+		signers = append(signers, signer)
+	}
+	return signers
 }
 
-func checkSingleNodeHealth(node NodeInfo) HealthResult {
-	result := HealthResult{
-		NodeName:  node.Name,
-		IPAddress: node.Network.IPAddress,
-		LastSeen:  node.LastSeen,
+// updateOrCreateNode grava no NodeInfo o que a descoberta encontrou
+func updateOrCreateNode(host discovery.Host, now time.Time) error {
+	found := host.Node
+	node, err := loadNode(found.Name)
+	if err != nil {
+		node = NodeInfo{
+			Name:        found.Name,
+			Description: found.Metadata.Description,
+			Created:     now.UTC().Format(time.RFC3339),
+		}
+	}
+	if node.Metadata == nil {
+		node.Metadata = map[string]string{}
 	}
 
-	if node.Network.IPAddress == "" {
-		result.Status = "unknown"
-		result.Error = "No IP address"
-		return result
+	// Um nó já provado pelo certificado não muda de endereço só porque um
+	// host sem prova diz ter o mesmo nome
+	if !found.Verified && node.Metadata["discovery_verified"] == "true" && node.Network.IPAddress != host.Addr {
+		return fmt.Errorf("node %s was verified at %s; unverified claim from %s ignored", found.Name, node.Network.IPAddress, host.Addr)
 	}
 
-	// Testar conectividade
-	conn, err := net.DialTimeout("tcp", fmt.Sprintf("%s:22", node.Network.IPAddress), 5*time.Second)
-	if err != nil {
-		result.Status = "offline"
-		result.SSH = false
-		result.Error = err.Error()
-		return result
+	node.Status = "online"
+	node.LastSeen = now.Format(time.RFC3339)
+	node.Network.IPAddress = host.Addr
+	node.Network.SSHPort = host.Port
+	node.Network.Latency = int(host.Latency.Milliseconds())
+	node.Network.LastPing = now.Format(time.RFC3339)
+	if node.Description == "" {
+		node.Description = found.Metadata.Description
+	}
+
+	node.Metadata["discovered_via"] = found.Method
+	node.Metadata["discovery_verified"] = fmt.Sprint(found.Verified)
+	for key, value := range map[string]string{
+		"role":              found.Metadata.Role,
+		"coordinates":       found.Metadata.Coordinates,
+		"platform_version":  found.Metadata.PlatformVersion,
+		"owner_fingerprint": found.Metadata.OwnerFingerprint,
+		"cert_serial":       found.Serial,
+		"ssh_host_key":      found.HostKey,
+	} {
+		if value != "" {
+			node.Metadata[key] = value
+		}
 	}
-	conn.Close()
 
-	result.Status = "online"
-	result.SSH = true
-	result.Latency = 10 // Placeholder
+	return saveNode(node)
+}
 
-	return result
+// updateDiscoveryCache registra a varredura em ~/.syntropy/cache
+func updateDiscoveryCache(hosts []discovery.Host, now time.Time) error {
+	cache, err := discovery.LoadCache(discoveryCachePath())
+	if err != nil {
+		return err
+	}
+	cache.Merge(hosts, now)
+	return cache.Save()
+}
+
+func discoveryCachePath() string {
+	return filepath.Join(getSyntropyDir(), "cache", "discovery.json")
 }
 
 // Funções de output
@@ -869,7 +1079,7 @@ func outputNodeYAML(node NodeInfo) error {
 }
 
 func outputHealthTable(results []HealthResult) error {
-	fmt.Printf("%-20s %-15s %-10s %-8s %-10s %s\n", "NODE", "IP ADDRESS", "STATUS", "SSH", "LATENCY", "ERROR")
+	fmt.Printf("%-20s %-15s %-10s %-6s %-8s %-10s %s\n", "NODE", "IP ADDRESS", "STATUS", "SCORE", "SSH", "LATENCY", "ERROR")
 	fmt.Println(strings.Repeat("-", 80))
 
 	for _, result := range results {
@@ -877,8 +1087,23 @@ func outputHealthTable(results []HealthResult) error {
 		if result.SSH {
 			ssh = "✅"
 		}
-		fmt.Printf("%-20s %-15s %-10s %-8s %-10d %s\n",
-			result.NodeName, result.IPAddress, result.Status, ssh, result.Latency, result.Error)
+		fmt.Printf("%-20s %-15s %-10s %-6d %-8s %-10d %s\n",
+			result.NodeName, result.IPAddress, result.Status, result.Score, ssh, result.Latency, result.Error)
+	}
+
+	warned := false
+	for _, result := range results {
+		if result.CertWarning == "" {
+			continue
+		}
+		if !warned {
+			fmt.Println()
+			warned = true
+		}
+		fmt.Printf("⚠️  %s: %s\n", result.NodeName, result.CertWarning)
+	}
+	if warned {
+		fmt.Println("   Run: syntropy manager certs renew")
 	}
 
 	return nil
@@ -899,21 +1124,91 @@ func outputHealthYAML(results []HealthResult) error {
 	for _, result := range results {
 		fmt.Printf("- node_name: %s\n", result.NodeName)
 		fmt.Printf("  status: %s\n", result.Status)
+		fmt.Printf("  score: %d\n", result.Score)
 		fmt.Printf("  ssh_accessible: %t\n", result.SSH)
+		if result.CertWarning != "" {
+			fmt.Printf("  cert_warning: %s\n", result.CertWarning)
+		}
 	}
 	return nil
 }
 
-func watchNodeStatus(nodes []NodeInfo, format string) error {
-	// Implementação simplificada - em produção usar ticker
-	fmt.Println("Watching node status (press Ctrl+C to stop)...")
-	return nil
+// watchNodeStatus acompanha o status de todos os nós, ou só de nodeName
+func watchNodeStatus(nodeName string, events bool, interval time.Duration) error {
+	load := loadAllNodes
+	if nodeName != "" {
+		load = func() ([]NodeInfo, error) {
+			node, err := loadNode(nodeName)
+			if err != nil {
+				return nil, fmt.Errorf("node not found: %w", err)
+			}
+			return []NodeInfo{node}, nil
+		}
+	}
+
+	w := &watcher{
+		title:    "Node status",
+		interval: interval,
+		events:   events,
+		out:      os.Stdout,
+		load:     load,
+		probe: func(ctx context.Context, node NodeInfo) watchSample {
+			err := updateNodeStatusContext(ctx, &node)
+			sample := watchSample{
+				Node:    node.Name,
+				Address: node.Network.IPAddress,
+				Status:  node.Status,
+				Latency: time.Duration(node.Network.Latency) * time.Millisecond,
+			}
+			if err != nil {
+				sample.Detail = err.Error()
+			}
+			return sample
+		},
+	}
+	return w.run()
 }
 
-func watchHealthResults(results []HealthResult, format string) error {
-	// Implementação simplificada - em produção usar ticker
-	fmt.Println("Watching health results (press Ctrl+C to stop)...")
-	return nil
+// watchHealthResults repete as verificações de saúde a cada intervalo. Como
+// no health avulso, cada rodada é registrada para a reputação dos nós.
+func watchHealthResults(events bool, interval, certWindow time.Duration) error {
+	checker, err := newNodeHealthChecker(certWindow)
+	if err != nil {
+		return err
+	}
+
+	w := &watcher{
+		title:    "Node health",
+		interval: interval,
+		events:   events,
+		out:      os.Stdout,
+		load:     loadAllNodes,
+		probe: func(ctx context.Context, node NodeInfo) watchSample {
+			result := checker.check(ctx, node)
+			detail := result.Error
+			if reachable(result.Status) {
+				detail = joinDetail(fmt.Sprintf("score %d", result.Score), detail)
+			}
+			return watchSample{
+				Node:    result.NodeName,
+				Address: result.IPAddress,
+				Status:  result.Status,
+				Latency: time.Duration(result.Latency) * time.Millisecond,
+				Detail:  detail,
+			}
+		},
+		after: func(samples []watchSample) error {
+			results := make([]HealthResult, len(samples))
+			for i, s := range samples {
+				results[i] = HealthResult{NodeName: s.Node, Status: s.Status}
+			}
+			if err := recordHealthChecks(results); err != nil {
+				return fmt.Errorf("could not record health checks: %w", err)
+			}
+			return nil
+		},
+	}
+	return w.run()
 }
 
 func getHostname() string {
@@ -1,9 +1,20 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 
 	"github.com/spf13/cobra"
+
+	"syntropy-cc/cooperative-grid/core/services/network"
+	"syntropy-cc/cooperative-grid/core/storage"
+	"syntropy-cc/cooperative-grid/core/types/models"
+	"syntropy-cc/cooperative-grid/infrastructure"
 )
 
 // NewNetworkCommand creates the network management command
@@ -14,7 +25,9 @@ func NewNetworkCommand() *cobra.Command {
 		Long: `Manage network configuration and service mesh.
 
 The network layer handles connectivity, routing, and service discovery
-within the Syntropy Cooperative Grid.`,
+within the Syntropy Cooperative Grid. Nodes are connected by a WireGuard
+overlay generated from the routes and the service mesh; changes reach the
+nodes with "syntropy network apply".`,
 	}
 
 	// Add subcommands
@@ -22,6 +35,7 @@ within the Syntropy Cooperative Grid.`,
 	cmd.AddCommand(newNetworkTopologyCommand())
 	cmd.AddCommand(newNetworkRoutesCommand())
 	cmd.AddCommand(newNetworkMeshCommand())
+	cmd.AddCommand(newNetworkApplyCommand())
 
 	return cmd
 }
@@ -37,14 +51,69 @@ func newNetworkStatusCommand() *cobra.Command {
 		Short: "Show network status",
 		Long:  `Show overall network status and connectivity information.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement network status
-			fmt.Println("Network Status:")
-			fmt.Println("  Status: Healthy")
-			fmt.Println("  Connected Nodes: 5")
-			fmt.Println("  Active Routes: 12")
-			fmt.Println("  Service Mesh: Enabled")
-			fmt.Printf("Format: %s\n", format)
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			ctx := cmd.Context()
+			mesh, _, err := svc.Mesh(ctx)
+			if err != nil {
+				return err
+			}
+			routes, err := svc.ListRoutes(ctx, &network.RouteFilter{})
+			if err != nil {
+				return err
+			}
+			peers, err := svc.Peers(ctx)
+			if err != nil {
+				return err
+			}
+			plans, err := svc.Plan(ctx)
+			if err != nil {
+				return err
+			}
+
+			byStatus := make(map[string]int)
+			for _, r := range routes {
+				byStatus[r.Status]++
+			}
+			pending := 0
+			for _, p := range plans {
+				if p.Action != network.PlanUnchanged {
+					pending++
+				}
+			}
 
+			switch format {
+			case "json":
+				return printJSON(map[string]interface{}{
+					"service_mesh":  mesh.Status,
+					"connected":     len(peers),
+					"routes":        byStatus,
+					"pending_nodes": pending,
+				})
+			case "yaml":
+				fmt.Printf("service_mesh: %s\n", mesh.Status)
+				fmt.Printf("connected: %d\n", len(peers))
+				fmt.Println("routes:")
+				for _, s := range []models.NetworkRouteStatus{models.NetworkRouteStatusActive, models.NetworkRouteStatusInactive, models.NetworkRouteStatusError} {
+					fmt.Printf("  %s: %d\n", s, byStatus[string(s)])
+				}
+				fmt.Printf("pending_nodes: %d\n", pending)
+			default:
+				fmt.Println("Network Status:")
+				fmt.Printf("  Service Mesh: %s\n", mesh.Status)
+				fmt.Printf("  Connected Nodes: %d\n", len(peers))
+				fmt.Printf("  Routes: %d active, %d inactive, %d error\n",
+					byStatus[string(models.NetworkRouteStatusActive)],
+					byStatus[string(models.NetworkRouteStatusInactive)],
+					byStatus[string(models.NetworkRouteStatusError)])
+				if pending > 0 {
+					fmt.Printf("  Pending Changes: %d node(s), run 'syntropy network apply'\n", pending)
+				}
+			}
 			return nil
 		},
 	}
@@ -58,26 +127,81 @@ func newNetworkStatusCommand() *cobra.Command {
 func newNetworkTopologyCommand() *cobra.Command {
 	var (
 		format string
+		from   string
+		to     string
 	)
 
 	cmd := &cobra.Command{
 		Use:   "topology",
 		Short: "Show network topology",
-		Long:  `Show the network topology and node connections.`,
+		Long: `Show the network topology and node connections.
+
+Links come from the routes and the service mesh; their latency is estimated
+from the latencies measured by "syntropy manager discover". The analysis
+lists partitions, single points of failure and how many node pairs each node
+relays on shortest paths, the natural candidates for dedicated relays.
+
+With --from and --to, the shortest and the most reliable paths between the
+two nodes are shown as well. Use --format dot to render with Graphviz:
+
+  syntropy network topology --format dot | dot -Tsvg > topology.svg`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement network topology
-			fmt.Println("Network Topology:")
-			fmt.Println("  Node-01 <-> Node-02")
-			fmt.Println("  Node-02 <-> Node-03")
-			fmt.Println("  Node-03 <-> Node-04")
-			fmt.Println("  Node-04 <-> Node-05")
-			fmt.Printf("Format: %s\n", format)
+			if (from == "") != (to == "") {
+				return fmt.Errorf("--from and --to must be used together")
+			}
+
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			topo, err := svc.Topology(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			var shortest, reliable *network.Path
+			if from != "" {
+				if shortest, err = topo.ShortestPath(from, to); err != nil {
+					return err
+				}
+				if reliable, err = topo.MostReliablePath(from, to); err != nil {
+					return err
+				}
+			}
 
+			switch format {
+			case "json":
+				result := map[string]interface{}{
+					"nodes":    topo.Nodes,
+					"links":    topo.Links,
+					"analysis": topo.Analyze(),
+				}
+				if shortest != nil {
+					result["paths"] = map[string]*network.Path{
+						"shortest":      shortest,
+						"most_reliable": reliable,
+					}
+				}
+				return printJSON(result)
+			case "dot":
+				return topo.WriteDOT(os.Stdout)
+			default:
+				printTopology(topo, topo.Analyze())
+				if shortest != nil {
+					fmt.Println()
+					fmt.Printf("Shortest path:      %s\n", formatPath(shortest))
+					fmt.Printf("Most reliable path: %s\n", formatPath(reliable))
+				}
+			}
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, yaml)")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, dot)")
+	cmd.Flags().StringVar(&from, "from", "", "Compute paths from this node")
+	cmd.Flags().StringVar(&to, "to", "", "Compute paths to this node")
 
 	return cmd
 }
@@ -102,6 +226,8 @@ func newNetworkRoutesCommand() *cobra.Command {
 func newNetworkRoutesListCommand() *cobra.Command {
 	var (
 		format string
+		nodeID string
+		status string
 	)
 
 	cmd := &cobra.Command{
@@ -109,18 +235,60 @@ func newNetworkRoutesListCommand() *cobra.Command {
 		Short: "List network routes",
 		Long:  `List all network routes and their status.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement routes listing
-			fmt.Println("Network Routes:")
-			fmt.Println("  Route-01: Node-01 -> Node-02 (Active)")
-			fmt.Println("  Route-02: Node-02 -> Node-03 (Active)")
-			fmt.Println("  Route-03: Node-03 -> Node-04 (Active)")
-			fmt.Printf("Format: %s\n", format)
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
 
+			routes, err := svc.ListRoutes(cmd.Context(), &network.RouteFilter{NodeID: nodeID, Status: status})
+			if err != nil {
+				return err
+			}
+
+			switch format {
+			case "json":
+				return printJSON(routes)
+			case "yaml":
+				fmt.Println("routes:")
+				for _, r := range routes {
+					rc, err := network.DecodeRouteConfig(r)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("- id: %s\n", r.ID)
+					fmt.Printf("  source: %s\n", r.SourceNodeID)
+					fmt.Printf("  destination: %s\n", r.DestinationNodeID)
+					fmt.Printf("  priority: %d\n", rc.Priority)
+					fmt.Printf("  encryption: %t\n", rc.Encryption)
+					fmt.Printf("  ports: %s\n", formatPorts(rc))
+					fmt.Printf("  status: %s\n", r.Status)
+				}
+			default:
+				if len(routes) == 0 {
+					fmt.Println("No routes found")
+					return nil
+				}
+				fmt.Printf("%-20s %-16s %-16s %-8s %-10s %-16s %-8s\n",
+					"ID", "SOURCE", "DESTINATION", "PRIORITY", "ENCRYPTED", "PORTS", "STATUS")
+				fmt.Println(strings.Repeat("-", 100))
+				for _, r := range routes {
+					rc, err := network.DecodeRouteConfig(r)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("%-20s %-16s %-16s %-8d %-10t %-16s %-8s\n",
+						truncate(r.ID, 20), truncate(r.SourceNodeID, 16), truncate(r.DestinationNodeID, 16),
+						rc.Priority, rc.Encryption, truncate(formatPorts(rc), 16), r.Status)
+				}
+			}
 			return nil
 		},
 	}
 
 	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, yaml)")
+	cmd.Flags().StringVar(&nodeID, "node", "", "Only routes from or to this node")
+	cmd.Flags().StringVar(&status, "status", "", "Filter by status (active, inactive, error)")
 
 	return cmd
 }
@@ -131,12 +299,21 @@ func newNetworkRoutesCreateCommand() *cobra.Command {
 		source      string
 		destination string
 		priority    int
+		protocol    string
+		ports       []int
+		encryption  bool
+		compression bool
 	)
 
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create a network route",
-		Long:  `Create a new network route between nodes.`,
+		Long: `Create a new network route between nodes.
+
+Encrypted routes put both nodes on the WireGuard overlay; with --ports the
+source may only reach those ports on the destination. A route created with
+--encryption=false keeps the pair off the overlay, even with the mesh enabled.
+Between the same two nodes the route with the highest priority wins.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Validate inputs
 			if source == "" {
@@ -146,12 +323,29 @@ func newNetworkRoutesCreateCommand() *cobra.Command {
 				return fmt.Errorf("destination node is required")
 			}
 
-			// TODO: Implement route creation
-			fmt.Printf("Creating route from %s to %s\n", source, destination)
-			if priority > 0 {
-				fmt.Printf("Priority: %d\n", priority)
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
 			}
+			defer closeStore()
 
+			route, err := svc.CreateRoute(cmd.Context(), &network.RouteRequest{
+				SourceNodeID:      source,
+				DestinationNodeID: destination,
+				Config: models.NetworkRouteConfig{
+					Priority:    priority,
+					Protocol:    protocol,
+					Ports:       ports,
+					Encryption:  encryption,
+					Compression: compression,
+				},
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("✅ Created route %s: %s -> %s\n", route.ID, source, destination)
+			fmt.Println("Run 'syntropy network apply' to configure the nodes")
 			return nil
 		},
 	}
@@ -159,6 +353,10 @@ func newNetworkRoutesCreateCommand() *cobra.Command {
 	cmd.Flags().StringVarP(&source, "source", "s", "", "Source node ID (required)")
 	cmd.Flags().StringVarP(&destination, "destination", "d", "", "Destination node ID (required)")
 	cmd.Flags().IntVarP(&priority, "priority", "p", 0, "Route priority")
+	cmd.Flags().StringVar(&protocol, "protocol", "", "Protocol of the allowed ports (tcp, udp)")
+	cmd.Flags().IntSliceVar(&ports, "ports", nil, "Destination ports the source may reach (default all)")
+	cmd.Flags().BoolVar(&encryption, "encryption", true, "Route through the encrypted WireGuard overlay")
+	cmd.Flags().BoolVar(&compression, "compression", false, "Enable compression")
 
 	cmd.MarkFlagRequired("source")
 	cmd.MarkFlagRequired("destination")
@@ -171,14 +369,21 @@ func newNetworkRoutesDeleteCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "delete <route-id>",
 		Short: "Delete a network route",
-		Long:  `Delete a network route.`,
+		Long:  `Delete a network route. The nodes keep it until the next "syntropy network apply".`,
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			routeID := args[0]
 
-			// TODO: Implement route deletion
-			fmt.Printf("Deleting route: %s\n", routeID)
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
 
+			if err := svc.DeleteRoute(cmd.Context(), routeID); err != nil {
+				return err
+			}
+			fmt.Printf("✅ Deleted route %s\n", routeID)
 			return nil
 		},
 	}
@@ -198,45 +403,129 @@ func newNetworkMeshCommand() *cobra.Command {
 	cmd.AddCommand(newNetworkMeshStatusCommand())
 	cmd.AddCommand(newNetworkMeshEnableCommand())
 	cmd.AddCommand(newNetworkMeshDisableCommand())
+	cmd.AddCommand(newNetworkMeshPolicyCommand())
 
 	return cmd
 }
 
 // newNetworkMeshStatusCommand creates the mesh status command
 func newNetworkMeshStatusCommand() *cobra.Command {
+	var (
+		format string
+	)
+
 	cmd := &cobra.Command{
 		Use:   "status",
 		Short: "Show service mesh status",
-		Long:  `Show service mesh status and configuration.`,
+		Long:  `Show service mesh status, overlay settings and the nodes on the overlay.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement mesh status
-			fmt.Println("Service Mesh Status:")
-			fmt.Println("  Status: Enabled")
-			fmt.Println("  Nodes: 5")
-			fmt.Println("  Services: 12")
-			fmt.Println("  Policies: 3")
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			mesh, cfg, err := svc.Mesh(cmd.Context())
+			if err != nil {
+				return err
+			}
+			peers, err := svc.Peers(cmd.Context())
+			if err != nil {
+				return err
+			}
 
+			switch format {
+			case "json":
+				return printJSON(map[string]interface{}{
+					"status": mesh.Status,
+					"config": cfg,
+					"peers":  peers,
+				})
+			case "yaml":
+				fmt.Printf("status: %s\n", mesh.Status)
+				fmt.Printf("subnet: %s\n", cfg.Subnet)
+				fmt.Printf("listen_port: %d\n", cfg.ListenPort)
+				fmt.Printf("interface: %s\n", cfg.Interface)
+				fmt.Println("peers:")
+				for _, p := range peers {
+					fmt.Printf("- node_id: %s\n", p.NodeID)
+					fmt.Printf("  address: %s\n", p.Address)
+					fmt.Printf("  public_key: %s\n", p.PublicKey)
+				}
+			default:
+				fmt.Println("Service Mesh Status:")
+				fmt.Printf("  Status: %s\n", mesh.Status)
+				fmt.Printf("  Subnet: %s\n", cfg.Subnet)
+				fmt.Printf("  Interface: %s (port %d/udp)\n", cfg.Interface, cfg.ListenPort)
+				fmt.Printf("  Nodes: %d\n", len(peers))
+				for _, p := range peers {
+					fmt.Printf("    %-20s %-15s %s\n", p.NodeID, p.Address, p.PublicKey)
+				}
+			}
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json, yaml)")
+
 	return cmd
 }
 
 // newNetworkMeshEnableCommand creates the mesh enable command
 func newNetworkMeshEnableCommand() *cobra.Command {
+	var (
+		subnet    string
+		port      int
+		iface     string
+		keepalive int
+	)
+
 	cmd := &cobra.Command{
 		Use:   "enable",
 		Short: "Enable service mesh",
-		Long:  `Enable service mesh for the network.`,
+		Long: `Enable service mesh for the network.
+
+Every node is connected to every other node over WireGuard, except pairs
+kept apart by an unencrypted route.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement mesh enable
-			fmt.Println("Enabling service mesh...")
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			_, cfg, err := svc.Mesh(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("subnet") {
+				cfg.Subnet = subnet
+			}
+			if cmd.Flags().Changed("port") {
+				cfg.ListenPort = port
+			}
+			if cmd.Flags().Changed("interface") {
+				cfg.Interface = iface
+			}
+			if cmd.Flags().Changed("keepalive") {
+				cfg.Keepalive = keepalive
+			}
 
+			fmt.Println("Enabling service mesh...")
+			if _, err := svc.EnableMesh(cmd.Context(), cfg); err != nil {
+				return err
+			}
+			fmt.Printf("✅ Service mesh enabled on %s\n", cfg.Subnet)
+			fmt.Println("Run 'syntropy network apply' to configure the nodes")
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&subnet, "subnet", network.DefaultSubnet, "Overlay subnet node addresses are allocated from")
+	cmd.Flags().IntVar(&port, "port", network.DefaultListenPort, "WireGuard listen port")
+	cmd.Flags().StringVar(&iface, "interface", network.DefaultInterface, "WireGuard interface name")
+	cmd.Flags().IntVar(&keepalive, "keepalive", network.DefaultKeepalive, "Persistent keepalive in seconds (0 disables)")
+
 	return cmd
 }
 
@@ -245,14 +534,627 @@ func newNetworkMeshDisableCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "disable",
 		Short: "Disable service mesh",
-		Long:  `Disable service mesh for the network.`,
+		Long:  `Disable service mesh for the network. Nodes stay connected through their routes.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			// TODO: Implement mesh disable
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
 			fmt.Println("Disabling service mesh...")
+			if _, err := svc.DisableMesh(cmd.Context()); err != nil {
+				return err
+			}
+			fmt.Println("✅ Service mesh disabled")
+			fmt.Println("Run 'syntropy network apply' to configure the nodes")
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newNetworkMeshPolicyCommand creates the mesh policy command
+func newNetworkMeshPolicyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "policy",
+		Short: "Manage service mesh policies",
+		Long: `Manage the policies that decide whether traffic between nodes is allowed,
+denied, rate-limited or rerouted through a relay.
+
+Blocked IPs are denied first, then sources outside the allowed IPs (when the
+list is not empty). After that, enabled policies are tried from the highest
+priority down and the first match decides; traffic nothing matches is allowed.`,
+	}
+
+	// Add subcommands
+	cmd.AddCommand(newNetworkMeshPolicyListCommand())
+	cmd.AddCommand(newNetworkMeshPolicyAddCommand())
+	cmd.AddCommand(newNetworkMeshPolicyRemoveCommand())
+	cmd.AddCommand(newNetworkMeshPolicySecurityCommand())
+	cmd.AddCommand(newNetworkMeshPolicyTestCommand())
+
+	return cmd
+}
+
+// newNetworkMeshPolicyListCommand creates the mesh policy list command
+func newNetworkMeshPolicyListCommand() *cobra.Command {
+	var (
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List service mesh policies",
+		Long:  `List the service mesh policies in evaluation order, and the IP lists.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			_, cfg, err := svc.Mesh(cmd.Context())
+			if err != nil {
+				return err
+			}
+			policies, err := svc.Policies(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if format == "json" {
+				return printJSON(map[string]interface{}{
+					"security": cfg.Security,
+					"policies": policies,
+				})
+			}
+
+			if len(cfg.Security.BlockedIPs) > 0 {
+				fmt.Printf("Blocked IPs: %s\n", strings.Join(cfg.Security.BlockedIPs, ", "))
+			}
+			if len(cfg.Security.AllowedIPs) > 0 {
+				fmt.Printf("Allowed IPs: %s\n", strings.Join(cfg.Security.AllowedIPs, ", "))
+			}
+			if len(policies) == 0 {
+				fmt.Println("No policies, all traffic is allowed")
+				return nil
+			}
+			fmt.Printf("%-20s %-10s %-8s %-8s %s\n", "NAME", "TYPE", "PRIORITY", "ENABLED", "CONFIG")
+			fmt.Println(strings.Repeat("-", 90))
+			for _, p := range policies {
+				config := "{}"
+				if len(p.Config) > 0 {
+					data, err := json.Marshal(p.Config)
+					if err != nil {
+						return err
+					}
+					config = string(data)
+				}
+				fmt.Printf("%-20s %-10s %-8d %-8t %s\n", truncate(p.Name, 20), p.Type, p.Priority, p.Enabled, config)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json)")
+
+	return cmd
+}
+
+// newNetworkMeshPolicyAddCommand creates the mesh policy add command
+func newNetworkMeshPolicyAddCommand() *cobra.Command {
+	var (
+		policyType       string
+		priority         int
+		disabled         bool
+		sourceNodes      []string
+		sourceContainers []string
+		sourceIPs        []string
+		destinationNodes []string
+		ports            []string
+		protocol         string
+		rate             int
+		burst            int
+		via              string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Add or replace a service mesh policy",
+		Long: `Add a service mesh policy, replacing any policy with the same name.
+
+Match flags left out match all traffic. Node and container names accept
+* globs, and ports accept ranges such as 8000-8100.
+
+Examples:
+  syntropy network mesh policy add no-db --type deny --priority 100 \
+    --destination-node 'db-*' --port 5432
+  syntropy network mesh policy add api-limit --type rate_limit \
+    --source-container 'api-*' --rate 100 --burst 200
+  syntropy network mesh policy add edge-relay --type reroute --source-node 'edge-*' --via relay-1`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config := make(map[string]interface{})
+			for key, values := range map[string][]string{
+				"source_nodes":      sourceNodes,
+				"source_containers": sourceContainers,
+				"source_ips":        sourceIPs,
+				"destination_nodes": destinationNodes,
+				"ports":             ports,
+			} {
+				if len(values) > 0 {
+					config[key] = values
+				}
+			}
+			if protocol != "" {
+				config["protocol"] = protocol
+			}
+			if rate > 0 {
+				config["requests_per_second"] = rate
+			}
+			if burst > 0 {
+				config["burst"] = burst
+			}
+			if via != "" {
+				config["via"] = via
+			}
+
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			err = svc.SetPolicy(cmd.Context(), models.MeshPolicy{
+				Name:     args[0],
+				Type:     policyType,
+				Config:   config,
+				Priority: priority,
+				Enabled:  !disabled,
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("✅ Saved policy %s\n", args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&policyType, "type", network.PolicyAllow, "Policy type (allow, deny, rate_limit, reroute)")
+	cmd.Flags().IntVarP(&priority, "priority", "p", 0, "Policy priority, higher is evaluated first")
+	cmd.Flags().BoolVar(&disabled, "disabled", false, "Save the policy without enforcing it")
+	cmd.Flags().StringSliceVar(&sourceNodes, "source-node", nil, "Match traffic from these nodes")
+	cmd.Flags().StringSliceVar(&sourceContainers, "source-container", nil, "Match traffic from these containers")
+	cmd.Flags().StringSliceVar(&sourceIPs, "source-ip", nil, "Match traffic from these IPs or CIDRs")
+	cmd.Flags().StringSliceVar(&destinationNodes, "destination-node", nil, "Match traffic to these nodes")
+	cmd.Flags().StringSliceVar(&ports, "port", nil, "Match these destination ports or ranges")
+	cmd.Flags().StringVar(&protocol, "protocol", "", "Match this protocol (tcp, udp)")
+	cmd.Flags().IntVar(&rate, "rate", 0, "Requests per second for rate_limit policies")
+	cmd.Flags().IntVar(&burst, "burst", 0, "Burst for rate_limit policies (default the rate)")
+	cmd.Flags().StringVar(&via, "via", "", "Relay node for reroute policies")
+
+	return cmd
+}
+
+// newNetworkMeshPolicyRemoveCommand creates the mesh policy remove command
+func newNetworkMeshPolicyRemoveCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <name>",
+		Short: "Remove a service mesh policy",
+		Long:  `Remove a service mesh policy by name.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			if err := svc.RemovePolicy(cmd.Context(), args[0]); err != nil {
+				return err
+			}
+			fmt.Printf("✅ Removed policy %s\n", args[0])
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newNetworkMeshPolicySecurityCommand creates the mesh policy security command
+func newNetworkMeshPolicySecurityCommand() *cobra.Command {
+	var (
+		allowed []string
+		blocked []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "security",
+		Short: "Set the allowed and blocked IPs",
+		Long: `Set the IPs and CIDRs the mesh always blocks, and the ones it accepts
+traffic from. An empty allowed list accepts every source. Lists left out
+are kept as they are.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			_, cfg, err := svc.Mesh(cmd.Context())
+			if err != nil {
+				return err
+			}
+			if !cmd.Flags().Changed("allowed-ips") {
+				allowed = cfg.Security.AllowedIPs
+			}
+			if !cmd.Flags().Changed("blocked-ips") {
+				blocked = cfg.Security.BlockedIPs
+			}
+
+			if err := svc.SetSecurityLists(cmd.Context(), allowed, blocked); err != nil {
+				return err
+			}
+			fmt.Println("✅ Saved security lists")
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&allowed, "allowed-ips", nil, "IPs and CIDRs allowed to send traffic (empty allows all)")
+	cmd.Flags().StringSliceVar(&blocked, "blocked-ips", nil, "IPs and CIDRs always denied")
+
+	return cmd
+}
 
+// newNetworkMeshPolicyTestCommand creates the mesh policy test command
+func newNetworkMeshPolicyTestCommand() *cobra.Command {
+	var (
+		from         string
+		to           string
+		container    string
+		sourceIP     string
+		port         int
+		protocol     string
+		policiesFile string
+		format       string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "test",
+		Short: "Check which policy applies to traffic",
+		Long: `Evaluate traffic from a node (or a container on it) to a port on another
+node, and explain every rule checked up to the one that decided.
+
+The source IP defaults to the node's overlay address. With --policies, the
+policies and IP lists are read from a JSON file (the same shape as
+"syntropy network mesh policy list --format json") instead of the stored
+ones, to try changes before saving them.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			traffic := network.Traffic{
+				SourceNode:      from,
+				SourceContainer: container,
+				SourceIP:        sourceIP,
+				DestinationNode: to,
+				Port:            port,
+				Protocol:        protocol,
+			}
+
+			var decision *network.Decision
+			if policiesFile != "" {
+				data, err := os.ReadFile(policiesFile)
+				if err != nil {
+					return fmt.Errorf("failed to read policies: %w", err)
+				}
+				var cfg models.ServiceMeshConfig
+				if err := json.Unmarshal(data, &cfg); err != nil {
+					return fmt.Errorf("failed to parse %s: %w", policiesFile, err)
+				}
+				decision, err = svc.EvaluatePolicyWith(cmd.Context(), cfg, traffic)
+				if err != nil {
+					return err
+				}
+			} else if decision, err = svc.EvaluatePolicy(cmd.Context(), traffic); err != nil {
+				return err
+			}
+
+			if format == "json" {
+				return printJSON(decision)
+			}
+
+			for i, c := range decision.Checks {
+				mark := "  "
+				if c.Matched {
+					mark = "=>"
+				}
+				fmt.Printf("%s %d. %-24s %s\n", mark, i+1, c.Rule, c.Reason)
+			}
+			fmt.Println()
+			switch decision.Action {
+			case network.PolicyDeny:
+				fmt.Printf("❌ Denied by %s\n", decision.Rule)
+			case network.PolicyRateLimit:
+				fmt.Printf("⏱️  Rate-limited by %s to %d req/s (burst %d)\n", decision.Rule,
+					decision.RateLimit.RequestsPerSecond, decision.RateLimit.Burst)
+			case network.PolicyReroute:
+				fmt.Printf("↪️  Rerouted via %s by %s\n", decision.Via, decision.Rule)
+			default:
+				fmt.Printf("✅ Allowed by %s\n", decision.Rule)
+			}
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&from, "from", "", "Source node (required)")
+	cmd.Flags().StringVar(&to, "to", "", "Destination node (required)")
+	cmd.Flags().StringVar(&container, "container", "", "Source container on the source node")
+	cmd.Flags().StringVar(&sourceIP, "source-ip", "", "Source IP (default the node's overlay address)")
+	cmd.Flags().IntVar(&port, "port", 0, "Destination port (required)")
+	cmd.Flags().StringVar(&protocol, "protocol", "tcp", "Protocol (tcp, udp)")
+	cmd.Flags().StringVar(&policiesFile, "policies", "", "Test the policies in this JSON file instead of the stored ones")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json)")
+
+	cmd.MarkFlagRequired("from")
+	cmd.MarkFlagRequired("to")
+	cmd.MarkFlagRequired("port")
+
 	return cmd
 }
+
+// newNetworkApplyCommand creates the network apply command
+func newNetworkApplyCommand() *cobra.Command {
+	var (
+		dryRun bool
+		format string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Apply the network configuration to the nodes",
+		Long: `Generate the WireGuard configuration of every node from the routes and the
+service mesh, show what changes on each node and install it over SSH.
+
+Private keys are never shown; use --dry-run to only see the changes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			svc, closeStore, err := openNetworkService()
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			plans, err := svc.Plan(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			changed := 0
+			for _, p := range plans {
+				if p.Action != network.PlanUnchanged {
+					changed++
+				}
+			}
+
+			if format == "json" {
+				if err := printJSON(plans); err != nil {
+					return err
+				}
+			} else {
+				printNetworkPlans(plans)
+			}
+
+			if dryRun || changed == 0 {
+				return nil
+			}
+
+			if err := svc.Apply(cmd.Context(), plans); err != nil {
+				return err
+			}
+			if format != "json" {
+				fmt.Printf("✅ Network configuration applied to %d node(s)\n", changed)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show the changes without applying them")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Output format (table, json)")
+
+	return cmd
+}
+
+// printNetworkPlans mostra as mudanças de cada nó como um diff
+func printNetworkPlans(plans []*network.NodePlan) {
+	changed := 0
+	for _, p := range plans {
+		if p.Action == network.PlanUnchanged {
+			continue
+		}
+		changed++
+		fmt.Printf("%s: %s", p.NodeID, p.Action)
+		if p.Address != "" {
+			fmt.Printf(" (%s)", p.Address)
+		}
+		fmt.Println()
+		for _, line := range p.Diff {
+			fmt.Printf("    %s\n", line)
+		}
+		fmt.Println()
+	}
+	if changed == 0 {
+		fmt.Println("No changes, the nodes are up to date")
+	}
+}
+
+// printTopology mostra nós, links e a análise da topologia em tabelas
+func printTopology(topo *network.Topology, analysis *network.Analysis) {
+	if len(topo.Nodes) == 0 {
+		fmt.Println("No nodes found")
+		return
+	}
+
+	links := make(map[string]int)
+	for _, l := range topo.Links {
+		links[l.A]++
+		links[l.B]++
+	}
+
+	fmt.Println("Nodes:")
+	fmt.Printf("%-20s %-15s %-10s %-8s %-6s %-8s\n", "NODE", "ADDRESS", "STATUS", "LATENCY", "LINKS", "TRANSIT")
+	fmt.Println(strings.Repeat("-", 72))
+	for _, n := range topo.Nodes {
+		latency := "-"
+		if n.Latency > 0 {
+			latency = fmt.Sprintf("%dms", n.Latency)
+		}
+		fmt.Printf("%-20s %-15s %-10s %-8s %-6d %-8d\n", truncate(n.ID, 20), n.Address, n.Status,
+			latency, links[n.ID], analysis.Transit[n.ID])
+	}
+
+	fmt.Println()
+	fmt.Println("Links:")
+	if len(topo.Links) == 0 {
+		fmt.Println("  none, create routes or enable the service mesh")
+	} else {
+		fmt.Printf("%-20s %-20s %-8s %-8s %-11s\n", "NODE", "NODE", "STATUS", "LATENCY", "RELIABILITY")
+		fmt.Println(strings.Repeat("-", 72))
+		for _, l := range topo.Links {
+			fmt.Printf("%-20s %-20s %-8s %-8s %-11s\n", truncate(l.A, 20), truncate(l.B, 20), l.Status,
+				fmt.Sprintf("%dms", l.Latency), fmt.Sprintf("%.1f%%", l.Reliability*100))
+		}
+	}
+
+	fmt.Println()
+	fmt.Printf("Partitions: %d\n", len(analysis.Partitions))
+	if len(analysis.Partitions) > 1 {
+		for i, p := range analysis.Partitions {
+			fmt.Printf("  %d: %s\n", i+1, strings.Join(p, ", "))
+		}
+	}
+	if len(analysis.CriticalNodes) == 0 && len(analysis.CriticalLinks) == 0 {
+		fmt.Println("Single points of failure: none")
+		return
+	}
+	fmt.Println("Single points of failure:")
+	for _, n := range analysis.CriticalNodes {
+		fmt.Printf("  node %s\n", n)
+	}
+	for _, l := range analysis.CriticalLinks {
+		fmt.Printf("  link %s <-> %s\n", l[0], l[1])
+	}
+}
+
+// formatPath descreve um caminho com latência e confiabilidade
+func formatPath(p *network.Path) string {
+	return fmt.Sprintf("%s (%dms, %.1f%%)", strings.Join(p.Nodes, " -> "), p.Latency, p.Reliability*100)
+}
+
+// formatPorts descreve as portas permitidas de uma rota
+func formatPorts(rc *models.NetworkRouteConfig) string {
+	if len(rc.Ports) == 0 {
+		return "all"
+	}
+	ports := make([]string, len(rc.Ports))
+	for i, p := range rc.Ports {
+		ports[i] = fmt.Sprint(p)
+	}
+	return rc.Protocol + "/" + strings.Join(ports, ",")
+}
+
+// wireGuardKeys entrega as chaves WireGuard dos nós guardadas em
+// ~/.syntropy/keys, gerando-as no primeiro uso
+type wireGuardKeys struct {
+	keys *infrastructure.KeyManager
+}
+
+func (k wireGuardKeys) WireGuardKey(nodeID string) (string, string, error) {
+	pair, err := k.keys.LoadKeyPair(infrastructure.WireGuardKey, nodeID)
+	if err == nil {
+		return strings.TrimSpace(pair.PrivateKey), strings.TrimSpace(pair.PublicKey), nil
+	}
+
+	pair, err = k.keys.GenerateWireGuardKeyPair(nodeID)
+	if err != nil {
+		return "", "", err
+	}
+	if err := k.keys.SaveKeyPair(pair, infrastructure.WireGuardKey, nodeID); err != nil {
+		return "", "", err
+	}
+	return pair.PrivateKey, pair.PublicKey, nil
+}
+
+// nodeDirectory lista os nós registrados em ~/.syntropy/nodes
+type nodeDirectory struct{}
+
+func (nodeDirectory) Nodes(ctx context.Context) ([]network.Node, error) {
+	nodes, err := loadAllNodes()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]network.Node, 0, len(nodes))
+	for _, n := range nodes {
+		result = append(result, network.Node{
+			ID:      n.Name,
+			Host:    n.Network.IPAddress,
+			Status:  n.Status,
+			Latency: n.Network.Latency,
+		})
+	}
+	return result, nil
+}
+
+// sshWireGuardApplier instala a configuração em /etc/wireguard e
+// (re)inicia o wg-quick do nó via ssh
+type sshWireGuardApplier struct{}
+
+func (sshWireGuardApplier) Apply(ctx context.Context, nodeID, iface, config string) error {
+	command := fmt.Sprintf(
+		"sudo -n sh -c 'umask 077; mkdir -p /etc/wireguard; cat > /etc/wireguard/%[1]s.conf' && "+
+			"sudo -n systemctl enable wg-quick@%[1]s >/dev/null 2>&1; sudo -n systemctl restart wg-quick@%[1]s",
+		iface)
+	return runOnNode(ctx, nodeID, command, config)
+}
+
+func (sshWireGuardApplier) Remove(ctx context.Context, nodeID, iface string) error {
+	command := fmt.Sprintf(
+		"sudo -n systemctl disable --now wg-quick@%[1]s >/dev/null 2>&1; sudo -n rm -f /etc/wireguard/%[1]s.conf",
+		iface)
+	return runOnNode(ctx, nodeID, command, "")
+}
+
+// runOnNode executa um comando no nó via ssh, enviando stdin ao comando
+func runOnNode(ctx context.Context, nodeID, command, stdin string) error {
+	args, err := nodeSSHArgs(nodeID, true)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "ssh", append(args, command)...)
+	cmd.Stdin = strings.NewReader(stdin)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// openNetworkService abre o serviço de rede sobre o banco local, com as
+// chaves WireGuard do KeyManager e aplicação nos nós via ssh
+func openNetworkService() (*network.Service, func(), error) {
+	store, err := openStore()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keys := wireGuardKeys{keys: infrastructure.NewKeyManager(filepath.Join(getSyntropyDir(), "keys"))}
+	svc := network.NewService(storage.NewNetworkRepository(store), keys, nodeDirectory{}, networkLogger{})
+	svc.SetApplier(sshWireGuardApplier{})
+	return svc, func() { store.Close() }, nil
+}
@@ -54,6 +54,7 @@ Exemplos:
 	rootCmd.AddCommand(NewManagerCommand())
 	rootCmd.AddCommand(NewTemplatesCommand())
 	rootCmd.AddCommand(usb.NewUSBCommand())
+	rootCmd.AddCommand(NewCACommand())
 	rootCmd.AddCommand(NewNodeCommand())
 	rootCmd.AddCommand(NewContainerCommand())
 	rootCmd.AddCommand(NewNetworkCommand())
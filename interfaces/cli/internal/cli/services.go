@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/services/container"
+	"syntropy-cc/cooperative-grid/core/services/credits"
+	"syntropy-cc/cooperative-grid/core/services/governance"
+	"syntropy-cc/cooperative-grid/core/services/network"
+	"syntropy-cc/cooperative-grid/core/services/reputation"
+	"syntropy-cc/cooperative-grid/core/storage"
+)
+
+// openStore abre o banco de dados local em ~/.syntropy
+func openStore() (*storage.Store, error) {
+	store, err := storage.Open(filepath.Join(getSyntropyDir(), storage.DefaultFileName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open local database: %w", err)
+	}
+	return store, nil
+}
+
+// baseLogger escreve logs dos serviços do core em stderr apenas quando
+// SYNTROPY_DEBUG está definido, para não poluir a saída dos comandos
+type baseLogger struct {
+	fields map[string]interface{}
+}
+
+func (l baseLogger) Info(args ...interface{})  { l.print("INFO", args...) }
+func (l baseLogger) Error(args ...interface{}) { l.print("ERROR", args...) }
+func (l baseLogger) Debug(args ...interface{}) { l.print("DEBUG", args...) }
+
+func (l baseLogger) with(fields map[string]interface{}) baseLogger {
+	merged := make(map[string]interface{}, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return baseLogger{fields: merged}
+}
+
+func (l baseLogger) print(level string, args ...interface{}) {
+	if os.Getenv("SYNTROPY_DEBUG") == "" {
+		return
+	}
+
+	keys := make([]string, 0, len(l.fields))
+	for k := range l.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprint(args...))
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=%v", k, l.fields[k])
+	}
+	log.New(os.Stderr, "", log.LstdFlags).Printf("[%s] %s", level, b.String())
+}
+
+// Adaptadores de logger para cada serviço do core
+
+type creditsLogger struct{ baseLogger }
+
+func (l creditsLogger) WithFields(fields map[string]interface{}) credits.Logger {
+	return creditsLogger{l.with(fields)}
+}
+
+type governanceLogger struct{ baseLogger }
+
+func (l governanceLogger) WithFields(fields map[string]interface{}) governance.Logger {
+	return governanceLogger{l.with(fields)}
+}
+
+type reputationLogger struct{ baseLogger }
+
+func (l reputationLogger) WithFields(fields map[string]interface{}) reputation.Logger {
+	return reputationLogger{l.with(fields)}
+}
+
+type containerLogger struct{ baseLogger }
+
+func (l containerLogger) WithFields(fields map[string]interface{}) container.Logger {
+	return containerLogger{l.with(fields)}
+}
+
+type networkLogger struct{ baseLogger }
+
+func (l networkLogger) WithFields(fields map[string]interface{}) network.Logger {
+	return networkLogger{l.with(fields)}
+}
+
+// printJSON imprime qualquer valor como JSON indentado
+func printJSON(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// formatTime formata timestamps para as tabelas, tratando o valor zero
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+// truncate corta textos longos para caber nas colunas das tabelas
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max-3] + "..."
+}
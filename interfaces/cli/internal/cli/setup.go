@@ -10,6 +10,9 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
+
+	"syntropy-cc/cooperative-grid/core/backup"
+	"syntropy-cc/cooperative-grid/core/health"
 )
 
 // ManagerConfig representa a configuração do gerenciador
@@ -23,6 +26,8 @@ type ManagerConfig struct {
 	Preferences   PreferencesConfig   `json:"preferences"`
 	Notifications NotificationsConfig `json:"notifications"`
 	Backup        BackupConfig        `json:"backup"`
+	// Health é a política de pontuação do "syntropy manager health"
+	Health health.Policy `json:"health"`
 }
 
 type SystemInfo struct {
@@ -69,8 +74,14 @@ type NotificationsConfig struct {
 type BackupConfig struct {
 	AutoBackup          bool `json:"auto_backup"`
 	BackupFrequencyDays int  `json:"backup_frequency_days"`
-	MaxBackups          int  `json:"max_backups"`
-	CompressBackups     bool `json:"compress_backups"`
+	// MaxBackups vale como keep_last quando não há política de retenção
+	MaxBackups      int  `json:"max_backups"`
+	CompressBackups bool `json:"compress_backups"`
+	// Recipients são as chaves públicas age que cifram os backups; sem
+	// elas é pedida uma frase secreta
+	Recipients []string `json:"recipients,omitempty"`
+	// Retention decide quais snapshots o "manager backup" mantém
+	Retention backup.Retention `json:"retention"`
 }
 
 // NewSetupCommand cria o comando de setup
@@ -281,8 +292,8 @@ func createManagerConfig() error {
 			Enabled:           true,
 			ScanNetworks:      localNetworks,
 			DefaultSSHPort:    22,
-			ConnectionTimeout: 10,
-			ParallelScans:     5,
+			ConnectionTimeout: 2,
+			ParallelScans:     64,
 			CacheResults:      true,
 			CacheTTLMinutes:   30,
 		},
@@ -307,12 +318,8 @@ func createManagerConfig() error {
 			WebhookURL:   "",
 			SlackChannel: "",
 		},
-		Backup: BackupConfig{
-			AutoBackup:          true,
-			BackupFrequencyDays: 7,
-			MaxBackups:          30,
-			CompressBackups:     true,
-		},
+		Backup: defaultBackupConfig(),
+		Health: health.DefaultPolicy(),
 	}
 
 	// Salvar configuração
@@ -363,33 +370,10 @@ done`
 	// Script de backup
 	backupScript := `#!/bin/bash
 
-# Backup all node configurations
-BACKUP_DIR="$HOME/.syntropy/backups/$(date +%Y%m%d_%H%M%S)"
-mkdir -p "$BACKUP_DIR"
-
-echo "Creating backup in: $BACKUP_DIR"
-
-# Copy all node metadata
-cp -r "$HOME/.syntropy/nodes" "$BACKUP_DIR/"
-cp -r "$HOME/.syntropy/keys" "$BACKUP_DIR/"
-cp -r "$HOME/.syntropy/config" "$BACKUP_DIR/"
-
-# Create backup info
-cat > "$BACKUP_DIR/backup_info.json" << EOF
-{
-  "created": "$(date -u +%Y-%m-%dT%H:%M:%SZ)",
-  "system": "$(hostname)",
-  "user": "$USER",
-  "nodes_count": $(ls -1 "$HOME/.syntropy/nodes"/*.json 2>/dev/null | wc -l),
-  "backup_type": "full"
-}
-EOF
-
-# Compress backup
-tar -czf "$BACKUP_DIR.tar.gz" -C "$(dirname "$BACKUP_DIR")" "$(basename "$BACKUP_DIR")"
-rm -rf "$BACKUP_DIR"
-
-echo "Backup created: $BACKUP_DIR.tar.gz"`
+# Backup all node configurations as an encrypted, incremental snapshot.
+# For unattended runs set SYNTROPY_BACKUP_PASSPHRASE or the "recipients"
+# of the backup section in ~/.syntropy/config/manager.json.
+exec syntropy manager backup "$@"`
 
 	backupPath := filepath.Join(scriptsDir, "backup-all-nodes.sh")
 	if err := os.WriteFile(backupPath, []byte(backupScript), 0755); err != nil {
@@ -1,12 +1,17 @@
 package cli
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
 	"github.com/spf13/cobra"
+
+	"syntropy-cc/cooperative-grid/core/services/container"
+	"syntropy-cc/cooperative-grid/core/types/models"
 )
 
 // NewTemplatesCommand cria o comando de templates
@@ -84,9 +89,10 @@ This displays the template configuration, requirements, and deployment options.`
 // newTemplatesDeployCommand cria o comando de deploy
 func newTemplatesDeployCommand() *cobra.Command {
 	var (
-		nodeName string
-		values   []string
-		dryRun   bool
+		nodeName    string
+		values      []string
+		dryRun      bool
+		runtimeName string
 	)
 
 	cmd := &cobra.Command{
@@ -102,13 +108,15 @@ This will:
 		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			templateName := args[0]
-			return deployTemplate(templateName, nodeName, values, dryRun)
+			return deployTemplate(templateName, nodeName, runtimeName, values, dryRun)
 		},
 	}
 
 	cmd.Flags().StringVarP(&nodeName, "node", "n", "", "Target node name (required)")
 	cmd.Flags().StringSliceVarP(&values, "set", "s", []string{}, "Set custom values (key=value)")
 	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "Show what would be deployed without actually deploying")
+	cmd.Flags().StringVar(&runtimeName, "runtime", container.RuntimeDocker,
+		"Container runtime on the node (docker, containerd)")
 
 	cmd.MarkFlagRequired("node")
 
@@ -181,7 +189,7 @@ func showTemplate(templateName, format string) error {
 	}
 }
 
-func deployTemplate(templateName, nodeName string, values []string, dryRun bool) error {
+func deployTemplate(templateName, nodeName, runtimeName string, values []string, dryRun bool) error {
 	fmt.Printf("🚀 Deploying template '%s' to node '%s'\n", templateName, nodeName)
 
 	// Carregar template
@@ -206,6 +214,7 @@ func deployTemplate(templateName, nodeName string, values []string, dryRun bool)
 		fmt.Printf("Template: %s\n", template.Name)
 		fmt.Printf("Node: %s (%s)\n", node.Name, node.Network.IPAddress)
 		fmt.Printf("Category: %s\n", template.Category)
+		fmt.Printf("Runtime: %s\n", runtimeName)
 		fmt.Println("Resources:")
 		fmt.Printf("  CPU: %s\n", template.Resources.CPU)
 		fmt.Printf("  Memory: %s\n", template.Resources.Memory)
@@ -217,7 +226,7 @@ func deployTemplate(templateName, nodeName string, values []string, dryRun bool)
 	fmt.Println("📦 Deploying application...")
 	
 	// Conectar ao nó e executar deploy
-	if err := executeDeployment(node, template); err != nil {
+	if err := executeDeployment(node, template, runtimeName); err != nil {
 		return fmt.Errorf("deployment failed: %w", err)
 	}
 
@@ -411,10 +420,84 @@ func applyTemplateValues(template Template, values []string) Template {
 	return template
 }
 
-func executeDeployment(node NodeInfo, template Template) error {
-	// Implementação simplificada - em produção executar deploy real
+// nodeVolumesDir é onde os volumes dos templates ficam no nó. O caminho é
+// do nó (Linux), não do PC de gerenciamento, por isso usa path e não filepath.
+const nodeVolumesDir = "/var/lib/syntropy/volumes"
+
+// validateVolumeSegment recusa nomes que, como parte do caminho de um
+// volume, sairiam do diretório do template no nó
+func validateVolumeSegment(kind, name string) error {
+	if name == "" || name == "." || strings.Contains(name, "/") || strings.Contains(name, "..") {
+		return fmt.Errorf("invalid %s name %q: must not be empty or contain '/' or '..'", kind, name)
+	}
+	return nil
+}
+
+// executeDeployment implanta cada serviço do template como um container
+// no nó usando o runtime escolhido com --runtime. Volumes nomeados ficam em
+// /var/lib/syntropy/volumes/<template>.
+func executeDeployment(node NodeInfo, template Template, runtimeName string) error {
+	// Os nomes viram caminhos no nó; valida todos antes de implantar algo
+	if err := validateVolumeSegment("template", template.Name); err != nil {
+		return err
+	}
+	for _, service := range template.Services {
+		for _, v := range service.Volumes {
+			if err := validateVolumeSegment("volume", v.Name); err != nil {
+				return fmt.Errorf("service %s: %w", service.Name, err)
+			}
+		}
+	}
+
+	svc, closeStore, err := newContainerService(runtimeName)
+	if err != nil {
+		return err
+	}
+	defer closeStore()
+
 	fmt.Printf("Connecting to node %s (%s)...\n", node.Name, node.Network.IPAddress)
-	fmt.Printf("Deploying template %s...\n", template.Name)
+	for _, service := range template.Services {
+		cfg := models.ContainerConfig{
+			EnvVars: make(map[string]string),
+			Resources: models.ResourceLimits{
+				CPU:    service.Resources.CPU,
+				Memory: service.Resources.Memory,
+			},
+		}
+		for k, v := range template.Environment {
+			cfg.EnvVars[k] = v
+		}
+		for k, v := range service.Environment {
+			cfg.EnvVars[k] = v
+		}
+		for _, p := range service.Ports {
+			host := p.Host
+			if host == 0 {
+				host = p.Container
+			}
+			protocol := strings.ToLower(p.Protocol)
+			if protocol == "" {
+				protocol = "tcp"
+			}
+			cfg.Ports = append(cfg.Ports, models.PortMapping{HostPort: host, ContainerPort: p.Container, Protocol: protocol})
+		}
+		for _, v := range service.Volumes {
+			cfg.Volumes = append(cfg.Volumes, models.VolumeMapping{
+				HostPath:      path.Join(nodeVolumesDir, template.Name, v.Name),
+				ContainerPath: v.MountPath,
+			})
+		}
+
+		fmt.Printf("Deploying service %s (%s)...\n", service.Name, service.Image)
+		if _, err := svc.Deploy(context.Background(), &container.DeployRequest{
+			Name:   template.Name + "-" + service.Name,
+			Image:  service.Image,
+			Nodes:  []string{node.Name},
+			Config: cfg,
+		}); err != nil {
+			return err
+		}
+	}
 	fmt.Println("Deployment completed successfully")
 	return nil
 }
@@ -1,18 +1,12 @@
 package usb
 
 import (
-	"crypto/rand"
-	"crypto/rsa"
-	"crypto/x509"
-	"crypto/x509/pkix"
-	"encoding/pem"
 	"fmt"
-	"math/big"
 	"net"
 	"os"
 	"path/filepath"
-	"time"
 
+	"syntropy-cc/cooperative-grid/core/ca"
 	"syntropy-cc/cooperative-grid/infrastructure"
 )
 
@@ -67,125 +61,84 @@ func loadExistingSSHKeyPair(nodeName string) (string, string, error) {
 	return keyPair.PrivateKey, keyPair.PublicKey, nil
 }
 
-// generateCertificates gera certificados TLS para o nó
-func generateCertificates(nodeName string, ownerKey string) (*Certificates, error) {
-	// Gerar chave CA
-	caKey, err := rsa.GenerateKey(rand.Reader, 4096)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao gerar chave CA: %w", err)
-	}
-
-	// Criar certificado CA
-	caTemplate := x509.Certificate{
-		SerialNumber: big.NewInt(1),
-		Subject: pkix.Name{
-			Organization:  []string{"Syntropy Cooperative Grid"},
-			Country:       []string{"BR"},
-			Province:      []string{""},
-			Locality:      []string{"São Paulo"},
-			StreetAddress: []string{""},
-			PostalCode:    []string{""},
-		},
-		NotBefore:             time.Now(),
-		NotAfter:              time.Now().AddDate(10, 0, 0), // 10 anos
-		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
-		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
-		BasicConstraintsValid: true,
-		IsCA:                  true,
+// generateCertificates emite o certificado TLS do nó pela CA persistente
+// da grade. Todos os nós compartilham a mesma raiz; a chave da CA nunca sai
+// do PC de gerenciamento. Sem ipSuffix, o sufixo é escolhido com a CA
+// travada, para que dois nós criados ao mesmo tempo não fiquem com o mesmo.
+func generateCertificates(authority *ca.Authority, nodeName, ipSuffix string) (*Certificates, error) {
+	req := ca.IssueRequest{
+		CommonName: nodeName,
+		DNSNames:   []string{nodeName, nodeName + ".local"},
+	}
+	if ipSuffix == "" {
+		req.Allocate = func(current []*ca.Record) ([]net.IP, error) {
+			assigned, err := allocateIPSuffixes([]string{nodeName}, map[int]string{}, recordSuffixes(current))
+			if err != nil {
+				return nil, err
+			}
+			ipSuffix = assigned[nodeName]
+			return []net.IP{net.ParseIP(meshIPPrefix + ipSuffix)}, nil
+		}
+	} else if ip := net.ParseIP(meshIPPrefix + ipSuffix); ip != nil {
+		req.IPAddresses = []net.IP{ip}
 	}
 
-	caCert, err := x509.CreateCertificate(rand.Reader, &caTemplate, &caTemplate, &caKey.PublicKey, caKey)
+	issued, err := authority.Issue(req)
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criar certificado CA: %w", err)
+		return nil, fmt.Errorf("erro ao emitir certificado do nó: %w", err)
 	}
 
-	// Gerar chave do nó
-	nodeKey, err := rsa.GenerateKey(rand.Reader, 2048)
-	if err != nil {
-		return nil, fmt.Errorf("erro ao gerar chave do nó: %w", err)
-	}
+	return &Certificates{
+		CACert:   issued.RootPEM,
+		NodeKey:  issued.KeyPEM,
+		NodeCert: issued.CertPEM,
+		Serial:   issued.Record.Serial,
+		IPSuffix: ipSuffix,
+	}, nil
+}
 
-	// Criar certificado do nó
-	nodeTemplate := x509.Certificate{
-		SerialNumber: big.NewInt(2),
-		Subject: pkix.Name{
-			Organization:  []string{"Syntropy Cooperative Grid"},
-			Country:       []string{"BR"},
-			Province:      []string{""},
-			Locality:      []string{"São Paulo"},
-			StreetAddress: []string{""},
-			PostalCode:    []string{""},
-			CommonName:    nodeName,
-		},
-		NotBefore:   time.Now(),
-		NotAfter:    time.Now().AddDate(1, 0, 0), // 1 ano
-		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
-		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
-		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
-		DNSNames:    []string{nodeName, "localhost"},
-	}
+// gridCADir retorna o diretório da CA persistente da grade
+func gridCADir() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".syntropy", "ca")
+}
 
-	nodeCert, err := x509.CreateCertificate(rand.Reader, &nodeTemplate, &caTemplate, &nodeKey.PublicKey, caKey)
+// openGridCA abre a CA da grade, criando-a se ainda não existir. Processos
+// concorrentes que a criam ao mesmo tempo ficam todos com a mesma raiz.
+func openGridCA() (*ca.Authority, error) {
+	dir := gridCADir()
+	authority, created, err := ca.OpenOrInit(dir, ca.Options{})
 	if err != nil {
-		return nil, fmt.Errorf("erro ao criar certificado do nó: %w", err)
+		return nil, fmt.Errorf("erro ao abrir CA da grade: %w", err)
 	}
-
-	// Codificar certificados em PEM
-	caKeyPEM := &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(caKey),
+	if created {
+		fmt.Printf("🏛️  CA da grade inicializada em %s\n", dir)
 	}
-
-	caCertPEM := &pem.Block{
-		Type:  "CERTIFICATE",
-		Bytes: caCert,
-	}
-
-	nodeKeyPEM := &pem.Block{
-		Type:  "RSA PRIVATE KEY",
-		Bytes: x509.MarshalPKCS1PrivateKey(nodeKey),
-	}
-
-	nodeCertPEM := &pem.Block{
-		Type:  "CERTIFICATE",
-		Bytes: nodeCert,
-	}
-
-	return &Certificates{
-		CAKey:    pem.EncodeToMemory(caKeyPEM),
-		CACert:   pem.EncodeToMemory(caCertPEM),
-		NodeKey:  pem.EncodeToMemory(nodeKeyPEM),
-		NodeCert: pem.EncodeToMemory(nodeCertPEM),
-	}, nil
+	return authority, nil
 }
 
 // saveCertificates salva os certificados no diretório de trabalho
-func saveCertificates(certs *Certificates, workDir string) (string, string, string, string, error) {
+func saveCertificates(certs *Certificates, workDir string) (string, string, string, error) {
 	certDir := filepath.Join(workDir, "certs")
 	if err := os.MkdirAll(certDir, 0755); err != nil {
-		return "", "", "", "", fmt.Errorf("erro ao criar diretório de certificados: %w", err)
+		return "", "", "", fmt.Errorf("erro ao criar diretório de certificados: %w", err)
 	}
 
-	caKeyPath := filepath.Join(certDir, "ca.key")
 	caCertPath := filepath.Join(certDir, "ca.crt")
 	nodeKeyPath := filepath.Join(certDir, "node.key")
 	nodeCertPath := filepath.Join(certDir, "node.crt")
 
-	if err := os.WriteFile(caKeyPath, certs.CAKey, 0600); err != nil {
-		return "", "", "", "", fmt.Errorf("erro ao salvar chave CA: %w", err)
-	}
-
 	if err := os.WriteFile(caCertPath, certs.CACert, 0644); err != nil {
-		return "", "", "", "", fmt.Errorf("erro ao salvar certificado CA: %w", err)
+		return "", "", "", fmt.Errorf("erro ao salvar certificado CA: %w", err)
 	}
 
 	if err := os.WriteFile(nodeKeyPath, certs.NodeKey, 0600); err != nil {
-		return "", "", "", "", fmt.Errorf("erro ao salvar chave do nó: %w", err)
+		return "", "", "", fmt.Errorf("erro ao salvar chave do nó: %w", err)
 	}
 
 	if err := os.WriteFile(nodeCertPath, certs.NodeCert, 0644); err != nil {
-		return "", "", "", "", fmt.Errorf("erro ao salvar certificado do nó: %w", err)
+		return "", "", "", fmt.Errorf("erro ao salvar certificado do nó: %w", err)
 	}
 
-	return caKeyPath, caCertPath, nodeKeyPath, nodeCertPath, nil
+	return caCertPath, nodeKeyPath, nodeCertPath, nil
 }
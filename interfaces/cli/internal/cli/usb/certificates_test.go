@@ -0,0 +1,72 @@
+package usb
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+)
+
+func TestGenerateCertificatesIPSuffix(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "ca")
+	authority, err := ca.Init(dir, ca.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := authority.Issue(ca.IssueRequest{CommonName: "old", IPAddresses: []net.IP{net.ParseIP(meshIPPrefix + "3")}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Criações simultâneas, cada uma com a CA aberta à parte, recebem
+	// sufixos diferentes e o certificado traz o IP atribuído
+	const nodes = 6
+	suffixes := make([]string, nodes)
+	var wg sync.WaitGroup
+	for i := 0; i < nodes; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			a, err := ca.Open(dir)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			certs, err := generateCertificates(a, fmt.Sprintf("node-%d", i), "")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			rec, err := a.Get(certs.Serial)
+			if err != nil || len(rec.IPAddresses) != 1 || rec.IPAddresses[0] != meshIPPrefix+certs.IPSuffix {
+				t.Errorf("node-%d: sufixo %q, certificado %+v", i, certs.IPSuffix, rec)
+			}
+			suffixes[i] = certs.IPSuffix
+		}(i)
+	}
+	wg.Wait()
+
+	seen := map[string]bool{"3": true}
+	for i, suffix := range suffixes {
+		if seen[suffix] {
+			t.Errorf("node-%d: sufixo %q repetido", i, suffix)
+		}
+		seen[suffix] = true
+	}
+
+	// Um nó reemitido mantém o sufixo e um sufixo explícito é respeitado
+	for name, want := range map[string]string{"old": "", "fixed": "200"} {
+		certs, err := generateCertificates(authority, name, want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want == "" {
+			want = "3"
+		}
+		if certs.IPSuffix != want {
+			t.Errorf("%s: sufixo %q, esperado %q", name, certs.IPSuffix, want)
+		}
+	}
+}
@@ -4,13 +4,25 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
-	"text/template"
 	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+	"syntropy-cc/cooperative-grid/infrastructure"
 )
 
 // generateCloudInitConfig gera a configuração do cloud-init
 func generateCloudInitConfig(config *Config, workDir string, certs *Certificates) (*CloudInitConfig, error) {
+	// Carregar o perfil (idioma, fuso, pacotes, firewall e papel)
+	templateDir := cloudInitTemplateDir(config.TemplateDir)
+	profile, err := infrastructure.NewTemplateManager(templateDir).LoadProfile(config.Profile)
+	if err != nil {
+		return nil, err
+	}
+
 	// Gerar ID único da instância
 	instanceID := fmt.Sprintf("%d", time.Now().UnixNano())
 
@@ -20,513 +32,241 @@ func generateCloudInitConfig(config *Config, workDir string, certs *Certificates
 	// Configurar gateway padrão
 	gateway := "192.168.1.1" // Padrão, será detectado automaticamente
 
-	// Gerar sufixo IP único
-	nodeIPSuffix := fmt.Sprintf("%d", time.Now().Unix()%254+2)
-
 	// Caminhos dos certificados
 	certDir := filepath.Join(workDir, "certs")
 	nodeCertPath := filepath.Join(certDir, "node.crt")
 	nodeKeyPath := filepath.Join(certDir, "node.key")
 	caCertPath := filepath.Join(certDir, "ca.crt")
 
-	return &CloudInitConfig{
-		NodeName:         config.NodeName,
-		NodeDescription:  config.NodeDescription,
-		Coordinates:      config.Coordinates,
-		OwnerKey:         config.OwnerKeyFile,
-		DiscoveryServer:  config.DiscoveryServer,
-		SSHPublicKey:     config.SSHPublicKey,
-		CreatedBy:        config.CreatedBy,
-		CreatedAt:        time.Now().Format(time.RFC3339),
-		InstanceID:       instanceID,
-		Interface:        networkInterface,
-		Gateway:          gateway,
-		NodeIPSuffix:     nodeIPSuffix,
-		PrimaryInterface: networkInterface,
-		MeshGateway:      "172.20.0.1",
-		MgmtGateway:      "192.168.100.1",
-		HTTPProxy:        "",
-		HTTPSProxy:       "",
-		NodeType:         "worker", // Padrão
-		HardwareType:     "generic",
-		CPUCores:         4,   // Será detectado automaticamente
-		MemoryGB:         8,   // Será detectado automaticamente
-		StorageGB:        100, // Será detectado automaticamente
-		InitialRole:      "worker",
-		CanBeLeader:      true,
-		CanBeWorker:      true,
-		NodeCertPath:     nodeCertPath,
-		NodeKeyPath:      nodeKeyPath,
-		CACertPath:       caCertPath,
-		// Certificados PEM para write_files
-		CACertPEM:   string(certs.CACert),
-		NodeCertPEM: string(certs.NodeCert),
-		NodeKeyPEM:  string(certs.NodeKey),
-	}, nil
+	cfg := &CloudInitConfig{
+		CloudInitData: infrastructure.CloudInitData{
+			NodeName:         config.NodeName,
+			NodeDescription:  config.NodeDescription,
+			Coordinates:      config.Coordinates,
+			OwnerKey:         config.OwnerKeyFile,
+			DiscoveryServer:  config.DiscoveryServer,
+			SSHPublicKey:     config.SSHPublicKey,
+			CreatedBy:        config.CreatedBy,
+			CreatedAt:        time.Now().Format(time.RFC3339),
+			InstanceID:       instanceID,
+			Interface:        networkInterface,
+			Gateway:          gateway,
+			NodeIPSuffix:     config.Network.IPSuffix,
+			PrimaryInterface: networkInterface,
+			MeshGateway:      "172.20.0.1",
+			MgmtGateway:      "192.168.100.1",
+			HTTPProxy:        "",
+			HTTPSProxy:       "",
+			NodeType:         "worker", // Padrão
+			HardwareType:     "generic",
+			CPUCores:         4,   // Será detectado automaticamente
+			MemoryGB:         8,   // Será detectado automaticamente
+			StorageGB:        100, // Será detectado automaticamente
+			InitialRole:      "worker",
+			CanBeLeader:      true,
+			CanBeWorker:      true,
+			NodeCertPath:     nodeCertPath,
+			NodeKeyPath:      nodeKeyPath,
+			CACertPath:       caCertPath,
+			// Certificados PEM para write_files
+			CACertPEM:   string(certs.CACert),
+			NodeCertPEM: string(certs.NodeCert),
+			NodeKeyPEM:  string(certs.NodeKey),
+			Profile:     *profile,
+		},
+		TemplateDir: templateDir,
+	}
+	if profile.Role != "" {
+		cfg.NodeType = profile.Role
+		cfg.InitialRole = profile.Role
+	}
+	if config.Offline {
+		cfg.OfflineLabel = offlineLabel
+	}
+	cfg.OwnerFingerprint = ownerFingerprint(config.OwnerKeyFile)
+	applyNodeOverrides(cfg, config)
+	return cfg, nil
 }
 
-// renderTemplate renderiza um template com os dados fornecidos
-func renderTemplate(templateStr string, data interface{}) (string, error) {
-	tmpl, err := template.New("config").Option("missingkey=error").Parse(templateStr)
+// ownerFingerprint retorna a impressão SHA256 da chave do dono informada em
+// --owner-key. O arquivo pode ser a chave pública ou a privada, cuja pública
+// é lida do .pub ao lado ou derivada dela. Sem chave do dono, fica vazio.
+func ownerFingerprint(ownerKeyFile string) string {
+	if ownerKeyFile == "" {
+		return ""
+	}
+	for _, path := range []string{ownerKeyFile, ownerKeyFile + ".pub"} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if fingerprint := sshFingerprint(string(data)); fingerprint != "" {
+			return fingerprint
+		}
+	}
+	data, err := os.ReadFile(ownerKeyFile)
 	if err != nil {
-		return "", fmt.Errorf("erro ao fazer parse do template: %w", err)
+		return ""
 	}
-
-	var buf strings.Builder
-	if err := tmpl.Execute(&buf, data); err != nil {
-		return "", fmt.Errorf("erro ao executar template: %w", err)
+	signer, err := ssh.ParsePrivateKey(data)
+	if err != nil {
+		return ""
 	}
-
-	return buf.String(), nil
+	return ssh.FingerprintSHA256(signer.PublicKey())
 }
 
-// createCloudInitFiles cria os arquivos de configuração do cloud-init
-func createCloudInitFiles(config *CloudInitConfig, workDir string, certPaths map[string]string) error {
-	cloudInitDir := filepath.Join(workDir, "cloud-init")
-	if err := os.MkdirAll(cloudInitDir, 0755); err != nil {
-		return fmt.Errorf("erro ao criar diretório cloud-init: %w", err)
+// sshFingerprint retorna a impressão SHA256 de uma chave pública no formato
+// authorized_keys, ou vazio se a chave não puder ser lida
+func sshFingerprint(authorizedKey string) string {
+	key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(strings.TrimSpace(authorizedKey)))
+	if err != nil {
+		return ""
 	}
+	return ssh.FingerprintSHA256(key)
+}
+
+// meshIPPrefix é a rede /24 da mesh onde cada nó recebe seu sufixo
+const meshIPPrefix = "172.20.0."
 
-	// Template do user-data
-	userDataTemplate := `#cloud-config
-# Ubuntu Server 24.04 LTS - Syntropy Cooperative Grid Node
-# Este arquivo configura automaticamente um nó da rede Syntropy
-# Gerado automaticamente pelo PC de trabalho (Quartel General)
-
-# Configurações básicas do sistema
-locale: pt_BR.UTF-8
-timezone: America/Sao_Paulo
-hostname: {{.NodeName}}
-
-# Usuário padrão (será substituído pela configuração Syntropy)
-users:
-  - name: syntropy
-    groups: [adm, sudo, docker]
-    shell: /bin/bash
-    sudo: ALL=(ALL) NOPASSWD:ALL
-    lock_passwd: true
-
-# Configuração de rede
-network:
-  version: 2
-  ethernets:
-    {{.Interface}}:
-      dhcp4: true
-      dhcp6: false
-
-# Configuração SSH
-ssh_pwauth: false
-disable_root: true
-ssh_authorized_keys:
-  - {{.SSHPublicKey}}
-
-# Escrever arquivos de certificados
-write_files:
-  - path: /opt/syntropy/certs/ca.crt
-    owner: root:root
-    permissions: "0644"
-    content: |
-      {{.CACertPEM}}
-  - path: /opt/syntropy/certs/node.crt
-    owner: root:root
-    permissions: "0644"
-    content: |
-      {{.NodeCertPEM}}
-  - path: /opt/syntropy/certs/node.key
-    owner: root:root
-    permissions: "0600"
-    content: |
-      {{.NodeKeyPEM}}
-
-# Pacotes a serem instalados
-packages:
-  - curl
-  - wget
-  - git
-  - htop
-  - vim
-  - net-tools
-  - dnsutils
-  - fail2ban
-  - ufw
-  - docker.io
-  - docker-compose-plugin
-  - containerd
-  - kubectl
-  - wireguard
-  - jq
-  - openssl
-  - ca-certificates
-  - gnupg
-  - lsb-release
-  - prometheus-node-exporter
-  - ntp
-  - rsync
-  - unzip
-  - tree
-  - tmux
-
-# Configuração do Docker
-runcmd:
-  # Configurar Docker
-  - systemctl enable docker
-  - systemctl start docker
-  - usermod -aG docker syntropy
-  
-  # Configurar firewall
-  - ufw --force enable
-  - ufw default deny incoming
-  - ufw default allow outgoing
-  - ufw allow ssh
-  - ufw allow 6443/tcp
-  - ufw allow 2379:2380/tcp
-  - ufw allow 10250/tcp
-  - ufw allow 10251/tcp
-  - ufw allow 10252/tcp
-  - ufw allow 30000:32767/tcp
-  - ufw allow 51820/udp
-  - ufw allow 8080/tcp
-  - ufw allow 9090/tcp
-  - ufw allow 9100/tcp
-  
-  # Configurar fail2ban
-  - systemctl enable fail2ban
-  - systemctl start fail2ban
-  
-  # Criar diretórios Syntropy
-  - mkdir -p /opt/syntropy/{bin,config,logs,certs,data,scripts,backups,audit}
-  - chown -R syntropy:syntropy /opt/syntropy
-  
-  # Download e instalação do Syntropy Agent
-  - curl -L https://github.com/syntropy-cooperative-grid/agent/releases/latest/download/syntropy-agent-linux-amd64 -o /opt/syntropy/bin/syntropy-agent
-  - chmod +x /opt/syntropy/bin/syntropy-agent
-  
-  # Configurar certificados (já criados via write_files)
-  - chmod 600 /opt/syntropy/certs/*
-  - chown syntropy:syntropy /opt/syntropy/certs/*
-  
-  # Configurar Syntropy Agent
-  - cat > /opt/syntropy/config/agent.yaml << EOF
-node:
-  name: "{{.NodeName}}"
-  type: "{{.NodeType}}"
-  description: "{{.NodeDescription}}"
-  coordinates: "{{.Coordinates}}"
-  owner_key: "{{.OwnerKey}}"
-
-network:
-  discovery_endpoints:
-    - "https://{{.DiscoveryServer}}:8443"
-  mesh_port: 51820
-  api_port: 8080
-
-security:
-  tls:
-    enabled: true
-    cert_file: "/opt/syntropy/certs/node.crt"
-    key_file: "/opt/syntropy/certs/node.key"
-    ca_file: "/opt/syntropy/certs/ca.crt"
-  
-  firewall:
-    enabled: true
-    default_policy: "deny"
-    allow_ssh: true
-    allow_management: true
-
-logging:
-  level: "info"
-  file: "/opt/syntropy/logs/agent.log"
-  max_size: "100MB"
-  max_files: 5
-
-metrics:
-  enabled: true
-  port: 9090
-  path: "/metrics"
-EOF
-  
-  # Configurar systemd service
-  - cat > /etc/systemd/system/syntropy-agent.service << EOF
-[Unit]
-Description=Syntropy Cooperative Grid Agent
-After=network.target docker.service
-Wants=docker.service
-
-[Service]
-Type=simple
-User=syntropy
-Group=syntropy
-WorkingDirectory=/opt/syntropy
-ExecStart=/opt/syntropy/bin/syntropy-agent --config=/opt/syntropy/config/agent.yaml
-Restart=always
-RestartSec=5
-StandardOutput=journal
-StandardError=journal
-
-[Install]
-WantedBy=multi-user.target
-EOF
-  
-  # Iniciar Syntropy Agent
-  - systemctl daemon-reload
-  - systemctl enable syntropy-agent
-  - systemctl start syntropy-agent
-  
-  # Configurar logrotate
-  - cat > /etc/logrotate.d/syntropy << EOF
-/opt/syntropy/logs/*.log {
-    daily
-    rotate 30
-    compress
-    delaycompress
-    missingok
-    notifempty
-    create 644 syntropy syntropy
-    postrotate
-        systemctl reload syntropy-agent
-    endscript
+// Faixa de sufixos atribuíveis: .1 é o gateway da mesh e .255 o broadcast
+const (
+	minIPSuffix = 2
+	maxIPSuffix = 254
+)
+
+// parseIPSuffix interpreta o sufixo IP de um nó, aceito entre 2 e 254
+func parseIPSuffix(s string) (int, error) {
+	suffix, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || suffix < minIPSuffix || suffix > maxIPSuffix {
+		return 0, fmt.Errorf("sufixo IP inválido %q: use um número entre %d e %d", s, minIPSuffix, maxIPSuffix)
+	}
+	return suffix, nil
 }
-EOF
-
-# Configurações finais
-final_message: |
-  ✅ Nó Syntropy configurado com sucesso!
-  
-  📊 Informações do Nó:
-  - Nome: {{.NodeName}}
-  - Tipo: {{.NodeType}}
-  - Descrição: {{.NodeDescription}}
-  - Coordenadas: {{.Coordinates}}
-  
-  🔐 Segurança:
-  - SSH configurado com chave pública
-  - Firewall ativo
-  - Fail2ban configurado
-  - Certificados TLS instalados
-  
-  🌐 Rede:
-  - Descoberta: {{.DiscoveryServer}}
-  - Mesh: porta 51820
-  - API: porta 8080
-  
-  📝 Logs:
-  - Agent: /opt/syntropy/logs/agent.log
-  - System: journalctl -u syntropy-agent
-  
-  🚀 Status: systemctl status syntropy-agent`
-
-	// Renderizar e criar arquivo user-data
-	userDataContent, err := renderTemplate(userDataTemplate, config)
+
+// meshSuffixes retorna o sufixo IP da mesh de cada nó com certificado
+// vigente na CA da grade
+func meshSuffixes(authority *ca.Authority) (map[string]int, error) {
+	suffixes := make(map[string]int)
+	if authority == nil {
+		return suffixes, nil
+	}
+	records, err := authority.NodeCertificates()
 	if err != nil {
-		return fmt.Errorf("erro ao renderizar user-data: %w", err)
+		return nil, fmt.Errorf("erro ao ler certificados da CA: %w", err)
+	}
+	return recordSuffixes(records), nil
+}
+
+// recordSuffixes retorna o sufixo IP da mesh de cada nó em records
+func recordSuffixes(records []*ca.Record) map[string]int {
+	suffixes := make(map[string]int)
+	for _, rec := range records {
+		for _, ip := range rec.IPAddresses {
+			if !strings.HasPrefix(ip, meshIPPrefix) {
+				continue
+			}
+			if suffix, err := parseIPSuffix(strings.TrimPrefix(ip, meshIPPrefix)); err == nil {
+				suffixes[rec.CommonName] = suffix
+			}
+		}
 	}
+	return suffixes
+}
 
-	userDataPath := filepath.Join(cloudInitDir, "user-data")
-	if err := os.WriteFile(userDataPath, []byte(userDataContent), 0644); err != nil {
-		return fmt.Errorf("erro ao criar user-data: %w", err)
+// allocateIPSuffixes escolhe um sufixo para cada nó em names. O nó mantém o
+// sufixo que já tinha na CA (existing) se ninguém mais o usa; senão recebe o
+// menor ainda livre. taken traz os sufixos já ocupados e o nó de cada um, e
+// é atualizado com os atribuídos.
+func allocateIPSuffixes(names []string, taken map[int]string, existing map[string]int) (map[string]string, error) {
+	pending := make(map[string]bool, len(names))
+	for _, name := range names {
+		pending[name] = true
+	}
+	for name, suffix := range existing {
+		if _, ok := taken[suffix]; !ok && !pending[name] {
+			taken[suffix] = name
+		}
 	}
 
-	// Template do meta-data
-	metaDataTemplate := `# Syntropy Cooperative Grid - Node Metadata
-# Gerado automaticamente pelo PC de trabalho (Quartel General)
-
-instance-id: {{.NodeName}}-{{.InstanceID}}
-local-hostname: {{.NodeName}}
-
-# Informações do nó Syntropy
-syntropy:
-  node:
-    name: {{.NodeName}}
-    type: {{.NodeType}}
-    description: {{.NodeDescription}}
-    coordinates: {{.Coordinates}}
-    owner_key: {{.OwnerKey}}
-    created_at: {{.CreatedAt}}
-    created_by: {{.CreatedBy}}
-  
-  network:
-    discovery_server: {{.DiscoveryServer}}
-    mesh_port: 51820
-    api_port: 8080
-    metrics_port: 9090
-  
-  security:
-    ca_cert: {{.CACertPath}}
-    node_cert: {{.NodeCertPath}}
-    node_key: {{.NodeKeyPath}}
-    ssh_public_key: {{.SSHPublicKey}}
-  
-  hardware:
-    detected_type: {{.HardwareType}}
-    cpu_cores: {{.CPUCores}}
-    memory_gb: {{.MemoryGB}}
-    storage_gb: {{.StorageGB}}
-  
-  role:
-    initial_role: {{.InitialRole}}
-    can_be_leader: {{.CanBeLeader}}
-    can_be_worker: {{.CanBeWorker}}
-  
-  audit:
-    enabled: true
-    log_level: info
-    retention_days: 90`
-
-	// Renderizar e criar arquivo meta-data
-	metaDataContent, err := renderTemplate(metaDataTemplate, config)
-	if err != nil {
-		return fmt.Errorf("erro ao renderizar meta-data: %w", err)
+	assigned := make(map[string]string, len(names))
+	for _, name := range names {
+		if suffix, ok := existing[name]; ok {
+			if _, used := taken[suffix]; !used {
+				taken[suffix] = name
+				assigned[name] = strconv.Itoa(suffix)
+			}
+		}
 	}
+	next := minIPSuffix
+	for _, name := range names {
+		if _, ok := assigned[name]; ok {
+			continue
+		}
+		for ; next <= maxIPSuffix; next++ {
+			if _, used := taken[next]; !used {
+				break
+			}
+		}
+		if next > maxIPSuffix {
+			return nil, fmt.Errorf("não há sufixo IP livre na mesh %s0/24 para o nó %s", meshIPPrefix, name)
+		}
+		taken[next] = name
+		assigned[name] = strconv.Itoa(next)
+	}
+	return assigned, nil
+}
 
-	metaDataPath := filepath.Join(cloudInitDir, "meta-data")
-	if err := os.WriteFile(metaDataPath, []byte(metaDataContent), 0644); err != nil {
-		return fmt.Errorf("erro ao criar meta-data: %w", err)
+// cloudInitTemplateDir retorna o diretório de templates do cloud-init:
+// o informado ou ~/.syntropy/templates/cloud-init. Templates e perfis
+// ausentes nele caem nos padrões embutidos.
+func cloudInitTemplateDir(dir string) string {
+	if dir != "" {
+		return dir
 	}
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".syntropy", "templates", "cloud-init")
+}
 
-	// Template do network-config
-	networkConfigTemplate := `# Syntropy Cooperative Grid - Network Configuration
-# Gerado automaticamente pelo PC de trabalho (Quartel General)
-
-version: 2
-ethernets:
-  # Configuração automática para interfaces Ethernet
-  en*:
-    dhcp4: true
-    dhcp6: false
-    dhcp4-overrides:
-      hostname: {{.NodeName}}
-      use-dns: true
-      use-routes: true
-      use-domains: true
-    nameservers:
-      addresses:
-        - 8.8.8.8
-        - 8.8.4.4
-        - 1.1.1.1
-        - 1.0.0.1
-    routes:
-      - to: 0.0.0.0/0
-        via: {{.Gateway}}
-        metric: 100
-  
-  # Configuração automática para interfaces Ethernet (nomenclatura alternativa)
-  eth*:
-    dhcp4: true
-    dhcp6: false
-    dhcp4-overrides:
-      hostname: {{.NodeName}}
-      use-dns: true
-      use-routes: true
-      use-domains: true
-    nameservers:
-      addresses:
-        - 8.8.8.8
-        - 8.8.4.4
-        - 1.1.1.1
-        - 1.0.0.1
-    routes:
-      - to: 0.0.0.0/0
-        via: {{.Gateway}}
-        metric: 100
-  
-  # Configuração automática para interfaces Ethernet (nomenclatura moderna)
-  enp*:
-    dhcp4: true
-    dhcp6: false
-    dhcp4-overrides:
-      hostname: {{.NodeName}}
-      use-dns: true
-      use-routes: true
-      use-domains: true
-    nameservers:
-      addresses:
-        - 8.8.8.8
-        - 8.8.4.4
-        - 1.1.1.1
-        - 1.0.0.1
-    routes:
-      - to: 0.0.0.0/0
-        via: {{.Gateway}}
-        metric: 100
-
-# Configuração de bridge para virtualização (se necessário)
-bridges:
-  br0:
-    interfaces: []
-    dhcp4: false
-    dhcp6: false
-    addresses:
-      - 172.20.0.{{.NodeIPSuffix}}/24
-    gateway4: 172.20.0.1
-    nameservers:
-      addresses:
-        - 8.8.8.8
-        - 8.8.4.4
-        - 1.1.1.1
-        - 1.0.0.1
-    parameters:
-      stp: false
-      forward-delay: 0
-
-# Configuração de VLAN (se necessário)
-vlans:
-  vlan100:
-    id: 100
-    link: {{.PrimaryInterface}}
-    dhcp4: false
-    dhcp6: false
-    addresses:
-      - 192.168.100.{{.NodeIPSuffix}}/24
-    gateway4: 192.168.100.1
-    nameservers:
-      addresses:
-        - 8.8.8.8
-        - 8.8.4.4
-        - 1.1.1.1
-        - 1.0.0.1
-
-# Configuração de roteamento estático para redes Syntropy
-routes:
-  # Rota para rede mesh Syntropy
-  - to: 172.20.0.0/12
-    via: {{.MeshGateway}}
-    metric: 50
-    table: 100
-  # Rota para rede de gerenciamento
-  - to: 192.168.100.0/24
-    via: {{.MgmtGateway}}
-    metric: 75
-    table: 100
-
-# Configuração de regras de roteamento
-routing-policy:
-  - from: 172.20.0.0/12
-    table: 100
-  - from: 192.168.100.0/24
-    table: 100
-
-# Configuração de proxy (se necessário)
-proxy:
-  http: {{.HTTPProxy}}
-  https: {{.HTTPSProxy}}
-  no_proxy:
-    - localhost
-    - 127.0.0.1
-    - 172.20.0.0/12
-    - 192.168.100.0/24`
-
-	// Renderizar e criar arquivo network-config
-	networkConfigContent, err := renderTemplate(networkConfigTemplate, config)
-	if err != nil {
-		return fmt.Errorf("erro ao renderizar network-config: %w", err)
+// applyNodeOverrides aplica o papel e a rede definidos para o nó (por
+// exemplo, no manifesto da frota) sobre os valores padrão
+func applyNodeOverrides(cfg *CloudInitConfig, config *Config) {
+	if config.Role != "" {
+		cfg.NodeType = config.Role
+		cfg.InitialRole = config.Role
 	}
 
-	networkConfigPath := filepath.Join(cloudInitDir, "network-config")
-	if err := os.WriteFile(networkConfigPath, []byte(networkConfigContent), 0644); err != nil {
-		return fmt.Errorf("erro ao criar network-config: %w", err)
+	n := config.Network
+	if n.Interface != "" {
+		cfg.Interface = n.Interface
+		cfg.PrimaryInterface = n.Interface
+	}
+	if n.Gateway != "" {
+		cfg.Gateway = n.Gateway
+	}
+	if n.IPSuffix != "" {
+		cfg.NodeIPSuffix = n.IPSuffix
+	}
+	if n.MeshGateway != "" {
+		cfg.MeshGateway = n.MeshGateway
 	}
+	if n.MgmtGateway != "" {
+		cfg.MgmtGateway = n.MgmtGateway
+	}
+	if n.HTTPProxy != "" {
+		cfg.HTTPProxy = n.HTTPProxy
+	}
+	if n.HTTPSProxy != "" {
+		cfg.HTTPSProxy = n.HTTPSProxy
+	}
+}
 
-	return nil
+// createCloudInitFiles cria os arquivos de configuração do cloud-init. Os
+// três arquivos são validados contra o esquema do cloud-init antes de
+// qualquer um ser gravado.
+func createCloudInitFiles(config *CloudInitConfig, workDir string, certPaths map[string]string) error {
+	cloudInitDir := filepath.Join(workDir, "cloud-init")
+	tm := infrastructure.NewTemplateManager(config.TemplateDir)
+	return tm.SaveCloudInitFiles(cloudInitDir, &config.CloudInitData)
 }
 
 // copyScripts copia os scripts de instalação para o diretório de trabalho
@@ -575,6 +315,7 @@ func copyScripts(workDir string) error {
 		"network-discovery.sh",
 		"syntropy-install.sh",
 		"cluster-join.sh",
+		"mdns-browse.sh",
 	}
 
 	for _, script := range scripts {
@@ -0,0 +1,59 @@
+package usb
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func TestOwnerFingerprint(t *testing.T) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	block, err := ssh.MarshalPrivateKey(private, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	privatePEM := pem.EncodeToMemory(block)
+	publicKey := ssh.MarshalAuthorizedKey(signer.PublicKey())
+	want := ssh.FingerprintSHA256(signer.PublicKey())
+
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	withPub := write("owner.key", privatePEM)
+	write("owner.key.pub", publicKey)
+
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{"sem chave do dono", "", ""},
+		{"chave pública", write("owner.pub", publicKey), want},
+		{"privada com .pub ao lado", withPub, want},
+		{"só a privada", write("only.key", privatePEM), want},
+		{"arquivo inexistente", filepath.Join(dir, "missing.key"), ""},
+		{"arquivo que não é chave", write("notes.txt", []byte("hello")), ""},
+	}
+	for _, tt := range tests {
+		if got := ownerFingerprint(tt.file); got != tt.want {
+			t.Errorf("%s: impressão = %q, esperado %q", tt.name, got, tt.want)
+		}
+	}
+}
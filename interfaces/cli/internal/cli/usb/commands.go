@@ -27,7 +27,12 @@ NOTA: No WSL, os comandos utilizam PowerShell para acessar dispositivos físicos
 	usbCmd.AddCommand(newUSBListCommand())
 	usbCmd.AddCommand(newUSBCreateCommand())
 	usbCmd.AddCommand(newUSBFormatCommand())
+	usbCmd.AddCommand(newUSBVerifyCommand())
 	usbCmd.AddCommand(newUSBDebugCommand())
+	usbCmd.AddCommand(newUSBISOCommand())
+	usbCmd.AddCommand(newUSBTemplatesCommand())
+
+	addISOFlags(usbCmd)
 
 	return usbCmd
 }
@@ -75,6 +80,14 @@ func newUSBCreateCommand() *cobra.Command {
 		isoPath         string
 		discoveryServer string
 		createdBy       string
+		outputImage     string
+		imageFormat     string
+		manifestPath    string
+		outputDir       string
+		parallel        int
+		profile         string
+		templateDir     string
+		offline         bool
 	)
 
 	cmd := &cobra.Command{
@@ -83,6 +96,9 @@ func newUSBCreateCommand() *cobra.Command {
 		Long: `Cria um USB com boot contendo Ubuntu Server e configuração automática
 para um nó da Syntropy Cooperative Grid.
 
+Discos com a raiz, o boot ou a swap do sistema montados são recusados, e a
+identidade do disco é conferida de novo imediatamente antes da gravação.
+
 Exemplos:
   # Criar USB com auto-detecção
   syntropy usb create --auto-detect --node-name "node-01"
@@ -90,17 +106,72 @@ Exemplos:
   # Criar USB especificando dispositivo (Linux)
   syntropy usb create /dev/sdb --node-name "node-01"
 
+  # Criar USB pelo ID estável do disco (veja "usb list")
+  syntropy usb create /dev/disk/by-id/usb-SanDisk_Cruzer_Blade_4C530001-0:0 --node-name "node-01"
+
   # Criar USB especificando dispositivo (Windows/WSL)
   syntropy usb create PHYSICALDRIVE1 --node-name "node-01"
 
   # Criar USB com ISO personalizada
   syntropy usb create --auto-detect --node-name "node-01" --iso /path/to/ubuntu.iso
+
+  # Gerar imagem de disco em vez de gravar um dispositivo (não requer root)
+  syntropy usb create --node-name "node-01" --output-image node-01.img
+  syntropy usb create --node-name "node-01" --output-image node-01.qcow2
+
+  # Provisionar vários nós a partir de um manifesto da frota
+  syntropy usb create --manifest fleet.yaml --output-dir images/ --parallel 4
+  syntropy usb create --manifest fleet.yaml
+
+  # Usar um perfil de cloud-init de ~/.syntropy/templates/cloud-init/profiles
+  syntropy usb create --node-name "node-01" --output-image node-01.img --profile edge
+
+  # Incluir pacotes, imagens e agente em uma partição extra (nó sem internet)
+  syntropy usb create /dev/sdb --node-name "node-01" --offline
 `,
 		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			var devicePath string
 
-			if autoDetect {
+			// Configurar usuário atual se não especificado
+			if createdBy == "" {
+				createdBy = os.Getenv("USER")
+				if createdBy == "" {
+					createdBy = "unknown"
+				}
+			}
+
+			if manifestPath != "" {
+				// Destinos vêm do manifesto ou de --output-dir
+				if nodeName != "" || outputImage != "" || autoDetect || len(args) > 0 {
+					return fmt.Errorf("--manifest não pode ser usado com --node-name, --output-image ou um dispositivo")
+				}
+				return runFleet(manifestPath, FleetOptions{
+					OutputDir:       outputDir,
+					ImageFormat:     imageFormat,
+					Parallel:        parallel,
+					Label:           label,
+					ISOPath:         isoPath,
+					CreatedBy:       createdBy,
+					WorkDir:         workDir,
+					CacheDir:        cacheDir,
+					DiscoveryServer: discoveryServer,
+					OwnerKeyFile:    ownerKeyFile,
+					Profile:         profile,
+					TemplateDir:     templateDir,
+					Offline:         offline,
+				})
+			}
+			if nodeName == "" {
+				return fmt.Errorf("especifique --node-name ou --manifest")
+			}
+
+			if outputImage != "" {
+				// A imagem substitui o dispositivo como destino
+				if autoDetect || len(args) > 0 {
+					return fmt.Errorf("--output-image não pode ser usado junto com um dispositivo")
+				}
+			} else if autoDetect {
 				device, err := SelectDevice()
 				if err != nil {
 					return fmt.Errorf("falha na auto-detecção: %w", err)
@@ -112,14 +183,6 @@ Exemplos:
 				return fmt.Errorf("especifique um dispositivo ou use --auto-detect")
 			}
 
-			// Configurar usuário atual se não especificado
-			if createdBy == "" {
-				createdBy = os.Getenv("USER")
-				if createdBy == "" {
-					createdBy = "unknown"
-				}
-			}
-
 			config := &Config{
 				NodeName:        nodeName,
 				NodeDescription: nodeDescription,
@@ -129,13 +192,19 @@ Exemplos:
 				ISOPath:         isoPath,
 				DiscoveryServer: discoveryServer,
 				CreatedBy:       createdBy,
+				Profile:         profile,
+				TemplateDir:     templateDir,
+				Offline:         offline,
 			}
 
+			if outputImage != "" {
+				return createUSBImage(outputImage, imageFormat, config, workDir, cacheDir)
+			}
 			return createUSB(devicePath, config, workDir, cacheDir)
 		},
 	}
 
-	cmd.Flags().StringVar(&nodeName, "node-name", "", "Nome do nó (obrigatório)")
+	cmd.Flags().StringVar(&nodeName, "node-name", "", "Nome do nó (obrigatório sem --manifest)")
 	cmd.Flags().StringVar(&nodeDescription, "description", "", "Descrição do nó")
 	cmd.Flags().StringVar(&coordinates, "coordinates", "", "Coordenadas geográficas (lat,lon)")
 	cmd.Flags().StringVar(&ownerKeyFile, "owner-key", "", "Arquivo de chave de proprietário existente")
@@ -146,8 +215,14 @@ Exemplos:
 	cmd.Flags().StringVar(&isoPath, "iso", "", "Caminho para ISO Ubuntu (baixa automaticamente se não especificado)")
 	cmd.Flags().StringVar(&discoveryServer, "discovery-server", "syntropy-discovery.local", "Servidor de descoberta da rede")
 	cmd.Flags().StringVar(&createdBy, "created-by", "", "Usuário que criou o nó (padrão: usuário atual)")
-
-	cmd.MarkFlagRequired("node-name")
+	cmd.Flags().StringVar(&outputImage, "output-image", "", "Gerar imagem de disco neste arquivo em vez de gravar um dispositivo")
+	cmd.Flags().StringVar(&imageFormat, "image-format", "", "Formato da imagem: raw ou qcow2 (padrão: pela extensão do arquivo)")
+	cmd.Flags().StringVar(&manifestPath, "manifest", "", "Manifesto YAML da frota com vários nós")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "Com --manifest, gerar imagens neste diretório para nós sem device/image")
+	cmd.Flags().IntVar(&parallel, "parallel", 1, "Com --manifest, número de nós gravados em paralelo")
+	cmd.Flags().StringVar(&profile, "profile", "", "Perfil do cloud-init (locale, timezone, pacotes, firewall e papel)")
+	cmd.Flags().StringVar(&templateDir, "template-dir", "", "Diretório com templates e perfis do cloud-init (padrão: ~/.syntropy/templates/cloud-init)")
+	cmd.Flags().BoolVar(&offline, "offline", false, "Gravar pacotes .deb, imagens e agente no USB para nós sem acesso à internet (requer docker)")
 
 	return cmd
 }
@@ -248,23 +323,20 @@ func createUSB(devicePath string, config *Config, workDir, cacheDir string) erro
 	platform := detectPlatform()
 
 	// Validar dispositivo
-	if err := validateDevice(devicePath); err != nil {
+	identity, err := validateDevice(devicePath)
+	if err != nil {
 		return err
 	}
-
-	// Configurar diretórios padrão com timestamp único
-	if workDir == "" {
-		homeDir, _ := os.UserHomeDir()
-		workDir = filepath.Join(homeDir, ".syntropy", "work", "usb-"+time.Now().Format("20060102-150405"))
-	}
-	if cacheDir == "" {
-		homeDir, _ := os.UserHomeDir()
-		cacheDir = filepath.Join(homeDir, ".syntropy", "cache")
+	backend, err := newBackend(platform)
+	if err != nil {
+		return err
 	}
 
+	workDir, cacheDir = resolveUSBDirs(workDir, cacheDir)
+
 	fmt.Printf("🚀 Iniciando criação de USB para nó: %s\n", config.NodeName)
 	fmt.Printf("📍 Plataforma: %s\n", platform)
-	fmt.Printf("💾 Dispositivo: %s\n", devicePath)
+	fmt.Printf("💾 Dispositivo: %s - %s\n", identity.Device, identity)
 	fmt.Printf("📂 Diretório de trabalho: %s\n", workDir)
 	fmt.Printf("📂 Diretório de cache: %s\n", cacheDir)
 	fmt.Println()
@@ -273,6 +345,62 @@ func createUSB(devicePath string, config *Config, workDir, cacheDir string) erro
 	os.MkdirAll(workDir, 0755)
 	os.MkdirAll(cacheDir, 0755)
 
+	if err := prepareOfflineBundle(config, cacheDir); err != nil {
+		return err
+	}
+	if err := prepareNodeFiles(config, workDir); err != nil {
+		return err
+	}
+
+	isoPath := config.ISOPath
+	if isoPath == "" {
+		isoPath, err = manageISOCache(cacheDir)
+		if err != nil {
+			return fmt.Errorf("erro ao gerenciar ISO: %w", err)
+		}
+	}
+	plan, err := noCloudPlan(config, isoPath, workDir)
+	if err != nil {
+		return err
+	}
+
+	// Mesmo pipeline em todas as plataformas: ISO + partição CIDATA
+	engine := newEngine(backend)
+	guardDevice(engine, identity)
+	result, err := engine.Run(identity.Device, plan)
+	if err != nil {
+		return fmt.Errorf("erro ao criar USB: %w", err)
+	}
+
+	fmt.Println("✅ USB criado com sucesso usando estratégia NoCloud!")
+	fmt.Println("🔧 O USB agora contém:")
+	printNoCloudSummary(config)
+	if config.OfflineBundle != "" {
+		fmt.Println("   • Mantenha o USB conectado até o fim do primeiro boot")
+	}
+	fmt.Println("   • Configuração será aplicada automaticamente no boot")
+	fmt.Println("🔍 Verificação da mídia gravada:")
+	printReport(result.Report)
+
+	return nil
+}
+
+// resolveUSBDirs aplica os diretórios padrão, com timestamp único no de trabalho
+func resolveUSBDirs(workDir, cacheDir string) (string, string) {
+	if workDir == "" {
+		homeDir, _ := os.UserHomeDir()
+		workDir = filepath.Join(homeDir, ".syntropy", "work", "usb-"+time.Now().Format("20060102-150405"))
+	}
+	if cacheDir == "" {
+		homeDir, _ := os.UserHomeDir()
+		cacheDir = filepath.Join(homeDir, ".syntropy", "cache")
+	}
+	return workDir, cacheDir
+}
+
+// prepareNodeFiles gera chaves, certificados, cloud-init e scripts do nó
+// em workDir, comuns à gravação em dispositivo e em imagem
+func prepareNodeFiles(config *Config, workDir string) error {
 	// Gerar ou carregar chaves SSH
 	if config.SSHPublicKey == "" {
 		fmt.Println("🔑 Verificando chaves SSH existentes...")
@@ -306,14 +434,31 @@ func createUSB(devicePath string, config *Config, workDir, cacheDir string) erro
 
 	// Gerar certificados TLS
 	fmt.Println("🔐 Gerando certificados TLS...")
-	certs, err := generateCertificates(config.NodeName, config.OwnerKeyFile)
+	authority := config.Authority
+	if authority == nil {
+		var err error
+		if authority, err = openGridCA(); err != nil {
+			return err
+		}
+	}
+
+	// O sufixo é fixado junto com o certificado para que o IP da mesh no SAN
+	// seja o mesmo configurado pelo cloud-init. Sem um definido, o nó fica
+	// com o que já tinha na CA ou com o primeiro livre.
+	if config.Network.IPSuffix != "" {
+		if _, err := parseIPSuffix(config.Network.IPSuffix); err != nil {
+			return err
+		}
+	}
+	certs, err := generateCertificates(authority, config.NodeName, config.Network.IPSuffix)
 	if err != nil {
 		return fmt.Errorf("erro ao gerar certificados: %w", err)
 	}
-	fmt.Println("✅ Certificados TLS gerados com sucesso")
+	config.Network.IPSuffix = certs.IPSuffix
+	fmt.Printf("✅ Certificado TLS emitido pela CA da grade (serial %s)\n", certs.Serial)
 
 	// Salvar certificados
-	caKeyPath, caCertPath, nodeKeyPath, nodeCertPath, err := saveCertificates(certs, workDir)
+	caCertPath, nodeKeyPath, nodeCertPath, err := saveCertificates(certs, workDir)
 	if err != nil {
 		return fmt.Errorf("erro ao salvar certificados: %w", err)
 	}
@@ -327,7 +472,6 @@ func createUSB(devicePath string, config *Config, workDir, cacheDir string) erro
 
 	// Criar arquivos do cloud-init
 	certPaths := map[string]string{
-		"CAKey":    caKeyPath,
 		"CACert":   caCertPath,
 		"NodeKey":  nodeKeyPath,
 		"NodeCert": nodeCertPath,
@@ -345,13 +489,5 @@ func createUSB(devicePath string, config *Config, workDir, cacheDir string) erro
 	}
 	fmt.Println("✅ Scripts copiados com sucesso")
 
-	// Criar USB com estratégia NoCloud (dd + partição CIDATA)
-	switch platform {
-	case "wsl":
-		return createUSBWithNoCloudWSL(devicePath, config, workDir, cacheDir)
-	case "windows":
-		return createUSBWithNoCloudWindows(devicePath, config, workDir, cacheDir)
-	default:
-		return createUSBWithNoCloudLinux(devicePath, config, workDir, cacheDir)
-	}
+	return nil
 }
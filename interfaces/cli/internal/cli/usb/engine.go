@@ -0,0 +1,96 @@
+package usb
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	coreusb "syntropy-cc/cooperative-grid/core/usb"
+)
+
+// stepIcons são os ícones das etapas do pipeline na saída do CLI
+var stepIcons = map[coreusb.Step]string{
+	coreusb.StepWipe:      "🧹",
+	coreusb.StepCopy:      "📝",
+	coreusb.StepPartition: "📦",
+	coreusb.StepFormat:    "🔧",
+	coreusb.StepSeed:      "📋",
+	coreusb.StepVerify:    "🔍",
+}
+
+// newBackend escolhe o backend de gravação da plataforma. Em todas elas os
+// comandos são os mesmos do Linux; no Windows eles rodam no WSL.
+func newBackend(platform string) (coreusb.Backend, error) {
+	switch platform {
+	case "linux":
+		if os.Geteuid() != 0 {
+			return nil, fmt.Errorf("este comando requer privilégios de root (use sudo)")
+		}
+		return coreusb.NewDeviceBackend(&coreusb.LocalRunner{Stream: os.Stdout}), nil
+	case "wsl":
+		return coreusb.NewWSLBackend(&coreusb.LocalRunner{Sudo: true, Stream: os.Stdout}, &coreusb.LocalRunner{}), nil
+	case "windows":
+		if !isRunningAsAdministrator() {
+			return nil, fmt.Errorf("este comando requer um terminal executado como administrador")
+		}
+		return coreusb.NewWSLBackend(&coreusb.WSLRunner{Stream: os.Stdout}, &coreusb.LocalRunner{}), nil
+	}
+	return nil, fmt.Errorf("plataforma não suportada: %s", platform)
+}
+
+// newEngine cria o engine com a saída de progresso do CLI
+func newEngine(backend coreusb.Backend) *coreusb.Engine {
+	engine := coreusb.NewEngine(backend)
+	var last coreusb.Step
+	engine.SetProgress(func(step coreusb.Step, msg string) {
+		if step != last {
+			fmt.Printf("%s Etapa %s\n", stepIcons[step], step)
+			last = step
+		}
+		fmt.Printf("   %s\n", msg)
+	})
+	return engine
+}
+
+// noCloudPlan monta o plano NoCloud: a ISO seguida da partição CIDATA com
+// o seed do cloud-init e, no modo offline, da partição com o pacote. Após
+// a gravação, o seed é relido e conferido como em "usb verify".
+func noCloudPlan(config *Config, isoPath, workDir string) (*coreusb.Plan, error) {
+	cloudInitDir := filepath.Join(workDir, "cloud-init")
+	var seed []string
+	for _, name := range []string{"user-data", "meta-data", "network-config"} {
+		seed = append(seed, filepath.Join(cloudInitDir, name))
+	}
+
+	plan := &coreusb.Plan{
+		Image:      isoPath,
+		Partitions: []coreusb.PartitionSpec{{Label: "CIDATA", SizeMiB: cidataSizeMiB, Sources: seed}},
+		Checks:     seedChecks(),
+	}
+
+	if config.OfflineBundle != "" {
+		sizeMiB, err := offlinePartitionMiB(config.OfflineBundle)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler pacote offline: %w", err)
+		}
+		entries, err := offlineEntries(config.OfflineBundle)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao ler pacote offline: %w", err)
+		}
+		plan.Partitions = append(plan.Partitions, coreusb.PartitionSpec{
+			Label:   offlineLabel,
+			SizeMiB: sizeMiB,
+			Sources: entries,
+		})
+	}
+	return plan, nil
+}
+
+// printNoCloudSummary descreve o conteúdo gravado pelo plano NoCloud
+func printNoCloudSummary(config *Config) {
+	fmt.Println("   • ISO Ubuntu original (bootável)")
+	fmt.Println("   • Partição CIDATA com configuração cloud-init")
+	if config.OfflineBundle != "" {
+		fmt.Printf("   • Partição %s com pacotes, imagens e agente\n", offlineLabel)
+	}
+}
@@ -0,0 +1,414 @@
+package usb
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+	"syntropy-cc/cooperative-grid/infrastructure"
+)
+
+// FleetManifest descreve vários nós a provisionar de uma só vez
+type FleetManifest struct {
+	Defaults FleetNode   `yaml:"defaults"`
+	Nodes    []FleetNode `yaml:"nodes"`
+}
+
+// FleetNode é uma entrada do manifesto. Campos vazios herdam de defaults.
+type FleetNode struct {
+	Name            string          `yaml:"name"`
+	Description     string          `yaml:"description"`
+	Coordinates     string          `yaml:"coordinates"`
+	Role            string          `yaml:"role"`
+	Profile         string          `yaml:"profile"`
+	OwnerKey        string          `yaml:"owner_key"`
+	DiscoveryServer string          `yaml:"discovery_server"`
+	Network         NetworkSettings `yaml:"network"`
+	// Device grava o nó diretamente neste dispositivo; Image gera um arquivo.
+	// Sem nenhum dos dois, o usuário é solicitado a trocar o USB.
+	Device string `yaml:"device"`
+	Image  string `yaml:"image"`
+}
+
+// FleetOptions controla a execução de um manifesto
+type FleetOptions struct {
+	OutputDir   string
+	ImageFormat string
+	Parallel    int
+	Label       string
+	ISOPath     string
+	CreatedBy   string
+	WorkDir     string
+	CacheDir    string
+	// DiscoveryServer e OwnerKeyFile valem para nós que não os definem
+	DiscoveryServer string
+	OwnerKeyFile    string
+	// Profile vale para nós que não o definem; TemplateDir vale para todos
+	Profile     string
+	TemplateDir string
+	// Offline grava em cada nó o pacote offline do seu perfil
+	Offline bool
+
+	// authority é a CA da grade, aberta uma vez para todos os nós
+	authority *ca.Authority
+}
+
+// FleetResult é o resultado do provisionamento de um nó
+type FleetResult struct {
+	Node        string        `json:"node"`
+	Destination string        `json:"destination"`
+	Success     bool          `json:"success"`
+	Error       string        `json:"error,omitempty"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// loadFleetManifest lê e valida o manifesto, aplicando os valores padrão.
+// Nós sem destino ganham uma imagem em opts.OutputDir, e dois nós não podem
+// ser gravados no mesmo dispositivo ou imagem. Nós sem sufixo IP recebem um
+// livre, que não colide com os definidos no manifesto nem com os de outros
+// nós já emitidos pela CA da grade.
+func loadFleetManifest(path string, opts FleetOptions) (*FleetManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao ler manifesto: %w", err)
+	}
+
+	var manifest FleetManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("erro ao interpretar manifesto: %w", err)
+	}
+	if len(manifest.Nodes) == 0 {
+		return nil, fmt.Errorf("manifesto %s não contém nós", path)
+	}
+	if manifest.Defaults.Network.IPSuffix != "" {
+		return nil, fmt.Errorf("ip_suffix não pode ficar em defaults: cada nó precisa do seu")
+	}
+
+	seen := make(map[string]bool)
+	destinations := make(map[string]string)
+	taken := make(map[int]string)
+	var pending []string
+	for i := range manifest.Nodes {
+		node := &manifest.Nodes[i]
+		if node.Name == "" {
+			return nil, fmt.Errorf("nó %d do manifesto sem nome", i+1)
+		}
+		if seen[node.Name] {
+			return nil, fmt.Errorf("nó duplicado no manifesto: %s", node.Name)
+		}
+		seen[node.Name] = true
+		if node.Device != "" && node.Image != "" {
+			return nil, fmt.Errorf("nó %s: use device ou image, não ambos", node.Name)
+		}
+		node.applyDefaults(manifest.Defaults)
+
+		if node.Image == "" && node.Device == "" && opts.OutputDir != "" {
+			node.Image = filepath.Join(opts.OutputDir, node.Name+imageExtension(opts.ImageFormat))
+		}
+		if dest := destinationKey(*node); dest != "" {
+			if other, ok := destinations[dest]; ok {
+				return nil, fmt.Errorf("nós %s e %s usam o mesmo destino: %s", other, node.Name, dest)
+			}
+			destinations[dest] = node.Name
+		}
+
+		if node.Network.IPSuffix == "" {
+			pending = append(pending, node.Name)
+			continue
+		}
+		suffix, err := parseIPSuffix(node.Network.IPSuffix)
+		if err != nil {
+			return nil, fmt.Errorf("nó %s: %w", node.Name, err)
+		}
+		if other, ok := taken[suffix]; ok {
+			return nil, fmt.Errorf("nós %s e %s usam o mesmo sufixo IP: %d", other, node.Name, suffix)
+		}
+		taken[suffix] = node.Name
+	}
+
+	existing, err := meshSuffixes(opts.authority)
+	if err != nil {
+		return nil, err
+	}
+	assigned, err := allocateIPSuffixes(pending, taken, existing)
+	if err != nil {
+		return nil, err
+	}
+	for i := range manifest.Nodes {
+		if suffix, ok := assigned[manifest.Nodes[i].Name]; ok {
+			manifest.Nodes[i].Network.IPSuffix = suffix
+		}
+	}
+	return &manifest, nil
+}
+
+// destinationKey normaliza o destino do nó para comparação: o caminho
+// absoluto da imagem ou o dispositivo com links simbólicos resolvidos
+func destinationKey(n FleetNode) string {
+	switch {
+	case n.Image != "":
+		if abs, err := filepath.Abs(n.Image); err == nil {
+			return abs
+		}
+		return filepath.Clean(n.Image)
+	case n.Device != "":
+		if real, err := filepath.EvalSymlinks(n.Device); err == nil {
+			return real
+		}
+		return filepath.Clean(n.Device)
+	}
+	return ""
+}
+
+// applyDefaults preenche os campos vazios do nó com os de d
+func (n *FleetNode) applyDefaults(d FleetNode) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&n.Description, d.Description)
+	fill(&n.Coordinates, d.Coordinates)
+	fill(&n.Role, d.Role)
+	fill(&n.Profile, d.Profile)
+	fill(&n.OwnerKey, d.OwnerKey)
+	fill(&n.DiscoveryServer, d.DiscoveryServer)
+	fill(&n.Network.Interface, d.Network.Interface)
+	fill(&n.Network.Gateway, d.Network.Gateway)
+	fill(&n.Network.IPSuffix, d.Network.IPSuffix)
+	fill(&n.Network.MeshGateway, d.Network.MeshGateway)
+	fill(&n.Network.MgmtGateway, d.Network.MgmtGateway)
+	fill(&n.Network.HTTPProxy, d.Network.HTTPProxy)
+	fill(&n.Network.HTTPSProxy, d.Network.HTTPSProxy)
+}
+
+// config monta a configuração de criação de USB do nó
+func (n *FleetNode) config(opts FleetOptions) *Config {
+	n.applyDefaults(FleetNode{DiscoveryServer: opts.DiscoveryServer, OwnerKey: opts.OwnerKeyFile, Profile: opts.Profile})
+	return &Config{
+		NodeName:        n.Name,
+		NodeDescription: n.Description,
+		Coordinates:     n.Coordinates,
+		OwnerKeyFile:    n.OwnerKey,
+		Label:           opts.Label,
+		ISOPath:         opts.ISOPath,
+		DiscoveryServer: n.DiscoveryServer,
+		CreatedBy:       opts.CreatedBy,
+		Role:            n.Role,
+		Network:         n.Network,
+		Profile:         n.Profile,
+		TemplateDir:     opts.TemplateDir,
+		Offline:         opts.Offline,
+		Authority:       opts.authority,
+	}
+}
+
+// runFleet provisiona todos os nós do manifesto. Imagens e nós com
+// dispositivo fixo são gravados em paralelo; os demais são gravados um a um,
+// pedindo ao usuário que troque o USB entre eles.
+func runFleet(manifestPath string, opts FleetOptions) error {
+	// A CA é aberta uma só vez; os nós em paralelo emitem por ela, e os
+	// sufixos IP já emitidos orientam os que o manifesto não define
+	var err error
+	opts.authority, err = openGridCA()
+	if err != nil {
+		return err
+	}
+	manifest, err := loadFleetManifest(manifestPath, opts)
+	if err != nil {
+		return err
+	}
+	if opts.Parallel < 1 {
+		opts.Parallel = 1
+	}
+
+	// Perfis inválidos falham antes de gravar qualquer nó
+	templates := infrastructure.NewTemplateManager(cloudInitTemplateDir(opts.TemplateDir))
+	for _, node := range manifest.Nodes {
+		profile := node.Profile
+		if profile == "" {
+			profile = opts.Profile
+		}
+		if _, err := templates.LoadProfile(profile); err != nil {
+			return fmt.Errorf("nó %s: %w", node.Name, err)
+		}
+	}
+
+	homeDir, _ := os.UserHomeDir()
+	if opts.WorkDir == "" {
+		opts.WorkDir = filepath.Join(homeDir, ".syntropy", "work", "fleet-"+time.Now().Format("20060102-150405"))
+	}
+	if opts.CacheDir == "" {
+		opts.CacheDir = filepath.Join(homeDir, ".syntropy", "cache")
+	}
+	os.MkdirAll(opts.WorkDir, 0755)
+	os.MkdirAll(opts.CacheDir, 0755)
+
+	// Todos os nós compartilham a mesma ISO; obtê-la antes evita downloads
+	// concorrentes no cache
+	if opts.ISOPath == "" {
+		opts.ISOPath, err = manageISOCache(opts.CacheDir)
+		if err != nil {
+			return fmt.Errorf("erro ao gerenciar ISO: %w", err)
+		}
+	}
+
+	// Um pacote offline por perfil, montado antes para que os nós em
+	// paralelo apenas o reutilizem
+	if opts.Offline {
+		built := map[string]bool{}
+		for _, node := range manifest.Nodes {
+			config := node.config(opts)
+			if built[config.Profile] {
+				continue
+			}
+			built[config.Profile] = true
+			if err := prepareOfflineBundle(config, opts.CacheDir); err != nil {
+				return fmt.Errorf("nó %s: %w", node.Name, err)
+			}
+		}
+	}
+
+	var concurrent, swapped []FleetNode
+	used := make(map[string]string)
+	for _, node := range manifest.Nodes {
+		if dest := destinationKey(node); dest != "" {
+			used[dest] = node.Name
+			concurrent = append(concurrent, node)
+		} else {
+			swapped = append(swapped, node)
+		}
+	}
+	if opts.OutputDir != "" {
+		if err := os.MkdirAll(opts.OutputDir, 0755); err != nil {
+			return fmt.Errorf("erro ao criar diretório de saída: %w", err)
+		}
+	}
+
+	fmt.Printf("🚚 Provisionando %d nós do manifesto %s\n", len(manifest.Nodes), manifestPath)
+	fmt.Printf("📂 Diretório de trabalho: %s\n\n", opts.WorkDir)
+
+	results := make([]FleetResult, 0, len(manifest.Nodes))
+	var mu sync.Mutex
+	record := func(r FleetResult) {
+		mu.Lock()
+		results = append(results, r)
+		mu.Unlock()
+	}
+
+	jobs := make(chan FleetNode)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for node := range jobs {
+				record(provisionFleetNode(node, opts))
+			}
+		}()
+	}
+	for _, node := range concurrent {
+		jobs <- node
+	}
+	close(jobs)
+	wg.Wait()
+
+	reader := bufio.NewReader(os.Stdin)
+	for i, node := range swapped {
+		fmt.Printf("\n🔌 [%d/%d] Insira o USB para o nó %s e pressione Enter (ou digite 'pular'): ",
+			i+1, len(swapped), node.Name)
+		answer, _ := reader.ReadString('\n')
+		if strings.EqualFold(strings.TrimSpace(answer), "pular") {
+			record(FleetResult{Node: node.Name, Error: "ignorado pelo usuário"})
+			continue
+		}
+		device, err := SelectDevice()
+		if err != nil {
+			record(FleetResult{Node: node.Name, Error: err.Error()})
+			continue
+		}
+		node.Device = device.Path
+		if other, ok := used[destinationKey(node)]; ok {
+			record(FleetResult{Node: node.Name, Destination: node.Device, Error: fmt.Sprintf("dispositivo já usado pelo nó %s", other)})
+			continue
+		}
+		used[destinationKey(node)] = node.Name
+		record(provisionFleetNode(node, opts))
+	}
+
+	return reportFleet(results, filepath.Join(opts.WorkDir, "fleet-report.json"))
+}
+
+// provisionFleetNode grava um nó em seu dispositivo ou imagem, usando um
+// diretório de trabalho próprio para não colidir com os demais nós
+func provisionFleetNode(node FleetNode, opts FleetOptions) FleetResult {
+	start := time.Now()
+	result := FleetResult{Node: node.Name, Destination: node.Device}
+	workDir := filepath.Join(opts.WorkDir, node.Name)
+
+	var err error
+	if node.Image != "" {
+		result.Destination = node.Image
+		err = createUSBImage(node.Image, opts.ImageFormat, node.config(opts), workDir, opts.CacheDir)
+	} else {
+		err = createUSB(node.Device, node.config(opts), workDir, opts.CacheDir)
+	}
+
+	result.Duration = time.Since(start).Round(time.Second)
+	if err != nil {
+		result.Error = err.Error()
+		fmt.Printf("❌ Nó %s falhou: %v\n", node.Name, err)
+	} else {
+		result.Success = true
+		fmt.Printf("✅ Nó %s provisionado em %s\n", node.Name, result.Destination)
+	}
+	return result
+}
+
+// imageExtension retorna a extensão de arquivo para o formato de imagem
+func imageExtension(format string) string {
+	if format == imageFormatQCOW2 {
+		return ".qcow2"
+	}
+	return ".img"
+}
+
+// reportFleet imprime o resumo do provisionamento e o salva em reportPath.
+// Retorna erro se algum nó falhou.
+func reportFleet(results []FleetResult, reportPath string) error {
+	fmt.Println()
+	fmt.Println("📋 Resumo do provisionamento")
+	fmt.Printf("%-20s %-8s %-10s %-30s %s\n", "NÓ", "STATUS", "DURAÇÃO", "DESTINO", "ERRO")
+	fmt.Println(strings.Repeat("─", 80))
+
+	failed := 0
+	for _, r := range results {
+		status := "OK"
+		if !r.Success {
+			status = "FALHA"
+			failed++
+		}
+		fmt.Printf("%-20s %-8s %-10s %-30s %s\n", r.Node, status, r.Duration, r.Destination, r.Error)
+	}
+	fmt.Println(strings.Repeat("─", 80))
+	fmt.Printf("Total: %d  Sucesso: %d  Falha: %d\n", len(results), len(results)-failed, failed)
+
+	if data, err := json.MarshalIndent(results, "", "  "); err == nil {
+		if err := os.WriteFile(reportPath, data, 0644); err == nil {
+			fmt.Printf("📄 Relatório salvo em: %s\n", reportPath)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d de %d nós falharam", failed, len(results))
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+package usb
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+)
+
+// writeManifest grava o manifesto em um diretório temporário
+func writeManifest(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fleet.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFleetManifestRejects(t *testing.T) {
+	outputDir := t.TempDir()
+	tests := []struct {
+		name     string
+		manifest string
+		want     string
+	}{
+		{"nome repetido", `
+nodes:
+  - name: a
+  - name: a
+`, "nó duplicado"},
+		{"dispositivo repetido", `
+nodes:
+  - name: a
+    device: /dev/sdx
+  - name: b
+    device: /dev/sdx/
+`, "mesmo destino"},
+		{"imagem explícita igual à gerada", `
+nodes:
+  - name: a
+    image: ` + filepath.Join(outputDir, "b.img") + `
+  - name: b
+`, "mesmo destino"},
+		{"imagem repetida por caminho relativo", `
+nodes:
+  - name: a
+    image: node.img
+  - name: b
+    image: ./node.img
+`, "mesmo destino"},
+		{"sufixo repetido", `
+nodes:
+  - name: a
+    network: {ip_suffix: "10"}
+  - name: b
+    network: {ip_suffix: "10"}
+`, "mesmo sufixo IP"},
+		{"sufixo abaixo da faixa", `
+nodes:
+  - name: a
+    network: {ip_suffix: "1"}
+`, "sufixo IP inválido"},
+		{"sufixo acima da faixa", `
+nodes:
+  - name: a
+    network: {ip_suffix: "255"}
+`, "sufixo IP inválido"},
+		{"sufixo não numérico", `
+nodes:
+  - name: a
+    network: {ip_suffix: "x"}
+`, "sufixo IP inválido"},
+		{"sufixo em defaults", `
+defaults:
+  network: {ip_suffix: "10"}
+nodes:
+  - name: a
+`, "defaults"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadFleetManifest(writeManifest(t, tt.manifest), FleetOptions{OutputDir: outputDir})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("erro = %v, esperado contendo %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFleetManifestDestinations(t *testing.T) {
+	outputDir := t.TempDir()
+	path := writeManifest(t, `
+nodes:
+  - name: a
+  - name: b
+    device: /dev/sdx
+  - name: c
+    image: c.img
+`)
+	manifest, err := loadFleetManifest(path, FleetOptions{OutputDir: outputDir, ImageFormat: imageFormatQCOW2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(outputDir, "a.qcow2"), "", "c.img"}
+	for i, node := range manifest.Nodes {
+		if node.Image != want[i] {
+			t.Errorf("%s: image = %q, esperado %q", node.Name, node.Image, want[i])
+		}
+	}
+}
+
+func TestLoadFleetManifestIPSuffixes(t *testing.T) {
+	const manifest = `
+nodes:
+  - name: a
+  - name: b
+    network: {ip_suffix: "2"}
+  - name: c
+  - name: d
+    network: {ip_suffix: "4"}
+  - name: e
+`
+	// Sem CA, os livres são atribuídos em ordem, pulando os explícitos
+	loaded, err := loadFleetManifest(writeManifest(t, manifest), FleetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSuffixes(t, loaded, map[string]string{"a": "3", "b": "2", "c": "5", "d": "4", "e": "6"})
+
+	// Com a CA, o nó mantém o sufixo que já tinha e os de outros nós
+	// emitidos ficam reservados
+	authority, err := ca.Init(filepath.Join(t.TempDir(), "ca"), ca.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, ip := range map[string]string{"c": "172.20.0.9", "old": "172.20.0.3", "e": "172.20.0.4"} {
+		if _, err := authority.Issue(ca.IssueRequest{CommonName: name, IPAddresses: []net.IP{net.ParseIP(ip)}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	loaded, err = loadFleetManifest(writeManifest(t, manifest), FleetOptions{authority: authority})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// e tinha o 4, agora explícito em d
+	assertSuffixes(t, loaded, map[string]string{"a": "5", "b": "2", "c": "9", "d": "4", "e": "6"})
+}
+
+func assertSuffixes(t *testing.T, manifest *FleetManifest, want map[string]string) {
+	t.Helper()
+	for _, node := range manifest.Nodes {
+		if node.Network.IPSuffix != want[node.Name] {
+			t.Errorf("%s: ip_suffix = %q, esperado %q", node.Name, node.Network.IPSuffix, want[node.Name])
+		}
+	}
+}
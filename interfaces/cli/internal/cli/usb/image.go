@@ -0,0 +1,204 @@
+package usb
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	coreusb "syntropy-cc/cooperative-grid/core/usb"
+)
+
+// Formatos de imagem suportados por --output-image
+const (
+	imageFormatRaw   = "raw"
+	imageFormatQCOW2 = "qcow2"
+)
+
+// cidataSizeMiB é o tamanho da partição CIDATA, o mesmo usado no dispositivo
+const cidataSizeMiB = 128
+
+// resolveImageFormat escolhe o formato pela flag ou pela extensão do arquivo
+func resolveImageFormat(path, format string) (string, error) {
+	if format == "" {
+		if strings.EqualFold(filepath.Ext(path), ".qcow2") {
+			return imageFormatQCOW2, nil
+		}
+		return imageFormatRaw, nil
+	}
+	switch format {
+	case imageFormatRaw, imageFormatQCOW2:
+		return format, nil
+	}
+	return "", fmt.Errorf("formato de imagem inválido: %s (use raw ou qcow2)", format)
+}
+
+// validateImagePath confere o arquivo de --output-image: o diretório precisa
+// existir e o caminho não pode ser um diretório, um dispositivo nem a ISO
+// de origem
+func validateImagePath(imagePath, isoPath string) error {
+	if imagePath == "" {
+		return fmt.Errorf("--output-image precisa de um arquivo")
+	}
+	if strings.HasSuffix(imagePath, "/") || strings.HasSuffix(imagePath, string(filepath.Separator)) {
+		return fmt.Errorf("--output-image precisa de um arquivo, não de um diretório: %s", imagePath)
+	}
+	if info, err := os.Stat(filepath.Dir(imagePath)); err != nil || !info.IsDir() {
+		return fmt.Errorf("diretório da imagem não existe: %s", filepath.Dir(imagePath))
+	}
+
+	info, err := os.Stat(imagePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch {
+	case info.IsDir():
+		return fmt.Errorf("--output-image precisa de um arquivo, não de um diretório: %s", imagePath)
+	case !info.Mode().IsRegular():
+		return fmt.Errorf("%s não é um arquivo comum; para gravar um dispositivo, passe-o sem --output-image", imagePath)
+	}
+	if isoPath != "" {
+		if iso, err := os.Stat(isoPath); err == nil && os.SameFile(info, iso) {
+			return fmt.Errorf("a imagem não pode sobrescrever a ISO de origem: %s", imagePath)
+		}
+	}
+	return nil
+}
+
+// rawImagePath retorna o arquivo raw gravado pelo plano: a própria imagem
+// ou, para qcow2, um temporário em workDir que depois é convertido
+func rawImagePath(imagePath, format, workDir string) string {
+	if format == imageFormatQCOW2 {
+		return filepath.Join(workDir, "disk.img")
+	}
+	return imagePath
+}
+
+// writeImage grava o plano no arquivo rawPath. Um arquivo não tem tamanho
+// para a última partição ocupar, então toda partição precisa do seu.
+func writeImage(backend coreusb.Backend, rawPath string, plan *coreusb.Plan) (*coreusb.Result, error) {
+	for _, p := range plan.Partitions {
+		if p.SizeMiB <= 0 {
+			return nil, fmt.Errorf("partição %s sem tamanho: imagens precisam do tamanho de cada partição", p.Label)
+		}
+	}
+	result, err := newEngine(backend).Run(rawPath, plan)
+	if err != nil {
+		os.Remove(rawPath)
+		return nil, fmt.Errorf("erro ao criar imagem: %w", err)
+	}
+	return result, nil
+}
+
+// checkImageTools verifica se as ferramentas usadas para montar a imagem
+// sem privilégios de root estão instaladas
+func checkImageTools(format string) error {
+	tools := [][2]string{
+		{"sgdisk", "gdisk"},
+		{"mkfs.vfat", "dosfstools"},
+		{"mcopy", "mtools"},
+	}
+	if format == imageFormatQCOW2 {
+		tools = append(tools, [2]string{"qemu-img", "qemu-utils"})
+	}
+
+	var missing []string
+	for _, tool := range tools {
+		if _, err := exec.LookPath(tool[0]); err != nil {
+			missing = append(missing, fmt.Sprintf("%s (pacote %s)", tool[0], tool[1]))
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("ferramentas necessárias não encontradas: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// createUSBImage gera em um arquivo o mesmo conteúdo gravado no USB: a ISO
+// Ubuntu seguida da partição CIDATA com o seed NoCloud. Nada é montado,
+// então não são necessários privilégios de root.
+func createUSBImage(imagePath, format string, config *Config, workDir, cacheDir string) error {
+	format, err := resolveImageFormat(imagePath, format)
+	if err != nil {
+		return err
+	}
+	if err := validateImagePath(imagePath, config.ISOPath); err != nil {
+		return err
+	}
+	if err := checkImageTools(format); err != nil {
+		return err
+	}
+	imagePath, err = filepath.Abs(imagePath)
+	if err != nil {
+		return err
+	}
+	workDir, cacheDir = resolveUSBDirs(workDir, cacheDir)
+
+	fmt.Printf("🚀 Iniciando criação de imagem para nó: %s\n", config.NodeName)
+	fmt.Printf("💿 Imagem: %s (%s)\n", imagePath, format)
+	fmt.Printf("📂 Diretório de trabalho: %s\n", workDir)
+	fmt.Printf("📂 Diretório de cache: %s\n", cacheDir)
+	fmt.Println()
+
+	os.MkdirAll(workDir, 0755)
+	os.MkdirAll(cacheDir, 0755)
+
+	if err := prepareOfflineBundle(config, cacheDir); err != nil {
+		return err
+	}
+	if err := prepareNodeFiles(config, workDir); err != nil {
+		return err
+	}
+
+	isoPath := config.ISOPath
+	if isoPath == "" {
+		isoPath, err = manageISOCache(cacheDir)
+		if err != nil {
+			return fmt.Errorf("erro ao gerenciar ISO: %w", err)
+		}
+	}
+
+	// qcow2 é convertido a partir de uma imagem raw temporária
+	rawPath := rawImagePath(imagePath, format, workDir)
+	if rawPath != imagePath {
+		defer os.Remove(rawPath)
+	}
+
+	plan, err := noCloudPlan(config, isoPath, workDir)
+	if err != nil {
+		return err
+	}
+	// A imagem é montada com mtools direto no arquivo, sem root
+	result, err := writeImage(coreusb.NewImageBackend(filepath.Join(workDir, "image")), rawPath, plan)
+	if err != nil {
+		return err
+	}
+
+	if format == imageFormatQCOW2 {
+		fmt.Println("🗜️  Convertendo para qcow2...")
+		if err := runCommandWithTimeout(30*time.Minute, "qemu-img", "convert", "-f", "raw", "-O", "qcow2", rawPath, imagePath); err != nil {
+			os.Remove(imagePath)
+			return fmt.Errorf("erro ao converter imagem: %w", err)
+		}
+	}
+
+	fmt.Println("✅ Imagem criada com sucesso usando estratégia NoCloud!")
+	fmt.Println("🔧 A imagem contém:")
+	printNoCloudSummary(config)
+	fmt.Println("🔍 Verificação da mídia gravada:")
+	printReport(result.Report)
+	fmt.Println()
+	fmt.Println("Para testar no QEMU:")
+	fmt.Printf("   qemu-system-x86_64 -m 4096 -enable-kvm -drive file=%s,format=%s\n", imagePath, format)
+	if format == imageFormatRaw {
+		fmt.Println("Para gravar em um USB:")
+		fmt.Printf("   sudo dd if=%s of=/dev/sdX bs=4M status=progress oflag=sync\n", imagePath)
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package usb
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	coreusb "syntropy-cc/cooperative-grid/core/usb"
+)
+
+func TestResolveImageFormat(t *testing.T) {
+	tests := []struct {
+		path, format, want string
+	}{
+		{"node-01.img", "", imageFormatRaw},
+		{"node-01", "", imageFormatRaw},
+		{"node-01.qcow2", "", imageFormatQCOW2},
+		{"NODE-01.QCOW2", "", imageFormatQCOW2},
+		{"node-01.img", "qcow2", imageFormatQCOW2},
+		{"node-01.qcow2", "raw", imageFormatRaw},
+		{"node-01.img", "vmdk", ""},
+		{"node-01.img", "QCOW2", ""},
+	}
+	for _, tt := range tests {
+		got, err := resolveImageFormat(tt.path, tt.format)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("resolveImageFormat(%q, %q) = %q, esperado erro", tt.path, tt.format, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("resolveImageFormat(%q, %q) = %q, %v; esperado %q", tt.path, tt.format, got, err, tt.want)
+		}
+	}
+}
+
+func TestRawImagePath(t *testing.T) {
+	work := t.TempDir()
+	if got := rawImagePath("/out/node.img", imageFormatRaw, work); got != "/out/node.img" {
+		t.Errorf("raw: %s", got)
+	}
+	// qcow2 é gravado em um raw temporário e convertido depois
+	if got := rawImagePath("/out/node.qcow2", imageFormatQCOW2, work); got != filepath.Join(work, "disk.img") {
+		t.Errorf("qcow2: %s", got)
+	}
+}
+
+func TestValidateImagePath(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "old.img")
+	iso := filepath.Join(dir, "ubuntu.iso")
+	for _, path := range []string{existing, iso} {
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name, path, iso, err string
+	}{
+		{"arquivo novo", filepath.Join(dir, "node.img"), iso, ""},
+		{"sobrescreve imagem antiga", existing, iso, ""},
+		{"vazio", "", "", "precisa de um arquivo"},
+		{"diretório", dir, "", "não de um diretório"},
+		{"barra no fim", filepath.Join(dir, "node") + string(filepath.Separator), "", "não de um diretório"},
+		{"diretório inexistente", filepath.Join(dir, "missing", "node.img"), "", "não existe"},
+		{"pai é arquivo", filepath.Join(existing, "node.img"), "", "não existe"},
+		{"a própria ISO", iso, iso, "ISO de origem"},
+	}
+	if runtime.GOOS != "windows" {
+		tests = append(tests, struct{ name, path, iso, err string }{"dispositivo", "/dev/null", "", "não é um arquivo comum"})
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateImagePath(tt.path, tt.iso)
+			switch {
+			case tt.err == "" && err != nil:
+				t.Errorf("erro inesperado: %v", err)
+			case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+				t.Errorf("erro = %v, esperado %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestWriteImage(t *testing.T) {
+	dir := t.TempDir()
+	seed := filepath.Join(dir, "user-data")
+	if err := os.WriteFile(seed, []byte("#cloud-config\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cidata := coreusb.PartitionSpec{Label: "CIDATA", SizeMiB: cidataSizeMiB, Sources: []string{seed}}
+
+	// raw e qcow2 gravam o plano no arquivo raw de cada formato
+	for _, format := range []string{imageFormatRaw, imageFormatQCOW2} {
+		imagePath := filepath.Join(dir, "node."+format)
+		rawPath := rawImagePath(imagePath, format, filepath.Join(dir, "work"))
+		dry := coreusb.NewDryRun()
+		result, err := writeImage(dry, rawPath, &coreusb.Plan{Partitions: []coreusb.PartitionSpec{cidata}})
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if result.Device != rawPath {
+			t.Errorf("%s: gravado em %s, esperado %s", format, result.Device, rawPath)
+		}
+		var added string
+		for _, op := range dry.Ops {
+			if op.Name == "add-partition" {
+				added = strings.Join(op.Args, " ")
+			}
+		}
+		if added != rawPath+" CIDATA 128" {
+			t.Errorf("%s: add-partition %q", format, added)
+		}
+	}
+
+	// Sem tamanho, a partição seria do tamanho restante de um arquivo vazio
+	dry := coreusb.NewDryRun()
+	plan := &coreusb.Plan{Partitions: []coreusb.PartitionSpec{cidata, {Label: "OFFLINE", Sources: []string{seed}}}}
+	if _, err := writeImage(dry, filepath.Join(dir, "node.img"), plan); err == nil || !strings.Contains(err.Error(), "OFFLINE sem tamanho") {
+		t.Errorf("erro = %v", err)
+	}
+	if len(dry.Ops) != 0 {
+		t.Errorf("operações executadas: %v", dry.Names())
+	}
+
+	// Uma falha do backend é devolvida como erro da imagem
+	dry = coreusb.NewDryRun()
+	dry.Fail = "add-partition"
+	if _, err := writeImage(dry, filepath.Join(dir, "node.img"), &coreusb.Plan{Partitions: []coreusb.PartitionSpec{cidata}}); err == nil ||
+		!strings.Contains(err.Error(), "erro ao criar imagem") {
+		t.Errorf("erro = %v", err)
+	}
+}
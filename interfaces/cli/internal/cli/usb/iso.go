@@ -0,0 +1,282 @@
+package usb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"syntropy-cc/cooperative-grid/core/iso"
+)
+
+// isoSettings guarda as opções de verificação de ISO, comuns a todos os
+// subcomandos usb
+var isoSettings struct {
+	keyring       string
+	allowUnsigned bool
+}
+
+// defaultKeyringPath é onde a chave de assinatura das imagens Ubuntu é
+// procurada. Para criá-la:
+//
+//	gpg --keyserver hkp://keyserver.ubuntu.com --recv-keys 843938DF228D22F7B3742BC0D94AA3F0EFE21092
+//	gpg --export 843938DF228D22F7B3742BC0D94AA3F0EFE21092 > ~/.syntropy/keys/ubuntu-cdimage-keyring.gpg
+func defaultKeyringPath() string {
+	homeDir, _ := os.UserHomeDir()
+	return filepath.Join(homeDir, ".syntropy", "keys", "ubuntu-cdimage-keyring.gpg")
+}
+
+// addISOFlags registra as opções de verificação no comando usb
+func addISOFlags(cmd *cobra.Command) {
+	cmd.PersistentFlags().StringVar(&isoSettings.keyring, "iso-keyring", "",
+		"Keyring GPG para verificar SHA256SUMS (padrão: $SYNTROPY_ISO_KEYRING ou ~/.syntropy/keys/ubuntu-cdimage-keyring.gpg)")
+	cmd.PersistentFlags().BoolVar(&isoSettings.allowUnsigned, "allow-unsigned-iso", false,
+		"Aceitar ISOs verificadas apenas pelo SHA256SUMS, sem assinatura GPG")
+}
+
+// newISOManager cria o gerenciador do cache de ISOs em cacheDir/iso
+func newISOManager(cacheDir string) (*iso.Manager, error) {
+	paths := []string{defaultKeyringPath()}
+	if env := os.Getenv("SYNTROPY_ISO_KEYRING"); env != "" {
+		paths = []string{env}
+	}
+	if isoSettings.keyring != "" {
+		paths = []string{isoSettings.keyring}
+	}
+
+	var verifier iso.SignatureVerifier
+	v, path, err := iso.LoadGPGVerifier(paths...)
+	switch {
+	case err == nil:
+		verifier = v
+		fmt.Printf("🔑 Keyring: %s\n", path)
+	case errors.Is(err, os.ErrNotExist) && isoSettings.allowUnsigned:
+		fmt.Println("⚠️  Nenhum keyring encontrado: a ISO será verificada apenas pelo SHA256SUMS")
+	case errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("keyring GPG não encontrado em %s; exporte a chave de assinatura do Ubuntu "+
+			"(veja 'syntropy usb iso --help') ou use --allow-unsigned-iso", strings.Join(paths, ", "))
+	default:
+		return nil, err
+	}
+
+	m := iso.NewManager(isoCacheDir(cacheDir), verifier)
+	m.SetAllowUnsigned(isoSettings.allowUnsigned)
+	m.SetProgress(printISOProgress())
+	return m, nil
+}
+
+// printISOProgress mostra o andamento do download e da verificação na
+// mesma linha do terminal
+func printISOProgress() iso.ProgressFunc {
+	var last time.Time
+	return func(p iso.Progress) {
+		if p.Stage == iso.StageManifest {
+			fmt.Printf("   Baixando %s\n", p.File)
+			return
+		}
+		finished := p.Total > 0 && p.Done == p.Total
+		if !finished && time.Since(last) < 500*time.Millisecond {
+			return
+		}
+		last = time.Now()
+
+		label := "📥 Baixando"
+		if p.Stage == iso.StageVerify {
+			label = "🔍 Verificando"
+		}
+		if p.Total > 0 {
+			fmt.Printf("\r   %s: %s / %s (%.0f%%)   ", label, formatSize(p.Done), formatSize(p.Total),
+				float64(p.Done)*100/float64(p.Total))
+		} else {
+			fmt.Printf("\r   %s: %s   ", label, formatSize(p.Done))
+		}
+		if finished {
+			fmt.Println()
+		}
+	}
+}
+
+// acquireISO obtém uma ISO verificada, tentando as versões da mais recente
+// para a mais antiga. Sem versão, a primeira disponível é usada.
+func acquireISO(m *iso.Manager, version string) (*iso.Entry, error) {
+	releases := iso.DefaultReleases
+	if version != "" {
+		r, ok := iso.FindRelease(version)
+		if !ok {
+			return nil, fmt.Errorf("versão de ISO desconhecida: %s", version)
+		}
+		releases = []iso.Release{r}
+	}
+
+	ctx := context.Background()
+	fmt.Println("🔍 Verificando cache de ISOs...")
+	for _, r := range releases {
+		e, err := m.Cached(ctx, r.Version)
+		if err != nil {
+			return nil, err
+		}
+		if e != nil {
+			fmt.Printf("✅ ISO encontrada no cache: %s\n", r.Name)
+			fmt.Printf("   Arquivo: %s\n", e.Path)
+			return e, nil
+		}
+	}
+
+	fmt.Println("\n📥 Nenhuma ISO válida no cache. Iniciando download...")
+	fmt.Println("   Cache: " + m.Dir())
+	var lastErr error
+	for _, r := range releases {
+		fmt.Printf("\n🌐 Tentando baixar: %s\n", r.Name)
+		e, err := m.Acquire(ctx, r)
+		if err != nil {
+			fmt.Printf("\n   ❌ %v\n", err)
+			lastErr = err
+			continue
+		}
+		fmt.Printf("✅ ISO baixada e verificada: %s\n", e.Filename)
+		fmt.Printf("   SHA256: %s\n", e.SHA256)
+		if e.Signer != "" {
+			fmt.Printf("   Assinada por: %s\n", e.Signer)
+		}
+		return e, nil
+	}
+	return nil, lastErr
+}
+
+// newUSBISOCommand cria o grupo de comandos do cache de ISOs
+func newUSBISOCommand() *cobra.Command {
+	var cacheDir string
+
+	cmd := &cobra.Command{
+		Use:   "iso",
+		Short: "Gerencia o cache de ISOs Ubuntu",
+		Long: `Gerencia o cache de ISOs Ubuntu usadas pelo 'usb create'.
+
+As ISOs são baixadas com retomada de downloads interrompidos e verificadas
+contra o SHA256SUMS publicado pelo Ubuntu, cuja assinatura GPG é conferida
+com o keyring local. Para criar o keyring:
+
+  gpg --keyserver hkp://keyserver.ubuntu.com --recv-keys 843938DF228D22F7B3742BC0D94AA3F0EFE21092
+  gpg --export 843938DF228D22F7B3742BC0D94AA3F0EFE21092 > ~/.syntropy/keys/ubuntu-cdimage-keyring.gpg
+
+Exemplos:
+  syntropy usb iso list
+  syntropy usb iso download --version 24.04
+  syntropy usb iso verify 24.04
+  syntropy usb iso prune --max-size 5
+`,
+	}
+	cmd.PersistentFlags().StringVar(&cacheDir, "cache-dir", "", "Diretório de cache (padrão: ~/.syntropy/cache)")
+
+	var format string
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lista as ISOs em cache",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m := openISOCache(cacheDir)
+			entries, err := m.List()
+			if err != nil {
+				return err
+			}
+			if format == "json" {
+				data, err := json.MarshalIndent(entries, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+			if len(entries) == 0 {
+				fmt.Println("Nenhuma ISO em cache.")
+				return nil
+			}
+			fmt.Printf("%-8s %-40s %-10s %-8s %-20s\n", "VERSÃO", "ARQUIVO", "TAMANHO", "ASSINADA", "ÚLTIMO USO")
+			fmt.Println(strings.Repeat("-", 90))
+			for _, e := range entries {
+				signed := "sim"
+				if e.Signer == "" {
+					signed = "não"
+				}
+				fmt.Printf("%-8s %-40s %-10s %-8s %-20s\n", e.Version, e.Filename, formatSize(e.Size), signed,
+					e.LastUsed.Local().Format("2006-01-02 15:04:05"))
+			}
+			return nil
+		},
+	}
+	listCmd.Flags().StringVarP(&format, "format", "f", "table", "Formato de saída (table, json)")
+
+	var version string
+	downloadCmd := &cobra.Command{
+		Use:   "download",
+		Short: "Baixa e verifica uma ISO",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m, err := newISOManager(cacheDir)
+			if err != nil {
+				return err
+			}
+			_, err = acquireISO(m, version)
+			return err
+		},
+	}
+	downloadCmd.Flags().StringVar(&version, "version", "", "Versão do Ubuntu (24.04, 22.04, 20.04)")
+
+	verifyCmd := &cobra.Command{
+		Use:   "verify <versão>",
+		Short: "Confere o SHA256 de uma ISO em cache",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m := openISOCache(cacheDir)
+			e, err := m.Verify(context.Background(), args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Printf("\n✅ %s íntegra (SHA256 %s)\n", e.Filename, e.SHA256)
+			return nil
+		},
+	}
+
+	var maxSize float64
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove as ISOs usadas há mais tempo até o cache caber no limite",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			m := openISOCache(cacheDir)
+			removed, err := m.Evict(int64(maxSize * (1 << 30)))
+			if err != nil {
+				return err
+			}
+			for _, e := range removed {
+				fmt.Printf("🗑️  Removida: %s (%s)\n", e.Filename, formatSize(e.Size))
+			}
+			fmt.Printf("✅ %d ISO(s) removida(s)\n", len(removed))
+			return nil
+		},
+	}
+	pruneCmd.Flags().Float64Var(&maxSize, "max-size", 0, "Tamanho máximo do cache em GB (0 remove todas)")
+
+	cmd.AddCommand(listCmd, downloadCmd, verifyCmd, pruneCmd)
+	return cmd
+}
+
+// openISOCache abre o cache para operações que não baixam nada e por isso
+// dispensam o keyring
+func openISOCache(cacheDir string) *iso.Manager {
+	m := iso.NewManager(isoCacheDir(cacheDir), nil)
+	m.SetProgress(printISOProgress())
+	return m
+}
+
+// isoCacheDir retorna o diretório das ISOs dentro do cache
+func isoCacheDir(cacheDir string) string {
+	if cacheDir == "" {
+		homeDir, _ := os.UserHomeDir()
+		cacheDir = filepath.Join(homeDir, ".syntropy", "cache")
+	}
+	return filepath.Join(cacheDir, "iso")
+}
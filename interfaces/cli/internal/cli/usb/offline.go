@@ -0,0 +1,249 @@
+package usb
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"syntropy-cc/cooperative-grid/core/bundle"
+	"syntropy-cc/cooperative-grid/infrastructure"
+)
+
+const (
+	// offlineLabel é o rótulo da partição do pacote offline, montada pelo
+	// cloud-init no primeiro boot
+	offlineLabel = "SYNOFFLINE"
+	// offlineRelease e offlineArch são a versão e a arquitetura do Ubuntu
+	// instalado pela ISO, das quais os .deb são baixados
+	offlineRelease = "noble"
+	offlineArch    = "amd64"
+	offlineImage   = "ubuntu:24.04"
+	// offlineMarginMiB é a folga da partição para a FAT e arredondamentos
+	offlineMarginMiB = 64
+)
+
+var (
+	// offlineMu serializa a montagem dos pacotes, que compartilham o cache
+	offlineMu sync.Mutex
+	// offlineVerified lembra os pacotes já conferidos nesta execução
+	offlineVerified = map[string]bool{}
+)
+
+// prepareOfflineBundle obtém o pacote offline do perfil do nó, montando-o
+// ou reutilizando um do cache, e o associa à configuração. Sem --offline
+// não faz nada.
+func prepareOfflineBundle(config *Config, cacheDir string) error {
+	if !config.Offline || config.OfflineBundle != "" {
+		return nil
+	}
+
+	tm := infrastructure.NewTemplateManager(cloudInitTemplateDir(config.TemplateDir))
+	profile, err := tm.LoadProfile(config.Profile)
+	if err != nil {
+		return err
+	}
+
+	dir, err := ensureOfflineBundle(profile, cacheDir)
+	if err != nil {
+		return fmt.Errorf("erro ao preparar pacote offline: %w", err)
+	}
+	config.OfflineBundle = dir
+	return nil
+}
+
+// ensureOfflineBundle retorna o diretório do pacote offline do perfil em
+// <cacheDir>/offline, montando-o se não existir ou estiver corrompido
+func ensureOfflineBundle(profile *infrastructure.CloudInitProfile, cacheDir string) (string, error) {
+	offlineMu.Lock()
+	defer offlineMu.Unlock()
+
+	key := bundle.Key(offlineRelease, offlineArch, profile.Packages, profile.Images, profile.AgentURL)
+	dir := filepath.Join(cacheDir, "offline", key)
+	if offlineVerified[dir] {
+		return dir, nil
+	}
+
+	if _, err := os.Stat(dir); err == nil {
+		fmt.Printf("🔍 Verificando pacote offline em cache (%s)...\n", key)
+		_, err := bundle.Verify(dir)
+		if err == nil {
+			offlineVerified[dir] = true
+			fmt.Println("✅ Pacote offline em cache íntegro")
+			return dir, nil
+		}
+		fmt.Printf("⚠️  Pacote offline em cache inválido, recriando: %v\n", err)
+		if err := os.RemoveAll(dir); err != nil {
+			return "", err
+		}
+	}
+
+	if err := checkOfflineTools(); err != nil {
+		return "", err
+	}
+
+	fmt.Printf("📦 Montando pacote offline do perfil %s...\n", profile.Name)
+	tmpDir := dir + ".tmp"
+	os.RemoveAll(tmpDir)
+	if err := buildOfflineBundle(tmpDir, profile); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", err
+	}
+	if err := os.Rename(tmpDir, dir); err != nil {
+		os.RemoveAll(tmpDir)
+		return "", err
+	}
+
+	offlineVerified[dir] = true
+	fmt.Printf("✅ Pacote offline criado: %s\n", dir)
+	return dir, nil
+}
+
+// checkOfflineTools verifica se o Docker, usado para baixar os .deb e as
+// imagens, está disponível
+func checkOfflineTools() error {
+	if _, err := exec.LookPath("docker"); err != nil {
+		return fmt.Errorf("docker não encontrado: necessário para montar o pacote offline")
+	}
+	if err := exec.Command("docker", "info").Run(); err != nil {
+		return fmt.Errorf("docker não está acessível (o daemon está rodando?): %w", err)
+	}
+	return nil
+}
+
+// buildOfflineBundle baixa pacotes, imagens e agente para dir e sela o
+// pacote com o manifesto
+func buildOfflineBundle(dir string, profile *infrastructure.CloudInitProfile) error {
+	for _, sub := range []string{bundle.AptDir, bundle.ImagesDir, bundle.AgentDir} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			return fmt.Errorf("erro ao criar diretório: %w", err)
+		}
+	}
+
+	if err := downloadOfflinePackages(filepath.Join(dir, bundle.AptDir), profile.Packages); err != nil {
+		return err
+	}
+	if err := saveOfflineImages(filepath.Join(dir, bundle.ImagesDir), profile.Images); err != nil {
+		return err
+	}
+	if err := downloadOfflineAgent(filepath.Join(dir, bundle.AgentDir, "syntropy-agent"), profile.AgentURL); err != nil {
+		return err
+	}
+
+	m := &bundle.Manifest{
+		Release:  offlineRelease,
+		Arch:     offlineArch,
+		Packages: profile.Packages,
+		Images:   profile.Images,
+		AgentURL: profile.AgentURL,
+	}
+	if err := bundle.Seal(dir, m); err != nil {
+		return fmt.Errorf("erro ao selar pacote offline: %w", err)
+	}
+	fmt.Printf("📋 Pacote offline: %d arquivos, %d MiB\n", len(m.Files), m.Size()>>20)
+	return nil
+}
+
+// downloadOfflinePackages baixa os pacotes e suas dependências em um
+// contêiner Ubuntu da mesma versão da ISO e gera o índice do repositório
+func downloadOfflinePackages(aptDir string, packages []string) error {
+	fmt.Printf("📥 Baixando %d pacotes e dependências (%s/%s)...\n", len(packages), offlineRelease, offlineArch)
+
+	// Os .deb são baixados antes de instalar o dpkg-dev, para que só as
+	// dependências dos pacotes do perfil entrem no repositório
+	script := strings.Join([]string{
+		"set -e",
+		"export DEBIAN_FRONTEND=noninteractive",
+		"apt-get update -qq",
+		"apt-get install -y -qq --download-only -o Dir::Cache::archives=/out/ " + strings.Join(packages, " "),
+		"rm -rf /out/partial /out/lock",
+		"apt-get install -y -qq --no-install-recommends dpkg-dev >/dev/null",
+		"cd /out && dpkg-scanpackages --multiversion . /dev/null > Packages 2>/dev/null && gzip -9 -k Packages",
+		fmt.Sprintf("chown -R %d:%d /out", os.Getuid(), os.Getgid()),
+	}, "\n")
+
+	if err := runCommandWithTimeout(60*time.Minute, "docker", "run", "--rm",
+		"--platform", "linux/"+offlineArch,
+		"-v", aptDir+":/out",
+		offlineImage, "sh", "-c", script); err != nil {
+		return fmt.Errorf("erro ao baixar pacotes: %w", err)
+	}
+	return nil
+}
+
+// saveOfflineImages baixa as imagens de contêiner e as exporta como tar
+func saveOfflineImages(imagesDir string, images []string) error {
+	for _, image := range images {
+		fmt.Printf("🐳 Exportando imagem %s...\n", image)
+		if err := runCommandWithTimeout(30*time.Minute, "docker", "pull", "--platform", "linux/"+offlineArch, image); err != nil {
+			return fmt.Errorf("erro ao baixar imagem %s: %w", image, err)
+		}
+		tarPath := filepath.Join(imagesDir, imageTarName(image))
+		if err := runCommandWithTimeout(30*time.Minute, "docker", "save", "-o", tarPath, image); err != nil {
+			return fmt.Errorf("erro ao exportar imagem %s: %w", image, err)
+		}
+	}
+	return nil
+}
+
+// imageTarName converte a referência da imagem em um nome de arquivo
+// válido na FAT32
+func imageTarName(image string) string {
+	return strings.NewReplacer("/", "_", ":", "_", "@", "_").Replace(image) + ".tar"
+}
+
+// downloadOfflineAgent baixa o binário do syntropy-agent
+func downloadOfflineAgent(path, url string) error {
+	fmt.Println("📥 Baixando syntropy-agent...")
+	client := &http.Client{Timeout: 10 * time.Minute}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("erro ao baixar agente: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("erro ao baixar agente: %s retornou %s", url, resp.Status)
+	}
+
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		return fmt.Errorf("erro ao baixar agente: %w", err)
+	}
+	return out.Close()
+}
+
+// offlinePartitionMiB calcula o tamanho da partição para o pacote em dir
+func offlinePartitionMiB(dir string) (int64, error) {
+	m, err := bundle.Load(dir)
+	if err != nil {
+		return 0, err
+	}
+	const mib = 1 << 20
+	size := m.Size()
+	return (size+size/10+mib-1)/mib + offlineMarginMiB, nil
+}
+
+// offlineEntries lista o conteúdo de topo do pacote, copiado para a raiz
+// da partição
+func offlineEntries(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, e := range entries {
+		paths = append(paths, filepath.Join(dir, e.Name()))
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
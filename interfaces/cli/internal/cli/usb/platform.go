@@ -3,9 +3,10 @@ package usb
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"runtime"
 	"strings"
+
+	coreusb "syntropy-cc/cooperative-grid/core/usb"
 )
 
 // detectPlatform detecta a plataforma atual (linux, windows, wsl)
@@ -29,114 +30,52 @@ func detectPlatform() string {
 	return "linux"
 }
 
-// ListDevices lista dispositivos USB disponíveis baseado na plataforma
+// ListDevices lista dispositivos USB disponíveis baseado na plataforma.
+// Discos que sustentam o sistema em execução nunca são listados.
 func ListDevices() ([]USBDevice, error) {
-	platform := detectPlatform()
-
-	switch platform {
-	case "windows":
-		return listDevicesWindows()
-	case "wsl":
-		return listDevicesWSL()
-	case "linux":
-		return listDevicesLinux()
-	default:
-		return nil, fmt.Errorf("plataforma não suportada: %s", platform)
-	}
-}
-
-// validateDevice valida se um dispositivo é seguro para usar
-func validateDevice(devicePath string) error {
-	platform := detectPlatform()
-
-	// Validação específica por plataforma
-	switch platform {
-	case "linux":
-		return validateDeviceLinux(devicePath)
-	case "wsl", "windows":
-		return validateDeviceWindows(devicePath)
-	default:
-		return fmt.Errorf("plataforma não suportada: %s", platform)
-	}
-}
-
-// validateDeviceLinux valida dispositivos no Linux
-func validateDeviceLinux(devicePath string) error {
-	// Verificar se o dispositivo existe
-	if _, err := os.Stat(devicePath); err != nil {
-		return fmt.Errorf("dispositivo não encontrado: %s", devicePath)
-	}
-
-	// Verificar se não é um dispositivo do sistema
-	if isSystemDeviceLinux(devicePath) {
-		return fmt.Errorf("dispositivo %s parece ser um dispositivo do sistema - operação cancelada por segurança", devicePath)
-	}
-
-	return nil
-}
-
-// validateDeviceWindows valida dispositivos no Windows/WSL
-func validateDeviceWindows(devicePath string) error {
-	// Extrair número do disco
-	var diskNum int
-	if strings.HasPrefix(devicePath, "PHYSICALDRIVE") {
-		fmt.Sscanf(devicePath, "PHYSICALDRIVE%d", &diskNum)
-	} else if strings.HasPrefix(devicePath, "\\\\.\\PHYSICALDRIVE") {
-		fmt.Sscanf(devicePath, "\\\\.\\PHYSICALDRIVE%d", &diskNum)
-	} else {
-		return fmt.Errorf("formato de dispositivo inválido para Windows: %s", devicePath)
-	}
-
-	// Verificar se não é um dispositivo do sistema usando PowerShell
-	if isSystemDeviceWindows(diskNum) {
-		return fmt.Errorf("dispositivo %s parece ser um dispositivo do sistema - operação cancelada por segurança", devicePath)
+	detected, err := coreusb.NewDetector().DetectDevices()
+	if err != nil {
+		return nil, err
 	}
 
-	return nil
-}
-
-// isSystemDeviceLinux verifica se um dispositivo é do sistema no Linux
-func isSystemDeviceLinux(devicePath string) bool {
-	// Verificar se contém partições do sistema
-	if strings.Contains(devicePath, "/dev/sda") || strings.Contains(devicePath, "/dev/nvme0n1") {
-		// Verificar se tem partições do sistema
-		cmd := exec.Command("lsblk", "-n", "-o", "MOUNTPOINT", devicePath)
-		output, err := cmd.Output()
-		if err == nil {
-			mountpoints := strings.Split(strings.TrimSpace(string(output)), "\n")
-			for _, mp := range mountpoints {
-				if mp == "/" || mp == "/boot" || mp == "/home" {
-					return true
-				}
-			}
+	devices := make([]USBDevice, 0, len(detected))
+	for _, d := range detected {
+		device := USBDevice{
+			Path:       d.Path,
+			ID:         d.ID,
+			Size:       d.Size,
+			SizeGB:     d.SizeGB,
+			Model:      d.Model,
+			Vendor:     d.Vendor,
+			Serial:     d.Serial,
+			Removable:  d.Removable,
+			Platform:   d.Platform,
+			DiskNumber: d.DiskNumber,
+		}
+		if d.Platform == "wsl" {
+			device.WindowsPath = fmt.Sprintf("\\\\.\\PHYSICALDRIVE%d", d.DiskNumber)
 		}
+		devices = append(devices, device)
 	}
-	return false
+	return devices, nil
 }
 
-// isSystemDeviceWindows verifica se um dispositivo é do sistema no Windows
-func isSystemDeviceWindows(diskNum int) bool {
-	// Usar PowerShell para verificar se é disco do sistema
-	psScript := fmt.Sprintf(`
-$disk = Get-Disk -Number %d -ErrorAction SilentlyContinue
-if ($disk) {
-    $isSystem = $disk.IsSystem -or $disk.IsBoot -or $disk.IsOffline
-    $partitions = Get-Partition -DiskNumber %d -ErrorAction SilentlyContinue
-    foreach ($part in $partitions) {
-        if ($part.IsSystem -or $part.IsBoot -or $part.DriveLetter -eq "C") {
-            $isSystem = $true
-            break
-        }
-    }
-    if ($isSystem) { exit 1 } else { exit 0 }
-} else {
-    exit 1
+// validateDevice recusa partições e discos que sustentam a raiz, o boot ou
+// a swap do sistema e retorna a identidade do disco. No Linux, aceita
+// também links de /dev/disk/by-id; Identity.Device é o caminho a gravar.
+func validateDevice(devicePath string) (*coreusb.Identity, error) {
+	detector := coreusb.NewDetector()
+	if err := detector.ValidateDevice(devicePath); err != nil {
+		return nil, err
+	}
+	return detector.Identify(devicePath)
 }
-`, diskNum, diskNum)
 
-	cmd := exec.Command("powershell.exe", "-NoProfile", "-Command", psScript)
-	err := cmd.Run()
-	return err != nil // Se houver erro, é dispositivo do sistema
+// guardDevice confere, imediatamente antes da gravação, se o dispositivo
+// ainda é o disco validado: se o USB foi trocado nesse intervalo, o
+// caminho pode apontar para outro disco
+func guardDevice(engine *coreusb.Engine, identity *coreusb.Identity) {
+	engine.SetGuard(coreusb.IdentityGuard(coreusb.NewDetector(), identity))
 }
 
 // formatUSB formata um dispositivo USB
@@ -144,13 +83,14 @@ func formatUSB(devicePath, label string, force bool) error {
 	platform := detectPlatform()
 
 	// Validar dispositivo
-	if err := validateDevice(devicePath); err != nil {
+	identity, err := validateDevice(devicePath)
+	if err != nil {
 		return err
 	}
 
 	// Confirmação do usuário
 	if !force {
-		fmt.Printf("⚠️  ATENÇÃO: Esta operação apagará TODOS os dados em %s!\n", devicePath)
+		fmt.Printf("⚠️  ATENÇÃO: Esta operação apagará TODOS os dados em %s: %s!\n", identity.Device, identity)
 		fmt.Print("Tem certeza que deseja continuar? (y/N): ")
 
 		var response string
@@ -161,16 +101,20 @@ func formatUSB(devicePath, label string, force bool) error {
 		}
 	}
 
-	fmt.Printf("🔧 Formatando dispositivo %s...\n", devicePath)
+	backend, err := newBackend(platform)
+	if err != nil {
+		return err
+	}
 
-	switch platform {
-	case "wsl":
-		return formatUSBWSL(devicePath, label)
-	case "windows":
-		return formatUSBWindows(devicePath, label)
-	default:
-		return formatUSBLinux(devicePath, label)
+	fmt.Printf("🔧 Formatando dispositivo %s...\n", identity.Device)
+	engine := newEngine(backend)
+	guardDevice(engine, identity)
+	if err := engine.FormatDevice(identity.Device, strings.ToUpper(label)); err != nil {
+		return fmt.Errorf("erro ao formatar dispositivo: %w", err)
 	}
+
+	fmt.Printf("✅ Dispositivo %s formatado com sucesso!\n", devicePath)
+	return nil
 }
 
 // listUSBDevices lista dispositivos USB e formata a saída
@@ -232,7 +176,7 @@ func SelectDevice() (*USBDevice, error) {
 	fmt.Println("\n🔍 Dispositivos USB detectados:")
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	for i, device := range devices {
-		fmt.Printf("[%d] %s - %s (%s)\n", i+1, device.Path, device.Model, device.Size)
+		fmt.Printf("[%d] %s - %s (%s) %s\n", i+1, device.Path, device.Model, device.Size, device.ID)
 	}
 
 	fmt.Printf("\nSelecione o dispositivo (1-%d): ", len(devices))
@@ -0,0 +1,86 @@
+package usb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"syntropy-cc/cooperative-grid/infrastructure"
+)
+
+// newUSBTemplatesCommand cria o grupo de comandos de templates do cloud-init
+func newUSBTemplatesCommand() *cobra.Command {
+	var templateDir string
+
+	cmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Gerencia templates e perfis do cloud-init",
+		Long: `Gerencia os templates e perfis usados para gerar o cloud-init dos nós.
+
+Arquivos em ~/.syntropy/templates/cloud-init (ou em --template-dir)
+sobrescrevem os templates padrão de mesmo nome:
+
+  user-data-template.yaml
+  meta-data-template.yaml
+  network-config-template.yaml
+  profiles/<nome>.yaml
+
+Perfis definem locale, timezone, pacotes, regras de firewall e papel do
+nó, herdando do perfil default o que não definem.
+`,
+	}
+
+	cmd.PersistentFlags().StringVar(&templateDir, "template-dir", "", "Diretório de templates (padrão: ~/.syntropy/templates/cloud-init)")
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "export",
+		Short: "Copia os templates padrão para o diretório de templates",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := cloudInitTemplateDir(templateDir)
+			written, err := infrastructure.NewTemplateManager(dir).ExportDefaults(dir)
+			if err != nil {
+				return fmt.Errorf("erro ao exportar templates: %w", err)
+			}
+			if len(written) == 0 {
+				fmt.Printf("Templates já presentes em %s\n", dir)
+				return nil
+			}
+			for _, path := range written {
+				fmt.Printf("✅ %s\n", path)
+			}
+			return nil
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "profiles",
+		Short: "Lista os perfis disponíveis",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tm := infrastructure.NewTemplateManager(cloudInitTemplateDir(templateDir))
+			names, err := tm.Profiles()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("%-16s %-14s %-22s %-10s %-9s %s\n", "PERFIL", "LOCALE", "TIMEZONE", "PAPEL", "PACOTES", "FIREWALL")
+			fmt.Println(strings.Repeat("-", 90))
+			for _, name := range names {
+				p, err := tm.LoadProfile(name)
+				if err != nil {
+					fmt.Printf("%-16s ❌ %v\n", name, err)
+					continue
+				}
+				rules := make([]string, len(p.Firewall))
+				for i, r := range p.Firewall {
+					rules[i] = r.Spec()
+				}
+				fmt.Printf("%-16s %-14s %-22s %-10s %-9d %s\n",
+					p.Name, p.Locale, p.Timezone, p.Role, len(p.Packages), strings.Join(rules, ","))
+			}
+			return nil
+		},
+	})
+
+	return cmd
+}
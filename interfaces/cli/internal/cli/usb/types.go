@@ -1,8 +1,14 @@
 package usb
 
+import (
+	"syntropy-cc/cooperative-grid/core/ca"
+	"syntropy-cc/cooperative-grid/infrastructure"
+)
+
 // USBDevice representa um dispositivo USB detectado
 type USBDevice struct {
 	Path        string `json:"path"`
+	ID          string `json:"id,omitempty"`
 	Size        string `json:"size"`
 	SizeGB      int    `json:"size_gb"`
 	Model       string `json:"model"`
@@ -26,58 +32,46 @@ type Config struct {
 	SSHPublicKey    string `json:"ssh_public_key"`
 	SSHPrivateKey   string `json:"ssh_private_key"`
 	CreatedBy       string `json:"created_by"`
+	// Role e Network são opcionais; vazios mantêm os padrões do cloud-init
+	Role    string          `json:"role,omitempty"`
+	Network NetworkSettings `json:"network"`
+	// Profile e TemplateDir escolhem o perfil e os templates do cloud-init
+	Profile     string `json:"profile,omitempty"`
+	TemplateDir string `json:"template_dir,omitempty"`
+	// Offline grava pacotes, imagens e agente em uma partição extra, para
+	// nós sem acesso à rede; OfflineBundle é o diretório do pacote montado
+	Offline       bool   `json:"offline,omitempty"`
+	OfflineBundle string `json:"-"`
+	// Authority é a CA da grade já aberta, compartilhada pelos nós de um
+	// manifesto; sem ela a CA é aberta na emissão do certificado
+	Authority *ca.Authority `json:"-"`
+}
+
+// NetworkSettings sobrescreve a configuração de rede gerada para o nó
+type NetworkSettings struct {
+	Interface   string `json:"interface,omitempty" yaml:"interface"`
+	Gateway     string `json:"gateway,omitempty" yaml:"gateway"`
+	IPSuffix    string `json:"ip_suffix,omitempty" yaml:"ip_suffix"`
+	MeshGateway string `json:"mesh_gateway,omitempty" yaml:"mesh_gateway"`
+	MgmtGateway string `json:"mgmt_gateway,omitempty" yaml:"mgmt_gateway"`
+	HTTPProxy   string `json:"http_proxy,omitempty" yaml:"http_proxy"`
+	HTTPSProxy  string `json:"https_proxy,omitempty" yaml:"https_proxy"`
 }
 
-// CloudInitConfig representa a configuração do cloud-init
+// CloudInitConfig representa a configuração do cloud-init de um nó
 type CloudInitConfig struct {
-	NodeName         string
-	NodeDescription  string
-	Coordinates      string
-	OwnerKey         string
-	DiscoveryServer  string
-	SSHPublicKey     string
-	CreatedBy        string
-	CreatedAt        string
-	InstanceID       string
-	Interface        string
-	Gateway          string
-	NodeIPSuffix     string
-	PrimaryInterface string
-	MeshGateway      string
-	MgmtGateway      string
-	HTTPProxy        string
-	HTTPSProxy       string
-	NodeType         string
-	HardwareType     string
-	CPUCores         int
-	MemoryGB         int
-	StorageGB        int
-	InitialRole      string
-	CanBeLeader      bool
-	CanBeWorker      bool
-	NodeCertPath     string
-	NodeKeyPath      string
-	CACertPath       string
-	// Certificados PEM para write_files
-	CACertPEM   string
-	NodeCertPEM string
-	NodeKeyPEM  string
+	infrastructure.CloudInitData
+	// TemplateDir contém templates e perfis que sobrescrevem os padrões
+	TemplateDir string
 }
 
-// Certificates representa os certificados TLS gerados
+// Certificates representa os certificados TLS emitidos para o nó
 type Certificates struct {
-	CAKey    []byte
+	// CACert é a raiz da CA da grade; NodeCert inclui intermediárias
 	CACert   []byte
 	NodeKey  []byte
 	NodeCert []byte
-}
-
-// WindowsDisk estrutura para parse do JSON do PowerShell
-type WindowsDisk struct {
-	Number       int    `json:"Number"`
-	FriendlyName string `json:"FriendlyName"`
-	Size         int64  `json:"Size"`
-	SerialNumber string `json:"SerialNumber"`
-	BusType      string `json:"BusType"`
-	Model        string `json:"Model"`
+	Serial   string
+	// IPSuffix é o sufixo do IP da mesh gravado no certificado
+	IPSuffix string
 }
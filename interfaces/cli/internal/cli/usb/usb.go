@@ -6,8 +6,11 @@ package usb
 // - types.go: Estruturas de dados
 // - commands.go: Comandos CLI e função createUSB
 // - platform.go: Detecção de plataforma e funções comuns
-// - linux.go: Implementações específicas do Linux
-// - windows.go: Implementações específicas do Windows/WSL
+// - engine.go: Backend por plataforma e plano NoCloud do engine de core/usb
+// - verify.go: Comando verify e verificações do seed NoCloud
+// - linux.go: Listagem de dispositivos no Linux
+// - windows.go: Listagem e diagnóstico no Windows/WSL
 // - certificates.go: Geração de certificados TLS e chaves SSH
 // - cloudinit.go: Configuração do cloud-init
+// - iso.go: Download verificado e cache de ISOs Ubuntu
 // - utils.go: Funções auxiliares e formatação
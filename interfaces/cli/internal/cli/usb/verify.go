@@ -0,0 +1,228 @@
+package usb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"syntropy-cc/cooperative-grid/core/ca"
+	coreusb "syntropy-cc/cooperative-grid/core/usb"
+	"syntropy-cc/cooperative-grid/infrastructure"
+)
+
+// nodeCertPath é onde o user-data grava o certificado do nó
+const nodeCertPath = "/opt/syntropy/certs/node.crt"
+
+// newUSBVerifyCommand cria o comando para conferir uma mídia gravada
+func newUSBVerifyCommand() *cobra.Command {
+	var (
+		isoPath string
+		workDir string
+		format  string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "verify [device|image]",
+		Short: "Confere se um USB ou imagem gravada está pronta para o boot",
+		Long: `Relê um USB ou uma imagem gerada por "usb create" e confere:
+
+  • o menu do GRUB da ISO e o kernel e initrd de cada entrada
+  • o conteúdo gravado contra a ISO Ubuntu (com --iso)
+  • os arquivos da partição CIDATA contra o seed gerado (com --work-dir)
+  • a sintaxe do user-data e do meta-data do NoCloud
+  • se o certificado do nó no user-data foi emitido pela CA da grade
+
+Nada é gravado na mídia. O comando termina com erro se alguma verificação
+falhar.
+
+Exemplos:
+  # Conferir um USB (Linux)
+  sudo syntropy usb verify /dev/sdb --iso ~/.syntropy/cache/iso/ubuntu-24.04.3-live-server-amd64.iso
+
+  # Conferir um USB (Windows/WSL)
+  syntropy usb verify PHYSICALDRIVE1
+
+  # Conferir uma imagem contra o seed gerado na criação
+  syntropy usb verify node-01.qcow2 --work-dir ~/.syntropy/work/usb-20250101-120000
+
+  # Relatório em JSON
+  syntropy usb verify node-01.img --format json
+`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return verifyUSB(args[0], isoPath, workDir, format)
+		},
+	}
+
+	cmd.Flags().StringVar(&isoPath, "iso", "", "ISO Ubuntu gravada na mídia, para comparar o conteúdo")
+	cmd.Flags().StringVar(&workDir, "work-dir", "", "Diretório de trabalho da criação, para comparar o seed")
+	cmd.Flags().StringVarP(&format, "format", "f", "table", "Formato de saída (table, json)")
+
+	return cmd
+}
+
+// verifyUSB confere um dispositivo ou arquivo de imagem e exibe o relatório
+func verifyUSB(target, isoPath, workDir, format string) error {
+	if format != "table" && format != "json" {
+		return fmt.Errorf("formato inválido: %s (use table ou json)", format)
+	}
+
+	backend, device, cleanup, err := verifyBackend(target)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	plan := &coreusb.Plan{
+		Image:      isoPath,
+		Partitions: []coreusb.PartitionSpec{{Label: "CIDATA"}},
+		Checks:     seedChecks(),
+	}
+	if workDir != "" {
+		for _, name := range []string{"user-data", "meta-data", "network-config"} {
+			plan.Partitions[0].Sources = append(plan.Partitions[0].Sources, filepath.Join(workDir, "cloud-init", name))
+		}
+	}
+
+	engine := coreusb.NewEngine(backend)
+	if format == "table" {
+		fmt.Printf("🔍 Conferindo %s...\n", target)
+		engine = newEngine(backend)
+	}
+	report, err := engine.Verify(device, plan)
+	if err != nil {
+		return err
+	}
+	report.Device = target
+
+	if format == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Println()
+		printReport(report)
+		if isoPath == "" {
+			fmt.Println("ℹ️  Use --iso para comparar o conteúdo com a ISO Ubuntu")
+		}
+	}
+
+	if failed := report.Failed(); len(failed) > 0 {
+		return fmt.Errorf("mídia reprovada: %d verificação(ões) falharam", len(failed))
+	}
+	if format == "table" {
+		fmt.Println("✅ Mídia pronta para o boot")
+	}
+	return nil
+}
+
+// verifyBackend escolhe o backend para o alvo: arquivos são lidos como
+// imagem (qcow2 é convertido antes) e os demais como dispositivo
+func verifyBackend(target string) (coreusb.Backend, string, func(), error) {
+	info, err := os.Stat(target)
+	if err != nil || !info.Mode().IsRegular() {
+		identity, err := validateDevice(target)
+		if err != nil {
+			return nil, "", nil, err
+		}
+		backend, err := newBackend(detectPlatform())
+		return backend, identity.Device, func() {}, err
+	}
+
+	format, err := resolveImageFormat(target, "")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if err := checkImageTools(format); err != nil {
+		return nil, "", nil, err
+	}
+	workDir, err := os.MkdirTemp("", "syntropy-verify-")
+	if err != nil {
+		return nil, "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(workDir) }
+
+	rawPath := target
+	if format == imageFormatQCOW2 {
+		rawPath = filepath.Join(workDir, "disk.img")
+		if err := runCommandWithTimeout(30*time.Minute, "qemu-img", "convert", "-f", "qcow2", "-O", "raw", target, rawPath); err != nil {
+			cleanup()
+			return nil, "", nil, fmt.Errorf("erro ao converter imagem: %w", err)
+		}
+	}
+	return coreusb.NewImageBackend(workDir), rawPath, cleanup, nil
+}
+
+// seedChecks conferem o seed NoCloud lido da partição CIDATA
+func seedChecks() []coreusb.FileCheck {
+	return []coreusb.FileCheck{
+		{Name: "user-data", Partition: "CIDATA", File: "user-data", Check: cloudInitCheck("user-data")},
+		{Name: "meta-data", Partition: "CIDATA", File: "meta-data", Check: cloudInitCheck("meta-data")},
+		{Name: "certificate", Partition: "CIDATA", File: "user-data", Check: checkNodeCertificate},
+	}
+}
+
+// cloudInitCheck valida o arquivo contra o esquema do cloud-init
+func cloudInitCheck(name string) func([]byte) (string, error) {
+	return func(data []byte) (string, error) {
+		if err := infrastructure.ValidateCloudInitFile(name, string(data)); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d bytes, esquema válido", len(data)), nil
+	}
+}
+
+// checkNodeCertificate confere se o certificado do nó gravado pelo
+// user-data foi emitido pela CA da grade e não foi revogado
+func checkNodeCertificate(userData []byte) (string, error) {
+	var doc struct {
+		WriteFiles []struct {
+			Path    string `yaml:"path"`
+			Content string `yaml:"content"`
+		} `yaml:"write_files"`
+	}
+	if err := yaml.Unmarshal(userData, &doc); err != nil {
+		return "", fmt.Errorf("user-data inválido: %w", err)
+	}
+
+	var certPEM string
+	for _, f := range doc.WriteFiles {
+		if f.Path == nodeCertPath {
+			certPEM = f.Content
+		}
+	}
+	if certPEM == "" {
+		return "", fmt.Errorf("%s ausente no user-data", nodeCertPath)
+	}
+
+	if !ca.Exists(gridCADir()) {
+		return "", fmt.Errorf("CA da grade não encontrada em %s", gridCADir())
+	}
+	authority, err := ca.Open(gridCADir())
+	if err != nil {
+		return "", err
+	}
+	rec, err := authority.Verify([]byte(certPEM))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s (serial %s), válido até %s", rec.CommonName, rec.Serial, rec.NotAfter.Format("2006-01-02")), nil
+}
+
+// printReport exibe o resultado de cada verificação
+func printReport(report *coreusb.Report) {
+	for _, c := range report.Checks {
+		icon := "✅"
+		if !c.Passed {
+			icon = "❌"
+		}
+		fmt.Printf("   %s %-12s %s\n", icon, c.Name, c.Detail)
+	}
+}
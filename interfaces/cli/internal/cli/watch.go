@@ -0,0 +1,322 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWatchInterval é o intervalo padrão entre duas rodadas do --watch
+	defaultWatchInterval = 5 * time.Second
+	// watchWorkers limita quantos nós são testados ao mesmo tempo
+	watchWorkers = 32
+	// Uma latência é um pico quando passa de latencySpikeFactor vezes a
+	// média recente do nó e a diferença é de pelo menos latencySpikeMin
+	latencySpikeFactor = 3
+	latencySpikeMin    = 50 * time.Millisecond
+)
+
+// Tipos de evento emitidos por --events
+const (
+	eventStatus       = "status"
+	eventLatencySpike = "latency_spike"
+	eventRemoved      = "removed"
+)
+
+// watchSample é o estado de um nó em uma rodada do watch
+type watchSample struct {
+	Node    string
+	Address string
+	Status  string
+	Latency time.Duration
+	Detail  string
+}
+
+// watchEvent é uma mudança de estado, escrita como uma linha JSON por --events
+type watchEvent struct {
+	Time       string `json:"time"`
+	Node       string `json:"node"`
+	Type       string `json:"type"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+	IPAddress  string `json:"ip_address,omitempty"`
+	LatencyMS  int64  `json:"latency_ms"`
+	BaselineMS int64  `json:"baseline_ms,omitempty"`
+	Detail     string `json:"detail,omitempty"`
+}
+
+// nodeWatch acompanha um nó entre as rodadas
+type nodeWatch struct {
+	sample watchSample
+	// since é quando o status atual começou
+	since time.Time
+	// baseline é a média móvel da latência enquanto online
+	baseline time.Duration
+	// highlight é o evento da última rodada, destacado na tabela
+	highlight *watchEvent
+}
+
+// watcher testa os nós a cada intervalo e redesenha a tabela, ou emite as
+// mudanças de estado em JSON
+type watcher struct {
+	title    string
+	interval time.Duration
+	events   bool
+	out      io.Writer
+	// load devolve os nós de cada rodada, para incluir os recém-descobertos
+	load func() ([]NodeInfo, error)
+	// probe testa um nó; é chamado em paralelo
+	probe func(ctx context.Context, node NodeInfo) watchSample
+	// after, se definido, recebe as amostras de cada rodada
+	after func(samples []watchSample) error
+
+	nodes map[string]*nodeWatch
+	order []string
+}
+
+// run executa rodadas até Ctrl+C
+func (w *watcher) run() error {
+	if w.interval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	w.nodes = map[string]*nodeWatch{}
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		nodes, err := w.load()
+		if err != nil {
+			return fmt.Errorf("failed to load nodes: %w", err)
+		}
+		samples := pollNodes(ctx, nodes, w.probe)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		now := time.Now()
+		events := w.update(samples, now)
+		var warning string
+		if w.after != nil {
+			if err := w.after(samples); err != nil {
+				warning = err.Error()
+			}
+		}
+
+		if w.events {
+			if err := writeEvents(w.out, events); err != nil {
+				return err
+			}
+			if warning != "" {
+				fmt.Fprintf(os.Stderr, "⚠️  %s\n", warning)
+			}
+		} else {
+			w.render(now, warning)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// pollNodes testa todos os nós em paralelo e devolve as amostras na ordem
+// dos nós
+func pollNodes(ctx context.Context, nodes []NodeInfo, probe func(context.Context, NodeInfo) watchSample) []watchSample {
+	samples := make([]watchSample, len(nodes))
+	parallel(len(nodes), func(i int) {
+		samples[i] = probe(ctx, nodes[i])
+	})
+	return samples
+}
+
+// parallel chama fn(0) a fn(n-1) com até watchWorkers chamadas ao mesmo tempo
+func parallel(n int, fn func(i int)) {
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(watchWorkers, n); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// update compara as amostras com a rodada anterior e devolve as mudanças.
+// Na primeira vez em que um nó aparece é emitido seu status inicial.
+func (w *watcher) update(samples []watchSample, now time.Time) []watchEvent {
+	var events []watchEvent
+	seen := make(map[string]bool, len(samples))
+	w.order = w.order[:0]
+
+	for _, s := range samples {
+		seen[s.Node] = true
+		w.order = append(w.order, s.Node)
+		event := watchEvent{
+			Time:      now.Format(time.RFC3339),
+			Node:      s.Node,
+			IPAddress: s.Address,
+			LatencyMS: s.Latency.Milliseconds(),
+			Detail:    s.Detail,
+		}
+
+		state, ok := w.nodes[s.Node]
+		if !ok {
+			state = &nodeWatch{since: now}
+			w.nodes[s.Node] = state
+			event.Type, event.To = eventStatus, s.Status
+			events = append(events, event)
+		} else {
+			state.highlight = nil
+			switch {
+			case state.sample.Status != s.Status:
+				event.Type, event.From, event.To = eventStatus, state.sample.Status, s.Status
+				state.since = now
+				state.highlight = &event
+				events = append(events, event)
+			case s.Status == "online" && latencySpike(s.Latency, state.baseline):
+				event.Type = eventLatencySpike
+				event.BaselineMS = state.baseline.Milliseconds()
+				state.highlight = &event
+				events = append(events, event)
+			}
+		}
+
+		if s.Status == "online" {
+			if state.baseline == 0 {
+				state.baseline = s.Latency
+			} else {
+				state.baseline = (7*state.baseline + 3*s.Latency) / 10
+			}
+		}
+		state.sample = s
+	}
+
+	for name, state := range w.nodes {
+		if !seen[name] {
+			events = append(events, watchEvent{
+				Time: now.Format(time.RFC3339),
+				Node: name,
+				Type: eventRemoved,
+				From: state.sample.Status,
+			})
+			delete(w.nodes, name)
+		}
+	}
+	return events
+}
+
+// latencySpike diz se latency é um pico em relação à média baseline
+func latencySpike(latency, baseline time.Duration) bool {
+	return baseline > 0 && latency > latencySpikeFactor*baseline && latency-baseline >= latencySpikeMin
+}
+
+// writeEvents escreve um evento JSON por linha
+func writeEvents(out io.Writer, events []watchEvent) error {
+	encoder := json.NewEncoder(out)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Códigos ANSI usados quando a saída é um terminal
+const (
+	ansiClear  = "\033[H\033[2J"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiReset  = "\033[0m"
+)
+
+// render redesenha a tabela. Fora de um terminal, cada rodada é impressa
+// abaixo da anterior, sem cores.
+func (w *watcher) render(now time.Time, warning string) {
+	tty := isTerminal(w.out)
+	var b strings.Builder
+	if tty {
+		b.WriteString(ansiClear)
+	}
+	fmt.Fprintf(&b, "%s — every %s — %s (press Ctrl+C to stop)\n\n", w.title, w.interval, now.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(&b, "%-20s %-15s %-10s %-10s %-10s %s\n", "NODE", "IP ADDRESS", "STATUS", "LATENCY", "SINCE", "DETAIL")
+	b.WriteString(strings.Repeat("-", 80) + "\n")
+
+	for _, name := range w.order {
+		state := w.nodes[name]
+		s := state.sample
+		latency := "-"
+		if s.Status == "online" {
+			latency = fmt.Sprintf("%dms", s.Latency.Milliseconds())
+		}
+		detail := s.Detail
+		color := ""
+		if event := state.highlight; event != nil {
+			switch event.Type {
+			case eventStatus:
+				detail = joinDetail(fmt.Sprintf("was %s", event.From), detail)
+				color = ansiRed
+				if event.To == "online" {
+					color = ansiGreen
+				}
+			case eventLatencySpike:
+				detail = joinDetail(fmt.Sprintf("latency spike, usually %dms", event.BaselineMS), detail)
+				color = ansiYellow
+			}
+		}
+
+		line := fmt.Sprintf("%-20s %-15s %-10s %-10s %-10s %s", s.Node, s.Address, s.Status, latency,
+			now.Sub(state.since).Round(time.Second), detail)
+		switch {
+		case tty && color != "":
+			line = color + line + ansiReset
+		case !tty && state.highlight != nil:
+			line += " *"
+		}
+		b.WriteString(line + "\n")
+	}
+
+	if warning != "" {
+		fmt.Fprintf(&b, "\n⚠️  %s\n", warning)
+	}
+	if !tty {
+		b.WriteString("\n")
+	}
+	io.WriteString(w.out, b.String())
+}
+
+func joinDetail(note, detail string) string {
+	if detail == "" {
+		return note
+	}
+	return note + "; " + detail
+}
+
+// isTerminal diz se out é um terminal, onde a tabela pode ser redesenhada
+func isTerminal(out io.Writer) bool {
+	f, ok := out.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
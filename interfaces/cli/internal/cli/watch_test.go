@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sample(node, status string, latencyMS int) watchSample {
+	return watchSample{Node: node, Address: "10.0.0.1", Status: status, Latency: time.Duration(latencyMS) * time.Millisecond}
+}
+
+// eventSummary resume os eventos como "nó:tipo:de>para"
+func eventSummary(events []watchEvent) string {
+	var parts []string
+	for _, e := range events {
+		parts = append(parts, fmt.Sprintf("%s:%s:%s>%s", e.Node, e.Type, e.From, e.To))
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestWatcherUpdate(t *testing.T) {
+	tests := []struct {
+		name   string
+		rounds [][]watchSample
+		// want são os eventos da última rodada
+		want string
+	}{
+		{"primeira aparição", [][]watchSample{
+			{sample("a", "online", 10), sample("b", "offline", 0)},
+		}, "a:status:>online b:status:>offline"},
+		{"sem mudança", [][]watchSample{
+			{sample("a", "online", 10)},
+			{sample("a", "online", 12)},
+		}, ""},
+		{"online para offline", [][]watchSample{
+			{sample("a", "online", 10)},
+			{sample("a", "offline", 0)},
+		}, "a:status:online>offline"},
+		{"recuperação", [][]watchSample{
+			{sample("a", "online", 10)},
+			{sample("a", "offline", 0)},
+			{sample("a", "online", 11)},
+		}, "a:status:offline>online"},
+		{"pico de latência", [][]watchSample{
+			{sample("a", "online", 10)},
+			{sample("a", "online", 10)},
+			{sample("a", "online", 200)},
+		}, "a:latency_spike:>"},
+		{"latência alta mas perto da média", [][]watchSample{
+			{sample("a", "online", 10)},
+			{sample("a", "online", 40)},
+		}, ""},
+		{"nó removido", [][]watchSample{
+			{sample("a", "online", 10), sample("b", "degraded", 10)},
+			{sample("a", "online", 10)},
+		}, "b:removed:degraded>"},
+		{"nó novo depois da primeira rodada", [][]watchSample{
+			{sample("a", "online", 10)},
+			{sample("a", "online", 10), sample("c", "online", 5)},
+		}, "c:status:>online"},
+	}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &watcher{nodes: map[string]*nodeWatch{}}
+			var events []watchEvent
+			for i, round := range tt.rounds {
+				events = w.update(round, start.Add(time.Duration(i)*time.Minute))
+			}
+			if got := eventSummary(events); got != tt.want {
+				t.Errorf("eventos = %q, esperado %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWatcherUpdateState(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	w := &watcher{nodes: map[string]*nodeWatch{}}
+	w.update([]watchSample{sample("a", "online", 10)}, start)
+	w.update([]watchSample{sample("a", "online", 20)}, start.Add(time.Minute))
+
+	state := w.nodes["a"]
+	if state.since != start || state.highlight != nil {
+		t.Errorf("since = %v, highlight = %+v", state.since, state.highlight)
+	}
+	// Média móvel: 70% da anterior e 30% da nova
+	if state.baseline != 13*time.Millisecond {
+		t.Errorf("baseline = %v", state.baseline)
+	}
+
+	// Offline não entra na média e marca o início do novo status
+	events := w.update([]watchSample{sample("a", "offline", 0)}, start.Add(2*time.Minute))
+	if state.baseline != 13*time.Millisecond || state.since != start.Add(2*time.Minute) || state.highlight == nil {
+		t.Errorf("depois de offline: %+v", state)
+	}
+	if len(events) != 1 || events[0].Time != "2026-01-01T12:02:00Z" {
+		t.Errorf("eventos = %+v", events)
+	}
+}
+
+func TestLatencySpike(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		latency, baseline time.Duration
+		want              bool
+	}{
+		{200 * ms, 10 * ms, true},
+		{30 * ms, 10 * ms, false},   // não passa de 3x
+		{40 * ms, 10 * ms, false},   // 4x, mas só 30ms acima
+		{61 * ms, 11 * ms, true},    // mais de 3x e 50ms acima
+		{500 * ms, 0, false},        // sem média ainda
+		{300 * ms, 100 * ms, false}, // exatamente 3x
+	}
+	for _, tt := range tests {
+		if got := latencySpike(tt.latency, tt.baseline); got != tt.want {
+			t.Errorf("latencySpike(%v, %v) = %v, esperado %v", tt.latency, tt.baseline, got, tt.want)
+		}
+	}
+}
+
+func TestWriteEvents(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeEvents(&buf, []watchEvent{
+		{Time: "2026-01-01T12:00:00Z", Node: "a", Type: eventStatus, To: "online", IPAddress: "10.0.0.1", LatencyMS: 10},
+		{Time: "2026-01-01T12:01:00Z", Node: "a", Type: eventLatencySpike, IPAddress: "10.0.0.1", LatencyMS: 200, BaselineMS: 10},
+		{Time: "2026-01-01T12:02:00Z", Node: "b", Type: eventRemoved, From: "offline"},
+		{Time: "2026-01-01T12:03:00Z", Node: "a", Type: eventStatus, From: "online", To: "offline", IPAddress: "10.0.0.1", Detail: "connection refused"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"time":"2026-01-01T12:00:00Z","node":"a","type":"status","to":"online","ip_address":"10.0.0.1","latency_ms":10}
+{"time":"2026-01-01T12:01:00Z","node":"a","type":"latency_spike","ip_address":"10.0.0.1","latency_ms":200,"baseline_ms":10}
+{"time":"2026-01-01T12:02:00Z","node":"b","type":"removed","from":"offline","latency_ms":0}
+{"time":"2026-01-01T12:03:00Z","node":"a","type":"status","from":"online","to":"offline","ip_address":"10.0.0.1","latency_ms":0,"detail":"connection refused"}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("saída:\n%s\nesperado:\n%s", got, want)
+	}
+}
+
+func TestWatcherRender(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	w := &watcher{title: "Node status", interval: 5 * time.Second, out: &buf, nodes: map[string]*nodeWatch{}}
+	w.update([]watchSample{sample("a", "online", 10), sample("b", "online", 10)}, start)
+	w.update([]watchSample{sample("a", "online", 10), sample("b", "offline", 0)}, start.Add(time.Minute))
+	w.render(start.Add(time.Minute), "")
+
+	// Fora de um terminal, sem cores e com a linha alterada marcada
+	out := buf.String()
+	if strings.Contains(out, "\033[") {
+		t.Errorf("saída com códigos ANSI: %q", out)
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if strings.HasPrefix(line, "b ") && !strings.HasSuffix(line, "was online *") {
+			t.Errorf("linha de b = %q", line)
+		}
+		if strings.HasPrefix(line, "a ") && strings.HasSuffix(line, "*") {
+			t.Errorf("linha de a destacada: %q", line)
+		}
+	}
+}